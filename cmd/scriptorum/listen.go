@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+// sdListenFDsStart is the first file descriptor systemd passes to an
+// activated service (SD_LISTEN_FDS_START).
+const sdListenFDsStart = 3
+
+const defaultUnixSocketMode = 0o660
+
+// openListeners opens every listener the config asks for: systemd-activated
+// sockets, a unix socket, and the TCP address. The TCP address is skipped
+// when it is empty and another listener exists; with nothing configured it
+// falls back to ":http" like http.ListenAndServe.
+func openListeners(cfg *config.Config) ([]net.Listener, error) {
+	var out []net.Listener
+	fail := func(err error) ([]net.Listener, error) {
+		for _, l := range out {
+			_ = l.Close()
+		}
+		return nil, err
+	}
+	if cfg.HTTP.SocketActivation {
+		ls, err := systemdListeners()
+		if err != nil {
+			return fail(err)
+		}
+		out = append(out, ls...)
+	}
+	if path := strings.TrimSpace(cfg.HTTP.UnixSocket); path != "" {
+		l, err := listenUnix(path, cfg.HTTP.UnixSocketMode)
+		if err != nil {
+			return fail(err)
+		}
+		out = append(out, l)
+	}
+	addr := strings.TrimSpace(cfg.HTTP.Listen)
+	if addr == "" && len(out) > 0 {
+		return out, nil
+	}
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fail(err)
+	}
+	return append(out, l), nil
+}
+
+// systemdListeners returns the sockets passed by systemd socket activation,
+// or none when the process was not socket-activated. The LISTEN_* variables
+// are cleared so child processes do not inherit them.
+func systemdListeners() ([]net.Listener, error) {
+	pid, fds := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if fds == "" {
+		return nil, nil
+	}
+	if pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("socket activation: invalid LISTEN_FDS %q", fds)
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	out := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(sdListenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(sdListenFDsStart+i), name)
+		l, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, prev := range out {
+				_ = prev.Close()
+			}
+			return nil, fmt.Errorf("socket activation: fd %d (%s): %w", sdListenFDsStart+i, name, err)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// listenUnix listens on a unix domain socket at path with the given octal
+// permissions. A stale socket left by an earlier run is removed first; any
+// other file at path is an error.
+func listenUnix(path, mode string) (net.Listener, error) {
+	perm := fs.FileMode(defaultUnixSocketMode)
+	if mode = strings.TrimSpace(mode); mode != "" {
+		m, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || m > 0o777 {
+			return nil, fmt.Errorf("unix socket: invalid mode %q", mode)
+		}
+		perm = fs.FileMode(m)
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket: %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("unix socket: remove stale %s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unix socket: %w", err)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("unix socket: chmod %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// listenerName describes l for the startup log.
+func listenerName(l net.Listener) string {
+	a := l.Addr()
+	if a.Network() == "unix" {
+		return "unix:" + a.String()
+	}
+	return a.String()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+// shortTempDir keeps socket paths under the ~100 byte sun_path limit, which
+// t.TempDir can exceed.
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestListenUnixServesWithMode(t *testing.T) {
+	path := filepath.Join(shortTempDir(t), "s.sock")
+	cfg := &config.Config{}
+	cfg.HTTP.UnixSocket = path
+	cfg.HTTP.UnixSocketMode = "0600"
+
+	ls, err := openListeners(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ls) != 1 || listenerName(ls[0]) != "unix:"+path {
+		t.Fatalf("listeners = %v", ls)
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, %v", fi, err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = io.WriteString(w, "ok") })}
+	go func() { _ = srv.Serve(ls[0]) }()
+	defer srv.Shutdown(context.Background())
+	client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", path)
+	}}}
+	resp, err := client.Get("http://scriptorum/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestListenUnixReplacesStaleSocketOnly(t *testing.T) {
+	dir := shortTempDir(t)
+	path := filepath.Join(dir, "s.sock")
+	// A socket file left behind by a crashed run.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	l, err := listenUnix(path, "")
+	if err != nil {
+		t.Fatalf("stale socket not replaced: %v", err)
+	}
+	fi, _ := os.Stat(path)
+	if fi.Mode().Perm() != defaultUnixSocketMode {
+		t.Fatalf("default mode = %v", fi.Mode().Perm())
+	}
+	_ = l.Close()
+
+	regular := filepath.Join(dir, "file")
+	_ = os.WriteFile(regular, []byte("x"), 0o600)
+	if _, err := listenUnix(regular, ""); err == nil {
+		t.Fatal("expected an error for a regular file")
+	}
+	if _, err := listenUnix(filepath.Join(dir, "m.sock"), "999"); err == nil {
+		t.Fatal("expected an error for an invalid mode")
+	}
+}
+
+func TestOpenListenersTCPAndFallback(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.HTTP.Listen = "127.0.0.1:0"
+	cfg.HTTP.UnixSocket = filepath.Join(shortTempDir(t), "s.sock")
+	ls, err := openListeners(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range ls {
+		_ = l.Close()
+	}
+	if len(ls) != 2 || ls[1].Addr().Network() != "tcp" {
+		t.Fatalf("listeners = %v", ls)
+	}
+
+	cfg.HTTP.Listen = "256.0.0.1:bad"
+	if _, err := openListeners(cfg); err == nil {
+		t.Fatal("expected a TCP listen error")
+	}
+	if _, err := os.Stat(cfg.HTTP.UnixSocket); !os.IsNotExist(err) {
+		t.Fatalf("unix socket should be closed on failure: %v", err)
+	}
+}
+
+func TestSystemdListenersEnv(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "")
+	if ls, err := systemdListeners(); err != nil || ls != nil {
+		t.Fatalf("not activated: %v %v", ls, err)
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	if ls, err := systemdListeners(); err != nil || ls != nil {
+		t.Fatalf("fds for another process should be ignored: %v %v", ls, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Fatal("LISTEN_FDS should be cleared")
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "x")
+	if _, err := systemdListeners(); err == nil {
+		t.Fatal("expected an error for a bad LISTEN_FDS")
+	}
+}
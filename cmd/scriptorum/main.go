@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,13 +17,17 @@ import (
 var (
 	ensureFirstRunFn = bootstrap.EnsureFirstRun
 	newServerFn      = httpapi.NewServer
-	listenAndServeFn = func(server *http.Server) error { return server.ListenAndServe() }
+	openListenersFn  = openListeners
+	serveFn          = func(server *http.Server, l net.Listener) error { return server.Serve(l) }
 	shutdownServerFn = func(server *http.Server, ctx context.Context) error { return server.Shutdown(ctx) }
 	notifyContextFn  = signal.NotifyContext
 	logFatalfFn      = log.Fatalf
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "test-notifications" {
+		os.Exit(testNotifications(os.Stdout))
+	}
 	run()
 }
 
@@ -47,12 +52,18 @@ func run() {
 	srv.StartBackgroundTasks(appCtx)
 	server := &http.Server{Addr: cfg.HTTP.Listen, Handler: srv.Router()}
 
-	go func() {
-		log.Printf("listening on %s", cfg.HTTP.Listen)
-		if err := listenAndServeFn(server); err != nil && err != http.ErrServerClosed {
-			logFatalfFn("http: %v", err)
-		}
-	}()
+	listeners, err := openListenersFn(cfg)
+	if err != nil {
+		logFatalfFn("listen: %v", err)
+	}
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			log.Printf("listening on %s", listenerName(l))
+			if err := serveFn(server, l); err != nil && err != http.ErrServerClosed {
+				logFatalfFn("http: %v", err)
+			}
+		}(l)
+	}
 
 	stopCtx, stop := notifyContextFn(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -15,7 +16,8 @@ import (
 func resetMainDeps() {
 	ensureFirstRunFn = bootstrapEnsureFirstRun
 	newServerFn = httpapiNewServer
-	listenAndServeFn = func(server *http.Server) error { return server.ListenAndServe() }
+	openListenersFn = openListeners
+	serveFn = func(server *http.Server, l net.Listener) error { return server.Serve(l) }
 	shutdownServerFn = func(server *http.Server, ctx context.Context) error { return server.Shutdown(ctx) }
 	notifyContextFn = signalNotifyContext
 	logFatalfFn = logFatalf
@@ -78,7 +80,7 @@ func TestRunStartsAndShutsDown(t *testing.T) {
 	ensureFirstRunFn = func(ctx context.Context, cfgPath, dbPath string) (*config.Config, *db.DB, error) {
 		return cfg, database, nil
 	}
-	listenAndServeFn = func(server *http.Server) error { return http.ErrServerClosed }
+	serveFn = func(server *http.Server, l net.Listener) error { return http.ErrServerClosed }
 	shutdownServerFn = func(server *http.Server, ctx context.Context) error { return nil }
 	notifyContextFn = func(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
 		ctx, cancel := context.WithCancel(parent)
@@ -107,7 +109,7 @@ func TestMainCallsRun(t *testing.T) {
 	ensureFirstRunFn = func(ctx context.Context, cfgPath, dbPath string) (*config.Config, *db.DB, error) {
 		return cfg, database, nil
 	}
-	listenAndServeFn = func(server *http.Server) error { return http.ErrServerClosed }
+	serveFn = func(server *http.Server, l net.Listener) error { return http.ErrServerClosed }
 	shutdownServerFn = func(server *http.Server, ctx context.Context) error { return nil }
 	notifyContextFn = func(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
 		ctx, cancel := context.WithCancel(parent)
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/httpapi"
+)
+
+// testNotifications runs `scriptorum test-notifications`: it sends a test
+// event through every enabled notification provider using the saved
+// configuration and prints one line per provider. The exit code is 0 when
+// all passed or none are enabled, 1 when any failed, and 2 when the
+// configuration or database could not be opened.
+func testNotifications(out io.Writer) int {
+	cfgPath := getenv("SCRIPTORUM_CONFIG_PATH", "data/scriptorum.yaml")
+	dbPath := getenv("SCRIPTORUM_DB_PATH", "data/scriptorum.db")
+
+	cfg, database, err := ensureFirstRunFn(context.Background(), cfgPath, dbPath)
+	if err != nil {
+		fmt.Fprintf(out, "bootstrap: %v\n", err)
+		return 2
+	}
+	defer database.Close()
+
+	results := newServerFn(cfg, database, cfgPath).TestNotifications()
+	if len(results) == 0 {
+		fmt.Fprintln(out, "no notification providers are enabled")
+		return 0
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		status := "PASS"
+		if !r.OK {
+			status = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%dms\t%s\n", status, r.Provider, r.Target, r.DurationMS, r.Error)
+	}
+	_ = tw.Flush()
+	if !httpapi.NotificationTestsPassed(results) {
+		return 1
+	}
+	return 0
+}
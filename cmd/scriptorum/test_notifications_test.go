@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestTestNotificationsExitCodes(t *testing.T) {
+	t.Cleanup(resetMainDeps)
+
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer hook.Close()
+
+	cfg := &config.Config{}
+	ensureFirstRunFn = func(ctx context.Context, cfgPath, dbPath string) (*config.Config, *db.DB, error) {
+		database, err := db.Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+		if err != nil {
+			return nil, nil, err
+		}
+		return cfg, database, database.Migrate(ctx)
+	}
+
+	var out bytes.Buffer
+	if code := testNotifications(&out); code != 0 || !strings.Contains(out.String(), "no notification providers") {
+		t.Fatalf("nothing enabled: %d %q", code, out.String())
+	}
+
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.URL = hook.URL + "/ok"
+	out.Reset()
+	if code := testNotifications(&out); code != 0 || !strings.HasPrefix(out.String(), "PASS  webhook") {
+		t.Fatalf("passing webhook: %d %q", code, out.String())
+	}
+
+	cfg.Notifications.Webhook.Endpoints = []config.WebhookEndpoint{{Name: "broken", URL: hook.URL + "/broken"}}
+	out.Reset()
+	if code := testNotifications(&out); code != 1 || !strings.Contains(out.String(), "FAIL  webhook  broken") {
+		t.Fatalf("failing endpoint: %d %q", code, out.String())
+	}
+
+	ensureFirstRunFn = func(ctx context.Context, cfgPath, dbPath string) (*config.Config, *db.DB, error) {
+		return nil, nil, errors.New("boom")
+	}
+	if code := testNotifications(&out); code != 2 {
+		t.Fatalf("bootstrap failure exit = %d", code)
+	}
+}
@@ -0,0 +1,93 @@
+// Package attachments stores files that requesters attach to a request, such
+// as a screenshot of the edition they want. Blobs live on local disk or in an
+// S3-compatible bucket; metadata is kept in the database by the caller.
+package attachments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+// DefaultMaxSizeKB is the upload limit used when max_size_kb is unset.
+const DefaultMaxSizeKB = 2048
+
+// DefaultAllowedTypes are the content types accepted when allowed_types is
+// unset.
+var DefaultAllowedTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf"}
+
+var (
+	// ErrNotFound is returned by Get when no blob exists for the key.
+	ErrNotFound = errors.New("attachment not found")
+	// ErrDisabled is returned by New when file storage is turned off.
+	ErrDisabled = errors.New("attachment storage is disabled")
+)
+
+// Store persists attachment blobs under caller-chosen keys such as "12/ab34".
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the store selected by cfg. defaultDir is used for disk storage
+// when cfg.Path is empty, and client for S3 requests.
+func New(cfg config.AttachmentsConfig, defaultDir string, client *http.Client) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Storage)) {
+	case "", "disk":
+		dir := strings.TrimSpace(cfg.Path)
+		if dir == "" {
+			dir = defaultDir
+		}
+		return NewDiskStore(dir), nil
+	case "s3":
+		return NewS3Store(cfg.S3, client)
+	case "none":
+		return nil, ErrDisabled
+	default:
+		return nil, fmt.Errorf("unknown attachments storage %q", cfg.Storage)
+	}
+}
+
+// MaxSize returns the configured upload limit in bytes.
+func MaxSize(cfg config.AttachmentsConfig) int64 {
+	kb := cfg.MaxSizeKB
+	if kb <= 0 {
+		kb = DefaultMaxSizeKB
+	}
+	return int64(kb) * 1024
+}
+
+// Allowed reports whether contentType is accepted by cfg. Entries may end in
+// "/*" to allow a whole family, e.g. "image/*".
+func Allowed(cfg config.AttachmentsConfig, contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = strings.TrimSpace(ct[:i])
+	}
+	allowed := cfg.AllowedTypes
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedTypes
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == ct || (strings.HasSuffix(a, "/*") && strings.HasPrefix(ct, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// validKey rejects keys that could escape the store's root.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return fmt.Errorf("invalid attachment key %q", key)
+	}
+	return nil
+}
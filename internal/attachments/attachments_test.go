@@ -0,0 +1,155 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func TestDiskStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	st := NewDiskStore(t.TempDir())
+	if err := st.Put(ctx, "7/abc", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	rc, err := st.Get(ctx, "7/abc")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(b) != "hello" {
+		t.Fatalf("got %q", b)
+	}
+	if err := st.Delete(ctx, "7/abc"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := st.Delete(ctx, "7/abc"); err != nil {
+		t.Fatalf("second delete: %v", err)
+	}
+	if _, err := st.Get(ctx, "7/abc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get after delete: %v", err)
+	}
+	for _, bad := range []string{"", "../x", "/etc/passwd", "a/../../x", "a\\b"} {
+		if err := st.Put(ctx, bad, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("key %q accepted", bad)
+		}
+	}
+}
+
+func TestAllowedAndMaxSize(t *testing.T) {
+	var cfg config.AttachmentsConfig
+	if !Allowed(cfg, "image/png") || !Allowed(cfg, "application/pdf") || Allowed(cfg, "text/html; charset=utf-8") {
+		t.Fatal("unexpected default allow list")
+	}
+	cfg.AllowedTypes = []string{"image/*"}
+	if !Allowed(cfg, "image/avif") || Allowed(cfg, "application/pdf") {
+		t.Fatal("wildcard not honoured")
+	}
+	if MaxSize(cfg) != DefaultMaxSizeKB*1024 {
+		t.Fatalf("default max size = %d", MaxSize(cfg))
+	}
+	cfg.MaxSizeKB = 10
+	if MaxSize(cfg) != 10240 {
+		t.Fatalf("max size = %d", MaxSize(cfg))
+	}
+}
+
+func TestNewSelectsStorage(t *testing.T) {
+	dir := t.TempDir()
+	st, err := New(config.AttachmentsConfig{}, dir, nil)
+	if ds, ok := st.(*DiskStore); err != nil || !ok || ds.Dir != dir {
+		t.Fatalf("default storage = %#v, %v", st, err)
+	}
+	if _, err := New(config.AttachmentsConfig{Storage: "none"}, dir, nil); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("none: %v", err)
+	}
+	if _, err := New(config.AttachmentsConfig{Storage: "s3"}, dir, nil); err == nil {
+		t.Fatal("s3 without bucket accepted")
+	}
+	if _, err := New(config.AttachmentsConfig{Storage: "ftp"}, dir, nil); err == nil {
+		t.Fatal("unknown storage accepted")
+	}
+}
+
+func TestS3StoreSignsAndRoundTrips(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240102/eu-west-1/s3/aws4_request, SignedHeaders=") ||
+			!strings.Contains(auth, "host;x-amz-content-sha256;x-amz-date") || r.Header.Get("X-Amz-Date") != "20240102T030405Z" {
+			http.Error(w, "bad signature: "+auth, http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(b) {
+				http.Error(w, "payload hash mismatch", http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = string(b)
+		case http.MethodGet:
+			v, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, v)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	st, err := NewS3Store(config.S3Config{Endpoint: srv.URL, Region: "eu-west-1", Bucket: "books", AccessKey: "AKID", SecretKey: "secret", Prefix: "/scriptorum/", PathStyle: true}, srv.Client())
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	st.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	ctx := context.Background()
+
+	if err := st.Put(ctx, "3/cover", strings.NewReader("png-bytes"), 9, "image/png"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, ok := objects["/books/scriptorum/3/cover"]; !ok {
+		t.Fatalf("object stored at unexpected path: %v", objects)
+	}
+	rc, err := st.Get(ctx, "3/cover")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(b) != "png-bytes" {
+		t.Fatalf("got %q", b)
+	}
+	if err := st.Delete(ctx, "3/cover"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := st.Get(ctx, "3/cover"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get after delete: %v", err)
+	}
+}
+
+func TestS3StoreVirtualHostURL(t *testing.T) {
+	st, err := NewS3Store(config.S3Config{Endpoint: "s3.example.com", Bucket: "books"}, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := st.objectURL("1/a b").String(); got != "https://books.s3.example.com/1/a%20b" {
+		t.Fatalf("url = %s", got)
+	}
+}
@@ -0,0 +1,84 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DiskStore keeps blobs as files below Dir.
+type DiskStore struct {
+	Dir string
+}
+
+// NewDiskStore returns a store rooted at dir. The directory is created on the
+// first Put.
+func NewDiskStore(dir string) *DiskStore { return &DiskStore{Dir: dir} }
+
+func (d *DiskStore) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(d.Dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes r to key, replacing any existing blob. The file is written to a
+// temporary name first so readers never see a partial upload.
+func (d *DiskStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (d *DiskStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Delete removes key. Deleting a missing blob is not an error.
+func (d *DiskStore) Delete(ctx context.Context, key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+// S3Store keeps blobs in an S3-compatible bucket, signing requests with AWS
+// Signature Version 4.
+type S3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	prefix    string
+	pathStyle bool
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3Store validates cfg and returns a store for its bucket. Region
+// defaults to us-east-1, which most S3-compatible services accept.
+func NewS3Store(cfg config.S3Config, client *http.Client) (*S3Store, error) {
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	if endpoint == "" || strings.TrimSpace(cfg.Bucket) == "" {
+		return nil, fmt.Errorf("s3 attachments require an endpoint and bucket")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	region := strings.TrimSpace(cfg.Region)
+	if region == "" {
+		region = "us-east-1"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	prefix := strings.Trim(strings.TrimSpace(cfg.Prefix), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &S3Store{
+		endpoint:  u,
+		region:    region,
+		bucket:    strings.TrimSpace(cfg.Bucket),
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		prefix:    prefix,
+		pathStyle: cfg.PathStyle,
+		client:    client,
+		now:       time.Now,
+	}, nil
+}
+
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	objectPath := "/" + s.prefix + key
+	if s.pathStyle {
+		objectPath = "/" + s.bucket + objectPath
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(s.endpoint.Path, "/") + objectPath
+	u.RawPath = awsEscapePath(u.Path)
+	return &u
+}
+
+// Put uploads r to key. The body is buffered so its SHA-256 can be signed;
+// attachments are capped well below sizes where that matters.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes key. S3 reports success for missing objects, so this does
+// too.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do signs and sends req, turning non-2xx responses into errors.
+func (s *S3Store) do(req *http.Request, body []byte) (*http.Response, error) {
+	s.sign(req, body)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// sign adds SigV4 headers for the s3 service to req.
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	headers := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		signed = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+		headers = "content-type:" + strings.TrimSpace(ct) + "\n" + headers
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscapePath percent-encodes everything but unreserved characters and
+// slashes, as SigV4 expects for S3 object paths.
+func awsEscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,303 @@
+// Package barcode reads EAN-13 barcodes, the symbology printed on book
+// covers, from photos. It scans rows of the image in all four orientations,
+// so a phone picture only needs the barcode roughly level or upright.
+package barcode
+
+import (
+	"errors"
+	"image"
+	"sort"
+)
+
+// ErrNotFound is returned when no valid EAN-13 barcode is found.
+var ErrNotFound = errors.New("no EAN-13 barcode found")
+
+// maxScanDim caps the longer image side before scanning; barcodes stay
+// readable well below phone camera resolutions and scanning stays fast.
+const maxScanDim = 1600
+
+// Digit widths (space, bar, space, bar) for the L code set. R codes use the
+// same widths starting with a bar; G codes are the L widths reversed.
+var lPatterns = [10][4]float64{
+	{3, 2, 1, 1}, {2, 2, 2, 1}, {2, 1, 2, 2}, {1, 4, 1, 1}, {1, 1, 3, 2},
+	{1, 2, 3, 1}, {1, 1, 1, 4}, {1, 3, 1, 2}, {1, 2, 1, 3}, {3, 1, 1, 2},
+}
+
+// firstDigitParity maps the L/G parity of the six left digits (bit set = G,
+// most significant bit first) to the implied leading digit.
+var firstDigitParity = map[int]byte{
+	0x00: 0, 0x0B: 1, 0x0D: 2, 0x0E: 3, 0x13: 4,
+	0x19: 5, 0x1C: 6, 0x15: 7, 0x16: 8, 0x1A: 9,
+}
+
+// maxDigitError is the largest summed width error, in modules, accepted when
+// matching a digit.
+const maxDigitError = 1.6
+
+// DecodeEAN13 returns the 13-digit code of the most consistently read
+// EAN-13 barcode in img.
+func DecodeEAN13(img image.Image) (string, error) {
+	g := newGray(img)
+	votes := map[string]int{}
+	for turn := 0; turn < 2; turn++ {
+		rows := g.h
+		step := rows / 60
+		if step < 1 {
+			step = 1
+		}
+		line := make([]float64, g.w)
+		for y := step / 2; y < rows; y += step {
+			g.row(y, line)
+			for _, dark := range binarize(line) {
+				runs, colors := runLengths(dark)
+				for _, code := range scanRuns(runs, colors) {
+					votes[code]++
+				}
+				reverseRuns(runs, colors)
+				for _, code := range scanRuns(runs, colors) {
+					votes[code]++
+				}
+			}
+		}
+		if len(votes) > 0 {
+			break
+		}
+		g = g.transpose()
+	}
+	if len(votes) == 0 {
+		return "", ErrNotFound
+	}
+	codes := make([]string, 0, len(votes))
+	for c := range votes {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if votes[codes[i]] != votes[codes[j]] {
+			return votes[codes[i]] > votes[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	return codes[0], nil
+}
+
+// gray is a luminance image, downscaled to at most maxScanDim.
+type gray struct {
+	w, h int
+	pix  []float64
+}
+
+func newGray(img image.Image) *gray {
+	b := img.Bounds()
+	scale := 1
+	for (b.Dx()/scale) > maxScanDim || (b.Dy()/scale) > maxScanDim {
+		scale++
+	}
+	g := &gray{w: b.Dx() / scale, h: b.Dy() / scale}
+	g.pix = make([]float64, g.w*g.h)
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			r, gr, bl, _ := img.At(b.Min.X+x*scale, b.Min.Y+y*scale).RGBA()
+			g.pix[y*g.w+x] = 0.299*float64(r) + 0.587*float64(gr) + 0.114*float64(bl)
+		}
+	}
+	return g
+}
+
+func (g *gray) row(y int, out []float64) {
+	copy(out, g.pix[y*g.w:(y+1)*g.w])
+}
+
+func (g *gray) transpose() *gray {
+	t := &gray{w: g.h, h: g.w, pix: make([]float64, len(g.pix))}
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			t.pix[x*t.w+y] = g.pix[y*g.w+x]
+		}
+	}
+	return t
+}
+
+// binarize returns two dark/light readings of a scan line: one against the
+// line's mid-level, and one against a moving average that copes with uneven
+// lighting across the cover.
+func binarize(line []float64) [][]bool {
+	n := len(line)
+	if n == 0 {
+		return nil
+	}
+	lo, hi := line[0], line[0]
+	for _, v := range line {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	if hi-lo < 0.1*65535 {
+		return nil
+	}
+	mid := (lo + hi) / 2
+	global := make([]bool, n)
+	for i, v := range line {
+		global[i] = v < mid
+	}
+
+	window := n / 12
+	if window < 9 {
+		window = 9
+	}
+	prefix := make([]float64, n+1)
+	for i, v := range line {
+		prefix[i+1] = prefix[i] + v
+	}
+	local := make([]bool, n)
+	for i, v := range line {
+		a, b := max(0, i-window), min(n, i+window+1)
+		local[i] = v < (prefix[b]-prefix[a])/float64(b-a)
+	}
+	return [][]bool{global, local}
+}
+
+// runLengths converts a binarized line into run widths and their colours
+// (true = bar).
+func runLengths(dark []bool) ([]float64, []bool) {
+	var runs []float64
+	var colors []bool
+	for i, d := range dark {
+		if i == 0 || d != dark[i-1] {
+			runs = append(runs, 0)
+			colors = append(colors, d)
+		}
+		runs[len(runs)-1]++
+	}
+	return runs, colors
+}
+
+func reverseRuns(runs []float64, colors []bool) {
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+		colors[i], colors[j] = colors[j], colors[i]
+	}
+}
+
+// scanRuns tries every bar as the start of a symbol: guard (3 runs), six
+// left digits (24), centre guard (5), six right digits (24), end guard (3).
+func scanRuns(runs []float64, colors []bool) []string {
+	const symbolRuns = 59
+	var found []string
+	for i := 1; i+symbolRuns <= len(runs); i++ {
+		if !colors[i] {
+			continue
+		}
+		total := 0.0
+		for _, r := range runs[i : i+symbolRuns] {
+			total += r
+		}
+		module := total / 95
+		// A quiet zone of light space should precede the start guard.
+		if runs[i-1] < 3*module {
+			continue
+		}
+		if code, ok := decodeSymbol(runs[i:i+symbolRuns], module); ok {
+			found = append(found, code)
+			i += symbolRuns - 1
+		}
+	}
+	return found
+}
+
+func decodeSymbol(runs []float64, module float64) (string, bool) {
+	guards := [][2]int{{0, 3}, {27, 32}, {56, 59}}
+	for _, gr := range guards {
+		for _, r := range runs[gr[0]:gr[1]] {
+			if r < 0.4*module || r > 2.0*module {
+				return "", false
+			}
+		}
+	}
+	var digits [13]byte
+	parity := 0
+	for d := 0; d < 6; d++ {
+		digit, isG, ok := matchDigit(runs[3+d*4:7+d*4], true)
+		if !ok {
+			return "", false
+		}
+		digits[d+1] = digit
+		parity <<= 1
+		if isG {
+			parity |= 1
+		}
+	}
+	first, ok := firstDigitParity[parity]
+	if !ok {
+		return "", false
+	}
+	digits[0] = first
+	for d := 0; d < 6; d++ {
+		digit, _, ok := matchDigit(runs[32+d*4:36+d*4], false)
+		if !ok {
+			return "", false
+		}
+		digits[d+7] = digit
+	}
+	if !checksumOK(digits) {
+		return "", false
+	}
+	out := make([]byte, 13)
+	for i, d := range digits {
+		out[i] = '0' + d
+	}
+	return string(out), true
+}
+
+// matchDigit finds the closest digit for four run widths. Left-half digits
+// may be L or G coded; right-half digits are always R (same widths as L).
+func matchDigit(w []float64, left bool) (digit byte, isG bool, ok bool) {
+	sum := w[0] + w[1] + w[2] + w[3]
+	if sum == 0 {
+		return 0, false, false
+	}
+	var n [4]float64
+	for k := range n {
+		n[k] = w[k] * 7 / sum
+	}
+	best := maxDigitError
+	for dgt, p := range lPatterns {
+		if e := patternError(n, p); e < best {
+			best, digit, isG, ok = e, byte(dgt), false, true
+		}
+		if left {
+			rev := [4]float64{p[3], p[2], p[1], p[0]}
+			if e := patternError(n, rev); e < best {
+				best, digit, isG, ok = e, byte(dgt), true, true
+			}
+		}
+	}
+	return digit, isG, ok
+}
+
+func patternError(n [4]float64, p [4]float64) float64 {
+	e := 0.0
+	for k := range n {
+		d := n[k] - p[k]
+		if d < 0 {
+			d = -d
+		}
+		e += d
+	}
+	return e
+}
+
+func checksumOK(d [13]byte) bool {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		v := int(d[i])
+		if i%2 == 1 {
+			v *= 3
+		}
+		sum += v
+	}
+	return (10-sum%10)%10 == int(d[12])
+}
+
+// IsISBN reports whether an EAN-13 code is a Bookland ISBN-13.
+func IsISBN(code string) bool {
+	return len(code) == 13 && (code[:3] == "978" || code[:3] == "979")
+}
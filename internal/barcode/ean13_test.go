@@ -0,0 +1,149 @@
+package barcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// renderEAN13 draws code as a barcode with a quiet zone, moduleW pixels per
+// module, on a white canvas.
+func renderEAN13(t *testing.T, code string, moduleW int) *image.Gray {
+	t.Helper()
+	var bits []bool
+	add := func(pattern string) {
+		for _, c := range pattern {
+			bits = append(bits, c == '1')
+		}
+	}
+	widthsToBits := func(w [4]float64, startBar bool) string {
+		out := ""
+		bar := startBar
+		for _, n := range w {
+			for i := 0; i < int(n); i++ {
+				if bar {
+					out += "1"
+				} else {
+					out += "0"
+				}
+			}
+			bar = !bar
+		}
+		return out
+	}
+	var parity int
+	for p, first := range firstDigitParity {
+		if first == code[0]-'0' {
+			parity = p
+		}
+	}
+	add("101")
+	for i := 1; i <= 6; i++ {
+		w := lPatterns[code[i]-'0']
+		if parity&(1<<(6-i)) != 0 {
+			w = [4]float64{w[3], w[2], w[1], w[0]}
+		}
+		add(widthsToBits(w, false))
+	}
+	add("01010")
+	for i := 7; i <= 12; i++ {
+		add(widthsToBits(lPatterns[code[i]-'0'], true))
+	}
+	add("101")
+
+	quiet := 12 * moduleW
+	w := quiet*2 + len(bits)*moduleW
+	h := 80
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetGray(x, y, color.Gray{Y: 240})
+		}
+		for i, b := range bits {
+			if !b {
+				continue
+			}
+			for k := 0; k < moduleW; k++ {
+				img.SetGray(quiet+i*moduleW+k, y, color.Gray{Y: 20})
+			}
+		}
+	}
+	return img
+}
+
+func rotate90(src *image.Gray) *image.Gray {
+	b := src.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dst.SetGray(b.Dy()-1-y, x, src.GrayAt(x, y))
+		}
+	}
+	return dst
+}
+
+func TestDecodeEAN13(t *testing.T) {
+	codes := []string{"9780441013593", "9781250313195", "4006381333931"}
+	for _, code := range codes {
+		img := renderEAN13(t, code, 3)
+		got, err := DecodeEAN13(img)
+		if err != nil || got != code {
+			t.Fatalf("upright %s: got %q, %v", code, got, err)
+		}
+		upsideDown := rotate90(rotate90(img))
+		if got, err := DecodeEAN13(upsideDown); err != nil || got != code {
+			t.Fatalf("upside down %s: got %q, %v", code, got, err)
+		}
+		if got, err := DecodeEAN13(rotate90(img)); err != nil || got != code {
+			t.Fatalf("rotated %s: got %q, %v", code, got, err)
+		}
+	}
+}
+
+func TestDecodeEAN13ThroughEncoders(t *testing.T) {
+	const code = "9780441013593"
+	img := renderEAN13(t, code, 4)
+
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatal(err)
+	}
+	var jpgBuf bytes.Buffer
+	if err := jpeg.Encode(&jpgBuf, img, &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"png": pngBuf.Bytes(), "jpeg": jpgBuf.Bytes()} {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s decode: %v", name, err)
+		}
+		if got, err := DecodeEAN13(decoded); err != nil || got != code {
+			t.Fatalf("%s: got %q, %v", name, got, err)
+		}
+	}
+}
+
+func TestDecodeEAN13NotFound(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 200, 100))
+	if _, err := DecodeEAN13(blank); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	// A damaged check digit must not decode.
+	bad := renderEAN13(t, "9780441013590", 3)
+	if got, err := DecodeEAN13(bad); err == nil {
+		t.Fatalf("expected checksum failure, got %q", got)
+	}
+}
+
+func TestIsISBN(t *testing.T) {
+	if !IsISBN("9780441013593") || !IsISBN("9791032305560") {
+		t.Fatal("expected Bookland codes to be ISBNs")
+	}
+	if IsISBN("4006381333931") || IsISBN("978") {
+		t.Fatal("expected non-Bookland codes to be rejected")
+	}
+}
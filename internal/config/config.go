@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -88,6 +91,12 @@ var discoveryLanguageAliases = map[string]string{
 
 type Config struct {
 	Debug bool `yaml:"debug"`
+	// Offline keeps Scriptorum on the local network: the public metadata
+	// sources (Amazon, Open Library, Google Books, ISBNdb, Audnex) and the
+	// Open Library discovery shelves are turned off, and ntfy no longer
+	// falls back to ntfy.sh. Services configured explicitly, such as
+	// Readarr, a Discord webhook, or an OIDC provider, are still used.
+	Offline bool `yaml:"offline"`
 	// InsecureSkipVerify disables TLS certificate verification for all outbound
 	// connections to self-hosted services (Readarr, ntfy, Discord, SMTP, OIDC).
 	// Intended for self-hosted deployments using self-signed certificates.
@@ -96,10 +105,8 @@ type Config struct {
 	Discovery          struct {
 		Languages []string `yaml:"languages"`
 	} `yaml:"discovery"`
-	HTTP struct {
-		Listen string `yaml:"listen"`
-	} `yaml:"http"`
-	DB struct {
+	HTTP HTTPConfig `yaml:"http"`
+	DB   struct {
 		Path string `yaml:"path"`
 	} `yaml:"db"`
 	Setup struct {
@@ -110,6 +117,10 @@ type Config struct {
 		Salt string `yaml:"salt"`
 	} `yaml:"auth"`
 
+	Sessions SessionConfig `yaml:"sessions"`
+
+	Branding BrandingConfig `yaml:"branding"`
+
 	Admins struct {
 		Usernames []string `yaml:"usernames"`
 		// Back-compat: allow reading legacy admins.emails and map into usernames on load
@@ -134,12 +145,34 @@ type Config struct {
 		// AutoCreateUsers will create a local user record on first OAuth login
 		// using the OIDC email as the username. Password is random/unusable.
 		AutoCreateUsers bool `yaml:"auto_create_users"`
+		// Directory is an optional SCIM 2.0 endpoint on the identity provider
+		// that admins can import users from before they first sign in.
+		Directory struct {
+			URL   string `yaml:"url,omitempty"`
+			Token string `yaml:"token,omitempty"`
+			// AdminGroup makes members of this directory group admins.
+			AdminGroup string `yaml:"admin_group,omitempty"`
+		} `yaml:"directory,omitempty"`
 	} `yaml:"oauth"`
 
 	AmazonPublic struct {
 		Enabled bool `yaml:"enabled"`
+		// Marketplace is the Amazon store searches fall back to, e.g.
+		// "amazon.co.uk" or "amazon.de". Defaults to amazon.com. Pasted
+		// Amazon links still use the store they came from.
+		Marketplace string `yaml:"marketplace,omitempty"`
 	} `yaml:"amazon_public"`
 
+	ISBNdb ISBNdbConfig `yaml:"isbndb,omitempty"`
+
+	// Mylar turns on comic requests, which go to a Mylar3 server instead of
+	// Readarr.
+	Mylar MylarConfig `yaml:"mylar,omitempty"`
+
+	// Magazines decides where the per-issue requests of magazine
+	// subscriptions are sent.
+	Magazines MagazinesConfig `yaml:"magazines,omitempty"`
+
 	// Audiobookshelf integration removed
 
 	Readarr struct {
@@ -148,6 +181,13 @@ type Config struct {
 		// SyncInterval controls how often the automatic Readarr catalog sync runs.
 		// Accepts Go duration strings (e.g. "15m", "1h"). Defaults to 15m.
 		SyncInterval string `yaml:"sync_interval"`
+		// MinFreeSpaceMB blocks approvals when the target root folder has less
+		// free space than this. Defaults to 1024; a negative value disables it.
+		MinFreeSpaceMB int `yaml:"min_free_space_mb"`
+		// WarmCache loads each instance's quality profiles, root folders,
+		// metadata profiles, and tags at startup so the first approval
+		// doesn't wait on them.
+		WarmCache bool `yaml:"warm_cache,omitempty"`
 	} `yaml:"readarr"`
 
 	Notifications struct {
@@ -155,14 +195,108 @@ type Config struct {
 		SMTP    SMTPConfig    `yaml:"smtp"`
 		Discord DiscordConfig `yaml:"discord"`
 		Webhook WebhookConfig `yaml:"webhook"`
+		Apprise AppriseConfig `yaml:"apprise"`
+		WebPush WebPushConfig `yaml:"web_push,omitempty"`
+		// BatchWindowSeconds holds request, approval, and availability
+		// notifications for this long so a burst goes out to ntfy, email,
+		// Discord, and Apprise as one summary. 0 (default) sends each right
+		// away.
+		BatchWindowSeconds int `yaml:"batch_window_seconds,omitempty"`
 	} `yaml:"notifications"`
 
 	Requests struct {
 		// MaxPendingPerUser caps how many requests a single user may have in
 		// "pending" status at once. 0 (the default) means unlimited.
 		MaxPendingPerUser int `yaml:"max_pending_per_user"`
+		// LabelsAsReadarrTags adds a request's admin labels to the book as
+		// Readarr tags on approval, creating missing tags as needed.
+		LabelsAsReadarrTags bool `yaml:"labels_as_readarr_tags"`
+		// ReminderAfterHours nudges admins about requests still pending after
+		// this many hours, again at twice and three times the age with rising
+		// priority. 0 (the default) disables reminders.
+		ReminderAfterHours int `yaml:"reminder_after_hours"`
+		// AskNeedBy adds an optional need-by date to the request form.
+		// Requests with one sort first in the approval queue and send
+		// reminders with rising priority as the date approaches.
+		AskNeedBy bool `yaml:"ask_need_by,omitempty"`
+		// EnrichmentChain lists, in order, the sources tried when a new or
+		// hydrated request has no Readarr match with foreign ids yet:
+		// readarr, isbndb, openlibrary, googlebooks, audnex. Readarr is
+		// looked up again whenever a later source fills in an identifier.
+		// isbndb is skipped without an API key. Empty uses all five in that
+		// order.
+		EnrichmentChain []string `yaml:"enrichment_chain"`
+		// HydrateInterval repeats the bulk hydration of pending and failed
+		// requests without a selected book, which otherwise runs once a few
+		// minutes after startup. It is a Go duration (at least "1h"); "off"
+		// skips the startup run too.
+		HydrateInterval string `yaml:"hydrate_interval,omitempty"`
+		// AuthorCleanup decides what happens to a Readarr author Scriptorum
+		// created for a request once that request is declined or its add
+		// fails: "offer" (the default) shows a remove button on the request
+		// page, "auto" removes the author right away, and "off" does neither.
+		// Authors with files, monitored books, or other live requests are
+		// never removed.
+		AuthorCleanup string `yaml:"author_cleanup,omitempty"`
+		// ExtraFields are additional questions on the request form, such as
+		// "Why do you want this?" or a preferred narrator. Answers are stored
+		// with the request and shown to admins.
+		ExtraFields []RequestField `yaml:"extra_fields,omitempty"`
+		// TwoPersonApproval requires two different admins to approve
+		// requests matching any of its formats or labels, such as
+		// audiobooks or "purchase required". Matching requests are never
+		// auto-approved.
+		TwoPersonApproval TwoPersonApprovalConfig `yaml:"two_person_approval,omitempty"`
+		// SourcePolicies overrides auto-approval for requests arriving
+		// through a channel, keyed by source ("web", "api", "bulk",
+		// "bookmarklet", "webhook", "guest", "subscription"): "auto"
+		// auto-approves them whatever the requester's own setting, and
+		// "review" always leaves them for an admin. Sources left out follow
+		// the requester's setting. Denylist flags, two-person approval, and
+		// the other holds still apply.
+		SourcePolicies map[string]string `yaml:"source_policies,omitempty"`
+		// Currency is the symbol shown with purchase costs recorded on
+		// requests; it defaults to "$".
+		Currency string `yaml:"currency,omitempty"`
+		// ExpireAfterDays closes requests still pending this many days after
+		// they were made or last renewed, and tells the requester, who can
+		// renew them from the request page. 0 (the default) never expires
+		// requests.
+		ExpireAfterDays int `yaml:"expire_after_days,omitempty"`
+		// ExpireAction is "decline" (the default) or "archive", which moves
+		// expired requests to the "expired" status instead.
+		ExpireAction string `yaml:"expire_action,omitempty"`
+		// Statuses adds workflow states beyond the built-in ones, such as
+		// "Purchasing" or "On hold", that admins can move requests into.
+		Statuses []RequestStatus `yaml:"statuses,omitempty"`
+		// Templates are Readarr payload fields merged into a request's
+		// stored book selection, keyed by format ("ebook" or "audiobook"),
+		// such as anyEditionOk: false for audiobooks. Nested objects like
+		// author merge key by key, and template values win.
+		Templates map[string]map[string]any `yaml:"templates,omitempty"`
 	} `yaml:"requests"`
 
+	// Groups lets one install serve several households. Each group can map its
+	// own Readarr instances, pending-request quota, and group admins; users
+	// without a group fall back to the top-level settings.
+	Groups []GroupConfig `yaml:"groups,omitempty"`
+
+	Attachments AttachmentsConfig `yaml:"attachments"`
+
+	Search SearchConfig `yaml:"search"`
+
+	Maintenance MaintenanceConfig `yaml:"maintenance"`
+
+	HomeAssistant HomeAssistantConfig `yaml:"home_assistant"`
+
+	SCIM SCIMConfig `yaml:"scim"`
+
+	InboundWebhook InboundWebhookConfig `yaml:"inbound_webhook"`
+
+	GuestRequests GuestRequestsConfig `yaml:"guest_requests,omitempty"`
+
+	ImportList ImportListConfig `yaml:"import_list,omitempty"`
+
 	Audit struct {
 		// RetentionDays prunes audit_events older than this many days during
 		// the periodic security janitor sweep. 0 (the default) keeps events
@@ -171,54 +305,549 @@ type Config struct {
 	} `yaml:"audit"`
 }
 
+// HomeAssistantConfig publishes Scriptorum's state to Home Assistant as
+// entities and lets Home Assistant approve or decline requests.
+type HomeAssistantConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// URL is the Home Assistant base URL, e.g. http://homeassistant.local:8123.
+	URL string `yaml:"url"`
+	// Token is a Home Assistant long-lived access token used to publish
+	// states. Without it nothing is pushed, but the state endpoint still
+	// serves Home Assistant's REST sensors.
+	Token string `yaml:"token"`
+	// EntityPrefix starts every entity id; it defaults to "scriptorum".
+	EntityPrefix string `yaml:"entity_prefix,omitempty"`
+	// APIToken is the bearer token Home Assistant presents when it calls
+	// Scriptorum's /api/homeassistant endpoints.
+	APIToken string `yaml:"api_token"`
+}
+
+// SCIMConfig lets an identity provider such as authentik or Keycloak manage
+// accounts through a SCIM 2.0 endpoint at /scim/v2.
+type SCIMConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Token is the bearer token the identity provider presents.
+	Token string `yaml:"token"`
+	// AdminGroup is the provider group whose members are Scriptorum admins.
+	// Members of a group named like a household group join that group.
+	AdminGroup string `yaml:"admin_group,omitempty"`
+}
+
+// InboundWebhookConfig lets external tools, such as browser extensions or
+// phone shortcuts, create requests at /api/v1/webhooks/inbound.
+type InboundWebhookConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Token is the bearer token callers present.
+	Token string `yaml:"token"`
+	// DefaultRequester is the user a request is filed for when the call
+	// names none.
+	DefaultRequester string `yaml:"default_requester,omitempty"`
+	// Secret, when set, also requires every call to be signed: an
+	// X-Scriptorum-Timestamp header with the Unix time and an
+	// X-Scriptorum-Signature header of "sha256=" and the hex HMAC-SHA256
+	// of "<timestamp>.<body>", keyed with the secret.
+	Secret string `yaml:"secret,omitempty"`
+	// SignatureToleranceSeconds is how far a signed call's timestamp may
+	// be from the server's clock; 0 means 300.
+	SignatureToleranceSeconds int `yaml:"signature_tolerance_seconds,omitempty"`
+}
+
+// GuestRequestsConfig lets people without an account ask for books through
+// a shared link, /guest/<token>. Their requests wait in a separate queue
+// until an admin converts them into real requests or rejects them.
+type GuestRequestsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Token is the secret part of the guest link.
+	Token string `yaml:"token"`
+}
+
+// ImportListConfig publishes approved requests as an import list at
+// /importlist/<format>, in a shape a Readarr "Readarr" import list reads,
+// plus a plain JSON list at /importlist/<format>/list.json.
+type ImportListConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Token is the API key readers present.
+	Token string `yaml:"token"`
+}
+
+// HTTPConfig controls where the web server listens.
+type HTTPConfig struct {
+	// Listen is the TCP listen address. It may be left empty when a unix
+	// socket or socket activation provides the listener instead.
+	Listen string `yaml:"listen"`
+	// UnixSocket additionally serves on a unix domain socket at this path,
+	// created with UnixSocketMode permissions (octal, default "0660").
+	UnixSocket     string `yaml:"unix_socket"`
+	UnixSocketMode string `yaml:"unix_socket_mode"`
+	// SocketActivation serves on the sockets systemd passes via LISTEN_FDS
+	// when present.
+	SocketActivation bool `yaml:"socket_activation"`
+}
+
+// MaintenanceConfig schedules SQLite upkeep: a WAL checkpoint, VACUUM, and
+// ANALYZE. Interval is a Go duration ("24h" when blank, at least "1h");
+// "off" disables the scheduled run but keeps the manual action on /settings.
+type MaintenanceConfig struct {
+	Interval string `yaml:"interval"`
+}
+
+// SessionConfig controls how long sign-ins last. LifetimeHours is the
+// absolute limit of a normal session (default 24) and RememberDays that of
+// one signed in with "remember me" (default 30). IdleMinutes, when set, also
+// ends a normal session after that long without a request; each request
+// renews it. Remembered sessions are exempt from the idle timeout.
+type SessionConfig struct {
+	LifetimeHours int `yaml:"lifetime_hours"`
+	RememberDays  int `yaml:"remember_days"`
+	IdleMinutes   int `yaml:"idle_minutes"`
+}
+
+// Lifetime is the absolute session lifetime, with or without remember me.
+func (c SessionConfig) Lifetime(remember bool) time.Duration {
+	if remember {
+		if c.RememberDays > 0 {
+			return time.Duration(c.RememberDays) * 24 * time.Hour
+		}
+		return 30 * 24 * time.Hour
+	}
+	if c.LifetimeHours > 0 {
+		return time.Duration(c.LifetimeHours) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// IdleTimeout is how long a normal session may go unused; 0 means forever.
+func (c SessionConfig) IdleTimeout() time.Duration {
+	if c.IdleMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(c.IdleMinutes) * time.Minute
+}
+
+// BrandingConfig renames the instance for households that don't call it
+// Scriptorum. InstanceName replaces the name on the login page, the header,
+// emails, and notification titles; LogoURL replaces the icon (an uploaded
+// logo is served from /branding/logo); AccentColor is a "#rrggbb" color
+// used for buttons and email headers.
+type BrandingConfig struct {
+	InstanceName string `yaml:"instance_name,omitempty"`
+	LogoURL      string `yaml:"logo_url,omitempty"`
+	AccentColor  string `yaml:"accent_color,omitempty"`
+}
+
+// Name is the instance name, "Scriptorum" unless renamed.
+func (b BrandingConfig) Name() string {
+	if n := strings.TrimSpace(b.InstanceName); n != "" {
+		return n
+	}
+	return "Scriptorum"
+}
+
+// Logo is the logo URL, the bundled icon unless replaced.
+func (b BrandingConfig) Logo() string {
+	if u := strings.TrimSpace(b.LogoURL); u != "" {
+		return u
+	}
+	return "/static/icon.svg"
+}
+
+// Accent is the accent color, or "" when unset or not a "#rrggbb" color.
+func (b BrandingConfig) Accent() string {
+	return NormalizeAccentColor(b.AccentColor)
+}
+
+// NormalizeAccentColor lowercases a "#rrggbb" (or "rrggbb") color and
+// returns "" for anything else, so the value is always safe to put in CSS.
+func NormalizeAccentColor(v string) string {
+	v = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(v), "#"))
+	if len(v) != 6 {
+		return ""
+	}
+	for _, c := range v {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return ""
+		}
+	}
+	return "#" + v
+}
+
+// SearchConfig tunes how search results from Readarr, Amazon, and
+// OpenLibrary are merged and ordered.
+//
+// Dedupe decides when two results are the same book: "identifier" (the
+// default; ASIN, then ISBN-13, then title and first author), "title_author"
+// (ignores identifiers), "foreign_book_id" (Readarr work ids keep
+// same-titled works apart), or "isbn" (every edition stays separate).
+//
+// Order is "source" (the default; Readarr results first, then the other
+// providers in the order they answered), "relevance", "year" (newest first),
+// or "rating".
+//
+// DisabledProviders lists search sources left out of the fan-out:
+// "readarr_ebooks", "readarr_audiobooks", "openlibrary", or "amazon".
+type SearchConfig struct {
+	Dedupe            string   `yaml:"dedupe"`
+	Order             string   `yaml:"order"`
+	DisabledProviders []string `yaml:"disabled_providers,omitempty"`
+	// ProviderOrder lists book sources by priority for the "source" order;
+	// sources left out follow in the built-in order. Users can override it
+	// on their account page.
+	ProviderOrder []string `yaml:"provider_order,omitempty"`
+	// CoverImages is how pages show book covers: "proxy" (default) fetches
+	// Readarr-hosted covers through the server, "direct" links every cover
+	// at its source so the server never fetches images, and "none" shows
+	// no covers.
+	CoverImages string `yaml:"cover_images,omitempty"`
+	// ResultLimits caps how many results each source adds to one page of
+	// a search, keyed by the DisabledProviders names plus "mylar". Readarr
+	// answers a lookup with every match at once; with a limit, later pages
+	// show the next ones instead. Open Library and Amazon already return
+	// a page of 20, so only a smaller limit changes them.
+	ResultLimits map[string]int `yaml:"result_limits,omitempty"`
+	// Timeout bounds a whole search across every source, as a Go duration
+	// such as "8s". Sources that have not answered by then are left out of
+	// the results. Empty leaves each source to its own timeout.
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// ResultLimit returns the configured result cap for a search source, or 0
+// for none.
+func (c SearchConfig) ResultLimit(name string) int {
+	if n := c.ResultLimits[name]; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// PublicSearchProviders are the search sources on the public internet,
+// which offline mode leaves out.
+var PublicSearchProviders = []string{"openlibrary", "amazon"}
+
+// SearchProviderEnabled reports whether the named search source is queried,
+// taking offline mode into account.
+func (c *Config) SearchProviderEnabled(name string) bool {
+	if c.Offline {
+		for _, p := range PublicSearchProviders {
+			if strings.EqualFold(p, name) {
+				return false
+			}
+		}
+	}
+	return c.Search.ProviderEnabled(name)
+}
+
+// ProviderEnabled reports whether the named search source is queried.
+func (c SearchConfig) ProviderEnabled(name string) bool {
+	for _, d := range c.DisabledProviders {
+		if strings.EqualFold(strings.TrimSpace(d), name) {
+			return false
+		}
+	}
+	return true
+}
+
+// AttachmentsConfig controls files requesters may attach to a request.
+// Storage is "disk" (the default), "s3" for any S3-compatible service, or
+// "none" to accept links only. Path defaults to an "attachments" directory
+// next to the database; MaxSizeKB defaults to 2048 and AllowedTypes to common
+// image types plus PDF.
+type AttachmentsConfig struct {
+	Storage      string   `yaml:"storage"`
+	Path         string   `yaml:"path"`
+	MaxSizeKB    int      `yaml:"max_size_kb"`
+	AllowedTypes []string `yaml:"allowed_types"`
+	S3           S3Config `yaml:"s3"`
+}
+
+// S3Config points at an S3-compatible bucket (AWS, MinIO, Garage, R2, ...).
+// PathStyle addresses the bucket as endpoint/bucket, which most self-hosted
+// services require.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	Prefix    string `yaml:"prefix"`
+	PathStyle bool   `yaml:"path_style"`
+}
+
 type NtfyConfig struct {
-	Enabled                      bool   `yaml:"enabled"`
-	Server                       string `yaml:"server"`
-	Topic                        string `yaml:"topic"`
-	Username                     string `yaml:"username"`
-	Password                     string `yaml:"password"`
-	EnableRequestNotifications   bool   `yaml:"enable_request_notifications"`
-	EnableApprovalNotifications  bool   `yaml:"enable_approval_notifications"`
-	EnableAvailableNotifications bool   `yaml:"enable_available_notifications"`
-	EnableSystemNotifications    bool   `yaml:"enable_system_notifications"`
+	Enabled                      bool       `yaml:"enabled"`
+	Server                       string     `yaml:"server"`
+	Topic                        string     `yaml:"topic"`
+	Username                     string     `yaml:"username"`
+	Password                     string     `yaml:"password"`
+	EnableRequestNotifications   bool       `yaml:"enable_request_notifications"`
+	EnableApprovalNotifications  bool       `yaml:"enable_approval_notifications"`
+	EnableAvailableNotifications bool       `yaml:"enable_available_notifications"`
+	EnableSystemNotifications    bool       `yaml:"enable_system_notifications"`
+	QuietHours                   QuietHours `yaml:"quiet_hours,omitempty"`
 }
 
 type SMTPConfig struct {
-	Enabled                      bool   `yaml:"enabled"`
-	Host                         string `yaml:"host"`
-	Port                         int    `yaml:"port"`
-	Username                     string `yaml:"username"`
-	Password                     string `yaml:"password"`
-	FromEmail                    string `yaml:"from_email"`
-	FromName                     string `yaml:"from_name"`
-	ToEmail                      string `yaml:"to_email"`
-	EnableTLS                    bool   `yaml:"enable_tls"`
-	EnableRequestNotifications   bool   `yaml:"enable_request_notifications"`
-	EnableApprovalNotifications  bool   `yaml:"enable_approval_notifications"`
-	EnableAvailableNotifications bool   `yaml:"enable_available_notifications"`
-	EnableSystemNotifications    bool   `yaml:"enable_system_notifications"`
+	Enabled                      bool       `yaml:"enabled"`
+	Host                         string     `yaml:"host"`
+	Port                         int        `yaml:"port"`
+	Username                     string     `yaml:"username"`
+	Password                     string     `yaml:"password"`
+	FromEmail                    string     `yaml:"from_email"`
+	FromName                     string     `yaml:"from_name"`
+	ToEmail                      string     `yaml:"to_email"`
+	EnableTLS                    bool       `yaml:"enable_tls"`
+	EnableRequestNotifications   bool       `yaml:"enable_request_notifications"`
+	EnableApprovalNotifications  bool       `yaml:"enable_approval_notifications"`
+	EnableAvailableNotifications bool       `yaml:"enable_available_notifications"`
+	EnableSystemNotifications    bool       `yaml:"enable_system_notifications"`
+	QuietHours                   QuietHours `yaml:"quiet_hours,omitempty"`
+	// Replies lets admins answer a new-request email with "approve" or
+	// "decline <reason>" instead of following its links.
+	Replies MailRepliesConfig `yaml:"replies,omitempty"`
+}
+
+// MailRepliesConfig is the IMAP mailbox, reached over TLS, that replies to
+// request emails arrive in. Port defaults to 993 and Mailbox to INBOX.
+type MailRepliesConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Host     string `yaml:"host,omitempty"`
+	Port     int    `yaml:"port,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	Mailbox  string `yaml:"mailbox,omitempty"`
 }
 
 type DiscordConfig struct {
-	Enabled                      bool   `yaml:"enabled"`
-	WebhookURL                   string `yaml:"webhook_url"`
-	Username                     string `yaml:"username"`
-	EnableRequestNotifications   bool   `yaml:"enable_request_notifications"`
-	EnableApprovalNotifications  bool   `yaml:"enable_approval_notifications"`
-	EnableAvailableNotifications bool   `yaml:"enable_available_notifications"`
-	EnableSystemNotifications    bool   `yaml:"enable_system_notifications"`
+	Enabled                      bool       `yaml:"enabled"`
+	WebhookURL                   string     `yaml:"webhook_url"`
+	Username                     string     `yaml:"username"`
+	EnableRequestNotifications   bool       `yaml:"enable_request_notifications"`
+	EnableApprovalNotifications  bool       `yaml:"enable_approval_notifications"`
+	EnableAvailableNotifications bool       `yaml:"enable_available_notifications"`
+	EnableSystemNotifications    bool       `yaml:"enable_system_notifications"`
+	QuietHours                   QuietHours `yaml:"quiet_hours,omitempty"`
+	// PublicKey is the hex public key of the Discord application that owns
+	// the webhook. When set, new-request messages carry Approve and Decline
+	// buttons answered at /api/v1/discord/interactions instead of links.
+	PublicKey string `yaml:"public_key,omitempty"`
+	// Moderators maps Discord user ids to the Scriptorum accounts their
+	// button presses act as. Presses from anyone else are refused.
+	Moderators map[string]string `yaml:"moderators,omitempty"`
+}
+
+// WebPushConfig holds the VAPID key pair browsers use to check that pushes
+// come from this server. Scriptorum generates the keys the first time
+// someone turns on push; changing them invalidates every subscription.
+type WebPushConfig struct {
+	// Subject is a mailto: or https: contact for push services; the server
+	// URL is used when empty.
+	Subject    string `yaml:"subject,omitempty"`
+	PublicKey  string `yaml:"vapid_public_key,omitempty"`
+	PrivateKey string `yaml:"vapid_private_key,omitempty"`
+}
+
+// QuietHours holds a provider's notifications during a daily window in the
+// server's local time, e.g. 23:00-07:00, and sends them when it ends. Start
+// and End are "HH:MM"; the window is off unless both are set and differ.
+type QuietHours struct {
+	Start string `yaml:"start,omitempty"`
+	End   string `yaml:"end,omitempty"`
+	// UrgentOverrides lets system alerts through during the window.
+	UrgentOverrides bool `yaml:"urgent_overrides,omitempty"`
+}
+
+// ParseClock reads "HH:MM" as minutes after midnight.
+func ParseClock(v string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(v))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// Remaining returns how long the window that now falls in has left, or 0
+// when now is outside it. Windows that cross midnight are supported.
+func (q QuietHours) Remaining(now time.Time) time.Duration {
+	start, ok1 := ParseClock(q.Start)
+	end, ok2 := ParseClock(q.End)
+	if !ok1 || !ok2 || start == end {
+		return 0
+	}
+	at := func(day, min int) time.Time {
+		return time.Date(now.Year(), now.Month(), now.Day()+day, min/60, min%60, 0, 0, now.Location())
+	}
+	mins := now.Hour()*60 + now.Minute()
+	switch {
+	case start < end && mins >= start && mins < end:
+		return at(0, end).Sub(now)
+	case start > end && mins >= start:
+		return at(1, end).Sub(now)
+	case start > end && mins < end:
+		return at(0, end).Sub(now)
+	}
+	return 0
+}
+
+// AppriseConfig relays notifications through an Apprise API server, which
+// fans them out to any of the services Apprise supports (Telegram, Slack,
+// Matrix, Pushover, ...).
+type AppriseConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	ServerURL string `yaml:"server_url"`
+	// ConfigKey names a configuration stored on the Apprise server. When it
+	// is blank, URLs are sent with every notification instead.
+	ConfigKey string `yaml:"config_key,omitempty"`
+	// URLs are Apprise service URLs such as tgram://bottoken/ChatID.
+	URLs []string `yaml:"urls,omitempty"`
+	// Tag limits a stored configuration to the services tagged with it.
+	Tag                          string     `yaml:"tag,omitempty"`
+	EnableRequestNotifications   bool       `yaml:"enable_request_notifications"`
+	EnableApprovalNotifications  bool       `yaml:"enable_approval_notifications"`
+	EnableAvailableNotifications bool       `yaml:"enable_available_notifications"`
+	EnableSystemNotifications    bool       `yaml:"enable_system_notifications"`
+	QuietHours                   QuietHours `yaml:"quiet_hours,omitempty"`
 }
 
 // WebhookConfig sends a generic JSON POST to any HTTP endpoint, for users who
 // want to pipe Scriptorum events into something not natively supported
 // (n8n, Home Assistant, a custom relay, etc.) rather than a specific chat app.
 type WebhookConfig struct {
-	Enabled                      bool   `yaml:"enabled"`
-	URL                          string `yaml:"url"`
-	EnableRequestNotifications   bool   `yaml:"enable_request_notifications"`
-	EnableApprovalNotifications  bool   `yaml:"enable_approval_notifications"`
-	EnableAvailableNotifications bool   `yaml:"enable_available_notifications"`
-	EnableSystemNotifications    bool   `yaml:"enable_system_notifications"`
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"`
+	// Secret, when set, signs each body with HMAC-SHA256 in the
+	// X-Scriptorum-Signature header.
+	Secret string `yaml:"secret,omitempty"`
+	// Template is a Go text/template rendering the JSON body from the event
+	// fields; blank posts the event as-is.
+	Template string `yaml:"template,omitempty"`
+	// Endpoints are further URLs that receive the same events. Each inherits
+	// Secret and Template when its own are blank.
+	Endpoints                    []WebhookEndpoint `yaml:"endpoints,omitempty"`
+	EnableRequestNotifications   bool              `yaml:"enable_request_notifications"`
+	EnableApprovalNotifications  bool              `yaml:"enable_approval_notifications"`
+	EnableAvailableNotifications bool              `yaml:"enable_available_notifications"`
+	EnableSystemNotifications    bool              `yaml:"enable_system_notifications"`
+	QuietHours                   QuietHours        `yaml:"quiet_hours,omitempty"`
+}
+
+// WebhookEndpoint is one outbound webhook destination.
+type WebhookEndpoint struct {
+	// Name labels the endpoint in the delivery log; it defaults to the host.
+	Name     string `yaml:"name,omitempty"`
+	URL      string `yaml:"url"`
+	Secret   string `yaml:"secret,omitempty"`
+	Template string `yaml:"template,omitempty"`
+}
+
+// Targets returns every endpoint events are posted to: URL first, then
+// Endpoints, with blank secrets and templates filled in from the top level.
+// Entries without a URL and repeated URLs are skipped.
+func (w WebhookConfig) Targets() []WebhookEndpoint {
+	var out []WebhookEndpoint
+	seen := map[string]bool{}
+	add := func(ep WebhookEndpoint) {
+		ep.URL = strings.TrimSpace(ep.URL)
+		if ep.URL == "" || seen[ep.URL] {
+			return
+		}
+		seen[ep.URL] = true
+		if ep.Secret == "" {
+			ep.Secret = w.Secret
+		}
+		if strings.TrimSpace(ep.Template) == "" {
+			ep.Template = w.Template
+		}
+		out = append(out, ep)
+	}
+	add(WebhookEndpoint{URL: w.URL})
+	for _, ep := range w.Endpoints {
+		add(ep)
+	}
+	return out
+}
+
+// RequestField is one admin-configured extra question on the request form.
+type RequestField struct {
+	// Key identifies the answer; it defaults to the label in snake_case.
+	Key   string `yaml:"key,omitempty"`
+	Label string `yaml:"label"`
+	// Type is "text" (default), "textarea", or "date".
+	Type     string `yaml:"type,omitempty"`
+	Required bool   `yaml:"required,omitempty"`
+}
+
+// Request field types.
+const (
+	FieldText     = "text"
+	FieldTextarea = "textarea"
+	FieldDate     = "date"
+)
+
+// MaxRequestFields caps how many extra request fields are kept.
+const MaxRequestFields = 10
+
+// RequestStatus is one admin-defined workflow state.
+type RequestStatus struct {
+	// Key is the stored status; it defaults to the label in snake_case.
+	Key   string `yaml:"key,omitempty"`
+	Label string `yaml:"label"`
+	// Color is one of StatusColors; it defaults to "slate".
+	Color string `yaml:"color,omitempty"`
+	// From lists the statuses a request may enter this one from, and To
+	// the statuses it may leave it for. Either left empty allows any.
+	From []string `yaml:"from,omitempty"`
+	To   []string `yaml:"to,omitempty"`
+}
+
+// BuiltinStatuses are the request statuses Scriptorum itself sets, in
+// workflow order.
+var BuiltinStatuses = []string{"pending", "processing", "approved", "queued", "available", "declined", "expired", "error"}
+
+// StatusColors are the badge colors a custom status may use.
+var StatusColors = []string{"slate", "amber", "sky", "emerald", "royal", "rose"}
+
+// MaxRequestStatuses caps how many custom statuses are kept.
+const MaxRequestStatuses = 10
+
+// TwoPersonApprovalConfig picks the requests that need a second admin's
+// approval. Formats are request formats ("ebook", "audiobook", ...); labels
+// are admin labels, compared without regard to case.
+type TwoPersonApprovalConfig struct {
+	Formats []string `yaml:"formats,omitempty"`
+	Labels  []string `yaml:"labels,omitempty"`
+}
+
+// Enabled reports whether any request can need a second approval.
+func (c TwoPersonApprovalConfig) Enabled() bool {
+	return len(c.Formats) > 0 || len(c.Labels) > 0
+}
+
+// GroupConfig describes one household group. Readarr instances left blank
+// inherit the top-level readarr settings, and a MaxPendingPerUser of 0 inherits
+// requests.max_pending_per_user.
+type GroupConfig struct {
+	Name string `yaml:"name"`
+	// Admins may approve and decline requests made by members of this group,
+	// without being full Scriptorum admins.
+	Admins            []string `yaml:"admins"`
+	MaxPendingPerUser int      `yaml:"max_pending_per_user"`
+	Readarr           struct {
+		Ebooks     ReadarrInstance `yaml:"ebooks"`
+		Audiobooks ReadarrInstance `yaml:"audiobooks"`
+	} `yaml:"readarr"`
+}
+
+// Group returns the configured group with the given name (case-insensitive).
+func (c *Config) Group(name string) (GroupConfig, bool) {
+	name = strings.TrimSpace(name)
+	if c == nil || name == "" {
+		return GroupConfig{}, false
+	}
+	for _, g := range c.Groups {
+		if strings.EqualFold(strings.TrimSpace(g.Name), name) {
+			return g, true
+		}
+	}
+	return GroupConfig{}, false
 }
 
 type ReadarrInstance struct {
@@ -229,6 +858,57 @@ type ReadarrInstance struct {
 	DefaultTags             []string `yaml:"default_tags"`
 	// If true, the Readarr HTTP client will skip TLS certificate verification.
 	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+	// AdminQualityProfileID replaces DefaultQualityProfileID for books
+	// requested by admins; 0 uses the default for everyone.
+	AdminQualityProfileID int `yaml:"admin_quality_profile_id,omitempty"`
+	// Monitor decides what else Readarr monitors when a request adds an
+	// author it does not have yet: "none" (default, only the requested
+	// book), "requested" (strict: booksToMonitor and the monitored edition
+	// are pinned to the request), "new" (also the author's future
+	// releases), or "all" (the author's whole backlist).
+	Monitor string `yaml:"monitor,omitempty"`
+	// SearchOnAdd has Readarr search for the book as soon as it is added.
+	// Defaults to true when unset.
+	SearchOnAdd *bool `yaml:"search_on_add,omitempty"`
+	// AddType is Readarr's addOptions.addType: "automatic" (default) or
+	// "manual".
+	AddType string `yaml:"add_type,omitempty"`
+	// CompatRetry retries an add that Readarr rejects with a validation
+	// error using a few alternative payload shapes, remembering the one
+	// that worked. Off by default.
+	CompatRetry bool `yaml:"compat_retry,omitempty"`
+	// Library is where this instance's books are read or listened to, so
+	// "now available" notifications can link straight to the item.
+	Library LibraryConfig `yaml:"library,omitempty"`
+}
+
+// LibraryConfig points at an Audiobookshelf, Kavita, or Calibre-Web server.
+type LibraryConfig struct {
+	// Kind is "audiobookshelf", "kavita", or "calibre-web"; blank turns
+	// links off.
+	Kind string `yaml:"kind,omitempty"`
+	// URL is how Scriptorum reaches the server; PublicURL, when set, is the
+	// address used in links.
+	URL       string `yaml:"url,omitempty"`
+	PublicURL string `yaml:"public_url,omitempty"`
+	// APIKey is an Audiobookshelf API token or a Kavita API key.
+	APIKey string `yaml:"api_key,omitempty"`
+	// Username and Password log in to Calibre-Web's OPDS feed.
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// LibraryID limits Audiobookshelf searches and Kavita syncs to one
+	// library.
+	LibraryID string `yaml:"library_id,omitempty"`
+	// Sync copies the Kavita library into the local cache alongside the
+	// Readarr catalog sync, so search results show books already on the
+	// server and links resolve without a lookup.
+	Sync bool `yaml:"sync,omitempty"`
+}
+
+// SearchOnAddEnabled reports whether books added to this instance are
+// searched for right away.
+func (r ReadarrInstance) SearchOnAddEnabled() bool {
+	return r.SearchOnAdd == nil || *r.SearchOnAdd
 }
 
 func Load(path string) (*Config, error) {
@@ -279,6 +959,244 @@ func NormalizeDiscoveryLanguages(input []string) []string {
 	return out
 }
 
+// ISBNdbConfig enables the isbndb.com metadata source, which needs a paid
+// API key.
+type ISBNdbConfig struct {
+	APIKey string `yaml:"api_key,omitempty"`
+	// BaseURL is only needed for the premium and pro plans, which have
+	// their own API hosts. Defaults to https://api2.isbndb.com.
+	BaseURL string `yaml:"base_url,omitempty"`
+	// DailyLimit stops lookups once this many calls were made in a UTC
+	// day, so enrichment doesn't use up the plan's quota. 0 means no cap
+	// beyond ISBNdb's own.
+	DailyLimit int `yaml:"daily_limit,omitempty"`
+}
+
+// Enabled reports whether an API key is set.
+func (c ISBNdbConfig) Enabled() bool {
+	return strings.TrimSpace(c.APIKey) != ""
+}
+
+// MylarConfig points at a Mylar3 server that comic requests are sent to.
+type MylarConfig struct {
+	BaseURL string `yaml:"base_url,omitempty"`
+	APIKey  string `yaml:"api_key,omitempty"`
+}
+
+// Enabled reports whether both the URL and API key are set.
+func (c MylarConfig) Enabled() bool {
+	return strings.TrimSpace(c.BaseURL) != "" && strings.TrimSpace(c.APIKey) != ""
+}
+
+// MagazinesConfig routes magazine issue requests.
+type MagazinesConfig struct {
+	// Backend is "none" (default: approving an issue only marks it
+	// approved), "ebooks", or "audiobooks" for that Readarr instance.
+	Backend string `yaml:"backend,omitempty"`
+}
+
+// ReadarrFormat is the Readarr instance format issues go to, or "" when
+// they are handled by hand.
+func (c MagazinesConfig) ReadarrFormat() string {
+	switch strings.ToLower(strings.TrimSpace(c.Backend)) {
+	case "ebook", "ebooks":
+		return "ebook"
+	case "audiobook", "audiobooks":
+		return "audiobook"
+	default:
+		return ""
+	}
+}
+
+// Enrichment sources for Requests.EnrichmentChain.
+const (
+	EnrichReadarr     = "readarr"
+	EnrichISBNdb      = "isbndb"
+	EnrichOpenLibrary = "openlibrary"
+	EnrichGoogleBooks = "googlebooks"
+	EnrichAudnex      = "audnex"
+)
+
+var enrichmentSourceAliases = map[string]string{
+	"readarr":      EnrichReadarr,
+	"isbndb":       EnrichISBNdb,
+	"isbn_db":      EnrichISBNdb,
+	"openlibrary":  EnrichOpenLibrary,
+	"open_library": EnrichOpenLibrary,
+	"ol":           EnrichOpenLibrary,
+	"googlebooks":  EnrichGoogleBooks,
+	"google_books": EnrichGoogleBooks,
+	"google":       EnrichGoogleBooks,
+	"audnex":       EnrichAudnex,
+	"audnexus":     EnrichAudnex,
+}
+
+func DefaultEnrichmentChain() []string {
+	return []string{EnrichReadarr, EnrichISBNdb, EnrichOpenLibrary, EnrichGoogleBooks, EnrichAudnex}
+}
+
+// NormalizeEnrichmentChain canonicalizes source names, drops unknown and
+// repeated ones, and falls back to the default chain when nothing is left.
+func NormalizeEnrichmentChain(input []string) []string {
+	seen := make(map[string]struct{}, len(input))
+	out := make([]string, 0, len(input))
+	for _, raw := range input {
+		for _, part := range strings.Split(raw, ",") {
+			name, ok := enrichmentSourceAliases[strings.ToLower(strings.TrimSpace(part))]
+			if !ok {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			out = append(out, name)
+		}
+	}
+	if len(out) == 0 {
+		return DefaultEnrichmentChain()
+	}
+	return out
+}
+
+// NormalizeRequestFields trims fields, derives missing keys from labels,
+// defaults unknown types to text, and drops unlabeled and repeated fields.
+func NormalizeRequestFields(input []RequestField) []RequestField {
+	seen := make(map[string]struct{}, len(input))
+	var out []RequestField
+	for _, f := range input {
+		f.Label = strings.TrimSpace(f.Label)
+		if f.Label == "" {
+			continue
+		}
+		f.Key = fieldKey(f.Key)
+		if f.Key == "" {
+			f.Key = fieldKey(f.Label)
+		}
+		if f.Key == "" {
+			continue
+		}
+		if _, ok := seen[f.Key]; ok {
+			continue
+		}
+		seen[f.Key] = struct{}{}
+		switch t := strings.ToLower(strings.TrimSpace(f.Type)); t {
+		case FieldTextarea, FieldDate:
+			f.Type = t
+		default:
+			f.Type = FieldText
+		}
+		out = append(out, f)
+		if len(out) == MaxRequestFields {
+			break
+		}
+	}
+	return out
+}
+
+// NormalizeRequestStatuses trims statuses, derives missing keys from
+// labels, defaults unknown colors to slate, and drops unlabeled, repeated,
+// and built-in statuses. From and To are reduced to status keys.
+func NormalizeRequestStatuses(input []RequestStatus) []RequestStatus {
+	seen := make(map[string]struct{}, len(input)+len(BuiltinStatuses))
+	for _, st := range BuiltinStatuses {
+		seen[st] = struct{}{}
+	}
+	keys := func(in []string) []string {
+		var out []string
+		for _, k := range in {
+			if k = fieldKey(k); k != "" && !slices.Contains(out, k) {
+				out = append(out, k)
+			}
+		}
+		return out
+	}
+	var out []RequestStatus
+	for _, st := range input {
+		st.Label = strings.TrimSpace(st.Label)
+		if st.Label == "" {
+			continue
+		}
+		st.Key = fieldKey(st.Key)
+		if st.Key == "" {
+			st.Key = fieldKey(st.Label)
+		}
+		if _, ok := seen[st.Key]; ok || st.Key == "" {
+			continue
+		}
+		seen[st.Key] = struct{}{}
+		st.Color = strings.ToLower(strings.TrimSpace(st.Color))
+		if !slices.Contains(StatusColors, st.Color) {
+			st.Color = StatusColors[0]
+		}
+		st.From, st.To = keys(st.From), keys(st.To)
+		out = append(out, st)
+		if len(out) == MaxRequestStatuses {
+			break
+		}
+	}
+	return out
+}
+
+// CheckStatusTransition reports whether a request may move from one status
+// to another given the custom statuses. Built-in moves are always allowed;
+// the rules of a custom status apply when entering or leaving it, with the
+// approval steps "processing" and "queued" counting as "approved". A status
+// that is neither built in nor configured cannot be entered, though a
+// request left in one may move anywhere.
+func CheckStatusTransition(statuses []RequestStatus, from, to string) error {
+	if from == to {
+		return nil
+	}
+	byKey := make(map[string]RequestStatus, len(statuses))
+	for _, st := range NormalizeRequestStatuses(statuses) {
+		byKey[st.Key] = st
+	}
+	family := func(s string) string {
+		if s == "processing" || s == "queued" {
+			return "approved"
+		}
+		return s
+	}
+	allows := func(list []string, s string) bool {
+		return len(list) == 0 || slices.Contains(list, s) || slices.Contains(list, family(s))
+	}
+	target, custom := byKey[to]
+	if !custom && !slices.Contains(BuiltinStatuses, to) {
+		return fmt.Errorf("unknown status %q", to)
+	}
+	if custom && !allows(target.From, from) {
+		return fmt.Errorf("a request cannot move from %q to %q", from, to)
+	}
+	if cur, ok := byKey[from]; ok && !allows(cur.To, to) {
+		return fmt.Errorf("a request cannot move from %q to %q", from, to)
+	}
+	return nil
+}
+
+// fieldKey reduces s to lower-case letters, digits, and single underscores.
+func fieldKey(s string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			sep = false
+			b.WriteRune(r)
+		default:
+			sep = true
+		}
+	}
+	key := b.String()
+	if len(key) > 40 {
+		key = strings.TrimRight(key[:40], "_")
+	}
+	return key
+}
+
 func Save(path string, cfg *Config) error {
 	b, err := yaml.Marshal(cfg)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 // Test config loading from file
@@ -75,9 +76,7 @@ func TestSaveConfig(t *testing.T) {
 	// Create a test config
 	cfg := &Config{
 		Debug: true,
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{
+		HTTP: HTTPConfig{
 			Listen: ":9090",
 		},
 		DB: struct {
@@ -109,11 +108,17 @@ func TestSaveConfig(t *testing.T) {
 			AllowDomains    []string `yaml:"allow_email_domains,omitempty"`
 			AllowEmails     []string `yaml:"allow_emails,omitempty"`
 			AutoCreateUsers bool     `yaml:"auto_create_users"`
+			Directory       struct {
+				URL        string `yaml:"url,omitempty"`
+				Token      string `yaml:"token,omitempty"`
+				AdminGroup string `yaml:"admin_group,omitempty"`
+			} `yaml:"directory,omitempty"`
 		}{
 			Enabled: false,
 		},
 		AmazonPublic: struct {
-			Enabled bool `yaml:"enabled"`
+			Enabled     bool   `yaml:"enabled"`
+			Marketplace string `yaml:"marketplace,omitempty"`
 		}{
 			Enabled: true,
 		},
@@ -363,3 +368,134 @@ func TestNormalizeDiscoveryLanguages(t *testing.T) {
 		t.Fatalf("NormalizeDiscoveryLanguages = %+v, want %+v", got, want)
 	}
 }
+
+func TestNormalizeEnrichmentChain(t *testing.T) {
+	got := NormalizeEnrichmentChain([]string{"Google, ol", "bogus", "readarr", "openlibrary"})
+	want := []string{EnrichGoogleBooks, EnrichOpenLibrary, EnrichReadarr}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NormalizeEnrichmentChain = %+v, want %+v", got, want)
+	}
+	if got := NormalizeEnrichmentChain([]string{"", "nope"}); !reflect.DeepEqual(got, DefaultEnrichmentChain()) {
+		t.Fatalf("expected the default chain, got %+v", got)
+	}
+}
+
+func TestGroupLookupIsCaseInsensitive(t *testing.T) {
+	cfg := &Config{Groups: []GroupConfig{{Name: "Smiths", Admins: []string{"alice"}}}}
+	if g, ok := cfg.Group(" smiths "); !ok || g.Name != "Smiths" {
+		t.Fatalf("expected Smiths, got %+v ok=%v", g, ok)
+	}
+	if _, ok := cfg.Group(""); ok {
+		t.Fatalf("blank name should not match")
+	}
+	if _, ok := cfg.Group("jones"); ok {
+		t.Fatalf("unknown group should not match")
+	}
+}
+
+func TestNormalizeRequestFields(t *testing.T) {
+	got := NormalizeRequestFields([]RequestField{
+		{Label: " Reason for request ", Required: true},
+		{Label: "Needed by", Type: "DATE"},
+		{Label: "", Type: "text"},
+		{Label: "reason-for request", Type: "textarea"},
+		{Label: "Notes", Type: "rich"},
+		{Key: "My Key!", Label: "Other"},
+	})
+	want := []RequestField{
+		{Key: "reason_for_request", Label: "Reason for request", Type: FieldText, Required: true},
+		{Key: "needed_by", Label: "Needed by", Type: FieldDate},
+		{Key: "notes", Label: "Notes", Type: FieldText},
+		{Key: "my_key", Label: "Other", Type: FieldText},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NormalizeRequestFields = %+v, want %+v", got, want)
+	}
+}
+
+func TestRequestStatusesAndTransitions(t *testing.T) {
+	statuses := NormalizeRequestStatuses([]RequestStatus{
+		{Label: "On hold", Color: "Amber", To: []string{"Pending", "approved"}},
+		{Label: "Purchasing", Color: "teal", From: []string{"approved"}},
+		{Label: "Approved"},
+		{Label: "on-hold"},
+		{Label: ""},
+	})
+	want := []RequestStatus{
+		{Key: "on_hold", Label: "On hold", Color: "amber", To: []string{"pending", "approved"}},
+		{Key: "purchasing", Label: "Purchasing", Color: "slate", From: []string{"approved"}},
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("NormalizeRequestStatuses = %+v, want %+v", statuses, want)
+	}
+	cases := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"pending", "approved", true},
+		{"pending", "on_hold", true},
+		{"on_hold", "pending", true},
+		{"on_hold", "processing", true},
+		{"on_hold", "declined", false},
+		{"pending", "purchasing", false},
+		{"queued", "purchasing", true},
+		{"purchasing", "declined", true},
+		{"pending", "shipped", false},
+		{"shipped", "pending", true},
+	}
+	for _, c := range cases {
+		if err := CheckStatusTransition(statuses, c.from, c.to); (err == nil) != c.ok {
+			t.Errorf("CheckStatusTransition(%q, %q) = %v, want ok=%v", c.from, c.to, err, c.ok)
+		}
+	}
+}
+
+func TestWebhookTargetsInheritSecretAndTemplate(t *testing.T) {
+	w := WebhookConfig{
+		URL:      "https://a.example",
+		Secret:   "top",
+		Template: "{}",
+		Endpoints: []WebhookEndpoint{
+			{URL: "https://b.example", Secret: "own"},
+			{URL: " https://a.example "},
+			{URL: ""},
+		},
+	}
+	got := w.Targets()
+	want := []WebhookEndpoint{
+		{URL: "https://a.example", Secret: "top", Template: "{}"},
+		{URL: "https://b.example", Secret: "own", Template: "{}"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Targets = %+v, want %+v", got, want)
+	}
+	if got := (WebhookConfig{}).Targets(); len(got) != 0 {
+		t.Fatalf("empty config targets = %+v", got)
+	}
+}
+
+func TestQuietHoursRemaining(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 10, h, m, 0, 0, time.UTC) }
+	overnight := QuietHours{Start: "23:00", End: "07:00"}
+	daytime := QuietHours{Start: "09:30", End: "17:00"}
+	cases := []struct {
+		q    QuietHours
+		now  time.Time
+		want time.Duration
+	}{
+		{overnight, at(23, 30), 7*time.Hour + 30*time.Minute},
+		{overnight, at(6, 0), time.Hour},
+		{overnight, at(7, 0), 0},
+		{overnight, at(12, 0), 0},
+		{daytime, at(9, 30), 7*time.Hour + 30*time.Minute},
+		{daytime, at(9, 29), 0},
+		{QuietHours{Start: "23:00"}, at(23, 30), 0},
+		{QuietHours{Start: "08:00", End: "08:00"}, at(8, 0), 0},
+		{QuietHours{Start: "bogus", End: "07:00"}, at(6, 0), 0},
+	}
+	for _, c := range cases {
+		if got := c.q.Remaining(c.now); got != c.want {
+			t.Errorf("%+v at %s: got %s, want %s", c.q, c.now.Format("15:04"), got, c.want)
+		}
+	}
+}
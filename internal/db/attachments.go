@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// RequestAttachment is a file or link attached to a request. File attachments
+// carry a StorageKey into the attachment store; links carry a URL.
+type RequestAttachment struct {
+	ID          int64     `json:"id"`
+	RequestID   int64     `json:"requestId"`
+	Kind        string    `json:"kind"` // "file" | "link"
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	StorageKey  string    `json:"-"`
+	URL         string    `json:"url,omitempty"`
+	UploadedBy  string    `json:"uploadedBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+const attachmentColumns = `id, request_id, kind, name, content_type, size, storage_key, url, uploaded_by, created_at`
+
+func scanAttachment(row rowScanner) (RequestAttachment, error) {
+	var a RequestAttachment
+	var created string
+	if err := row.Scan(&a.ID, &a.RequestID, &a.Kind, &a.Name, &a.ContentType, &a.Size, &a.StorageKey, &a.URL, &a.UploadedBy, &created); err != nil {
+		return a, err
+	}
+	a.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+	return a, nil
+}
+
+// AddRequestAttachment stores a and returns its new id.
+func (d *DB) AddRequestAttachment(ctx context.Context, a *RequestAttachment) (int64, error) {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	res, err := d.sql.ExecContext(ctx, `
+INSERT INTO request_attachments (request_id, kind, name, content_type, size, storage_key, url, uploaded_by, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.RequestID, a.Kind, a.Name, a.ContentType, a.Size, a.StorageKey, a.URL, a.UploadedBy, a.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	a.ID, err = res.LastInsertId()
+	return a.ID, err
+}
+
+// ListRequestAttachments returns a request's attachments, oldest first.
+func (d *DB) ListRequestAttachments(ctx context.Context, requestID int64) ([]RequestAttachment, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+attachmentColumns+` FROM request_attachments WHERE request_id=? ORDER BY id`, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []RequestAttachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// GetRequestAttachment returns attachment id of request requestID, or
+// sql.ErrNoRows.
+func (d *DB) GetRequestAttachment(ctx context.Context, requestID, id int64) (*RequestAttachment, error) {
+	a, err := scanAttachment(d.sql.QueryRowContext(ctx, `SELECT `+attachmentColumns+` FROM request_attachments WHERE request_id=? AND id=?`, requestID, id))
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteRequestAttachment removes one attachment record. The caller deletes
+// the stored blob.
+func (d *DB) DeleteRequestAttachment(ctx context.Context, requestID, id int64) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM request_attachments WHERE request_id=? AND id=?`, requestID, id)
+	return err
+}
+
+// ListAttachmentStorageKeys returns the storage keys of every file
+// attachment, so blobs can be removed before their requests are deleted.
+func (d *DB) ListAttachmentStorageKeys(ctx context.Context) ([]string, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT storage_key FROM request_attachments WHERE storage_key <> ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
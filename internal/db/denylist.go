@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Denylist entry kinds and actions.
+const (
+	DenyAuthor = "author"
+	DenyTitle  = "title"
+	DenyISBN   = "isbn"
+
+	// DenyBlock refuses matching requests; DenyFlag lets them through but
+	// holds them for an admin.
+	DenyBlock = "block"
+	DenyFlag  = "flag"
+)
+
+// DenylistEntry is an author, title, or identifier that requests are
+// checked against. Note is shown to requesters whose request it blocks.
+type DenylistEntry struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Action    string    `json:"action"`
+	Note      string    `json:"note,omitempty"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Blocks reports whether the entry refuses requests rather than flagging
+// them.
+func (e *DenylistEntry) Blocks() bool { return e.Action != DenyFlag }
+
+// AddDenylistEntry stores e and returns its id. An entry with the same kind
+// and value, ignoring case, is replaced.
+func (d *DB) AddDenylistEntry(ctx context.Context, e *DenylistEntry) (int64, error) {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	_, err := d.sql.ExecContext(ctx, `
+INSERT INTO denylist (kind, value, action, note, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(kind, value COLLATE NOCASE) DO UPDATE SET action=excluded.action, note=excluded.note, created_by=excluded.created_by, created_at=excluded.created_at`,
+		e.Kind, strings.TrimSpace(e.Value), e.Action, strings.TrimSpace(e.Note), strings.ToLower(e.CreatedBy), e.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = d.sql.QueryRowContext(ctx, `SELECT id FROM denylist WHERE kind=? AND value=? COLLATE NOCASE`, e.Kind, strings.TrimSpace(e.Value)).Scan(&id)
+	return id, err
+}
+
+// ListDenylist returns every entry, grouped by kind and sorted by value.
+func (d *DB) ListDenylist(ctx context.Context) ([]DenylistEntry, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT id, kind, value, action, note, created_by, created_at FROM denylist ORDER BY kind, value COLLATE NOCASE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DenylistEntry
+	for rows.Next() {
+		var e DenylistEntry
+		var created string
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Value, &e.Action, &e.Note, &e.CreatedBy, &created); err != nil {
+			return nil, err
+		}
+		e.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// DeleteDenylistEntry removes entry id.
+func (d *DB) DeleteDenylistEntry(ctx context.Context, id int64) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM denylist WHERE id=?`, id)
+	return err
+}
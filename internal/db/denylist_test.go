@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestDenylist(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	id, err := d.AddDenylistEntry(ctx, &DenylistEntry{Kind: DenyAuthor, Value: " Jane Doe ", Action: DenyBlock, CreatedBy: "Admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Re-adding the same value in another case updates the entry.
+	again, err := d.AddDenylistEntry(ctx, &DenylistEntry{Kind: DenyAuthor, Value: "jane doe", Action: DenyFlag, Note: "check first"})
+	if err != nil || again != id {
+		t.Fatalf("re-add = %d, %v (want %d)", again, err, id)
+	}
+	if _, err := d.AddDenylistEntry(ctx, &DenylistEntry{Kind: DenyTitle, Value: "Jane Doe", Action: DenyBlock}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := d.ListDenylist(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if e := list[0]; e.Kind != DenyAuthor || e.Value != "Jane Doe" || e.Blocks() || e.Note != "check first" {
+		t.Fatalf("author entry = %+v", e)
+	}
+	if err := d.DeleteDenylistEntry(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := d.ListDenylist(ctx); len(list) != 1 || list[0].Kind != DenyTitle {
+		t.Fatalf("after delete = %+v", list)
+	}
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// ExpireRequest closes pending request id as status ("declined" or
+// "expired") with reason, marking it as expired so its requester can renew
+// it. It reports false when the request is no longer pending.
+func (d *DB) ExpireRequest(ctx context.Context, id int64, status, reason string) (bool, error) {
+	if err := d.checkStatus(ctx, id, status); err != nil {
+		return false, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET status=?, status_reason=?, expired_at=?, updated_at=?
+WHERE id=? AND status='pending'`, status, reason, now, now, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RenewRequest returns expired request id to pending and restarts its
+// clock for reminders and expiry. It reports false when the request did not
+// expire or has moved on since.
+func (d *DB) RenewRequest(ctx context.Context, id int64) (bool, error) {
+	if err := d.checkStatus(ctx, id, "pending"); err != nil {
+		return false, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET status='pending', status_reason='', expired_at=NULL, renewed_at=?, reminders_sent=0, updated_at=?
+WHERE id=? AND expired_at IS NOT NULL AND status IN ('declined','expired')`, now, now, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpireAndRenewRequest(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err := d.SetRemindersSent(ctx, id, 3); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := d.RenewRequest(ctx, id); err != nil || ok {
+		t.Fatalf("renewing a pending request = %v, %v", ok, err)
+	}
+	if ok, err := d.ExpireRequest(ctx, id, "declined", "expired"); err != nil || !ok {
+		t.Fatalf("expire = %v, %v", ok, err)
+	}
+	if ok, _ := d.ExpireRequest(ctx, id, "declined", "expired"); ok {
+		t.Fatal("expired a request that was no longer pending")
+	}
+	req, _ := d.GetRequest(ctx, id)
+	if req.Status != "declined" || req.StatusReason != "expired" || req.ExpiredAt == nil {
+		t.Fatalf("expired request = %+v", req)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if ok, err := d.RenewRequest(ctx, id); err != nil || !ok {
+		t.Fatalf("renew = %v, %v", ok, err)
+	}
+	req, _ = d.GetRequest(ctx, id)
+	if req.Status != "pending" || req.StatusReason != "" || req.ExpiredAt != nil {
+		t.Fatalf("renewed request = %+v", req)
+	}
+	pending, _ := d.ListPendingReminders(ctx, time.Now())
+	if len(pending) != 1 || pending[0].RemindersSent != 0 || pending[0].CreatedAt.Before(before) {
+		t.Fatalf("renewed request should restart its reminder clock: %+v", pending)
+	}
+	if ok, _ := d.RenewRequest(ctx, id); ok {
+		t.Fatal("renewed a request twice")
+	}
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Guest request states. A guest request stays pending until an admin
+// converts it into a real request or rejects it.
+const (
+	GuestPending   = "pending"
+	GuestConverted = "converted"
+	GuestRejected  = "rejected"
+)
+
+// ErrGuestRequestDecided is returned by DecideGuestRequest when the guest
+// request was already converted or rejected.
+var ErrGuestRequestDecided = errors.New("guest request already decided")
+
+// GuestRequest is a book asked for through the guest link by someone
+// without an account.
+type GuestRequest struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author,omitempty"`
+	ISBN      string    `json:"isbn,omitempty"`
+	Format    string    `json:"format"`
+	Note      string    `json:"note,omitempty"`
+	Status    string    `json:"status"`
+	// RequestID is the request a converted guest request became.
+	RequestID int64     `json:"requestId,omitempty"`
+	DecidedBy string    `json:"decidedBy,omitempty"`
+	DecidedAt time.Time `json:"decidedAt,omitempty"`
+}
+
+const guestRequestColumns = `id, created_at, name, email, title, author, isbn, format, note, status, request_id, decided_by, decided_at`
+
+func scanGuestRequest(row interface{ Scan(...any) error }) (GuestRequest, error) {
+	var g GuestRequest
+	var created, decided string
+	if err := row.Scan(&g.ID, &created, &g.Name, &g.Email, &g.Title, &g.Author, &g.ISBN, &g.Format, &g.Note, &g.Status, &g.RequestID, &g.DecidedBy, &decided); err != nil {
+		return g, err
+	}
+	g.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+	g.DecidedAt, _ = time.Parse(time.RFC3339Nano, decided)
+	return g, nil
+}
+
+// CreateGuestRequest stores g as pending and returns its id.
+func (d *DB) CreateGuestRequest(ctx context.Context, g *GuestRequest) (int64, error) {
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = time.Now().UTC()
+	}
+	res, err := d.sql.ExecContext(ctx, `
+INSERT INTO guest_requests (created_at, name, email, title, author, isbn, format, note, status)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		g.CreatedAt.UTC().Format(time.RFC3339Nano), strings.TrimSpace(g.Name), strings.ToLower(strings.TrimSpace(g.Email)),
+		strings.TrimSpace(g.Title), strings.TrimSpace(g.Author), strings.TrimSpace(g.ISBN), g.Format, strings.TrimSpace(g.Note), GuestPending)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetGuestRequest returns guest request id, or sql.ErrNoRows.
+func (d *DB) GetGuestRequest(ctx context.Context, id int64) (*GuestRequest, error) {
+	g, err := scanGuestRequest(d.sql.QueryRowContext(ctx, `SELECT `+guestRequestColumns+` FROM guest_requests WHERE id=?`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// ListGuestRequests returns up to limit guest requests in status, or in any
+// status when it is empty. Pending ones come oldest first, since they are
+// worked through in order; decided ones newest first.
+func (d *DB) ListGuestRequests(ctx context.Context, status string, limit int) ([]GuestRequest, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	order := "id DESC"
+	if status == GuestPending {
+		order = "id"
+	}
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+guestRequestColumns+` FROM guest_requests
+WHERE (?='' OR status=?) ORDER BY `+order+` LIMIT ?`, status, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []GuestRequest
+	for rows.Next() {
+		g, err := scanGuestRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// CountPendingGuestRequests returns how many guest requests await review.
+func (d *DB) CountPendingGuestRequests(ctx context.Context) (int, error) {
+	var n int
+	err := d.sql.QueryRowContext(ctx, `SELECT COUNT(1) FROM guest_requests WHERE status=?`, GuestPending).Scan(&n)
+	return n, err
+}
+
+// DecideGuestRequest moves pending guest request id to status, recording
+// actor and, for a conversion, the request it became. It returns
+// sql.ErrNoRows for an unknown id and ErrGuestRequestDecided when the
+// guest request is no longer pending.
+func (d *DB) DecideGuestRequest(ctx context.Context, id int64, status, actor string, requestID int64) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.ExecContext(ctx, `
+UPDATE guest_requests SET status=?, request_id=?, decided_by=?, decided_at=?
+WHERE id=? AND status=?`, status, requestID, strings.ToLower(actor), now, id, GuestPending)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	if _, err := d.GetGuestRequest(ctx, id); errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return ErrGuestRequestDecided
+}
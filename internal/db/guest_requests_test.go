@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestGuestRequests(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	first, err := d.CreateGuestRequest(ctx, &GuestRequest{Name: " Ann ", Email: "Ann@Example.com", Title: "Dune", Format: "ebook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := d.CreateGuestRequest(ctx, &GuestRequest{Name: "Bo", Title: "Emma", Format: "audiobook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.CountPendingGuestRequests(ctx); err != nil || n != 2 {
+		t.Fatalf("pending = %d, %v", n, err)
+	}
+	pending, err := d.ListGuestRequests(ctx, GuestPending, 0)
+	if err != nil || len(pending) != 2 || pending[0].ID != first {
+		t.Fatalf("pending list = %+v, %v (want oldest first)", pending, err)
+	}
+	if g := pending[0]; g.Name != "Ann" || g.Email != "ann@example.com" || g.Status != GuestPending {
+		t.Fatalf("stored guest request = %+v", g)
+	}
+
+	if err := d.DecideGuestRequest(ctx, first, GuestConverted, "Admin", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.DecideGuestRequest(ctx, first, GuestRejected, "admin", 0); !errors.Is(err, ErrGuestRequestDecided) {
+		t.Fatalf("second decision = %v, want ErrGuestRequestDecided", err)
+	}
+	if err := d.DecideGuestRequest(ctx, 999, GuestRejected, "admin", 0); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unknown id = %v, want sql.ErrNoRows", err)
+	}
+	g, err := d.GetGuestRequest(ctx, first)
+	if err != nil || g.Status != GuestConverted || g.RequestID != 42 || g.DecidedBy != "admin" || g.DecidedAt.IsZero() {
+		t.Fatalf("converted = %+v, %v", g, err)
+	}
+	if all, _ := d.ListGuestRequests(ctx, "", 0); len(all) != 2 || all[0].ID != second {
+		t.Fatalf("all = %+v (want newest first)", all)
+	}
+	if n, _ := d.CountPendingGuestRequests(ctx); n != 1 {
+		t.Fatalf("pending after convert = %d", n)
+	}
+}
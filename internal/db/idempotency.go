@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// IdempotencyRecord is a stored response for a client-supplied
+// Idempotency-Key. StatusCode is 0 while the original request is in flight.
+type IdempotencyRecord struct {
+	Fingerprint string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// ReserveIdempotencyKey claims key for username. It returns (nil, nil) when
+// the key was free and is now reserved, or the existing record otherwise.
+func (d *DB) ReserveIdempotencyKey(ctx context.Context, username, key, fingerprint string) (*IdempotencyRecord, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.ExecContext(ctx, `INSERT OR IGNORE INTO idempotency_keys(username, idem_key, fingerprint, created_at) VALUES(?,?,?,?)`,
+		username, key, fingerprint, now)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+	var rec IdempotencyRecord
+	var headers, created string
+	err = d.sql.QueryRowContext(ctx, `SELECT fingerprint, status_code, headers, body, created_at FROM idempotency_keys WHERE username=? AND idem_key=?`,
+		username, key).Scan(&rec.Fingerprint, &rec.StatusCode, &headers, &rec.Body, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Pruned or released between the insert and the read; try again.
+		return d.ReserveIdempotencyKey(ctx, username, key, fingerprint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(headers), &rec.Headers)
+	rec.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+	return &rec, nil
+}
+
+// CompleteIdempotencyKey stores the response for a reserved key.
+func (d *DB) CompleteIdempotencyKey(ctx context.Context, username, key string, status int, headers map[string]string, body []byte) error {
+	hb, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	_, err = d.sql.ExecContext(ctx, `UPDATE idempotency_keys SET status_code=?, headers=?, body=? WHERE username=? AND idem_key=?`,
+		status, string(hb), body, username, key)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a reservation so the client may retry, used
+// when the original request failed before creating anything.
+func (d *DB) ReleaseIdempotencyKey(ctx context.Context, username, key string) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE username=? AND idem_key=?`, username, key)
+	return err
+}
+
+// PruneIdempotencyKeys deletes keys created before cutoff.
+func (d *DB) PruneIdempotencyKeys(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := d.sql.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, cutoff.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+// LabelCount is a label in use and how many requests carry it.
+type LabelCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// SetRequestLabels replaces the labels on request id. Labels differing only
+// in case are stored once, keeping the first spelling.
+func (d *DB) SetRequestLabels(ctx context.Context, id int64, labels []string) error {
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM request_labels WHERE request_id=?`, id); err != nil {
+		return err
+	}
+	for _, l := range labels {
+		if l = strings.TrimSpace(l); l == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO request_labels (request_id, label) VALUES (?, ?)`, id, l); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetRequestLabels returns the labels on request id in alphabetical order.
+func (d *DB) GetRequestLabels(ctx context.Context, id int64) ([]string, error) {
+	byID, err := d.requestLabels(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	return byID[id], nil
+}
+
+// ListLabels returns every label in use with its request count, in
+// alphabetical order.
+func (d *DB) ListLabels(ctx context.Context) ([]LabelCount, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT MIN(label), COUNT(1) FROM request_labels GROUP BY label ORDER BY label`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LabelCount
+	for rows.Next() {
+		var lc LabelCount
+		if err := rows.Scan(&lc.Label, &lc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, lc)
+	}
+	return out, rows.Err()
+}
+
+// requestLabels loads the labels for several requests at once.
+func (d *DB) requestLabels(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	out := map[int64][]string{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := d.sql.QueryContext(ctx, `SELECT request_id, label FROM request_labels WHERE request_id IN (?`+strings.Repeat(",?", len(ids)-1)+`) ORDER BY label`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var label string
+		if err := rows.Scan(&id, &label); err != nil {
+			return nil, err
+		}
+		out[id] = append(out[id], label)
+	}
+	return out, rows.Err()
+}
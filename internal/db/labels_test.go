@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequestLabels(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", Format: "ebook", Status: "pending"})
+	b, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "bob", Title: "Emma", Format: "ebook", Status: "pending"})
+
+	if err := d.SetRequestLabels(ctx, a, []string{"Book Club", "gift", "book club", " "}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetRequestLabels(ctx, b, []string{"gift"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := d.GetRequestLabels(ctx, a)
+	if err != nil || strings.Join(got, "|") != "Book Club|gift" {
+		t.Fatalf("labels = %q, %v", got, err)
+	}
+
+	counts, err := d.ListLabels(ctx)
+	if err != nil || len(counts) != 2 || counts[0] != (LabelCount{"Book Club", 1}) || counts[1] != (LabelCount{"gift", 2}) {
+		t.Fatalf("counts = %+v, %v", counts, err)
+	}
+
+	page, err := d.ListRequestsPageScoped(ctx, RequestScope{Label: "GIFT"}, 10)
+	if err != nil || len(page) != 2 || len(page[1].Labels) != 2 {
+		t.Fatalf("filtered page = %+v, %v", page, err)
+	}
+	page, _ = d.ListRequestsPageScoped(ctx, RequestScope{Label: "book club", Requester: "bob"}, 10)
+	if len(page) != 0 {
+		t.Fatalf("label and requester should both filter: %+v", page)
+	}
+
+	if err := d.SetRequestLabels(ctx, a, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.GetRequestLabels(ctx, a); len(got) != 0 {
+		t.Fatalf("labels not cleared: %q", got)
+	}
+	if err := d.DeleteRequest(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if counts, _ := d.ListLabels(ctx); len(counts) != 0 {
+		t.Fatalf("labels should go with the request: %+v", counts)
+	}
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LibraryItem is a book on a library server (e.g. Kavita), cached so search
+// results can show it as available without asking the server.
+type LibraryItem struct {
+	SourceKind string    `json:"sourceKind"`
+	Server     string    `json:"server"`
+	ItemID     string    `json:"itemId"`
+	Title      string    `json:"title"`
+	AuthorName string    `json:"authorName"`
+	Path       string    `json:"path"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+var libraryTitleJunk = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// LibraryTitleKey folds case and punctuation so "The Hobbit: or There and
+// Back Again" and "the hobbit or there and back again" match.
+func LibraryTitleKey(title string) string {
+	return strings.TrimSpace(libraryTitleJunk.ReplaceAllString(strings.ToLower(title), " "))
+}
+
+// ReplaceLibraryItems swaps the cached items of sourceKind for items.
+func (d *DB) ReplaceLibraryItems(ctx context.Context, sourceKind string, items []LibraryItem) error {
+	kind := strings.ToLower(strings.TrimSpace(sourceKind))
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM library_items WHERE source_kind=?`, kind); err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+INSERT INTO library_items (source_kind, server, item_id, title, title_key, author_name, path, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, it := range items {
+		key := LibraryTitleKey(it.Title)
+		if key == "" || strings.TrimSpace(it.Path) == "" {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, kind, it.Server, it.ItemID, strings.TrimSpace(it.Title), key, strings.TrimSpace(it.AuthorName), it.Path, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ClearLibraryItems drops the cached items of sourceKind.
+func (d *DB) ClearLibraryItems(ctx context.Context, sourceKind string) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM library_items WHERE source_kind=?`, strings.ToLower(strings.TrimSpace(sourceKind)))
+	return err
+}
+
+// CountLibraryItems reports how many items of sourceKind are cached.
+func (d *DB) CountLibraryItems(ctx context.Context, sourceKind string) (int, error) {
+	var n int
+	err := d.sql.QueryRowContext(ctx, `SELECT COUNT(1) FROM library_items WHERE source_kind=?`, strings.ToLower(strings.TrimSpace(sourceKind))).Scan(&n)
+	return n, err
+}
+
+// FindLibraryItem returns the cached item whose title matches title. Items
+// that record an author must also share one with authors. It returns
+// sql.ErrNoRows when nothing matches.
+func (d *DB) FindLibraryItem(ctx context.Context, sourceKind, title string, authors []string) (*LibraryItem, error) {
+	key := LibraryTitleKey(title)
+	if key == "" {
+		return nil, sql.ErrNoRows
+	}
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT source_kind, server, item_id, title, author_name, path, updated_at
+FROM library_items WHERE source_kind=? AND title_key=? ORDER BY id`, strings.ToLower(strings.TrimSpace(sourceKind)), key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var it LibraryItem
+		var updated string
+		if err := rows.Scan(&it.SourceKind, &it.Server, &it.ItemID, &it.Title, &it.AuthorName, &it.Path, &updated); err != nil {
+			return nil, err
+		}
+		it.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated)
+		if libraryAuthorMatches(it.AuthorName, authors) {
+			return &it, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return nil, sql.ErrNoRows
+}
+
+func libraryAuthorMatches(got string, authors []string) bool {
+	got = strings.ToLower(strings.TrimSpace(got))
+	if got == "" || len(authors) == 0 {
+		return true
+	}
+	for _, a := range authors {
+		if a = strings.ToLower(strings.TrimSpace(a)); a != "" && strings.Contains(got, a) {
+			return true
+		}
+	}
+	return false
+}
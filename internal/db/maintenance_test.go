@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOptimizeShrinksAfterDeletes(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	blob := strings.Repeat("x", 64*1024)
+	for i := 0; i < 40; i++ {
+		if err := d.Exec(ctx, `INSERT INTO readarr_cache (cache_key, cache_type, data) VALUES (?, 'test', ?)`, "k"+string(rune('A'+i)), blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Exec(ctx, `DELETE FROM readarr_cache`); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := d.Optimize(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BeforeBytes < 40*64*1024 || res.AfterBytes >= res.BeforeBytes/4 {
+		t.Fatalf("expected the file to shrink: %+v", res)
+	}
+	if res.AfterBytes != d.FileSize() || res.StartedAt.IsZero() {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
@@ -6,7 +6,15 @@ import (
 	"fmt"
 )
 
-const schemaVersion = 3
+// schemaVersion is the PRAGMA user_version Migrate leaves behind. Every
+// schema change below bumps it. The list only covers the most recent bumps;
+// git log -p on this file has the earlier ones:
+//
+//	33: users.max_pending
+//	34: users.disabled
+//	35: cache_stats and provider_calls tables
+//	36: requests.source
+const schemaVersion = 36
 
 func (d *DB) Migrate(ctx context.Context) error {
 	if err := d.Exec(ctx, `
@@ -41,6 +49,80 @@ CREATE TABLE IF NOT EXISTS requests (
 	if err := d.ensureRequestColumn(ctx, "cover_url", "TEXT"); err != nil {
 		return err
 	}
+	if err := d.ensureRequestColumn(ctx, "group_name", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "linked_request_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "reminders_sent", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "extra_fields", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "needs_review", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "readarr_instance", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "first_approver", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "first_approved_at", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "purchase_cents", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "purchase_source", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "purchased_at", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "need_by", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "asin", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "foreign_book_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	// admin_note is for admins coordinating a request. It is left out of
+	// requestColumns so it never reaches requesters with the request.
+	if err := d.ensureRequestColumn(ctx, "admin_note", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "admin_note_by", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "admin_note_at", "TEXT"); err != nil {
+		return err
+	}
+	// renewed_at restarts the pending clock for reminders and expiry when a
+	// requester renews an expired request.
+	if err := d.ensureRequestColumn(ctx, "renewed_at", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.ensureRequestColumn(ctx, "expired_at", "TEXT"); err != nil {
+		return err
+	}
+	// candidate_request keeps the selection payload as stored at request
+	// time; readarr_request is replaced by the payload actually sent.
+	if err := d.ensureRequestColumn(ctx, "candidate_request", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.Exec(ctx, `UPDATE requests SET candidate_request=readarr_request WHERE candidate_request IS NULL AND status='pending'`); err != nil {
+		return err
+	}
+	// source is the channel a request arrived through (web, api, bulk,
+	// ...); requests made before it was tracked keep ''.
+	if err := d.ensureRequestColumn(ctx, "source", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
 
 	if err := d.Exec(ctx, `
 CREATE TABLE IF NOT EXISTS audit_events (
@@ -78,6 +160,10 @@ CREATE TABLE IF NOT EXISTS users (
 		{"notify_webhook_url", "TEXT"},
 		{"notify_on_approved", "INTEGER NOT NULL DEFAULT 0"},
 		{"notify_on_available", "INTEGER NOT NULL DEFAULT 0"},
+		{"group_name", "TEXT NOT NULL DEFAULT ''"},
+		{"max_pending", "INTEGER NOT NULL DEFAULT 0"},
+		{"disabled", "INTEGER NOT NULL DEFAULT 0"},
+		{"search_providers", "TEXT NOT NULL DEFAULT ''"},
 	} {
 		if err := d.ensureUserColumn(ctx, col.name, col.def); err != nil {
 			return err
@@ -102,12 +188,26 @@ CREATE TABLE IF NOT EXISTS readarr_authors (
   id INTEGER PRIMARY KEY,
   name TEXT NOT NULL,
   readarr_id INTEGER,
+  foreign_author_id TEXT NOT NULL DEFAULT '',
+  base_url TEXT NOT NULL DEFAULT '',
   created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
   updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );`); err != nil {
 		return err
 	}
 
+	if err := d.ensureTableColumn(ctx, "readarr_authors", "foreign_author_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.ensureTableColumn(ctx, "readarr_authors", "base_url", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	// Rows from before the cache was scoped per Readarr instance mix ids from
+	// every instance under one name; drop them and let lookups refill it.
+	if err := d.Exec(ctx, `DELETE FROM readarr_authors WHERE base_url = ''`); err != nil {
+		return err
+	}
+
 	if err := d.Exec(ctx, `
 CREATE TABLE IF NOT EXISTS readarr_books (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -157,6 +257,207 @@ CREATE TABLE IF NOT EXISTS readarr_books (
 		return err
 	}
 
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  username TEXT NOT NULL,
+  idem_key TEXT NOT NULL,
+  fingerprint TEXT NOT NULL,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  headers TEXT NOT NULL DEFAULT '{}',
+  body BLOB,
+  created_at DATETIME NOT NULL,
+  PRIMARY KEY (username, idem_key)
+);`); err != nil {
+		return err
+	}
+
+	// Revocable read-only links to one user's wishlist.
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS wishlist_shares (
+  token TEXT PRIMARY KEY,
+  username TEXT NOT NULL,
+  created_at TEXT NOT NULL
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS request_attachments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  request_id INTEGER NOT NULL,
+  kind TEXT NOT NULL,
+  name TEXT NOT NULL DEFAULT '',
+  content_type TEXT NOT NULL DEFAULT '',
+  size INTEGER NOT NULL DEFAULT 0,
+  storage_key TEXT NOT NULL DEFAULT '',
+  url TEXT NOT NULL DEFAULT '',
+  uploaded_by TEXT NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS request_labels (
+  request_id INTEGER NOT NULL,
+  label TEXT NOT NULL COLLATE NOCASE,
+  PRIMARY KEY (request_id, label)
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS notification_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  event TEXT NOT NULL,
+  provider TEXT NOT NULL,
+  target TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  attempts INTEGER NOT NULL DEFAULT 0
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS library_items (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  source_kind TEXT NOT NULL,
+  server TEXT NOT NULL,
+  item_id TEXT NOT NULL,
+  title TEXT NOT NULL,
+  title_key TEXT NOT NULL,
+  author_name TEXT NOT NULL DEFAULT '',
+  path TEXT NOT NULL,
+  updated_at TEXT NOT NULL
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS sessions (
+  id TEXT PRIMARY KEY,
+  username TEXT NOT NULL,
+  created_at DATETIME NOT NULL,
+  last_seen_at DATETIME NOT NULL,
+  expires_at DATETIME NOT NULL,
+  remember INTEGER NOT NULL DEFAULT 0,
+  user_agent TEXT NOT NULL DEFAULT ''
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS subscriptions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME NOT NULL,
+  requester_email TEXT NOT NULL,
+  title TEXT NOT NULL,
+  publisher TEXT NOT NULL DEFAULT '',
+  frequency TEXT NOT NULL,
+  group_name TEXT NOT NULL DEFAULT '',
+  active INTEGER NOT NULL DEFAULT 1,
+  next_issue_at TEXT NOT NULL DEFAULT '',
+  last_issue_at TEXT NOT NULL DEFAULT '',
+  issue_count INTEGER NOT NULL DEFAULT 0,
+  last_request_id INTEGER NOT NULL DEFAULT 0
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS request_authors (
+  request_id INTEGER PRIMARY KEY,
+  base_url TEXT NOT NULL,
+  readarr_author_id INTEGER NOT NULL,
+  foreign_author_id TEXT NOT NULL DEFAULT '',
+  name TEXT NOT NULL DEFAULT '',
+  created INTEGER NOT NULL DEFAULT 0,
+  removed_at TEXT NOT NULL DEFAULT '',
+  recorded_at TEXT NOT NULL
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS denylist (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  kind TEXT NOT NULL,
+  value TEXT NOT NULL,
+  action TEXT NOT NULL DEFAULT 'block',
+  note TEXT NOT NULL DEFAULT '',
+  created_by TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL,
+  UNIQUE (kind, value COLLATE NOCASE)
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  username TEXT NOT NULL,
+  endpoint TEXT NOT NULL UNIQUE,
+  p256dh TEXT NOT NULL,
+  auth TEXT NOT NULL,
+  user_agent TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS request_watchers (
+  request_id INTEGER NOT NULL,
+  username TEXT NOT NULL,
+  PRIMARY KEY (request_id, username)
+);`); err != nil {
+		return err
+	}
+
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS guest_requests (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  created_at TEXT NOT NULL,
+  name TEXT NOT NULL,
+  email TEXT NOT NULL DEFAULT '',
+  title TEXT NOT NULL,
+  author TEXT NOT NULL DEFAULT '',
+  isbn TEXT NOT NULL DEFAULT '',
+  format TEXT NOT NULL DEFAULT 'ebook',
+  note TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending',
+  request_id INTEGER NOT NULL DEFAULT 0,
+  decided_by TEXT NOT NULL DEFAULT '',
+  decided_at TEXT NOT NULL DEFAULT ''
+);`); err != nil {
+		return err
+	}
+
+	// Daily usage counters: cache hits and misses per readarr_cache type,
+	// and outbound calls per provider.
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS cache_stats (
+  day TEXT NOT NULL,
+  cache_type TEXT NOT NULL,
+  hits INTEGER NOT NULL DEFAULT 0,
+  misses INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (day, cache_type)
+);`); err != nil {
+		return err
+	}
+	if err := d.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS provider_calls (
+  day TEXT NOT NULL,
+  provider TEXT NOT NULL,
+  calls INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (day, provider)
+);`); err != nil {
+		return err
+	}
+
 	if err := d.ensureIndexes(ctx); err != nil {
 		return err
 	}
@@ -171,7 +472,21 @@ func (d *DB) ensureIndexes(ctx context.Context) error {
 	indexes := []string{
 		`CREATE INDEX IF NOT EXISTS idx_requests_requester_email_id ON requests(requester_email, id DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_requests_status ON requests(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_requests_group_name_id ON requests(group_name, id DESC)`,
+		// Lets list views tell which requests have a selection payload
+		// without reading the payload blobs themselves.
+		`CREATE INDEX IF NOT EXISTS idx_requests_has_payload ON requests(id) WHERE readarr_request IS NOT NULL AND TRIM(readarr_request) <> ''`,
+		`CREATE INDEX IF NOT EXISTS idx_request_attachments_request_id ON request_attachments(request_id, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_request_labels_label ON request_labels(label)`,
+		`CREATE INDEX IF NOT EXISTS idx_request_authors_author ON request_authors(base_url, readarr_author_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_username ON sessions(username)`,
+		`CREATE INDEX IF NOT EXISTS idx_push_subscriptions_username ON push_subscriptions(username)`,
+		`CREATE INDEX IF NOT EXISTS idx_guest_requests_status ON guest_requests(status, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_subscriptions_requester_email ON subscriptions(requester_email, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_readarr_authors_base_url_foreign_author_id ON readarr_authors(base_url, foreign_author_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_readarr_authors_base_url_name ON readarr_authors(base_url, name)`,
 		`CREATE INDEX IF NOT EXISTS idx_readarr_books_source_kind_readarr_id ON readarr_books(source_kind, readarr_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_library_items_source_kind_title_key ON library_items(source_kind, title_key)`,
 		`CREATE INDEX IF NOT EXISTS idx_readarr_books_source_kind_foreign_edition_id ON readarr_books(source_kind, foreign_edition_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_readarr_books_source_kind_foreign_book_id ON readarr_books(source_kind, foreign_book_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_readarr_books_source_kind_isbn13 ON readarr_books(source_kind, isbn13)`,
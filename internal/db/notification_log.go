@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Notification delivery statuses.
+const (
+	NotifyPending  = "pending"
+	NotifySent     = "sent"
+	NotifyRetrying = "retrying"
+	NotifyFailed   = "failed"
+	// NotifyHeld deliveries wait for the provider's quiet hours to end.
+	NotifyHeld = "held"
+)
+
+// NotificationLogEntry records one notification sent to one provider.
+// Attempts counts sends so far; Error is the last failure, if any.
+type NotificationLogEntry struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Event     string    `json:"event"`
+	Provider  string    `json:"provider"`
+	Target    string    `json:"target,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts"`
+}
+
+// CreateNotificationLog records a pending delivery and returns its id.
+func (d *DB) CreateNotificationLog(ctx context.Context, event, provider, target string) (int64, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.ExecContext(ctx, `
+INSERT INTO notification_log (created_at, updated_at, event, provider, target, status)
+VALUES (?, ?, ?, ?, ?, ?)`, now, now, event, provider, target, NotifyPending)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateNotificationLog records the outcome of a delivery attempt.
+func (d *DB) UpdateNotificationLog(ctx context.Context, id int64, status, errText string, attempts int) error {
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE notification_log SET status=?, error=?, attempts=?, updated_at=? WHERE id=?`,
+		status, errText, attempts, time.Now().UTC().Format(time.RFC3339Nano), id)
+	return err
+}
+
+// ListNotificationLog returns the most recent deliveries, newest first.
+func (d *DB) ListNotificationLog(ctx context.Context, limit int) ([]NotificationLogEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT id, created_at, updated_at, event, provider, target, status, error, attempts
+FROM notification_log ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []NotificationLogEntry
+	for rows.Next() {
+		var e NotificationLogEntry
+		var created, updated string
+		if err := rows.Scan(&e.ID, &created, &updated, &e.Event, &e.Provider, &e.Target, &e.Status, &e.Error, &e.Attempts); err != nil {
+			return nil, err
+		}
+		e.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+		e.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// AbandonNotificationLog marks deliveries still pending, awaiting a retry,
+// or held for quiet hours as failed. Retries and held deliveries live in
+// memory, so after a restart nothing will finish them.
+func (d *DB) AbandonNotificationLog(ctx context.Context, reason string) error {
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE notification_log SET status=?, error=CASE WHEN error='' THEN ? ELSE error || ' (' || ? || ')' END, updated_at=?
+WHERE status IN (?, ?, ?)`,
+		NotifyFailed, reason, reason, time.Now().UTC().Format(time.RFC3339Nano), NotifyPending, NotifyRetrying, NotifyHeld)
+	return err
+}
+
+// PruneNotificationLog deletes deliveries created before cutoff.
+func (d *DB) PruneNotificationLog(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := d.sql.ExecContext(ctx, `DELETE FROM notification_log WHERE created_at < ?`, cutoff.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNotificationLogLifecycle(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sent, _ := d.CreateNotificationLog(ctx, "request.created", "discord", "")
+	retrying, _ := d.CreateNotificationLog(ctx, "request.approved", "ntfy", "user:bob")
+	if err := d.UpdateNotificationLog(ctx, sent, NotifySent, "", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UpdateNotificationLog(ctx, retrying, NotifyRetrying, "ntfy server returned error: 503", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AbandonNotificationLog(ctx, "interrupted by restart"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.ListNotificationLog(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != retrying || got[0].Status != NotifyFailed || got[0].Error != "ntfy server returned error: 503 (interrupted by restart)" || got[0].Target != "user:bob" {
+		t.Fatalf("log = %+v", got)
+	}
+	if got[1].Status != NotifySent || got[1].Attempts != 1 {
+		t.Fatalf("sent entry = %+v", got[1])
+	}
+
+	if n, err := d.PruneNotificationLog(ctx, time.Now().Add(time.Minute)); err != nil || n != 2 {
+		t.Fatalf("prune n=%d err=%v", n, err)
+	}
+}
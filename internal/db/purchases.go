@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// SpendRow is what was spent on requests in one month for one requester
+// and format.
+type SpendRow struct {
+	Month     string `json:"month"` // YYYY-MM
+	Requester string `json:"requester"`
+	Format    string `json:"format"`
+	Cents     int64  `json:"cents"`
+	Count     int    `json:"count"`
+}
+
+// SetRequestPurchase records what request id cost and where it was bought.
+// A zero cost with no source clears the purchase.
+func (d *DB) SetRequestPurchase(ctx context.Context, id, cents int64, source string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	source = strings.TrimSpace(source)
+	if cents == 0 && source == "" {
+		_, err := d.sql.ExecContext(ctx, `UPDATE requests SET purchase_cents=0, purchase_source='', purchased_at=NULL, updated_at=? WHERE id=?`, now, id)
+		return err
+	}
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET purchase_cents=?, purchase_source=?, purchased_at=COALESCE(purchased_at, ?), updated_at=?
+WHERE id=?`, cents, source, now, now, id)
+	return err
+}
+
+// SpendByMonth totals recorded purchases made since since, per month,
+// requester, and format, newest month first.
+func (d *DB) SpendByMonth(ctx context.Context, since time.Time) ([]SpendRow, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT substr(purchased_at, 1, 7) AS month, requester_email, format, SUM(purchase_cents), COUNT(1)
+FROM requests
+WHERE purchased_at IS NOT NULL AND purchased_at >= ?
+GROUP BY month, requester_email, format
+ORDER BY month DESC, requester_email, format`, since.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SpendRow
+	for rows.Next() {
+		var r SpendRow
+		if err := rows.Scan(&r.Month, &r.Requester, &r.Format, &r.Cents, &r.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
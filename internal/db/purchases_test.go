@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRequestPurchasesAndSpend(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", Format: "audiobook", Status: "pending"})
+	b, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Emma", Format: "ebook", Status: "pending"})
+	c, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "bob", Title: "Ulysses", Format: "audiobook", Status: "pending"})
+
+	for id, cents := range map[int64]int64{a: 2499, b: 999, c: 1500} {
+		if err := d.SetRequestPurchase(ctx, id, cents, "Audible"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, _ := d.GetRequest(ctx, a)
+	if got.PurchaseCents != 2499 || got.PurchaseSource != "Audible" || got.PurchasedAt == nil {
+		t.Fatalf("purchase = %+v", got)
+	}
+
+	if err := d.SetRequestPurchase(ctx, b, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.GetRequest(ctx, b); got.PurchasedAt != nil || got.PurchaseCents != 0 {
+		t.Fatalf("cleared purchase = %+v", got)
+	}
+
+	rows, err := d.SpendByMonth(ctx, time.Now().AddDate(0, -1, 0))
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("spend = %+v, %v", rows, err)
+	}
+	month := time.Now().UTC().Format("2006-01")
+	if rows[0] != (SpendRow{month, "amy", "audiobook", 2499, 1}) || rows[1] != (SpendRow{month, "bob", "audiobook", 1500, 1}) {
+		t.Fatalf("spend = %+v", rows)
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// PushSubscription is one browser or installed app a user allowed to show
+// Web Push notifications. Endpoint is the push service URL; P256DH and
+// Auth are the browser's base64url encryption keys.
+type PushSubscription struct {
+	ID        int64
+	Username  string
+	Endpoint  string
+	P256DH    string
+	Auth      string
+	UserAgent string
+	Created   time.Time
+}
+
+// SavePushSubscription records a subscription, moving it to username if
+// the browser was subscribed for someone else before.
+func (d *DB) SavePushSubscription(ctx context.Context, sub PushSubscription) (int64, error) {
+	if _, err := d.sql.ExecContext(ctx, `
+INSERT INTO push_subscriptions (username, endpoint, p256dh, auth, user_agent, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(endpoint) DO UPDATE SET username=excluded.username, p256dh=excluded.p256dh, auth=excluded.auth, user_agent=excluded.user_agent`,
+		strings.ToLower(sub.Username), sub.Endpoint, sub.P256DH, sub.Auth, sub.UserAgent, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := d.sql.QueryRowContext(ctx, `SELECT id FROM push_subscriptions WHERE endpoint=?`, sub.Endpoint).Scan(&id)
+	return id, err
+}
+
+// ListPushSubscriptions returns username's subscriptions, newest first.
+func (d *DB) ListPushSubscriptions(ctx context.Context, username string) ([]PushSubscription, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT id, username, endpoint, p256dh, auth, user_agent, created_at
+FROM push_subscriptions WHERE username=? ORDER BY id DESC`, strings.ToLower(username))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PushSubscription
+	for rows.Next() {
+		var s PushSubscription
+		var created string
+		if err := rows.Scan(&s.ID, &s.Username, &s.Endpoint, &s.P256DH, &s.Auth, &s.UserAgent, &created); err != nil {
+			return nil, err
+		}
+		s.Created, _ = time.Parse(time.RFC3339Nano, created)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DeletePushSubscription removes one of username's subscriptions.
+func (d *DB) DeletePushSubscription(ctx context.Context, username string, id int64) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE id=? AND username=?`, id, strings.ToLower(username))
+	return err
+}
+
+// DeletePushEndpoint forgets a subscription the push service reported gone,
+// or one the browser unsubscribed.
+func (d *DB) DeletePushEndpoint(ctx context.Context, endpoint string) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE endpoint=?`, endpoint)
+	return err
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPushSubscriptions(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sub := PushSubscription{Username: "Alice", Endpoint: "https://push.example.com/1", P256DH: "key", Auth: "auth", UserAgent: "Phone"}
+	id, err := d.SavePushSubscription(ctx, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same browser signing in as someone else moves the subscription.
+	sub.Username = "bob"
+	if again, err := d.SavePushSubscription(ctx, sub); err != nil || again != id {
+		t.Fatalf("resubscribe = %d, %v (want %d)", again, err, id)
+	}
+	if subs, _ := d.ListPushSubscriptions(ctx, "alice"); len(subs) != 0 {
+		t.Fatalf("alice still has %+v", subs)
+	}
+	subs, err := d.ListPushSubscriptions(ctx, "BOB")
+	if err != nil || len(subs) != 1 || subs[0].Endpoint != sub.Endpoint || subs[0].UserAgent != "Phone" || subs[0].Created.IsZero() {
+		t.Fatalf("bob's subscriptions = %+v, %v", subs, err)
+	}
+	if err := d.DeletePushSubscription(ctx, "alice", id); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := d.ListPushSubscriptions(ctx, "bob"); len(subs) != 1 {
+		t.Fatal("another user deleted bob's subscription")
+	}
+	if err := d.DeletePushEndpoint(ctx, sub.Endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := d.ListPushSubscriptions(ctx, "bob"); len(subs) != 0 {
+		t.Fatalf("after delete: %+v", subs)
+	}
+}
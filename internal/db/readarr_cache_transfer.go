@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReadarrAuthor is one row of the Readarr author cache: the id Readarr gave
+// an author on the instance at BaseURL.
+type ReadarrAuthor struct {
+	Name            string    `json:"name"`
+	ReadarrID       int64     `json:"readarrId"`
+	ForeignAuthorID string    `json:"foreignAuthorId"`
+	BaseURL         string    `json:"baseUrl"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// ReadarrCache is the author and book cache of every Readarr instance, as
+// moved between installs.
+type ReadarrCache struct {
+	Authors []ReadarrAuthor `json:"authors"`
+	Books   []ReadarrBook   `json:"books"`
+}
+
+// ExportReadarrCache reads the whole author and book cache.
+func (d *DB) ExportReadarrCache(ctx context.Context) (*ReadarrCache, error) {
+	out := &ReadarrCache{Authors: []ReadarrAuthor{}, Books: []ReadarrBook{}}
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT name, COALESCE(readarr_id, 0), foreign_author_id, base_url, COALESCE(updated_at, '')
+FROM readarr_authors
+WHERE readarr_id IS NOT NULL
+ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a ReadarrAuthor
+		var updated string
+		if err := rows.Scan(&a.Name, &a.ReadarrID, &a.ForeignAuthorID, &a.BaseURL, &updated); err != nil {
+			return nil, err
+		}
+		a.UpdatedAt = parseCacheTime(updated)
+		out.Authors = append(out.Authors, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	books, err := d.sql.QueryContext(ctx, `
+SELECT id, source_kind, readarr_id, title, COALESCE(author_name, ''), COALESCE(isbn10, ''), COALESCE(isbn13, ''), COALESCE(asin, ''), COALESCE(foreign_book_id, ''), COALESCE(foreign_edition_id, ''), monitored, grabbed, book_file_count, readarr_data, COALESCE(created_at, ''), COALESCE(updated_at, '')
+FROM readarr_books
+ORDER BY source_kind, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer books.Close()
+	for books.Next() {
+		book, err := scanReadarrBook(books)
+		if err != nil {
+			return nil, err
+		}
+		out.Books = append(out.Books, *book)
+	}
+	return out, books.Err()
+}
+
+// ImportReadarrCache loads an exported cache in one transaction. Each
+// source kind with books in c has its catalog replaced; authors are merged,
+// an imported author replacing the cached one with the same instance and
+// foreign id (or name, when it has none). It returns how many authors and
+// books were written.
+func (d *DB) ImportReadarrCache(ctx context.Context, c *ReadarrCache) (int, int, error) {
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	authors := 0
+	for _, a := range c.Authors {
+		scope := strings.TrimRight(strings.TrimSpace(a.BaseURL), "/")
+		name := strings.ToLower(strings.TrimSpace(a.Name))
+		fid := strings.TrimSpace(a.ForeignAuthorID)
+		if scope == "" || name == "" || a.ReadarrID <= 0 {
+			continue
+		}
+		if fid != "" {
+			_, err = tx.ExecContext(ctx, `DELETE FROM readarr_authors WHERE base_url = ? AND foreign_author_id = ?`, scope, fid)
+		} else {
+			_, err = tx.ExecContext(ctx, `DELETE FROM readarr_authors WHERE base_url = ? AND name = ? AND foreign_author_id = ''`, scope, name)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		updated := a.UpdatedAt
+		if updated.IsZero() {
+			updated = time.Now()
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO readarr_authors (name, readarr_id, foreign_author_id, base_url, updated_at)
+VALUES (?, ?, ?, ?, ?)`, name, a.ReadarrID, fid, scope, updated.UTC().Format("2006-01-02 15:04:05")); err != nil {
+			return 0, 0, err
+		}
+		authors++
+	}
+
+	byKind := map[string][]ReadarrBook{}
+	for _, b := range c.Books {
+		kind := strings.ToLower(strings.TrimSpace(b.SourceKind))
+		if kind == "" || strings.TrimSpace(b.Title) == "" {
+			continue
+		}
+		byKind[kind] = append(byKind[kind], b)
+	}
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	books := 0
+	for _, kind := range kinds {
+		if err := replaceReadarrBooks(ctx, tx, kind, byKind[kind]); err != nil {
+			return 0, 0, err
+		}
+		books += len(byKind[kind])
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return authors, books, nil
+}
+
+// parseCacheTime reads a cache timestamp, written either by this package
+// (RFC 3339) or by SQLite's CURRENT_TIMESTAMP default.
+func parseCacheTime(v string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t
+	}
+	t, _ := time.Parse("2006-01-02 15:04:05", v)
+	return t
+}
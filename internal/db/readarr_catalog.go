@@ -59,7 +59,15 @@ func (d *DB) ReplaceReadarrBooks(ctx context.Context, sourceKind string, books [
 		return err
 	}
 	defer tx.Rollback()
+	if err := replaceReadarrBooks(ctx, tx, sourceKind, books); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
+// replaceReadarrBooks swaps the cached catalog of sourceKind for books
+// inside tx.
+func replaceReadarrBooks(ctx context.Context, tx *sql.Tx, sourceKind string, books []ReadarrBook) error {
 	if _, err := tx.ExecContext(ctx, `DELETE FROM readarr_books WHERE source_kind=?`, strings.ToLower(strings.TrimSpace(sourceKind))); err != nil {
 		return err
 	}
@@ -97,8 +105,7 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			return err
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
 func (d *DB) CountReadarrBooks(ctx context.Context, sourceKind string) (int, error) {
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// PendingReminder is a pending request and how many approval reminders have
+// already been sent for it.
+type PendingReminder struct {
+	ID             int64
+	CreatedAt      time.Time
+	RequesterEmail string
+	Title          string
+	Format         string
+	GroupName      string
+	// NeedBy is the requester's need-by date, YYYY-MM-DD, or "".
+	NeedBy        string
+	RemindersSent int
+}
+
+// ListPendingReminders returns pending requests created or last renewed at
+// or before cutoff, and those with a need-by date however new, oldest first.
+// CreatedAt is the renewal time for renewed requests.
+func (d *DB) ListPendingReminders(ctx context.Context, cutoff time.Time) ([]PendingReminder, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT id, COALESCE(renewed_at, created_at), requester_email, title, format, COALESCE(group_name,''), COALESCE(need_by,''), COALESCE(reminders_sent,0)
+FROM requests WHERE status='pending' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PendingReminder
+	for rows.Next() {
+		var p PendingReminder
+		var created string
+		if err := rows.Scan(&p.ID, &created, &p.RequesterEmail, &p.Title, &p.Format, &p.GroupName, &p.NeedBy, &p.RemindersSent); err != nil {
+			return nil, err
+		}
+		// created_at is RFC3339Nano text, which does not sort reliably as a
+		// string, so the age filter runs here.
+		p.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+		if p.CreatedAt.IsZero() || p.CreatedAt.After(cutoff) && p.NeedBy == "" {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetRemindersSent records how many approval reminders have gone out for
+// request id.
+func (d *DB) SetRemindersSent(ctx context.Context, id int64, n int) error {
+	_, err := d.sql.ExecContext(ctx, `UPDATE requests SET reminders_sent=? WHERE id=?`, n, id)
+	return err
+}
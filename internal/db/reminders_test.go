@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPendingReminders(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "u", Title: "Old", Format: "ebook", Status: "pending"})
+	done, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "u", Title: "Done", Format: "ebook", Status: "approved"})
+	fresh, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "u", Title: "Fresh", Format: "ebook", Status: "pending"})
+	past := time.Now().UTC().Add(-48 * time.Hour).Format(time.RFC3339Nano)
+	for _, id := range []int64{old, done} {
+		if err := d.Exec(ctx, `UPDATE requests SET created_at=? WHERE id=?`, past, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := d.ListPendingReminders(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != old || got[0].RemindersSent != 0 {
+		t.Fatalf("pending reminders = %+v (fresh=%d)", got, fresh)
+	}
+	if err := d.SetRemindersSent(ctx, old, 2); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = d.ListPendingReminders(ctx, time.Now())
+	if len(got) != 2 || got[0].RemindersSent != 2 || got[1].ID != fresh {
+		t.Fatalf("after update = %+v", got)
+	}
+}
+
+func TestPendingRemindersIncludeNeedByDates(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	dated, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "u", Title: "Dated", Format: "ebook", Status: "pending", NeedBy: "2026-11-01"})
+	_, _ = d.CreateRequest(ctx, &Request{RequesterEmail: "u", Title: "Fresh", Format: "ebook", Status: "pending"})
+
+	got, err := d.ListPendingReminders(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != dated || got[0].NeedBy != "2026-11-01" {
+		t.Fatalf("pending reminders = %+v", got)
+	}
+	if r, _ := d.GetRequest(ctx, dated); r.NeedBy != "2026-11-01" {
+		t.Fatalf("request need-by = %q", r.NeedBy)
+	}
+}
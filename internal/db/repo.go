@@ -10,29 +10,80 @@ import (
 )
 
 type Request struct {
-	ID               int64           `json:"id"`
-	CreatedAt        time.Time       `json:"createdAt"`
-	UpdatedAt        time.Time       `json:"updatedAt"`
-	RequesterEmail   string          `json:"requesterEmail"`
-	Title            string          `json:"title"`
-	Authors          []string        `json:"authors"`
-	ISBN10           string          `json:"isbn10"`
-	ISBN13           string          `json:"isbn13"`
-	Format           string          `json:"format"`
-	Status           string          `json:"status"`
-	StatusReason     string          `json:"statusReason"`
-	ExternalStatus   string          `json:"externalStatus"`
-	MatchedReadarrID int64           `json:"matchedReadarrId"`
-	ApproverEmail    string          `json:"approverEmail"`
-	ApprovedAt       *time.Time      `json:"approvedAt,omitempty"`
-	HasReadarrReq    bool            `json:"hasReadarrRequest,omitempty"`
-	CoverURL         string          `json:"coverUrl,omitempty"`
-	ReadarrReq       json.RawMessage `json:"readarrRequest,omitempty"`
-	ReadarrResp      json.RawMessage `json:"readarrResponse,omitempty"`
+	ID               int64      `json:"id"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	RequesterEmail   string     `json:"requesterEmail"`
+	Title            string     `json:"title"`
+	Authors          []string   `json:"authors"`
+	ISBN10           string     `json:"isbn10"`
+	ISBN13           string     `json:"isbn13"`
+	Format           string     `json:"format"`
+	Status           string     `json:"status"`
+	StatusReason     string     `json:"statusReason"`
+	ExternalStatus   string     `json:"externalStatus"`
+	MatchedReadarrID int64      `json:"matchedReadarrId"`
+	ApproverEmail    string     `json:"approverEmail"`
+	ApprovedAt       *time.Time `json:"approvedAt,omitempty"`
+	HasReadarrReq    bool       `json:"hasReadarrRequest,omitempty"`
+	// NeedsReview marks a selection payload that was guessed rather than
+	// matched by identifier; an admin must confirm it before approval.
+	NeedsReview bool `json:"needsReview,omitempty"`
+	// ExpiredAt is when the request was closed for waiting too long; the
+	// requester may renew it until it is approved or declined by hand.
+	ExpiredAt *time.Time `json:"expiredAt,omitempty"`
+	// ASIN and ForeignBookID are filled in from Readarr once the book is
+	// added, for requests made with fewer identifiers.
+	ASIN          string `json:"asin,omitempty"`
+	ForeignBookID string `json:"foreignBookId,omitempty"`
+	// ReadarrInstance is "ebook" or "audiobook" when an admin sent the
+	// request to that instance instead of the one its format maps to.
+	ReadarrInstance string `json:"readarrInstance,omitempty"`
+	// FirstApprover is the admin who gave the first of the two approvals
+	// a request matching the two-person approval policy needs.
+	FirstApprover   string     `json:"firstApprover,omitempty"`
+	FirstApprovedAt *time.Time `json:"firstApprovedAt,omitempty"`
+	// PurchaseCents and PurchaseSource record what an admin paid for the
+	// book and where, for households that budget purchases.
+	PurchaseCents  int64      `json:"purchaseCents,omitempty"`
+	PurchaseSource string     `json:"purchaseSource,omitempty"`
+	PurchasedAt    *time.Time `json:"purchasedAt,omitempty"`
+	// Source is the channel the request arrived through, such as "web",
+	// "api", or "bulk"; "" for requests made before it was recorded.
+	Source          string `json:"source,omitempty"`
+	CoverURL        string `json:"coverUrl,omitempty"`
+	GroupName       string `json:"group,omitempty"`
+	LinkedRequestID int64  `json:"linkedRequestId,omitempty"`
+	// NeedBy is the date the requester needs the book by, as YYYY-MM-DD,
+	// or "" when they gave none.
+	NeedBy      string          `json:"needBy,omitempty"`
+	Labels      []string        `json:"labels,omitempty"`
+	ExtraFields []RequestExtra  `json:"extraFields,omitempty"`
+	ReadarrReq  json.RawMessage `json:"readarrRequest,omitempty"`
+	ReadarrResp json.RawMessage `json:"readarrResponse,omitempty"`
 }
 
+// RequestExtra is the requester's answer to one of the admin-configured
+// extra request form fields. The label is stored with the answer so it still
+// reads correctly after the field is renamed or removed.
+type RequestExtra struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// requestSummaryColumns is the SELECT column list for the list shape of a
+// Request: every column of requestColumns except the stored payload blobs,
+// with a flag for whether a selection payload exists in their place. The
+// flag is answered from idx_requests_has_payload so the blobs are not read.
+const requestSummaryColumns = `id, created_at, updated_at, requester_email, title, authors, isbn10, isbn13, format, status, status_reason, external_status, matched_readarr_id, approver_email, approved_at, cover_url, COALESCE(group_name,''), COALESCE(linked_request_id,0), COALESCE(need_by,''), COALESCE(asin,''), COALESCE(foreign_book_id,''), COALESCE(extra_fields,''), COALESCE(needs_review,0), COALESCE(readarr_instance,''), COALESCE(first_approver,''), first_approved_at, COALESCE(purchase_cents,0), COALESCE(purchase_source,''), purchased_at, expired_at, COALESCE(source,'')`
+
 // requestColumns is the shared SELECT column list for the full Request shape.
-const requestColumns = `id, created_at, updated_at, requester_email, title, authors, isbn10, isbn13, format, status, status_reason, external_status, matched_readarr_id, approver_email, approved_at, cover_url, readarr_request, readarr_response`
+const requestColumns = requestSummaryColumns + `, readarr_request, readarr_response`
+
+// hasPayloadColumn reports whether the row in the outer query has a stored
+// selection payload. Its WHERE matches the partial index exactly.
+const hasPayloadColumn = `, id IN (SELECT id FROM requests WHERE readarr_request IS NOT NULL AND TRIM(readarr_request) <> '')`
 
 // rowScanner is satisfied by both *sql.Row and *sql.Rows.
 type rowScanner interface {
@@ -41,14 +92,36 @@ type rowScanner interface {
 
 // scanRequest reads one full-shape request row (matching requestColumns).
 func scanRequest(sc rowScanner) (Request, error) {
+	return scanRequestRow(sc, true)
+}
+
+// scanRequestSummary reads one list-shape request row (matching
+// requestSummaryColumns + hasPayloadColumn).
+func scanRequestSummary(sc rowScanner) (Request, error) {
+	return scanRequestRow(sc, false)
+}
+
+func scanRequestRow(sc rowScanner, full bool) (Request, error) {
 	var rr Request
-	var created, updated, approved sql.NullString
+	var created, updated, approved, firstApproved, purchased, expired sql.NullString
 	var authorsStr, approver, externalStatus, coverURL sql.NullString
 	var readarrReqStr, readarrRespStr sql.NullString
 	var matchedReadarrID sql.NullInt64
-	if err := sc.Scan(&rr.ID, &created, &updated, &rr.RequesterEmail, &rr.Title, &authorsStr, &rr.ISBN10, &rr.ISBN13, &rr.Format, &rr.Status, &rr.StatusReason, &externalStatus, &matchedReadarrID, &approver, &approved, &coverURL, &readarrReqStr, &readarrRespStr); err != nil {
+	var extraStr string
+	var needsReview int
+	dest := []any{&rr.ID, &created, &updated, &rr.RequesterEmail, &rr.Title, &authorsStr, &rr.ISBN10, &rr.ISBN13, &rr.Format, &rr.Status, &rr.StatusReason, &externalStatus, &matchedReadarrID, &approver, &approved, &coverURL, &rr.GroupName, &rr.LinkedRequestID, &rr.NeedBy, &rr.ASIN, &rr.ForeignBookID, &extraStr, &needsReview, &rr.ReadarrInstance, &rr.FirstApprover, &firstApproved, &rr.PurchaseCents, &rr.PurchaseSource, &purchased, &expired, &rr.Source}
+	if full {
+		dest = append(dest, &readarrReqStr, &readarrRespStr)
+	} else {
+		dest = append(dest, &rr.HasReadarrReq)
+	}
+	if err := sc.Scan(dest...); err != nil {
 		return rr, err
 	}
+	rr.NeedsReview = needsReview == 1
+	if extraStr != "" {
+		_ = json.Unmarshal([]byte(extraStr), &rr.ExtraFields)
+	}
 	if externalStatus.Valid {
 		rr.ExternalStatus = externalStatus.String
 	}
@@ -73,6 +146,18 @@ func scanRequest(sc rowScanner) (Request, error) {
 		t, _ := time.Parse(time.RFC3339Nano, approved.String)
 		rr.ApprovedAt = &t
 	}
+	if firstApproved.Valid && firstApproved.String != "" {
+		t, _ := time.Parse(time.RFC3339Nano, firstApproved.String)
+		rr.FirstApprovedAt = &t
+	}
+	if purchased.Valid && purchased.String != "" {
+		t, _ := time.Parse(time.RFC3339Nano, purchased.String)
+		rr.PurchasedAt = &t
+	}
+	if expired.Valid && expired.String != "" {
+		t, _ := time.Parse(time.RFC3339Nano, expired.String)
+		rr.ExpiredAt = &t
+	}
 	if authorsStr.Valid && authorsStr.String != "" {
 		_ = json.Unmarshal([]byte(authorsStr.String), &rr.Authors)
 	}
@@ -92,18 +177,31 @@ func scanRequests(rows *sql.Rows) ([]Request, error) {
 	return out, rows.Err()
 }
 
+// scanRequestSummaries drains rows of the list shape via scanRequestSummary.
+func scanRequestSummaries(rows *sql.Rows) ([]Request, error) {
+	var out []Request
+	for rows.Next() {
+		rr, err := scanRequestSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rr)
+	}
+	return out, rows.Err()
+}
+
 func (d *DB) CreateRequest(ctx context.Context, r *Request) (int64, error) {
 	now := time.Now().UTC()
 	r.CreatedAt, r.UpdatedAt = now, now
 	authorsJSON, _ := json.Marshal(r.Authors)
 	res, err := d.sql.ExecContext(ctx, `
 INSERT INTO requests
-(created_at, updated_at, requester_email, title, authors, isbn10, isbn13, format, status, status_reason, external_status, matched_readarr_id, cover_url, readarr_request, readarr_response)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+(created_at, updated_at, requester_email, title, authors, isbn10, isbn13, format, status, status_reason, external_status, matched_readarr_id, cover_url, group_name, need_by, extra_fields, needs_review, readarr_request, readarr_response, candidate_request, source)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		now.Format(time.RFC3339Nano), now.Format(time.RFC3339Nano),
 		strings.ToLower(r.RequesterEmail), r.Title, string(authorsJSON),
-		r.ISBN10, r.ISBN13, r.Format, r.Status, r.StatusReason, r.ExternalStatus, r.MatchedReadarrID, r.CoverURL,
-		bytesOrNil(r.ReadarrReq), bytesOrNil(r.ReadarrResp),
+		r.ISBN10, r.ISBN13, r.Format, r.Status, r.StatusReason, r.ExternalStatus, r.MatchedReadarrID, r.CoverURL, strings.ToLower(strings.TrimSpace(r.GroupName)),
+		r.NeedBy, extraFieldsJSON(r.ExtraFields), boolToInt(r.NeedsReview), bytesOrNil(r.ReadarrReq), bytesOrNil(r.ReadarrResp), bytesOrNil(r.ReadarrReq), strings.ToLower(strings.TrimSpace(r.Source)),
 	)
 	if err != nil {
 		return 0, err
@@ -113,6 +211,9 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 }
 
 func (d *DB) UpdateRequestStatus(ctx context.Context, id int64, status, reason, actor string, readarrReq, readarrResp []byte) error {
+	if err := d.checkStatus(ctx, id, status); err != nil {
+		return err
+	}
 	now := time.Now().UTC()
 	_, err := d.sql.ExecContext(ctx, `
 UPDATE requests
@@ -123,6 +224,15 @@ WHERE id=?`,
 	return err
 }
 
+// extraFieldsJSON encodes request form answers, or NULL when there are none.
+func extraFieldsJSON(extras []RequestExtra) any {
+	if len(extras) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(extras)
+	return string(b)
+}
+
 func bytesOrNil(b []byte) any {
 	if len(b) == 0 {
 		return nil
@@ -131,6 +241,9 @@ func bytesOrNil(b []byte) any {
 }
 
 func (d *DB) ApproveRequest(ctx context.Context, id int64, actor string) error {
+	if err := d.checkStatus(ctx, id, "approved"); err != nil {
+		return err
+	}
 	now := time.Now().UTC()
 	_, err := d.sql.ExecContext(ctx, `
 UPDATE requests
@@ -141,6 +254,37 @@ WHERE id=?`,
 	return err
 }
 
+// RecordFirstApproval stores actor as the first of two approvals on pending
+// request id. It reports false when the request already has a first
+// approval or is no longer pending.
+func (d *DB) RecordFirstApproval(ctx context.Context, id int64, actor, reason string) (bool, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET first_approver=?, first_approved_at=?, status_reason=?, updated_at=?
+WHERE id=? AND status='pending' AND COALESCE(first_approver,'')=''`,
+		strings.ToLower(actor), now, reason, now, id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// LastApprovedRequest returns the most recently approved request, or
+// sql.ErrNoRows when none has been approved.
+func (d *DB) LastApprovedRequest(ctx context.Context) (*Request, error) {
+	row := d.sql.QueryRowContext(ctx, `SELECT `+requestColumns+` FROM requests
+WHERE approved_at IS NOT NULL AND approved_at != ''
+ORDER BY julianday(approved_at) DESC, id DESC LIMIT 1`)
+	rr, err := scanRequest(row)
+	if err != nil {
+		return nil, err
+	}
+	return &rr, nil
+}
+
 func (d *DB) GetRequest(ctx context.Context, id int64) (*Request, error) {
 	row := d.sql.QueryRowContext(ctx, `SELECT `+requestColumns+` FROM requests WHERE id=?`, id)
 	rr, err := scanRequest(row)
@@ -173,6 +317,38 @@ ORDER BY id DESC LIMIT ?`, limit)
 	return scanRequests(rows)
 }
 
+// ListRequestsByAuthor returns the requests crediting author (compared
+// case-insensitively) that were not declined, newest first.
+func (d *DB) ListRequestsByAuthor(ctx context.Context, author string) ([]Request, error) {
+	author = strings.TrimSpace(author)
+	if author == "" {
+		return nil, nil
+	}
+	// The LIKE narrows the scan; the authors list is checked exactly below.
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+requestColumns+`
+FROM requests
+WHERE LOWER(authors) LIKE ? AND status <> 'declined'
+ORDER BY id DESC LIMIT 500`, "%"+strings.ToLower(author)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	all, err := scanRequests(rows)
+	if err != nil {
+		return nil, err
+	}
+	out := all[:0]
+	for _, r := range all {
+		for _, a := range r.Authors {
+			if strings.EqualFold(strings.TrimSpace(a), author) {
+				out = append(out, r)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
 func (d *DB) ListRequestsByStatus(ctx context.Context, status string, limit int) ([]Request, error) {
 	query := `SELECT ` + requestColumns + `
 FROM requests
@@ -192,6 +368,23 @@ ORDER BY id ASC`
 	return scanRequests(rows)
 }
 
+// ListRequestsMissingPayload returns pending and failed requests that have
+// no stored selection payload, oldest first.
+func (d *DB) ListRequestsMissingPayload(ctx context.Context, limit int) ([]Request, error) {
+	if limit <= 0 {
+		limit = 200
+	}
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+requestColumns+`
+FROM requests
+WHERE status IN ('pending', 'error') AND (readarr_request IS NULL OR TRIM(readarr_request) = '')
+ORDER BY id ASC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRequests(rows)
+}
+
 // ListSearchableRequests returns requests that are eligible for startup search
 // queue recovery: status is "queued", matched_readarr_id is set, and
 // external_status is not "available".
@@ -209,71 +402,232 @@ ORDER BY id ASC`)
 	return scanRequests(rows)
 }
 
-func (d *DB) ListRequestsPage(ctx context.Context, mine string, limit int) ([]Request, error) {
-	if limit <= 0 {
-		limit = 200
+// RequestScope narrows a request listing. Requester limits it to one user's
+// own requests, Group to the members of one household group, Label to
+// requests carrying that label, and Status to requests in that status;
+// empty fields do not filter.
+type RequestScope struct {
+	Requester string
+	Group     string
+	Label     string
+	Status    string
+	Source    string
+}
+
+func (sc RequestScope) where() (string, []any) {
+	var clauses []string
+	var args []any
+	if v := strings.ToLower(strings.TrimSpace(sc.Requester)); v != "" {
+		clauses = append(clauses, "requester_email=?")
+		args = append(args, v)
 	}
-	var rows *sql.Rows
-	var err error
-	query := `
-SELECT id, created_at, updated_at, requester_email, title, authors, isbn10, isbn13, format, status, status_reason, external_status, matched_readarr_id, approver_email, approved_at, cover_url,
-       CASE WHEN readarr_request IS NOT NULL AND TRIM(readarr_request) <> '' THEN 1 ELSE 0 END AS has_readarr_request
-FROM requests`
-	if mine != "" {
-		rows, err = d.sql.QueryContext(ctx, query+`
-WHERE requester_email=?
-ORDER BY id DESC LIMIT ?`, strings.ToLower(mine), limit)
-	} else {
-		rows, err = d.sql.QueryContext(ctx, query+`
-ORDER BY id DESC LIMIT ?`, limit)
+	if v := strings.ToLower(strings.TrimSpace(sc.Group)); v != "" {
+		clauses = append(clauses, "group_name=?")
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(sc.Label); v != "" {
+		clauses = append(clauses, "id IN (SELECT request_id FROM request_labels WHERE label=?)")
+		args = append(args, v)
 	}
+	if v := strings.ToLower(strings.TrimSpace(sc.Status)); v != "" {
+		clauses = append(clauses, "status=?")
+		args = append(args, v)
+	}
+	if v := strings.ToLower(strings.TrimSpace(sc.Source)); v != "" {
+		clauses = append(clauses, "source=?")
+		args = append(args, v)
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return "\nWHERE " + strings.Join(clauses, " AND "), args
+}
+
+// CountRequestsByStatus returns the number of requests in each status within
+// scope.
+func (d *DB) CountRequestsByStatus(ctx context.Context, scope RequestScope) (map[string]int, error) {
+	where, args := scope.where()
+	rows, err := d.sql.QueryContext(ctx, `SELECT status, COUNT(1) FROM requests`+where+` GROUP BY status`, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-
-	var out []Request
+	out := map[string]int{}
 	for rows.Next() {
-		var rr Request
-		var created, updated, approved sql.NullString
-		var authorsStr sql.NullString
-		var approver sql.NullString
-		var externalStatus sql.NullString
-		var matchedReadarrID sql.NullInt64
-		var coverURL sql.NullString
-		var hasReadarrReq int
-		if err := rows.Scan(&rr.ID, &created, &updated, &rr.RequesterEmail, &rr.Title, &authorsStr, &rr.ISBN10, &rr.ISBN13, &rr.Format, &rr.Status, &rr.StatusReason, &externalStatus, &matchedReadarrID, &approver, &approved, &coverURL, &hasReadarrReq); err != nil {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
 			return nil, err
 		}
-		if externalStatus.Valid {
-			rr.ExternalStatus = externalStatus.String
-		}
-		if matchedReadarrID.Valid {
-			rr.MatchedReadarrID = matchedReadarrID.Int64
-		}
-		if approver.Valid {
-			rr.ApproverEmail = approver.String
-		}
-		if coverURL.Valid {
-			rr.CoverURL = coverURL.String
-		}
-		rr.HasReadarrReq = hasReadarrReq == 1
-		rr.CreatedAt, _ = time.Parse(time.RFC3339Nano, created.String)
-		rr.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated.String)
-		if approved.Valid {
-			t, _ := time.Parse(time.RFC3339Nano, approved.String)
-			rr.ApprovedAt = &t
-		}
-		if authorsStr.Valid && authorsStr.String != "" {
-			_ = json.Unmarshal([]byte(authorsStr.String), &rr.Authors)
+		out[status] = n
+	}
+	return out, rows.Err()
+}
+
+// CountRequestsBySource returns the number of requests that arrived through
+// each channel within scope. Requests made before sources were recorded are
+// counted under "".
+func (d *DB) CountRequestsBySource(ctx context.Context, scope RequestScope) (map[string]int, error) {
+	where, args := scope.where()
+	rows, err := d.sql.QueryContext(ctx, `SELECT COALESCE(source,''), COUNT(1) FROM requests`+where+` GROUP BY COALESCE(source,'')`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var source string
+		var n int
+		if err := rows.Scan(&source, &n); err != nil {
+			return nil, err
 		}
-		out = append(out, rr)
+		out[source] = n
+	}
+	return out, rows.Err()
+}
+
+// ListRequestsPage lists requests for table views, newest first, leaving out
+// the stored payload blobs that ListRequests loads.
+func (d *DB) ListRequestsPage(ctx context.Context, mine string, limit int) ([]Request, error) {
+	return d.ListRequestsPageScoped(ctx, RequestScope{Requester: mine}, limit)
+}
+
+// ListRequestsPageScoped is ListRequestsPage with group scoping. It skips the
+// stored payload blobs and only reports whether a selection payload exists.
+func (d *DB) ListRequestsPageScoped(ctx context.Context, scope RequestScope, limit int) ([]Request, error) {
+	if limit <= 0 {
+		limit = 200
+	}
+	where, args := scope.where()
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+requestSummaryColumns+hasPayloadColumn+`
+FROM requests`+where+`
+ORDER BY id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out, err := scanRequestSummaries(rows)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(out))
+	for i := range out {
+		ids[i] = out[i].ID
+	}
+	labels, err := d.requestLabels(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Labels = labels[out[i].ID]
 	}
 	return out, nil
 }
 
 func (d *DB) DeleteRequest(ctx context.Context, id int64) error {
-	_, err := d.sql.ExecContext(ctx, `DELETE FROM requests WHERE id=?`, id)
+	if _, err := d.sql.ExecContext(ctx, `DELETE FROM requests WHERE id=?`, id); err != nil {
+		return err
+	}
+	if _, err := d.sql.ExecContext(ctx, `DELETE FROM request_attachments WHERE request_id=?`, id); err != nil {
+		return err
+	}
+	if _, err := d.sql.ExecContext(ctx, `DELETE FROM request_labels WHERE request_id=?`, id); err != nil {
+		return err
+	}
+	if _, err := d.sql.ExecContext(ctx, `DELETE FROM request_authors WHERE request_id=?`, id); err != nil {
+		return err
+	}
+	if _, err := d.sql.ExecContext(ctx, `DELETE FROM request_watchers WHERE request_id=?`, id); err != nil {
+		return err
+	}
+	_, err := d.sql.ExecContext(ctx, `UPDATE requests SET linked_request_id=0 WHERE linked_request_id=?`, id)
+	return err
+}
+
+// FindFormatCounterpart returns the newest pending, unlinked request in the
+// same group for the same book in the other format (ebook vs audiobook). Books
+// match on ISBN-13, ISBN-10, or title plus authors. Returns sql.ErrNoRows when
+// there is no counterpart, which is always the case for comics.
+func (d *DB) FindFormatCounterpart(ctx context.Context, r *Request) (*Request, error) {
+	var other string
+	switch strings.ToLower(r.Format) {
+	case "audiobook":
+		other = "ebook"
+	case "ebook", "":
+		other = "audiobook"
+	default:
+		return nil, sql.ErrNoRows
+	}
+	authorsJSON, _ := json.Marshal(r.Authors)
+	row := d.sql.QueryRowContext(ctx, `SELECT `+requestColumns+` FROM requests
+WHERE id<>? AND format=? AND status='pending' AND COALESCE(linked_request_id,0)=0 AND COALESCE(group_name,'')=?
+  AND ((?<>'' AND isbn13=?) OR (?<>'' AND isbn10=?) OR (?<>'' AND LOWER(title)=LOWER(?) AND LOWER(authors)=LOWER(?)))
+ORDER BY id DESC LIMIT 1`,
+		r.ID, other, strings.ToLower(strings.TrimSpace(r.GroupName)),
+		r.ISBN13, r.ISBN13, r.ISBN10, r.ISBN10,
+		strings.TrimSpace(r.Title), strings.TrimSpace(r.Title), string(authorsJSON),
+	)
+	rr, err := scanRequest(row)
+	if err != nil {
+		return nil, err
+	}
+	return &rr, nil
+}
+
+// LinkRequests records that requests a and b are the ebook and audiobook
+// editions of the same book so they can be approved together.
+func (d *DB) LinkRequests(ctx context.Context, a, b int64) error {
+	now := time.Now().UTC()
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET linked_request_id=CASE id WHEN ? THEN ? ELSE ? END, updated_at=?
+WHERE id IN (?, ?)`,
+		a, b, a, now.Format(time.RFC3339Nano), a, b,
+	)
+	return err
+}
+
+// ReassignRequest makes username the requester of request id and moves it
+// into group. Pending requests then count against the new requester's cap.
+func (d *DB) ReassignRequest(ctx context.Context, id int64, username, group string) error {
+	now := time.Now().UTC()
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET requester_email=?, group_name=?, updated_at=?
+WHERE id=?`,
+		strings.ToLower(strings.TrimSpace(username)),
+		strings.ToLower(strings.TrimSpace(group)),
+		now.Format(time.RFC3339Nano),
+		id,
+	)
+	return err
+}
+
+// RequestIdentifiers are the identifiers Readarr reports for a book.
+type RequestIdentifiers struct {
+	ISBN10, ISBN13, ASIN, ForeignBookID string
+}
+
+// BackfillRequestIdentifiers fills in whichever of request id's ISBN-10,
+// ISBN-13, ASIN, and foreign book id are still empty from ids. Identifiers
+// the request already has are kept.
+func (d *DB) BackfillRequestIdentifiers(ctx context.Context, id int64, ids RequestIdentifiers) error {
+	now := time.Now().UTC()
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET isbn10=CASE WHEN COALESCE(isbn10,'')='' THEN ? ELSE isbn10 END,
+    isbn13=CASE WHEN COALESCE(isbn13,'')='' THEN ? ELSE isbn13 END,
+    asin=CASE WHEN COALESCE(asin,'')='' THEN ? ELSE asin END,
+    foreign_book_id=CASE WHEN COALESCE(foreign_book_id,'')='' THEN ? ELSE foreign_book_id END,
+    updated_at=?
+WHERE id=?`,
+		strings.TrimSpace(ids.ISBN10),
+		strings.TrimSpace(ids.ISBN13),
+		strings.TrimSpace(ids.ASIN),
+		strings.TrimSpace(ids.ForeignBookID),
+		now.Format(time.RFC3339Nano),
+		id,
+	)
 	return err
 }
 
@@ -290,10 +644,64 @@ WHERE id=?`,
 	return err
 }
 
+// UpdateRequestPayload replaces the stored Readarr selection payload.
+func (d *DB) UpdateRequestPayload(ctx context.Context, id int64, readarrReq []byte) error {
+	now := time.Now().UTC()
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET readarr_request=?, candidate_request=?, updated_at=?
+WHERE id=?`,
+		bytesOrNil(readarrReq), bytesOrNil(readarrReq),
+		now.Format(time.RFC3339Nano),
+		id,
+	)
+	return err
+}
+
+// GetRequestCandidate returns request id's selection payload as it was
+// stored at request time or last picked by an admin, before the add
+// pipeline replaced it with what was sent. It is nil for requests sent
+// before it was recorded.
+func (d *DB) GetRequestCandidate(ctx context.Context, id int64) ([]byte, error) {
+	var v sql.NullString
+	if err := d.sql.QueryRowContext(ctx, `SELECT candidate_request FROM requests WHERE id=?`, id).Scan(&v); err != nil {
+		return nil, err
+	}
+	if !v.Valid || v.String == "" {
+		return nil, nil
+	}
+	return []byte(v.String), nil
+}
+
+// SetRequestSelection replaces a request's stored selection payload and
+// records whether an admin still has to confirm it.
+func (d *DB) SetRequestSelection(ctx context.Context, id int64, readarrReq []byte, needsReview bool) error {
+	now := time.Now().UTC()
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE requests
+SET readarr_request=?, candidate_request=?, needs_review=?, updated_at=?
+WHERE id=?`,
+		bytesOrNil(readarrReq), bytesOrNil(readarrReq), boolToInt(needsReview), now.Format(time.RFC3339Nano), id,
+	)
+	return err
+}
+
+// SetRequestReadarrInstance routes request id to the "ebook" or "audiobook"
+// Readarr instance; "" returns it to the one its format maps to.
+func (d *DB) SetRequestReadarrInstance(ctx context.Context, id int64, kind string) error {
+	now := time.Now().UTC()
+	_, err := d.sql.ExecContext(ctx, `UPDATE requests SET readarr_instance=?, updated_at=? WHERE id=?`,
+		kind, now.Format(time.RFC3339Nano), id)
+	return err
+}
+
 func (d *DB) DeclineRequest(ctx context.Context, id int64, actor, reason string) error {
 	if strings.TrimSpace(reason) == "" {
 		reason = "declined by admin"
 	}
+	if err := d.checkStatus(ctx, id, "declined"); err != nil {
+		return err
+	}
 	now := time.Now().UTC()
 	_, err := d.sql.ExecContext(ctx, `
 UPDATE requests
@@ -313,8 +721,50 @@ func (d *DB) CountPendingRequestsByUser(ctx context.Context, requesterEmail stri
 	return n, nil
 }
 
+// MostRequestedFormat returns the book format, ebook or audiobook, that
+// requesterEmail has asked for most often, the newer format winning a tie.
+// It returns "" for a user without book requests.
+func (d *DB) MostRequestedFormat(ctx context.Context, requesterEmail string) (string, error) {
+	row := d.sql.QueryRowContext(ctx, `SELECT format FROM requests
+WHERE requester_email=? AND format IN ('ebook', 'audiobook')
+GROUP BY format ORDER BY COUNT(1) DESC, MAX(id) DESC LIMIT 1`, strings.ToLower(requesterEmail))
+	var format string
+	if err := row.Scan(&format); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return format, nil
+}
+
+// HasFormatRequest reports whether r's requester already has a request for
+// the same book in format that was not declined. Books match as in
+// FindFormatCounterpart.
+func (d *DB) HasFormatRequest(ctx context.Context, r *Request, format string) (bool, error) {
+	authorsJSON, _ := json.Marshal(r.Authors)
+	row := d.sql.QueryRowContext(ctx, `SELECT COUNT(1) FROM requests
+WHERE id<>? AND requester_email=? AND format=? AND status<>'declined'
+  AND ((?<>'' AND isbn13=?) OR (?<>'' AND isbn10=?) OR (?<>'' AND LOWER(title)=LOWER(?) AND LOWER(authors)=LOWER(?)))`,
+		r.ID, strings.ToLower(r.RequesterEmail), format,
+		r.ISBN13, r.ISBN13, r.ISBN10, r.ISBN10,
+		strings.TrimSpace(r.Title), strings.TrimSpace(r.Title), string(authorsJSON),
+	)
+	var n int
+	if err := row.Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 func (d *DB) DeleteAllRequests(ctx context.Context) error {
-	_, err := d.sql.ExecContext(ctx, `DELETE FROM requests`)
+	if _, err := d.sql.ExecContext(ctx, `DELETE FROM requests`); err != nil {
+		return err
+	}
+	if _, err := d.sql.ExecContext(ctx, `DELETE FROM request_attachments`); err != nil {
+		return err
+	}
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM request_labels`)
 	return err
 }
 
@@ -334,24 +784,35 @@ type User struct {
 	NotifyWebhookURL     string
 	NotifyOnApproved     bool
 	NotifyOnAvailable    bool
+	// GroupName is the household group the user belongs to ("" for none).
+	GroupName string
+	// MaxPending overrides the group or global pending-request cap for this
+	// user; 0 keeps the default.
+	MaxPending int
+	// Disabled accounts are kept for their history but cannot sign in.
+	Disabled bool
+	// SearchProviders is the user's search source order, with sources they
+	// turned off prefixed by "-"; "" keeps the admin defaults.
+	SearchProviders string
 }
 
 // userColumns is the shared SELECT list for the full User shape. COALESCE keeps
 // it forward-compatible with rows created before the notification columns were
 // added.
-const userColumns = `id, created_at, username, password_hash, is_admin, COALESCE(auto_approve,0), COALESCE(email,''), COALESCE(notify_ntfy_topic,''), COALESCE(notify_discord_webhook,''), COALESCE(notify_webhook_url,''), COALESCE(notify_on_approved,0), COALESCE(notify_on_available,0)`
+const userColumns = `id, created_at, username, password_hash, is_admin, COALESCE(auto_approve,0), COALESCE(email,''), COALESCE(notify_ntfy_topic,''), COALESCE(notify_discord_webhook,''), COALESCE(notify_webhook_url,''), COALESCE(notify_on_approved,0), COALESCE(notify_on_available,0), COALESCE(group_name,''), COALESCE(max_pending,0), COALESCE(disabled,0), COALESCE(search_providers,'')`
 
 func scanUser(sc rowScanner) (User, error) {
 	var u User
 	var created string
-	var isAdminInt, autoApproveInt, onApprovedInt, onAvailableInt int
-	if err := sc.Scan(&u.ID, &created, &u.Username, &u.Hash, &isAdminInt, &autoApproveInt, &u.Email, &u.NotifyNtfyTopic, &u.NotifyDiscordWebhook, &u.NotifyWebhookURL, &onApprovedInt, &onAvailableInt); err != nil {
+	var isAdminInt, autoApproveInt, onApprovedInt, onAvailableInt, disabledInt int
+	if err := sc.Scan(&u.ID, &created, &u.Username, &u.Hash, &isAdminInt, &autoApproveInt, &u.Email, &u.NotifyNtfyTopic, &u.NotifyDiscordWebhook, &u.NotifyWebhookURL, &onApprovedInt, &onAvailableInt, &u.GroupName, &u.MaxPending, &disabledInt, &u.SearchProviders); err != nil {
 		return u, err
 	}
 	u.IsAdmin = isAdminInt == 1
 	u.AutoApprove = autoApproveInt == 1
 	u.NotifyOnApproved = onApprovedInt == 1
 	u.NotifyOnAvailable = onAvailableInt == 1
+	u.Disabled = disabledInt == 1
 	u.Created, _ = time.Parse(time.RFC3339Nano, created)
 	return u, nil
 }
@@ -377,6 +838,16 @@ func (d *DB) GetUserByUsername(ctx context.Context, username string) (*User, err
 	return &u, nil
 }
 
+// GetUserByID returns the user with the given id.
+func (d *DB) GetUserByID(ctx context.Context, id int64) (*User, error) {
+	row := d.sql.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id=?`, id)
+	u, err := scanUser(row)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 func (d *DB) CountAdmins(ctx context.Context) (int, error) {
 	row := d.sql.QueryRowContext(ctx, `SELECT COUNT(1) FROM users WHERE is_admin=1`)
 	var n int
@@ -418,6 +889,81 @@ func (d *DB) SetUserAutoApprove(ctx context.Context, id int64, autoApprove bool)
 	return err
 }
 
+// SetUserGroup assigns a user to a household group; "" removes the assignment.
+func (d *DB) SetUserGroup(ctx context.Context, id int64, group string) error {
+	_, err := d.sql.ExecContext(ctx, `UPDATE users SET group_name=? WHERE id=?`, strings.ToLower(strings.TrimSpace(group)), id)
+	return err
+}
+
+// SetUserMaxPending sets a user's own pending-request cap; 0 removes it.
+func (d *DB) SetUserMaxPending(ctx context.Context, id int64, n int) error {
+	if n < 0 {
+		n = 0
+	}
+	_, err := d.sql.ExecContext(ctx, `UPDATE users SET max_pending=? WHERE id=?`, n, id)
+	return err
+}
+
+// SetUserDisabled blocks (or restores) a user's sign-in without deleting them.
+func (d *DB) SetUserDisabled(ctx context.Context, id int64, disabled bool) error {
+	_, err := d.sql.ExecContext(ctx, `UPDATE users SET disabled=? WHERE id=?`, boolToInt(disabled), id)
+	return err
+}
+
+// AnonymizeUser replaces a user's username with an alias everywhere it is
+// recorded (requests, approvals, notes, attachments, subscriptions, the
+// denylist, guest decisions, the notification log and the audit log), drops
+// their sessions, watches and share links, clears their contact details and
+// password, and deactivates them and their subscriptions. Counts and history
+// survive under the alias. It returns the alias.
+func (d *DB) AnonymizeUser(ctx context.Context, id int64) (string, error) {
+	u, err := d.GetUserByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	alias := "former-user-" + strconv.FormatInt(id, 10)
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		`UPDATE requests SET requester_email=? WHERE requester_email=?`,
+		`UPDATE requests SET approver_email=? WHERE approver_email=?`,
+		`UPDATE request_attachments SET uploaded_by=? WHERE uploaded_by=?`,
+		`UPDATE audit_events SET actor_email=? WHERE actor_email=?`,
+		`UPDATE subscriptions SET requester_email=?, active=0 WHERE requester_email=?`,
+		`UPDATE requests SET first_approver=? WHERE first_approver=?`,
+		`UPDATE requests SET admin_note_by=? WHERE admin_note_by=?`,
+		`UPDATE denylist SET created_by=? WHERE created_by=?`,
+		`UPDATE guest_requests SET decided_by=? WHERE decided_by=?`,
+		`UPDATE notification_log SET target='user:'||? WHERE target='user:'||?`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, alias, u.Username); err != nil {
+			return "", err
+		}
+	}
+	for _, q := range []string{`DELETE FROM idempotency_keys WHERE username=?`, `DELETE FROM sessions WHERE username=?`, `DELETE FROM push_subscriptions WHERE username=?`, `DELETE FROM wishlist_shares WHERE username=?`, `DELETE FROM request_watchers WHERE username=?`} {
+		if _, err := tx.ExecContext(ctx, q, u.Username); err != nil {
+			return "", err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+UPDATE users
+SET username=?, password_hash='', email='', notify_ntfy_topic='', notify_discord_webhook='', notify_webhook_url='',
+    notify_on_approved=0, notify_on_available=0, disabled=1
+WHERE id=?`, alias, id); err != nil {
+		return "", err
+	}
+	return alias, tx.Commit()
+}
+
+// SetUserEmail replaces a user's email address.
+func (d *DB) SetUserEmail(ctx context.Context, id int64, email string) error {
+	_, err := d.sql.ExecContext(ctx, `UPDATE users SET email=? WHERE id=?`, strings.TrimSpace(email), id)
+	return err
+}
+
 // UpdateUserNotificationPrefs persists a user's self-service notification
 // destinations and per-event opt-ins.
 func (d *DB) UpdateUserNotificationPrefs(ctx context.Context, id int64, email, ntfyTopic, discordWebhook, webhookURL string, onApproved, onAvailable bool) error {
@@ -431,6 +977,12 @@ WHERE id=?`,
 	return err
 }
 
+// SetUserSearchProviders stores a user's search source preferences.
+func (d *DB) SetUserSearchProviders(ctx context.Context, id int64, providers string) error {
+	_, err := d.sql.ExecContext(ctx, `UPDATE users SET search_providers=? WHERE id=?`, strings.TrimSpace(providers), id)
+	return err
+}
+
 // SetUserEmailIfEmpty backfills a user's email (e.g. from an OIDC claim) without
 // overwriting one the user has already set.
 func (d *DB) SetUserEmailIfEmpty(ctx context.Context, username, email string) error {
@@ -497,6 +1049,44 @@ ORDER BY id DESC LIMIT ?`, limit)
 	if err != nil {
 		return nil, err
 	}
+	return scanAuditEvents(rows)
+}
+
+// ListRequestAuditEvents returns the audit events recorded for request id,
+// newest first.
+func (d *DB) ListRequestAuditEvents(ctx context.Context, id int64, limit int) ([]AuditEvent, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT id, ts, actor_email, event_type, request_id, details
+FROM audit_events
+WHERE request_id=?
+ORDER BY id DESC LIMIT ?`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEvents(rows)
+}
+
+// ListAuditEventsByType returns the most recent audit events of one type,
+// newest first.
+func (d *DB) ListAuditEventsByType(ctx context.Context, eventType string, limit int) ([]AuditEvent, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT id, ts, actor_email, event_type, request_id, details
+FROM audit_events
+WHERE event_type=?
+ORDER BY id DESC LIMIT ?`, eventType, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEvents(rows)
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]AuditEvent, error) {
 	defer rows.Close()
 	var out []AuditEvent
 	for rows.Next() {
@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func openMigratedDB(t *testing.T) *DB {
@@ -419,3 +421,230 @@ func TestListSearchableRequestsOnlyReturnsQueued(t *testing.T) {
 		t.Fatalf("expected queued status, got %q", items[0].Status)
 	}
 }
+
+func TestListRequestsPageScoped(t *testing.T) {
+	d := openMigratedDB(t)
+	ctx := context.Background()
+	for _, r := range []*Request{
+		{RequesterEmail: "bob", Title: "A", Format: "ebook", Status: "pending", GroupName: "Smiths"},
+		{RequesterEmail: "alice", Title: "B", Format: "ebook", Status: "pending", GroupName: "smiths"},
+		{RequesterEmail: "carol", Title: "C", Format: "ebook", Status: "pending"},
+	} {
+		if _, err := d.CreateRequest(ctx, r); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	cases := []struct {
+		scope RequestScope
+		want  int
+	}{
+		{RequestScope{}, 3},
+		{RequestScope{Group: "smiths"}, 2},
+		{RequestScope{Requester: "bob", Group: "smiths"}, 1},
+		{RequestScope{Requester: "carol"}, 1},
+	}
+	for _, c := range cases {
+		items, err := d.ListRequestsPageScoped(ctx, c.scope, 10)
+		if err != nil {
+			t.Fatalf("list %+v: %v", c.scope, err)
+		}
+		if len(items) != c.want {
+			t.Fatalf("scope %+v: expected %d, got %d", c.scope, c.want, len(items))
+		}
+	}
+}
+
+func TestListRequestsPageSkipsPayloads(t *testing.T) {
+	d := openMigratedDB(t)
+	ctx := context.Background()
+	for _, r := range []*Request{
+		{RequesterEmail: "bob", Title: "With", Format: "ebook", Status: "pending", ReadarrReq: []byte(`{"title":"With"}`)},
+		{RequesterEmail: "bob", Title: "Without", Format: "ebook", Status: "pending"},
+	} {
+		if _, err := d.CreateRequest(ctx, r); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	items, err := d.ListRequestsPage(ctx, "bob", 10)
+	if err != nil {
+		t.Fatalf("ListRequestsPage: %v", err)
+	}
+	if len(items) != 2 || items[0].Title != "Without" || items[0].HasReadarrReq || !items[1].HasReadarrReq {
+		t.Fatalf("unexpected page: %+v", items)
+	}
+	for _, it := range items {
+		if it.ReadarrReq != nil || it.ReadarrResp != nil {
+			t.Fatalf("page row carried payloads: %+v", it)
+		}
+	}
+
+	rows, err := d.SQL().QueryContext(ctx, `EXPLAIN QUERY PLAN SELECT `+requestSummaryColumns+hasPayloadColumn+` FROM requests ORDER BY id DESC LIMIT 10`)
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+	defer rows.Close()
+	var plan []string
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			t.Fatalf("scan plan: %v", err)
+		}
+		plan = append(plan, detail)
+	}
+	if !strings.Contains(strings.Join(plan, "\n"), "idx_requests_has_payload") {
+		t.Fatalf("payload flag does not use the partial index: %v", plan)
+	}
+}
+
+func TestFindAndLinkFormatCounterpart(t *testing.T) {
+	d := openMigratedDB(t)
+	ctx := context.Background()
+	ebook := &Request{RequesterEmail: "bob", Title: "Dune", Authors: []string{"Frank Herbert"}, Format: "ebook", Status: "pending"}
+	ebookID, err := d.CreateRequest(ctx, ebook)
+	if err != nil {
+		t.Fatalf("create ebook: %v", err)
+	}
+	audio := &Request{RequesterEmail: "alice", Title: "dune", Authors: []string{"Frank Herbert"}, Format: "audiobook", Status: "pending"}
+	audioID, err := d.CreateRequest(ctx, audio)
+	if err != nil {
+		t.Fatalf("create audiobook: %v", err)
+	}
+	audio.ID = audioID
+
+	other, err := d.FindFormatCounterpart(ctx, audio)
+	if err != nil || other.ID != ebookID {
+		t.Fatalf("expected counterpart %d, got %+v err=%v", ebookID, other, err)
+	}
+	if err := d.LinkRequests(ctx, audioID, ebookID); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	a, _ := d.GetRequest(ctx, audioID)
+	e, _ := d.GetRequest(ctx, ebookID)
+	if a.LinkedRequestID != ebookID || e.LinkedRequestID != audioID {
+		t.Fatalf("expected mutual links, got %d/%d", a.LinkedRequestID, e.LinkedRequestID)
+	}
+	// Already-linked requests are not offered again.
+	if _, err := d.FindFormatCounterpart(ctx, audio); err != sql.ErrNoRows {
+		t.Fatalf("expected no counterpart once linked, got %v", err)
+	}
+	// Deleting one side clears the link on the other.
+	if err := d.DeleteRequest(ctx, ebookID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if a, _ := d.GetRequest(ctx, audioID); a.LinkedRequestID != 0 {
+		t.Fatalf("expected link cleared, got %d", a.LinkedRequestID)
+	}
+}
+
+func TestMostRequestedFormatAndHasFormatRequest(t *testing.T) {
+	d := openMigratedDB(t)
+	ctx := context.Background()
+	if f, err := d.MostRequestedFormat(ctx, "alice"); err != nil || f != "" {
+		t.Fatalf("no requests = %q, %v", f, err)
+	}
+	create := func(title, format, status string) *Request {
+		r := &Request{RequesterEmail: "alice", Title: title, Authors: []string{"Frank Herbert"}, Format: format, Status: status}
+		id, err := d.CreateRequest(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.ID = id
+		return r
+	}
+	dune := create("Dune", "ebook", "approved")
+	create("Dune Messiah", "audiobook", "approved")
+	if f, _ := d.MostRequestedFormat(ctx, "Alice"); f != "audiobook" {
+		t.Fatalf("tie = %q, want the newer format", f)
+	}
+	create("Children of Dune", "ebook", "pending")
+	create("Saga", "comic", "pending")
+	create("God Emperor of Dune", "comic", "pending")
+	if f, _ := d.MostRequestedFormat(ctx, "alice"); f != "ebook" {
+		t.Fatalf("most requested = %q", f)
+	}
+
+	if has, err := d.HasFormatRequest(ctx, dune, "audiobook"); err != nil || has {
+		t.Fatalf("audiobook of Dune = %v, %v", has, err)
+	}
+	create("dune", "audiobook", "declined")
+	if has, _ := d.HasFormatRequest(ctx, dune, "audiobook"); has {
+		t.Fatal("a declined request counts as already requested")
+	}
+	create("Dune", "audiobook", "pending")
+	if has, _ := d.HasFormatRequest(ctx, dune, "audiobook"); !has {
+		t.Fatal("pending audiobook of Dune not found")
+	}
+}
+
+func TestIdempotencyKeyLifecycle(t *testing.T) {
+	d := openMigratedDB(t)
+	ctx := context.Background()
+
+	rec, err := d.ReserveIdempotencyKey(ctx, "user", "k1", "fp")
+	if err != nil || rec != nil {
+		t.Fatalf("first reserve should succeed: %+v %v", rec, err)
+	}
+	rec, err = d.ReserveIdempotencyKey(ctx, "user", "k1", "fp")
+	if err != nil || rec == nil || rec.StatusCode != 0 {
+		t.Fatalf("second reserve should return in-flight record: %+v %v", rec, err)
+	}
+	if err := d.CompleteIdempotencyKey(ctx, "user", "k1", 201, map[string]string{"Content-Type": "application/json"}, []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	rec, _ = d.ReserveIdempotencyKey(ctx, "user", "k1", "fp")
+	if rec == nil || rec.StatusCode != 201 || string(rec.Body) != `{"id":1}` || rec.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected stored response: %+v", rec)
+	}
+
+	if err := d.ReleaseIdempotencyKey(ctx, "user", "k1"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if rec, _ := d.ReserveIdempotencyKey(ctx, "user", "k1", "fp2"); rec != nil {
+		t.Fatalf("released key should be reservable again")
+	}
+	if n, err := d.PruneIdempotencyKeys(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("prune: n=%d err=%v", n, err)
+	}
+}
+
+func TestRequestAttachmentsLifecycle(t *testing.T) {
+	d := openMigratedDB(t)
+	ctx := context.Background()
+	reqID, err := d.CreateRequest(ctx, &Request{RequesterEmail: "bob", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	file := &RequestAttachment{RequestID: reqID, Kind: "file", Name: "edition.png", ContentType: "image/png", Size: 42, StorageKey: "1/abc", UploadedBy: "bob"}
+	if _, err := d.AddRequestAttachment(ctx, file); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+	if _, err := d.AddRequestAttachment(ctx, &RequestAttachment{RequestID: reqID, Kind: "link", Name: "store", URL: "https://example.com/dune", UploadedBy: "bob"}); err != nil {
+		t.Fatalf("add link: %v", err)
+	}
+
+	list, err := d.ListRequestAttachments(ctx, reqID)
+	if err != nil || len(list) != 2 || list[0].Name != "edition.png" || list[1].URL != "https://example.com/dune" || list[0].CreatedAt.IsZero() {
+		t.Fatalf("list: %+v err=%v", list, err)
+	}
+	got, err := d.GetRequestAttachment(ctx, reqID, file.ID)
+	if err != nil || got.StorageKey != "1/abc" || got.Size != 42 {
+		t.Fatalf("get: %+v err=%v", got, err)
+	}
+	if _, err := d.GetRequestAttachment(ctx, reqID+1, file.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("get from other request: %v", err)
+	}
+	if keys, err := d.ListAttachmentStorageKeys(ctx); err != nil || len(keys) != 1 || keys[0] != "1/abc" {
+		t.Fatalf("storage keys: %v err=%v", keys, err)
+	}
+
+	if err := d.DeleteRequestAttachment(ctx, reqID, file.ID); err != nil {
+		t.Fatalf("delete attachment: %v", err)
+	}
+	if err := d.DeleteRequest(ctx, reqID); err != nil {
+		t.Fatalf("delete request: %v", err)
+	}
+	if list, _ := d.ListRequestAttachments(ctx, reqID); len(list) != 0 {
+		t.Fatalf("attachments survived request deletion: %+v", list)
+	}
+}
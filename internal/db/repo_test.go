@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -272,6 +273,64 @@ func TestListRequestsByStatus(t *testing.T) {
 	}
 }
 
+func TestListRequestsByAuthor(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ctx := context.Background()
+	for _, r := range []Request{
+		{RequesterEmail: "a", Title: "Dune", Authors: []string{"Frank Herbert"}, Status: "pending"},
+		{RequesterEmail: "a", Title: "Dune Messiah", Authors: []string{"frank herbert"}, Status: "declined"},
+		{RequesterEmail: "a", Title: "Dune Prequel", Authors: []string{"Brian Herbert", "Kevin J. Anderson"}, Status: "pending"},
+		{RequesterEmail: "a", Title: "Herbert's Garden", Authors: []string{"Frank Herbert Jr."}, Status: "pending"},
+	} {
+		if _, err := db.CreateRequest(ctx, &r); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	got, err := db.ListRequestsByAuthor(ctx, "FRANK HERBERT")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got) != 1 || got[0].Title != "Dune" {
+		t.Fatalf("got %+v, want only the open Dune request", got)
+	}
+}
+
+func TestListRequestsMissingPayload(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, r := range []*Request{
+		{Title: "Legacy", Status: "pending"},
+		{Title: "Attached", Status: "pending", ReadarrReq: json.RawMessage(`{"foreignBookId":"1"}`)},
+		{Title: "Failed", Status: "error"},
+		{Title: "Done", Status: "approved"},
+	} {
+		if _, err := db.CreateRequest(ctx, r); err != nil {
+			t.Fatalf("create %s: %v", r.Title, err)
+		}
+	}
+	got, err := db.ListRequestsMissingPayload(ctx, 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got) != 2 || got[0].Title != "Legacy" || got[1].Title != "Failed" {
+		t.Fatalf("unexpected requests: %+v", got)
+	}
+}
+
 func TestPing(t *testing.T) {
 	tdir := t.TempDir()
 	db, err := Open(filepath.Join(tdir, "scriptorum.db"))
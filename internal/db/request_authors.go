@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// RequestAuthor links a request to the Readarr author its add went to.
+// Created is set when that author was not in Readarr before the add, i.e.
+// Scriptorum created it for this request.
+type RequestAuthor struct {
+	RequestID       int64     `json:"requestId"`
+	BaseURL         string    `json:"-"`
+	ReadarrAuthorID int       `json:"readarrAuthorId"`
+	ForeignAuthorID string    `json:"foreignAuthorId,omitempty"`
+	Name            string    `json:"name"`
+	Created         bool      `json:"created"`
+	RemovedAt       time.Time `json:"removedAt,omitzero"`
+	RecordedAt      time.Time `json:"recordedAt"`
+}
+
+// Removed reports whether the author was already deleted from Readarr.
+func (a *RequestAuthor) Removed() bool { return !a.RemovedAt.IsZero() }
+
+const requestAuthorColumns = `request_id, base_url, readarr_author_id, foreign_author_id, name, created, removed_at, recorded_at`
+
+func scanRequestAuthor(row rowScanner) (*RequestAuthor, error) {
+	var a RequestAuthor
+	var created int
+	var removed, recorded string
+	if err := row.Scan(&a.RequestID, &a.BaseURL, &a.ReadarrAuthorID, &a.ForeignAuthorID, &a.Name, &created, &removed, &recorded); err != nil {
+		return nil, err
+	}
+	a.Created = created != 0
+	a.RemovedAt, _ = time.Parse(time.RFC3339Nano, removed)
+	a.RecordedAt, _ = time.Parse(time.RFC3339Nano, recorded)
+	return &a, nil
+}
+
+// RecordRequestAuthor stores the author a request's add went to, replacing
+// any earlier record for the request. A request that once created its author
+// keeps Created when a later retry finds the author already there.
+func (d *DB) RecordRequestAuthor(ctx context.Context, a *RequestAuthor) error {
+	if a.RecordedAt.IsZero() {
+		a.RecordedAt = time.Now().UTC()
+	}
+	_, err := d.sql.ExecContext(ctx, `
+INSERT INTO request_authors (request_id, base_url, readarr_author_id, foreign_author_id, name, created, removed_at, recorded_at)
+VALUES (?, ?, ?, ?, ?, ?, '', ?)
+ON CONFLICT(request_id) DO UPDATE SET
+  created = CASE WHEN request_authors.readarr_author_id = excluded.readarr_author_id AND request_authors.base_url = excluded.base_url
+    THEN MAX(request_authors.created, excluded.created) ELSE excluded.created END,
+  base_url = excluded.base_url,
+  readarr_author_id = excluded.readarr_author_id,
+  foreign_author_id = excluded.foreign_author_id,
+  name = excluded.name,
+  removed_at = '',
+  recorded_at = excluded.recorded_at`,
+		a.RequestID, strings.TrimRight(strings.TrimSpace(a.BaseURL), "/"), a.ReadarrAuthorID, strings.TrimSpace(a.ForeignAuthorID),
+		strings.TrimSpace(a.Name), boolToInt(a.Created), a.RecordedAt.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// GetRequestAuthor returns the author record of request id, or
+// sql.ErrNoRows when its add never reached an author.
+func (d *DB) GetRequestAuthor(ctx context.Context, id int64) (*RequestAuthor, error) {
+	return scanRequestAuthor(d.sql.QueryRowContext(ctx, `SELECT `+requestAuthorColumns+` FROM request_authors WHERE request_id=?`, id))
+}
+
+// CountOtherAuthorRequests counts requests other than excludeID that went to
+// the same Readarr author and are still live: not declined, not failed, and
+// whose author was not removed.
+func (d *DB) CountOtherAuthorRequests(ctx context.Context, baseURL string, readarrAuthorID int, excludeID int64) (int, error) {
+	var n int
+	err := d.sql.QueryRowContext(ctx, `
+SELECT COUNT(1) FROM request_authors ra JOIN requests r ON r.id = ra.request_id
+WHERE ra.base_url=? AND ra.readarr_author_id=? AND ra.request_id<>? AND ra.removed_at=''
+  AND r.status NOT IN ('declined', 'error')`,
+		strings.TrimRight(strings.TrimSpace(baseURL), "/"), readarrAuthorID, excludeID).Scan(&n)
+	return n, err
+}
+
+// MarkRequestAuthorRemoved records that the author of request id was deleted
+// from Readarr, along with every other record pointing at the same author.
+func (d *DB) MarkRequestAuthorRemoved(ctx context.Context, id int64) error {
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE request_authors SET removed_at=?
+WHERE removed_at='' AND (base_url, readarr_author_id) IN (SELECT base_url, readarr_author_id FROM request_authors WHERE request_id=?)`,
+		time.Now().UTC().Format(time.RFC3339Nano), id)
+	return err
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestAuthors(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", Format: "ebook", Status: "error"})
+	b, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "bob", Title: "Dune Messiah", Format: "ebook", Status: "queued"})
+
+	if _, err := d.GetRequestAuthor(ctx, a); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("missing record: %v", err)
+	}
+	if err := d.RecordRequestAuthor(ctx, &RequestAuthor{RequestID: a, BaseURL: "http://readarr/", ReadarrAuthorID: 5, Name: "Frank Herbert", Created: true}); err != nil {
+		t.Fatal(err)
+	}
+	// A retry that finds the author already there keeps the created flag.
+	if err := d.RecordRequestAuthor(ctx, &RequestAuthor{RequestID: a, BaseURL: "http://readarr", ReadarrAuthorID: 5, Name: "Frank Herbert"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := d.GetRequestAuthor(ctx, a)
+	if err != nil || !got.Created || got.BaseURL != "http://readarr" || got.ReadarrAuthorID != 5 || got.Removed() {
+		t.Fatalf("record = %+v, %v", got, err)
+	}
+
+	if n, _ := d.CountOtherAuthorRequests(ctx, "http://readarr", 5, a); n != 0 {
+		t.Fatalf("others before b = %d", n)
+	}
+	_ = d.RecordRequestAuthor(ctx, &RequestAuthor{RequestID: b, BaseURL: "http://readarr", ReadarrAuthorID: 5})
+	if n, _ := d.CountOtherAuthorRequests(ctx, "http://readarr", 5, a); n != 1 {
+		t.Fatalf("others with b = %d", n)
+	}
+	_ = d.DeclineRequest(ctx, b, "admin", "")
+	if n, _ := d.CountOtherAuthorRequests(ctx, "http://readarr", 5, a); n != 0 {
+		t.Fatalf("others after decline = %d", n)
+	}
+
+	if err := d.MarkRequestAuthorRemoved(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.GetRequestAuthor(ctx, b); !got.Removed() {
+		t.Fatal("removal should mark every record of the author")
+	}
+	_ = d.DeleteRequest(ctx, a)
+	if _, err := d.GetRequestAuthor(ctx, a); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("record after delete: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// FindDuplicateRequests returns the other requests that were not declined
+// for the same book in the same format as r: a shared ISBN-13, ISBN-10, or
+// ASIN, or the same Readarr book. Newest first.
+func (d *DB) FindDuplicateRequests(ctx context.Context, r *Request) ([]Request, error) {
+	if r.ISBN13 == "" && r.ISBN10 == "" && r.ASIN == "" && r.ForeignBookID == "" && r.MatchedReadarrID == 0 {
+		return nil, nil
+	}
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+requestColumns+` FROM requests
+WHERE id<>? AND format=? AND status<>'declined'
+  AND ((?<>'' AND isbn13=?) OR (?<>'' AND isbn10=?) OR (?<>'' AND asin=?) OR (?<>'' AND foreign_book_id=?) OR (?>0 AND matched_readarr_id=?))
+ORDER BY id DESC LIMIT 20`,
+		r.ID, r.Format,
+		r.ISBN13, r.ISBN13, r.ISBN10, r.ISBN10, r.ASIN, r.ASIN, r.ForeignBookID, r.ForeignBookID, r.MatchedReadarrID, r.MatchedReadarrID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRequests(rows)
+}
+
+// AddRequestWatcher has username notified about request id alongside its
+// requester.
+func (d *DB) AddRequestWatcher(ctx context.Context, id int64, username string) error {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if username == "" {
+		return nil
+	}
+	_, err := d.sql.ExecContext(ctx, `INSERT OR IGNORE INTO request_watchers (request_id, username) VALUES (?, ?)`, id, username)
+	return err
+}
+
+// ListRequestWatchers returns the users notified about request id besides
+// its requester, in alphabetical order.
+func (d *DB) ListRequestWatchers(ctx context.Context, id int64) ([]string, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT username FROM request_watchers WHERE request_id=? ORDER BY username`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// MergeRequests folds request dup into keep and deletes dup. Its
+// attachments, labels, history, and watchers move to keep, its requester
+// becomes a watcher of keep, and anything linked to dup points at keep.
+// keep takes over dup's linked request when it has none of its own.
+func (d *DB) MergeRequests(ctx context.Context, keep, dup int64) error {
+	if keep == dup {
+		return errors.New("cannot merge a request into itself")
+	}
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var keepRequester, dupRequester string
+	if err := tx.QueryRowContext(ctx, `SELECT requester_email FROM requests WHERE id=?`, keep).Scan(&keepRequester); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT requester_email FROM requests WHERE id=?`, dup).Scan(&dupRequester); err != nil {
+		return err
+	}
+	for _, stmt := range []struct {
+		sql  string
+		args []any
+	}{
+		{`UPDATE request_attachments SET request_id=? WHERE request_id=?`, []any{keep, dup}},
+		{`INSERT OR IGNORE INTO request_labels (request_id, label) SELECT ?, label FROM request_labels WHERE request_id=?`, []any{keep, dup}},
+		{`DELETE FROM request_labels WHERE request_id=?`, []any{dup}},
+		{`UPDATE audit_events SET request_id=? WHERE request_id=?`, []any{keep, dup}},
+		{`INSERT OR IGNORE INTO request_watchers (request_id, username) SELECT ?, username FROM request_watchers WHERE request_id=? AND username<>?`, []any{keep, dup, keepRequester}},
+		{`INSERT OR IGNORE INTO request_watchers (request_id, username) SELECT ?, ? WHERE ?<>? AND ?<>''`, []any{keep, dupRequester, dupRequester, keepRequester, dupRequester}},
+		{`DELETE FROM request_watchers WHERE request_id=?`, []any{dup}},
+		{`INSERT OR IGNORE INTO request_authors (request_id, base_url, readarr_author_id, foreign_author_id, name, created, removed_at, recorded_at) SELECT ?, base_url, readarr_author_id, foreign_author_id, name, created, removed_at, recorded_at FROM request_authors WHERE request_id=?`, []any{keep, dup}},
+		{`DELETE FROM request_authors WHERE request_id=?`, []any{dup}},
+		{`UPDATE requests SET linked_request_id=(SELECT linked_request_id FROM requests WHERE id=?) WHERE id=? AND linked_request_id=0`, []any{dup, keep}},
+		{`UPDATE requests SET linked_request_id=? WHERE linked_request_id=? AND id<>?`, []any{keep, dup, keep}},
+		{`UPDATE requests SET linked_request_id=0 WHERE id=? AND linked_request_id=?`, []any{keep, dup}},
+		{`UPDATE subscriptions SET last_request_id=? WHERE last_request_id=?`, []any{keep, dup}},
+		{`DELETE FROM requests WHERE id=?`, []any{dup}},
+	} {
+		if _, err := tx.ExecContext(ctx, stmt.sql, stmt.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeRequests(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	keep, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", ISBN13: "9780441172719", Format: "ebook", Status: "pending"})
+	dup, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "bob", Title: "Dune (Deluxe)", ISBN13: "9780441172719", Format: "ebook", Status: "pending"})
+	audio, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "bob", Title: "Dune", ISBN13: "9780441172719", Format: "audiobook", Status: "pending"})
+	other, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "cat", Title: "Emma", ISBN13: "9780141439587", Format: "ebook", Status: "pending"})
+
+	r, _ := d.GetRequest(ctx, keep)
+	dups, err := d.FindDuplicateRequests(ctx, r)
+	if err != nil || len(dups) != 1 || dups[0].ID != dup {
+		t.Fatalf("duplicates = %+v, %v", dups, err)
+	}
+	if o, _ := d.GetRequest(ctx, other); len(must(d.FindDuplicateRequests(ctx, o))) != 0 {
+		t.Fatal("unrelated request reported as a duplicate")
+	}
+
+	_ = d.SetRequestLabels(ctx, keep, []string{"gift"})
+	_ = d.SetRequestLabels(ctx, dup, []string{"gift", "book club"})
+	_ = d.AddRequestWatcher(ctx, dup, "Dan")
+	_ = d.AddRequestWatcher(ctx, dup, "amy")
+	_ = d.InsertAuditEvent(ctx, "bob", "request.created", &dup, "")
+	if err := d.LinkRequests(ctx, dup, audio); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.MergeRequests(ctx, keep, keep); err == nil {
+		t.Fatal("merged a request into itself")
+	}
+	if err := d.MergeRequests(ctx, keep, dup); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.GetRequest(ctx, dup); err == nil {
+		t.Fatal("duplicate still exists")
+	}
+	if labels, _ := d.GetRequestLabels(ctx, keep); strings.Join(labels, "|") != "book club|gift" {
+		t.Fatalf("labels = %q", labels)
+	}
+	if w, _ := d.ListRequestWatchers(ctx, keep); strings.Join(w, "|") != "bob|dan" {
+		t.Fatalf("watchers = %q", w)
+	}
+	if ev, _ := d.ListRequestAuditEvents(ctx, keep, 10); len(ev) != 1 || ev[0].EventType != "request.created" {
+		t.Fatalf("history = %+v", ev)
+	}
+	k, _ := d.GetRequest(ctx, keep)
+	a, _ := d.GetRequest(ctx, audio)
+	if k.LinkedRequestID != audio || a.LinkedRequestID != keep {
+		t.Fatalf("links = %d, %d", k.LinkedRequestID, a.LinkedRequestID)
+	}
+
+	if err := d.DeleteRequest(ctx, keep); err != nil {
+		t.Fatal(err)
+	}
+	if w, _ := d.ListRequestWatchers(ctx, keep); len(w) != 0 {
+		t.Fatalf("watchers left after delete: %q", w)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestBackfillRequestIdentifiers(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", ISBN10: "0441172717", Format: "ebook", Status: "pending"})
+	other, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "bob", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err := d.BackfillRequestIdentifiers(ctx, id, RequestIdentifiers{ISBN10: "1111111111", ISBN13: "9780441172719", ASIN: "B00B7NPPY8", ForeignBookID: "234225"}); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := d.GetRequest(ctx, id)
+	if r.ISBN10 != "0441172717" || r.ISBN13 != "9780441172719" || r.ASIN != "B00B7NPPY8" || r.ForeignBookID != "234225" {
+		t.Fatalf("backfilled = %+v, want the existing ISBN-10 kept", r)
+	}
+	_ = d.BackfillRequestIdentifiers(ctx, other, RequestIdentifiers{ForeignBookID: "234225"})
+	if dups, _ := d.FindDuplicateRequests(ctx, r); len(dups) != 1 || dups[0].ID != other {
+		t.Fatalf("duplicates by foreign book id = %+v", dups)
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// AdminNote is the internal note admins keep on a request, such as
+// "waiting for paperback release". Requesters never see it.
+type AdminNote struct {
+	Text      string     `json:"note"`
+	UpdatedBy string     `json:"updatedBy,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// GetRequestAdminNote returns the admin note on request id, empty when
+// there is none. It returns sql.ErrNoRows for an unknown request.
+func (d *DB) GetRequestAdminNote(ctx context.Context, id int64) (AdminNote, error) {
+	var n AdminNote
+	var at sql.NullString
+	err := d.sql.QueryRowContext(ctx, `SELECT COALESCE(admin_note,''), COALESCE(admin_note_by,''), admin_note_at FROM requests WHERE id=?`, id).Scan(&n.Text, &n.UpdatedBy, &at)
+	if err != nil {
+		return AdminNote{}, err
+	}
+	if at.Valid && at.String != "" {
+		t, _ := time.Parse(time.RFC3339Nano, at.String)
+		n.UpdatedAt = &t
+	}
+	return n, nil
+}
+
+// SetRequestAdminNote replaces the admin note on request id. An empty note
+// clears it.
+func (d *DB) SetRequestAdminNote(ctx context.Context, id int64, note, actor string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	note = strings.TrimSpace(note)
+	if note == "" {
+		_, err := d.sql.ExecContext(ctx, `UPDATE requests SET admin_note='', admin_note_by='', admin_note_at=NULL, updated_at=? WHERE id=?`, now, id)
+		return err
+	}
+	_, err := d.sql.ExecContext(ctx, `UPDATE requests SET admin_note=?, admin_note_by=?, admin_note_at=?, updated_at=? WHERE id=?`, note, strings.ToLower(strings.TrimSpace(actor)), now, now, id)
+	return err
+}
+
+// RequestAdminNotes returns the admin notes on the given requests, keyed by
+// request id. Requests without a note are left out.
+func (d *DB) RequestAdminNotes(ctx context.Context, ids []int64) (map[int64]string, error) {
+	out := map[int64]string{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := d.sql.QueryContext(ctx, `SELECT id, admin_note FROM requests WHERE admin_note<>'' AND id IN (?`+strings.Repeat(",?", len(ids)-1)+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var note string
+		if err := rows.Scan(&id, &note); err != nil {
+			return nil, err
+		}
+		out[id] = note
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequestAdminNotes(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", Format: "ebook", Status: "pending"})
+	b, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Emma", Format: "ebook", Status: "pending"})
+
+	if err := d.SetRequestAdminNote(ctx, a, "  waiting for paperback release ", "Admin"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := d.GetRequestAdminNote(ctx, a)
+	if err != nil || n.Text != "waiting for paperback release" || n.UpdatedBy != "admin" || n.UpdatedAt == nil {
+		t.Fatalf("note = %+v, %v", n, err)
+	}
+	if _, err := d.GetRequestAdminNote(ctx, 999); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unknown request = %v, want sql.ErrNoRows", err)
+	}
+	notes, err := d.RequestAdminNotes(ctx, []int64{a, b})
+	if err != nil || len(notes) != 1 || notes[a] != "waiting for paperback release" {
+		t.Fatalf("notes = %v, %v", notes, err)
+	}
+	req, _ := d.GetRequest(ctx, a)
+	if raw, _ := json.Marshal(req); strings.Contains(string(raw), "paperback") {
+		t.Fatalf("request JSON carries the admin note: %s", raw)
+	}
+
+	if err := d.SetRequestAdminNote(ctx, a, " ", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := d.GetRequestAdminNote(ctx, a); n.Text != "" || n.UpdatedBy != "" || n.UpdatedAt != nil {
+		t.Fatalf("cleared note = %+v", n)
+	}
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrStaleRequest is returned when a request changed since the version a
+// caller acted on.
+var ErrStaleRequest = errors.New("request changed since it was read")
+
+// ClaimRequestVersion moves request id's updated_at on, but only while it is
+// still seen, so of two callers acting on the same version exactly one
+// wins. The update is conditional on the stored value itself, so rows
+// written with an older timestamp format still match. It returns
+// ErrStaleRequest when the request has moved on (or is gone).
+func (d *DB) ClaimRequestVersion(ctx context.Context, id int64, seen time.Time) error {
+	var stored string
+	err := d.sql.QueryRowContext(ctx, `SELECT updated_at FROM requests WHERE id=?`, id).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrStaleRequest
+	}
+	if err != nil {
+		return err
+	}
+	cur, _ := time.Parse(time.RFC3339Nano, stored)
+	if !cur.Equal(seen) {
+		return ErrStaleRequest
+	}
+	next := time.Now().UTC()
+	if !next.After(cur) {
+		next = cur.Add(time.Microsecond)
+	}
+	res, err := d.sql.ExecContext(ctx, `UPDATE requests SET updated_at=? WHERE id=? AND updated_at=?`, next.Format(time.RFC3339Nano), id, stored)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrStaleRequest
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Session is a signed-in browser. The cookie carries only its id, so
+// deleting the row signs the browser out.
+type Session struct {
+	ID        string
+	Username  string
+	Created   time.Time
+	LastSeen  time.Time
+	Expires   time.Time
+	Remember  bool
+	UserAgent string
+}
+
+// CreateSession records a new session.
+func (d *DB) CreateSession(ctx context.Context, s Session) error {
+	_, err := d.sql.ExecContext(ctx, `
+INSERT INTO sessions (id, username, created_at, last_seen_at, expires_at, remember, user_agent)
+VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, strings.ToLower(s.Username), s.Created.UTC().Format(time.RFC3339Nano), s.LastSeen.UTC().Format(time.RFC3339Nano),
+		s.Expires.UTC().Format(time.RFC3339Nano), boolToInt(s.Remember), s.UserAgent)
+	return err
+}
+
+const sessionColumns = `id, username, created_at, last_seen_at, expires_at, remember, user_agent`
+
+func scanSession(sc rowScanner) (Session, error) {
+	var s Session
+	var created, seen, expires string
+	var remember int
+	if err := sc.Scan(&s.ID, &s.Username, &created, &seen, &expires, &remember, &s.UserAgent); err != nil {
+		return s, err
+	}
+	s.Created, _ = time.Parse(time.RFC3339Nano, created)
+	s.LastSeen, _ = time.Parse(time.RFC3339Nano, seen)
+	s.Expires, _ = time.Parse(time.RFC3339Nano, expires)
+	s.Remember = remember == 1
+	return s, nil
+}
+
+// GetSession returns the session with the given id.
+func (d *DB) GetSession(ctx context.Context, id string) (*Session, error) {
+	s, err := scanSession(d.sql.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id=?`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListUserSessions returns username's sessions, most recently used first.
+func (d *DB) ListUserSessions(ctx context.Context, username string) ([]Session, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE username=? ORDER BY last_seen_at DESC`, strings.ToLower(username))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// TouchSession records activity on a session.
+func (d *DB) TouchSession(ctx context.Context, id string, at time.Time) error {
+	_, err := d.sql.ExecContext(ctx, `UPDATE sessions SET last_seen_at=? WHERE id=?`, at.UTC().Format(time.RFC3339Nano), id)
+	return err
+}
+
+// DeleteSession revokes one session.
+func (d *DB) DeleteSession(ctx context.Context, id string) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM sessions WHERE id=?`, id)
+	return err
+}
+
+// DeleteUserSessions revokes every session of username except keepID, and
+// returns how many were revoked.
+func (d *DB) DeleteUserSessions(ctx context.Context, username, keepID string) (int64, error) {
+	res, err := d.sql.ExecContext(ctx, `DELETE FROM sessions WHERE username=? AND id<>?`, strings.ToLower(username), keepID)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PruneSessions deletes sessions that expired before cutoff.
+func (d *DB) PruneSessions(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := d.sql.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < ?`, cutoff.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
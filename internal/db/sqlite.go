@@ -9,7 +9,12 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-type DB struct{ sql *sql.DB }
+type DB struct {
+	sql  *sql.DB
+	path string
+	// statusCheck vets request status changes; see SetStatusCheck.
+	statusCheck func(from, to string) error
+}
 
 func Open(path string) (*DB, error) {
 	// WAL allows concurrent readers alongside a single writer; busy_timeout makes
@@ -24,7 +29,7 @@ func Open(path string) (*DB, error) {
 	// the bottleneck for this workload, so the simplicity is worth more than the
 	// marginal read concurrency a larger pool would add.
 	s.SetMaxOpenConns(1)
-	return &DB{sql: s}, nil
+	return &DB{sql: s, path: path}, nil
 }
 
 func (d *DB) Close() error { return d.sql.Close() }
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrStatusTransition is returned when the status check refuses a move.
+var ErrStatusTransition = errors.New("status change not allowed")
+
+// SetStatusCheck installs fn to vet every status change made through
+// UpdateRequestStatus, ApproveRequest, and DeclineRequest. fn gets the
+// current and new status; a non-nil error blocks the change. Call it
+// before the database is shared; nil removes the check.
+func (d *DB) SetStatusCheck(fn func(from, to string) error) {
+	d.statusCheck = fn
+}
+
+// checkStatus runs the status check for moving request id to status. An
+// unknown request passes, leaving the update itself to match no row.
+func (d *DB) checkStatus(ctx context.Context, id int64, status string) error {
+	if d.statusCheck == nil {
+		return nil
+	}
+	var cur string
+	err := d.sql.QueryRowContext(ctx, `SELECT status FROM requests WHERE id=?`, id).Scan(&cur)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := d.statusCheck(cur, status); err != nil {
+		return fmt.Errorf("%w: %v", ErrStatusTransition, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatusCheckGuardsStatusChanges(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Dune", Format: "ebook", Status: "pending"})
+	other, _ := d.CreateRequest(ctx, &Request{RequesterEmail: "amy", Title: "Emma", Format: "ebook", Status: "pending"})
+	d.SetStatusCheck(func(from, to string) error {
+		if from == "on_hold" && to != "pending" {
+			return fmt.Errorf("cannot leave on_hold for %s", to)
+		}
+		return nil
+	})
+
+	if err := d.UpdateRequestStatus(ctx, id, "on_hold", "", "admin", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ApproveRequest(ctx, id, "admin"); !errors.Is(err, ErrStatusTransition) {
+		t.Fatalf("approve from on_hold = %v, want ErrStatusTransition", err)
+	}
+	if err := d.DeclineRequest(ctx, id, "admin", ""); !errors.Is(err, ErrStatusTransition) {
+		t.Fatalf("decline from on_hold = %v, want ErrStatusTransition", err)
+	}
+	if req, _ := d.GetRequest(ctx, id); req.Status != "on_hold" {
+		t.Fatalf("status = %q after refused changes", req.Status)
+	}
+	page, err := d.ListRequestsPageScoped(ctx, RequestScope{Status: "On_Hold"}, 10)
+	if err != nil || len(page) != 1 || page[0].ID != id {
+		t.Fatalf("status scope = %+v, %v", page, err)
+	}
+	if err := d.UpdateRequestStatus(ctx, id, "pending", "", "admin", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ApproveRequest(ctx, other, "admin"); err != nil {
+		t.Fatal(err)
+	}
+}
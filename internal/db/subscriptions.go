@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Subscription is a recurring magazine request. Each issue becomes its own
+// request when NextIssueAt comes around.
+type Subscription struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"createdAt"`
+	RequesterEmail string    `json:"requester"`
+	Title          string    `json:"title"`
+	Publisher      string    `json:"publisher,omitempty"`
+	// Frequency is weekly, biweekly, monthly, bimonthly, or quarterly.
+	Frequency     string    `json:"frequency"`
+	GroupName     string    `json:"group,omitempty"`
+	Active        bool      `json:"active"`
+	NextIssueAt   time.Time `json:"nextIssueAt"`
+	LastIssueAt   time.Time `json:"lastIssueAt"`
+	IssueCount    int       `json:"issueCount"`
+	LastRequestID int64     `json:"lastRequestId,omitempty"`
+}
+
+const subscriptionColumns = `id, created_at, requester_email, title, publisher, frequency, group_name, active, next_issue_at, last_issue_at, issue_count, last_request_id`
+
+func scanSubscription(row interface{ Scan(...any) error }) (*Subscription, error) {
+	var s Subscription
+	var created, next, last string
+	var active int
+	if err := row.Scan(&s.ID, &created, &s.RequesterEmail, &s.Title, &s.Publisher, &s.Frequency, &s.GroupName, &active, &next, &last, &s.IssueCount, &s.LastRequestID); err != nil {
+		return nil, err
+	}
+	s.Active = active != 0
+	s.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+	s.NextIssueAt, _ = time.Parse(time.RFC3339Nano, next)
+	s.LastIssueAt, _ = time.Parse(time.RFC3339Nano, last)
+	return &s, nil
+}
+
+func formatSubscriptionTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// CreateSubscription stores s as active and returns its id.
+func (d *DB) CreateSubscription(ctx context.Context, s *Subscription) (int64, error) {
+	now := time.Now().UTC()
+	s.CreatedAt, s.Active = now, true
+	res, err := d.sql.ExecContext(ctx, `
+INSERT INTO subscriptions (created_at, requester_email, title, publisher, frequency, group_name, active, next_issue_at, last_issue_at, issue_count, last_request_id)
+VALUES (?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?)`,
+		now.Format(time.RFC3339Nano), strings.ToLower(s.RequesterEmail), s.Title, s.Publisher, s.Frequency,
+		strings.ToLower(strings.TrimSpace(s.GroupName)), formatSubscriptionTime(s.NextIssueAt), formatSubscriptionTime(s.LastIssueAt),
+		s.IssueCount, s.LastRequestID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	s.ID, _ = res.LastInsertId()
+	return s.ID, nil
+}
+
+// GetSubscription returns subscription id, or sql.ErrNoRows.
+func (d *DB) GetSubscription(ctx context.Context, id int64) (*Subscription, error) {
+	return scanSubscription(d.sql.QueryRowContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id=?`, id))
+}
+
+// ListSubscriptions returns subscriptions newest first: requester's own, or
+// all of them when requester is empty.
+func (d *DB) ListSubscriptions(ctx context.Context, requester string) ([]Subscription, error) {
+	var rows *sql.Rows
+	var err error
+	if requester = strings.ToLower(strings.TrimSpace(requester)); requester != "" {
+		rows, err = d.sql.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE requester_email=? ORDER BY id DESC`, requester)
+	} else {
+		rows, err = d.sql.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions ORDER BY id DESC`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// ListDueSubscriptions returns active subscriptions whose next issue is at
+// or before now, oldest first.
+func (d *DB) ListDueSubscriptions(ctx context.Context, now time.Time) ([]Subscription, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE active=1 ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		// Timestamps are RFC3339Nano text, which does not sort reliably as
+		// a string, so the due filter runs here.
+		if s.NextIssueAt.IsZero() || s.NextIssueAt.After(now) {
+			continue
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// RecordSubscriptionIssue notes that requestID was created for the issue
+// due at issueAt and schedules the next one.
+func (d *DB) RecordSubscriptionIssue(ctx context.Context, id, requestID int64, issueAt, next time.Time) error {
+	_, err := d.sql.ExecContext(ctx, `
+UPDATE subscriptions SET issue_count=issue_count+1, last_request_id=?, last_issue_at=?, next_issue_at=? WHERE id=?`,
+		requestID, formatSubscriptionTime(issueAt), formatSubscriptionTime(next), id)
+	return err
+}
+
+// SetSubscriptionActive pauses or resumes subscription id. Resuming sets
+// the next issue to next so missed issues are not requested in a burst.
+func (d *DB) SetSubscriptionActive(ctx context.Context, id int64, active bool, next time.Time) error {
+	if !active {
+		_, err := d.sql.ExecContext(ctx, `UPDATE subscriptions SET active=0 WHERE id=?`, id)
+		return err
+	}
+	_, err := d.sql.ExecContext(ctx, `UPDATE subscriptions SET active=1, next_issue_at=? WHERE id=?`, formatSubscriptionTime(next), id)
+	return err
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSubscriptions(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	due, err := d.CreateSubscription(ctx, &Subscription{RequesterEmail: "U", Title: "Wired", Frequency: "monthly", NextIssueAt: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later, _ := d.CreateSubscription(ctx, &Subscription{RequesterEmail: "v", Title: "Economist", Frequency: "weekly", NextIssueAt: now.Add(time.Hour)})
+
+	got, err := d.ListDueSubscriptions(ctx, now)
+	if err != nil || len(got) != 1 || got[0].ID != due || !got[0].Active || got[0].RequesterEmail != "u" {
+		t.Fatalf("due = %+v, %v", got, err)
+	}
+	if err := d.RecordSubscriptionIssue(ctx, due, 42, now, now.AddDate(0, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.ListDueSubscriptions(ctx, now); len(got) != 0 {
+		t.Fatalf("still due after recording an issue: %+v", got)
+	}
+	s, err := d.GetSubscription(ctx, due)
+	if err != nil || s.IssueCount != 1 || s.LastRequestID != 42 || !s.NextIssueAt.After(now) {
+		t.Fatalf("after issue: %+v, %v", s, err)
+	}
+
+	if err := d.SetSubscriptionActive(ctx, later, false, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.ListDueSubscriptions(ctx, now.Add(2*time.Hour)); len(got) != 0 {
+		t.Fatalf("paused subscription listed as due: %+v", got)
+	}
+	if mine, _ := d.ListSubscriptions(ctx, "u"); len(mine) != 1 {
+		t.Fatalf("own subscriptions = %+v", mine)
+	}
+	if all, _ := d.ListSubscriptions(ctx, ""); len(all) != 2 || all[0].ID != later || all[0].Active {
+		t.Fatalf("all subscriptions = %+v", all)
+	}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// usageDayLayout is how cache_stats and provider_calls key their days (UTC).
+const usageDayLayout = "2006-01-02"
+
+// CacheStat is the hits and misses on one readarr_cache type.
+type CacheStat struct {
+	CacheType string `json:"cache_type"`
+	Hits      int64  `json:"hits"`
+	Misses    int64  `json:"misses"`
+}
+
+// ProviderCallCount is how many outbound calls went to one provider on one
+// UTC day.
+type ProviderCallCount struct {
+	Day      string `json:"day"` // YYYY-MM-DD
+	Provider string `json:"provider"`
+	Calls    int64  `json:"calls"`
+}
+
+// CountProviderCall adds one call to provider's count for today.
+func (d *DB) CountProviderCall(ctx context.Context, provider string) error {
+	_, err := d.sql.ExecContext(ctx, `
+INSERT INTO provider_calls (day, provider, calls) VALUES (?, ?, 1)
+ON CONFLICT(day, provider) DO UPDATE SET calls = calls + 1`, time.Now().UTC().Format(usageDayLayout), provider)
+	return err
+}
+
+// CacheStatsSince totals cache hits and misses per cache type from the UTC
+// day of since onwards, busiest type first.
+func (d *DB) CacheStatsSince(ctx context.Context, since time.Time) ([]CacheStat, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT cache_type, SUM(hits), SUM(misses)
+FROM cache_stats
+WHERE day >= ?
+GROUP BY cache_type
+ORDER BY SUM(hits) + SUM(misses) DESC, cache_type`, since.UTC().Format(usageDayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CacheStat
+	for rows.Next() {
+		var c CacheStat
+		if err := rows.Scan(&c.CacheType, &c.Hits, &c.Misses); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ProviderCallsSince lists the daily call counts from the UTC day of since
+// onwards, by provider and then day.
+func (d *DB) ProviderCallsSince(ctx context.Context, since time.Time) ([]ProviderCallCount, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+SELECT day, provider, calls
+FROM provider_calls
+WHERE day >= ?
+ORDER BY provider, day`, since.UTC().Format(usageDayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ProviderCallCount
+	for rows.Next() {
+		var c ProviderCallCount
+		if err := rows.Scan(&c.Day, &c.Provider, &c.Calls); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// PruneUsageStats deletes cache and provider counters for days before
+// cutoff's UTC day.
+func (d *DB) PruneUsageStats(ctx context.Context, cutoff time.Time) (int64, error) {
+	day := cutoff.UTC().Format(usageDayLayout)
+	res, err := d.sql.ExecContext(ctx, `DELETE FROM cache_stats WHERE day < ?`, day)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	res, err = d.sql.ExecContext(ctx, `DELETE FROM provider_calls WHERE day < ?`, day)
+	if err != nil {
+		return n, err
+	}
+	m, _ := res.RowsAffected()
+	return n + m, nil
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUsageStatsCountAndPrune(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "scriptorum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if err := d.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := d.CountProviderCall(ctx, "openlibrary"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.sql.Exec(`INSERT INTO provider_calls (day, provider, calls) VALUES ('2020-01-01', 'openlibrary', 9)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.sql.Exec(`INSERT INTO cache_stats (day, cache_type, hits, misses) VALUES (?, 'lookup', 4, 1), ('2020-01-01', 'lookup', 50, 50)`, time.Now().UTC().Format(usageDayLayout)); err != nil {
+		t.Fatal(err)
+	}
+
+	week := time.Now().AddDate(0, 0, -7)
+	calls, err := d.ProviderCallsSince(ctx, week)
+	if err != nil || len(calls) != 1 || calls[0].Provider != "openlibrary" || calls[0].Calls != 3 {
+		t.Fatalf("calls = %+v, %v", calls, err)
+	}
+	caches, err := d.CacheStatsSince(ctx, week)
+	if err != nil || len(caches) != 1 || caches[0] != (CacheStat{CacheType: "lookup", Hits: 4, Misses: 1}) {
+		t.Fatalf("caches = %+v, %v", caches, err)
+	}
+
+	if n, err := d.PruneUsageStats(ctx, week); err != nil || n != 2 {
+		t.Fatalf("pruned %d, %v", n, err)
+	}
+	if all, _ := d.ProviderCallsSince(ctx, time.Time{}); len(all) != 1 {
+		t.Fatalf("old counters survived the prune: %+v", all)
+	}
+}
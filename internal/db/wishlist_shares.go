@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// WishlistShare is a revocable link that shows one user's requests to
+// anyone holding the token, without logging in.
+type WishlistShare struct {
+	Token     string    `json:"token"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateWishlistShare records a new share token for username.
+func (d *DB) CreateWishlistShare(ctx context.Context, token, username string) (*WishlistShare, error) {
+	sh := &WishlistShare{Token: token, Username: strings.ToLower(username), CreatedAt: time.Now().UTC()}
+	if _, err := d.sql.ExecContext(ctx, `INSERT INTO wishlist_shares (token, username, created_at) VALUES (?, ?, ?)`, sh.Token, sh.Username, sh.CreatedAt.Format(time.RFC3339Nano)); err != nil {
+		return nil, err
+	}
+	return sh, nil
+}
+
+// GetWishlistShare looks up a share token, returning nil when it is unknown
+// or has been revoked.
+func (d *DB) GetWishlistShare(ctx context.Context, token string) (*WishlistShare, error) {
+	var sh WishlistShare
+	var created string
+	err := d.sql.QueryRowContext(ctx, `SELECT token, username, created_at FROM wishlist_shares WHERE token=?`, token).Scan(&sh.Token, &sh.Username, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sh.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+	return &sh, nil
+}
+
+// ListWishlistShares returns username's share links, newest first.
+func (d *DB) ListWishlistShares(ctx context.Context, username string) ([]WishlistShare, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT token, username, created_at FROM wishlist_shares WHERE username=? ORDER BY created_at DESC`, strings.ToLower(username))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []WishlistShare
+	for rows.Next() {
+		var sh WishlistShare
+		var created string
+		if err := rows.Scan(&sh.Token, &sh.Username, &created); err != nil {
+			return nil, err
+		}
+		sh.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+		out = append(out, sh)
+	}
+	return out, rows.Err()
+}
+
+// RevokeWishlistShare deletes one of username's share tokens, reporting
+// whether it existed.
+func (d *DB) RevokeWishlistShare(ctx context.Context, username, token string) (bool, error) {
+	res, err := d.sql.ExecContext(ctx, `DELETE FROM wishlist_shares WHERE token=? AND username=?`, token, strings.ToLower(username))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,690 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL result. Data is omitted when the request failed
+// before execution started.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a request or field error.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Prepared is a parsed and validated operation ready to run.
+type Prepared struct {
+	schema *Schema
+	doc    *document
+	op     *operation
+	root   *Object
+	vars   map[string]any
+	fields int // fields selected so far, for MaxFields
+}
+
+// Execute runs a query. Request errors are reported in the response.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	p, err := s.Prepare(req)
+	if err != nil {
+		return &Response{Errors: []*Error{toError(err)}}
+	}
+	return p.Execute(ctx)
+}
+
+// Prepare parses req, picks the operation to run, validates it against the
+// schema, and coerces its variables.
+func (s *Schema) Prepare(req Request) (*Prepared, error) {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	var op *operation
+	switch {
+	case req.OperationName != "":
+		for _, o := range doc.operations {
+			if o.name == req.OperationName {
+				op = o
+			}
+		}
+		if op == nil {
+			return nil, fmt.Errorf("unknown operation %q", req.OperationName)
+		}
+	case len(doc.operations) == 1:
+		op = doc.operations[0]
+	default:
+		return nil, fmt.Errorf("operationName is required when the document contains several operations")
+	}
+
+	p := &Prepared{schema: s, doc: doc, op: op}
+	switch op.kind {
+	case "query":
+		p.root = s.Query
+	case "subscription":
+		if s.Subscription == nil {
+			return nil, fmt.Errorf("subscriptions are not supported")
+		}
+		p.root = s.Subscription
+		if fields := p.collect(p.root, op.selections, nil); len(fields) != 1 {
+			return nil, fmt.Errorf("a subscription must select exactly one top-level field")
+		}
+	default:
+		return nil, fmt.Errorf("%s operations are not supported", op.kind)
+	}
+
+	if err := p.validate(p.root, op.selections, map[string]bool{}, 1); err != nil {
+		return nil, err
+	}
+	if p.vars, err = coerceVariables(op.vars, req.Variables); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Kind reports "query" or "subscription".
+func (p *Prepared) Kind() string { return p.op.kind }
+
+// Execute runs a prepared query operation.
+func (p *Prepared) Execute(ctx context.Context) *Response {
+	if p.op.kind != "query" {
+		return &Response{Errors: []*Error{{Message: "subscriptions must be executed with Subscribe"}}}
+	}
+	e := &executor{p: p, ctx: ctx}
+	data := e.selectionSet(p.root, nil, p.op.selections, nil)
+	return &Response{Data: data, Errors: e.errs}
+}
+
+// Subscribe starts a prepared subscription. Each event from the root field's
+// source is resolved and sent as a Response. The returned channel is closed
+// when the source closes or ctx is done.
+func (p *Prepared) Subscribe(ctx context.Context) (<-chan *Response, error) {
+	if p.op.kind != "subscription" {
+		return nil, fmt.Errorf("only subscription operations can be subscribed to")
+	}
+	group := p.collect(p.root, p.op.selections, nil)[0]
+	f := group.fields[0]
+	def := p.root.field(f.name)
+	if def == nil || def.Subscribe == nil {
+		return nil, fmt.Errorf("field %q cannot be subscribed to", f.name)
+	}
+	args, err := coerceArgs(def, f.args, p.vars)
+	if err != nil {
+		return nil, err
+	}
+	src, err := def.Subscribe(ResolveParams{Context: ctx, Args: args})
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan *Response)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-src:
+				if !ok {
+					return
+				}
+				e := &executor{p: p, ctx: ctx}
+				path := []any{group.key}
+				data := &orderedMap{}
+				data.set(group.key, e.complete(def.Type, group.fields, ev, path))
+				select {
+				case out <- &Response{Data: data, Errors: e.errs}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func toError(err error) *Error {
+	var ge *Error
+	if errors.As(err, &ge) {
+		return ge
+	}
+	return &Error{Message: err.Error()}
+}
+
+// fieldGroup is every selected field sharing one response key.
+type fieldGroup struct {
+	key    string
+	fields []*astField
+}
+
+// collect flattens fragments and applies @skip/@include, merging fields that
+// share a response key. Variables are taken from p.vars, which is nil during
+// validation; directives are then assumed to include.
+func (p *Prepared) collect(obj *Object, sels []selection, visited map[string]bool) []*fieldGroup {
+	if visited == nil {
+		visited = map[string]bool{}
+	}
+	var groups []*fieldGroup
+	index := map[string]*fieldGroup{}
+	var walk func(sels []selection)
+	walk = func(sels []selection) {
+		for _, sel := range sels {
+			switch s := sel.(type) {
+			case *astField:
+				if !p.included(s.directives) {
+					continue
+				}
+				g := index[s.responseKey()]
+				if g == nil {
+					g = &fieldGroup{key: s.responseKey()}
+					index[g.key] = g
+					groups = append(groups, g)
+				}
+				g.fields = append(g.fields, s)
+			case *inlineFragment:
+				if !p.included(s.directives) || (s.typeCond != "" && s.typeCond != obj.Name) {
+					continue
+				}
+				walk(s.selections)
+			case *fragmentSpread:
+				if !p.included(s.directives) || visited[s.name] {
+					continue
+				}
+				frag := p.doc.fragments[s.name]
+				if frag == nil || frag.typeCond != obj.Name {
+					continue
+				}
+				visited[s.name] = true
+				walk(frag.selections)
+				delete(visited, s.name)
+			}
+		}
+	}
+	walk(sels)
+	return groups
+}
+
+func (p *Prepared) included(dirs []*directive) bool {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		var cond any
+		for _, a := range d.args {
+			if a.name == "if" {
+				cond = a.value
+			}
+		}
+		if ref, ok := cond.(variableRef); ok {
+			cond = p.vars[string(ref)]
+		}
+		b, _ := cond.(bool)
+		if (d.name == "skip" && b) || (d.name == "include" && !b && p.vars != nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks selections against the schema so typos fail the whole
+// request instead of silently returning null. It also enforces the
+// schema's depth and field limits, so a query nesting linked objects or
+// fragments cannot make one request resolve without bound. depth is the
+// nesting level of sels, starting at 1 for the operation's own fields.
+func (p *Prepared) validate(obj *Object, sels []selection, fragPath map[string]bool, depth int) error {
+	maxDepth, maxFields := p.schema.MaxDepth, p.schema.MaxFields
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if maxFields <= 0 {
+		maxFields = DefaultMaxFields
+	}
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *astField:
+			if depth > maxDepth {
+				return fmt.Errorf("query is nested too deeply (maximum depth %d)", maxDepth)
+			}
+			if p.fields++; p.fields > maxFields {
+				return fmt.Errorf("query selects too many fields (maximum %d)", maxFields)
+			}
+			for _, d := range s.directives {
+				if d.name != "skip" && d.name != "include" {
+					return fmt.Errorf("unknown directive @%s", d.name)
+				}
+			}
+			if s.name == "__typename" {
+				if len(s.selections) > 0 {
+					return fmt.Errorf("field \"__typename\" must not have a selection")
+				}
+				continue
+			}
+			def := obj.field(s.name)
+			if def == nil {
+				return fmt.Errorf("cannot query field %q on type %q", s.name, obj.Name)
+			}
+			given := map[string]any{}
+			for _, a := range s.args {
+				if argDef(def, a.name) == nil {
+					return fmt.Errorf("unknown argument %q on field %s.%s", a.name, obj.Name, s.name)
+				}
+				given[a.name] = a.value
+			}
+			for _, a := range def.Args {
+				v, ok := given[a.Name]
+				if _, required := a.Type.(*NonNull); required && !ok && a.Default == nil {
+					return fmt.Errorf("argument %q of type %s is required on field %s.%s", a.Name, a.Type, obj.Name, s.name)
+				}
+				// Literal values can be checked now; variables are checked
+				// when the field runs.
+				if ok && !hasVariable(v) {
+					if _, err := coerceInput(a.Type, v, nil); err != nil {
+						return fmt.Errorf("argument %q on field %s.%s: %v", a.Name, obj.Name, s.name, err)
+					}
+				}
+			}
+			child, isObject := namedType(def.Type).(*Object)
+			switch {
+			case isObject && len(s.selections) == 0:
+				return fmt.Errorf("field %q of type %q must have a selection of subfields", s.name, def.Type)
+			case !isObject && len(s.selections) > 0:
+				return fmt.Errorf("field %q must not have a selection since type %q has no subfields", s.name, def.Type)
+			case isObject:
+				if err := p.validate(child, s.selections, fragPath, depth+1); err != nil {
+					return err
+				}
+			}
+		case *inlineFragment:
+			if s.typeCond != "" && s.typeCond != obj.Name {
+				return fmt.Errorf("fragment cannot be spread here: type %q can never be %q", obj.Name, s.typeCond)
+			}
+			if err := p.validate(obj, s.selections, fragPath, depth); err != nil {
+				return err
+			}
+		case *fragmentSpread:
+			frag := p.doc.fragments[s.name]
+			if frag == nil {
+				return fmt.Errorf("unknown fragment %q", s.name)
+			}
+			if frag.typeCond != obj.Name {
+				return fmt.Errorf("fragment %q cannot be spread here: type %q can never be %q", s.name, obj.Name, frag.typeCond)
+			}
+			if fragPath[s.name] {
+				return fmt.Errorf("cannot spread fragment %q within itself", s.name)
+			}
+			fragPath[s.name] = true
+			err := p.validate(obj, frag.selections, fragPath, depth)
+			delete(fragPath, s.name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasVariable(v any) bool {
+	switch vv := v.(type) {
+	case variableRef:
+		return true
+	case []any:
+		for _, item := range vv {
+			if hasVariable(item) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range vv {
+			if hasVariable(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func argDef(f *Field, name string) *Arg {
+	for _, a := range f.Args {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+type executor struct {
+	p    *Prepared
+	ctx  context.Context
+	errs []*Error
+}
+
+func (e *executor) fieldError(path []any, err error) {
+	e.errs = append(e.errs, &Error{Message: err.Error(), Path: append([]any(nil), path...)})
+}
+
+func (e *executor) selectionSet(obj *Object, source any, sels []selection, path []any) *orderedMap {
+	out := &orderedMap{}
+	for _, g := range e.p.collect(obj, sels, nil) {
+		f := g.fields[0]
+		fieldPath := append(append([]any(nil), path...), g.key)
+		if f.name == "__typename" {
+			out.set(g.key, obj.Name)
+			continue
+		}
+		def := obj.field(f.name)
+		args, err := coerceArgs(def, f.args, e.p.vars)
+		if err != nil {
+			e.fieldError(fieldPath, err)
+			out.set(g.key, nil)
+			continue
+		}
+		var val any
+		if def.Resolve != nil {
+			val, err = def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+		} else if m, ok := source.(map[string]any); ok {
+			val = m[def.Name]
+		}
+		if err != nil {
+			e.fieldError(fieldPath, err)
+			out.set(g.key, nil)
+			continue
+		}
+		out.set(g.key, e.complete(def.Type, g.fields, val, fieldPath))
+	}
+	return out
+}
+
+func (e *executor) complete(t Type, fields []*astField, v any, path []any) any {
+	if nn, ok := t.(*NonNull); ok {
+		out := e.complete(nn.OfType, fields, v, path)
+		if out == nil {
+			e.fieldError(path, fmt.Errorf("cannot return null for non-nullable field"))
+		}
+		return out
+	}
+	if isNil(v) {
+		return nil
+	}
+	switch tt := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fieldError(path, fmt.Errorf("expected a list, got %T", v))
+			return nil
+		}
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = e.complete(tt.OfType, fields, rv.Index(i).Interface(), append(append([]any(nil), path...), i))
+		}
+		return out
+	case *Scalar:
+		out, err := serialize(tt, v)
+		if err != nil {
+			e.fieldError(path, err)
+			return nil
+		}
+		return out
+	case *Object:
+		var sels []selection
+		for _, f := range fields {
+			sels = append(sels, f.selections...)
+		}
+		return e.selectionSet(tt, v, sels, path)
+	}
+	return nil
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func serialize(s *Scalar, v any) (any, error) {
+	if ptr := reflect.ValueOf(v); ptr.Kind() == reflect.Pointer {
+		v = ptr.Elem().Interface()
+	}
+	switch s {
+	case String:
+		switch vv := v.(type) {
+		case string:
+			return vv, nil
+		case time.Time:
+			if vv.IsZero() {
+				return nil, nil
+			}
+			return vv.UTC().Format(time.RFC3339), nil
+		case []byte:
+			return string(vv), nil
+		case fmt.Stringer:
+			return vv.String(), nil
+		}
+		return fmt.Sprint(v), nil
+	case ID:
+		if n, ok := toInt64(v); ok {
+			return strconv.FormatInt(n, 10), nil
+		}
+		if str, ok := v.(string); ok {
+			return str, nil
+		}
+	case Int:
+		if n, ok := toInt64(v); ok {
+			if n > math.MaxInt32 || n < math.MinInt32 {
+				return nil, fmt.Errorf("Int cannot represent %d", n)
+			}
+			return n, nil
+		}
+	case Float:
+		if n, ok := toInt64(v); ok {
+			return float64(n), nil
+		}
+		if f, ok := v.(float64); ok {
+			return f, nil
+		}
+	case Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	default:
+		return v, nil
+	}
+	return nil, fmt.Errorf("%s cannot represent %T", s.Name, v)
+}
+
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), true
+	case float64:
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return int64(n), true
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func coerceVariables(defs []*varDef, given map[string]any) (map[string]any, error) {
+	out := map[string]any{}
+	for _, d := range defs {
+		v, ok := given[d.name]
+		if !ok && d.hasDef {
+			v, ok = d.defValue, true
+		}
+		if (!ok || v == nil) && d.nonNull {
+			return nil, fmt.Errorf("variable $%s of required type %s was not provided", d.name, d.typ)
+		}
+		if ok {
+			out[d.name] = v
+		}
+	}
+	return out, nil
+}
+
+// coerceArgs resolves variables and defaults and converts argument values to
+// the declared types.
+func coerceArgs(def *Field, args []*argument, vars map[string]any) (map[string]any, error) {
+	out := map[string]any{}
+	given := map[string]any{}
+	for _, a := range args {
+		v := a.value
+		if ref, ok := v.(variableRef); ok {
+			vv, present := vars[string(ref)]
+			if !present {
+				continue
+			}
+			v = vv
+		}
+		given[a.name] = v
+	}
+	for _, a := range def.Args {
+		v, ok := given[a.Name]
+		if !ok && a.Default != nil {
+			v, ok = a.Default, true
+		}
+		if !ok || v == nil {
+			if _, required := a.Type.(*NonNull); required {
+				return nil, fmt.Errorf("argument %q of type %s is required", a.Name, a.Type)
+			}
+			continue
+		}
+		cv, err := coerceInput(a.Type, v, vars)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", a.Name, err)
+		}
+		out[a.Name] = cv
+	}
+	return out, nil
+}
+
+func coerceInput(t Type, v any, vars map[string]any) (any, error) {
+	if ref, ok := v.(variableRef); ok {
+		v = vars[string(ref)]
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected non-null %s", nn)
+		}
+		return coerceInput(nn.OfType, v, vars)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch tt := t.(type) {
+	case *List:
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		out := make([]any, 0, len(items))
+		for _, item := range items {
+			cv, err := coerceInput(tt.OfType, item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, cv)
+		}
+		return out, nil
+	case *Scalar:
+		switch tt {
+		case String:
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+		case ID:
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			if n, ok := toInt64(v); ok {
+				return strconv.FormatInt(n, 10), nil
+			}
+		case Int:
+			if n, ok := toInt64(v); ok && n <= math.MaxInt32 && n >= math.MinInt32 {
+				return n, nil
+			}
+		case Float:
+			if n, ok := toInt64(v); ok {
+				return float64(n), nil
+			}
+			if f, ok := v.(float64); ok {
+				return f, nil
+			}
+		case Boolean:
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("%s cannot represent %s", tt.Name, literal(v))
+	}
+	return nil, fmt.Errorf("unsupported input type %s", t)
+}
+
+// orderedMap keeps response keys in selection order, as the spec requires.
+type orderedMap struct {
+	keys []string
+	vals map[string]any
+}
+
+func (m *orderedMap) set(k string, v any) {
+	if m.vals == nil {
+		m.vals = map[string]any{}
+	}
+	if _, ok := m.vals[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.vals[k] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		b.Write(kb)
+		b.WriteByte(':')
+		vb, err := json.Marshal(m.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(vb)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,175 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSchema() *Schema {
+	book := &Object{Name: "Book", Fields: []*Field{
+		{Name: "id", Type: NonNullOf(ID)},
+		{Name: "title", Type: String},
+		{Name: "pages", Type: Int},
+		{Name: "tags", Type: ListOf(String)},
+		{Name: "added", Type: String},
+	}}
+	books := []any{
+		map[string]any{"id": int64(1), "title": "Dune", "pages": 412, "tags": []string{"sf"}, "added": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		map[string]any{"id": int64(2), "title": "Emma", "pages": 474},
+	}
+	query := &Object{Name: "Query", Fields: []*Field{
+		{Name: "books", Type: ListOf(book), Args: []*Arg{{Name: "limit", Type: Int, Default: 10}},
+			Resolve: func(p ResolveParams) (any, error) {
+				n := int(p.Args["limit"].(int64))
+				if n > len(books) {
+					n = len(books)
+				}
+				return books[:n], nil
+			}},
+		{Name: "book", Type: book, Args: []*Arg{{Name: "id", Type: NonNullOf(ID)}},
+			Resolve: func(p ResolveParams) (any, error) {
+				for _, b := range books {
+					if fmt.Sprint(b.(map[string]any)["id"]) == p.Args["id"] {
+						return b, nil
+					}
+				}
+				return nil, fmt.Errorf("book %s not found", p.Args["id"])
+			}},
+	}}
+	sub := &Object{Name: "Subscription", Fields: []*Field{
+		{Name: "bookAdded", Type: book, Subscribe: func(p ResolveParams) (<-chan any, error) {
+			ch := make(chan any, len(books))
+			for _, b := range books {
+				ch <- b
+			}
+			close(ch)
+			return ch, nil
+		}},
+	}}
+	return &Schema{Query: query, Subscription: sub}
+}
+
+func run(t *testing.T, req Request) string {
+	t.Helper()
+	b, err := json.Marshal(testSchema().Execute(context.Background(), req))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestExecuteSelectsRequestedFieldsInOrder(t *testing.T) {
+	got := run(t, Request{Query: `
+		# comments and commas are ignored
+		query Shelf($n: Int = 1, $withTags: Boolean!) {
+			first: books(limit: $n) { title, id __typename tags @include(if: $withTags) }
+			all: books { ...Basic ... on Book { added } }
+		}
+		fragment Basic on Book { id pages }`,
+		Variables: map[string]any{"withTags": true}})
+	want := `{"data":{"first":[{"title":"Dune","id":"1","__typename":"Book","tags":["sf"]}],"all":[{"id":"1","pages":412,"added":"2024-01-02T03:04:05Z"},{"id":"2","pages":474,"added":null}]}}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestExecuteReportsFieldErrorsWithPath(t *testing.T) {
+	got := run(t, Request{Query: `{ a: book(id: 2) { title } b: book(id: "9") { title } }`})
+	want := `{"data":{"a":{"title":"Emma"},"b":null},"errors":[{"message":"book 9 not found","path":["b"]}]}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestPrepareRejectsInvalidDocuments(t *testing.T) {
+	cases := map[string]string{
+		`{ books { nope } }`:                                       `cannot query field "nope" on type "Book"`,
+		`{ books }`:                                                `must have a selection of subfields`,
+		`{ books(limit: 1) { title { x } } }`:                      `must not have a selection`,
+		`{ books(max: 1) { title } }`:                              `unknown argument "max"`,
+		`{ book { title } }`:                                       `argument "id" of type ID! is required on field Query.book`,
+		`{ books(limit: "x") { title } }`:                          `Int cannot represent "x"`,
+		`query Q($id: ID!) { book(id: $id) { title } }`:            `variable $id of required type ID! was not provided`,
+		`{ books { ...Missing } }`:                                 `unknown fragment "Missing"`,
+		`mutation { books { title } }`:                             `mutation operations are not supported`,
+		`subscription { a: bookAdded { id } b: bookAdded { id } }`: `exactly one top-level field`,
+		`{ books { title }`:                                        `syntax error`,
+		`{ books(limit: 1.5.2) { title } }`:                        `syntax error`,
+	}
+	for q, want := range cases {
+		resp := testSchema().Execute(context.Background(), Request{Query: q})
+		if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, want) {
+			b, _ := json.Marshal(resp)
+			t.Errorf("%s\n  got  %s\n  want request error containing %q", q, b, want)
+		}
+	}
+}
+
+func TestPrepareRejectsQueriesOverTheLimits(t *testing.T) {
+	node := &Object{Name: "Node", Fields: []*Field{{Name: "id", Type: ID}}}
+	node.Fields = append(node.Fields, &Field{Name: "next", Type: node})
+	s := &Schema{Query: &Object{Name: "Query", Fields: []*Field{{Name: "node", Type: node}}}, MaxDepth: 3, MaxFields: 6}
+	prepare := func(q string) error {
+		_, err := s.Prepare(Request{Query: q})
+		return err
+	}
+
+	if err := prepare(`{ node { next { id } } }`); err != nil {
+		t.Fatalf("query within the limits: %v", err)
+	}
+	if err := prepare(`{ node { next { next { id } } } }`); err == nil || !strings.Contains(err.Error(), "maximum depth 3") {
+		t.Fatalf("deep query = %v", err)
+	}
+	// Each spread of a fragment counts again, so doubling fragments cannot
+	// slip past the field budget.
+	err := prepare(`{ node { ...A ...A } }
+		fragment A on Node { ...B ...B }
+		fragment B on Node { id id }`)
+	if err == nil || !strings.Contains(err.Error(), "maximum 6") {
+		t.Fatalf("wide query = %v", err)
+	}
+
+	s.MaxDepth, s.MaxFields = 0, 0
+	deep := strings.Repeat("next { ", DefaultMaxDepth) + "id" + strings.Repeat(" }", DefaultMaxDepth)
+	if err := prepare(`{ node { ` + deep + ` } }`); err == nil || !strings.Contains(err.Error(), "maximum depth") {
+		t.Fatalf("default depth limit = %v", err)
+	}
+}
+
+func TestSubscribeResolvesEachEvent(t *testing.T) {
+	p, err := testSchema().Prepare(Request{Query: `subscription { added: bookAdded { title } }`})
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	ch, err := p.Subscribe(context.Background())
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	var got []string
+	for resp := range ch {
+		b, _ := json.Marshal(resp)
+		got = append(got, string(b))
+	}
+	want := []string{`{"data":{"added":{"title":"Dune"}}}`, `{"data":{"added":{"title":"Emma"}}}`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSDL(t *testing.T) {
+	sdl := testSchema().SDL()
+	for _, want := range []string{
+		"schema {\n  query: Query\n  subscription: Subscription\n}",
+		"books(limit: Int = 10): [Book]",
+		"book(id: ID!): Book",
+		"type Book {\n  id: ID!",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Fatalf("SDL missing %q:\n%s", want, sdl)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "<EOF>"
+	case tokString:
+		return fmt.Sprintf("%q", t.val)
+	}
+	return t.val
+}
+
+// lex splits a GraphQL document into tokens. Commas and comments are
+// insignificant in GraphQL and are dropped here.
+func lex(src string) ([]token, error) {
+	var out []token
+	i := 0
+	if strings.HasPrefix(src, "\uFEFF") {
+		i = len("\uFEFF")
+	}
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			out = append(out, token{tokPunct, "...", i})
+			i += 3
+		case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+			out = append(out, token{tokPunct, string(c), i})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			out = append(out, token{tokName, src[start:i], start})
+		case c == '-' || isDigit(c):
+			tok, n, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, tok)
+			i = n
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, fmt.Errorf("syntax error at %d: unterminated block string", i)
+			}
+			out = append(out, token{tokString, blockStringValue(src[i+3 : i+3+end]), i})
+			i += end + 6
+		case c == '"':
+			val, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, token{tokString, val, i})
+			i = n
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, fmt.Errorf("syntax error at %d: unexpected character %q", i, r)
+		}
+	}
+	return append(out, token{tokEOF, "", len(src)}), nil
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+func lexNumber(src string, i int) (token, int, error) {
+	start := i
+	float := false
+	if src[i] == '-' {
+		i++
+	}
+	digits := func() int {
+		n := 0
+		for i < len(src) && isDigit(src[i]) {
+			i++
+			n++
+		}
+		return n
+	}
+	if digits() == 0 {
+		return token{}, 0, fmt.Errorf("syntax error at %d: invalid number", start)
+	}
+	if i < len(src) && src[i] == '.' {
+		float = true
+		i++
+		if digits() == 0 {
+			return token{}, 0, fmt.Errorf("syntax error at %d: invalid number", start)
+		}
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		float = true
+		i++
+		if i < len(src) && (src[i] == '+' || src[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return token{}, 0, fmt.Errorf("syntax error at %d: invalid number", start)
+		}
+	}
+	kind := tokInt
+	if float {
+		kind = tokFloat
+	}
+	return token{kind, src[start:i], start}, i, nil
+}
+
+func lexString(src string, i int) (string, int, error) {
+	start := i
+	i++ // opening quote
+	var b strings.Builder
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '"':
+			return b.String(), i + 1, nil
+		case c == '\n' || c == '\r':
+			return "", 0, fmt.Errorf("syntax error at %d: unterminated string", start)
+		case c == '\\':
+			if i+1 >= len(src) {
+				return "", 0, fmt.Errorf("syntax error at %d: unterminated string", start)
+			}
+			esc := src[i+1]
+			i += 2
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+4 > len(src) {
+					return "", 0, fmt.Errorf("syntax error at %d: invalid unicode escape", i)
+				}
+				var r rune
+				if _, err := fmt.Sscanf(src[i:i+4], "%04x", &r); err != nil {
+					return "", 0, fmt.Errorf("syntax error at %d: invalid unicode escape", i)
+				}
+				b.WriteRune(r)
+				i += 4
+			default:
+				return "", 0, fmt.Errorf("syntax error at %d: invalid escape \\%c", i-1, esc)
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, fmt.Errorf("syntax error at %d: unterminated string", start)
+}
+
+// blockStringValue applies the common-indentation removal rules for
+// """block strings""".
+func blockStringValue(raw string) string {
+	raw = strings.ReplaceAll(raw, `\"""`, `"""`)
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, l := range lines[1:] {
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(l) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,462 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// document is a parsed GraphQL request document.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // "query" | "mutation" | "subscription"
+	name       string
+	vars       []*varDef
+	selections []selection
+}
+
+type varDef struct {
+	name     string
+	typ      string
+	nonNull  bool
+	defValue any
+	hasDef   bool
+}
+
+// selection is one of *astField, *fragmentSpread, or *inlineFragment.
+type selection interface{}
+
+type astField struct {
+	alias      string
+	name       string
+	args       []*argument
+	directives []*directive
+	selections []selection
+}
+
+func (f *astField) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value any
+}
+
+type directive struct {
+	name string
+	args []*argument
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+}
+
+type inlineFragment struct {
+	typeCond   string
+	directives []*directive
+	selections []selection
+}
+
+type fragment struct {
+	name       string
+	typeCond   string
+	selections []selection
+}
+
+// Value literals are parsed to Go values: int64, float64, string, bool, nil,
+// []any, map[string]any, plus the two wrapper types below.
+type (
+	variableRef string
+	enumValue   string
+)
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func parse(src string) (*document, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	doc := &document{fragments: map[string]*fragment{}}
+	for p.peek().kind != tokEOF {
+		t := p.peek()
+		switch {
+		case t.kind == tokPunct && t.val == "{":
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sel})
+		case t.kind == tokName && (t.val == "query" || t.val == "mutation" || t.val == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case t.kind == tokName && t.val == "fragment":
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[f.name]; dup {
+				return nil, fmt.Errorf("there can be only one fragment named %q", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected(t)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document contains no operations")
+	}
+	return doc, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token) error {
+	return fmt.Errorf("syntax error at %d: unexpected %s", t.pos, t)
+}
+
+func (p *parser) isPunct(v string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.val == v
+}
+
+func (p *parser) expectPunct(v string) error {
+	if t := p.next(); t.kind != tokPunct || t.val != v {
+		return fmt.Errorf("syntax error at %d: expected %q, found %s", t.pos, v, t)
+	}
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	t := p.next()
+	if t.kind != tokName {
+		return "", fmt.Errorf("syntax error at %d: expected name, found %s", t.pos, t)
+	}
+	return t.val, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.next().val}
+	if p.peek().kind == tokName {
+		op.name = p.next().val
+	}
+	if p.isPunct("(") {
+		p.next()
+		for !p.isPunct(")") {
+			v, err := p.varDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, v)
+		}
+		p.next()
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sel
+	return op, nil
+}
+
+func (p *parser) varDef() (*varDef, error) {
+	if err := p.expectPunct("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	v := &varDef{name: name, typ: typ}
+	if n := len(typ); n > 0 && typ[n-1] == '!' {
+		v.nonNull = true
+	}
+	if p.isPunct("=") {
+		p.next()
+		if v.defValue, err = p.value(true); err != nil {
+			return nil, err
+		}
+		v.hasDef = true
+	}
+	return v, nil
+}
+
+func (p *parser) typeRef() (string, error) {
+	var out string
+	if p.isPunct("[") {
+		p.next()
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return "", err
+		}
+		out = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		out = name
+	}
+	if p.isPunct("!") {
+		p.next()
+		out += "!"
+	}
+	return out, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	p.next() // "fragment"
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("fragment cannot be named \"on\"")
+	}
+	if on, err := p.name(); err != nil || on != "on" {
+		return nil, fmt.Errorf("syntax error: expected \"on\" after fragment %s", name)
+	}
+	typeCond, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeCond: typeCond, selections: sel}, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var out []selection
+	for !p.isPunct("}") {
+		if p.peek().kind == tokEOF {
+			return nil, p.unexpected(p.peek())
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sel)
+	}
+	p.next()
+	if len(out) == 0 {
+		return nil, fmt.Errorf("syntax error: empty selection set")
+	}
+	return out, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.isPunct("...") {
+		p.next()
+		t := p.peek()
+		if t.kind == tokName && t.val != "on" {
+			p.next()
+			dirs, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			return &fragmentSpread{name: t.val, directives: dirs}, nil
+		}
+		frag := &inlineFragment{}
+		if t.kind == tokName {
+			p.next()
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			frag.typeCond = name
+		}
+		var err error
+		if frag.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if frag.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return frag, nil
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f := &astField{name: name}
+	if p.isPunct(":") {
+		p.next()
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.isPunct("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments(constant bool) ([]*argument, error) {
+	if !p.isPunct("(") {
+		return nil, nil
+	}
+	p.next()
+	var out []*argument
+	for !p.isPunct(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &argument{name: name, value: v})
+	}
+	p.next()
+	return out, nil
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var out []*directive
+	for p.isPunct("@") {
+		p.next()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &directive{name: name, args: args})
+	}
+	return out, nil
+}
+
+func (p *parser) value(constant bool) (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokInt:
+		n, err := strconv.ParseInt(t.val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d: integer out of range", t.pos)
+		}
+		return n, nil
+	case tokFloat:
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d: invalid float", t.pos)
+		}
+		return f, nil
+	case tokString:
+		return t.val, nil
+	case tokName:
+		switch t.val {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(t.val), nil
+	case tokPunct:
+		switch t.val {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("syntax error at %d: variables are not allowed here", t.pos)
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return variableRef(name), nil
+		case "[":
+			list := []any{}
+			for !p.isPunct("]") {
+				if p.peek().kind == tokEOF {
+					return nil, p.unexpected(p.peek())
+				}
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			obj := map[string]any{}
+			for !p.isPunct("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				obj[name] = v
+			}
+			p.next()
+			return obj, nil
+		}
+	}
+	return nil, p.unexpected(t)
+}
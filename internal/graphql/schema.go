@@ -0,0 +1,202 @@
+// Package graphql is a small GraphQL executor for Scriptorum's read-only API.
+//
+// It covers what dashboard clients need: queries and subscriptions with
+// arguments, variables, aliases, fragments, and @skip/@include over object,
+// list, and scalar types. Mutations, interfaces, unions, input objects, and
+// introspection queries are not supported; Schema.SDL describes the schema
+// instead.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type is one of *Scalar, *Object, *List, or *NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type.
+type Scalar struct {
+	Name        string
+	Description string
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// Built-in scalars.
+var (
+	String  = &Scalar{Name: "String"}
+	Int     = &Scalar{Name: "Int"}
+	Float   = &Scalar{Name: "Float"}
+	Boolean = &Scalar{Name: "Boolean"}
+	ID      = &Scalar{Name: "ID"}
+)
+
+// List wraps a type as a list.
+type List struct{ OfType Type }
+
+func (l *List) String() string { return "[" + l.OfType.String() + "]" }
+
+// NonNull marks a type as never null.
+type NonNull struct{ OfType Type }
+
+func (n *NonNull) String() string { return n.OfType.String() + "!" }
+
+// ListOf returns [t].
+func ListOf(t Type) *List { return &List{OfType: t} }
+
+// NonNullOf returns t!.
+func NonNullOf(t Type) *NonNull { return &NonNull{OfType: t} }
+
+// Object is a type with fields.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+func (o *Object) String() string { return o.Name }
+
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Field is a field on an Object. Resolve may be nil, in which case the value
+// is looked up by name when the source is a map[string]any. Fields on the
+// Subscription type must set Subscribe.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Arg
+	Resolve     ResolveFunc
+	Subscribe   SubscribeFunc
+}
+
+// Arg is a field argument. Default is used when the argument is omitted.
+type Arg struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     any
+}
+
+// ResolveParams is passed to resolvers. Args holds coerced argument values:
+// string for String and ID, int64 for Int, float64 for Float, bool for
+// Boolean, and []any for lists.
+type ResolveParams struct {
+	Context context.Context
+	Source  any
+	Args    map[string]any
+}
+
+// ResolveFunc produces a field value.
+type ResolveFunc func(p ResolveParams) (any, error)
+
+// SubscribeFunc returns a channel of events for a subscription field. Each
+// event is resolved against the field's type and sent to the client. The
+// channel should be closed when p.Context is done.
+type SubscribeFunc func(p ResolveParams) (<-chan any, error)
+
+// Schema is the root of a GraphQL API. MaxDepth and MaxFields bound how
+// deep a query may nest and how many fields it may select, counting each
+// fragment every time it is spread; zero means DefaultMaxDepth and
+// DefaultMaxFields.
+type Schema struct {
+	Query        *Object
+	Subscription *Object
+	MaxDepth     int
+	MaxFields    int
+}
+
+// Limits applied when a Schema does not set its own.
+const (
+	DefaultMaxDepth  = 10
+	DefaultMaxFields = 500
+)
+
+func namedType(t Type) Type {
+	for {
+		switch tt := t.(type) {
+		case *List:
+			t = tt.OfType
+		case *NonNull:
+			t = tt.OfType
+		default:
+			return t
+		}
+	}
+}
+
+// SDL renders the schema in GraphQL schema definition language.
+func (s *Schema) SDL() string {
+	var objects []*Object
+	seen := map[string]bool{}
+	var visit func(o *Object)
+	visit = func(o *Object) {
+		if o == nil || seen[o.Name] {
+			return
+		}
+		seen[o.Name] = true
+		objects = append(objects, o)
+		for _, f := range o.Fields {
+			if obj, ok := namedType(f.Type).(*Object); ok {
+				visit(obj)
+			}
+		}
+	}
+	visit(s.Query)
+	visit(s.Subscription)
+
+	var b strings.Builder
+	b.WriteString("schema {\n  query: " + s.Query.Name + "\n")
+	if s.Subscription != nil {
+		b.WriteString("  subscription: " + s.Subscription.Name + "\n")
+	}
+	b.WriteString("}\n")
+	for _, o := range objects {
+		b.WriteString("\n")
+		if o.Description != "" {
+			b.WriteString(strconv.Quote(o.Description) + "\n")
+		}
+		b.WriteString("type " + o.Name + " {\n")
+		for _, f := range o.Fields {
+			if f.Description != "" {
+				b.WriteString("  " + strconv.Quote(f.Description) + "\n")
+			}
+			b.WriteString("  " + f.Name)
+			if len(f.Args) > 0 {
+				parts := make([]string, 0, len(f.Args))
+				for _, a := range f.Args {
+					part := a.Name + ": " + a.Type.String()
+					if a.Default != nil {
+						part += " = " + literal(a.Default)
+					}
+					parts = append(parts, part)
+				}
+				b.WriteString("(" + strings.Join(parts, ", ") + ")")
+			}
+			b.WriteString(": " + f.Type.String() + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+func literal(v any) string {
+	switch vv := v.(type) {
+	case string:
+		return strconv.Quote(vv)
+	default:
+		return fmt.Sprint(vv)
+	}
+}
@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// adminAlert is a persistent banner shown to admins on every page until the
+// condition that raised it is resolved.
+type adminAlert struct {
+	Key     string
+	Level   string // "error" | "warning"
+	Title   string
+	Message string
+	Since   time.Time
+}
+
+// raiseAdminAlert records or refreshes the alert for key. It reports whether
+// the alert is new, so callers can send a one-time notification.
+func (s *Server) raiseAdminAlert(key, level, title, message string) bool {
+	s.adminAlertsMu.Lock()
+	defer s.adminAlertsMu.Unlock()
+	if s.adminAlerts == nil {
+		s.adminAlerts = make(map[string]adminAlert)
+	}
+	prev, exists := s.adminAlerts[key]
+	since := time.Now()
+	if exists {
+		since = prev.Since
+	}
+	s.adminAlerts[key] = adminAlert{Key: key, Level: level, Title: title, Message: message, Since: since}
+	return !exists
+}
+
+// clearAdminAlert removes the alert for key and reports whether one existed.
+func (s *Server) clearAdminAlert(key string) bool {
+	s.adminAlertsMu.Lock()
+	defer s.adminAlertsMu.Unlock()
+	if _, ok := s.adminAlerts[key]; !ok {
+		return false
+	}
+	delete(s.adminAlerts, key)
+	return true
+}
+
+// listAdminAlerts returns the active alerts, oldest first.
+func (s *Server) listAdminAlerts() []adminAlert {
+	s.adminAlertsMu.RLock()
+	out := make([]adminAlert, 0, len(s.adminAlerts))
+	for _, a := range s.adminAlerts {
+		out = append(out, a)
+	}
+	s.adminAlertsMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Since.Equal(out[j].Since) {
+			return out[i].Key < out[j].Key
+		}
+		return out[i].Since.Before(out[j].Since)
+	})
+	return out
+}
+
+// handleAdminAlerts renders the admin banner fragment polled by base.html.
+func (u *ui) handleAdminAlerts(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = u.tpl.ExecuteTemplate(w, "admin_alerts", map[string]any{"Alerts": s.listAdminAlerts()})
+	}
+}
@@ -3,8 +3,11 @@ package httpapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,14 +19,22 @@ import (
 )
 
 type RequestPayload struct {
-	Title           string   `json:"title"`
-	Authors         []string `json:"authors"`
-	ISBN10          string   `json:"isbn10"`
-	ISBN13          string   `json:"isbn13"`
-	ASIN            string   `json:"asin"`
-	Format          string   `json:"format"` // ebook | audiobook
-	Provider        string   `json:"provider"`
-	ProviderPayload string   `json:"provider_payload"`
+	Title   string   `json:"title"`
+	Authors []string `json:"authors"`
+	ISBN10  string   `json:"isbn10"`
+	ISBN13  string   `json:"isbn13"`
+	ASIN    string   `json:"asin"`
+	Format  string   `json:"format"` // ebook | audiobook | comic | magazine
+	// Frequency is a magazine's issue schedule; see subscriptionFrequencies.
+	Frequency       string `json:"frequency,omitempty"`
+	Provider        string `json:"provider"`
+	ProviderPayload string `json:"provider_payload"`
+	// Extra holds answers to the admin-configured request form fields,
+	// keyed by field key.
+	Extra map[string]string `json:"extra,omitempty"`
+	// NeedBy is the date the requester needs the book by, YYYY-MM-DD. The
+	// request form sends it with the extra answers instead.
+	NeedBy string `json:"need_by,omitempty"`
 }
 
 func (s *Server) readarrInstanceForLookup(format string) (providers.ReadarrInstance, bool) {
@@ -40,22 +51,59 @@ func (s *Server) readarrInstanceForLookup(format string) (providers.ReadarrInsta
 }
 
 func (s *Server) mountAPI(r chi.Router) {
+	r.Get("/api/v1/me", s.requireLogin(s.apiMe))
+	r.Get("/api/v1/subscriptions", s.requireLogin(s.apiListSubscriptions))
 	r.Route("/api/v1/requests", func(rr chi.Router) {
-		rr.Post("/", s.requireLogin(s.apiCreateRequest))
+		rr.Post("/", s.requireLogin(s.withIdempotencyKey(s.apiCreateRequest)))
 		rr.Get("/", s.requireLogin(s.apiListRequests))
-		rr.Post("/{id}/approve", s.requireAdmin(s.apiApproveRequest))
-		rr.Post("/{id}/retry", s.requireAdmin(s.apiRetryRequest))
+		rr.Post("/{id}/approve", s.requireRequestAdmin(s.apiApproveRequest))
+		rr.Post("/{id}/approve-linked", s.requireRequestAdmin(s.apiApproveLinkedRequests))
+		rr.Post("/{id}/approve-both", s.requireRequestAdmin(s.apiApproveBothFormats))
+		rr.Post("/{id}/retry", s.requireRequestAdmin(s.apiRetryRequest))
 		rr.Post("/{id}/search", s.requireLogin(s.apiSearchRequest))
+		rr.Post("/{id}/alternate", s.requireLogin(s.apiRequestAlternateFormat))
+		rr.Post("/{id}/renew", s.requireLogin(s.apiRenewRequest))
 		rr.Post("/{id}/hydrate", s.requireAdmin(s.apiHydrateRequest))
-		rr.Post("/{id}/decline", s.requireAdmin(s.apiDeclineRequest))
+		rr.Post("/{id}/decline", s.requireRequestAdmin(s.apiDeclineRequest))
+		rr.Get("/{id}/attachments", s.requireLogin(s.apiListAttachments))
+		rr.Post("/{id}/attachments", s.requireLogin(s.apiAddAttachment))
+		rr.Get("/{id}/attachments/{aid}", s.requireLogin(s.apiGetAttachment))
+		rr.Delete("/{id}/attachments/{aid}", s.requireLogin(s.apiDeleteAttachment))
+		rr.Get("/labels", s.requireAdmin(s.apiListLabels))
+		rr.Get("/{id}/labels", s.requireAdmin(s.apiGetRequestLabels))
+		rr.Put("/{id}/labels", s.requireAdmin(s.apiSetRequestLabels))
+		rr.Put("/{id}/purchase", s.requireRequestAdmin(s.apiSetRequestPurchase))
+		rr.Get("/{id}/note", s.requireRequestAdmin(s.apiGetRequestNote))
+		rr.Put("/{id}/note", s.requireRequestAdmin(s.apiSetRequestNote))
+		rr.Put("/{id}/status", s.requireRequestAdmin(s.apiSetRequestStatus))
+		rr.Put("/{id}/requester", s.requireAdmin(s.apiReassignRequest))
+		rr.Post("/{id}/merge", s.requireAdmin(s.apiMergeRequests))
+		rr.Get("/{id}/author", s.requireAdmin(s.apiGetRequestAuthor))
+		rr.Put("/{id}/author", s.requireAdmin(s.apiSetRequestAuthor))
+		rr.Get("/{id}/candidates", s.requireAdmin(s.apiGetRequestCandidates))
+		rr.Put("/{id}/selection", s.requireAdmin(s.apiSetRequestSelection))
+		rr.Post("/{id}/remove-author", s.requireAdmin(s.apiRemoveRequestAuthor))
 		rr.Delete("/{id}", s.requireAdmin(s.apiDeleteRequest))
 		rr.Delete("/", s.requireAdmin(s.apiDeleteAllRequests))
 		rr.Post("/approve-all", s.requireAdmin(s.apiApproveAllRequests))
+		rr.Post("/retry-errors", s.requireAdmin(s.apiRetryFailedRequests))
+		rr.Get("/queue/events", s.requireAdmin(s.apiQueueEvents))
+		rr.Post("/bulk/resolve", s.requireLogin(s.apiBulkResolve))
+		rr.Post("/bulk", s.requireLogin(s.apiBulkCreate))
 	})
 	// Book details endpoint used by UI to fetch richer metadata on-demand
+	r.Get("/api/v1/spend", s.requireAdmin(s.apiSpendReport))
+	r.Get("/api/v1/usage", s.requireAdmin(s.apiUsageStats))
+	r.Get("/api/v1/statuses", s.requireLogin(s.apiListStatuses))
+	r.Route("/api/v1/denylist", func(dr chi.Router) {
+		dr.Get("/", s.requireAdmin(s.apiListDenylist))
+		dr.Post("/", s.requireAdmin(s.apiAddDenylistEntry))
+		dr.Delete("/{id}", s.requireAdmin(s.apiDeleteDenylistEntry))
+	})
 	r.Route("/api/v1/book", func(br chi.Router) {
 		br.Post("/details", s.requireLogin(s.apiBookDetails))
 		br.Post("/enriched", s.requireLogin(s.apiBookEnriched))
+		br.Post("/scan", s.requireLogin(s.apiBookScan))
 	})
 }
 
@@ -88,6 +136,8 @@ func (s *Server) apiBookDetails(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, map[string]any{"error": "no details found"}, 404)
 			return
 		}
+		s.fillFromOpenLibrary(r.Context(), in, obj)
+		s.fillFromISBNdb(r.Context(), obj)
 		// normalize authors
 		if a, ok := obj["authors"]; ok {
 			switch t := a.(type) {
@@ -228,6 +278,12 @@ func (s *Server) apiBookDetails(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+	if term == "" && s.providerEnabled(providerOpenLibrary) &&
+		(inputStringValue(in, "open_library_work_key") != "" || inputStringValue(in, "open_library_edition_key") != "") {
+		// Open Library ids need no Readarr lookup.
+		writeNormalized(s.openLibraryEnrichedData(r.Context(), in))
+		return
+	}
 	if term == "" {
 		writeJSON(w, map[string]any{"error": "no query provided"}, 400)
 		return
@@ -248,6 +304,12 @@ func (s *Server) apiBookDetails(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	list, err := ra.LookupByTerm(ctx, term)
 	if err != nil || len(list) == 0 {
+		if s.providerEnabled(providerOpenLibrary) {
+			if fallback := s.openLibraryEnrichedData(ctx, in); fallback != nil && fallback["description"] != nil {
+				writeNormalized(fallback)
+				return
+			}
+		}
 		writeJSON(w, map[string]any{"error": "no matches from Readarr"}, 404)
 		return
 	}
@@ -637,7 +699,13 @@ func (s *Server) persistRecoveredRequestCover(ctx context.Context, in map[string
 	}
 }
 
+// openLibraryEditionsShown caps the edition list in the details modal.
+const openLibraryEditionsShown = 5
+
 func (s *Server) openLibraryEnrichedData(ctx context.Context, in map[string]any) map[string]any {
+	if !s.providerEnabled(providerOpenLibrary) {
+		return nil
+	}
 	ol := providers.NewOpenLibrary()
 	title := inputStringValue(in, "title")
 	isbn13 := inputStringValue(in, "isbn13")
@@ -666,10 +734,34 @@ func (s *Server) openLibraryEnrichedData(ctx context.Context, in map[string]any)
 		cover = inputStringValue(detailsPayload, "cover")
 	}
 
-	workKey := ""
-	if detailsPayload != nil {
+	workKey := inputStringValue(in, "open_library_work_key")
+	if workKey == "" && detailsPayload != nil {
 		workKey = inputStringValue(detailsPayload, "open_library_work_key")
 	}
+	// An edition OLID pins the exact printing; its record names the work.
+	editionKey := inputStringValue(in, "open_library_edition_key")
+	if editionKey == "" && detailsPayload != nil {
+		editionKey = inputStringValue(detailsPayload, "open_library_edition_key")
+	}
+	if editionKey != "" && (workKey == "" || isbn13 == "") {
+		if ed, err := ol.Edition(ctx, editionKey); err == nil && ed != nil {
+			if workKey == "" {
+				workKey = ed.WorkKey
+			}
+			if title == "" {
+				title = ed.Title
+			}
+			if isbn13 == "" {
+				isbn13 = ed.ISBN13
+			}
+			if isbn10 == "" {
+				isbn10 = ed.ISBN10
+			}
+			if cover == "" {
+				cover = ed.CoverMedium
+			}
+		}
+	}
 
 	out := map[string]any{}
 	if title != "" {
@@ -763,6 +855,59 @@ func (s *Server) openLibraryEnrichedData(ctx context.Context, in map[string]any)
 	return out
 }
 
+// fillFromOpenLibrary gives a details response that came back without a
+// description Open Library's description and subjects, which is what setups
+// without Readarr, or books Readarr does not know, rely on. Responses that
+// resolved to an Open Library work also list its editions.
+func (s *Server) fillFromOpenLibrary(ctx context.Context, in, out map[string]any) {
+	if !s.providerEnabled(providerOpenLibrary) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if d, _ := out["description"].(string); strings.TrimSpace(d) == "" {
+		for k, v := range s.openLibraryEnrichedData(ctx, in) {
+			if cur, ok := out[k]; !ok || cur == nil || cur == "" {
+				out[k] = v
+			}
+		}
+	}
+	if _, ok := out["editions"]; !ok {
+		if editions := openLibraryEditionList(ctx, out); len(editions) > 0 {
+			out["editions"] = editions
+		}
+	}
+}
+
+// openLibraryEditionList lists the editions of the Open Library work a
+// details response resolved to.
+func openLibraryEditionList(ctx context.Context, out map[string]any) []map[string]any {
+	dp, _ := out["details_payload"].(map[string]any)
+	workKey := inputStringValue(dp, "open_library_work_key")
+	if workKey == "" {
+		return nil
+	}
+	editions, err := providers.NewOpenLibrary().WorkEditions(ctx, workKey, openLibraryEditionsShown)
+	if err != nil {
+		return nil
+	}
+	list := make([]map[string]any, 0, len(editions))
+	for _, ed := range editions {
+		list = append(list, map[string]any{
+			"key":         ed.Key,
+			"title":       ed.Title,
+			"publisher":   ed.Publisher,
+			"publishDate": ed.PublishDate,
+			"isbn13":      ed.ISBN13,
+			"isbn10":      ed.ISBN10,
+			"pages":       ed.Pages,
+			"format":      ed.Format,
+			"language":    ed.Language,
+		})
+	}
+	return list
+}
+
 func pickOpenLibraryMatch(matches []providers.BookItem, title string, authors []string) providers.BookItem {
 	if len(matches) == 0 {
 		return providers.BookItem{}
@@ -899,6 +1044,8 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 			p.Format = strings.TrimSpace(r.FormValue("format"))
 			p.Provider = strings.TrimSpace(r.FormValue("provider"))
 			p.ProviderPayload = strings.TrimSpace(r.FormValue("provider_payload"))
+			p.Extra = s.extrasFromForm(r)
+			p.NeedBy = strings.TrimSpace(r.FormValue("need_by"))
 		}
 	} else {
 		// Fallback: parse form-encoded body
@@ -913,18 +1060,58 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 		p.ISBN13 = strings.TrimSpace(r.FormValue("isbn13"))
 		p.ASIN = strings.TrimSpace(r.FormValue("asin"))
 		p.Format = strings.TrimSpace(r.FormValue("format"))
+		p.Frequency = strings.TrimSpace(r.FormValue("frequency"))
 		p.Provider = strings.TrimSpace(r.FormValue("provider"))
 		p.ProviderPayload = strings.TrimSpace(r.FormValue("provider_payload"))
+		p.Extra = s.extrasFromForm(r)
+		p.NeedBy = strings.TrimSpace(r.FormValue("need_by"))
 	}
+	s.createRequest(w, r, p)
+}
+
+// createRequest validates p and creates a request for the logged-in user,
+// short-circuiting when the title is already in the Readarr catalog and
+// auto-approving when the user is allowed to.
+func (s *Server) createRequest(w http.ResponseWriter, r *http.Request, p RequestPayload) {
 	if p.Title == "" && p.ISBN13 == "" && p.ISBN10 == "" && p.ASIN == "" {
 		http.Error(w, "title or identifier required", 400)
 		return
 	}
-	format := strings.ToLower(p.Format)
-	if format != "ebook" && format != "audiobook" {
-		format = "ebook"
+	format, err := s.requestFormat(p.Format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var frequency string
+	if format == formatMagazine {
+		var ok bool
+		if frequency, ok = normalizeFrequency(p.Frequency); !ok {
+			http.Error(w, "frequency must be one of "+strings.Join(subscriptionFrequencies, ", "), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(p.Title) == "" {
+			http.Error(w, "magazine title required", http.StatusBadRequest)
+			return
+		}
 	}
-	if match, err := s.findCatalogMatchForPayload(format, p); err == nil && match != nil {
+	extras, err := s.collectRequestExtras(p.Extra)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	needBy, err := parseNeedBy(util.FirstNonEmpty(p.NeedBy, p.Extra[needByFieldKey]), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if e, why := s.denylistMatch(r.Context(), p.Title, p.Authors, p.ISBN10, p.ISBN13, p.ASIN); e != nil && e.Blocks() {
+		s.refuseDeniedRequest(w, r, p.Title, e, why)
+		return
+	}
+	// The catalog mirror only covers the top-level Readarr instances, so it
+	// cannot answer for a group that routes to its own Readarr.
+	_, groupReadarr := s.groupReadarrConfig(requestGroup(r), format)
+	if match, err := s.findCatalogMatchForPayload(format, p); !groupReadarr && err == nil && match != nil {
 		isHX := strings.Contains(r.Header.Get("HX-Request"), "true") || r.Header.Get("HX-Request") == "true"
 
 		// If the book is already downloaded there's nothing to do; report it as a
@@ -981,9 +1168,13 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 			StatusReason:     fmt.Sprintf("already in Readarr (%s); search triggered", status),
 			ExternalStatus:   status,
 			MatchedReadarrID: match.ReadarrID,
+			GroupName:        requestGroup(r),
+			NeedBy:           needBy,
+			ExtraFields:      extras,
+			Source:           requestSource(r),
 		}
 		if strings.TrimSpace(p.ProviderPayload) != "" {
-			req.ReadarrReq = json.RawMessage([]byte(p.ProviderPayload))
+			req.ReadarrReq = s.withRequestTemplate(format, json.RawMessage(p.ProviderPayload))
 			req.CoverURL = s.requestCoverFromPayload(format, req.ReadarrReq)
 		}
 		id, _ := s.db.CreateRequest(r.Context(), req)
@@ -1006,10 +1197,10 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 
 	u := r.Context().Value(ctxUser).(*session)
 
-	if maxPending := s.settings.Get().Requests.MaxPendingPerUser; maxPending > 0 {
-		pendingCount, err := s.db.CountPendingRequestsByUser(r.Context(), u.Username)
-		if err == nil && pendingCount >= maxPending {
-			msg := fmt.Sprintf("you already have %d pending request(s), which is the maximum allowed", pendingCount)
+	group := requestGroup(r)
+	if quota := s.requestQuota(r.Context(), u.Username, group); !quota.Unlimited {
+		if quota.Remaining <= 0 {
+			msg := fmt.Sprintf("you already have %d pending request(s), which is the maximum allowed", quota.Pending)
 			if strings.Contains(r.Header.Get("HX-Request"), "true") {
 				w.Header().Set("Content-Type", "text/html; charset=utf-8")
 				w.WriteHeader(http.StatusTooManyRequests)
@@ -1025,100 +1216,76 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 		// store username in requester_email for backward-compatible storage
 		RequesterEmail: strings.ToLower(u.Username),
 		Title:          p.Title, Authors: p.Authors, ISBN10: p.ISBN10, ISBN13: p.ISBN13,
-		Format: format, Status: "pending",
+		Format: format, Status: "pending", GroupName: group,
+		NeedBy: needBy, ExtraFields: extras, Source: requestSource(r),
+	}
+	if format == formatMagazine {
+		req.Title = issueTitle(p.Title, frequency, time.Now().UTC())
 	}
 	// Stash provider payload on request so approval can use it.
 	// If missing, try to attach by looking it up from Readarr now.
+	var enriched string
 	if strings.TrimSpace(p.ProviderPayload) != "" {
-		req.ReadarrReq = json.RawMessage([]byte(p.ProviderPayload))
+		req.ReadarrReq = s.withRequestTemplate(format, json.RawMessage(p.ProviderPayload))
 		req.CoverURL = s.requestCoverFromPayload(format, req.ReadarrReq)
-	} else {
+	} else if format != formatMagazine {
 		// Attempt server-side attach for convenience/fallback
-		// Pick instance based on format
-		var inst providers.ReadarrInstance
-		if format == "audiobook" {
-			c := s.settings.Get().Readarr.Audiobooks
-			inst = s.toProviderInstance(c)
-		} else {
-			c := s.settings.Get().Readarr.Ebooks
-			inst = s.toProviderInstance(c)
-		}
+		// Pick instance based on format (and the requester's group mapping)
+		inst := s.readarrInstanceForRequest(req)
 		if strings.TrimSpace(inst.BaseURL) != "" && strings.TrimSpace(inst.APIKey) != "" {
 			ra := providers.NewReadarrWithDB(inst, s.db.SQL())
-			term := util.FirstNonEmpty(p.ASIN, p.ISBN13, p.ISBN10)
-			if term == "" {
-				term = strings.TrimSpace(p.Title)
-				if len(p.Authors) > 0 && strings.TrimSpace(p.Authors[0]) != "" {
-					term = term + " " + strings.TrimSpace(p.Authors[0])
-				}
+			hints := bookHints{Title: strings.TrimSpace(p.Title), ISBN13: strings.TrimSpace(p.ISBN13), ISBN10: strings.TrimSpace(p.ISBN10), ASIN: strings.TrimSpace(p.ASIN)}
+			if len(p.Authors) > 0 {
+				hints.Author = strings.TrimSpace(p.Authors[0])
 			}
-			if term != "" {
-				ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
-				defer cancel()
-				if list, err := ra.LookupByTerm(ctx, term); err == nil && len(list) > 0 {
-					pick := list[0]
-					for _, b := range list {
-						titleOK := strings.EqualFold(strings.TrimSpace(b.Title), strings.TrimSpace(p.Title)) && strings.TrimSpace(b.Title) != ""
-						authorOK := false
-						if len(p.Authors) > 0 {
-							want := strings.TrimSpace(p.Authors[0])
-							if b.Author != nil {
-								if n, _ := b.Author["name"].(string); n != "" && strings.EqualFold(strings.TrimSpace(n), want) {
-									authorOK = true
-								}
-							} else if len(b.Authors) > 0 {
-								if n, _ := b.Authors[0]["name"].(string); n != "" && strings.EqualFold(strings.TrimSpace(n), want) {
-									authorOK = true
-								}
-							} else if b.AuthorTitle != "" {
-								if strings.Contains(strings.ToLower(b.AuthorTitle), strings.ToLower(strings.ReplaceAll(want, " ", ""))) {
-									authorOK = true
-								}
-							}
-						}
-						if titleOK && authorOK {
-							pick = b
-							break
-						}
-					}
-					var author map[string]any
-					if pick.Author != nil {
-						author = pick.Author
-					}
-					if author == nil && len(pick.Authors) > 0 {
-						author = pick.Authors[0]
-					}
-					if author == nil && pick.AuthorId > 0 {
-						author = map[string]any{"id": pick.AuthorId}
-					}
-					if author == nil && pick.AuthorTitle != "" {
-						author = map[string]any{"name": parseAuthorNameFromTitle(pick.AuthorTitle)}
-					}
-					cand := map[string]any{
-						"title":     pick.Title,
-						"titleSlug": pick.TitleSlug,
-						"author":    author,
-						// include one monitored edition to pin selection
-						"editions":         []any{map[string]any{"foreignEditionId": pick.ForeignEditionId, "monitored": true}},
-						"foreignBookId":    pick.ForeignBookId,
-						"foreignEditionId": pick.ForeignEditionId,
-						// provider will backfill remaining defaults if missing
-						"monitored":         true,
-						"metadataProfileId": 1,
-					}
-					if b, err := json.Marshal(cand); err == nil {
-						req.ReadarrReq = json.RawMessage(b)
-						req.CoverURL = s.requestCoverFromPayload(format, req.ReadarrReq)
-					}
+			pick, filled, steps := s.resolveReadarrBook(r.Context(), ra, hints)
+			s.logEnrichment(p.Title, steps)
+			enriched = enrichmentSummary(steps)
+			// Keep identifiers the chain found so catalog matching and
+			// hydration can use them later.
+			req.ISBN13 = util.FirstNonEmpty(req.ISBN13, filled.ISBN13)
+			req.ISBN10 = util.FirstNonEmpty(req.ISBN10, filled.ISBN10)
+			if len(req.Authors) == 0 && filled.Author != "" {
+				req.Authors = []string{filled.Author}
+			}
+			if pick != nil {
+				if b, err := json.Marshal(readarrSelectionPayload(*pick)); err == nil {
+					req.ReadarrReq = s.withRequestTemplate(format, b)
+					req.CoverURL = s.requestCoverFromPayload(format, req.ReadarrReq)
+					req.NeedsReview = matchNeedsReview(steps)
 				}
 			}
 		}
 	}
+	// Enrichment may have found the author or identifier the denylist
+	// names, so check again before storing.
+	deny, denyWhy := s.denylistMatch(r.Context(), req.Title, req.Authors, req.ISBN10, req.ISBN13, p.ASIN)
+	if deny != nil && deny.Blocks() {
+		s.refuseDeniedRequest(w, r, req.Title, deny, denyWhy)
+		return
+	}
+	if deny != nil {
+		req.StatusReason = "flagged by denylist: " + denyWhy
+	}
 	id, err := s.db.CreateRequest(r.Context(), req)
 	if err != nil {
 		http.Error(w, "db: "+err.Error(), 500)
 		return
 	}
+	if deny != nil {
+		s.auditLog(r.Context(), u.Username, "request.flagged", &id, fmt.Sprintf("%q matched denylist %s", req.Title, denyWhy))
+	}
+	if enriched != "" {
+		s.auditLog(r.Context(), "system", "request.enriched", &id, enriched)
+	}
+	req.ID = id
+	var subscriptionID int64
+	if format == formatMagazine {
+		if subscriptionID, err = s.startSubscription(r.Context(), req, p.Title, frequency); err != nil {
+			http.Error(w, "db: "+err.Error(), 500)
+			return
+		}
+	}
 
 	// Check if user has auto-approve enabled
 	autoApprove := false
@@ -1127,17 +1294,41 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 			autoApprove = usr.AutoApprove
 		}
 	}
+	autoApprove = s.applySourcePolicy(r.Context(), req, autoApprove)
 
+	// Flagged requests always wait for an admin.
+	if autoApprove && deny != nil {
+		autoApprove = false
+		s.auditLog(r.Context(), "system", "request.auto_approve_held", &id, "flagged by denylist")
+	}
+	// Requests under the two-person policy always wait for two admins.
+	if autoApprove && s.needsTwoApprovals(r.Context(), req) {
+		autoApprove = false
+		s.auditLog(r.Context(), "system", "request.auto_approve_held", &id, "needs two admin approvals")
+	}
+	// Auto-approval waits for an admin to confirm a guessed edition.
+	if autoApprove && s.needsReviewBlocks(req) {
+		autoApprove = false
+		s.auditLog(r.Context(), "system", "request.auto_approve_held", &id, errNeedsReview.Error())
+	}
+	// Auto-approval waits for an admin while the target root folder is full.
 	if autoApprove {
-		// If Readarr not configured for this format, mark approved; else set processing and kick off async approval
-		var inst providers.ReadarrInstance
-		if format == "audiobook" {
-			c := s.settings.Get().Readarr.Audiobooks
-			inst = s.toProviderInstance(c)
-		} else {
-			c := s.settings.Get().Readarr.Ebooks
-			inst = s.toProviderInstance(c)
+		if inst := s.readarrInstanceForRequest(req); strings.TrimSpace(inst.BaseURL) != "" && strings.TrimSpace(inst.APIKey) != "" {
+			if err := s.checkReadarrSpace(r.Context(), inst); err != nil {
+				autoApprove = false
+				s.auditLog(r.Context(), "system", "request.auto_approve_held", &id, err.Error())
+			}
 		}
+	}
+
+	var linkedID int64
+	if autoApprove && format == formatComic {
+		if _, err := s.approveComic(r.Context(), req, u.Username, "auto-approved; "); err != nil {
+			_ = s.db.UpdateRequestStatus(r.Context(), id, "error", err.Error(), "system", nil, nil)
+		}
+	} else if autoApprove {
+		// If Readarr not configured for this format, mark approved; else set processing and kick off async approval
+		inst := s.readarrInstanceForRequest(req)
 
 		if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
 			// Approve without Readarr
@@ -1152,6 +1343,9 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	} else {
+		// Link with a pending request for the other format of the same book
+		// so both can be approved together.
+		linkedID = s.linkFormatCounterpart(r.Context(), req)
 		// Send notification for new request (only when not auto-approved)
 		s.SendRequestNotification(id, u.Username, p.Title, p.Authors)
 	}
@@ -1166,7 +1360,14 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 		if autoApprove {
 			msg = "Request auto-approved"
 		}
-		w.Write([]byte(`<li class="p-3 bg-emerald-50 text-emerald-700 rounded mb-2">` + msg + ` (ID ` + strconv.FormatInt(id, 10) + `). <a class="underline text-emerald-800" href="/requests">View in Requests</a></li>`))
+		msg += ` (ID ` + strconv.FormatInt(id, 10) + `)`
+		if linkedID > 0 {
+			msg += `, linked with request ` + strconv.FormatInt(linkedID, 10) + ` for the other format`
+		}
+		if subscriptionID > 0 {
+			msg += `; later ` + frequency + ` issues will be requested automatically`
+		}
+		w.Write([]byte(`<li class="p-3 bg-emerald-50 text-emerald-700 rounded mb-2">` + msg + `. <a class="underline text-emerald-800" href="/requests">View in Requests</a></li>`))
 		return
 	}
 	// Non-HTMX: prefer redirect back to the referrer if it's a browser form post
@@ -1178,17 +1379,46 @@ func (s *Server) apiCreateRequest(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, ref, http.StatusSeeOther)
 		return
 	}
-	writeJSON(w, map[string]any{"id": id, "status": "pending"}, 201)
+	resp := map[string]any{"id": id, "status": "pending"}
+	if linkedID > 0 {
+		resp["linked_request_id"] = linkedID
+	}
+	if subscriptionID > 0 {
+		resp["subscription_id"] = subscriptionID
+	}
+	writeJSON(w, resp, 201)
 }
 
+// apiListRequests lists the caller's requests. ?fields=id,title,status
+// returns only those fields of each; without it every field is returned
+// except the stored Readarr payloads, which are large.
 func (s *Server) apiListRequests(w http.ResponseWriter, r *http.Request) {
 	u := r.Context().Value(ctxUser).(*session)
-	items, err := s.db.ListRequests(r.Context(), u.Username, 200)
+	fields, err := parseFieldSelection(r.URL.Query().Get("fields"), requestFieldNames)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The payloads are only read from the database when asked for.
+	list := s.db.ListRequestsPage
+	if slices.Contains(fields, "readarrRequest") || slices.Contains(fields, "readarrResponse") {
+		list = s.db.ListRequests
+	}
+	items, err := list(r.Context(), u.Username, 200)
 	if err != nil {
 		http.Error(w, "db: "+err.Error(), 500)
 		return
 	}
-	writeJSON(w, items, 200)
+	if fields == nil {
+		writeJSON(w, items, 200)
+		return
+	}
+	out, err := selectFields(items, fields)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, out, 200)
 }
 
 func (s *Server) apiApproveRequest(w http.ResponseWriter, r *http.Request) {
@@ -1200,45 +1430,115 @@ func (s *Server) apiApproveRequest(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "not found", 404)
 		return
 	}
+	override, err := parseAddPolicyOverride(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
 
-	var inst providers.ReadarrInstance
-	if req.Format == "audiobook" {
-		c := s.settings.Get().Readarr.Audiobooks
-		inst = s.toProviderInstance(c)
-	} else {
-		c := s.settings.Get().Readarr.Ebooks
-		inst = s.toProviderInstance(c)
+	username := r.Context().Value(ctxUser).(*session).Username
+	if cost, source := r.FormValue("purchase_cost"), r.FormValue("purchase_source"); strings.TrimSpace(cost+source) != "" {
+		if err := s.recordPurchase(r.Context(), username, id, cost, source); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	status, err := s.approveRequest(r.Context(), req, username, "", override)
+	if errors.Is(err, errReadarrOutOfSpace) {
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	}
+	if errors.Is(err, errNeedsReview) || errors.Is(err, errSameApprover) || errors.Is(err, db.ErrStatusTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, errInstanceOverride) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Send immediate response to unblock the UI
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]string{"status": status}, 200)
+}
+
+// approveRequest approves a single request on behalf of actor. Without a
+// configured Readarr instance the request is marked approved immediately;
+// otherwise it moves to processing and is handed to the submission queue.
+// The returned status is "approved" or "processing". override adjusts the
+// instance's add policy for this approval only; an instance override is
+// stored on the request so retries and syncs use the same instance.
+// Requests under the two-person approval policy return
+// statusAwaitingSecondApproval until a second admin approves.
+func (s *Server) approveRequest(ctx context.Context, req *db.Request, actor, auditNote string, override addPolicyOverride) (string, error) {
+	// The approval steps below ignore refused status changes, so a request
+	// in a custom status that may not move on is stopped here.
+	if err := s.checkStatusTransition(req.Status, "approved"); err != nil {
+		return "", err
+	}
+	if req.Format != formatComic && s.needsReviewBlocks(req) {
+		return "", errNeedsReview
+	}
+	held, note, err := s.holdForSecondApproval(ctx, req, actor)
+	if err != nil {
+		return "", err
 	}
+	if held {
+		return statusAwaitingSecondApproval, nil
+	}
+	auditNote += note
+	if req.Format == formatComic {
+		return s.approveComic(ctx, req, actor, auditNote)
+	}
+	id := req.ID
+	if override.Instance != "" && override.Instance != req.ReadarrInstance {
+		routed := *req
+		routed.ReadarrInstance = override.Instance
+		if target := s.readarrInstanceForRequest(&routed); strings.TrimSpace(target.BaseURL) == "" || strings.TrimSpace(target.APIKey) == "" {
+			return "", fmt.Errorf("%w: no %s Readarr instance is configured", errInstanceOverride, override.Instance)
+		}
+		if err := s.db.SetRequestReadarrInstance(ctx, id, override.Instance); err != nil {
+			return "", err
+		}
+		req.ReadarrInstance = override.Instance
+	}
+	inst := override.apply(s.readarrInstanceForRequest(req))
+	auditNote += override.auditNote()
 	// If Readarr not configured, approve without sending
 	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
-		actor := r.Context().Value(ctxUser).(*session).Username
-		_ = s.db.ApproveRequest(r.Context(), id, actor)
-		_ = s.db.UpdateRequestStatus(r.Context(), id, "approved", "approved (no Readarr configured)", actor, nil, nil)
-		s.auditLog(r.Context(), actor, "request.approved", &id, "no Readarr configured")
+		_ = s.db.ApproveRequest(ctx, id, actor)
+		_ = s.db.UpdateRequestStatus(ctx, id, "approved", "approved (no Readarr configured)", actor, nil, nil)
+		s.auditLog(ctx, actor, "request.approved", &id, auditNote+"no Readarr configured")
 
 		// Send notification for approved request asynchronously
 		go s.SendApprovalNotification(req.RequesterEmail, req.Title, req.Authors)
+		return "approved", nil
+	}
 
-		w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
-		writeJSON(w, map[string]string{"status": "approved"}, 200)
-		return
+	// Leave the request pending when Readarr has nowhere to put the book.
+	if err := s.checkReadarrSpace(ctx, inst); err != nil {
+		return "", err
 	}
 
 	// For Readarr-enabled approvals, first update the UI immediately then process async
-	username := r.Context().Value(ctxUser).(*session).Username
-	_ = s.db.UpdateRequestStatus(r.Context(), id, "processing", "approval in progress", username, nil, nil)
+	_ = s.db.UpdateRequestStatus(ctx, id, "processing", "approval in progress", actor, nil, nil)
 
 	// Process approval asynchronously through the Readarr submission queue.
-	if err := s.enqueueAsyncApproval(id, req, inst, username); err != nil {
-		_ = s.db.UpdateRequestStatus(r.Context(), id, "error", err.Error(), "system", nil, nil)
-		http.Error(w, err.Error(), http.StatusServiceUnavailable)
-		return
+	if err := s.enqueueAsyncApproval(id, req, inst, actor); err != nil {
+		_ = s.db.UpdateRequestStatus(ctx, id, "error", err.Error(), "system", nil, nil)
+		return "", err
 	}
-	s.auditLog(r.Context(), username, "request.approved", &id, "queued for Readarr submission")
-
-	// Send immediate response to unblock the UI
-	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
-	writeJSON(w, map[string]string{"status": "processing"}, 200)
+	s.auditLog(ctx, actor, "request.approved", &id, auditNote+"queued for Readarr submission")
+	return "processing", nil
 }
 
 // apiRetryRequest retries processing of an already-approved request by
@@ -1256,47 +1556,74 @@ func (s *Server) apiRetryRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Only allow retry for requests that have been approved or previously queued
-	// (some approvals move to 'queued' after being sent to Readarr). Match the
-	// UI which shows the Retry action for both 'approved' and 'queued'.
-	if req.Status != "approved" && req.Status != "queued" {
-		http.Error(w, "can only retry approved or queued requests", 400)
+	// (some approvals move to 'queued' after being sent to Readarr), and for
+	// failed approvals from the Errors page. Match the UI which shows the
+	// Retry action for these states.
+	if req.Status != "approved" && req.Status != "queued" && req.Status != "error" {
+		http.Error(w, "can only retry approved, queued, or failed requests", 400)
 		return
 	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
 
-	// Must have a stored selection payload
-	if len(req.ReadarrReq) == 0 {
-		http.Error(w, "The originally selected book could not be matched to the backend system.", 400)
+	username := r.Context().Value(ctxUser).(*session).Username
+	status, err := s.retryRequest(r.Context(), req, username)
+	if errors.Is(err, errRetryNoSelection) || errors.Is(err, errRetryNoReadarr) {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if errors.Is(err, errNeedsReview) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
 
-	// Pick Readarr instance based on format
-	var inst providers.ReadarrInstance
-	if req.Format == "audiobook" {
-		c := s.settings.Get().Readarr.Audiobooks
-		inst = s.toProviderInstance(c)
-	} else {
-		c := s.settings.Get().Readarr.Ebooks
-		inst = s.toProviderInstance(c)
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]string{"status": status}, 200)
+}
+
+var (
+	errRetryNoSelection = errors.New("The originally selected book could not be matched to the backend system.")
+	errRetryNoReadarr   = errors.New("readarr not configured")
+)
+
+// retryRequest re-submits the stored selection payload to Readarr, or
+// re-adds a comic to Mylar. The returned status is "processing" for Readarr
+// and "approved" for Mylar.
+func (s *Server) retryRequest(ctx context.Context, req *db.Request, actor string) (string, error) {
+	if req.Format == formatComic {
+		return s.approveComic(ctx, req, actor, "retried; ")
+	}
+
+	// Must have a stored selection payload
+	if len(req.ReadarrReq) == 0 {
+		return "", errRetryNoSelection
+	}
+	if s.needsReviewBlocks(req) {
+		return "", errNeedsReview
 	}
 
+	// Pick Readarr instance based on format
+	inst := s.readarrInstanceForRequest(req)
 	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
-		http.Error(w, "readarr not configured", 400)
-		return
+		return "", errRetryNoReadarr
 	}
 
-	username := r.Context().Value(ctxUser).(*session).Username
 	// Update status to processing so UI reflects action immediately
-	_ = s.db.UpdateRequestStatus(r.Context(), id, "processing", "retrying approval", username, nil, nil)
+	_ = s.db.UpdateRequestStatus(ctx, req.ID, "processing", "retrying approval", actor, nil, nil)
 
 	// Re-run async approval using the stored request payload.
-	if err := s.enqueueAsyncApproval(id, req, inst, username); err != nil {
-		_ = s.db.UpdateRequestStatus(r.Context(), id, "error", err.Error(), "system", nil, nil)
-		http.Error(w, err.Error(), http.StatusServiceUnavailable)
-		return
+	if err := s.enqueueAsyncApproval(req.ID, req, inst, actor); err != nil {
+		_ = s.db.UpdateRequestStatus(ctx, req.ID, "error", err.Error(), "system", nil, nil)
+		return "", err
 	}
-
-	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
-	writeJSON(w, map[string]string{"status": "processing"}, 200)
+	return "processing", nil
 }
 
 // apiSearchRequest asks Readarr to re-search an already-requested book that is
@@ -1312,9 +1639,10 @@ func (s *Server) apiSearchRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Non-admin users may only search their own requests and only after the
-	// 30-minute cooldown has elapsed since the request was created.
+	// 30-minute cooldown has elapsed since the request was created. Group
+	// admins are treated as admins for requests in their group.
 	u := r.Context().Value(ctxUser).(*session)
-	if !u.Admin {
+	if !u.Admin && !s.isGroupAdmin(u.Username, req.GroupName) {
 		if !strings.EqualFold(strings.ToLower(req.RequesterEmail), strings.ToLower(u.Username)) {
 			http.Error(w, "forbidden", http.StatusForbidden)
 			return
@@ -1335,14 +1663,7 @@ func (s *Server) apiSearchRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var inst providers.ReadarrInstance
-	if req.Format == "audiobook" {
-		c := s.settings.Get().Readarr.Audiobooks
-		inst = s.toProviderInstance(c)
-	} else {
-		c := s.settings.Get().Readarr.Ebooks
-		inst = s.toProviderInstance(c)
-	}
+	inst := s.readarrInstanceForRequest(req)
 	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
 		http.Error(w, "readarr not configured", 400)
 		return
@@ -1350,7 +1671,7 @@ func (s *Server) apiSearchRequest(w http.ResponseWriter, r *http.Request) {
 
 	readarrID := req.MatchedReadarrID
 	if readarrID <= 0 {
-		match, err := s.findCatalogMatch(r.Context(), req.Format, req.Title, req.Authors, req.ISBN10, req.ISBN13, "", req.ReadarrReq)
+		match, err := s.findCatalogMatch(r.Context(), requestReadarrKind(req), req.Title, req.Authors, req.ISBN10, req.ISBN13, "", req.ReadarrReq)
 		if err != nil || match == nil || match.ReadarrID <= 0 {
 			http.Error(w, "request is not matched to a Readarr book", 400)
 			return
@@ -1387,11 +1708,13 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 	reqCtx, cancel := context.WithTimeout(ctx, 12*time.Second)
 	defer cancel()
 
-	if matched, err := s.tryCompleteApprovalFromCatalogMatch(reqCtx, req, inst, username, "", true); matched {
-		return
-	} else if err != nil {
-		_ = s.db.UpdateRequestStatus(ctx, id, "error", err.Error(), "system", nil, nil)
-		return
+	if !s.usesGroupReadarr(req) {
+		if matched, err := s.tryCompleteApprovalFromCatalogMatch(reqCtx, req, inst, username, "", true); matched {
+			return
+		} else if err != nil {
+			_ = s.db.UpdateRequestStatus(ctx, id, "error", err.Error(), "system", nil, nil)
+			return
+		}
 	}
 
 	// Require an exact selection payload saved at request-time
@@ -1420,7 +1743,8 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 				fmt.Printf("DEBUG: Author missing id, trying to resolve name='%s'\n", name)
 			}
 			if name != "" {
-				if aid, err := ra.FindAuthorIDByName(reqCtx, name); err == nil && aid != 0 {
+				fid, _ := a["foreignAuthorId"].(string)
+				if aid, err := ra.FindAuthorID(reqCtx, name, fid); err == nil && aid != 0 {
 					a["id"] = aid
 					if s.settings.Get().Debug {
 						fmt.Printf("DEBUG: Found author id %d for name '%s'\n", aid, name)
@@ -1438,6 +1762,10 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 			cand["author"] = a
 		}
 	}
+	authorProbe := probeAddAuthor(cand)
+
+	// Admin labels ride along as Readarr tags when enabled.
+	labelTags := s.labelReadarrTags(reqCtx, ra, req)
 
 	// Try to add the book to Readarr
 	var payload []byte
@@ -1450,7 +1778,7 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 		if json.Unmarshal(req.ReadarrReq, &raw) == nil {
 			// Heuristic: treat as full schema if it contains indicators
 			if _, ok := raw["authorTitle"]; ok || raw["author"] != nil || raw["editions"] != nil || raw["addOptions"] != nil {
-				payload, respBody, err = ra.AddBookRaw(reqCtx, req.ReadarrReq)
+				payload, respBody, err = ra.AddBookRaw(reqCtx, withReadarrTags(req.ReadarrReq, inst.DefaultTags, labelTags))
 			}
 		}
 	}
@@ -1461,7 +1789,7 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 			QualityProfileID: inst.DefaultQualityProfileID,
 			RootFolderPath:   inst.DefaultRootFolderPath,
 			SearchForMissing: true,
-			Tags:             inst.DefaultTags,
+			Tags:             mergeReadarrTags(inst.DefaultTags, labelTags),
 		})
 	}
 
@@ -1496,8 +1824,9 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 						}
 						// Persist matched id/availability before flipping to "queued".
 						_ = s.db.UpdateRequestExternalStatus(ctx, id, externalStatus, int64(bid), fmt.Sprintf("already in Readarr; monitoring enabled for id %d", bid))
+						s.backfillRequestIdentifiers(ctx, id, gotBody)
 						_ = s.db.UpdateRequestStatus(ctx, id, "queued", fmt.Sprintf("already in Readarr; monitoring enabled for id %d", bid), username, payload, respBody)
-						if cover := s.requestCoverFromPayload(req.Format, respBody); cover != "" {
+						if cover := s.requestCoverFromPayload(requestReadarrKind(req), respBody); cover != "" {
 							_ = s.db.UpdateRequestCover(ctx, id, cover)
 						}
 						go s.SendApprovalNotification(req.RequesterEmail, req.Title, req.Authors)
@@ -1512,17 +1841,25 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 				_ = s.db.UpdateRequestExternalStatus(ctx, id, status, bid, "already in Readarr (duplicate edition)")
 			}
 			_ = s.db.UpdateRequestStatus(ctx, id, "queued", "already in Readarr (duplicate edition)", username, payload, respBody)
-			if cover := s.requestCoverFromPayload(req.Format, respBody); cover != "" {
+			if cover := s.requestCoverFromPayload(requestReadarrKind(req), respBody); cover != "" {
 				_ = s.db.UpdateRequestCover(ctx, id, cover)
 			}
 			go s.SendApprovalNotification(req.RequesterEmail, req.Title, req.Authors)
 			return
 		}
 
+		if s.noteReadarrError(inst, err) {
+			s.parkApproval(approvalJob{id: id, req: req, inst: inst, username: username})
+			return
+		}
 		_ = s.db.UpdateRequestStatus(ctx, id, "error", err.Error(), "system", payload, respBody)
 		if s.settings.Get().Debug {
 			fmt.Printf("DEBUG: Readarr add error: %v\n---payload---\n%s\n---response---\n%s\n", err, string(payload), string(respBody))
 		}
+		if !authorProbe.Existed {
+			s.recordAddAuthor(ctx, req, ra, inst, authorProbe, respBody)
+			s.releaseRequestAuthor(id, "system")
+		}
 		return
 	}
 
@@ -1537,8 +1874,10 @@ func (s *Server) processAsyncApproval(id int64, req *db.Request, inst providers.
 		}
 		_ = s.db.UpdateRequestExternalStatus(ctx, id, status, bid, "sent to Readarr")
 	}
+	s.backfillRequestIdentifiers(ctx, id, respBody)
 	_ = s.db.UpdateRequestStatus(ctx, id, "queued", "sent to Readarr", username, payload, respBody)
-	if cover := s.requestCoverFromPayload(req.Format, respBody); cover != "" {
+	s.recordAddAuthor(ctx, req, ra, inst, authorProbe, respBody)
+	if cover := s.requestCoverFromPayload(requestReadarrKind(req), respBody); cover != "" {
 		_ = s.db.UpdateRequestCover(ctx, id, cover)
 	}
 
@@ -1658,20 +1997,31 @@ func (s *Server) apiDeclineRequest(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
 
-	_, err := s.db.GetRequest(r.Context(), id)
+	req, err := s.db.GetRequest(r.Context(), id)
 	if err != nil {
 		http.Error(w, "not found", 404)
 		return
 	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
 
 	reason := strings.TrimSpace(r.Header.Get("HX-Prompt"))
 	username := r.Context().Value(ctxUser).(*session).Username
 	err = s.db.DeclineRequest(r.Context(), id, username, reason)
+	if errors.Is(err, db.ErrStatusTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to decline request", 500)
 		return
 	}
 	s.auditLog(r.Context(), username, "request.declined", &id, reason)
+	s.releaseRequestAuthor(id, username)
+	s.kickHomeAssistant()
 
 	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
 	writeJSON(w, map[string]string{"status": "declined"}, 200)
@@ -1681,28 +2031,43 @@ func (s *Server) apiDeleteRequest(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
 
-	_, err := s.db.GetRequest(r.Context(), id)
+	req, err := s.db.GetRequest(r.Context(), id)
 	if err != nil {
 		http.Error(w, "not found", 404)
 		return
 	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	files, _ := s.db.ListRequestAttachments(r.Context(), id)
 
 	err = s.db.DeleteRequest(r.Context(), id)
 	if err != nil {
 		http.Error(w, "failed to delete request", 500)
 		return
 	}
+	var keys []string
+	for _, a := range files {
+		if a.StorageKey != "" {
+			keys = append(keys, a.StorageKey)
+		}
+	}
+	s.deleteAttachmentBlobs(r, keys)
 
 	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
 	writeJSON(w, map[string]string{"status": "deleted"}, 200)
 }
 
 func (s *Server) apiDeleteAllRequests(w http.ResponseWriter, r *http.Request) {
+	keys, _ := s.db.ListAttachmentStorageKeys(r.Context())
 	err := s.db.DeleteAllRequests(r.Context())
 	if err != nil {
 		http.Error(w, "failed to delete all requests", 500)
 		return
 	}
+	s.deleteAttachmentBlobs(r, keys)
 
 	w.Header().Set("HX-Trigger", `{"request:updated": {}}`)
 	writeJSON(w, map[string]string{"status": "all requests deleted"}, 200)
@@ -1731,16 +2096,35 @@ func (s *Server) apiApproveAllRequests(w http.ResponseWriter, r *http.Request) {
 
 	// Approve each pending request using the same processing path as single approvals.
 	username := r.Context().Value(ctxUser).(*session).Username
+	approved := 0
+	var held, review, awaiting []string
+	spaceErrs := make(map[string]error)
 	for _, pendingReq := range pendingRequests {
 		req := pendingReq
-		var inst providers.ReadarrInstance
-		if req.Format == "audiobook" {
-			c := s.settings.Get().Readarr.Audiobooks
-			inst = s.toProviderInstance(c)
-		} else {
-			c := s.settings.Get().Readarr.Ebooks
-			inst = s.toProviderInstance(c)
+		if s.needsReviewBlocks(&req) {
+			review = append(review, strconv.FormatInt(req.ID, 10))
+			continue
 		}
+		isHeld, note, err := s.holdForSecondApproval(r.Context(), &req, username)
+		if isHeld || err != nil {
+			awaiting = append(awaiting, strconv.FormatInt(req.ID, 10))
+			continue
+		}
+		inst := s.readarrInstanceForRequest(&req)
+
+		if strings.TrimSpace(inst.BaseURL) != "" && strings.TrimSpace(inst.APIKey) != "" {
+			key := readarrAuthAlertKey(inst)
+			spaceErr, checked := spaceErrs[key]
+			if !checked {
+				spaceErr = s.checkReadarrSpace(r.Context(), inst)
+				spaceErrs[key] = spaceErr
+			}
+			if spaceErr != nil {
+				held = append(held, strconv.FormatInt(req.ID, 10))
+				continue
+			}
+		}
+		approved++
 
 		if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
 			if err := s.db.ApproveRequest(r.Context(), req.ID, username); err != nil {
@@ -1751,7 +2135,7 @@ func (s *Server) apiApproveAllRequests(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, fmt.Sprintf("failed to approve request %d", req.ID), 500)
 				return
 			}
-			s.auditLog(r.Context(), username, "request.approved", &req.ID, "bulk action, no Readarr configured")
+			s.auditLog(r.Context(), username, "request.approved", &req.ID, note+"bulk action, no Readarr configured")
 			s.SendApprovalNotification(req.RequesterEmail, req.Title, req.Authors)
 			continue
 		}
@@ -1765,13 +2149,35 @@ func (s *Server) apiApproveAllRequests(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("failed to queue request %d: %v", req.ID, err), http.StatusServiceUnavailable)
 			return
 		}
-		s.auditLog(r.Context(), username, "request.approved", &req.ID, "bulk action, queued for Readarr submission")
+		s.auditLog(r.Context(), username, "request.approved", &req.ID, note+"bulk action, queued for Readarr submission")
 	}
 
+	status := fmt.Sprintf("approved %d requests", approved)
+	if len(held) > 0 {
+		var reasons []string
+		for _, err := range spaceErrs {
+			if err != nil {
+				reasons = append(reasons, err.Error())
+			}
+		}
+		sort.Strings(reasons)
+		status += fmt.Sprintf("; left %d pending (%s): %s", len(held), strings.Join(held, ", "), strings.Join(reasons, "; "))
+	}
+	if len(review) > 0 {
+		status += fmt.Sprintf("; left %d pending for edition review (%s)", len(review), strings.Join(review, ", "))
+	}
+	if len(awaiting) > 0 {
+		status += fmt.Sprintf("; left %d pending for a second admin's approval (%s)", len(awaiting), strings.Join(awaiting, ", "))
+	}
 	w.Header().Set("HX-Trigger", `{"request:updated": {}}`)
-	writeJSON(w, map[string]string{"status": fmt.Sprintf("approved %d requests", len(pendingRequests))}, 200)
+	writeJSON(w, map[string]string{"status": status}, 200)
 }
 
+var (
+	errHydrateNoTerms = errors.New("no identifiers or title to search")
+	errHydrateNoMatch = errors.New("no matches from Readarr")
+)
+
 // apiHydrateRequest tries to populate the stored selection payload for a request by
 // performing a Readarr lookup using the request's identifiers or title/author.
 // This is useful for older requests created before selection payloads were stored.
@@ -1790,81 +2196,52 @@ func (s *Server) apiHydrateRequest(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, map[string]any{"status": "ok", "message": "already attached"}, 200)
 		return
 	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
 
-	// Pick instance based on format
-	var inst providers.ReadarrInstance
-	if strings.ToLower(req.Format) == "audiobook" {
-		c := s.settings.Get().Readarr.Audiobooks
-		inst = s.toProviderInstance(c)
-	} else {
-		c := s.settings.Get().Readarr.Ebooks
-		inst = s.toProviderInstance(c)
+	needsReview, err := s.hydrateRequest(r.Context(), req, s.userEmail(r))
+	if errors.Is(err, errRetryNoReadarr) || errors.Is(err, errHydrateNoTerms) || errors.Is(err, errHydrateNoMatch) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
-		http.Error(w, "readarr not configured", http.StatusBadRequest)
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), 500)
 		return
 	}
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{"status": "ok", "needsReview": needsReview}, 200)
+}
+
+// hydrateRequest looks req up in Readarr and stores the match as its
+// selection payload. It reports whether the match was a guess that an admin
+// must confirm before approval.
+func (s *Server) hydrateRequest(ctx context.Context, req *db.Request, actor string) (bool, error) {
+	// Pick instance based on format
+	inst := s.readarrInstanceForRequest(req)
+	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
+		return false, errRetryNoReadarr
+	}
 
 	ra := providers.NewReadarrWithDB(inst, s.db.SQL())
 
-	// Build lookup term preference: ISBN13 > ISBN10 > Title [Author]
-	term := util.FirstNonEmpty(req.ISBN13, req.ISBN10)
-	if term == "" {
-		term = strings.TrimSpace(req.Title)
-		if len(req.Authors) > 0 && strings.TrimSpace(req.Authors[0]) != "" {
-			term = term + " " + strings.TrimSpace(req.Authors[0])
-		}
-	}
-	if term == "" {
-		http.Error(w, "no identifiers or title to search", http.StatusBadRequest)
-		return
+	hints := bookHints{Title: strings.TrimSpace(req.Title), ISBN13: strings.TrimSpace(req.ISBN13), ISBN10: strings.TrimSpace(req.ISBN10)}
+	if len(req.Authors) > 0 {
+		hints.Author = strings.TrimSpace(req.Authors[0])
 	}
-
-	ctx, cancel := context.WithTimeout(r.Context(), 12*time.Second)
-	defer cancel()
-	list, err := ra.LookupByTerm(ctx, term)
-	if err != nil || len(list) == 0 {
-		http.Error(w, "no matches from Readarr", http.StatusBadRequest)
-		return
+	if hints.isbn() == "" && hints.Title == "" {
+		return false, errHydrateNoTerms
 	}
-	// Choose best match: prefer title match and author match, else first
-	pick := list[0]
-	for _, b := range list {
-		titleOK := strings.EqualFold(strings.TrimSpace(b.Title), strings.TrimSpace(req.Title)) && strings.TrimSpace(b.Title) != ""
-		authorOK := false
-		if len(req.Authors) > 0 {
-			want := strings.TrimSpace(req.Authors[0])
-			if b.Author != nil {
-				if n, _ := b.Author["name"].(string); n != "" && strings.EqualFold(strings.TrimSpace(n), want) {
-					authorOK = true
-				}
-			} else if len(b.Authors) > 0 {
-				if n, _ := b.Authors[0]["name"].(string); n != "" && strings.EqualFold(strings.TrimSpace(n), want) {
-					authorOK = true
-				}
-			} else if b.AuthorTitle != "" {
-				if strings.Contains(strings.ToLower(b.AuthorTitle), strings.ToLower(strings.ReplaceAll(want, " ", ""))) {
-					authorOK = true
-				}
-			}
-		}
-		if titleOK && authorOK {
-			pick = b
-			break
-		}
+	pick, _, steps := s.resolveReadarrBook(ctx, ra, hints)
+	s.logEnrichment(req.Title, steps)
+	if pick == nil {
+		return false, errHydrateNoMatch
 	}
 
 	// Build candidate payload similar to search.go
-	var author map[string]any
-	if pick.Author != nil {
-		author = pick.Author
-	} else if len(pick.Authors) > 0 {
-		author = pick.Authors[0]
-	} else if pick.AuthorId > 0 {
-		author = map[string]any{"id": pick.AuthorId}
-	} else if pick.AuthorTitle != "" {
-		author = map[string]any{"name": parseAuthorNameFromTitle(pick.AuthorTitle)}
-	}
+	author := lookupBookAuthor(*pick)
 	cand := map[string]any{
 		"title":            pick.Title,
 		"titleSlug":        pick.TitleSlug,
@@ -1874,17 +2251,24 @@ func (s *Server) apiHydrateRequest(w http.ResponseWriter, r *http.Request) {
 		"foreignEditionId": pick.ForeignEditionId,
 	}
 	cjson, _ := json.Marshal(cand)
+	cjson = s.withRequestTemplate(req.Format, cjson)
 
 	// Save to DB
-	if err := s.db.UpdateRequestStatus(r.Context(), id, req.Status, "hydrated", s.userEmail(r), cjson, nil); err != nil {
-		http.Error(w, "db: "+err.Error(), 500)
-		return
+	reason := "hydrated"
+	if summary := enrichmentSummary(steps); summary != "" {
+		reason += " (" + summary + ")"
+	}
+	needsReview := matchNeedsReview(steps)
+	if err := s.db.UpdateRequestStatus(ctx, req.ID, req.Status, reason, actor, nil, nil); err != nil {
+		return false, err
+	}
+	if err := s.db.SetRequestSelection(ctx, req.ID, cjson, needsReview); err != nil {
+		return false, err
 	}
 	if cover := s.requestCoverFromPayload(req.Format, cjson); cover != "" {
-		_ = s.db.UpdateRequestCover(r.Context(), id, cover)
+		_ = s.db.UpdateRequestCover(ctx, req.ID, cover)
 	}
-	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
-	writeJSON(w, map[string]any{"status": "ok"}, 200)
+	return needsReview, nil
 }
 
 // parseAuthorNameFromTitle extracts author name from authorTitle like "andrews, ilona Burn for Me"
@@ -332,3 +332,52 @@ func TestBookEnrichedNormalizesReadarrMediaCoverForReaderModal(t *testing.T) {
 		t.Fatalf("unexpected normalized cover %q want %q", got, want)
 	}
 }
+
+func TestBookDetailsFallsBackToOpenLibraryWorkAndEditions(t *testing.T) {
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		switch r.URL.Path {
+		case "/books/OL32000000M.json":
+			body = `{"key":"/books/OL32000000M","title":"Project Hail Mary","isbn_13":["9780593135204"],"works":[{"key":"/works/OL21745884W"}]}`
+		case "/works/OL21745884W.json":
+			body = `{"key":"/works/OL21745884W","title":"Project Hail Mary","description":{"value":"A lone astronaut wakes up to save humanity."},"subjects":["Science fiction"],"first_publish_date":"2021-05-04"}`
+		case "/works/OL21745884W/editions.json":
+			if r.URL.Query().Get("limit") != "5" {
+				t.Fatalf("unexpected editions limit: %s", r.URL.RawQuery)
+			}
+			body = `{"size":2,"entries":[{"key":"/books/OL32000000M","title":"Project Hail Mary","publishers":["Ballantine Books"],"publish_date":"May 04, 2021","isbn_13":["9780593135204"],"number_of_pages":496,"physical_format":"Hardcover","languages":[{"key":"/languages/eng"}]},{"key":"/books/OL33000000M","title":"Project Hail Mary","publishers":["Audible Studios"]}]}`
+		default:
+			t.Fatalf("unexpected Open Library request: %s", r.URL.String())
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	}))
+
+	s := newServerForTest(t)
+	body, _ := json.Marshal(map[string]any{"open_library_edition_key": "OL32000000M"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/book/details", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		Title       string           `json:"title"`
+		ISBN13      string           `json:"isbn13"`
+		Description string           `json:"description"`
+		Subjects    []string         `json:"subjects"`
+		Editions    []map[string]any `json:"editions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if out.Title != "Project Hail Mary" || out.ISBN13 != "9780593135204" || out.Description != "A lone astronaut wakes up to save humanity." || len(out.Subjects) != 1 {
+		t.Fatalf("unexpected details: %+v", out)
+	}
+	if len(out.Editions) != 2 || out.Editions[0]["publisher"] != "Ballantine Books" || out.Editions[0]["pages"] != float64(496) || out.Editions[0]["language"] != "eng" {
+		t.Fatalf("unexpected editions: %+v", out.Editions)
+	}
+}
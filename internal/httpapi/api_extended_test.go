@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -13,6 +14,7 @@ import (
 
 	"gitea.knapp/jacoknapp/scriptorum/internal/config"
 	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
 )
 
 func TestDeleteRequestAndDeleteAllRequests(t *testing.T) {
@@ -178,6 +180,10 @@ func TestHydrateRequestErrors(t *testing.T) {
 }
 
 func TestBookDetailsVariants(t *testing.T) {
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(`{"docs":[]}`))}, nil
+	}))
 	s := newServerForTest(t)
 	r := s.Router()
 
@@ -43,13 +43,28 @@ func newServerForTest(t *testing.T) *Server {
 }
 
 func makeCookie(t *testing.T, s *Server, username string, admin bool) *http.Cookie {
-	sess := &session{Username: strings.ToLower(username), Name: "T", Admin: admin, Exp: 9999999999}
+	sess := &session{ID: recordTestSession(t, s, username), Username: strings.ToLower(username), Name: "T", Admin: admin, Exp: 9999999999}
 	b, _ := json.Marshal(sess)
 	sig := s.sign(b)
 	val := base64.RawURLEncoding.EncodeToString(b) + "." + base64.RawURLEncoding.EncodeToString(sig)
 	return &http.Cookie{Name: "scriptorum_session", Value: val, Path: "/"}
 }
 
+// recordTestSession stores a server-side session for username, as signing
+// in does, and returns its id.
+func recordTestSession(t *testing.T, s *Server, username string) string {
+	t.Helper()
+	id, err := randomToken(24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := s.db.CreateSession(context.Background(), db.Session{ID: id, Username: strings.ToLower(username), Created: now, LastSeen: now, Expires: now.Add(24 * time.Hour), Remember: true}); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
 func TestHealthAndSetupGate(t *testing.T) {
 	s := newServerForTest(t)
 	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
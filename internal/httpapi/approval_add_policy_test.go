@@ -0,0 +1,220 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+func TestParseAddPolicyOverride(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/?monitor=ALL&search_on_add=false&add_type=manual", nil)
+	o, err := parseAddPolicyOverride(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := o.apply(providers.ReadarrInstance{AddPolicy: providers.AddPolicy{Monitor: providers.MonitorNone}})
+	if inst.AddPolicy.Monitor != providers.MonitorAll || !inst.AddPolicy.SkipSearch || inst.AddPolicy.AddType != providers.AddTypeManual {
+		t.Fatalf("policy = %+v", inst.AddPolicy)
+	}
+	if o.auditNote() != "monitor=all add_type=manual search_on_add=false, " {
+		t.Fatalf("audit note = %q", o.auditNote())
+	}
+
+	empty, _ := parseAddPolicyOverride(httptest.NewRequest(http.MethodPost, "/", nil))
+	if empty.auditNote() != "" || empty.apply(inst).AddPolicy != inst.AddPolicy {
+		t.Fatal("empty override should keep the instance policy")
+	}
+	if o, err := parseAddPolicyOverride(httptest.NewRequest(http.MethodPost, "/?monitor=requested", nil)); err != nil || o.Monitor != providers.MonitorRequested {
+		t.Fatalf("strict override = %+v, %v", o, err)
+	}
+	for _, q := range []string{"monitor=backlist", "add_type=auto", "search_on_add=maybe"} {
+		if _, err := parseAddPolicyOverride(httptest.NewRequest(http.MethodPost, "/?"+q, nil)); err == nil {
+			t.Errorf("%s: expected an error", q)
+		}
+	}
+}
+
+func TestApprovalUsesInstanceAddPolicyAndOverride(t *testing.T) {
+	var mu sync.Mutex
+	var sent []map[string]any
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/book" && r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			var m map[string]any
+			_ = json.Unmarshal(body, &m)
+			mu.Lock()
+			sent = append(sent, m)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"id":101,"monitored":true,"statistics":{"bookFileCount":0}}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	cfg.Readarr.Ebooks.Monitor = "new"
+	_ = config.Save(s.cfgPath, cfg)
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+
+	approve := func(title, form string) {
+		t.Helper()
+		body := []byte(`{"title":"` + title + `","authors":["Alice"],"format":"ebook","provider_payload":"{\"title\":\"` + title + `\",\"foreignBookId\":\"fb-` + title + `\",\"foreignEditionId\":\"fe-` + title + `\",\"author\":{\"name\":\"Alice\"}}"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "user", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var created struct {
+			ID int64 `json:"id"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &created)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+		}
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(created.ID, 10)+"/approve", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(makeCookie(t, s, "admin", true))
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	waitFor := func(n int) map[string]any {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			if len(sent) >= n {
+				m := sent[n-1]
+				mu.Unlock()
+				return m
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for book add %d", n)
+		return nil
+	}
+	authorMonitor := func(m map[string]any) (string, string) {
+		a, _ := m["author"].(map[string]any)
+		ao, _ := a["addOptions"].(map[string]any)
+		monitor, _ := ao["monitor"].(string)
+		newItems, _ := a["monitorNewItems"].(string)
+		return monitor, newItems
+	}
+
+	approve("Default", "")
+	if monitor, newItems := authorMonitor(waitFor(1)); monitor != "future" || newItems != "new" {
+		t.Fatalf("instance policy: monitor=%q newItems=%q", monitor, newItems)
+	}
+
+	approve("Override", url.Values{"monitor": {"all"}, "search_on_add": {"false"}}.Encode())
+	m := waitFor(2)
+	if monitor, newItems := authorMonitor(m); monitor != "all" || newItems != "all" {
+		t.Fatalf("override: monitor=%q newItems=%q", monitor, newItems)
+	}
+	if ao, _ := m["addOptions"].(map[string]any); ao["searchForNewBook"] != false {
+		t.Fatalf("override addOptions = %#v", ao)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/1/approve?monitor=everything", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid override: %d", rec.Code)
+	}
+}
+
+func TestApprovalInstanceOverride(t *testing.T) {
+	var ebookAdds, audioAdds atomic.Int32
+	fake := func(n *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/api/v1/book" && r.Method == http.MethodPost {
+				n.Add(1)
+				_, _ = w.Write([]byte(`{"id":7,"monitored":true}`))
+				return
+			}
+			http.NotFound(w, r)
+		}))
+	}
+	ebooks, audiobooks := fake(&ebookAdds), fake(&audioAdds)
+	defer ebooks.Close()
+	defer audiobooks.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = ebooks.URL, "k"
+	cfg.Readarr.Audiobooks.BaseURL, cfg.Readarr.Audiobooks.APIKey = audiobooks.URL, "k"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "user", Title: "Dune", Authors: []string{"Frank"}, Format: "ebook", Status: "pending",
+		ReadarrReq: []byte(`{"title":"Dune","foreignBookId":"fb","foreignEditionId":"fe","author":{"name":"Frank","foreignAuthorId":"fa","id":3}}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := s.db.GetRequest(context.Background(), id)
+	if choices := s.readarrInstanceChoices(req); len(choices) != 2 || choices[0].Value != "" || !choices[0].Selected || choices[1].Value != "audiobook" {
+		t.Fatalf("choices = %+v", choices)
+	}
+
+	h := s.Router()
+	approve := func(form string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(id, 10)+"/approve", strings.NewReader(form))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(makeCookie(t, s, "admin", true))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+	if rec := approve("instance=comics"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad instance: %d", rec.Code)
+	}
+	if rec := approve("instance=audiobooks"); rec.Code != http.StatusOK {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for audioAdds.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if audioAdds.Load() != 1 || ebookAdds.Load() != 0 {
+		t.Fatalf("adds: audiobooks=%d ebooks=%d", audioAdds.Load(), ebookAdds.Load())
+	}
+	req, _ = s.db.GetRequest(context.Background(), id)
+	if req.ReadarrInstance != "audiobook" || s.readarrInstanceForRequest(req).BaseURL != audiobooks.URL {
+		t.Fatalf("stored instance = %q", req.ReadarrInstance)
+	}
+	events, _ := s.db.ListRequestAuditEvents(context.Background(), id, 10)
+	found := false
+	for _, e := range events {
+		found = found || (e.EventType == "request.approved" && strings.Contains(e.Details, "instance=audiobooks"))
+	}
+	if !found {
+		t.Fatalf("history = %+v", events)
+	}
+}
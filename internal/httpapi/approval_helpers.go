@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 
 	"gitea.knapp/jacoknapp/scriptorum/internal/db"
@@ -12,7 +16,7 @@ import (
 )
 
 func (s *Server) tryCompleteApprovalFromCatalogMatch(ctx context.Context, req *db.Request, inst providers.ReadarrInstance, username, reasonSuffix string, notify bool) (bool, error) {
-	match, err := s.findCatalogMatch(ctx, req.Format, req.Title, req.Authors, req.ISBN10, req.ISBN13, "", req.ReadarrReq)
+	match, err := s.findCatalogMatch(ctx, requestReadarrKind(req), req.Title, req.Authors, req.ISBN10, req.ISBN13, "", req.ReadarrReq)
 	if err == sql.ErrNoRows || match == nil {
 		return false, nil
 	}
@@ -59,7 +63,7 @@ func (s *Server) completeRequestFromCatalogMatch(ctx context.Context, req *db.Re
 	// the matched id must already be persisted by the time that status is visible.
 	_ = s.db.UpdateRequestExternalStatus(ctx, req.ID, externalStatus, match.ReadarrID, reason)
 	_ = s.db.UpdateRequestStatus(ctx, req.ID, "queued", reason, username, req.ReadarrReq, match.ReadarrData)
-	if cover := s.requestCoverFromPayload(req.Format, match.ReadarrData); cover != "" {
+	if cover := s.requestCoverFromPayload(requestReadarrKind(req), match.ReadarrData); cover != "" {
 		_ = s.db.UpdateRequestCover(ctx, req.ID, cover)
 	}
 	if notify {
@@ -67,7 +71,7 @@ func (s *Server) completeRequestFromCatalogMatch(ctx context.Context, req *db.Re
 	}
 	if !wasAvailable && externalStatus == "available" {
 		s.auditLog(ctx, "system", "request.available", &req.ID, req.Title)
-		s.SendAvailableNotification(req.RequesterEmail, req.Title, req.Authors)
+		s.notifyAvailable(*req)
 	}
 	return nil
 }
@@ -147,3 +151,140 @@ func readarrStateFromResponse(body []byte) (int64, string) {
 
 	return 0, ""
 }
+
+// readarrIdentifiersFromResponse reads a book's identifiers from a Readarr
+// book resource or list of them: its foreign book id, and the ISBN and ASIN
+// of its monitored edition, else of the first edition that has them.
+// Readarr's "isbn13" field sometimes holds an ISBN-10; either is accepted.
+func readarrIdentifiersFromResponse(body []byte) db.RequestIdentifiers {
+	var ids db.RequestIdentifiers
+	var raw any
+	if len(body) == 0 || json.Unmarshal(body, &raw) != nil {
+		return ids
+	}
+	book, _ := raw.(map[string]any)
+	if list, ok := raw.([]any); ok && len(list) > 0 {
+		book, _ = list[0].(map[string]any)
+	}
+	if book == nil {
+		return ids
+	}
+	ids.ForeignBookID = inputStringValue(book, "foreignBookId")
+	editions, _ := book["editions"].([]any)
+	sort.SliceStable(editions, func(i, j int) bool {
+		mi, _ := editions[i].(map[string]any)
+		mj, _ := editions[j].(map[string]any)
+		a, _ := mi["monitored"].(bool)
+		b, _ := mj["monitored"].(bool)
+		return a && !b
+	})
+	for _, e := range editions {
+		m, _ := e.(map[string]any)
+		if ids.ISBN13 == "" {
+			if ref, ok := providers.ParseBookIdentifier(inputStringValue(m, "isbn13")); ok && ref.Source == "isbn" {
+				ids.ISBN10, ids.ISBN13 = ref.ISBN10, ref.ISBN13
+			}
+		}
+		if ids.ASIN == "" {
+			ids.ASIN = strings.ToUpper(inputStringValue(m, "asin"))
+		}
+	}
+	return ids
+}
+
+// backfillRequestIdentifiers stores the identifiers Readarr reported for
+// request id's book where the request lacks them, so requests made with only
+// a title still match duplicates and library items later.
+func (s *Server) backfillRequestIdentifiers(ctx context.Context, id int64, body []byte) {
+	ids := readarrIdentifiersFromResponse(body)
+	if ids == (db.RequestIdentifiers{}) {
+		return
+	}
+	if err := s.db.BackfillRequestIdentifiers(ctx, id, ids); err != nil {
+		fmt.Printf("backfill identifiers for request %d: %v\n", id, err)
+	}
+}
+
+// errInstanceOverride rejects an instance override that cannot be honored.
+var errInstanceOverride = errors.New("cannot override the Readarr instance")
+
+// addPolicyOverride is an admin's change to the instance add policy for one
+// approval. Empty fields keep the instance setting. Instance ("ebook" or
+// "audiobook") sends the request to that Readarr instance instead of the
+// one its format maps to, and sticks to the request.
+type addPolicyOverride struct {
+	Monitor     string
+	AddType     string
+	SearchOnAdd *bool
+	Instance    string
+}
+
+// parseAddPolicyOverride reads the optional monitor, search_on_add,
+// add_type, and instance fields of an approve request.
+func parseAddPolicyOverride(r *http.Request) (addPolicyOverride, error) {
+	var o addPolicyOverride
+	if v := strings.ToLower(strings.TrimSpace(r.FormValue("instance"))); v != "" {
+		switch v {
+		case "ebook", "ebooks", "audiobook", "audiobooks":
+		default:
+			return o, fmt.Errorf("instance must be ebooks or audiobooks")
+		}
+		o.Instance = normalizeSyncKind(v)
+	}
+	if v := strings.ToLower(strings.TrimSpace(r.FormValue("monitor"))); v != "" {
+		switch v {
+		case providers.MonitorNone, providers.MonitorRequested, providers.MonitorNew, providers.MonitorAll:
+		default:
+			return o, fmt.Errorf("monitor must be none, requested, new, or all")
+		}
+		o.Monitor = v
+	}
+	if v := strings.ToLower(strings.TrimSpace(r.FormValue("add_type"))); v != "" {
+		if v != providers.AddTypeAutomatic && v != providers.AddTypeManual {
+			return o, fmt.Errorf("add_type must be automatic or manual")
+		}
+		o.AddType = v
+	}
+	if v := strings.TrimSpace(r.FormValue("search_on_add")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return o, fmt.Errorf("search_on_add must be true or false")
+		}
+		o.SearchOnAdd = &b
+	}
+	return o, nil
+}
+
+func (o addPolicyOverride) apply(inst providers.ReadarrInstance) providers.ReadarrInstance {
+	if o.Monitor != "" {
+		inst.AddPolicy.Monitor = o.Monitor
+	}
+	if o.AddType != "" {
+		inst.AddPolicy.AddType = o.AddType
+	}
+	if o.SearchOnAdd != nil {
+		inst.AddPolicy.SkipSearch = !*o.SearchOnAdd
+	}
+	return inst
+}
+
+// auditNote describes the override for the approval audit event.
+func (o addPolicyOverride) auditNote() string {
+	var parts []string
+	if o.Instance != "" {
+		parts = append(parts, "instance="+o.Instance+"s")
+	}
+	if o.Monitor != "" {
+		parts = append(parts, "monitor="+o.Monitor)
+	}
+	if o.AddType != "" {
+		parts = append(parts, "add_type="+o.AddType)
+	}
+	if o.SearchOnAdd != nil {
+		parts = append(parts, "search_on_add="+strconv.FormatBool(*o.SearchOnAdd))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, " ") + ", "
+}
@@ -62,6 +62,10 @@ func (s *Server) runApprovalQueue() {
 				time.Sleep(wait)
 			}
 		}
+		if s.readarrAuthPaused(job.inst) {
+			s.parkApproval(job)
+			continue
+		}
 		lastStarted = time.Now()
 		s.processAsyncApproval(job.id, job.req, job.inst, job.username)
 	}
@@ -19,8 +19,8 @@ func (s *Server) recoverProcessingApprovals(ctx context.Context) {
 	}
 	for i := range requests {
 		req := requests[i]
-		inst, ok := s.readarrInstanceForFormat(req.Format)
-		if !ok {
+		inst := s.readarrInstanceForRequest(&req)
+		if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
 			_ = s.db.UpdateRequestStatus(ctxOrBackground(ctx), req.ID, "error", "readarr not configured; could not restore queued approval after restart", "system", nil, nil)
 			continue
 		}
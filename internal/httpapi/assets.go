@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// staticAssets serves the embedded web/static files. Each file is also
+// reachable under a name carrying a hash of its contents
+// (css/tailwind.3f2a9c1b7d.css); pages link those through the "asset"
+// template func so browsers can cache them for good and still pick up a
+// rebuilt file, which gets a new name.
+type staticAssets struct {
+	fsys    fs.FS
+	hashed  map[string]string // plain name -> hashed name
+	byHash  map[string]string // hashed name -> plain name
+	handler http.Handler
+}
+
+var assets = loadStaticAssets()
+
+func loadStaticAssets() *staticAssets {
+	sub, _ := fs.Sub(staticFS, "web/static")
+	a := &staticAssets{
+		fsys:    sub,
+		hashed:  map[string]string{},
+		byHash:  map[string]string{},
+		handler: http.FileServer(http.FS(sub)),
+	}
+	_ = fs.WalkDir(sub, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(sub, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		ext := path.Ext(name)
+		h := strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:])[:10] + ext
+		a.hashed[name] = h
+		a.byHash[h] = name
+		return nil
+	})
+	return a
+}
+
+// assetURL returns the cache-busted URL of a static file, or its plain
+// /static URL when no such file is embedded.
+func assetURL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if h, ok := assets.hashed[name]; ok {
+		return "/static/" + h
+	}
+	return "/static/" + name
+}
+
+// ServeHTTP serves hashed names with a one-year immutable lifetime. Plain
+// names stay available with a day's lifetime for links that cannot be
+// rewritten, such as the web manifest icon and script fallbacks.
+func (a *staticAssets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/static/")
+	if plain, ok := a.byHash[name]; ok {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		http.ServeFileFS(w, r, a.fsys, plain)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.StripPrefix("/static/", a.handler).ServeHTTP(w, r)
+}
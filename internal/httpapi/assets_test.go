@@ -0,0 +1,38 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHashedStaticAssets(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	css := assetURL("css/tailwind.css")
+	if css == "/static/css/tailwind.css" || !strings.HasPrefix(css, "/static/css/tailwind.") || !strings.HasSuffix(css, ".css") {
+		t.Fatalf("assetURL = %q", css)
+	}
+	rec := get(css)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Cache-Control"), "immutable") || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/css") {
+		t.Fatalf("hashed asset: %d %v", rec.Code, rec.Header())
+	}
+	if plain := get("/static/css/tailwind.css"); plain.Code != http.StatusOK || plain.Body.Len() != rec.Body.Len() || plain.Header().Get("Cache-Control") != "public, max-age=86400" {
+		t.Fatalf("plain asset: %d %v", plain.Code, plain.Header())
+	}
+	if got := assetURL("missing.js"); got != "/static/missing.js" {
+		t.Fatalf("missing asset url = %q", got)
+	}
+
+	login := get("/login")
+	if body := login.Body.String(); !strings.Contains(body, `href="`+css+`"`) || !strings.Contains(body, assetURL("icon.svg")) {
+		t.Fatalf("login page does not link hashed assets: %d", login.Code)
+	}
+}
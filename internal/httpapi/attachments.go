@@ -0,0 +1,278 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/attachments"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// maxAttachmentsPerRequest caps files plus links on a single request.
+const maxAttachmentsPerRequest = 5
+
+// maxAttachmentURLLen bounds stored link attachments.
+const maxAttachmentURLLen = 2048
+
+// attachmentStore builds the configured blob store. It is rebuilt on each
+// call so settings changes apply without a restart.
+func (s *Server) attachmentStore() (attachments.Store, error) {
+	cfg := s.settings.Get()
+	dir := "attachments"
+	if p := strings.TrimSpace(cfg.DB.Path); p != "" {
+		dir = filepath.Join(filepath.Dir(p), "attachments")
+	}
+	return attachments.New(cfg.Attachments, dir, s.outboundHTTPClient(30*time.Second))
+}
+
+// loadAccessibleRequest fetches the {id} request and checks the caller may
+// see it, writing the error response itself when not.
+func (s *Server) loadAccessibleRequest(w http.ResponseWriter, r *http.Request) (*db.Request, bool) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !s.canAccessRequest(r.Context().Value(ctxUser).(*session), req) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	return req, true
+}
+
+func (s *Server) apiListAttachments(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.loadAccessibleRequest(w, r)
+	if !ok {
+		return
+	}
+	list, err := s.db.ListRequestAttachments(r.Context(), req.ID)
+	if err != nil {
+		http.Error(w, "failed to list attachments", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []db.RequestAttachment{}
+	}
+	writeJSON(w, list, http.StatusOK)
+}
+
+// apiAddAttachment accepts a multipart "file" upload or a "url" link. Uploads
+// are sniffed rather than trusting the client's content type.
+func (s *Server) apiAddAttachment(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.loadAccessibleRequest(w, r)
+	if !ok {
+		return
+	}
+	u := r.Context().Value(ctxUser).(*session)
+	cfg := s.settings.Get().Attachments
+	maxSize := attachments.MaxSize(cfg)
+
+	existing, err := s.db.ListRequestAttachments(r.Context(), req.ID)
+	if err != nil {
+		http.Error(w, "failed to list attachments", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) >= maxAttachmentsPerRequest {
+		http.Error(w, fmt.Sprintf("a request can have at most %d attachments", maxAttachmentsPerRequest), http.StatusConflict)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+64<<10)
+	if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "attachment is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid upload", http.StatusBadRequest)
+		return
+	}
+
+	a := &db.RequestAttachment{RequestID: req.ID, UploadedBy: strings.ToLower(u.Username)}
+	if link := strings.TrimSpace(r.FormValue("url")); link != "" {
+		parsed, err := url.Parse(link)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(link) > maxAttachmentURLLen {
+			http.Error(w, "url must be an http or https link", http.StatusBadRequest)
+			return
+		}
+		a.Kind = "link"
+		a.URL = parsed.String()
+		a.Name = truncateChars(strings.TrimSpace(r.FormValue("name")), 200)
+		if a.Name == "" {
+			a.Name = parsed.Host
+		}
+	} else {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file or url is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		store, err := s.attachmentStore()
+		if errors.Is(err, attachments.ErrDisabled) {
+			http.Error(w, "file attachments are disabled; attach a link instead", http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, "attachment storage is misconfigured", http.StatusInternalServerError)
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+		if err != nil {
+			http.Error(w, "failed to read upload", http.StatusBadRequest)
+			return
+		}
+		if int64(len(data)) > maxSize {
+			http.Error(w, "attachment is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if len(data) == 0 {
+			http.Error(w, "file is empty", http.StatusBadRequest)
+			return
+		}
+		contentType := http.DetectContentType(data)
+		if !attachments.Allowed(cfg, contentType) {
+			http.Error(w, "file type "+strings.SplitN(contentType, ";", 2)[0]+" is not allowed", http.StatusUnsupportedMediaType)
+			return
+		}
+		a.Kind = "file"
+		a.Name = truncateChars(filepath.Base(strings.ReplaceAll(header.Filename, "\\", "/")), 200)
+		a.ContentType = contentType
+		a.Size = int64(len(data))
+		a.StorageKey = strconv.FormatInt(req.ID, 10) + "/" + randomAttachmentID()
+		if err := store.Put(r.Context(), a.StorageKey, bytes.NewReader(data), a.Size, contentType); err != nil {
+			http.Error(w, "failed to store attachment", http.StatusBadGateway)
+			return
+		}
+	}
+	if _, err := s.db.AddRequestAttachment(r.Context(), a); err != nil {
+		if a.StorageKey != "" {
+			s.deleteAttachmentBlobs(r, []string{a.StorageKey})
+		}
+		http.Error(w, "failed to save attachment", http.StatusInternalServerError)
+		return
+	}
+
+	s.auditLog(r.Context(), u.Username, "request.attachment_added", &req.ID, fmt.Sprintf("%s %q", a.Kind, a.Name))
+	w.Header().Set("HX-Trigger", `{"attachment:changed": {"id": `+strconv.FormatInt(req.ID, 10)+`}}`)
+	writeJSON(w, a, http.StatusCreated)
+}
+
+// apiGetAttachment streams a file attachment. Files are served with their
+// sniffed type, nosniff, and a sandboxing CSP so an upload can never run as
+// a page on this origin.
+func (s *Server) apiGetAttachment(w http.ResponseWriter, r *http.Request) {
+	a, ok := s.loadAttachment(w, r)
+	if !ok {
+		return
+	}
+	if a.Kind != "file" {
+		http.Error(w, "not a file attachment", http.StatusBadRequest)
+		return
+	}
+	store, err := s.attachmentStore()
+	if err != nil {
+		http.Error(w, "attachment storage unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	body, err := store.Get(r.Context(), a.StorageKey)
+	if errors.Is(err, attachments.ErrNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to read attachment", http.StatusBadGateway)
+		return
+	}
+	defer body.Close()
+	disposition := "attachment"
+	if strings.HasPrefix(a.ContentType, "image/") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	_, _ = io.Copy(w, body)
+}
+
+// apiDeleteAttachment removes an attachment. Only whoever uploaded it and
+// the request's admins may remove it; others who can see the request may
+// not remove what someone else added.
+func (s *Server) apiDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.loadAccessibleRequest(w, r)
+	if !ok {
+		return
+	}
+	aid, _ := strconv.ParseInt(chi.URLParam(r, "aid"), 10, 64)
+	a, err := s.db.GetRequestAttachment(r.Context(), req.ID, aid)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	u := r.Context().Value(ctxUser).(*session)
+	if !strings.EqualFold(a.UploadedBy, u.Username) && !s.canAdminRequest(r, req) {
+		http.Error(w, "only the uploader or an admin can remove this attachment", http.StatusForbidden)
+		return
+	}
+	if err := s.db.DeleteRequestAttachment(r.Context(), a.RequestID, a.ID); err != nil {
+		http.Error(w, "failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+	if a.StorageKey != "" {
+		s.deleteAttachmentBlobs(r, []string{a.StorageKey})
+	}
+	s.auditLog(r.Context(), u.Username, "request.attachment_removed", &a.RequestID, fmt.Sprintf("%s %q", a.Kind, a.Name))
+	w.Header().Set("HX-Trigger", `{"attachment:changed": {"id": `+strconv.FormatInt(a.RequestID, 10)+`}}`)
+	writeJSON(w, map[string]string{"status": "deleted"}, http.StatusOK)
+}
+
+func (s *Server) loadAttachment(w http.ResponseWriter, r *http.Request) (*db.RequestAttachment, bool) {
+	req, ok := s.loadAccessibleRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+	aid, _ := strconv.ParseInt(chi.URLParam(r, "aid"), 10, 64)
+	a, err := s.db.GetRequestAttachment(r.Context(), req.ID, aid)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, false
+	}
+	return a, true
+}
+
+// deleteAttachmentBlobs removes stored files on a best-effort basis: the
+// database rows are already gone and an orphaned blob is harmless.
+func (s *Server) deleteAttachmentBlobs(r *http.Request, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	store, err := s.attachmentStore()
+	if err != nil {
+		return
+	}
+	for _, k := range keys {
+		_ = store.Delete(r.Context(), k)
+	}
+}
+
+func randomAttachmentID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,173 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// pngHeader is enough for http.DetectContentType to report image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func multipartUpload(t *testing.T, name string, data []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("form file: %v", err)
+	}
+	fw.Write(data)
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestRequestAttachmentsUploadDownloadDelete(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	reqID, err := s.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "user", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	base := "/api/v1/requests/" + strconv.FormatInt(reqID, 10) + "/attachments"
+
+	do := func(req *http.Request, user string) *httptest.ResponseRecorder {
+		req.AddCookie(makeCookie(t, s, user, user == "admin"))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body, ct := multipartUpload(t, "edition.png", pngHeader)
+	req := httptest.NewRequest(http.MethodPost, base, body)
+	req.Header.Set("Content-Type", ct)
+	rec := do(req, "user")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: %d %s", rec.Code, rec.Body.String())
+	}
+	var file db.RequestAttachment
+	_ = json.Unmarshal(rec.Body.Bytes(), &file)
+	if file.Kind != "file" || file.ContentType != "image/png" || file.Name != "edition.png" {
+		t.Fatalf("unexpected attachment: %+v", file)
+	}
+
+	rec = do(httptest.NewRequest(http.MethodGet, base+"/"+strconv.FormatInt(file.ID, 10), nil), "user")
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), pngHeader) {
+		t.Fatalf("download: %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Content-Type-Options") != "nosniff" || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("download headers: %v", rec.Header())
+	}
+
+	// Someone else's request is off limits.
+	rec = do(httptest.NewRequest(http.MethodGet, base+"/"+strconv.FormatInt(file.ID, 10), nil), "mallory")
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("other user download: %d", rec.Code)
+	}
+
+	// HTML is sniffed and rejected whatever the filename claims.
+	body, ct = multipartUpload(t, "fake.png", []byte("<html><script>alert(1)</script></html>"))
+	req = httptest.NewRequest(http.MethodPost, base, body)
+	req.Header.Set("Content-Type", ct)
+	if rec = do(req, "user"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("html upload: %d %s", rec.Code, rec.Body.String())
+	}
+
+	form := url.Values{"url": {"javascript:alert(1)"}}
+	req = httptest.NewRequest(http.MethodPost, base, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if rec = do(req, "user"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("javascript link: %d", rec.Code)
+	}
+	form = url.Values{"url": {"https://store.example.com/dune"}}
+	req = httptest.NewRequest(http.MethodPost, base, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if rec = do(req, "user"); rec.Code != http.StatusCreated {
+		t.Fatalf("link: %d %s", rec.Code, rec.Body.String())
+	}
+
+	page := do(httptest.NewRequest(http.MethodGet, "/requests/"+strconv.FormatInt(reqID, 10), nil), "user")
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "edition.png") || !strings.Contains(page.Body.String(), "store.example.com") {
+		t.Fatalf("detail page: %d", page.Code)
+	}
+
+	// An admin's attachment on the request can only be removed by an admin,
+	// not by the requester who can see it.
+	form = url.Values{"url": {"https://library.example.com/dune"}}
+	req = httptest.NewRequest(http.MethodPost, base, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if rec = do(req, "admin"); rec.Code != http.StatusCreated {
+		t.Fatalf("admin link: %d %s", rec.Code, rec.Body.String())
+	}
+	var adminLink db.RequestAttachment
+	_ = json.Unmarshal(rec.Body.Bytes(), &adminLink)
+	rec = do(httptest.NewRequest(http.MethodDelete, base+"/"+strconv.FormatInt(adminLink.ID, 10), nil), "user")
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("requester deleting the admin's attachment: %d %s", rec.Code, rec.Body.String())
+	}
+	if page := do(httptest.NewRequest(http.MethodGet, "/ui/requests/"+strconv.FormatInt(reqID, 10)+"/attachments", nil), "user"); strings.Count(page.Body.String(), "hx-delete=") != 2 {
+		t.Fatalf("requester should only be offered Remove on their own attachments: %s", page.Body.String())
+	}
+	if rec = do(httptest.NewRequest(http.MethodDelete, base+"/"+strconv.FormatInt(adminLink.ID, 10), nil), "admin"); rec.Code != http.StatusOK {
+		t.Fatalf("admin deleting their attachment: %d", rec.Code)
+	}
+
+	rec = do(httptest.NewRequest(http.MethodDelete, base+"/"+strconv.FormatInt(file.ID, 10), nil), "admin")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body.String())
+	}
+	store, _ := s.attachmentStore()
+	if _, err := store.Get(context.Background(), file.StorageKey); err == nil {
+		t.Fatal("blob survived attachment deletion")
+	}
+	rec = do(httptest.NewRequest(http.MethodGet, base, nil), "user")
+	var list []db.RequestAttachment
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Kind != "link" {
+		t.Fatalf("remaining attachments: %+v", list)
+	}
+}
+
+func TestRequestAttachmentLimits(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Attachments.MaxSizeKB = 1
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	h := s.Router()
+	reqID, _ := s.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "user", Title: "Emma", Format: "ebook", Status: "pending"})
+	base := "/api/v1/requests/" + strconv.FormatInt(reqID, 10) + "/attachments"
+
+	upload := func(data []byte) int {
+		body, ct := multipartUpload(t, "big.png", data)
+		req := httptest.NewRequest(http.MethodPost, base, body)
+		req.Header.Set("Content-Type", ct)
+		req.AddCookie(makeCookie(t, s, "user", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		io.Copy(io.Discard, rec.Body)
+		return rec.Code
+	}
+	if code := upload(append(append([]byte{}, pngHeader...), make([]byte, 2048)...)); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: %d", code)
+	}
+	for i := 0; i < maxAttachmentsPerRequest; i++ {
+		if code := upload(pngHeader); code != http.StatusCreated {
+			t.Fatalf("upload %d: %d", i, code)
+		}
+	}
+	if code := upload(pngHeader); code != http.StatusConflict {
+		t.Fatalf("upload past limit: %d", code)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-chi/chi/v5"
 	"golang.org/x/crypto/bcrypt"
@@ -216,10 +217,71 @@ func (s *Server) sessionSecret() string {
 }
 
 type session struct {
+	// ID names the server-side session record. Cookies without one, such
+	// as those issued before sessions were recorded, are not accepted.
+	ID       string `json:"sid,omitempty"`
 	Username string `json:"username"`
 	Name     string `json:"name"`
 	Admin    bool   `json:"admin"`
 	Exp      int64  `json:"exp"`
+	// Remember marks a "remember me" sign-in, whose cookie outlives the
+	// browser and which has no idle timeout.
+	Remember bool `json:"rem,omitempty"`
+}
+
+// sessionTouchInterval limits how often a session's last activity is
+// written, so busy pages do not write on every request.
+const sessionTouchInterval = time.Minute
+
+// startSession signs username in. The session is recorded server-side so it
+// can expire when idle and be revoked.
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, username, name string, admin, remember bool) error {
+	id, err := randomToken(24)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	exp := now.Add(s.settings.Get().Sessions.Lifetime(remember))
+	if err := s.db.CreateSession(r.Context(), db.Session{
+		ID:        id,
+		Username:  username,
+		Created:   now,
+		LastSeen:  now,
+		Expires:   exp,
+		Remember:  remember,
+		UserAgent: truncateRunes(r.UserAgent(), 200),
+	}); err != nil {
+		return err
+	}
+	s.setSession(w, &session{ID: id, Username: username, Name: name, Admin: admin, Exp: exp.Unix(), Remember: remember})
+	return nil
+}
+
+// sessionActive checks sess against its server-side record. Revoked,
+// expired, and idle sessions are over; live ones have their idle window
+// renewed.
+func (s *Server) sessionActive(ctx context.Context, sess *session) bool {
+	rec, err := s.db.GetSession(ctx, sess.ID)
+	if err != nil || !strings.EqualFold(rec.Username, sess.Username) {
+		return false
+	}
+	now := time.Now()
+	idle := s.settings.Get().Sessions.IdleTimeout()
+	if now.After(rec.Expires) || (idle > 0 && !rec.Remember && now.Sub(rec.LastSeen) > idle) {
+		_ = s.db.DeleteSession(ctx, rec.ID)
+		return false
+	}
+	if now.Sub(rec.LastSeen) > sessionTouchInterval {
+		_ = s.db.TouchSession(ctx, rec.ID, now)
+	}
+	return true
+}
+
+// pruneSessions drops session records that have expired.
+func (s *Server) pruneSessions(ctx context.Context) {
+	if _, err := s.db.PruneSessions(ctx, time.Now()); err != nil {
+		fmt.Printf("sessions: prune failed: %v\n", err)
+	}
 }
 
 func (s *Server) setSession(w http.ResponseWriter, sess *session) {
@@ -236,7 +298,10 @@ func (s *Server) setSession(w http.ResponseWriter, sess *session) {
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 		Secure:   s.sessionCookieSecure(),
-		MaxAge:   int((24 * time.Hour).Seconds()),
+	}
+	// Without "remember me" the cookie ends with the browser session.
+	if sess.Remember {
+		httpCookie.MaxAge = int(time.Until(time.Unix(sess.Exp, 0)).Seconds())
 	}
 
 	// Apply production cookie settings if configured
@@ -270,6 +335,11 @@ func (s *Server) getSession(r *http.Request) *session {
 	if time.Now().Unix() > sess.Exp {
 		return nil
 	}
+	// Every session must have a live server-side record, so revoking
+	// sessions or disabling the user signs it out.
+	if sess.ID == "" || !s.sessionActive(r.Context(), &sess) {
+		return nil
+	}
 
 	// Debug logging for session retrieval (only log occasionally to avoid spam)
 	cfg := s.settings.Get()
@@ -296,6 +366,8 @@ func (s *Server) mountAuth(r chi.Router) {
 		"csrfToken":     func(r *http.Request) string { return s.getCSRFToken(r) },
 		"authorsText":   authorsText,
 		"truncateChars": truncateChars,
+		"brand":         s.branding,
+		"asset":         assetURL,
 	}
 	authUI := struct{ tpl *template.Template }{
 		tpl: template.Must(template.New("tpl").Funcs(funcMap).ParseFS(tplFS, "web/templates/*.html")),
@@ -413,6 +485,12 @@ func (s *Server) handleOAuthLogin(w http.ResponseWriter, r *http.Request) {
 
 	http.SetCookie(w, stateCookie)
 	http.SetCookie(w, pkceCookie)
+	// The callback reads "remember me" back from this cookie.
+	rememberCookie := &http.Cookie{Name: "oauth_remember", Value: "1", Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: stateCookie.Secure, Domain: stateCookie.Domain}
+	if r.URL.Query().Get("remember") != "1" {
+		rememberCookie.Value, rememberCookie.MaxAge = "", -1
+	}
+	http.SetCookie(w, rememberCookie)
 
 	url := s.oidc.config.AuthCodeURL(state, oauth2.SetAuthURLParam("code_challenge", challenge), oauth2.SetAuthURLParam("code_challenge_method", "S256"))
 	if s.cfg.Debug {
@@ -467,6 +545,11 @@ func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 	// clear state cookie as well
 	http.SetCookie(w, &http.Cookie{Name: "oauth_state", Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: s.sessionCookieSecure()})
+	remember := false
+	if rc, err := r.Cookie("oauth_remember"); err == nil && rc.Value == "1" {
+		remember = true
+		http.SetCookie(w, &http.Cookie{Name: "oauth_remember", Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: s.sessionCookieSecure()})
+	}
 
 	// Pass code_verifier when using PKCE
 	var oauth2Token *oauth2.Token
@@ -611,12 +694,17 @@ func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
 	// Auto-provision users if enabled (use username)
 	if cfg.OAuth.AutoCreateUsers {
 		if _, err := s.db.GetUserByUsername(r.Context(), username); err != nil {
-			// create with random/empty password hash; password not used for OAuth logins
-			randHash := "$2a$10$scriptorum.oauth.autocreate.dummyhash012345678901234567890"
-			_, _ = s.db.CreateUser(r.Context(), username, randHash, s.isAdminUsername(username), false)
+			// create with an unusable password hash; password not used for OAuth logins
+			_, _ = s.db.CreateUser(r.Context(), username, oauthPlaceholderHash, s.isAdminUsername(username), false)
 		}
 	}
 
+	if u, err := s.db.GetUserByUsername(r.Context(), username); err == nil && u.Disabled {
+		s.auditLog(r.Context(), username, "user.login_failed", nil, "account disabled")
+		http.Error(w, "account disabled", http.StatusForbidden)
+		return
+	}
+
 	disp := fmt.Sprint(claims["name"])
 	if strings.TrimSpace(disp) == "" {
 		disp = username
@@ -637,8 +725,12 @@ func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
 		fmt.Printf("DEBUG: Admin usernames configured: %v\n", adminUsernames)
 	}
 
-	sess := &session{Username: username, Name: disp, Admin: s.isAdminUsername(username), Exp: time.Now().Add(24 * time.Hour).Unix()}
-	s.setSession(w, sess)
+	// Admin comes from the config list or the account's role, which SCIM
+	// group membership may have set.
+	if err := s.startSession(w, r, username, disp, s.requesterIsAdmin(username), remember); err != nil {
+		http.Error(w, "failed to start session", http.StatusInternalServerError)
+		return
+	}
 	http.Redirect(w, r, "/search", http.StatusFound)
 }
 
@@ -650,6 +742,11 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Expires", "0")
 	w.Header().Set("Clear-Site-Data", "\"cache\"")
 
+	// End the session server-side too, so a copied cookie stops working.
+	if sess := s.getSession(r); sess != nil && sess.ID != "" {
+		_ = s.db.DeleteSession(r.Context(), sess.ID)
+	}
+
 	// Clear the main session cookie
 	http.SetCookie(w, &http.Cookie{Name: s.sessionCookieName(), Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: s.sessionCookieSecure()})
 
@@ -697,6 +794,11 @@ func (s *Server) handleLocalLogin(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login?error=Invalid+credentials&username="+url.QueryEscape(username)+"&force_welcome=true", http.StatusFound)
 		return
 	}
+	if u.Disabled {
+		s.auditLog(r.Context(), username, "user.login_failed", nil, "account disabled")
+		http.Redirect(w, r, "/login?error=Account+disabled&username="+url.QueryEscape(username)+"&force_welcome=true", http.StatusFound)
+		return
+	}
 
 	// Debug logging for local authentication
 	cfg := s.settings.Get()
@@ -704,12 +806,18 @@ func (s *Server) handleLocalLogin(w http.ResponseWriter, r *http.Request) {
 		fmt.Printf("DEBUG: Local user authenticated - username: %s, admin: %t\n", u.Username, u.IsAdmin)
 	}
 
-	sess := &session{Username: u.Username, Name: u.Username, Admin: u.IsAdmin, Exp: time.Now().Add(24 * time.Hour).Unix()}
-	s.setSession(w, sess)
+	if err := s.startSession(w, r, u.Username, u.Username, u.IsAdmin, r.FormValue("remember") == "on"); err != nil {
+		http.Error(w, "failed to start session", http.StatusInternalServerError)
+		return
+	}
 	s.auditLog(r.Context(), u.Username, "user.login", nil, "")
 	http.Redirect(w, r, "/search", http.StatusFound)
 }
 
+// oauthPlaceholderHash is stored for accounts that only sign in through
+// OAuth. It is not a valid bcrypt hash, so no password matches it.
+const oauthPlaceholderHash = "$2a$10$scriptorum.oauth.autocreate.dummyhash012345678901234567890"
+
 // minPasswordLength is the minimum length enforced for local account passwords.
 const minPasswordLength = 8
 
@@ -43,7 +43,7 @@ func TestLocalLoginFormWhenOAuthDisabled(t *testing.T) {
 
 func TestSetAndReadSessionCookie(t *testing.T) {
 	s := newServerForAuthTest(t)
-	sess := &session{Username: "u", Name: "U", Admin: true, Exp: 9999999999}
+	sess := &session{ID: recordTestSession(t, s, "u"), Username: "u", Name: "U", Admin: true, Exp: 9999999999}
 	rec := httptest.NewRecorder()
 	s.setSession(rec, sess)
 	ck := rec.Result().Cookies()
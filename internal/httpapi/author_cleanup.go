@@ -0,0 +1,218 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	authorCleanupOffer = "offer"
+	authorCleanupAuto  = "auto"
+	authorCleanupOff   = "off"
+)
+
+var (
+	errNoOrphanAuthor = errors.New("this request did not create a Readarr author, or it was already removed")
+	errAuthorInUse    = errors.New("the author is still in use")
+)
+
+// authorCleanupMode returns requests.author_cleanup, defaulting to "offer".
+func (s *Server) authorCleanupMode() string {
+	switch v := strings.ToLower(strings.TrimSpace(s.settings.Get().Requests.AuthorCleanup)); v {
+	case authorCleanupAuto, authorCleanupOff:
+		return v
+	}
+	return authorCleanupOffer
+}
+
+// addAuthorProbe is the author an add is about to go to and whether Readarr
+// already had it.
+type addAuthorProbe struct {
+	Name            string
+	ForeignAuthorID string
+	Existed         bool
+}
+
+// probeAddAuthor reads cand after its author id was resolved: an author id
+// means Readarr already had the author, so the add will not create one.
+func probeAddAuthor(cand map[string]any) addAuthorProbe {
+	var p addAuthorProbe
+	if a, ok := cand["author"].(map[string]any); ok {
+		p.Name, _ = a["name"].(string)
+		p.ForeignAuthorID, _ = a["foreignAuthorId"].(string)
+		p.Existed = readarrIntValue(a["id"]) > 0
+	}
+	if readarrIntValue(cand["authorId"]) > 0 {
+		p.Existed = true
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	p.ForeignAuthorID = strings.TrimSpace(p.ForeignAuthorID)
+	return p
+}
+
+// authorIDFromAddResponse reads the author id out of Readarr's add response.
+func authorIDFromAddResponse(body []byte) int {
+	var rb map[string]any
+	if json.Unmarshal(body, &rb) != nil {
+		return 0
+	}
+	if id := readarrIntValue(rb["authorId"]); id > 0 {
+		return id
+	}
+	if am, ok := rb["author"].(map[string]any); ok {
+		return readarrIntValue(am["id"])
+	}
+	return 0
+}
+
+// recordAddAuthor links req to the Readarr author its add went to. A failed
+// add can still leave a new author behind, so it is looked up by foreign id
+// when the response does not name one.
+func (s *Server) recordAddAuthor(ctx context.Context, req *db.Request, ra *providers.Readarr, inst providers.ReadarrInstance, probe addAuthorProbe, respBody []byte) {
+	id := authorIDFromAddResponse(respBody)
+	if id <= 0 && probe.ForeignAuthorID != "" {
+		id, _ = ra.FindAuthorID(ctx, probe.Name, probe.ForeignAuthorID)
+	}
+	if id <= 0 {
+		return
+	}
+	link := &db.RequestAuthor{
+		RequestID:       req.ID,
+		BaseURL:         inst.BaseURL,
+		ReadarrAuthorID: id,
+		ForeignAuthorID: probe.ForeignAuthorID,
+		Name:            probe.Name,
+		Created:         !probe.Existed,
+	}
+	if err := s.db.RecordRequestAuthor(ctx, link); err != nil {
+		fmt.Printf("author cleanup: record author for request %d: %v\n", req.ID, err)
+	}
+}
+
+// releaseRequestAuthor runs after a request is declined or its add fails for
+// good. With author_cleanup "auto" the author it created is removed in the
+// background; otherwise the request page offers it.
+func (s *Server) releaseRequestAuthor(id int64, actor string) {
+	if s.authorCleanupMode() != authorCleanupAuto {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := s.removeOrphanAuthor(ctx, id, actor); err != nil && !errors.Is(err, errNoOrphanAuthor) {
+			fmt.Printf("author cleanup: request %d: %v\n", id, err)
+		}
+	}()
+}
+
+// orphanAuthor returns the author request id created when it can be
+// offered for removal: the request was declined or failed and the author
+// has not been removed yet.
+func (s *Server) orphanAuthor(ctx context.Context, req *db.Request) (*db.RequestAuthor, bool) {
+	if req.Status != "declined" && req.Status != "error" {
+		return nil, false
+	}
+	link, err := s.db.GetRequestAuthor(ctx, req.ID)
+	if err != nil || !link.Created || link.Removed() {
+		return nil, false
+	}
+	return link, true
+}
+
+// removeOrphanAuthor deletes the Readarr author request id created, after
+// checking that nothing else needs it: no other live request went to it and
+// none of its books has files or is monitored for something else.
+func (s *Server) removeOrphanAuthor(ctx context.Context, id int64, actor string) (*db.RequestAuthor, error) {
+	req, err := s.db.GetRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	link, ok := s.orphanAuthor(ctx, req)
+	if !ok {
+		return nil, errNoOrphanAuthor
+	}
+	inst := s.readarrInstanceForRequest(req)
+	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
+		return nil, errRetryNoReadarr
+	}
+	if strings.TrimRight(strings.TrimSpace(inst.BaseURL), "/") != link.BaseURL {
+		return nil, fmt.Errorf("%w: it was created on a different Readarr instance", errAuthorInUse)
+	}
+	if n, err := s.db.CountOtherAuthorRequests(ctx, link.BaseURL, link.ReadarrAuthorID, req.ID); err != nil {
+		return nil, err
+	} else if n > 0 {
+		return nil, fmt.Errorf("%w: %d other requests went to it", errAuthorInUse, n)
+	}
+
+	ra := providers.NewReadarrWithDB(inst, s.db.SQL())
+	books, err := ra.ListAuthorBooks(ctx, link.ReadarrAuthorID)
+	if err != nil {
+		s.noteReadarrError(inst, err)
+		return nil, err
+	}
+	own := storedRequestSelection(req).ForeignBookID
+	for _, b := range books {
+		if b.Statistics.BookFileCount > 0 {
+			return nil, fmt.Errorf("%w: %q has files", errAuthorInUse, b.Title)
+		}
+		if b.Monitored && (own == "" || b.ForeignBookId != own) {
+			return nil, fmt.Errorf("%w: %q is monitored", errAuthorInUse, b.Title)
+		}
+	}
+	if err := ra.DeleteAuthor(ctx, link.ReadarrAuthorID); err != nil {
+		s.noteReadarrError(inst, err)
+		return nil, err
+	}
+	if err := s.db.MarkRequestAuthorRemoved(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	s.auditLog(ctx, actor, "request.author_removed", &req.ID,
+		fmt.Sprintf("%s (Readarr #%d), request %s", link.Name, link.ReadarrAuthorID, req.Status))
+	link.RemovedAt = time.Now().UTC()
+	return link, nil
+}
+
+// apiRemoveRequestAuthor removes the author a declined or failed request
+// created in Readarr.
+func (s *Server) apiRemoveRequestAuthor(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	username := r.Context().Value(ctxUser).(*session).Username
+	link, err := s.removeOrphanAuthor(r.Context(), id, username)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errNoOrphanAuthor), errors.Is(err, errAuthorInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errRetryNoReadarr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "remove author failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{"status": "removed", "author": link}, http.StatusOK)
+}
@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestCreatedAuthorIsRemovedAfterDecline(t *testing.T) {
+	var deletes atomic.Int32
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/author/lookup":
+			_, _ = w.Write([]byte(`[{"name":"Alice","foreignAuthorId":"fa-alice"}]`))
+		case r.URL.Path == "/api/v1/book" && r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":101,"authorId":5,"monitored":true}`))
+		case r.URL.Path == "/api/v1/book" && r.URL.Query().Get("authorId") == "5":
+			_, _ = w.Write([]byte(`[{"title":"Book","foreignBookId":"fb-1","monitored":true,"statistics":{"bookFileCount":0}}]`))
+		case r.URL.Path == "/api/v1/book" && r.URL.Query().Get("authorId") == "6":
+			_, _ = w.Write([]byte(`[{"title":"Other Book","foreignBookId":"fb-other","monitored":true}]`))
+		case r.URL.Path == "/api/v1/author/5" && r.Method == http.MethodDelete:
+			deletes.Add(1)
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "k"
+	cfg.Requests.AuthorCleanup = "auto"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	req := &db.Request{RequesterEmail: "user", Title: "Book", Authors: []string{"Alice"}, Format: "ebook", Status: "pending",
+		ReadarrReq: []byte(`{"title":"Book","foreignBookId":"fb-1","foreignEditionId":"fe-1","author":{"name":"Alice","foreignAuthorId":"fa-alice"}}`)}
+	id, err := s.db.CreateRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.ID = id
+	s.processAsyncApproval(id, req, s.readarrInstanceForRequest(req), "admin")
+	link, err := s.db.GetRequestAuthor(ctx, id)
+	if err != nil || !link.Created || link.ReadarrAuthorID != 5 {
+		t.Fatalf("author link = %+v, %v", link, err)
+	}
+
+	h := s.Router()
+	post := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.AddCookie(makeCookie(t, s, "admin", true))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+	base := "/api/v1/requests/" + strconv.FormatInt(id, 10)
+	if rec := post(base + "/remove-author"); rec.Code != http.StatusConflict {
+		t.Fatalf("remove while queued: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(base + "/decline"); rec.Code != http.StatusOK {
+		t.Fatalf("decline: %d %s", rec.Code, rec.Body.String())
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for deletes.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if link, _ := s.db.GetRequestAuthor(ctx, id); deletes.Load() != 1 || !link.Removed() {
+		t.Fatalf("auto removal: deletes=%d link=%+v", deletes.Load(), link)
+	}
+
+	// An author with a monitored book of its own is kept.
+	other, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Book Two", Format: "ebook", Status: "declined"})
+	_ = s.db.RecordRequestAuthor(ctx, &db.RequestAuthor{RequestID: other, BaseURL: readarr.URL, ReadarrAuthorID: 6, Name: "Bea", Created: true})
+	if rec := post("/api/v1/requests/" + strconv.FormatInt(other, 10) + "/remove-author"); rec.Code != http.StatusConflict {
+		t.Fatalf("author in use: %d %s", rec.Code, rec.Body.String())
+	}
+}
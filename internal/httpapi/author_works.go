@@ -0,0 +1,225 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"gitea.knapp/jacoknapp/scriptorum/internal/util"
+)
+
+// maxAuthorWorks caps the works one author page lists.
+const maxAuthorWorks = 100
+
+// authorWork is one book on an author page, with the newest open request
+// for each format.
+type authorWork struct {
+	searchItem
+	EbookRequest     *db.Request
+	AudiobookRequest *db.Request
+}
+
+// Missing reports whether the work is neither in the library nor requested
+// in format.
+func (w authorWork) Missing(format string) bool {
+	if format == "audiobook" {
+		return w.AudiobookState != "available" && w.AudiobookRequest == nil
+	}
+	return w.EbookState != "available" && w.EbookRequest == nil
+}
+
+// authorWorkStatus is one format's library state and open request.
+type authorWorkStatus struct {
+	State   string
+	Request *db.Request
+}
+
+// EbookStatus is the work's eBook status, for the page template.
+func (w authorWork) EbookStatus() authorWorkStatus {
+	return authorWorkStatus{w.EbookState, w.EbookRequest}
+}
+
+// AudiobookStatus is EbookStatus for audiobooks.
+func (w authorWork) AudiobookStatus() authorWorkStatus {
+	return authorWorkStatus{w.AudiobookState, w.AudiobookRequest}
+}
+
+// readarrLookupAuthor is the author name of a Readarr lookup result.
+func readarrLookupAuthor(b providers.LookupBook) string {
+	switch {
+	case b.Author != nil:
+		n, _ := b.Author["name"].(string)
+		return n
+	case len(b.Authors) > 0:
+		n, _ := b.Authors[0]["name"].(string)
+		return n
+	case b.AuthorTitle != "":
+		return parseAuthorNameFromTitle(b.AuthorTitle)
+	}
+	return ""
+}
+
+// authorWorks gathers name's books from both Readarr instances and Open
+// Library, merged by title, oldest first, and marks which are in the
+// library or already requested.
+func (s *Server) authorWorks(ctx context.Context, prefs searchPrefs, name string) []authorWork {
+	var items []searchItem
+	dd := newSearchDeduper(dedupeTitleAuthor)
+	add := func(si searchItem, source string) {
+		if !isRenderableSearchBook(si.Title) {
+			return
+		}
+		si.Authors = []string{name}
+		if i, ok := dd.find(si); ok {
+			fillSearchItemFromOpenLibrary(&items[i], si.BookItem)
+			if items[i].ForeignBookID == "" {
+				items[i].ForeignBookID = si.ForeignBookID
+			}
+			items[i].addSource(source)
+			return
+		}
+		si.addSource(source)
+		if dd.add(si, len(items)) {
+			items = append(items, si)
+		}
+	}
+
+	cfg := s.settings.Get()
+	for _, inst := range []struct {
+		source, kind string
+		rc           config.ReadarrInstance
+	}{
+		{providerReadarrEbooks, "ebook", cfg.Readarr.Ebooks},
+		{providerReadarrAudiobooks, "audiobook", cfg.Readarr.Audiobooks},
+	} {
+		if strings.TrimSpace(inst.rc.BaseURL) == "" || strings.TrimSpace(inst.rc.APIKey) == "" || !s.searchEnabled(prefs, inst.source) {
+			continue
+		}
+		ra := providers.NewReadarrWithDB(providers.ReadarrInstance{BaseURL: inst.rc.BaseURL, APIKey: inst.rc.APIKey, InsecureSkipVerify: inst.rc.InsecureSkipVerify}, s.db.SQL())
+		started := time.Now()
+		list, err := ra.LookupByTerm(ctx, name)
+		s.recordProviderCall(inst.source, started, err)
+		if err != nil {
+			continue
+		}
+		for _, b := range list {
+			if !strings.EqualFold(strings.TrimSpace(readarrLookupAuthor(b)), name) || !isRenderableSearchBook(b.Title, b.Disambiguation) {
+				continue
+			}
+			isbn10, isbn13, asin := extractIdentifiers(b)
+			cover := util.FirstNonEmpty(b.RemoteCover, b.RemotePoster, b.CoverUrl)
+			if strings.HasPrefix(cover, "/") {
+				cover = strings.TrimRight(inst.rc.BaseURL, "/") + cover
+			}
+			cover = appendCoverIsbnFallback(s.normalizeRequestCover(inst.kind, cover), isbn13, isbn10)
+			add(searchItem{BookItem: providers.BookItem{Title: b.Title, ISBN10: isbn10, ISBN13: isbn13, ASIN: asin, CoverSmall: cover, CoverMedium: cover, Series: b.SeriesTitle, FirstPublishYear: readarrReleaseYear(b)}, ForeignBookID: b.ForeignBookId}, inst.source)
+		}
+	}
+
+	if s.searchEnabled(prefs, providerOpenLibrary) {
+		olCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
+		started := time.Now()
+		books, err := providers.NewOpenLibrary().Search(olCtx, `author:"`+strings.ReplaceAll(name, `"`, "")+`"`, 50, 1)
+		cancel()
+		s.recordProviderCall(providerOpenLibrary, started, err)
+		for _, b := range books {
+			for _, a := range b.Authors {
+				if strings.EqualFold(strings.TrimSpace(a), name) {
+					add(searchItem{BookItem: b}, providerOpenLibrary)
+					break
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].FirstPublishYear, items[j].FirstPublishYear
+		if a != b {
+			// Unknown years sort last.
+			return b == 0 || a != 0 && a < b
+		}
+		return strings.ToLower(items[i].Title) < strings.ToLower(items[j].Title)
+	})
+	if len(items) > maxAuthorWorks {
+		items = items[:maxAuthorWorks]
+	}
+	decorateSearchItems(s, items)
+
+	reqs, _ := s.db.ListRequestsByAuthor(ctx, name)
+	works := make([]authorWork, len(items))
+	for i, si := range items {
+		works[i] = authorWork{searchItem: si}
+		key := titleAuthorKey(si.BookItem)
+		for j := range reqs {
+			r := &reqs[j]
+			same := si.ISBN13 != "" && r.ISBN13 == si.ISBN13 || titleAuthorKey(providers.BookItem{Title: r.Title, Authors: []string{name}}) == key
+			if !same {
+				continue
+			}
+			// reqs is newest first, so the first match per format wins.
+			if r.Format == "audiobook" && works[i].AudiobookRequest == nil {
+				works[i].AudiobookRequest = r
+			} else if r.Format != "audiobook" && works[i].EbookRequest == nil {
+				works[i].EbookRequest = r
+			}
+		}
+	}
+	return works
+}
+
+// missingAuthorRows turns the works missing in format into bulk request
+// rows, at most maxBulkItems of them, and reports whether any were left
+// out.
+func missingAuthorRows(works []authorWork, format string) ([]bulkRow, bool) {
+	var rows []bulkRow
+	for _, w := range works {
+		if !w.Missing(format) {
+			continue
+		}
+		if len(rows) == maxBulkItems {
+			return rows, true
+		}
+		row := bulkRow{Line: len(rows) + 1, Input: w.Title, Matched: true, Status: "matched", Title: w.Title, Authors: w.Authors, ISBN10: w.ISBN10, ISBN13: w.ISBN13, ASIN: w.ASIN, Cover: w.CoverMedium}
+		if w.FirstPublishYear > 0 {
+			row.Input = w.Title + " (" + strconv.Itoa(w.FirstPublishYear) + ")"
+		}
+		rows = append(rows, row)
+	}
+	return rows, false
+}
+
+// handleAuthor renders an author's works across providers with their
+// library and request status, and a review table for requesting the ones
+// missing in the chosen format.
+func (u *ui) handleAuthor(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Join(strings.Fields(r.URL.Query().Get("name")), " ")
+		if name == "" {
+			http.Redirect(w, r, "/search", http.StatusFound)
+			return
+		}
+		format := bulkFormat(r.URL.Query().Get("format"))
+		ses := r.Context().Value(ctxUser).(*session)
+		works := s.authorWorks(r.Context(), s.requestSearchPrefs(r), name)
+		rows, more := missingAuthorRows(works, format)
+		bulk := map[string]any{"Rows": rows, "Format": format, "Review": true, "RequestFields": s.requestFields(), "Extra": map[string]string{}}
+		bulk["Quota"], bulk["Cost"] = s.bulkQuotaCost(r, rows)
+		data := map[string]any{
+			"UserName":    s.userName(r),
+			"IsAdmin":     ses.Admin,
+			"CSRFToken":   s.getCSRFToken(r),
+			"Author":      name,
+			"Works":       works,
+			"Format":      format,
+			"Bulk":        bulk,
+			"MoreMissing": more,
+		}
+		_ = u.tpl.ExecuteTemplate(w, "author.html", data)
+	}
+}
@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+func TestAuthorPageMergesProvidersAndOffersMissing(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[
+			{"title":"Dune","foreignBookId":"fb-1","author":{"name":"Frank Herbert"},"releaseDate":"1965-08-01T00:00:00Z"},
+			{"title":"Dune Messiah","foreignBookId":"fb-2","author":{"name":"Frank Herbert"},"releaseDate":"1969-10-15T00:00:00Z"},
+			{"title":"House Atreides","foreignBookId":"fb-3","author":{"name":"Brian Herbert"},"releaseDate":"1999-10-05T00:00:00Z"}
+		]`)
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"docs":[]}`
+		if r.URL.Query().Get("q") == `author:"Frank Herbert"` {
+			body = `{"docs":[
+				{"title":"Dune","author_name":["Frank Herbert"],"isbn":["9780441013593"],"first_publish_year":1965,"key":"/works/OL1W"},
+				{"title":"The Dosadi Experiment","author_name":["Frank Herbert"],"first_publish_year":1977,"key":"/works/OL2W"}
+			]}`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	}))
+	if _, err := s.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "alice", Title: "Dune Messiah", Authors: []string{"Frank Herbert"}, Format: "ebook", Status: "pending"}); err != nil {
+		t.Fatal(err)
+	}
+
+	works := s.authorWorks(context.Background(), parseSearchProviders("", nil), "Frank Herbert")
+	var titles []string
+	for _, w := range works {
+		titles = append(titles, w.Title)
+	}
+	if strings.Join(titles, "|") != "Dune|Dune Messiah|The Dosadi Experiment" {
+		t.Fatalf("works = %v", titles)
+	}
+	if works[0].ISBN13 != "9780441013593" || len(works[0].Sources) != 2 {
+		t.Fatalf("Dune not merged across providers: %+v", works[0])
+	}
+	if works[1].EbookRequest == nil || works[1].Missing("ebook") || !works[1].Missing("audiobook") {
+		t.Fatalf("Dune Messiah request not matched: %+v", works[1])
+	}
+	rows, more := missingAuthorRows(works, "ebook")
+	if len(rows) != 2 || more || rows[0].Title != "Dune" || rows[1].Title != "The Dosadi Experiment" {
+		t.Fatalf("missing rows = %+v", rows)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/authors?name=Frank+Herbert", nil)
+	req.AddCookie(makeCookie(t, s, "alice", false))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Requested · pending") || !strings.Contains(body, "Request selected eBooks") {
+		t.Fatalf("author page: %d %s", rec.Code, body)
+	}
+}
@@ -135,7 +135,7 @@ func TestGlobalApprovedAvailableTogglesAreIndependent(t *testing.T) {
 		case <-time.After(2 * time.Second):
 			t.Fatal("expected approved webhook")
 		}
-		s.SendAvailableNotification("alice", "Book", nil)
+		s.SendAvailableNotification("alice", "Book", nil, itemLink{})
 		select {
 		case p := <-ch:
 			t.Fatalf("did not expect available webhook, got %v", p["event"])
@@ -156,7 +156,7 @@ func TestGlobalApprovedAvailableTogglesAreIndependent(t *testing.T) {
 		if err := s.settings.Update(cfg); err != nil {
 			t.Fatalf("update: %v", err)
 		}
-		s.SendAvailableNotification("alice", "Book", nil)
+		s.SendAvailableNotification("alice", "Book", nil, itemLink{})
 		select {
 		case p := <-ch:
 			if p["event"] != "request.available" {
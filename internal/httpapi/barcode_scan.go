@@ -0,0 +1,120 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/barcode"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// maxScanUploadBytes bounds barcode photo uploads; phone captures are
+// usually a few megabytes.
+const maxScanUploadBytes = 12 << 20
+
+// maxScanPixels rejects images whose header claims more pixels than a
+// camera produces, before any decoding work is done.
+const maxScanPixels = 50_000_000
+
+// apiBookScan decodes an EAN-13/ISBN barcode from an uploaded photo (multipart
+// field "image") and returns the ISBN with the matching book, when one is
+// found, so the UI can offer it for requesting straight away.
+func (s *Server) apiBookScan(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxScanUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(maxScanUploadBytes); err != nil {
+		http.Error(w, "image too large or invalid upload", http.StatusRequestEntityTooLarge)
+		return
+	}
+	f, _, err := r.FormFile("image")
+	if err != nil {
+		http.Error(w, "image required", http.StatusBadRequest)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxScanUploadBytes+1))
+	if err != nil {
+		http.Error(w, "failed to read image", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxScanUploadBytes {
+		http.Error(w, "image too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		http.Error(w, "unsupported image format (use JPEG, PNG, or GIF)", http.StatusUnsupportedMediaType)
+		return
+	}
+	if cfg.Width*cfg.Height > maxScanPixels {
+		http.Error(w, "image dimensions too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		http.Error(w, "failed to decode image", http.StatusUnsupportedMediaType)
+		return
+	}
+	code, err := barcode.DecodeEAN13(img)
+	if errors.Is(err, barcode.ErrNotFound) {
+		http.Error(w, "no barcode found; fill the frame with the barcode and try again", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to read barcode", http.StatusInternalServerError)
+		return
+	}
+	out := map[string]any{
+		"ean":     code,
+		"is_isbn": barcode.IsISBN(code),
+		"book":    nil,
+	}
+	if !barcode.IsISBN(code) {
+		writeJSON(w, out, http.StatusOK)
+		return
+	}
+	out["isbn13"] = code
+	if !s.providerEnabled(providerOpenLibrary) {
+		writeJSON(w, out, http.StatusOK)
+		return
+	}
+	if book := lookupScannedISBN(r.Context(), code); book != nil {
+		// The scanned edition is what the user holds, so it wins over
+		// whichever edition the catalog returned first.
+		if book.ISBN13 != code {
+			book.ISBN13, book.ISBN10 = code, ""
+		}
+		cover := book.CoverMedium
+		if cover == "" {
+			cover = book.CoverSmall
+		}
+		out["book"] = map[string]any{
+			"title":              book.Title,
+			"authors":            book.Authors,
+			"isbn10":             book.ISBN10,
+			"isbn13":             book.ISBN13,
+			"asin":               book.ASIN,
+			"cover":              cover,
+			"first_publish_year": book.FirstPublishYear,
+		}
+	}
+	writeJSON(w, out, http.StatusOK)
+}
+
+// lookupScannedISBN finds the book for a scanned ISBN-13 on OpenLibrary.
+func lookupScannedISBN(ctx context.Context, isbn13 string) *providers.BookItem {
+	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+	books, err := providers.NewOpenLibrary().Search(ctx, isbn13, 1, 1)
+	if err != nil || len(books) == 0 {
+		return nil
+	}
+	return &books[0]
+}
@@ -0,0 +1,136 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// ean13PNG renders code as a PNG barcode photo stand-in.
+func ean13PNG(t *testing.T, code string) []byte {
+	t.Helper()
+	l := []string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	parity := []string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+	invert := func(s string) string {
+		return strings.Map(func(r rune) rune { return '0' + '1' - r }, s)
+	}
+	reverse := func(s string) string {
+		b := []byte(s)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return string(b)
+	}
+	bits := "101"
+	for i := 1; i <= 6; i++ {
+		p := l[code[i]-'0']
+		if parity[code[0]-'0'][i-1] == 'G' {
+			p = reverse(invert(p))
+		}
+		bits += p
+	}
+	bits += "01010"
+	for i := 7; i <= 12; i++ {
+		bits += invert(l[code[i]-'0'])
+	}
+	bits += "101"
+
+	const mod, quiet, h = 3, 36, 60
+	img := image.NewGray(image.Rect(0, 0, 2*quiet+len(bits)*mod, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			c := color.Gray{Y: 255}
+			if i := (x - quiet) / mod; x >= quiet && i < len(bits) && bits[i] == '1' {
+				c = color.Gray{Y: 0}
+			}
+			img.SetGray(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func scanUpload(t *testing.T, data []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("image", "scan.png")
+	if err != nil {
+		t.Fatalf("form file: %v", err)
+	}
+	fw.Write(data)
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestAPIBookScan(t *testing.T) {
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"docs":[]}`
+		if r.URL.Path == "/search.json" && r.URL.Query().Get("q") == "9780441013593" {
+			body = `{"docs":[{"title":"Dune","author_name":["Frank Herbert"],"isbn":["0441013597","9780441013593"],"cover_i":7,"key":"/works/OL1W"}]}`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	s := newServerForTest(t)
+	h := s.Router()
+
+	scan := func(data []byte) *httptest.ResponseRecorder {
+		body, ct := scanUpload(t, data)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/book/scan", body)
+		req.Header.Set("Content-Type", ct)
+		req.AddCookie(makeCookie(t, s, "user", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := scan(ean13PNG(t, "9780441013593"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scan: %d %s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		EAN    string `json:"ean"`
+		IsISBN bool   `json:"is_isbn"`
+		ISBN13 string `json:"isbn13"`
+		Book   *struct {
+			Title   string   `json:"title"`
+			Authors []string `json:"authors"`
+			ISBN13  string   `json:"isbn13"`
+		} `json:"book"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.ISBN13 != "9780441013593" || !out.IsISBN || out.Book == nil || out.Book.Title != "Dune" || out.Book.ISBN13 != "9780441013593" {
+		t.Fatalf("unexpected scan result: %s", rec.Body.String())
+	}
+
+	// A non-book EAN is reported without a lookup.
+	rec = scan(ean13PNG(t, "4006381333931"))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"is_isbn":false`) || !strings.Contains(rec.Body.String(), `"book":null`) {
+		t.Fatalf("non-ISBN scan: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var blank bytes.Buffer
+	_ = png.Encode(&blank, image.NewGray(image.Rect(0, 0, 120, 60)))
+	if rec = scan(blank.Bytes()); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("blank image: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = scan([]byte("not an image")); rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("non-image: %d %s", rec.Code, rec.Body.String())
+	}
+}
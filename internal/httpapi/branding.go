@@ -0,0 +1,143 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+// maxLogoBytes bounds an uploaded logo.
+const maxLogoBytes = 1 << 20
+
+// brandingLogoPath is where an uploaded logo is served. The login page shows
+// it, so it is public.
+const brandingLogoPath = "/branding/logo"
+
+// logoExtensions maps the image types accepted as a logo to the extension
+// the file is stored under. SVG is left out: served from our own origin it
+// could run script.
+var logoExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+func (s *Server) branding() config.BrandingConfig {
+	if cfg := s.settings.Get(); cfg != nil {
+		return cfg.Branding
+	}
+	return config.BrandingConfig{}
+}
+
+// instanceName is what the service calls itself in pages and notifications.
+func (s *Server) instanceName() string { return s.branding().Name() }
+
+// brandingDir holds the uploaded logo, next to the database like
+// attachments.
+func (s *Server) brandingDir() string {
+	if p := strings.TrimSpace(s.settings.Get().DB.Path); p != "" {
+		return filepath.Join(filepath.Dir(p), "branding")
+	}
+	return "branding"
+}
+
+// uploadedLogo returns the path of the uploaded logo, if any.
+func (s *Server) uploadedLogo() string {
+	matches, _ := filepath.Glob(filepath.Join(s.brandingDir(), "logo.*"))
+	for _, m := range matches {
+		if _, ok := logoContentType(m); ok {
+			return m
+		}
+	}
+	return ""
+}
+
+func logoContentType(path string) (string, bool) {
+	ext := filepath.Ext(path)
+	for ct, e := range logoExtensions {
+		if e == ext {
+			return ct, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) handleBrandingLogo(w http.ResponseWriter, r *http.Request) {
+	path := s.uploadedLogo()
+	if path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	ct, _ := logoContentType(path)
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeFile(w, r, path)
+}
+
+// apiUploadLogo stores a logo uploaded from the settings page and points
+// branding.logo_url at it. DELETE removes it again.
+func (s *Server) apiUploadLogo() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Context().Value(ctxUser).(*session).Username
+		cur := *s.settings.Get()
+		if r.Method == http.MethodDelete {
+			s.removeUploadedLogos()
+			if strings.HasPrefix(cur.Branding.LogoURL, brandingLogoPath) {
+				cur.Branding.LogoURL = ""
+				_ = s.settings.Update(&cur)
+			}
+			s.auditLog(r.Context(), actor, "settings.logo_removed", nil, "")
+			writeJSON(w, map[string]string{"logo_url": cur.Branding.Logo()}, http.StatusOK)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxLogoBytes+4096)
+		file, _, err := r.FormFile("logo")
+		if err != nil {
+			http.Error(w, "choose an image of at most 1 MB", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, maxLogoBytes+1))
+		if err != nil || len(data) == 0 || len(data) > maxLogoBytes {
+			http.Error(w, "choose an image of at most 1 MB", http.StatusBadRequest)
+			return
+		}
+		ext, ok := logoExtensions[http.DetectContentType(data)]
+		if !ok {
+			http.Error(w, "logo must be a PNG, JPEG, GIF, or WebP image", http.StatusBadRequest)
+			return
+		}
+		if err := os.MkdirAll(s.brandingDir(), 0o755); err != nil {
+			http.Error(w, "saving logo failed", http.StatusInternalServerError)
+			return
+		}
+		s.removeUploadedLogos()
+		if err := os.WriteFile(filepath.Join(s.brandingDir(), "logo"+ext), data, 0o644); err != nil {
+			http.Error(w, "saving logo failed", http.StatusInternalServerError)
+			return
+		}
+		// The version query busts browser caches when the logo is replaced.
+		cur.Branding.LogoURL = brandingLogoPath + "?v=" + strconv.FormatInt(time.Now().Unix(), 10)
+		if err := s.settings.Update(&cur); err != nil {
+			http.Error(w, "saving settings failed", http.StatusInternalServerError)
+			return
+		}
+		s.auditLog(r.Context(), actor, "settings.logo_uploaded", nil, "")
+		writeJSON(w, map[string]string{"logo_url": cur.Branding.LogoURL}, http.StatusOK)
+	}
+}
+
+func (s *Server) removeUploadedLogos() {
+	matches, _ := filepath.Glob(filepath.Join(s.brandingDir(), "logo.*"))
+	for _, m := range matches {
+		_ = os.Remove(m)
+	}
+}
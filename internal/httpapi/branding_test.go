@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBrandingAppliesToLoginAndLogo(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	cfg := s.settings.Get()
+	cfg.Branding.InstanceName = "Knapp Library"
+	cfg.Branding.AccentColor = "#AA3366"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "<title>Welcome to Knapp Library</title>") || !strings.Contains(body, "background:#aa3366 !important") {
+		t.Fatalf("login page not branded:\n%s", body)
+	}
+	if strings.Contains(body, "Scriptorum") {
+		t.Fatal("login page still says Scriptorum")
+	}
+
+	upload := func(data []byte) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		fw, _ := mw.CreateFormFile("logo", "logo.img")
+		_, _ = fw.Write(data)
+		_ = mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/settings/logo", &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.AddCookie(makeCookie(t, s, "admin", true))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := upload([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)); rec.Code != http.StatusBadRequest {
+		t.Fatalf("svg upload: %d", rec.Code)
+	}
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	if rec := upload(png); rec.Code != http.StatusOK {
+		t.Fatalf("png upload: %d %s", rec.Code, rec.Body.String())
+	}
+	if logo := s.branding().Logo(); !strings.HasPrefix(logo, brandingLogoPath+"?v=") {
+		t.Fatalf("logo url = %q", logo)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, brandingLogoPath, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" || !bytes.Equal(rec.Body.Bytes(), png) {
+		t.Fatalf("logo: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,385 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// maxBulkItems caps one bulk paste. Lookups run one at a time to stay
+// within OpenLibrary's rate limit, so this also bounds how long a resolve
+// takes.
+const maxBulkItems = 25
+
+// bulkRow is one line of a bulk paste: what was typed, the book it resolved
+// to, and, after creation, what happened to its request.
+type bulkRow struct {
+	Line    int      `json:"line"`
+	Input   string   `json:"input"`
+	Matched bool     `json:"matched"`
+	Title   string   `json:"title,omitempty"`
+	Authors []string `json:"authors,omitempty"`
+	ISBN10  string   `json:"isbn10,omitempty"`
+	ISBN13  string   `json:"isbn13,omitempty"`
+	ASIN    string   `json:"asin,omitempty"`
+	Cover   string   `json:"cover,omitempty"`
+	// Status is "matched", "not_found", "invalid", or "blocked" after
+	// resolving, and "created", "exists", "limit", "blocked", "error", or
+	// "skipped" after creation.
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+	RequestID int64  `json:"request_id,omitempty"`
+}
+
+// parseBulkLines splits pasted text into identifiers, skipping blank lines,
+// "#" comments, and repeats. Commas and semicolons separate entries too,
+// since spreadsheets often export them that way.
+func parseBulkLines(text string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' || r == ',' || r == ';' }) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || seen[strings.ToUpper(line)] {
+			continue
+		}
+		seen[strings.ToUpper(line)] = true
+		out = append(out, line)
+	}
+	if len(out) > maxBulkItems {
+		return nil, fmt.Errorf("too many entries: %d (maximum %d per batch)", len(out), maxBulkItems)
+	}
+	return out, nil
+}
+
+// resolveBulkRows looks every line up in turn. Matches the denylist blocks
+// are marked "blocked" so they cannot be picked.
+func (s *Server) resolveBulkRows(ctx context.Context, lines []string) []bulkRow {
+	rows := make([]bulkRow, 0, len(lines))
+	for i, line := range lines {
+		row := s.resolveBulkRow(ctx, i+1, line)
+		if row.Matched {
+			if e, _ := s.denylistMatch(ctx, row.Title, row.Authors, row.ISBN10, row.ISBN13, row.ASIN); e != nil && e.Blocks() {
+				row.Matched = false
+				row.Status = "blocked"
+				row.Message = "can't be requested"
+				if e.Note != "" {
+					row.Message += ": " + e.Note
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// resolveBulkRow matches one identifier through the same link pipeline as
+// search: OpenLibrary for ISBNs and Goodreads ids, Google Books volumes
+// directly, and Amazon for ASINs nothing else knows.
+func (s *Server) resolveBulkRow(ctx context.Context, line int, input string) bulkRow {
+	row := bulkRow{Line: line, Input: input}
+	ref, ok := providers.ParseBookIdentifier(input)
+	if !ok {
+		row.Status = "invalid"
+		row.Message = "not an ISBN, ASIN, or book link"
+		return row
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ls := s.resolveBookLink(ctx, ref)
+	var items []searchItem
+	if ls.book == nil && ls.query != "" && (ls.ref.ISBN13 != "" || ls.ref.ISBN10 != "" || ls.ref.GoodreadsID != "") && s.providerEnabled(providerOpenLibrary) {
+		if books, err := providers.NewOpenLibrary().Search(ctx, ls.query, 1, 1); err == nil && len(books) > 0 {
+			items = append(items, searchItem{BookItem: books[0]})
+		}
+	}
+	if len(items) == 0 && ls.book == nil && ls.ref.ASIN != "" && s.providerEnabled(providerAmazon) {
+		if book, err := providers.NewAmazonPublic(ls.amazonMarketplace(s.amazonMarketplace())).GetByASIN(ctx, ls.ref.ASIN); err == nil && book != nil && book.Title != "" {
+			items = append(items, searchItem{BookItem: providers.BookItem{ASIN: book.ASIN, Title: book.Title, Authors: book.Authors, ISBN10: book.ISBN10, ISBN13: book.ISBN13, CoverMedium: book.Image}})
+		}
+	}
+	items = ls.prefill(items)
+
+	row.ISBN10, row.ISBN13, row.ASIN = ls.ref.ISBN10, ls.ref.ISBN13, ls.ref.ASIN
+	if len(items) == 0 {
+		row.Status = "not_found"
+		row.Message = "no matching book found"
+		return row
+	}
+	b := items[0].BookItem
+	row.Matched = true
+	row.Status = "matched"
+	row.Title = b.Title
+	row.Authors = b.Authors
+	row.Cover = b.CoverMedium
+	if row.Cover == "" {
+		row.Cover = b.CoverSmall
+	}
+	row.Cover = s.displayCover(row.Cover, b.ISBN13, b.ISBN10)
+	if row.ISBN13 == "" {
+		row.ISBN10, row.ISBN13 = b.ISBN10, b.ISBN13
+	}
+	if row.ASIN == "" {
+		row.ASIN = b.ASIN
+	}
+	return row
+}
+
+// createBulkRows requests each confirmed row through createRequest, so quota,
+// duplicate, and auto-approval rules apply exactly as for a single request.
+// extra answers the request form fields once for the whole batch. Rows are
+// recorded as bulk requests unless r already names a source.
+func (s *Server) createBulkRows(r *http.Request, format string, rows []bulkRow, extra map[string]string) []bulkRow {
+	// Ask createRequest for its JSON answers rather than HTMX fragments or
+	// form redirects.
+	inner := r.Clone(r.Context())
+	if _, ok := r.Context().Value(ctxSource).(string); !ok {
+		inner = withRequestSource(inner, sourceBulk)
+	}
+	inner.Header.Del("HX-Request")
+	inner.Header.Set("Content-Type", "application/json")
+
+	out := make([]bulkRow, 0, len(rows))
+	for _, row := range rows {
+		if strings.TrimSpace(row.Title) == "" && row.ISBN13 == "" && row.ISBN10 == "" && row.ASIN == "" {
+			row.Status = "error"
+			row.Message = "nothing to request"
+			out = append(out, row)
+			continue
+		}
+		rec := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+		s.createRequest(rec, inner, RequestPayload{
+			Title:   row.Title,
+			Authors: row.Authors,
+			ISBN10:  row.ISBN10,
+			ISBN13:  row.ISBN13,
+			ASIN:    row.ASIN,
+			Format:  format,
+			Extra:   extra,
+		})
+		var resp struct {
+			ID      int64  `json:"id"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(rec.body.Bytes(), &resp)
+		switch {
+		case rec.status == http.StatusCreated:
+			row.Status, row.RequestID, row.Message = "created", resp.ID, ""
+		case rec.status == http.StatusConflict:
+			row.Status, row.Message = "exists", "already available in the library"
+		case rec.status == http.StatusTooManyRequests:
+			row.Status, row.Message = "limit", resp.Message
+		case rec.status == http.StatusForbidden:
+			row.Status, row.Message = "blocked", resp.Message
+		default:
+			row.Status = "error"
+			row.Message = resp.Message
+			if row.Message == "" {
+				row.Message = strings.TrimSpace(rec.body.String())
+			}
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// bufferedResponse collects a handler's response in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(code int)        { b.status = code }
+
+// apiBulkResolve resolves pasted identifiers for review without creating
+// anything. Body: {"items": "newline-separated text"} or {"items": [...]}.
+func (s *Server) apiBulkResolve(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Items json.RawMessage `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	var text string
+	var list []string
+	if err := json.Unmarshal(in.Items, &list); err == nil {
+		text = strings.Join(list, "\n")
+	} else if err := json.Unmarshal(in.Items, &text); err != nil {
+		http.Error(w, "items must be a string or an array of strings", http.StatusBadRequest)
+		return
+	}
+	lines, err := parseBulkLines(text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if len(lines) == 0 {
+		http.Error(w, "no identifiers given", http.StatusBadRequest)
+		return
+	}
+	rows := s.resolveBulkRows(r.Context(), lines)
+	quota, cost := s.bulkQuotaCost(r, rows)
+	writeJSON(w, map[string]any{"rows": rows, "quota": quota, "estimate": cost}, http.StatusOK)
+}
+
+// bulkQuotaCost estimates what requesting every matched row would do to
+// the user's pending-request quota.
+func (s *Server) bulkQuotaCost(r *http.Request, rows []bulkRow) (requestQuota, requestCost) {
+	u := r.Context().Value(ctxUser).(*session)
+	matched := 0
+	for _, row := range rows {
+		if row.Matched {
+			matched++
+		}
+	}
+	quota := s.requestQuota(r.Context(), u.Username, requestGroup(r))
+	return quota, quota.estimate(matched)
+}
+
+// apiBulkCreate creates requests for reviewed rows. Body: {"format":
+// "ebook"|"audiobook", "rows": [<rows from resolve>], "extra": {...}}. Rows
+// the user did not confirm should simply be left out; extra answers the
+// request form fields for every row.
+func (s *Server) apiBulkCreate(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Format string            `json:"format"`
+		Rows   []bulkRow         `json:"rows"`
+		Extra  map[string]string `json:"extra"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(in.Rows) == 0 {
+		http.Error(w, "no rows given", http.StatusBadRequest)
+		return
+	}
+	if len(in.Rows) > maxBulkItems {
+		http.Error(w, fmt.Sprintf("too many rows (maximum %d per batch)", maxBulkItems), http.StatusRequestEntityTooLarge)
+		return
+	}
+	rows := s.createBulkRows(r, in.Format, in.Rows, in.Extra)
+	if bulkCreatedAny(rows) {
+		w.Header().Set("HX-Trigger", `{"request:created": {}}`)
+	}
+	writeJSON(w, map[string]any{"rows": rows}, http.StatusOK)
+}
+
+func bulkCreatedAny(rows []bulkRow) bool {
+	for _, row := range rows {
+		if row.Status == "created" {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *ui) handleBulkPage(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ses := r.Context().Value(ctxUser).(*session)
+		data := map[string]any{
+			"UserName":    s.userName(r),
+			"IsAdmin":     ses.Admin,
+			"CanModerate": s.canModerateRequests(r),
+			"CSRFToken":   s.getCSRFToken(r),
+			"MaxItems":    maxBulkItems,
+		}
+		_ = u.tpl.ExecuteTemplate(w, "requests_bulk.html", data)
+	}
+}
+
+// handleBulkResolve renders the review table for the pasted text.
+func (u *ui) handleBulkResolve(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		data := map[string]any{"Format": bulkFormat(r.FormValue("format"))}
+		lines, err := parseBulkLines(r.FormValue("items"))
+		switch {
+		case err != nil:
+			data["Error"] = err.Error()
+		case len(lines) == 0:
+			data["Error"] = "Paste at least one ISBN or ASIN."
+		default:
+			rows := s.resolveBulkRows(r.Context(), lines)
+			data["Rows"] = rows
+			data["Review"] = true
+			data["Quota"], data["Cost"] = s.bulkQuotaCost(r, rows)
+			data["RequestFields"] = s.requestFields()
+			data["Extra"] = map[string]string{}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = u.tpl.ExecuteTemplate(w, "bulk_review", data)
+	}
+}
+
+// handleBulkCreate requests the rows ticked in the review table and renders
+// the table again with each row's outcome.
+func (u *ui) handleBulkCreate(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		format := bulkFormat(r.FormValue("format"))
+		confirmed := map[int]bool{}
+		for _, v := range r.Form["confirm"] {
+			if n, err := strconv.Atoi(v); err == nil {
+				confirmed[n] = true
+			}
+		}
+		var rows, picked []bulkRow
+		for _, raw := range r.Form["row"] {
+			var row bulkRow
+			if err := json.Unmarshal([]byte(raw), &row); err != nil {
+				continue
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) > maxBulkItems {
+			http.Error(w, "too many rows", http.StatusRequestEntityTooLarge)
+			return
+		}
+		for _, row := range rows {
+			if confirmed[row.Line] && row.Matched {
+				picked = append(picked, row)
+			}
+		}
+		done := map[int]bulkRow{}
+		extra := s.extrasFromForm(r)
+		if _, err := s.collectRequestExtras(extra); err != nil && len(picked) > 0 {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = u.tpl.ExecuteTemplate(w, "bulk_review", map[string]any{"Rows": rows, "Format": format, "Review": true, "RequestFields": s.requestFields(), "Extra": extra, "FieldError": err.Error()})
+			return
+		}
+		for _, row := range s.createBulkRows(r, format, picked, extra) {
+			done[row.Line] = row
+		}
+		for i, row := range rows {
+			if d, ok := done[row.Line]; ok {
+				rows[i] = d
+			} else if row.Matched {
+				rows[i].Status, rows[i].Message = "skipped", "not selected"
+			}
+		}
+		if bulkCreatedAny(rows) {
+			w.Header().Set("HX-Trigger", `{"request:created": {}}`)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = u.tpl.ExecuteTemplate(w, "bulk_review", map[string]any{"Rows": rows, "Format": format})
+	}
+}
+
+func bulkFormat(v string) string {
+	if strings.EqualFold(strings.TrimSpace(v), "audiobook") {
+		return "audiobook"
+	}
+	return "ebook"
+}
@@ -0,0 +1,133 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+func TestParseBulkLines(t *testing.T) {
+	lines, err := parseBulkLines("9780441013593\r\n\n# wishlist\n0441013597, 9780441013593;B08FHBV4ZX\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, "|") != "9780441013593|0441013597|B08FHBV4ZX" {
+		t.Fatalf("lines = %q", lines)
+	}
+	var many strings.Builder
+	for i := 0; i <= maxBulkItems; i++ {
+		many.WriteString(strings.Repeat("a", i+1) + "\n")
+	}
+	if _, err := parseBulkLines(many.String()); err == nil {
+		t.Fatal("expected an error over the batch limit")
+	}
+}
+
+func installBulkOpenLibrary(t *testing.T) {
+	t.Helper()
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"docs":[]}`
+		if r.URL.Path == "/search.json" && r.URL.Query().Get("q") == "9780441013593" {
+			body = `{"docs":[{"title":"Dune","author_name":["Frank Herbert"],"isbn":["9780441013593"],"key":"/works/OL1W"}]}`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+}
+
+func TestBulkRequestsUIFlow(t *testing.T) {
+	installBulkOpenLibrary(t)
+	s := newServerForTest(t)
+	h := s.Router()
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.AddCookie(makeCookie(t, s, "reader", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/ui/requests/bulk/resolve", url.Values{"items": {"978-0-441-01359-3\nnot an isbn\n9781250313195"}, "format": {"ebook"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resolve: %d %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Dune") || !strings.Contains(body, "not an ISBN, ASIN, or book link") || !strings.Contains(body, "no matching book found") {
+		t.Fatalf("unexpected review table: %s", body)
+	}
+	if strings.Count(body, `name="confirm"`) != 1 {
+		t.Fatalf("only the matched row should be selectable: %s", body)
+	}
+
+	// Post the rows back as the review form would, with the match ticked.
+	rows := s.resolveBulkRows(context.Background(), []string{"978-0-441-01359-3", "not an isbn"})
+	form := url.Values{"format": {"ebook"}, "confirm": {"1"}}
+	for _, row := range rows {
+		b, _ := json.Marshal(row)
+		form.Add("row", string(b))
+	}
+	rec = post("/ui/requests/bulk/create", form)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Requested (#") {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Header().Get("HX-Trigger"), "request:created") {
+		t.Fatalf("missing HX-Trigger: %v", rec.Header())
+	}
+	list, err := s.db.ListRequests(context.Background(), "reader", 10)
+	if err != nil || len(list) != 1 || list[0].Title != "Dune" || list[0].ISBN13 != "9780441013593" || list[0].Status != "pending" {
+		t.Fatalf("requests = %+v, %v", list, err)
+	}
+}
+
+func TestBulkRequestsAPI(t *testing.T) {
+	installBulkOpenLibrary(t)
+	s := newServerForTest(t)
+	h := s.Router()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "reader", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/api/v1/requests/bulk/resolve", `{"items":["9780441013593","9781250313195"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resolve: %d %s", rec.Code, rec.Body.String())
+	}
+	var resolved struct {
+		Rows []bulkRow `json:"rows"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resolved)
+	if len(resolved.Rows) != 2 || !resolved.Rows[0].Matched || resolved.Rows[0].Title != "Dune" {
+		t.Fatalf("resolved = %+v", resolved.Rows)
+	}
+
+	payload, _ := json.Marshal(map[string]any{"format": "audiobook", "rows": resolved.Rows[:1]})
+	rec = post("/api/v1/requests/bulk", string(payload))
+	var created struct {
+		Rows []bulkRow `json:"rows"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	if rec.Code != http.StatusOK || len(created.Rows) != 1 || created.Rows[0].Status != "created" || created.Rows[0].RequestID == 0 {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	req, err := s.db.GetRequest(context.Background(), created.Rows[0].RequestID)
+	if err != nil || req.Format != "audiobook" {
+		t.Fatalf("request = %+v, %v", req, err)
+	}
+
+	if rec = post("/api/v1/requests/bulk/resolve", `{"items":""}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty resolve: %d", rec.Code)
+	}
+}
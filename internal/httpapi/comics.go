@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// formatComic is the request format for comics and manga, which go to Mylar
+// rather than Readarr.
+const formatComic = "comic"
+
+var errComicsDisabled = errors.New("comic requests are not enabled; set up Mylar in Settings")
+
+// requestFormat maps a requested format to "ebook", "audiobook", "comic",
+// or "magazine". Anything else is an ebook, as it always was; comics need
+// Mylar.
+func (s *Server) requestFormat(v string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "audiobook", "audiobooks":
+		return "audiobook", nil
+	case "magazine", "magazines", "periodical":
+		return formatMagazine, nil
+	case "comic", "comics", "manga":
+		if !s.comicsEnabled() {
+			return "", errComicsDisabled
+		}
+		return formatComic, nil
+	default:
+		return "ebook", nil
+	}
+}
+
+func (s *Server) comicsEnabled() bool {
+	cfg := s.settings.Get()
+	return cfg != nil && cfg.Mylar.Enabled()
+}
+
+func (s *Server) mylarClient() *providers.Mylar {
+	cfg := s.settings.Get().Mylar
+	return providers.NewMylar(cfg.BaseURL, cfg.APIKey, nil)
+}
+
+// comicPayload is what a comic request stores as its selection payload: the
+// ComicVine series Mylar found.
+type comicPayload struct {
+	ComicID string `json:"comicid"`
+	Name    string `json:"name"`
+	Year    string `json:"comicyear,omitempty"`
+}
+
+// comicSearchItem is one series in the search page's comics section.
+type comicSearchItem struct {
+	providers.MylarComic
+	Payload string
+}
+
+// searchComics asks Mylar for series matching q, up to the mylar entry of
+// search.result_limits, or returns nil when comics are off.
+func (s *Server) searchComics(ctx context.Context, q string) []comicSearchItem {
+	if strings.TrimSpace(q) == "" || !s.comicsEnabled() || !s.providerEnabled(providerMylar) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	started := time.Now()
+	comics, err := s.mylarClient().FindComic(ctx, q)
+	s.recordProviderCall(providerMylar, started, err)
+	if err != nil {
+		return nil
+	}
+	out := make([]comicSearchItem, 0, len(comics))
+	for _, c := range comics {
+		if strings.TrimSpace(c.ID) == "" || strings.TrimSpace(c.Name) == "" {
+			continue
+		}
+		b, _ := json.Marshal(comicPayload{ComicID: c.ID, Name: c.Name, Year: c.Year})
+		out = append(out, comicSearchItem{MylarComic: c, Payload: string(b)})
+	}
+	if n := s.settings.Get().Search.ResultLimit(providerMylar); n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// approveComic adds the request's series to Mylar. A request made without a
+// stored series is matched by title first. Errors leave the request pending.
+func (s *Server) approveComic(ctx context.Context, req *db.Request, actor, auditNote string) (string, error) {
+	if !s.comicsEnabled() {
+		return "", errComicsDisabled
+	}
+	var p comicPayload
+	_ = json.Unmarshal(req.ReadarrReq, &p)
+	m := s.mylarClient()
+	if strings.TrimSpace(p.ComicID) == "" {
+		comics, err := m.FindComic(ctx, req.Title)
+		if err != nil {
+			return "", err
+		}
+		if len(comics) == 0 {
+			return "", errors.New("mylar found no series named " + req.Title)
+		}
+		p.ComicID = comics[0].ID
+	}
+	if err := m.AddComic(ctx, p.ComicID); err != nil {
+		return "", err
+	}
+	id := req.ID
+	_ = s.db.ApproveRequest(ctx, id, actor)
+	_ = s.db.UpdateRequestStatus(ctx, id, "approved", "added to Mylar", actor, nil, nil)
+	s.auditLog(ctx, actor, "request.approved", &id, auditNote+"added to Mylar")
+	go s.SendApprovalNotification(req.RequesterEmail, req.Title, req.Authors)
+	return "approved", nil
+}
@@ -0,0 +1,85 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func TestComicRequestsGoToMylar(t *testing.T) {
+	var added atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("cmd") {
+		case "findComic":
+			_, _ = w.Write([]byte(`{"success":true,"data":[{"comicid":"4050-48630","name":"Saga","comicyear":"2012","publisher":"Image","issues":"66","haveit":"No"}]}`))
+		case "addComic":
+			added.Store(q.Get("id"))
+			_, _ = w.Write([]byte(`{"success":true}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	s := newServerForTest(t)
+	h := s.Router()
+	user := makeCookie(t, s, "reader", false)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(user)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"title":"Saga","format":"comic"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("comic without Mylar: %d %s", rec.Code, rec.Body.String())
+	}
+
+	cfg := s.settings.Get()
+	cfg.Mylar = config.MylarConfig{BaseURL: srv.URL, APIKey: "my-key"}
+	cfg.Search.DisabledProviders = []string{providerOpenLibrary, providerAmazon}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ui/search?q=saga", nil)
+	req.AddCookie(user)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Request Comic") || !strings.Contains(rec.Body.String(), "4050-48630") {
+		t.Fatalf("search has no comic result: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := post(`{"title":"Saga","format":"comic","provider_payload":"{\"comicid\":\"4050-48630\",\"name\":\"Saga\"}"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	items, err := s.db.ListRequests(context.Background(), "", 10)
+	if err != nil || len(items) != 1 || items[0].Format != formatComic {
+		t.Fatalf("requests: %+v %v", items, err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(items[0].ID, 10)+"/approve", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+	if added.Load() != "4050-48630" {
+		t.Fatalf("Mylar addComic got %v", added.Load())
+	}
+	got, _ := s.db.GetRequest(context.Background(), items[0].ID)
+	if got.Status != "approved" || got.StatusReason != "added to Mylar" {
+		t.Fatalf("status %q (%q)", got.Status, got.StatusReason)
+	}
+}
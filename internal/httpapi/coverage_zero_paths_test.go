@@ -129,8 +129,8 @@ func TestAsyncNotificationHelpersExecute(t *testing.T) {
 
 	// Intentionally incomplete provider configs make async calls fail fast
 	// without touching external services.
-	s.sendRequestNotificationSMTP(cfg, 42, "alice", "Book", "Author")
-	s.sendRequestNotificationDiscord(cfg, 42, "alice", "Book", "Author")
+	s.sendRequestNotificationSMTP(cfg, 42, "alice", "Book", "Author", nil)
+	s.sendRequestNotificationDiscord(cfg, 42, "alice", "Book", "Author", nil)
 	s.sendApprovalNotificationDiscord(cfg, "alice", "Book", "Author")
 	s.sendSystemNotificationNtfy(cfg, "Alert", "Something happened")
 	s.sendSystemNotificationSMTP(cfg, "Alert", "Something happened")
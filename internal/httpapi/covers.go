@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Cover image modes, set by search.cover_images.
+const (
+	// coversProxy serves Readarr-hosted covers through /ui/readarr-cover.
+	coversProxy = "proxy"
+	// coversDirect links every cover at its source and never proxies:
+	// Readarr-hosted covers become Open Library's cover for the ISBN, or no
+	// image without one.
+	coversDirect = "direct"
+	// coversNone shows no cover images at all.
+	coversNone = "none"
+)
+
+func normalizeCoverImages(v string) string {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case coversDirect, coversNone:
+		return v
+	}
+	return coversProxy
+}
+
+// coverImages is the configured cover image mode.
+func (s *Server) coverImages() string {
+	if cfg := s.settings.Get(); cfg != nil {
+		return normalizeCoverImages(cfg.Search.CoverImages)
+	}
+	return coversProxy
+}
+
+// displayCover is the cover URL to render for a book under the cover image
+// mode. Covers stored or built as proxy URLs are rewritten too, so no
+// /ui/readarr-cover link reaches the page unless the proxy is on.
+func (s *Server) displayCover(cover, isbn13, isbn10 string) string {
+	switch s.coverImages() {
+	case coversNone:
+		return ""
+	case coversDirect:
+		if !strings.HasPrefix(cover, "/ui/readarr-cover") {
+			return cover
+		}
+		if !s.providerEnabled(providerOpenLibrary) {
+			return ""
+		}
+		isbn := strings.TrimSpace(isbn13)
+		if isbn == "" {
+			isbn = strings.TrimSpace(isbn10)
+		}
+		if isbn == "" {
+			if u, err := url.Parse(cover); err == nil {
+				isbn = u.Query().Get("isbn")
+			}
+		}
+		// Without default=false Open Library answers a missing cover with a
+		// blank image rather than a 404 the browser shows as broken.
+		return strings.TrimSuffix(openLibraryCoverFallbackURL(isbn), "?default=false")
+	}
+	return cover
+}
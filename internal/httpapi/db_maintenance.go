@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+const (
+	defaultDBMaintenanceInterval = 24 * time.Hour
+	minDBMaintenanceInterval     = time.Hour
+	dbMaintenanceTimeout         = 10 * time.Minute
+)
+
+var errDBMaintenanceInProgress = errors.New("database maintenance already in progress")
+
+// dbMaintenanceView is the JSON and template shape of one maintenance run.
+type dbMaintenanceView struct {
+	RanAt       time.Time `json:"ran_at"`
+	Actor       string    `json:"actor"`
+	DurationMS  int64     `json:"duration_ms"`
+	BeforeBytes int64     `json:"before_bytes"`
+	AfterBytes  int64     `json:"after_bytes"`
+	BeforeText  string    `json:"before_text"`
+	AfterText   string    `json:"after_text"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// dbMaintenanceInterval returns the schedule; 0 means scheduled runs are off.
+func (s *Server) dbMaintenanceInterval() time.Duration {
+	v := strings.ToLower(strings.TrimSpace(s.settings.Get().Maintenance.Interval))
+	switch v {
+	case "":
+		return defaultDBMaintenanceInterval
+	case "off", "0", "false", "disabled":
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return defaultDBMaintenanceInterval
+	}
+	if d < minDBMaintenanceInterval {
+		return minDBMaintenanceInterval
+	}
+	return d
+}
+
+// runDBMaintenanceLoop optimizes the database on the configured schedule.
+// The interval is re-read after each wait so settings changes take effect
+// without a restart.
+func (s *Server) runDBMaintenanceLoop(ctx context.Context) {
+	for {
+		interval := s.dbMaintenanceInterval()
+		wait := interval
+		if wait == 0 {
+			// Disabled: check again later in case it is turned on.
+			wait = minDBMaintenanceInterval
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if interval == 0 || s.dbMaintenanceInterval() == 0 {
+			continue
+		}
+		if _, err := s.optimizeDB(ctx, "system"); err != nil && !errors.Is(err, errDBMaintenanceInProgress) {
+			fmt.Printf("db: scheduled optimize failed: %v\n", err)
+		}
+	}
+}
+
+// optimizeDB runs one maintenance pass, records it for the settings page,
+// and writes an audit event. Only one pass runs at a time.
+func (s *Server) optimizeDB(ctx context.Context, actor string) (dbMaintenanceView, error) {
+	if !s.dbMaintenanceMu.TryLock() {
+		return dbMaintenanceView{}, errDBMaintenanceInProgress
+	}
+	defer s.dbMaintenanceMu.Unlock()
+
+	runCtx, cancel := context.WithTimeout(ctx, dbMaintenanceTimeout)
+	defer cancel()
+	res, err := s.db.Optimize(runCtx)
+	view := newDBMaintenanceView(res, actor)
+	if err != nil {
+		view.Error = err.Error()
+	}
+	s.dbMaintenanceLast.Store(&view)
+	if err != nil {
+		return view, err
+	}
+	s.auditLog(ctx, actor, "db.optimized", nil, fmt.Sprintf("%s -> %s in %dms", view.BeforeText, view.AfterText, view.DurationMS))
+	return view, nil
+}
+
+func newDBMaintenanceView(res db.OptimizeResult, actor string) dbMaintenanceView {
+	return dbMaintenanceView{
+		RanAt:       res.StartedAt,
+		Actor:       actor,
+		DurationMS:  res.Duration.Milliseconds(),
+		BeforeBytes: res.BeforeBytes,
+		AfterBytes:  res.AfterBytes,
+		BeforeText:  formatBytes(res.BeforeBytes),
+		AfterText:   formatBytes(res.AfterBytes),
+	}
+}
+
+// dbMaintenanceSettingsView feeds the maintenance card on /settings.
+func (s *Server) dbMaintenanceSettingsView() map[string]any {
+	schedule := "Off"
+	if d := s.dbMaintenanceInterval(); d > 0 {
+		schedule = "Every " + d.String()
+	}
+	return map[string]any{
+		"Schedule": schedule,
+		"SizeText": formatBytes(s.db.FileSize()),
+		"Last":     s.dbMaintenanceLast.Load(),
+	}
+}
+
+func (s *Server) apiDBOptimize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Context().Value(ctxUser).(*session).Username
+		view, err := s.optimizeDB(r.Context(), actor)
+		if errors.Is(err, errDBMaintenanceInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "optimize failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, view, http.StatusOK)
+	}
+}
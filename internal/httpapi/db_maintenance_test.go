@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDBMaintenanceInterval(t *testing.T) {
+	s := newServerForTest(t)
+	cases := map[string]time.Duration{
+		"":        defaultDBMaintenanceInterval,
+		"off":     0,
+		"10m":     minDBMaintenanceInterval,
+		"6h":      6 * time.Hour,
+		"garbage": defaultDBMaintenanceInterval,
+	}
+	for in, want := range cases {
+		cfg := *s.settings.Get()
+		cfg.Maintenance.Interval = in
+		if err := s.settings.Update(&cfg); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.dbMaintenanceInterval(); got != want {
+			t.Errorf("interval(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestDBOptimizeEndpoint(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	do := func(method, path string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(makeCookie(t, s, map[bool]string{true: "admin", false: "user"}[admin], admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/db/optimize", false); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin optimize: %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/db/optimize", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("optimize: %d %s", rec.Code, rec.Body.String())
+	}
+	var view dbMaintenanceView
+	if err := json.Unmarshal(rec.Body.Bytes(), &view); err != nil {
+		t.Fatal(err)
+	}
+	if view.Actor != "admin" || view.BeforeBytes == 0 || view.AfterText == "" {
+		t.Fatalf("view = %+v", view)
+	}
+
+	s.dbMaintenanceMu.Lock()
+	if rec := do(http.MethodPost, "/api/db/optimize", true); rec.Code != http.StatusConflict {
+		t.Fatalf("concurrent optimize: %d", rec.Code)
+	}
+	s.dbMaintenanceMu.Unlock()
+
+	rec = do(http.MethodGet, "/settings", true)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Optimize now") || !strings.Contains(body, "by admin") {
+		t.Fatalf("settings page: %d", rec.Code)
+	}
+}
@@ -0,0 +1,246 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"github.com/go-chi/chi/v5"
+)
+
+// denylistAttemptsShown caps the blocked and flagged attempts listed on the
+// denylist page.
+const denylistAttemptsShown = 25
+
+// denyKey folds a title or name for denylist matching: lower case, letters
+// and digits only, single spaces.
+func denyKey(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// normalizeDenylistEntry validates e and puts its value in the form it is
+// matched in: identifiers become ISBN-13s or upper-case ASINs.
+func normalizeDenylistEntry(e *db.DenylistEntry) error {
+	e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+	e.Action = strings.ToLower(strings.TrimSpace(e.Action))
+	e.Value = strings.TrimSpace(e.Value)
+	if len([]rune(strings.TrimSpace(e.Note))) > 200 {
+		return errors.New("note is too long (maximum 200 characters)")
+	}
+	if e.Action == "" {
+		e.Action = db.DenyBlock
+	}
+	if e.Action != db.DenyBlock && e.Action != db.DenyFlag {
+		return errors.New("action must be block or flag")
+	}
+	switch e.Kind {
+	case db.DenyAuthor, db.DenyTitle:
+		if denyKey(e.Value) == "" {
+			return errors.New("value required")
+		}
+	case db.DenyISBN:
+		ref, ok := providers.ParseBookIdentifier(e.Value)
+		if !ok || (ref.Source != "isbn" && ref.Source != "asin") {
+			return errors.New("not a valid ISBN or ASIN")
+		}
+		e.Value = ref.ISBN13
+		if e.Value == "" {
+			e.Value = ref.ASIN
+		}
+	default:
+		return errors.New("kind must be author, title, or isbn")
+	}
+	return nil
+}
+
+// denylistMatch returns the denylist entry a request matches, preferring a
+// blocking entry over a flagging one, and what matched. Authors must match
+// in full; a title matches when it contains the listed words in order.
+func (s *Server) denylistMatch(ctx context.Context, title string, authors []string, isbn10, isbn13, asin string) (*db.DenylistEntry, string) {
+	entries, err := s.db.ListDenylist(ctx)
+	if err != nil || len(entries) == 0 {
+		return nil, ""
+	}
+	ids := map[string]bool{}
+	for _, v := range []string{isbn10, isbn13} {
+		if ref, ok := providers.ParseBookIdentifier(v); ok && ref.ISBN13 != "" {
+			ids[ref.ISBN13] = true
+		}
+	}
+	if a := strings.ToUpper(strings.TrimSpace(asin)); a != "" {
+		ids[a] = true
+	}
+	titleKey := " " + denyKey(title) + " "
+	var flagged *db.DenylistEntry
+	var flaggedWhy string
+	for i := range entries {
+		e := &entries[i]
+		var why string
+		switch e.Kind {
+		case db.DenyAuthor:
+			for _, a := range authors {
+				if k := denyKey(a); k != "" && k == denyKey(e.Value) {
+					why = fmt.Sprintf("author %q", e.Value)
+					break
+				}
+			}
+		case db.DenyTitle:
+			if k := denyKey(e.Value); k != "" && strings.Contains(titleKey, " "+k+" ") {
+				why = fmt.Sprintf("title %q", e.Value)
+			}
+		case db.DenyISBN:
+			if ids[strings.ToUpper(e.Value)] {
+				why = "identifier " + e.Value
+			}
+		}
+		switch {
+		case why == "":
+		case e.Blocks():
+			return e, why
+		case flagged == nil:
+			flagged, flaggedWhy = e, why
+		}
+	}
+	return flagged, flaggedWhy
+}
+
+// refuseDeniedRequest answers a request the denylist blocks and records the
+// attempt.
+func (s *Server) refuseDeniedRequest(w http.ResponseWriter, r *http.Request, title string, e *db.DenylistEntry, why string) {
+	u := r.Context().Value(ctxUser).(*session)
+	s.auditLog(r.Context(), u.Username, "request.blocked", nil, fmt.Sprintf("%q matched denylist %s", title, why))
+	msg := "This book can't be requested"
+	if e.Note != "" {
+		msg += ": " + e.Note
+	}
+	if strings.Contains(r.Header.Get("HX-Request"), "true") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<li class="p-3 bg-red-50 text-red-700 rounded mb-2">` + html.EscapeString(msg) + `.</li>`))
+		return
+	}
+	writeJSON(w, map[string]any{"status": "blocked", "message": msg}, http.StatusForbidden)
+}
+
+// ----- Admin API -----
+
+func (s *Server) apiListDenylist(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.db.ListDenylist(r.Context())
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []db.DenylistEntry{}
+	}
+	writeJSON(w, entries, http.StatusOK)
+}
+
+// apiAddDenylistEntry adds an entry. Body: {"kind": "author"|"title"|"isbn",
+// "value": "...", "action": "block"|"flag", "note": "..."}.
+func (s *Server) apiAddDenylistEntry(w http.ResponseWriter, r *http.Request) {
+	var e db.DenylistEntry
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	id, err := s.addDenylistEntry(r, &e)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	e.ID = id
+	writeJSON(w, e, http.StatusCreated)
+}
+
+func (s *Server) apiDeleteDenylistEntry(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err := s.deleteDenylistEntry(r, id); err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) addDenylistEntry(r *http.Request, e *db.DenylistEntry) (int64, error) {
+	if err := normalizeDenylistEntry(e); err != nil {
+		return 0, err
+	}
+	actor := r.Context().Value(ctxUser).(*session).Username
+	e.CreatedBy = actor
+	id, err := s.db.AddDenylistEntry(r.Context(), e)
+	if err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+	s.auditLog(r.Context(), actor, "denylist.added", nil, fmt.Sprintf("%s %s %q", e.Action, e.Kind, e.Value))
+	return id, nil
+}
+
+func (s *Server) deleteDenylistEntry(r *http.Request, id int64) error {
+	var removed *db.DenylistEntry
+	if entries, err := s.db.ListDenylist(r.Context()); err == nil {
+		for i := range entries {
+			if entries[i].ID == id {
+				removed = &entries[i]
+			}
+		}
+	}
+	if err := s.db.DeleteDenylistEntry(r.Context(), id); err != nil {
+		return err
+	}
+	if removed != nil {
+		s.auditLog(r.Context(), r.Context().Value(ctxUser).(*session).Username, "denylist.removed", nil, fmt.Sprintf("%s %q", removed.Kind, removed.Value))
+	}
+	return nil
+}
+
+// ----- Admin page -----
+
+func (u *ui) handleDenylist(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, _ := s.db.ListDenylist(r.Context())
+		blocked, _ := s.db.ListAuditEventsByType(r.Context(), "request.blocked", denylistAttemptsShown)
+		flagged, _ := s.db.ListAuditEventsByType(r.Context(), "request.flagged", denylistAttemptsShown)
+		data := map[string]any{
+			"UserName":  s.userName(r),
+			"IsAdmin":   true,
+			"CSRFToken": s.getCSRFToken(r),
+			"Entries":   entries,
+			"Blocked":   blocked,
+			"Flagged":   flagged,
+			"Error":     r.URL.Query().Get("error"),
+		}
+		_ = u.tpl.ExecuteTemplate(w, "denylist.html", data)
+	}
+}
+
+func (s *Server) handleDenylistAdd(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	e := db.DenylistEntry{Kind: r.FormValue("kind"), Value: r.FormValue("value"), Action: r.FormValue("action"), Note: r.FormValue("note")}
+	if _, err := s.addDenylistEntry(r, &e); err != nil {
+		http.Redirect(w, r, "/denylist?error="+url.QueryEscape(err.Error()), http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/denylist", http.StatusFound)
+}
+
+func (s *Server) handleDenylistDelete(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err := s.deleteDenylistEntry(r, id); err != nil {
+		http.Redirect(w, r, "/denylist?error="+url.QueryEscape(err.Error()), http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/denylist", http.StatusFound)
+}
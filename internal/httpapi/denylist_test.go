@@ -0,0 +1,83 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestDenylistBlocksAndFlagsRequests(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	h := s.Router()
+	admin := makeCookie(t, s, "admin", true)
+
+	add := func(form url.Values) {
+		req := httptest.NewRequest(http.MethodPost, "/denylist/add", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(admin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusFound || strings.Contains(rec.Header().Get("Location"), "error") {
+			t.Fatalf("add %v: %d %s", form, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+	add(url.Values{"kind": {"author"}, "value": {"Jane Doe"}, "action": {"block"}, "note": {"Not for this library"}})
+	add(url.Values{"kind": {"isbn"}, "value": {"0-441-17271-7"}, "action": {"block"}})
+	add(url.Values{"kind": {"title"}, "value": {"Necronomicon"}, "action": {"flag"}})
+	if entries, _ := s.db.ListDenylist(ctx); len(entries) != 3 || entries[1].Value != "9780441172719" {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "alice", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := post(`{"title":"A Book","authors":["jane  doe"]}`); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "Not for this library") {
+		t.Fatalf("author block: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(`{"title":"Dune","isbn13":"9780441172719"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("isbn block: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(`{"title":"The Necronomicon: Annotated"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("flagged request: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(`{"title":"Necronomicons of the World"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("unrelated request: %d %s", rec.Code, rec.Body.String())
+	}
+
+	reqs, _ := s.db.ListRequests(ctx, "alice", 10)
+	if len(reqs) != 2 {
+		t.Fatalf("requests = %+v", reqs)
+	}
+	var flagged *db.Request
+	for i := range reqs {
+		if strings.Contains(reqs[i].StatusReason, "denylist") {
+			flagged = &reqs[i]
+		}
+	}
+	if flagged == nil || flagged.Title != "The Necronomicon: Annotated" || flagged.Status != "pending" {
+		t.Fatalf("flagged request = %+v", flagged)
+	}
+	blocked, _ := s.db.ListAuditEventsByType(ctx, "request.blocked", 10)
+	if len(blocked) != 2 || blocked[0].ActorEmail != "alice" {
+		t.Fatalf("blocked attempts = %+v", blocked)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/denylist", nil)
+	req.AddCookie(admin)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Jane Doe") || !strings.Contains(rec.Body.String(), "Blocked") {
+		t.Fatalf("denylist page: %d %s", rec.Code, rec.Body.String())
+	}
+}
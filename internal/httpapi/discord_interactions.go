@@ -0,0 +1,270 @@
+package httpapi
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	discordInteractionBodyLimit = 64 << 10
+	// discordSignatureMaxAge bounds how old a signed interaction may be, so
+	// a captured button press cannot be replayed later.
+	discordSignatureMaxAge = 5 * time.Minute
+
+	// Interaction and response types from the Discord API.
+	discordInteractionPing      = 1
+	discordInteractionComponent = 3
+	discordResponsePong         = 1
+	discordResponseMessage      = 4
+	discordResponseUpdate       = 7
+	discordFlagEphemeral        = 64
+)
+
+// discordRequestButtons is the action row added to new-request messages when
+// the interactions endpoint is configured. The custom ids carry the request
+// id back to apiDiscordInteraction.
+func discordRequestButtons(requestID int64, serverURL string) []map[string]any {
+	id := strconv.FormatInt(requestID, 10)
+	buttons := []map[string]any{
+		{"type": 2, "style": 3, "label": "Approve", "emoji": map[string]string{"name": "✅"}, "custom_id": "approve:" + id},
+		{"type": 2, "style": 4, "label": "Decline", "emoji": map[string]string{"name": "❌"}, "custom_id": "decline:" + id},
+	}
+	if serverURL = strings.TrimRight(strings.TrimSpace(serverURL), "/"); serverURL != "" {
+		buttons = append(buttons, map[string]any{"type": 2, "style": 5, "label": "View Requests", "url": serverURL + "/requests"})
+	}
+	return []map[string]any{{"type": 1, "components": buttons}}
+}
+
+// parseDiscordModerators reads "discord id = username" lines from the
+// notifications page; "id: username" works too.
+func parseDiscordModerators(text string) map[string]string {
+	out := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			continue
+		}
+		id, name := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if id != "" && name != "" {
+			out[id] = name
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// formatDiscordModerators is the inverse of parseDiscordModerators, sorted
+// by username.
+func formatDiscordModerators(m map[string]string) string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if m[ids[i]] != m[ids[j]] {
+			return m[ids[i]] < m[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	lines := make([]string, len(ids))
+	for i, id := range ids {
+		lines[i] = id + " = " + m[id]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// verifyDiscordSignature checks Discord's Ed25519 signature over the
+// timestamp and raw body.
+func verifyDiscordSignature(publicKey, signature, timestamp string, body []byte) bool {
+	key, err := hex.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > discordSignatureMaxAge || age < -discordSignatureMaxAge {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(key), append([]byte(timestamp), body...), sig)
+}
+
+func (s *Server) mountDiscordInteractions(r chi.Router) {
+	r.Post("/api/v1/discord/interactions", s.apiDiscordInteraction)
+}
+
+type discordInteraction struct {
+	Type int `json:"type"`
+	Data struct {
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+	// Member is set for presses in a server channel, User in a DM.
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+}
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// apiDiscordInteraction answers Discord's interactions endpoint: the PING
+// Discord sends when the URL is saved, and presses of the Approve and
+// Decline buttons on new-request messages. A press acts as the Scriptorum
+// account the Discord user is mapped to in notifications.discord.moderators.
+func (s *Server) apiDiscordInteraction(w http.ResponseWriter, r *http.Request) {
+	cfg := s.settings.Get().Notifications.Discord
+	if strings.TrimSpace(cfg.PublicKey) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, discordInteractionBodyLimit))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if !verifyDiscordSignature(cfg.PublicKey, r.Header.Get("X-Signature-Ed25519"), r.Header.Get("X-Signature-Timestamp"), body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+	var in discordInteraction
+	if err := json.Unmarshal(body, &in); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	switch in.Type {
+	case discordInteractionPing:
+		writeJSON(w, map[string]any{"type": discordResponsePong}, http.StatusOK)
+	case discordInteractionComponent:
+		s.handleDiscordButton(w, r, &in, cfg.Moderators)
+	default:
+		http.Error(w, "unsupported interaction type", http.StatusBadRequest)
+	}
+}
+
+// discordReply answers only the person who pressed the button.
+func discordReply(w http.ResponseWriter, msg string) {
+	writeJSON(w, map[string]any{
+		"type": discordResponseMessage,
+		"data": map[string]any{"content": msg, "flags": discordFlagEphemeral},
+	}, http.StatusOK)
+}
+
+// discordUpdate rewrites the message the button was on for everyone. With
+// done set the buttons are removed.
+func discordUpdate(w http.ResponseWriter, msg string, done bool) {
+	data := map[string]any{"content": msg}
+	if done {
+		data["components"] = []any{}
+	}
+	writeJSON(w, map[string]any{"type": discordResponseUpdate, "data": data}, http.StatusOK)
+}
+
+func (s *Server) handleDiscordButton(w http.ResponseWriter, r *http.Request, in *discordInteraction, moderators map[string]string) {
+	ctx := r.Context()
+	action, idText, _ := strings.Cut(in.Data.CustomID, ":")
+	id, err := strconv.ParseInt(idText, 10, 64)
+	if err != nil || (action != "approve" && action != "decline") {
+		discordReply(w, "Unknown action.")
+		return
+	}
+	presser := in.User
+	if in.Member != nil {
+		presser = &in.Member.User
+	}
+	if presser == nil || presser.ID == "" {
+		discordReply(w, "Could not tell who pressed the button.")
+		return
+	}
+	req, err := s.db.GetRequest(ctx, id)
+	if err != nil {
+		discordReply(w, fmt.Sprintf("Request #%d no longer exists.", id))
+		return
+	}
+	actor, ok := s.discordModerator(r, presser.ID, moderators, req)
+	if !ok {
+		discordReply(w, "You are not allowed to moderate requests from Discord.")
+		return
+	}
+	if req.Status != "pending" {
+		discordUpdate(w, fmt.Sprintf("Request #%d is already %s.", id, req.Status), true)
+		return
+	}
+	// Claim the request so a web click, email reply, or second press on
+	// the same request cannot act on it at the same time.
+	release, err := s.claimRequest(ctx, id, req.UpdatedAt)
+	if errors.Is(err, db.ErrStaleRequest) {
+		discordReply(w, fmt.Sprintf("Request #%d was already handled.", id))
+		return
+	}
+	if err != nil {
+		discordReply(w, "Failed to update the request.")
+		return
+	}
+	defer release()
+	if action == "decline" {
+		if err := s.db.DeclineRequest(ctx, id, actor, ""); err != nil {
+			discordReply(w, "Failed to decline the request.")
+			return
+		}
+		s.auditLog(ctx, actor, "request.declined", &id, "via Discord")
+		s.releaseRequestAuthor(id, actor)
+		s.kickHomeAssistant()
+		discordUpdate(w, fmt.Sprintf("❌ Declined by %s", actor), true)
+		return
+	}
+	status, err := s.approveRequest(ctx, req, actor, "via Discord, ", addPolicyOverride{})
+	switch {
+	case errors.Is(err, errSameApprover):
+		discordReply(w, err.Error()+".")
+	case err != nil:
+		discordReply(w, "Approval failed: "+err.Error())
+	case status == statusAwaitingSecondApproval:
+		discordUpdate(w, fmt.Sprintf("☑️ First approval by %s, waiting for a second admin", actor), false)
+	default:
+		s.kickHomeAssistant()
+		discordUpdate(w, fmt.Sprintf("✅ Approved by %s", actor), true)
+	}
+}
+
+// discordModerator returns the Scriptorum account a Discord user acts as on
+// req. The account must be enabled and either an admin or an admin of the
+// request's group.
+func (s *Server) discordModerator(r *http.Request, discordID string, moderators map[string]string, req *db.Request) (string, bool) {
+	name := strings.TrimSpace(moderators[discordID])
+	if name == "" {
+		return "", false
+	}
+	u, err := s.db.GetUserByUsername(r.Context(), name)
+	if err != nil || u.Disabled {
+		return "", false
+	}
+	if u.IsAdmin {
+		return u.Username, true
+	}
+	if req.GroupName != "" && strings.EqualFold(u.GroupName, req.GroupName) && s.isGroupAdmin(u.Username, req.GroupName) {
+		return u.Username, true
+	}
+	return "", false
+}
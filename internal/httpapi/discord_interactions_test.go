@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestDiscordInteractionButtons(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	h := s.Router()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := func(body string, sign bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/discord/interactions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		sig := ed25519.Sign(priv, []byte(ts+body))
+		if !sign {
+			sig = ed25519.Sign(priv, []byte(ts+"{}"))
+		}
+		req.Header.Set("X-Signature-Timestamp", ts)
+		req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(sig))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"type":1}`, true); rec.Code != http.StatusNotFound {
+		t.Fatalf("unconfigured endpoint: %d", rec.Code)
+	}
+	cfg := s.settings.Get()
+	cfg.Notifications.Discord.PublicKey = hex.EncodeToString(pub)
+	cfg.Notifications.Discord.Moderators = parseDiscordModerators("111 = admin\n222: bob")
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.CreateUser(ctx, "admin", "hash", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.CreateUser(ctx, "bob", "hash", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := post(`{"type":1}`, false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: %d", rec.Code)
+	}
+	if rec := post(`{"type":1}`, true); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"type":1`) {
+		t.Fatalf("ping: %d %s", rec.Code, rec.Body.String())
+	}
+
+	id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	press := func(action, user string) *httptest.ResponseRecorder {
+		return post(`{"type":3,"data":{"custom_id":"`+action+`:`+strconv.FormatInt(id, 10)+`"},"member":{"user":{"id":"`+user+`"}}}`, true)
+	}
+	if rec := press("approve", "222"); !strings.Contains(rec.Body.String(), "not allowed") {
+		t.Fatalf("non-admin press: %s", rec.Body.String())
+	}
+	if rec := press("decline", "999"); !strings.Contains(rec.Body.String(), "not allowed") {
+		t.Fatalf("unmapped press: %s", rec.Body.String())
+	}
+	if got, _ := s.db.GetRequest(ctx, id); got.Status != "pending" {
+		t.Fatalf("refused press changed status to %s", got.Status)
+	}
+	rec := press("approve", "111")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Approved by admin") || !strings.Contains(rec.Body.String(), `"components":[]`) {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+	got, _ := s.db.GetRequest(ctx, id)
+	if got.Status != "approved" || got.ApproverEmail != "admin" {
+		t.Fatalf("after approve: %+v", got)
+	}
+	if rec := press("decline", "111"); !strings.Contains(rec.Body.String(), "already approved") {
+		t.Fatalf("second press: %s", rec.Body.String())
+	}
+}
+
+func TestDiscordRequestNotificationUsesButtons(t *testing.T) {
+	s := newServerForTest(t)
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := new(strings.Builder)
+		_, _ = io.Copy(b, r.Body)
+		body = b.String()
+		if r.URL.Query().Get("with_components") != "true" {
+			t.Errorf("webhook query = %q", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	if err := s.sendDiscordMessage(srv.URL, "", "New", "Dune", 0, discordRequestButtons(7, "https://books.example.com/")); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"custom_id":"approve:7"`, `"custom_id":"decline:7"`, `"url":"https://books.example.com/requests"`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("payload missing %s: %s", want, body)
+		}
+	}
+}
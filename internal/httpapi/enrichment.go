@@ -0,0 +1,341 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// enrichmentTimeout bounds the whole chain, Readarr retries included.
+const enrichmentTimeout = 20 * time.Second
+
+// bookHints is what a request knows about a book before Readarr matches it.
+// Enrichment sources fill in the blanks.
+type bookHints struct {
+	Title  string
+	Author string
+	ISBN13 string
+	ISBN10 string
+	ASIN   string
+}
+
+// enrichmentStep records one source in the chain: which hint or Readarr
+// field it filled, or why it failed.
+type enrichmentStep struct {
+	Source string
+	Filled []string
+	Err    string
+	// Guess is set on a Readarr step whose pick came from a title/author
+	// search without an exact title and author match.
+	Guess bool
+}
+
+func (st enrichmentStep) String() string {
+	switch {
+	case st.Err != "":
+		return st.Source + ": " + st.Err
+	case len(st.Filled) == 0:
+		return st.Source + ": nothing"
+	}
+	return st.Source + ": " + strings.Join(st.Filled, ", ")
+}
+
+// enrichmentSource looks a book up by its hints in one metadata source.
+type enrichmentSource func(ctx context.Context, h bookHints) (*providers.BookItem, error)
+
+// enrichmentSources are the non-Readarr steps of the chain. Tests swap
+// entries to avoid the network.
+var enrichmentSources = map[string]enrichmentSource{
+	config.EnrichOpenLibrary: enrichFromOpenLibrary,
+	config.EnrichGoogleBooks: enrichFromGoogleBooks,
+	config.EnrichAudnex:      enrichFromAudnex,
+}
+
+func enrichFromOpenLibrary(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+	q := h.isbn()
+	if q == "" {
+		q = h.titleAuthor()
+	}
+	if q == "" {
+		return nil, nil
+	}
+	books, err := providers.NewOpenLibrary().Search(ctx, q, 5, 1)
+	if err != nil || len(books) == 0 {
+		return nil, err
+	}
+	match := pickOpenLibraryMatch(books, h.Title, []string{h.Author})
+	return &match, nil
+}
+
+func enrichFromGoogleBooks(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+	var q string
+	switch {
+	case h.ISBN13 != "":
+		q = "isbn:" + h.ISBN13
+	case h.ISBN10 != "":
+		q = "isbn:" + h.ISBN10
+	case h.Title != "":
+		q = fmt.Sprintf("intitle:%q", h.Title)
+		if h.Author != "" {
+			q += fmt.Sprintf(" inauthor:%q", h.Author)
+		}
+	default:
+		return nil, nil
+	}
+	books, err := providers.NewGoogleBooks().Search(ctx, q, 5)
+	if err != nil || len(books) == 0 {
+		return nil, err
+	}
+	match := pickOpenLibraryMatch(books, h.Title, []string{h.Author})
+	return &match, nil
+}
+
+// enrichFromAudnex only knows Audible ASINs, so it is skipped without one.
+func enrichFromAudnex(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+	if h.ASIN == "" {
+		return nil, nil
+	}
+	return providers.NewAudnex().Book(ctx, h.ASIN)
+}
+
+// isbn returns the ISBN-13, else the ISBN-10.
+func (h bookHints) isbn() string {
+	if h.ISBN13 != "" {
+		return h.ISBN13
+	}
+	return h.ISBN10
+}
+
+func (h bookHints) titleAuthor() string {
+	return strings.TrimSpace(h.Title + " " + h.Author)
+}
+
+// readarrTerms lists Readarr lookup terms from most to least specific.
+func (h bookHints) readarrTerms() []string {
+	var out []string
+	for _, t := range []string{h.ASIN, h.ISBN13, h.ISBN10, h.titleAuthor()} {
+		if t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// merge copies what item knows into empty hints and names the fields it
+// filled.
+func (h *bookHints) merge(item *providers.BookItem) []string {
+	if item == nil {
+		return nil
+	}
+	var filled []string
+	fill := func(dst *string, v, name string) {
+		if v = strings.TrimSpace(v); *dst == "" && v != "" {
+			*dst = v
+			filled = append(filled, name)
+		}
+	}
+	fill(&h.ISBN13, item.ISBN13, "isbn13")
+	fill(&h.ISBN10, item.ISBN10, "isbn10")
+	fill(&h.ASIN, item.ASIN, "asin")
+	fill(&h.Title, item.Title, "title")
+	if len(item.Authors) > 0 {
+		fill(&h.Author, item.Authors[0], "author")
+	}
+	return filled
+}
+
+// pickReadarrLookup prefers the result whose title and first author both
+// match, else the first result. exact reports whether such a match was found.
+func pickReadarrLookup(list []providers.LookupBook, title, author string) (pick providers.LookupBook, exact bool) {
+	pick = list[0]
+	for _, b := range list {
+		titleOK := strings.EqualFold(strings.TrimSpace(b.Title), strings.TrimSpace(title)) && strings.TrimSpace(b.Title) != ""
+		authorOK := false
+		if want := strings.TrimSpace(author); want != "" {
+			if b.Author != nil {
+				if n, _ := b.Author["name"].(string); n != "" && strings.EqualFold(strings.TrimSpace(n), want) {
+					authorOK = true
+				}
+			} else if len(b.Authors) > 0 {
+				if n, _ := b.Authors[0]["name"].(string); n != "" && strings.EqualFold(strings.TrimSpace(n), want) {
+					authorOK = true
+				}
+			} else if b.AuthorTitle != "" {
+				if strings.Contains(strings.ToLower(b.AuthorTitle), strings.ToLower(strings.ReplaceAll(want, " ", ""))) {
+					authorOK = true
+				}
+			}
+		}
+		if titleOK && authorOK {
+			return b, true
+		}
+	}
+	return pick, false
+}
+
+// lookupBookAuthor returns the author object of a Readarr lookup result,
+// falling back to its author id or the name inside authorTitle.
+func lookupBookAuthor(b providers.LookupBook) map[string]any {
+	switch {
+	case b.Author != nil:
+		return b.Author
+	case len(b.Authors) > 0:
+		return b.Authors[0]
+	case b.AuthorId > 0:
+		return map[string]any{"id": b.AuthorId}
+	case b.AuthorTitle != "":
+		return map[string]any{"name": parseAuthorNameFromTitle(b.AuthorTitle)}
+	}
+	return nil
+}
+
+// chainSource returns the chain step for src, or nil when the source is
+// unknown or not set up. ISBNdb needs the server for its key and quota.
+func (s *Server) chainSource(src string) enrichmentSource {
+	// Every source but Readarr is on the public internet.
+	if s.offline() {
+		return nil
+	}
+	if fn := enrichmentSources[src]; fn != nil {
+		return func(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+			s.countProviderCall(src)
+			return fn(ctx, h)
+		}
+	}
+	if src == config.EnrichISBNdb && s.settings.Get().ISBNdb.Enabled() {
+		return s.enrichFromISBNdb
+	}
+	return nil
+}
+
+// enrichmentChain is the configured source order.
+func (s *Server) enrichmentChain() []string {
+	return config.NormalizeEnrichmentChain(s.settings.Get().Requests.EnrichmentChain)
+}
+
+// resolveReadarrBook finds the Readarr lookup result for a book, walking the
+// enrichment chain while Readarr has no match with a foreignBookId. Each
+// metadata source fills empty hints, and any new identifier is looked up in
+// Readarr straight away. When no result has foreign ids the first Readarr
+// result is returned, as before the chain existed; nil means Readarr found
+// nothing at all. The returned hints carry whatever the sources filled in.
+func (s *Server) resolveReadarrBook(ctx context.Context, ra *providers.Readarr, h bookHints) (*providers.LookupBook, bookHints, []enrichmentStep) {
+	ctx, cancel := context.WithTimeout(ctx, enrichmentTimeout)
+	defer cancel()
+
+	var steps []enrichmentStep
+	var pick, fallback *providers.LookupBook
+	tried := map[string]bool{}
+	// lookup tries each identifier Readarr has not seen yet, most specific
+	// first, until one yields foreign ids.
+	lookup := func() {
+		for _, term := range h.readarrTerms() {
+			if pick != nil {
+				return
+			}
+			if tried[term] {
+				continue
+			}
+			tried[term] = true
+			step := enrichmentStep{Source: config.EnrichReadarr}
+			list, err := ra.LookupByTerm(ctx, term)
+			switch {
+			case err != nil:
+				step.Err = err.Error()
+			case len(list) > 0:
+				b, exact := pickReadarrLookup(list, h.Title, h.Author)
+				if fallback == nil {
+					fallback = &b
+				}
+				if strings.TrimSpace(b.ForeignBookId) != "" {
+					pick = &b
+					step.Guess = !exact && term == h.titleAuthor()
+					step.Filled = append(step.Filled, "foreignBookId")
+					if strings.TrimSpace(b.ForeignEditionId) != "" {
+						step.Filled = append(step.Filled, "foreignEditionId")
+					}
+				}
+			}
+			steps = append(steps, step)
+		}
+	}
+
+	for _, src := range s.enrichmentChain() {
+		if pick != nil {
+			break
+		}
+		if src == config.EnrichReadarr {
+			lookup()
+			continue
+		}
+		fn := s.chainSource(src)
+		if fn == nil {
+			continue
+		}
+		item, err := fn(ctx, h)
+		step := enrichmentStep{Source: src}
+		if err != nil {
+			step.Err = err.Error()
+		} else {
+			step.Filled = h.merge(item)
+		}
+		steps = append(steps, step)
+		if len(step.Filled) > 0 {
+			lookup()
+		}
+	}
+	if pick == nil {
+		pick = fallback
+	}
+	return pick, h, steps
+}
+
+// logEnrichment prints the chain's steps when it went past a single Readarr
+// lookup, or always with debug on.
+func (s *Server) logEnrichment(title string, steps []enrichmentStep) {
+	if len(steps) <= 1 && !s.settings.Get().Debug {
+		return
+	}
+	for _, st := range steps {
+		fmt.Printf("enrich: %q %s\n", title, st)
+	}
+}
+
+// matchNeedsReview reports whether the Readarr book resolveReadarrBook picked
+// is a guess: it came from a title/author search without an exact match, or
+// carries no foreign book id at all. Identifier lookups count as confident.
+func matchNeedsReview(steps []enrichmentStep) bool {
+	for i := len(steps) - 1; i >= 0; i-- {
+		st := steps[i]
+		if st.Source == config.EnrichReadarr && slices.Contains(st.Filled, "foreignBookId") {
+			return st.Guess
+		}
+	}
+	return true
+}
+
+// enrichmentSummary describes the steps that filled something, e.g.
+// "openlibrary: isbn13, isbn10; readarr: foreignBookId". It is empty when
+// only Readarr contributed.
+func enrichmentSummary(steps []enrichmentStep) string {
+	var parts []string
+	helped := false
+	for _, st := range steps {
+		if len(st.Filled) == 0 {
+			continue
+		}
+		if st.Source != config.EnrichReadarr {
+			helped = true
+		}
+		parts = append(parts, st.String())
+	}
+	if !helped {
+		return ""
+	}
+	return strings.Join(parts, "; ")
+}
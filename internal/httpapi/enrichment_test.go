@@ -0,0 +1,144 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// stubEnrichmentSource replaces one chain step for the test.
+func stubEnrichmentSource(t *testing.T, name string, fn enrichmentSource) {
+	t.Helper()
+	prev := enrichmentSources[name]
+	enrichmentSources[name] = fn
+	t.Cleanup(func() { enrichmentSources[name] = prev })
+}
+
+func TestRequestCreationWalksEnrichmentChain(t *testing.T) {
+	var mu sync.Mutex
+	var terms []string
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		term := r.URL.Query().Get("term")
+		mu.Lock()
+		terms = append(terms, term)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch term {
+		case "9780000000002":
+			_, _ = w.Write([]byte(`[{"title":"Quiet Book","foreignBookId":"fb-q","foreignEditionId":"fe-q","author":{"name":"Ann Author","foreignAuthorId":"fa-ann"}}]`))
+		default:
+			// A title hit without foreign ids, as Readarr returns for
+			// metadata it cannot place.
+			_, _ = w.Write([]byte(`[{"title":"Quiet Book"}]`))
+		}
+	}))
+	defer readarr.Close()
+
+	var calls []string
+	stubEnrichmentSource(t, config.EnrichOpenLibrary, func(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+		calls = append(calls, "openlibrary")
+		return &providers.BookItem{Title: "Quiet Book"}, nil
+	})
+	stubEnrichmentSource(t, config.EnrichGoogleBooks, func(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+		calls = append(calls, "googlebooks")
+		return &providers.BookItem{Title: h.Title, ISBN13: "9780000000002"}, nil
+	})
+	stubEnrichmentSource(t, config.EnrichAudnex, func(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+		calls = append(calls, "audnex")
+		return nil, nil
+	})
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	_ = config.Save(s.cfgPath, cfg)
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+
+	body := []byte(`{"title":"Quiet Book","authors":["Ann Author"],"format":"ebook"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+
+	if strings.Join(calls, ",") != "openlibrary,googlebooks" {
+		t.Fatalf("sources called = %v", calls)
+	}
+	if strings.Join(terms, "|") != "Quiet Book Ann Author|9780000000002" {
+		t.Fatalf("readarr terms = %v", terms)
+	}
+	stored, err := s.db.GetRequest(context.Background(), created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]any
+	_ = json.Unmarshal(stored.ReadarrReq, &payload)
+	if payload["foreignBookId"] != "fb-q" || stored.ISBN13 != "9780000000002" {
+		t.Fatalf("stored isbn13=%q payload=%s", stored.ISBN13, stored.ReadarrReq)
+	}
+	events, _ := s.db.ListAuditEvents(context.Background(), 20)
+	found := false
+	for _, e := range events {
+		if e.EventType == "request.enriched" && strings.Contains(e.Details, "googlebooks: isbn13") && strings.Contains(e.Details, "readarr: foreignBookId, foreignEditionId") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("missing request.enriched audit event: %+v", events)
+	}
+}
+
+func TestEnrichmentChainHonoursConfiguredOrder(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer readarr.Close()
+	called := false
+	stubEnrichmentSource(t, config.EnrichOpenLibrary, func(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+		called = true
+		return nil, nil
+	})
+	stubEnrichmentSource(t, config.EnrichAudnex, func(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+		return &providers.BookItem{ISBN10: "0000000001"}, nil
+	})
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Requests.EnrichmentChain = []string{"readarr", "audnex"}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ra := providers.NewReadarrWithDB(providers.ReadarrInstance{BaseURL: readarr.URL, APIKey: "k"}, s.db.SQL())
+	pick, hints, steps := s.resolveReadarrBook(context.Background(), ra, bookHints{Title: "Nothing", ASIN: "B000000001"})
+	if pick != nil || called {
+		t.Fatalf("pick=%+v openlibrary called=%v", pick, called)
+	}
+	var got []string
+	for _, st := range steps {
+		got = append(got, st.String())
+	}
+	want := "readarr: nothing|readarr: nothing|audnex: isbn10|readarr: nothing"
+	if strings.Join(got, "|") != want || hints.ISBN10 != "0000000001" {
+		t.Fatalf("steps = %v, hints = %+v", got, hints)
+	}
+}
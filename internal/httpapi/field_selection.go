@@ -0,0 +1,76 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// requestFieldNames are the JSON names of a request's fields, which
+// ?fields= may pick from.
+var requestFieldNames = jsonFieldNames(reflect.TypeOf(db.Request{}))
+
+// jsonFieldNames lists the JSON names of struct type t's exported fields.
+func jsonFieldNames(t reflect.Type) []string {
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// parseFieldSelection reads a comma-separated ?fields= list against the
+// names allowed. It returns nil when the list is empty.
+func parseFieldSelection(raw string, allowed []string) ([]string, error) {
+	var out []string
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || slices.Contains(out, f) {
+			continue
+		}
+		if !slices.Contains(allowed, f) {
+			return nil, fmt.Errorf("unknown field %q; fields are %s", f, strings.Join(allowed, ", "))
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// selectFields encodes each item with only the named JSON fields. Fields
+// an item leaves out with omitempty stay out.
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	out := make([]map[string]json.RawMessage, 0, len(items))
+	for _, it := range items {
+		b, err := json.Marshal(it)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		picked := make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				picked[f] = v
+			}
+		}
+		out = append(out, picked)
+	}
+	return out, nil
+}
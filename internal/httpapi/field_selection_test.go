@@ -0,0 +1,45 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestListRequestsFieldSelection(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	if _, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Authors: []string{"Frank Herbert"}, Format: "ebook", Status: "pending", ReadarrReq: json.RawMessage(`{"foreignBookId":"234225"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	alice := makeCookie(t, s, "alice", false)
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/requests"+query, nil)
+		req.AddCookie(alice)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"title":"Dune"`) || strings.Contains(rec.Body.String(), "readarrRequest") {
+		t.Fatalf("default list = %d %s", rec.Code, rec.Body.String())
+	}
+	var rows []map[string]any
+	rec = get("?fields=id,title,status,readarrRequest")
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &rows) != nil || len(rows) != 1 {
+		t.Fatalf("selected list = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(rows[0]) != 4 || rows[0]["title"] != "Dune" || rows[0]["status"] != "pending" || rows[0]["readarrRequest"] == nil {
+		t.Fatalf("selected row = %v", rows[0])
+	}
+	if rec := get("?fields=id,secret"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"secret"`) {
+		t.Fatalf("unknown field = %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,386 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/graphql"
+)
+
+const (
+	graphqlMaxLimit      = 500
+	graphqlPollInterval  = 2 * time.Second
+	graphqlSSEHeartbeat  = 15 * time.Second
+	graphqlRequestWindow = 1000
+)
+
+func (s *Server) mountGraphQL(r chi.Router) {
+	r.Get("/api/graphql", s.requireLogin(s.apiGraphQL))
+	r.Post("/api/graphql", s.requireLogin(s.apiGraphQL))
+	r.Get("/api/graphql/schema", s.requireLogin(s.apiGraphQLSchema))
+}
+
+// graphqlSchema builds the read-only GraphQL schema. Request visibility follows
+// the same scoping as the request list: admins see everything, group admins
+// their group, and everyone else their own requests.
+func (s *Server) graphqlSchema() *graphql.Schema {
+	s.graphqlOnce.Do(func() {
+		request := &graphql.Object{Name: "Request", Description: "A book request."}
+		request.Fields = []*graphql.Field{
+			{Name: "id", Type: graphql.NonNullOf(graphql.ID), Resolve: reqField(func(r *db.Request) any { return r.ID })},
+			{Name: "title", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.Title })},
+			{Name: "authors", Type: graphql.ListOf(graphql.String), Resolve: reqField(func(r *db.Request) any { return r.Authors })},
+			{Name: "isbn10", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.ISBN10 })},
+			{Name: "isbn13", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.ISBN13 })},
+			{Name: "format", Type: graphql.String, Description: "ebook or audiobook", Resolve: reqField(func(r *db.Request) any { return r.Format })},
+			{Name: "status", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.Status })},
+			{Name: "statusReason", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.StatusReason })},
+			{Name: "externalStatus", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.ExternalStatus })},
+			{Name: "requester", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.RequesterEmail })},
+			{Name: "approver", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.ApproverEmail })},
+			{Name: "group", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.GroupName })},
+			{Name: "source", Type: graphql.String, Description: "web, api, bulk, bookmarklet, webhook, guest, or subscription", Resolve: reqField(func(r *db.Request) any { return r.Source })},
+			{Name: "coverUrl", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.CoverURL })},
+			{Name: "createdAt", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.CreatedAt })},
+			{Name: "updatedAt", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.UpdatedAt })},
+			{Name: "approvedAt", Type: graphql.String, Resolve: reqField(func(r *db.Request) any { return r.ApprovedAt })},
+			{Name: "linkedRequest", Type: request, Description: "The request for the same book in the other format, if linked.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					r := p.Source.(*db.Request)
+					if r.LinkedRequestID == 0 {
+						return nil, nil
+					}
+					return s.graphqlVisibleRequest(p.Context, r.LinkedRequestID)
+				}},
+		}
+
+		user := &graphql.Object{Name: "User", Fields: []*graphql.Field{
+			{Name: "id", Type: graphql.NonNullOf(graphql.ID), Resolve: userField(func(u *db.User) any { return u.ID })},
+			{Name: "username", Type: graphql.String, Resolve: userField(func(u *db.User) any { return u.Username })},
+			{Name: "admin", Type: graphql.Boolean, Resolve: userField(func(u *db.User) any { return u.IsAdmin })},
+			{Name: "autoApprove", Type: graphql.Boolean, Resolve: userField(func(u *db.User) any { return u.AutoApprove })},
+			{Name: "group", Type: graphql.String, Resolve: userField(func(u *db.User) any { return u.GroupName })},
+			{Name: "email", Type: graphql.String, Resolve: userField(func(u *db.User) any { return u.Email })},
+			{Name: "active", Type: graphql.Boolean, Resolve: userField(func(u *db.User) any { return !u.Disabled })},
+			{Name: "createdAt", Type: graphql.String, Resolve: userField(func(u *db.User) any { return u.Created })},
+		}}
+
+		provider := &graphql.Object{Name: "Provider", Description: "A search source or Readarr instance.", Fields: []*graphql.Field{
+			{Name: "name", Type: graphql.String},
+			{Name: "kind", Type: graphql.String, Description: "search or readarr"},
+			{Name: "enabled", Type: graphql.Boolean},
+		}}
+
+		statusCount := &graphql.Object{Name: "StatusCount", Fields: []*graphql.Field{
+			{Name: "status", Type: graphql.String},
+			{Name: "count", Type: graphql.Int},
+		}}
+		sourceCount := &graphql.Object{Name: "SourceCount", Fields: []*graphql.Field{
+			{Name: "source", Type: graphql.String, Description: "Empty for requests made before sources were recorded."},
+			{Name: "count", Type: graphql.Int},
+		}}
+		stats := &graphql.Object{Name: "Stats", Description: "Request counts visible to the caller.", Fields: []*graphql.Field{
+			{Name: "total", Type: graphql.Int},
+			{Name: "byStatus", Type: graphql.ListOf(statusCount)},
+			{Name: "bySource", Type: graphql.ListOf(sourceCount)},
+		}}
+
+		query := &graphql.Object{Name: "Query", Fields: []*graphql.Field{
+			{Name: "me", Type: user, Resolve: func(p graphql.ResolveParams) (any, error) {
+				ses := p.Context.Value(ctxUser).(*session)
+				return s.db.GetUserByUsername(p.Context, ses.Username)
+			}},
+			{Name: "requests", Type: graphql.ListOf(request), Description: "Newest first.",
+				Args: []*graphql.Arg{
+					{Name: "status", Type: graphql.String},
+					{Name: "format", Type: graphql.String},
+					{Name: "limit", Type: graphql.Int, Default: 50},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return s.graphqlRequests(p.Context, p.Args)
+				}},
+			{Name: "request", Type: request, Args: []*graphql.Arg{{Name: "id", Type: graphql.NonNullOf(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := strconv.ParseInt(p.Args["id"].(string), 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid id")
+					}
+					return s.graphqlVisibleRequest(p.Context, id)
+				}},
+			{Name: "users", Type: graphql.ListOf(user), Description: "Admins only.", Resolve: func(p graphql.ResolveParams) (any, error) {
+				if ses := p.Context.Value(ctxUser).(*session); !ses.Admin {
+					return nil, errors.New("forbidden")
+				}
+				users, err := s.db.ListUsers(p.Context)
+				if err != nil {
+					return nil, err
+				}
+				out := make([]*db.User, len(users))
+				for i := range users {
+					out[i] = &users[i]
+				}
+				return out, nil
+			}},
+			{Name: "providers", Type: graphql.ListOf(provider), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return s.graphqlProviders(), nil
+			}},
+			{Name: "stats", Type: stats, Resolve: func(p graphql.ResolveParams) (any, error) {
+				scope := s.requestScopeContext(p.Context)
+				counts, err := s.db.CountRequestsByStatus(p.Context, scope)
+				if err != nil {
+					return nil, err
+				}
+				total := 0
+				var by []any
+				for _, opt := range s.statusOptions() {
+					st := opt.Key
+					total += counts[st]
+					by = append(by, map[string]any{"status": st, "count": counts[st]})
+					delete(counts, st)
+				}
+				rest := make([]string, 0, len(counts))
+				for st := range counts {
+					rest = append(rest, st)
+				}
+				sort.Strings(rest)
+				for _, st := range rest {
+					total += counts[st]
+					by = append(by, map[string]any{"status": st, "count": counts[st]})
+				}
+				sources, err := s.requestSourceCounts(p.Context, scope)
+				if err != nil {
+					return nil, err
+				}
+				bySource := make([]any, len(sources))
+				for i, c := range sources {
+					bySource[i] = map[string]any{"source": c.Source, "count": c.Count}
+				}
+				return map[string]any{"total": total, "byStatus": by, "bySource": bySource}, nil
+			}},
+		}}
+
+		subscription := &graphql.Object{Name: "Subscription", Fields: []*graphql.Field{
+			{Name: "requestStatusChanged", Type: request,
+				Description: "Emits a request whenever its status or status reason changes. Pass id to watch a single request.",
+				Args:        []*graphql.Arg{{Name: "id", Type: graphql.ID}},
+				Subscribe: func(p graphql.ResolveParams) (<-chan any, error) {
+					var only int64
+					if v, ok := p.Args["id"].(string); ok {
+						id, err := strconv.ParseInt(v, 10, 64)
+						if err != nil {
+							return nil, fmt.Errorf("invalid id")
+						}
+						only = id
+					}
+					return s.watchRequestStatus(p.Context, only), nil
+				}},
+		}}
+
+		s.graphql = &graphql.Schema{Query: query, Subscription: subscription}
+	})
+	return s.graphql
+}
+
+func reqField(get func(*db.Request) any) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) (any, error) { return get(p.Source.(*db.Request)), nil }
+}
+
+func userField(get func(*db.User) any) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) (any, error) { return get(p.Source.(*db.User)), nil }
+}
+
+func (s *Server) graphqlRequests(ctx context.Context, args map[string]any) ([]*db.Request, error) {
+	limit := int(args["limit"].(int64))
+	if limit <= 0 || limit > graphqlMaxLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", graphqlMaxLimit)
+	}
+	status, _ := args["status"].(string)
+	format, _ := args["format"].(string)
+	items, err := s.db.ListRequestsPageScoped(ctx, s.requestScopeContext(ctx), graphqlRequestWindow)
+	if err != nil {
+		return nil, err
+	}
+	var out []*db.Request
+	for i := range items {
+		if status != "" && !strings.EqualFold(items[i].Status, status) {
+			continue
+		}
+		if format != "" && normalizeSyncKind(items[i].Format) != normalizeSyncKind(format) {
+			continue
+		}
+		out = append(out, &items[i])
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// graphqlVisibleRequest loads a request the caller may see, or nil.
+func (s *Server) graphqlVisibleRequest(ctx context.Context, id int64) (*db.Request, error) {
+	req, err := s.db.GetRequest(ctx, id)
+	if err != nil {
+		return nil, nil
+	}
+	scope := s.requestScopeContext(ctx)
+	if scope.Requester != "" && !strings.EqualFold(req.RequesterEmail, scope.Requester) {
+		return nil, nil
+	}
+	if scope.Group != "" && !strings.EqualFold(req.GroupName, scope.Group) {
+		return nil, nil
+	}
+	// The stored Readarr payloads are not part of the schema.
+	req.ReadarrReq, req.ReadarrResp = nil, nil
+	return req, nil
+}
+
+func (s *Server) graphqlProviders() []any {
+	cfg := s.settings.Get()
+	out := []any{
+		map[string]any{"name": "openlibrary", "kind": "search", "enabled": s.providerEnabled(providerOpenLibrary)},
+		map[string]any{"name": "amazon", "kind": "search", "enabled": cfg.AmazonPublic.Enabled && s.providerEnabled(providerAmazon)},
+	}
+	for _, c := range s.configuredReadarrInstances() {
+		out = append(out, map[string]any{"name": s.readarrInstanceLabel(s.toProviderInstance(c)), "kind": "readarr", "enabled": true})
+	}
+	return out
+}
+
+// watchRequestStatus polls the caller's visible requests and emits those whose
+// status changed since the previous poll. The channel closes with ctx.
+func (s *Server) watchRequestStatus(ctx context.Context, only int64) <-chan any {
+	out := make(chan any)
+	go func() {
+		defer close(out)
+		snapshot := func() map[int64]db.Request {
+			items, err := s.db.ListRequestsPageScoped(ctx, s.requestScopeContext(ctx), graphqlRequestWindow)
+			if err != nil {
+				return nil
+			}
+			m := make(map[int64]db.Request, len(items))
+			for _, it := range items {
+				if only == 0 || it.ID == only {
+					m[it.ID] = it
+				}
+			}
+			return m
+		}
+		prev := snapshot()
+		ticker := time.NewTicker(graphqlPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cur := snapshot()
+			if cur == nil {
+				continue
+			}
+			for id, it := range cur {
+				old, seen := prev[id]
+				if seen && old.Status == it.Status && old.StatusReason == it.StatusReason {
+					continue
+				}
+				if !seen && prev != nil && it.Status == "pending" && only == 0 {
+					// Newly created requests are not status changes.
+					continue
+				}
+				select {
+				case out <- &it:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = cur
+		}
+	}()
+	return out
+}
+
+// apiGraphQL serves queries as JSON and subscriptions as server-sent events.
+// Queries may be sent as a JSON POST body or as GET query parameters.
+func (s *Server) apiGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				http.Error(w, "invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	prepared, err := s.graphqlSchema().Prepare(req)
+	if err != nil {
+		writeJSON(w, &graphql.Response{Errors: []*graphql.Error{{Message: err.Error()}}}, http.StatusBadRequest)
+		return
+	}
+	if prepared.Kind() != "subscription" {
+		writeJSON(w, prepared.Execute(r.Context()), http.StatusOK)
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "subscriptions require Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	events, err := prepared.Subscribe(r.Context())
+	if err != nil {
+		writeJSON(w, &graphql.Response{Errors: []*graphql.Error{{Message: err.Error()}}}, http.StatusBadRequest)
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	heartbeat := time.NewTicker(graphqlSSEHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, _ = w.Write([]byte(": keep-alive\n\n"))
+		case resp, ok := <-events:
+			if !ok {
+				_, _ = w.Write([]byte("event: complete\ndata:\n\n"))
+				_ = rc.Flush()
+				return
+			}
+			b, _ := json.Marshal(resp)
+			_, _ = fmt.Fprintf(w, "event: next\ndata: %s\n\n", b)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// apiGraphQLSchema returns the schema in SDL for client tooling.
+func (s *Server) apiGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(s.graphqlSchema().SDL()))
+}
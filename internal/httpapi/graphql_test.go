@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestGraphQLQueriesAreScopedToCaller(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	ctx := context.Background()
+	mine, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Mine", Authors: []string{"A"}, Format: "ebook", Status: "pending"})
+	_, _ = s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "other", Title: "Theirs", Authors: []string{"B"}, Format: "audiobook", Status: "approved"})
+
+	query := func(cookie *http.Cookie, q string) map[string]any {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{"query": q})
+		req := httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(cookie)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		var out map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode %d %s: %v", rr.Code, rr.Body.String(), err)
+		}
+		return out
+	}
+
+	user := makeCookie(t, s, "user", false)
+	got := query(user, `{ requests { id title } stats { total } users { username } }`)
+	data := got["data"].(map[string]any)
+	reqs := data["requests"].([]any)
+	if len(reqs) != 1 || reqs[0].(map[string]any)["id"] != strconv.FormatInt(mine, 10) {
+		t.Fatalf("user should only see their own request: %v", reqs)
+	}
+	if keys := len(reqs[0].(map[string]any)); keys != 2 {
+		t.Fatalf("only selected fields should be returned, got %v", reqs[0])
+	}
+	if data["stats"].(map[string]any)["total"] != float64(1) {
+		t.Fatalf("stats should be scoped: %v", data["stats"])
+	}
+	if data["users"] != nil || !strings.Contains(toJSONString(got["errors"]), "forbidden") {
+		t.Fatalf("users should be admin-only: %v", got)
+	}
+
+	admin := makeCookie(t, s, "admin", true)
+	got = query(admin, `{ requests(status: "approved") { title format } }`)
+	reqs = got["data"].(map[string]any)["requests"].([]any)
+	if len(reqs) != 1 || reqs[0].(map[string]any)["title"] != "Theirs" {
+		t.Fatalf("admin status filter: %v", reqs)
+	}
+
+	got = query(admin, `{ requests { nope } }`)
+	if got["data"] != nil || !strings.Contains(toJSONString(got["errors"]), `cannot query field \"nope\"`) {
+		t.Fatalf("expected validation error, got %v", got)
+	}
+
+	deep := strings.Repeat("linkedRequest { ", 12) + "id" + strings.Repeat(" }", 12)
+	got = query(admin, `{ requests { `+deep+` } }`)
+	if got["data"] != nil || !strings.Contains(toJSONString(got["errors"]), "nested too deeply") {
+		t.Fatalf("expected depth error, got %v", got)
+	}
+}
+
+func TestGraphQLSubscriptionStreamsStatusChanges(t *testing.T) {
+	s := newServerForTest(t)
+	srv := httptest.NewServer(s.Router())
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Watched", Authors: []string{"A"}, Format: "ebook", Status: "pending"})
+
+	q := `subscription { requestStatusChanged(id: ` + strconv.FormatInt(id, 10) + `) { id status } }`
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/graphql?"+url.Values{"query": {q}}.Encode(), nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.AddCookie(makeCookie(t, s, "user", false))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, ct)
+	}
+
+	if err := s.db.UpdateRequestStatus(ctx, id, "approved", "ok", "admin", nil, nil); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "data: ") {
+			want := `{"data":{"requestStatusChanged":{"id":"` + strconv.FormatInt(id, 10) + `","status":"approved"}}}`
+			if strings.TrimPrefix(line, "data: ") != want {
+				t.Fatalf("got %s, want %s", line, want)
+			}
+			return
+		}
+	}
+	t.Fatalf("stream ended without an event: %v", sc.Err())
+}
+
+func toJSONString(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,227 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// withGroup resolves the logged-in user's household group into the request
+// context. Group membership lives in the users table rather than the session
+// cookie so reassigning a user takes effect without a re-login.
+func (s *Server) withGroup(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, _ := r.Context().Value(ctxUser).(*session)
+		if u == nil || len(s.settings.Get().Groups) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if usr, err := s.db.GetUserByUsername(r.Context(), u.Username); err == nil && usr != nil && usr.GroupName != "" {
+			r = r.WithContext(context.WithValue(r.Context(), ctxGroup, usr.GroupName))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestGroup returns the household group resolved by withGroup, or "".
+func requestGroup(r *http.Request) string {
+	g, _ := r.Context().Value(ctxGroup).(string)
+	return g
+}
+
+// isGroupAdmin reports whether username is listed as an admin of group.
+func (s *Server) isGroupAdmin(username, group string) bool {
+	g, ok := s.settings.Get().Group(group)
+	if !ok {
+		return false
+	}
+	return containsInsensitive(g.Admins, username)
+}
+
+// canAccessRequest reports whether u may view or act on req as its
+// requester, an admin of its group, or a full admin.
+func (s *Server) canAccessRequest(u *session, req *db.Request) bool {
+	if u == nil || req == nil {
+		return false
+	}
+	return u.Admin || s.isGroupAdmin(u.Username, req.GroupName) || strings.EqualFold(req.RequesterEmail, u.Username)
+}
+
+// requestScope picks which requests a user may see in list views: full admins
+// see everything, group admins see their whole group, everyone else sees only
+// their own requests.
+func (s *Server) requestScope(r *http.Request) db.RequestScope {
+	return s.requestScopeContext(r.Context())
+}
+
+// requestScopeContext is requestScope for code that only has the request
+// context, such as GraphQL resolvers.
+func (s *Server) requestScopeContext(ctx context.Context) db.RequestScope {
+	ses, _ := ctx.Value(ctxUser).(*session)
+	if ses != nil && ses.Admin {
+		return db.RequestScope{}
+	}
+	group, _ := ctx.Value(ctxGroup).(string)
+	if ses != nil && group != "" && s.isGroupAdmin(ses.Username, group) {
+		return db.RequestScope{Group: group}
+	}
+	requester := ""
+	if ses != nil {
+		requester = strings.ToLower(ses.Username)
+	}
+	return db.RequestScope{Requester: requester, Group: group}
+}
+
+// canModerateRequests reports whether the current user sees approve/decline
+// controls, either as a full admin or as the admin of their group.
+func (s *Server) canModerateRequests(r *http.Request) bool {
+	ses, _ := r.Context().Value(ctxUser).(*session)
+	if ses == nil {
+		return false
+	}
+	return ses.Admin || s.isGroupAdmin(ses.Username, requestGroup(r))
+}
+
+// requireRequestAdmin allows full admins, and group admins acting on a
+// request that belongs to their own group.
+func (s *Server) requireRequestAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, _ := r.Context().Value(ctxUser).(*session)
+		if u == nil {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if u.Admin {
+			next(w, r)
+			return
+		}
+		id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		req, err := s.db.GetRequest(r.Context(), id)
+		if err != nil || !s.canAdminRequest(r, req) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// canAdminRequest reports whether the signed-in user may act as an admin on
+// req, as requireRequestAdmin allows.
+func (s *Server) canAdminRequest(r *http.Request, req *db.Request) bool {
+	u, _ := r.Context().Value(ctxUser).(*session)
+	if u == nil || req == nil {
+		return false
+	}
+	if u.Admin {
+		return true
+	}
+	return req.GroupName != "" && strings.EqualFold(req.GroupName, requestGroup(r)) && s.isGroupAdmin(u.Username, req.GroupName)
+}
+
+// groupReadarrConfig returns the group's own Readarr instance for format when
+// one is mapped, so callers can tell group-routed requests from global ones.
+func (s *Server) groupReadarrConfig(group, format string) (config.ReadarrInstance, bool) {
+	g, ok := s.settings.Get().Group(group)
+	if !ok {
+		return config.ReadarrInstance{}, false
+	}
+	c := g.Readarr.Ebooks
+	if normalizeSyncKind(format) == "audiobook" {
+		c = g.Readarr.Audiobooks
+	}
+	if strings.TrimSpace(c.BaseURL) == "" || strings.TrimSpace(c.APIKey) == "" {
+		return config.ReadarrInstance{}, false
+	}
+	return c, true
+}
+
+// readarrInstanceForRequest picks the Readarr instance a request is sent to:
+// the requester's group mapping when present, otherwise the top-level
+// instance for the request format. Admins' requests get the instance's admin
+// quality profile when one is set. The result may be unconfigured.
+func (s *Server) readarrInstanceForRequest(req *db.Request) providers.ReadarrInstance {
+	c := s.readarrConfigForRequest(req)
+	if c.AdminQualityProfileID > 0 && s.requesterIsAdmin(req.RequesterEmail) {
+		c.DefaultQualityProfileID = c.AdminQualityProfileID
+	}
+	return s.toProviderInstance(c)
+}
+
+// requesterIsAdmin reports whether the requesting account is an admin,
+// either through the config's admin list or the user record.
+func (s *Server) requesterIsAdmin(username string) bool {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return false
+	}
+	if s.isAdminUsername(username) {
+		return true
+	}
+	u, err := s.db.GetUserByUsername(context.Background(), username)
+	return err == nil && u != nil && u.IsAdmin
+}
+
+// readarrConfigForRequest is the configuration behind readarrInstanceForRequest.
+func (s *Server) readarrConfigForRequest(req *db.Request) config.ReadarrInstance {
+	format := requestReadarrKind(req)
+	switch format {
+	case formatComic:
+		return config.ReadarrInstance{}
+	case formatMagazine:
+		if format = s.settings.Get().Magazines.ReadarrFormat(); format == "" {
+			return config.ReadarrInstance{}
+		}
+	}
+	if c, ok := s.groupReadarrConfig(req.GroupName, format); ok {
+		return c
+	}
+	if normalizeSyncKind(format) == "audiobook" {
+		return s.settings.Get().Readarr.Audiobooks
+	}
+	return s.settings.Get().Readarr.Ebooks
+}
+
+// usesGroupReadarr reports whether a request routes to a group-specific
+// Readarr. The local catalog mirror only covers the top-level instances, so
+// catalog shortcuts must be skipped for these requests.
+func (s *Server) usesGroupReadarr(req *db.Request) bool {
+	_, ok := s.groupReadarrConfig(req.GroupName, requestReadarrKind(req))
+	return ok
+}
+
+// requestReadarrKind is the format that picks a request's Readarr instance
+// and catalog: the admin's instance override when set, else its format.
+// Comics never go to Readarr and ignore the override.
+func requestReadarrKind(req *db.Request) string {
+	if req.ReadarrInstance != "" && req.Format != formatComic {
+		return req.ReadarrInstance
+	}
+	return req.Format
+}
+
+// maxPendingForGroup returns the pending-request cap for a group, falling
+// back to the global requests.max_pending_per_user.
+func (s *Server) maxPendingForGroup(group string) int {
+	cfg := s.settings.Get()
+	if g, ok := cfg.Group(group); ok && g.MaxPendingPerUser > 0 {
+		return g.MaxPendingPerUser
+	}
+	return cfg.Requests.MaxPendingPerUser
+}
+
+// formGroup reads the "group" form field and returns the canonical configured
+// group name, or "" when the value is blank or names no configured group.
+func (s *Server) formGroup(r *http.Request) string {
+	g, ok := s.settings.Get().Group(strings.TrimSpace(r.FormValue("group")))
+	if !ok {
+		return ""
+	}
+	return g.Name
+}
@@ -0,0 +1,171 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func newGroupServerForTest(t *testing.T) *Server {
+	t.Helper()
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Groups = []config.GroupConfig{{Name: "Smiths", Admins: []string{"alice"}, MaxPendingPerUser: 1}}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	ctx := context.Background()
+	for _, u := range []struct {
+		name, group string
+	}{{"alice", "smiths"}, {"bob", "smiths"}, {"carol", ""}} {
+		id, err := s.db.CreateUser(ctx, u.name, "x", false, false)
+		if err != nil {
+			t.Fatalf("create user %s: %v", u.name, err)
+		}
+		if err := s.db.SetUserGroup(ctx, id, u.group); err != nil {
+			t.Fatalf("set group %s: %v", u.name, err)
+		}
+	}
+	return s
+}
+
+func createRequestAs(t *testing.T, s *Server, h http.Handler, username, title string) (int64, int) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(createRequestBody(t, title)))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(makeCookie(t, s, username, false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	id, _ := resp["id"].(float64)
+	return int64(id), rec.Code
+}
+
+func TestGroupQuotaOverridesGlobal(t *testing.T) {
+	s := newGroupServerForTest(t)
+	h := s.Router()
+	if _, code := createRequestAs(t, s, h, "bob", "Book One"); code != http.StatusCreated {
+		t.Fatalf("first request: expected 201, got %d", code)
+	}
+	if _, code := createRequestAs(t, s, h, "bob", "Book Two"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request: expected 429, got %d", code)
+	}
+	// Users outside the group fall back to the unlimited global quota.
+	for _, title := range []string{"Other One", "Other Two"} {
+		if _, code := createRequestAs(t, s, h, "carol", title); code != http.StatusCreated {
+			t.Fatalf("carol %q: expected 201, got %d", title, code)
+		}
+	}
+}
+
+func TestGroupAdminScopeAndModeration(t *testing.T) {
+	s := newGroupServerForTest(t)
+	h := s.Router()
+	bobID, code := createRequestAs(t, s, h, "bob", "Bobs Book")
+	if code != http.StatusCreated {
+		t.Fatalf("bob create: %d", code)
+	}
+	carolID, code := createRequestAs(t, s, h, "carol", "Carols Book")
+	if code != http.StatusCreated {
+		t.Fatalf("carol create: %d", code)
+	}
+	if req, err := s.db.GetRequest(context.Background(), bobID); err != nil || req.GroupName != "smiths" {
+		t.Fatalf("expected bob's request in group smiths, got %+v err=%v", req, err)
+	}
+
+	list := func(username string) string {
+		req := httptest.NewRequest(http.MethodGet, "/ui/requests/table", nil)
+		req.AddCookie(makeCookie(t, s, username, false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	if body := list("alice"); !strings.Contains(body, "Bobs Book") || strings.Contains(body, "Carols Book") {
+		t.Fatalf("group admin should see only group requests: %s", body)
+	}
+	if body := list("bob"); !strings.Contains(body, "Bobs Book") || strings.Contains(body, "/decline") {
+		t.Fatalf("member should see own request without moderation controls")
+	}
+
+	decline := func(username string, id int64) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(id, 10)+"/decline", nil)
+		req.AddCookie(makeCookie(t, s, username, false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := decline("bob", bobID); code != http.StatusForbidden {
+		t.Fatalf("member decline: expected 403, got %d", code)
+	}
+	if code := decline("alice", carolID); code != http.StatusForbidden {
+		t.Fatalf("group admin declining outside group: expected 403, got %d", code)
+	}
+	if code := decline("alice", bobID); code >= 400 {
+		t.Fatalf("group admin declining in group: got %d", code)
+	}
+	if req, _ := s.db.GetRequest(context.Background(), bobID); req.Status != "declined" {
+		t.Fatalf("expected declined, got %q", req.Status)
+	}
+}
+
+func TestGroupReadarrRouting(t *testing.T) {
+	s := newGroupServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = "http://global:8787"
+	cfg.Readarr.Ebooks.APIKey = "g"
+	cfg.Groups[0].Readarr.Ebooks.BaseURL = "http://smiths:8787"
+	cfg.Groups[0].Readarr.Ebooks.APIKey = "s"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	h := s.Router()
+	id, code := createRequestAs(t, s, h, "bob", "Routed Book")
+	if code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+	req, err := s.db.GetRequest(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if inst := s.readarrInstanceForRequest(req); inst.BaseURL != "http://smiths:8787" || !s.usesGroupReadarr(req) {
+		t.Fatalf("expected group instance, got %q", inst.BaseURL)
+	}
+	req.GroupName = ""
+	if inst := s.readarrInstanceForRequest(req); inst.BaseURL != "http://global:8787" || s.usesGroupReadarr(req) {
+		t.Fatalf("expected global instance, got %q", inst.BaseURL)
+	}
+	if got := s.maxPendingForGroup("unknown"); got != cfg.Requests.MaxPendingPerUser {
+		t.Fatalf("unknown group quota = %d", got)
+	}
+}
+
+func TestAdminRequestsUseAdminQualityProfile(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks = config.ReadarrInstance{BaseURL: "http://readarr.local", APIKey: "k", DefaultQualityProfileID: 1, AdminQualityProfileID: 4}
+	cfg.Admins.Usernames = []string{"root"}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "carol", "x", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.CreateUser(ctx, "dave", "x", false, false); err != nil {
+		t.Fatal(err)
+	}
+	for user, want := range map[string]int{"root": 4, "carol": 4, "dave": 1, "": 1} {
+		if got := s.readarrInstanceForRequest(&db.Request{RequesterEmail: user, Format: "ebook"}).DefaultQualityProfileID; got != want {
+			t.Errorf("%q: quality profile = %d, want %d", user, got, want)
+		}
+	}
+}
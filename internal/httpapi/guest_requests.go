@@ -0,0 +1,315 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"gitea.knapp/jacoknapp/scriptorum/internal/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxPendingGuestRequests caps the guest queue so a leaked link can't fill
+// the database; the form refuses new requests until admins catch up.
+const maxPendingGuestRequests = 200
+
+// guestDecidedShown is how many converted and rejected guest requests the
+// review page lists below the pending ones.
+const guestDecidedShown = 20
+
+// guestForm is what a guest filled in, kept to re-fill the form when it is
+// refused.
+type guestForm struct {
+	Name, Email, Title, Author, ISBN, Format, Note string
+}
+
+// guestFieldLimits bound each guest form field, in characters.
+var guestFieldLimits = []struct {
+	label string
+	value func(*guestForm) string
+	max   int
+}{
+	{"Name", func(f *guestForm) string { return f.Name }, 100},
+	{"Email", func(f *guestForm) string { return f.Email }, 200},
+	{"Title", func(f *guestForm) string { return f.Title }, 300},
+	{"Author", func(f *guestForm) string { return f.Author }, 200},
+	{"ISBN", func(f *guestForm) string { return f.ISBN }, 40},
+	{"Note", func(f *guestForm) string { return f.Note }, 1000},
+}
+
+// parseGuestForm reads and checks the guest request form. The returned
+// request has the ISBN or ASIN normalized.
+func parseGuestForm(r *http.Request) (guestForm, *db.GuestRequest, error) {
+	f := guestForm{
+		Name:   strings.TrimSpace(r.FormValue("name")),
+		Email:  strings.TrimSpace(r.FormValue("email")),
+		Title:  strings.TrimSpace(r.FormValue("title")),
+		Author: strings.TrimSpace(r.FormValue("author")),
+		ISBN:   strings.TrimSpace(r.FormValue("isbn")),
+		Format: bulkFormat(r.FormValue("format")),
+		Note:   strings.TrimSpace(r.FormValue("note")),
+	}
+	if f.Name == "" {
+		return f, nil, errors.New("please tell us your name")
+	}
+	if f.Title == "" {
+		return f, nil, errors.New("please enter the book's title")
+	}
+	for _, l := range guestFieldLimits {
+		if utf8.RuneCountInString(l.value(&f)) > l.max {
+			return f, nil, fmt.Errorf("%s is too long (at most %d characters)", l.label, l.max)
+		}
+	}
+	if f.Email != "" {
+		if a, err := mail.ParseAddress(f.Email); err != nil || a.Address != f.Email {
+			return f, nil, errors.New("that email address doesn't look right")
+		}
+	}
+	g := &db.GuestRequest{Name: f.Name, Email: f.Email, Title: f.Title, Author: f.Author, Format: f.Format, Note: f.Note}
+	if f.ISBN != "" {
+		ref, ok := providers.ParseBookIdentifier(f.ISBN)
+		if !ok || (ref.Source != "isbn" && ref.Source != "asin") {
+			return f, nil, errors.New("ISBN is not a valid ISBN or ASIN")
+		}
+		g.ISBN = ref.ISBN13
+		if g.ISBN == "" {
+			g.ISBN = ref.ISBN10
+		}
+		if g.ISBN == "" {
+			g.ISBN = ref.ASIN
+		}
+	}
+	return f, g, nil
+}
+
+// guestLinkOK reports whether token opens the guest form. The form does not
+// exist while guest requests are off.
+func (s *Server) guestLinkOK(token string) bool {
+	gr := s.settings.Get().GuestRequests
+	want := strings.TrimSpace(gr.Token)
+	return gr.Enabled && want != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+// mountGuest adds the guest request form. It sits outside the login group;
+// the token in the link is the only credential.
+func (s *Server) mountGuest(r chi.Router) {
+	funcMap := template.FuncMap{
+		"toJSON":        func(v any) string { b, _ := json.Marshal(v); return string(b) },
+		"csrfToken":     func(r *http.Request) string { return s.getCSRFToken(r) },
+		"authorsText":   authorsText,
+		"truncateChars": truncateChars,
+		"brand":         s.branding,
+		"asset":         assetURL,
+	}
+	tpl := template.Must(template.New("tpl").Funcs(funcMap).ParseFS(tplFS, "web/templates/*.html"))
+	r.Get("/guest/{token}", s.handleGuestForm(tpl))
+	r.Post("/guest/{token}", s.handleGuestSubmit(tpl))
+}
+
+func (s *Server) renderGuestForm(tpl *template.Template, w http.ResponseWriter, r *http.Request, f guestForm, msg string, status int) {
+	if f.Format == "" {
+		f.Format = "ebook"
+	}
+	data := map[string]any{
+		"Token":     chi.URLParam(r, "token"),
+		"CSRFToken": s.getCSRFToken(r),
+		"Form":      f,
+		"Error":     msg,
+		"Sent":      r.URL.Query().Get("sent") == "1",
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = tpl.ExecuteTemplate(w, "guest.html", data)
+}
+
+// handleGuestForm shows the guest request form, or a thank-you note after a
+// submission.
+func (s *Server) handleGuestForm(tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.guestLinkOK(chi.URLParam(r, "token")) {
+			http.NotFound(w, r)
+			return
+		}
+		s.renderGuestForm(tpl, w, r, guestForm{}, "", http.StatusOK)
+	}
+}
+
+// handleGuestSubmit files a guest request for admins to review.
+func (s *Server) handleGuestSubmit(tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := chi.URLParam(r, "token")
+		if !s.guestLinkOK(token) {
+			http.NotFound(w, r)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, inboundWebhookBodyLimit)
+		f, g, err := parseGuestForm(r)
+		if err != nil {
+			s.renderGuestForm(tpl, w, r, f, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if n, err := s.db.CountPendingGuestRequests(r.Context()); err != nil || n >= maxPendingGuestRequests {
+			s.renderGuestForm(tpl, w, r, f, "We can't take more guest requests right now. Please try again later.", http.StatusServiceUnavailable)
+			return
+		}
+		id, err := s.db.CreateGuestRequest(r.Context(), g)
+		if err != nil {
+			s.renderGuestForm(tpl, w, r, f, "Something went wrong saving your request. Please try again.", http.StatusInternalServerError)
+			return
+		}
+		s.auditLog(r.Context(), "guest:"+g.Name, "guest.requested", nil, fmt.Sprintf("#%d %q (%s) from %s", id, g.Title, g.Format, guestContact(g)))
+		http.Redirect(w, r, "/guest/"+url.PathEscape(token)+"?sent=1", http.StatusSeeOther)
+	}
+}
+
+// guestContact is a guest's name with their email when they left one.
+func guestContact(g *db.GuestRequest) string {
+	if g.Email == "" {
+		return g.Name
+	}
+	return g.Name + " <" + g.Email + ">"
+}
+
+// guestQueueData adds what the requests page needs for its guest queue
+// button: shown to admins while guest requests are on, with the pending
+// count.
+func (s *Server) guestQueueData(ctx context.Context, data map[string]any) {
+	if !s.settings.Get().GuestRequests.Enabled {
+		return
+	}
+	data["GuestQueue"] = true
+	data["GuestPending"], _ = s.db.CountPendingGuestRequests(ctx)
+}
+
+// handleGuestRequests lists the guest queue for admins to convert or
+// reject.
+func (u *ui) handleGuestRequests(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pending, _ := s.db.ListGuestRequests(r.Context(), db.GuestPending, maxPendingGuestRequests)
+		recent, _ := s.db.ListGuestRequests(r.Context(), "", guestDecidedShown+len(pending))
+		decided := recent[:0]
+		for _, g := range recent {
+			if g.Status != db.GuestPending && len(decided) < guestDecidedShown {
+				decided = append(decided, g)
+			}
+		}
+		cfg := s.settings.Get().GuestRequests
+		data := map[string]any{
+			"UserName":  s.userName(r),
+			"IsAdmin":   true,
+			"CSRFToken": s.getCSRFToken(r),
+			"Pending":   pending,
+			"Decided":   decided,
+			"Users":     s.activeUsers(r.Context()),
+			"Me":        r.Context().Value(ctxUser).(*session).Username,
+			"Enabled":   cfg.Enabled,
+			"Error":     r.URL.Query().Get("error"),
+		}
+		if cfg.Enabled && cfg.Token != "" {
+			data["Link"] = strings.TrimRight(s.settings.Get().ServerURL, "/") + "/guest/" + url.PathEscape(cfg.Token)
+		}
+		_ = u.tpl.ExecuteTemplate(w, "guest_requests.html", data)
+	}
+}
+
+// pendingGuestRequest loads the guest request named in the URL and checks it
+// still awaits review, redirecting back to the queue with an error when not.
+func (s *Server) pendingGuestRequest(w http.ResponseWriter, r *http.Request) *db.GuestRequest {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	g, err := s.db.GetGuestRequest(r.Context(), id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		guestQueueError(w, r, "guest request not found")
+	case err != nil:
+		guestQueueError(w, r, err.Error())
+	case g.Status != db.GuestPending:
+		guestQueueError(w, r, "guest request was already "+g.Status)
+	default:
+		return g
+	}
+	return nil
+}
+
+func guestQueueError(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, "/requests/guests?error="+url.QueryEscape(msg), http.StatusFound)
+}
+
+// handleGuestConvert turns a guest request into a real request filed for
+// the chosen user, as if they had made it: their group, limits, and
+// auto-approval apply.
+func (s *Server) handleGuestConvert(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	g := s.pendingGuestRequest(w, r)
+	if g == nil {
+		return
+	}
+	actor := r.Context().Value(ctxUser).(*session).Username
+	requester := strings.TrimSpace(r.FormValue("requester"))
+	if requester == "" {
+		requester = actor
+	}
+	usr, err := s.db.GetUserByUsername(r.Context(), requester)
+	if err != nil || usr == nil || usr.Disabled {
+		guestQueueError(w, r, "unknown requester "+requester)
+		return
+	}
+	format := g.Format
+	if f := r.FormValue("format"); f != "" {
+		format = bulkFormat(f)
+	}
+	row := bulkRow{Line: 1, Input: g.Title, Title: g.Title}
+	if g.Author != "" {
+		row.Authors = []string{g.Author}
+	}
+	if ref, ok := providers.ParseBookIdentifier(g.ISBN); ok {
+		row.ISBN10, row.ISBN13, row.ASIN = ref.ISBN10, ref.ISBN13, ref.ASIN
+	}
+
+	ctx := context.WithValue(r.Context(), ctxUser, &session{Username: usr.Username, Name: usr.Username, Admin: usr.IsAdmin})
+	if usr.GroupName != "" && len(s.settings.Get().Groups) > 0 {
+		ctx = context.WithValue(ctx, ctxGroup, usr.GroupName)
+	}
+	res := s.createBulkRows(withRequestSource(r.WithContext(ctx), sourceGuest), format, []bulkRow{row}, nil)[0]
+	if res.Status != "created" {
+		guestQueueError(w, r, "could not create the request: "+util.FirstNonEmpty(res.Message, res.Status))
+		return
+	}
+	if err := s.db.DecideGuestRequest(r.Context(), g.ID, db.GuestConverted, actor, res.RequestID); err != nil {
+		guestQueueError(w, r, err.Error())
+		return
+	}
+	details := "from guest " + guestContact(g)
+	if g.Note != "" {
+		details += ": " + g.Note
+	}
+	s.auditLog(r.Context(), actor, "guest.converted", &res.RequestID, details)
+	http.Redirect(w, r, "/requests/guests", http.StatusFound)
+}
+
+// handleGuestReject turns a guest request down.
+func (s *Server) handleGuestReject(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	g := s.pendingGuestRequest(w, r)
+	if g == nil {
+		return
+	}
+	actor := r.Context().Value(ctxUser).(*session).Username
+	if err := s.db.DecideGuestRequest(r.Context(), g.ID, db.GuestRejected, actor, 0); err != nil {
+		guestQueueError(w, r, err.Error())
+		return
+	}
+	s.auditLog(r.Context(), actor, "guest.rejected", nil, fmt.Sprintf("#%d %q from %s", g.ID, g.Title, guestContact(g)))
+	http.Redirect(w, r, "/requests/guests", http.StatusFound)
+}
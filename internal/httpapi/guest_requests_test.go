@@ -0,0 +1,107 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestGuestRequestLinkAndReview(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "alice", "hash", false, false); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	do := func(method, path string, form url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+		var req *http.Request
+		if form != nil {
+			req = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			req = httptest.NewRequest(method, path, nil)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/guest/secret", nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("guest link while off = %d", rec.Code)
+	}
+	cfg := *s.settings.Get()
+	cfg.GuestRequests.Enabled = true
+	cfg.GuestRequests.Token = "secret"
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(http.MethodGet, "/guest/wrong", nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("wrong token = %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/guest/secret", nil, nil); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `name="title"`) {
+		t.Fatalf("guest form = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/guest/secret", url.Values{"name": {"Ann"}, "title": {"Dune"}, "email": {"not an email"}}, nil); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Dune") {
+		t.Fatalf("bad email = %d, want 400 with the form re-filled", rec.Code)
+	}
+	rec := do(http.MethodPost, "/guest/secret", url.Values{"name": {"Ann"}, "email": {"ann@example.com"}, "title": {"Dune"}, "author": {"Frank Herbert"}, "format": {"audiobook"}, "note": {"for book club"}}, nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/guest/secret?sent=1" {
+		t.Fatalf("submit = %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := do(http.MethodPost, "/guest/secret", url.Values{"name": {"Bo"}, "title": {"Emma"}}, nil); rec.Code != http.StatusSeeOther {
+		t.Fatalf("second submit = %d", rec.Code)
+	}
+	pending, _ := s.db.ListGuestRequests(ctx, db.GuestPending, 0)
+	if len(pending) != 2 || pending[0].Format != "audiobook" || pending[1].Format != "ebook" {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if reqs, _ := s.db.ListRequests(ctx, "", 10); len(reqs) != 0 {
+		t.Fatalf("guest submissions created requests: %+v", reqs)
+	}
+
+	admin := makeCookie(t, s, "admin", true)
+	if rec := do(http.MethodGet, "/requests/guests", nil, makeCookie(t, s, "alice", false)); rec.Code == http.StatusOK {
+		t.Fatal("non-admin opened the guest queue")
+	}
+	if rec := do(http.MethodGet, "/requests", nil, admin); !strings.Contains(rec.Body.String(), "Guests (2)") {
+		t.Fatalf("requests page lacks the guest queue button: %s", rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/requests/guests", nil, admin); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "for book club") {
+		t.Fatalf("guest queue = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPost, "/requests/guests/"+itoa(pending[0].ID)+"/convert", url.Values{"requester": {"alice"}}, admin)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/requests/guests" {
+		t.Fatalf("convert = %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	g, _ := s.db.GetGuestRequest(ctx, pending[0].ID)
+	if g.Status != db.GuestConverted || g.RequestID == 0 || g.DecidedBy != "admin" {
+		t.Fatalf("converted guest request = %+v", g)
+	}
+	req, err := s.db.GetRequest(ctx, g.RequestID)
+	if err != nil || req.RequesterEmail != "alice" || req.Title != "Dune" || req.Format != "audiobook" {
+		t.Fatalf("created request = %+v, %v", req, err)
+	}
+	rec = do(http.MethodPost, "/requests/guests/"+itoa(pending[0].ID)+"/reject", nil, admin)
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=") {
+		t.Fatalf("rejecting a converted guest request redirected to %q", loc)
+	}
+
+	if rec := do(http.MethodPost, "/requests/guests/"+itoa(pending[1].ID)+"/reject", nil, admin); rec.Code != http.StatusFound {
+		t.Fatalf("reject = %d", rec.Code)
+	}
+	if g, _ := s.db.GetGuestRequest(ctx, pending[1].ID); g.Status != db.GuestRejected {
+		t.Fatalf("rejected guest request = %+v", g)
+	}
+	if n, _ := s.db.CountPendingGuestRequests(ctx); n != 0 {
+		t.Fatalf("pending after review = %d", n)
+	}
+}
@@ -0,0 +1,311 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// haPublishInterval is how often entity states are pushed to Home Assistant
+// when nothing has changed in between.
+const haPublishInterval = time.Minute
+
+// haMaxListedRequests caps the pending requests listed in the pending
+// sensor's attributes.
+const haMaxListedRequests = 10
+
+// haActor is recorded as the approver or decliner of requests handled
+// through Home Assistant.
+const haActor = "homeassistant"
+
+var haPrefixPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// haEntityPrefix is the configured entity prefix as a valid object id.
+func (s *Server) haEntityPrefix() string {
+	p := strings.ToLower(strings.TrimSpace(s.settings.Get().HomeAssistant.EntityPrefix))
+	p = strings.Trim(haPrefixPattern.ReplaceAllString(p, "_"), "_")
+	if p == "" {
+		return "scriptorum"
+	}
+	return p
+}
+
+// haEntity is one Home Assistant entity state.
+type haEntity struct {
+	EntityID   string         `json:"entity_id"`
+	State      string         `json:"state"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// homeAssistantEntities describes Scriptorum's current state as three
+// entities: the pending request count, the last approval, and whether the
+// database and every Readarr instance are healthy.
+func (s *Server) homeAssistantEntities(ctx context.Context) []haEntity {
+	prefix := s.haEntityPrefix()
+
+	pending, _ := s.db.ListRequestsByStatus(ctx, "pending", 0)
+	listed := make([]map[string]any, 0, haMaxListedRequests)
+	for i, r := range pending {
+		if i == haMaxListedRequests {
+			break
+		}
+		listed = append(listed, map[string]any{"id": r.ID, "title": r.Title, "requester": r.RequesterEmail, "format": r.Format})
+	}
+	out := []haEntity{{
+		EntityID: "sensor." + prefix + "_pending_requests",
+		State:    strconv.Itoa(len(pending)),
+		Attributes: map[string]any{
+			"friendly_name":       "Scriptorum pending requests",
+			"unit_of_measurement": "requests",
+			"state_class":         "measurement",
+			"icon":                "mdi:book-clock",
+			"requests":            listed,
+		},
+	}}
+
+	last := haEntity{
+		EntityID: "sensor." + prefix + "_last_approval",
+		State:    "none",
+		Attributes: map[string]any{
+			"friendly_name": "Scriptorum last approval",
+			"icon":          "mdi:book-check",
+		},
+	}
+	if req, err := s.db.LastApprovedRequest(ctx); err == nil {
+		// Home Assistant rejects states longer than 255 characters.
+		last.State = truncateRunes(req.Title, 255)
+		last.Attributes["request_id"] = req.ID
+		last.Attributes["authors"] = strings.Join(req.Authors, ", ")
+		last.Attributes["requester"] = req.RequesterEmail
+		last.Attributes["approved_by"] = req.ApproverEmail
+		if req.ApprovedAt != nil {
+			last.Attributes["approved_at"] = req.ApprovedAt.UTC().Format(time.RFC3339)
+		}
+	}
+	out = append(out, last)
+
+	var problems []string
+	pctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	if err := s.db.Ping(pctx); err != nil {
+		problems = append(problems, "database unavailable")
+	}
+	cancel()
+	for _, c := range s.configuredReadarrInstances() {
+		inst := s.toProviderInstance(c)
+		label := s.readarrInstanceLabel(inst)
+		if s.readarrAuthPaused(inst) {
+			problems = append(problems, "Readarr "+label+" rejected the API key")
+			continue
+		}
+		s.readarrHealthMu.Lock()
+		st := s.readarrHealth[readarrHealthAlertKey(inst)]
+		if st != nil && st.unhealthy {
+			problems = append(problems, "Readarr "+label+" unreachable: "+st.lastErr)
+		}
+		s.readarrHealthMu.Unlock()
+	}
+	healthy := "on"
+	if len(problems) > 0 {
+		healthy = "off"
+	}
+	out = append(out, haEntity{
+		EntityID: "binary_sensor." + prefix + "_healthy",
+		State:    healthy,
+		Attributes: map[string]any{
+			"friendly_name": "Scriptorum healthy",
+			"device_class":  "connectivity",
+			"problems":      problems,
+			"version":       Version,
+		},
+	})
+	return out
+}
+
+// truncateRunes shortens s to at most n characters.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// publishHomeAssistant pushes every entity state to Home Assistant's REST
+// API. It does nothing unless the integration is enabled with a URL and a
+// long-lived access token.
+func (s *Server) publishHomeAssistant(ctx context.Context) error {
+	ha := s.settings.Get().HomeAssistant
+	base := strings.TrimRight(strings.TrimSpace(ha.URL), "/")
+	if !ha.Enabled || base == "" || strings.TrimSpace(ha.Token) == "" {
+		return nil
+	}
+	client := s.outboundHTTPClient(10 * time.Second)
+	for _, e := range s.homeAssistantEntities(ctx) {
+		body, err := json.Marshal(map[string]any{"state": e.State, "attributes": e.Attributes})
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", e.EntityID, err)
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", base+"/api/states/"+e.EntityID, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create Home Assistant request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(ha.Token))
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to publish %s: %w", e.EntityID, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("home assistant returned error for %s: %d", e.EntityID, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// kickHomeAssistant asks the publish loop to push states now, e.g. after a
+// request was created or approved. Kicks while one is pending are merged.
+func (s *Server) kickHomeAssistant() {
+	select {
+	case s.haKick <- struct{}{}:
+	default:
+	}
+}
+
+// runHomeAssistantLoop publishes states every haPublishInterval and whenever
+// kicked, until ctx is cancelled.
+func (s *Server) runHomeAssistantLoop(ctx context.Context) {
+	ticker := time.NewTicker(haPublishInterval)
+	defer ticker.Stop()
+	var lastErr string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.haKick:
+		}
+		msg := ""
+		if err := s.publishHomeAssistant(ctx); err != nil {
+			msg = err.Error()
+			// Log a failure once rather than every minute while HA is down.
+			if msg != lastErr {
+				fmt.Printf("homeassistant: %v\n", err)
+			}
+		}
+		lastErr = msg
+	}
+}
+
+// requireHomeAssistant admits calls carrying the configured API token as a
+// bearer token. The endpoints do not exist while the integration is off.
+func (s *Server) requireHomeAssistant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ha := s.settings.Get().HomeAssistant
+		want := strings.TrimSpace(ha.APIToken)
+		if !ha.Enabled || want == "" {
+			http.NotFound(w, r)
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// mountHomeAssistant adds the endpoints Home Assistant calls. They sit
+// outside the login group and authenticate with the API token instead.
+func (s *Server) mountHomeAssistant(r chi.Router) {
+	r.Route("/api/homeassistant", func(rt chi.Router) {
+		rt.Get("/state", s.requireHomeAssistant(s.apiHomeAssistantState))
+		rt.Post("/requests/{id}/approve", s.requireHomeAssistant(s.apiHomeAssistantApprove))
+		rt.Post("/requests/{id}/decline", s.requireHomeAssistant(s.apiHomeAssistantDecline))
+	})
+}
+
+// apiHomeAssistantState returns the entity states, keyed by entity id, for
+// Home Assistant REST sensors that poll instead of being pushed to.
+func (s *Server) apiHomeAssistantState(w http.ResponseWriter, r *http.Request) {
+	out := map[string]haEntity{}
+	for _, e := range s.homeAssistantEntities(r.Context()) {
+		out[e.EntityID] = e
+	}
+	writeJSON(w, out, http.StatusOK)
+}
+
+// apiHomeAssistantApprove approves a pending request from a Home Assistant
+// rest_command, as an admin would from the request list.
+func (s *Server) apiHomeAssistantApprove(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if req.Status != "pending" {
+		http.Error(w, "request is "+req.Status, http.StatusConflict)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	status, err := s.approveRequest(r.Context(), req, haActor, "via Home Assistant, ", addPolicyOverride{})
+	if errors.Is(err, errSameApprover) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.kickHomeAssistant()
+	writeJSON(w, map[string]any{"id": id, "status": status}, http.StatusOK)
+}
+
+// apiHomeAssistantDecline declines a pending request. An optional JSON
+// {"reason": "..."} body is kept as the decline reason.
+func (s *Server) apiHomeAssistantDecline(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if req.Status != "pending" {
+		http.Error(w, "request is "+req.Status, http.StatusConflict)
+		return
+	}
+	var in struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&in)
+	reason := strings.TrimSpace(in.Reason)
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	if err := s.db.DeclineRequest(r.Context(), id, haActor, reason); err != nil {
+		http.Error(w, "failed to decline request", http.StatusInternalServerError)
+		return
+	}
+	s.auditLog(r.Context(), haActor, "request.declined", &id, reason)
+	s.releaseRequestAuthor(id, haActor)
+	s.kickHomeAssistant()
+	writeJSON(w, map[string]any{"id": id, "status": "declined"}, http.StatusOK)
+}
@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func enableHomeAssistant(t *testing.T, s *Server, url, token string) {
+	t.Helper()
+	cfg := s.settings.Get()
+	cfg.HomeAssistant.Enabled = true
+	cfg.HomeAssistant.URL = url
+	cfg.HomeAssistant.Token = token
+	cfg.HomeAssistant.EntityPrefix = "Books Shelf"
+	cfg.HomeAssistant.APIToken = "ha-secret"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublishHomeAssistantPushesEntities(t *testing.T) {
+	var mu sync.Mutex
+	states := map[string]map[string]any{}
+	ha := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer llat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		states[strings.TrimPrefix(r.URL.Path, "/api/states/")] = body
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ha.Close()
+
+	s := newServerForTest(t)
+	ctx := context.Background()
+	enableHomeAssistant(t, s, ha.URL+"/", "llat")
+	_, _ = s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Waiting", Format: "ebook", Status: "pending"})
+	done, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "bob", Title: "Done", Format: "ebook", Status: "pending"})
+	_ = s.db.ApproveRequest(ctx, done, "admin")
+
+	if err := s.publishHomeAssistant(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := states["sensor.books_shelf_pending_requests"]; got == nil || got["state"] != "1" {
+		t.Fatalf("pending sensor = %v", got)
+	}
+	if got := states["sensor.books_shelf_last_approval"]; got == nil || got["state"] != "Done" {
+		t.Fatalf("last approval sensor = %v", got)
+	}
+	if got := states["binary_sensor.books_shelf_healthy"]; got == nil || got["state"] != "on" {
+		t.Fatalf("health sensor = %v", got)
+	}
+
+	enableHomeAssistant(t, s, ha.URL, "wrong")
+	if err := s.publishHomeAssistant(ctx); err == nil {
+		t.Fatal("expected an error when Home Assistant rejects the token")
+	}
+}
+
+func TestHomeAssistantServiceCalls(t *testing.T) {
+	s := newServerForTest(t)
+	s.disableCSRF = false
+	h := s.Router()
+	ctx := context.Background()
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	other, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Emma", Format: "ebook", Status: "pending"})
+
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"reason": "not now"}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	approve := "/api/homeassistant/requests/" + strconv.FormatInt(id, 10) + "/approve"
+
+	if rec := call("POST", approve, "ha-secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled: code %d", rec.Code)
+	}
+	enableHomeAssistant(t, s, "", "")
+	if rec := call("POST", approve, "nope"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token: code %d", rec.Code)
+	}
+	if rec := call("POST", approve, "ha-secret"); rec.Code != http.StatusOK {
+		t.Fatalf("approve: code %d body %s", rec.Code, rec.Body.String())
+	}
+	if req, _ := s.db.GetRequest(ctx, id); req.Status != "approved" || req.ApproverEmail != haActor {
+		t.Fatalf("after approve: status %q approver %q", req.Status, req.ApproverEmail)
+	}
+	if rec := call("POST", approve, "ha-secret"); rec.Code != http.StatusConflict {
+		t.Fatalf("second approve: code %d", rec.Code)
+	}
+
+	if rec := call("POST", "/api/homeassistant/requests/"+strconv.FormatInt(other, 10)+"/decline", "ha-secret"); rec.Code != http.StatusOK {
+		t.Fatalf("decline: code %d body %s", rec.Code, rec.Body.String())
+	}
+	if req, _ := s.db.GetRequest(ctx, other); req.Status != "declined" {
+		t.Fatalf("after decline: status %q", req.Status)
+	}
+
+	rec := call("GET", "/api/homeassistant/state", "ha-secret")
+	var state map[string]haEntity
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state["sensor.books_shelf_pending_requests"].State != "0" || state["sensor.books_shelf_last_approval"].State != "Dune" {
+		t.Fatalf("state = %+v", state)
+	}
+}
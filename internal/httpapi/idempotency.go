@@ -0,0 +1,143 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	idempotencyKeyMaxLen = 255
+	idempotencyKeyTTL    = 24 * time.Hour
+	// idempotencyBodyLimit bounds how much of a request body is buffered to
+	// fingerprint it; request payloads are small JSON or form posts.
+	idempotencyBodyLimit = 1 << 20
+)
+
+// replayedHeaders are the response headers stored with an idempotency key and
+// sent again on replay.
+var replayedHeaders = []string{"Content-Type", "Location", "HX-Trigger"}
+
+// withIdempotencyKey makes next safe to retry when the client sends an
+// Idempotency-Key header. The first response for a key is stored per user and
+// replayed for later requests with the same key and body; a different body
+// gets 422 and a retry while the first request is still running gets 409.
+// Server errors release the key so the client can try again.
+func (s *Server) withIdempotencyKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > idempotencyKeyMaxLen {
+			http.Error(w, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, idempotencyKeyMaxLen), http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyBodyLimit+1))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > idempotencyBodyLimit {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) == 0 && r.PostForm != nil {
+			// The CSRF check may already have consumed a form body.
+			body = []byte(r.PostForm.Encode())
+		}
+
+		username := r.Context().Value(ctxUser).(*session).Username
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		rec, err := s.db.ReserveIdempotencyKey(r.Context(), username, key, fingerprint)
+		if err != nil {
+			http.Error(w, "db: "+err.Error(), 500)
+			return
+		}
+		if rec != nil && time.Since(rec.CreatedAt) > idempotencyKeyTTL {
+			// Expired but not yet pruned: treat the key as new.
+			_ = s.db.ReleaseIdempotencyKey(r.Context(), username, key)
+			if rec, err = s.db.ReserveIdempotencyKey(r.Context(), username, key, fingerprint); err != nil {
+				http.Error(w, "db: "+err.Error(), 500)
+				return
+			}
+		}
+		if rec != nil {
+			switch {
+			case rec.StatusCode == 0:
+				http.Error(w, "a request with this "+idempotencyKeyHeader+" is still being processed", http.StatusConflict)
+			case rec.Fingerprint != fingerprint:
+				http.Error(w, idempotencyKeyHeader+" was already used with a different request", http.StatusUnprocessableEntity)
+			default:
+				for k, v := range rec.Headers {
+					w.Header().Set(k, v)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(rec.StatusCode)
+				_, _ = w.Write(rec.Body)
+			}
+			return
+		}
+
+		cw := &capturingWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			// Use a fresh context: the client may have gone away, which is
+			// exactly when the stored response matters.
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if cw.status >= 500 {
+				_ = s.db.ReleaseIdempotencyKey(ctx, username, key)
+				return
+			}
+			headers := make(map[string]string, len(replayedHeaders))
+			for _, h := range replayedHeaders {
+				if v := w.Header().Get(h); v != "" {
+					headers[h] = v
+				}
+			}
+			_ = s.db.CompleteIdempotencyKey(ctx, username, key, cw.status, headers, cw.body.Bytes())
+		}()
+		next(cw, r)
+	}
+}
+
+// capturingWriter passes a response through while keeping a copy of the
+// status and body.
+type capturingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (c *capturingWriter) WriteHeader(code int) {
+	if !c.wroteHeader {
+		c.status = code
+		c.wroteHeader = true
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *capturingWriter) Write(b []byte) (int, error) {
+	c.wroteHeader = true
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// pruneIdempotencyKeys drops stored responses older than idempotencyKeyTTL.
+func (s *Server) pruneIdempotencyKeys(ctx context.Context) {
+	if _, err := s.db.PruneIdempotencyKeys(ctx, time.Now().Add(-idempotencyKeyTTL)); err != nil {
+		fmt.Printf("idempotency: prune failed: %v\n", err)
+	}
+}
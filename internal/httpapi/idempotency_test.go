@@ -0,0 +1,61 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIdempotencyKeyReplaysCreateRequest(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	user := makeCookie(t, s, "user", false)
+
+	post := func(key string, body []byte, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		req.AddCookie(cookie)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	body := createRequestBody(t, "Double Tap")
+	first := post("tap-1", body, user)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first: %d %s", first.Code, first.Body.String())
+	}
+	again := post("tap-1", body, user)
+	if again.Code != http.StatusCreated || again.Body.String() != first.Body.String() {
+		t.Fatalf("expected replayed response, got %d %s", again.Code, again.Body.String())
+	}
+	if again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay should be marked")
+	}
+	items, _ := s.db.ListRequests(context.Background(), "user", 10)
+	if len(items) != 1 {
+		t.Fatalf("expected one request row, got %d", len(items))
+	}
+
+	if rr := post("tap-1", createRequestBody(t, "Something Else"), user); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with a different body: expected 422, got %d", rr.Code)
+	}
+
+	// Keys are scoped per user.
+	if rr := post("tap-1", body, makeCookie(t, s, "other", false)); rr.Code != http.StatusCreated || rr.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("other user should create their own request, got %d", rr.Code)
+	}
+
+	// An in-flight reservation is reported as a conflict.
+	if _, err := s.db.ReserveIdempotencyKey(context.Background(), "user", "tap-2", "pending"); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if rr := post("tap-2", body, user); rr.Code != http.StatusConflict {
+		t.Fatalf("expected in-flight key to be refused, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,240 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// importListProfileID is the one quality profile the Readarr-compatible
+// import list reports, so Readarr's profile filter has something to offer.
+const importListProfileID = 1
+
+// importListItem is one approved request in the plain JSON import list, in
+// the spirit of Radarr's StevenLu lists: a flat array that scripts and other
+// tools can read without speaking the Readarr API.
+type importListItem struct {
+	Title            string `json:"title"`
+	Author           string `json:"author,omitempty"`
+	ISBN             string `json:"isbn,omitempty"`
+	Format           string `json:"format"`
+	ForeignBookID    string `json:"foreignBookId,omitempty"`
+	ForeignEditionID string `json:"foreignEditionId,omitempty"`
+	ForeignAuthorID  string `json:"foreignAuthorId,omitempty"`
+	CoverURL         string `json:"coverUrl,omitempty"`
+	RequestID        int64  `json:"requestId"`
+	ApprovedAt       string `json:"approvedAt,omitempty"`
+}
+
+// importListAuthor and importListBook are the parts of Readarr's author and
+// book resources a Readarr import list pointed at another instance reads.
+type importListAuthor struct {
+	ID               int64  `json:"id"`
+	AuthorName       string `json:"authorName"`
+	ForeignAuthorID  string `json:"foreignAuthorId"`
+	Monitored        bool   `json:"monitored"`
+	QualityProfileID int    `json:"qualityProfileId"`
+	Tags             []int  `json:"tags"`
+}
+
+type importListEdition struct {
+	Title            string `json:"title"`
+	ForeignEditionID string `json:"foreignEditionId"`
+	Monitored        bool   `json:"monitored"`
+}
+
+type importListBook struct {
+	ID               int64               `json:"id"`
+	Title            string              `json:"title"`
+	ForeignBookID    string              `json:"foreignBookId"`
+	ForeignEditionID string              `json:"foreignEditionId"`
+	Monitored        bool                `json:"monitored"`
+	AuthorID         int64               `json:"authorId"`
+	Editions         []importListEdition `json:"editions"`
+}
+
+// requireImportList admits calls carrying the import list token, as the
+// X-Api-Key header Readarr sends or an apikey query parameter for tools
+// that only take a URL. The endpoints do not exist while the list is off.
+func (s *Server) requireImportList(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		il := s.settings.Get().ImportList
+		want := strings.TrimSpace(il.Token)
+		if !il.Enabled || want == "" {
+			http.NotFound(w, r)
+			return
+		}
+		got := r.Header.Get("X-Api-Key")
+		if got == "" {
+			got = r.URL.Query().Get("apikey")
+		}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch chi.URLParam(r, "format") {
+		case "ebook", "audiobook", "all":
+			next(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// mountImportList adds the import list endpoints. They sit outside the login
+// group and authenticate with the import list token instead. Each format has
+// its own base URL, /importlist/{ebook,audiobook,all}, which a Readarr
+// "Readarr" import list takes as the instance URL.
+func (s *Server) mountImportList(r chi.Router) {
+	r.Route("/importlist/{format}", func(rt chi.Router) {
+		rt.Get("/list.json", s.requireImportList(s.apiImportList))
+		rt.Get("/api/v1/book", s.requireImportList(s.apiImportListBooks))
+		rt.Get("/api/v1/author", s.requireImportList(s.apiImportListAuthors))
+		rt.Get("/api/v1/qualityprofile", s.requireImportList(s.apiImportListProfiles))
+		rt.Get("/api/v1/tag", s.requireImportList(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, []any{}, http.StatusOK)
+		}))
+	})
+}
+
+// importListItems lists the approved requests in the format named in the
+// URL, oldest first.
+func (s *Server) importListItems(r *http.Request) ([]importListItem, error) {
+	reqs, err := s.db.ListRequestsByStatus(r.Context(), "approved", 0)
+	if err != nil {
+		return nil, err
+	}
+	format := chi.URLParam(r, "format")
+	out := []importListItem{}
+	for i := range reqs {
+		req := &reqs[i]
+		if format != "all" && req.Format != format {
+			continue
+		}
+		out = append(out, newImportListItem(req))
+	}
+	return out, nil
+}
+
+func newImportListItem(req *db.Request) importListItem {
+	var sel struct {
+		ForeignBookID    string `json:"foreignBookId"`
+		ForeignEditionID string `json:"foreignEditionId"`
+		Author           struct {
+			ForeignAuthorID string `json:"foreignAuthorId"`
+			AuthorName      string `json:"authorName"`
+		} `json:"author"`
+	}
+	if len(req.ReadarrReq) > 0 {
+		_ = json.Unmarshal(req.ReadarrReq, &sel)
+	}
+	it := importListItem{
+		Title:            req.Title,
+		ISBN:             util.FirstNonEmpty(req.ISBN13, req.ISBN10),
+		Format:           req.Format,
+		ForeignBookID:    util.FirstNonEmpty(sel.ForeignBookID, req.ForeignBookID),
+		ForeignEditionID: sel.ForeignEditionID,
+		ForeignAuthorID:  sel.Author.ForeignAuthorID,
+		CoverURL:         req.CoverURL,
+		RequestID:        req.ID,
+	}
+	if len(req.Authors) > 0 {
+		it.Author = req.Authors[0]
+	} else {
+		it.Author = sel.Author.AuthorName
+	}
+	if req.ApprovedAt != nil {
+		it.ApprovedAt = req.ApprovedAt.UTC().Format(time.RFC3339)
+	}
+	return it
+}
+
+// apiImportList serves the plain JSON import list.
+func (s *Server) apiImportList(w http.ResponseWriter, r *http.Request) {
+	items, err := s.importListItems(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, items, http.StatusOK)
+}
+
+// importListAuthorIDs numbers the distinct authors of items, by name
+// ignoring case, in the order they first appear. An author's Readarr id is
+// taken from the first of their books that has one.
+func importListAuthorIDs(items []importListItem) (map[string]int64, []importListAuthor) {
+	ids := map[string]int64{}
+	var authors []importListAuthor
+	for _, it := range items {
+		key := strings.ToLower(strings.TrimSpace(it.Author))
+		if id, ok := ids[key]; ok {
+			if a := &authors[id-1]; a.ForeignAuthorID == "" {
+				a.ForeignAuthorID = it.ForeignAuthorID
+			}
+			continue
+		}
+		ids[key] = int64(len(authors) + 1)
+		authors = append(authors, importListAuthor{
+			ID:               ids[key],
+			AuthorName:       it.Author,
+			ForeignAuthorID:  it.ForeignAuthorID,
+			Monitored:        true,
+			QualityProfileID: importListProfileID,
+			Tags:             []int{},
+		})
+	}
+	return ids, authors
+}
+
+// apiImportListBooks serves approved requests as Readarr book resources.
+// Each has exactly one monitored edition, which Readarr requires; its id is
+// empty when the request was never matched to an edition, and Readarr then
+// looks the book up by title and author.
+func (s *Server) apiImportListBooks(w http.ResponseWriter, r *http.Request) {
+	items, err := s.importListItems(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ids, _ := importListAuthorIDs(items)
+	books := make([]importListBook, 0, len(items))
+	for _, it := range items {
+		books = append(books, importListBook{
+			ID:               it.RequestID,
+			Title:            it.Title,
+			ForeignBookID:    it.ForeignBookID,
+			ForeignEditionID: it.ForeignEditionID,
+			Monitored:        true,
+			AuthorID:         ids[strings.ToLower(strings.TrimSpace(it.Author))],
+			Editions:         []importListEdition{{Title: it.Title, ForeignEditionID: it.ForeignEditionID, Monitored: true}},
+		})
+	}
+	writeJSON(w, books, http.StatusOK)
+}
+
+// apiImportListAuthors serves the authors of the approved requests as
+// Readarr author resources.
+func (s *Server) apiImportListAuthors(w http.ResponseWriter, r *http.Request) {
+	items, err := s.importListItems(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, authors := importListAuthorIDs(items)
+	if authors == nil {
+		authors = []importListAuthor{}
+	}
+	writeJSON(w, authors, http.StatusOK)
+}
+
+// apiImportListProfiles reports the single quality profile every author
+// has.
+func (s *Server) apiImportListProfiles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, []map[string]any{{"id": importListProfileID, "name": "Scriptorum"}}, http.StatusOK)
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestImportListServesApprovedRequests(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	add := func(title, author, format string, payload string, approve bool) {
+		id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: title, Authors: []string{author}, ISBN13: "9780441013593", Format: format, Status: "pending", ReadarrReq: json.RawMessage(payload)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if approve {
+			if err := s.db.ApproveRequest(ctx, id, "admin"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	add("Dune", "Frank Herbert", "ebook", `{"foreignBookId":"234225","foreignEditionId":"44767458","author":{"foreignAuthorId":"58","authorName":"Frank Herbert"}}`, true)
+	add("Dune Messiah", "frank herbert", "ebook", ``, true)
+	add("Emma", "Jane Austen", "audiobook", ``, true)
+	add("Persuasion", "Jane Austen", "ebook", ``, false)
+
+	h := s.Router()
+	get := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := get("/importlist/ebook/api/v1/book", "k"); rec.Code != http.StatusNotFound {
+		t.Fatalf("import list while off = %d", rec.Code)
+	}
+	cfg := *s.settings.Get()
+	cfg.ImportList.Enabled = true
+	cfg.ImportList.Token = "k"
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if rec := get("/importlist/ebook/api/v1/book", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong key = %d", rec.Code)
+	}
+	if rec := get("/importlist/paperback/api/v1/book", "k"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown format = %d", rec.Code)
+	}
+
+	var books []importListBook
+	if rec := get("/importlist/ebook/api/v1/book", "k"); rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &books) != nil {
+		t.Fatalf("books = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(books) != 2 || books[0].ForeignBookID != "234225" || books[1].Title != "Dune Messiah" {
+		t.Fatalf("books = %+v", books)
+	}
+	if len(books[1].Editions) != 1 || !books[1].Editions[0].Monitored || books[0].AuthorID != books[1].AuthorID {
+		t.Fatalf("books need one monitored edition and a shared author: %+v", books)
+	}
+	var authors []importListAuthor
+	_ = json.Unmarshal(get("/importlist/ebook/api/v1/author", "k").Body.Bytes(), &authors)
+	if len(authors) != 1 || authors[0].ID != books[0].AuthorID || authors[0].ForeignAuthorID != "58" || !authors[0].Monitored || authors[0].QualityProfileID != importListProfileID {
+		t.Fatalf("authors = %+v", authors)
+	}
+
+	var items []importListItem
+	rec := get("/importlist/all/list.json?apikey=k", "")
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &items) != nil || len(items) != 3 {
+		t.Fatalf("plain list = %d %s", rec.Code, rec.Body.String())
+	}
+	if items[2].Title != "Emma" || items[2].Format != "audiobook" || items[2].ISBN != "9780441013593" || items[2].ApprovedAt == "" {
+		t.Fatalf("plain list item = %+v", items[2])
+	}
+}
@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"github.com/go-chi/chi/v5"
+)
+
+// inboundWebhookBodyLimit bounds an inbound webhook call; payloads are a
+// handful of short fields.
+const inboundWebhookBodyLimit = 64 << 10
+
+// inboundRequest is the JSON an external tool posts to create a request.
+// ISBN also takes an ASIN. Requester names an existing user and defaults to
+// inbound_webhook.default_requester.
+type inboundRequest struct {
+	Title     string            `json:"title"`
+	Author    string            `json:"author"`
+	ISBN      string            `json:"isbn"`
+	Format    string            `json:"format"`
+	Requester string            `json:"requester"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+// requireInboundWebhook admits calls carrying the inbound webhook token as a
+// bearer token. The endpoint does not exist while the webhook is off.
+func (s *Server) requireInboundWebhook(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wh := s.settings.Get().InboundWebhook
+		want := strings.TrimSpace(wh.Token)
+		if !wh.Enabled || want == "" {
+			http.NotFound(w, r)
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// mountInboundWebhook adds the request intake endpoint. It sits outside the
+// login group and authenticates with the webhook token instead.
+func (s *Server) mountInboundWebhook(r chi.Router) {
+	r.Post("/api/v1/webhooks/inbound", s.requireInboundWebhook(s.apiInboundWebhook))
+}
+
+// apiInboundWebhook creates a request for the named requester as if they
+// had submitted it themselves: their group, pending-request limit, and
+// auto-approval apply, and an Idempotency-Key header makes retries safe.
+// With a secret configured, calls must also carry a valid signature.
+func (s *Server) apiInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, inboundWebhookBodyLimit+1))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > inboundWebhookBodyLimit {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if wh := s.settings.Get().InboundWebhook; strings.TrimSpace(wh.Secret) != "" {
+		tolerance := time.Duration(wh.SignatureToleranceSeconds) * time.Second
+		if err := verifyWebhookSignature(wh.Secret, tolerance, r.Header, body, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	var in inboundRequest
+	if err := json.Unmarshal(body, &in); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	p := RequestPayload{
+		Title:  strings.TrimSpace(in.Title),
+		Format: strings.TrimSpace(in.Format),
+		Extra:  in.Extra,
+	}
+	if a := strings.TrimSpace(in.Author); a != "" {
+		p.Authors = []string{a}
+	}
+	if v := strings.TrimSpace(in.ISBN); v != "" {
+		ref, ok := providers.ParseBookIdentifier(v)
+		if !ok || (ref.Source != "isbn" && ref.Source != "asin") {
+			http.Error(w, "isbn is not a valid ISBN or ASIN", http.StatusBadRequest)
+			return
+		}
+		p.ISBN10, p.ISBN13, p.ASIN = ref.ISBN10, ref.ISBN13, ref.ASIN
+	}
+
+	requester := strings.TrimSpace(in.Requester)
+	if requester == "" {
+		requester = strings.TrimSpace(s.settings.Get().InboundWebhook.DefaultRequester)
+	}
+	if requester == "" {
+		http.Error(w, "requester required", http.StatusBadRequest)
+		return
+	}
+	usr, err := s.db.GetUserByUsername(r.Context(), requester)
+	if err != nil || usr == nil || usr.Disabled {
+		http.Error(w, "unknown requester", http.StatusUnprocessableEntity)
+		return
+	}
+	ctx := context.WithValue(r.Context(), ctxUser, &session{Username: usr.Username, Name: usr.Username, Admin: usr.IsAdmin})
+	if usr.GroupName != "" && len(s.settings.Get().Groups) > 0 {
+		ctx = context.WithValue(ctx, ctxGroup, usr.GroupName)
+	}
+	r = withRequestSource(r.WithContext(ctx), sourceWebhook)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	s.withIdempotencyKey(func(w http.ResponseWriter, r *http.Request) {
+		s.createRequest(w, r, p)
+	})(w, r)
+}
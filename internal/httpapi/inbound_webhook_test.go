@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInboundWebhookCreatesRequest(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "alice", "hash", false, false); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	post := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/inbound", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	book := `{"title":"Dune","author":"Frank Herbert","isbn":"0-441-17271-7","format":"audiobook"}`
+
+	if rec := post("secret", book); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled webhook: %d", rec.Code)
+	}
+	cfg := s.settings.Get()
+	cfg.InboundWebhook.Enabled = true
+	cfg.InboundWebhook.Token = "secret"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if rec := post("wrong", book); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", rec.Code)
+	}
+	if rec := post("secret", book); rec.Code != http.StatusBadRequest {
+		t.Fatalf("no requester: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post("secret", `{"title":"Dune","requester":"nobody"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("unknown requester: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post("secret", `{"title":"Dune","isbn":"12345","requester":"alice"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad isbn: %d %s", rec.Code, rec.Body.String())
+	}
+
+	cfg = s.settings.Get()
+	cfg.InboundWebhook.DefaultRequester = "alice"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	rec := post("secret", book)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || out.Status != "pending" {
+		t.Fatalf("response %s: %v", rec.Body.String(), err)
+	}
+	got, err := s.db.GetRequest(ctx, out.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RequesterEmail != "alice" || got.Format != "audiobook" || got.ISBN13 != "9780441172719" || len(got.Authors) != 1 || got.Authors[0] != "Frank Herbert" {
+		t.Fatalf("request = %+v", got)
+	}
+}
+
+func TestInboundWebhookRequiresSignature(t *testing.T) {
+	s := newServerForTest(t)
+	if _, err := s.db.CreateUser(context.Background(), "alice", "hash", false, false); err != nil {
+		t.Fatal(err)
+	}
+	cfg := s.settings.Get()
+	cfg.InboundWebhook.Enabled = true
+	cfg.InboundWebhook.Token = "token"
+	cfg.InboundWebhook.Secret = "shh"
+	cfg.InboundWebhook.DefaultRequester = "alice"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	body := `{"title":"Dune","author":"Frank Herbert"}`
+	post := func(ts, sig string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/inbound", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer token")
+		if ts != "" {
+			req.Header.Set(webhookTimestampHeader, ts)
+			req.Header.Set(webhookSignatureHeader, sig)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	if rec := post("", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned: %d", rec.Code)
+	}
+	if rec := post(now, signInboundWebhook("guess", now, []byte(body))); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong secret: %d", rec.Code)
+	}
+	if rec := post(old, signInboundWebhook("shh", old, []byte(body))); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "window") {
+		t.Fatalf("stale: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(now, signInboundWebhook("shh", now, []byte(body))); rec.Code != http.StatusCreated {
+		t.Fatalf("signed: %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,191 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// providerQuota counts a metered provider's calls in the current UTC day.
+type providerQuota struct {
+	day       string
+	used      int
+	exhausted bool
+}
+
+// providerQuotaView is one metered provider on the provider health page.
+type providerQuotaView struct {
+	Name      string `json:"name"`
+	Label     string `json:"label"`
+	Used      int    `json:"used"`
+	Limit     int    `json:"limit"`
+	Exhausted bool   `json:"exhausted"`
+}
+
+func quotaDay() string { return time.Now().UTC().Format("2006-01-02") }
+
+// quotaLocked returns name's counter for today. Callers hold providerStatsMu.
+func (s *Server) quotaLocked(name string) *providerQuota {
+	if s.providerQuota == nil {
+		s.providerQuota = make(map[string]*providerQuota)
+	}
+	q := s.providerQuota[name]
+	if q == nil || q.day != quotaDay() {
+		q = &providerQuota{day: quotaDay()}
+		s.providerQuota[name] = q
+	}
+	return q
+}
+
+// takeProviderQuota counts one call against name's daily quota, or reports
+// false without counting when the quota is used up. limit 0 means the
+// provider's own quota is the only cap.
+func (s *Server) takeProviderQuota(name string, limit int) bool {
+	s.providerStatsMu.Lock()
+	defer s.providerStatsMu.Unlock()
+	q := s.quotaLocked(name)
+	if q.exhausted || (limit > 0 && q.used >= limit) {
+		return false
+	}
+	q.used++
+	return true
+}
+
+// exhaustProviderQuota stops calls to name until the next UTC day, for when
+// the provider says the quota is gone before our own count does.
+func (s *Server) exhaustProviderQuota(name string) {
+	s.providerStatsMu.Lock()
+	s.quotaLocked(name).exhausted = true
+	s.providerStatsMu.Unlock()
+}
+
+func (s *Server) providerQuotaViews() []providerQuotaView {
+	cfg := s.settings.Get()
+	if cfg == nil || !cfg.ISBNdb.Enabled() {
+		return nil
+	}
+	s.providerStatsMu.Lock()
+	defer s.providerStatsMu.Unlock()
+	q := s.quotaLocked(config.EnrichISBNdb)
+	return []providerQuotaView{{
+		Name:      config.EnrichISBNdb,
+		Label:     "ISBNdb",
+		Used:      q.used,
+		Limit:     cfg.ISBNdb.DailyLimit,
+		Exhausted: q.exhausted || (cfg.ISBNdb.DailyLimit > 0 && q.used >= cfg.ISBNdb.DailyLimit),
+	}}
+}
+
+var errISBNdbDailyLimit = errors.New("daily limit reached")
+
+// isbndbCall runs one ISBNdb call within the daily quota.
+func (s *Server) isbndbCall(fn func(c *providers.ISBNdb) error) error {
+	cfg := s.settings.Get().ISBNdb
+	if !cfg.Enabled() || s.offline() {
+		return nil
+	}
+	if !s.takeProviderQuota(config.EnrichISBNdb, cfg.DailyLimit) {
+		return errISBNdbDailyLimit
+	}
+	s.countProviderCall(config.EnrichISBNdb)
+	err := fn(providers.NewISBNdb(cfg.APIKey, cfg.BaseURL))
+	if errors.Is(err, providers.ErrISBNdbQuota) {
+		s.exhaustProviderQuota(config.EnrichISBNdb)
+	}
+	return err
+}
+
+// enrichFromISBNdb looks the book up by ISBN, which ISBNdb has straight from
+// publishers, or by title and author without one.
+func (s *Server) enrichFromISBNdb(ctx context.Context, h bookHints) (*providers.BookItem, error) {
+	if isbn := h.isbn(); isbn != "" {
+		var book *providers.ISBNdbBook
+		err := s.isbndbCall(func(c *providers.ISBNdb) (err error) {
+			book, err = c.Book(ctx, isbn)
+			return err
+		})
+		if err != nil || book == nil {
+			return nil, err
+		}
+		return book.BookItem(), nil
+	}
+	q := h.titleAuthor()
+	if q == "" {
+		return nil, nil
+	}
+	var books []providers.ISBNdbBook
+	err := s.isbndbCall(func(c *providers.ISBNdb) (err error) {
+		books, err = c.Search(ctx, q, 5)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]providers.BookItem, 0, len(books))
+	for _, b := range books {
+		if item := b.BookItem(); item != nil {
+			items = append(items, *item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	match := pickOpenLibraryMatch(items, h.Title, []string{h.Author})
+	return &match, nil
+}
+
+// fillFromISBNdb adds ISBNdb's publisher, list price, binding, and page
+// count to a details response that has an ISBN.
+func (s *Server) fillFromISBNdb(ctx context.Context, out map[string]any) {
+	isbn := ""
+	for _, k := range []string{"isbn13", "isbn10"} {
+		if v, _ := out[k].(string); strings.TrimSpace(v) != "" {
+			isbn = strings.TrimSpace(v)
+			break
+		}
+	}
+	if isbn == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var book *providers.ISBNdbBook
+	err := s.isbndbCall(func(c *providers.ISBNdb) (err error) {
+		book, err = c.Book(ctx, isbn)
+		return err
+	})
+	if err != nil || book == nil {
+		return
+	}
+	set := func(key, v string) {
+		if cur, _ := out[key].(string); strings.TrimSpace(cur) == "" && strings.TrimSpace(v) != "" {
+			out[key] = strings.TrimSpace(v)
+		}
+	}
+	set("publisher", book.Publisher)
+	set("price", book.Price())
+	set("binding", book.Binding)
+	set("description", book.Synopsis)
+	if _, ok := out["pageCount"]; !ok && book.Pages > 0 {
+		out["pageCount"] = book.Pages
+	}
+	if _, ok := out["releaseDate"]; !ok && book.DatePublished != "" {
+		out["releaseDate"] = book.DatePublished
+	}
+	if v, _ := out["isbn13"].(string); v == "" && book.ISBN13 != "" {
+		out["isbn13"] = book.ISBN13
+	}
+}
+
+// String is how the settings and providers pages show today's usage.
+func (v providerQuotaView) String() string {
+	if v.Limit > 0 {
+		return strconv.Itoa(v.Used) + " of " + strconv.Itoa(v.Limit) + " lookups today"
+	}
+	return strconv.Itoa(v.Used) + " lookups today"
+}
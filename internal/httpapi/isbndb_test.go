@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func newISBNdbTestServer(t *testing.T, calls *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.Header.Get("Authorization") != "isbndb-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/book/9780441013593":
+			_, _ = w.Write([]byte(`{"book":{"title":"Dune","isbn":"0441013597","isbn13":"9780441013593","authors":["Frank Herbert"],"publisher":"Ace","pages":528,"binding":"Paperback","msrp":18.99}}`))
+		case "/books/Dune Frank Herbert":
+			_, _ = w.Write([]byte(`{"books":[{"title":"Dune","isbn13":"9780441013593","authors":["Frank Herbert"]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestISBNdbEnrichmentRespectsDailyLimit(t *testing.T) {
+	var calls int32
+	srv := newISBNdbTestServer(t, &calls)
+	s := newServerForTest(t)
+	if s.chainSource(config.EnrichISBNdb) != nil {
+		t.Fatalf("isbndb should be skipped without a key")
+	}
+	cfg := s.settings.Get()
+	cfg.ISBNdb = config.ISBNdbConfig{APIKey: "isbndb-key", BaseURL: srv.URL, DailyLimit: 2}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	fn := s.chainSource(config.EnrichISBNdb)
+	if fn == nil {
+		t.Fatalf("isbndb source missing with a key")
+	}
+
+	item, err := fn(context.Background(), bookHints{Title: "Dune", Author: "Frank Herbert"})
+	if err != nil || item == nil || item.ISBN13 != "9780441013593" {
+		t.Fatalf("title search: item=%+v err=%v", item, err)
+	}
+	item, err = fn(context.Background(), bookHints{ISBN13: "9780441013593"})
+	if err != nil || item == nil || item.ISBN10 != "0441013597" {
+		t.Fatalf("isbn lookup: item=%+v err=%v", item, err)
+	}
+	if _, err := fn(context.Background(), bookHints{ISBN13: "9780441013593"}); err != errISBNdbDailyLimit {
+		t.Fatalf("expected daily limit, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("isbndb called %d times, want 2", n)
+	}
+	if q := s.providerQuotaViews(); len(q) != 1 || q[0].Used != 2 || !q[0].Exhausted || q[0].String() != "2 of 2 lookups today" {
+		t.Fatalf("quota view: %+v", q)
+	}
+}
+
+func TestBookDetailsAddsISBNdbPublisherAndPrice(t *testing.T) {
+	var calls int32
+	srv := newISBNdbTestServer(t, &calls)
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.ISBNdb = config.ISBNdbConfig{APIKey: "isbndb-key", BaseURL: srv.URL}
+	cfg.Search.DisabledProviders = []string{providerOpenLibrary}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(map[string]any{"title": "Dune", "isbn13": "9780441013593"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/book/details", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var out map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &out)
+	if out["publisher"] != "Ace" || out["price"] != "18.99" || out["binding"] != "Paperback" || out["pageCount"] != float64(528) {
+		t.Fatalf("unexpected details: %+v", out)
+	}
+}
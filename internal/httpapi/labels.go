@@ -0,0 +1,218 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	maxRequestLabels = 10
+	maxLabelLength   = 32
+)
+
+// normalizeRequestLabels trims and collapses whitespace in each label,
+// splits comma-separated entries, and drops case-insensitive duplicates.
+func normalizeRequestLabels(in []string) ([]string, error) {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, entry := range in {
+		for _, l := range strings.Split(entry, ",") {
+			l = strings.Join(strings.Fields(l), " ")
+			if l == "" || seen[strings.ToLower(l)] {
+				continue
+			}
+			if len([]rune(l)) > maxLabelLength {
+				return nil, fmt.Errorf("label %q is longer than %d characters", l, maxLabelLength)
+			}
+			seen[strings.ToLower(l)] = true
+			out = append(out, l)
+		}
+	}
+	if len(out) > maxRequestLabels {
+		return nil, fmt.Errorf("a request can have at most %d labels", maxRequestLabels)
+	}
+	return out, nil
+}
+
+// requestListScope is requestScope plus the ?status= and ?source= filters
+// and the ?label= filter, which only full admins may use since labels are
+// an admin tool.
+func (s *Server) requestListScope(r *http.Request) db.RequestScope {
+	scope := s.requestScope(r)
+	scope.Status = strings.ToLower(strings.TrimSpace(r.URL.Query().Get("status")))
+	scope.Source = strings.ToLower(strings.TrimSpace(r.URL.Query().Get("source")))
+	if ses, _ := r.Context().Value(ctxUser).(*session); ses != nil && ses.Admin {
+		scope.Label = strings.Join(strings.Fields(r.URL.Query().Get("label")), " ")
+	}
+	return scope
+}
+
+func (s *Server) apiListLabels(w http.ResponseWriter, r *http.Request) {
+	labels, err := s.db.ListLabels(r.Context())
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), 500)
+		return
+	}
+	if labels == nil {
+		labels = []db.LabelCount{}
+	}
+	writeJSON(w, labels, 200)
+}
+
+func (s *Server) apiGetRequestLabels(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if _, err := s.db.GetRequest(r.Context(), id); err != nil {
+		http.Error(w, "not found", 404)
+		return
+	}
+	labels, err := s.db.GetRequestLabels(r.Context(), id)
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), 500)
+		return
+	}
+	if labels == nil {
+		labels = []string{}
+	}
+	writeJSON(w, map[string]any{"id": id, "labels": labels}, 200)
+}
+
+// apiSetRequestLabels replaces a request's labels. It accepts JSON
+// {"labels": [...]} or a form with a comma-separated "labels" field.
+func (s *Server) apiSetRequestLabels(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", 404)
+		return
+	}
+	var raw []string
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var in struct {
+			Labels []string `json:"labels"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", 400)
+			return
+		}
+		raw = in.Labels
+	} else {
+		_ = r.ParseForm()
+		raw = r.Form["labels"]
+	}
+	labels, err := normalizeRequestLabels(raw)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	if err := s.db.SetRequestLabels(r.Context(), id, labels); err != nil {
+		http.Error(w, "failed to save labels", 500)
+		return
+	}
+	username := r.Context().Value(ctxUser).(*session).Username
+	s.auditLog(r.Context(), username, "request.labels_updated", &id, strings.Join(labels, ", "))
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{"id": id, "labels": labels}, 200)
+}
+
+// labelReadarrTags resolves req's labels to Readarr tag ids when
+// requests.labels_as_readarr_tags is on. Tag lookup failures never block an
+// approval; the book is added with its default tags instead.
+func (s *Server) labelReadarrTags(ctx context.Context, ra *providers.Readarr, req *db.Request) []int {
+	if !s.settings.Get().Requests.LabelsAsReadarrTags {
+		return nil
+	}
+	labels, err := s.db.GetRequestLabels(ctx, req.ID)
+	if err != nil || len(labels) == 0 {
+		return nil
+	}
+	ids, err := ra.EnsureTags(ctx, labels)
+	if err != nil {
+		if s.settings.Get().Debug {
+			fmt.Printf("DEBUG: Readarr tags for request %d labels %v: %v\n", req.ID, labels, err)
+		}
+		return nil
+	}
+	return ids
+}
+
+// mergeReadarrTags appends extra tag ids to base, the configured default
+// tags. base is returned unchanged when there is nothing to add.
+func mergeReadarrTags(base any, extra []int) any {
+	if len(extra) == 0 {
+		return base
+	}
+	out := readarrTagIDs(base)
+	for _, id := range extra {
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// readarrTagIDs reads tag ids from a config or payload value such as
+// []string{"1"} or a decoded JSON array.
+func readarrTagIDs(v any) []int {
+	var out []int
+	add := func(e any) {
+		switch x := e.(type) {
+		case int:
+			out = append(out, x)
+		case float64:
+			out = append(out, int(x))
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(x)); err == nil {
+				out = append(out, n)
+			}
+		}
+	}
+	switch t := v.(type) {
+	case []int:
+		out = append(out, t...)
+	case []string:
+		for _, e := range t {
+			add(e)
+		}
+	case []any:
+		for _, e := range t {
+			add(e)
+		}
+	}
+	return out
+}
+
+// withReadarrTags returns a stored full-schema add payload with extra tag
+// ids merged into its tags, or into defaults when it carries none.
+func withReadarrTags(payload json.RawMessage, defaults any, extra []int) json.RawMessage {
+	if len(extra) == 0 {
+		return payload
+	}
+	var m map[string]any
+	if json.Unmarshal(payload, &m) != nil || m == nil {
+		return payload
+	}
+	base := m["tags"]
+	if base == nil {
+		base = defaults
+	}
+	m["tags"] = mergeReadarrTags(base, extra)
+	b, err := json.Marshal(m)
+	if err != nil {
+		return payload
+	}
+	return b
+}
@@ -0,0 +1,195 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestNormalizeRequestLabels(t *testing.T) {
+	got, err := normalizeRequestLabels([]string{" Book   Club ,gift", "book club", "", "Q4"})
+	if err != nil || strings.Join(got, "|") != "Book Club|gift|Q4" {
+		t.Fatalf("labels = %q, %v", got, err)
+	}
+	if _, err := normalizeRequestLabels([]string{strings.Repeat("x", maxLabelLength+1)}); err == nil {
+		t.Fatal("expected an error for an overlong label")
+	}
+	if _, err := normalizeRequestLabels([]string{"a,b,c,d,e,f,g,h,i,j,k"}); err == nil {
+		t.Fatal("expected an error over the label limit")
+	}
+}
+
+func TestRequestLabelsAPIAndFilter(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	ctx := context.Background()
+	dune, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Dune", Format: "ebook", Status: "pending"})
+	emma, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Emma", Format: "ebook", Status: "pending"})
+	do := func(method, path, body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, map[bool]string{true: "admin", false: "user"}[admin], admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	labelsPath := "/api/v1/requests/" + strconv.FormatInt(dune, 10) + "/labels"
+
+	if rec := do(http.MethodPut, labelsPath, `{"labels":["gift"]}`, false); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin set: %d", rec.Code)
+	}
+	rec := do(http.MethodPut, labelsPath, `{"labels":["Book Club","gift"]}`, true)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("HX-Trigger"), "request:updated") {
+		t.Fatalf("set: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPut, labelsPath, `{"labels":["`+strings.Repeat("x", 40)+`"]}`, true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("overlong label: %d", rec.Code)
+	}
+	rec = do(http.MethodGet, labelsPath, "", true)
+	var got struct {
+		Labels []string `json:"labels"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &got)
+	if strings.Join(got.Labels, "|") != "Book Club|gift" {
+		t.Fatalf("get: %s", rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/api/v1/requests/999/labels", "", true); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing request: %d", rec.Code)
+	}
+
+	// Form posts from the detail page send one comma-separated field.
+	form := url.Values{"labels": {"gift"}}
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/requests/"+strconv.FormatInt(emma, 10)+"/labels", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("form set: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/api/v1/requests/labels", "", true)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `{"label":"gift","count":2}`) {
+		t.Fatalf("list labels: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/ui/requests/table?label=Book+Club", "", true)
+	if body := rec.Body.String(); !strings.Contains(body, "Dune") || strings.Contains(body, "Emma") || !strings.Contains(body, "/requests?label=gift") {
+		t.Fatalf("filtered table: %s", body)
+	}
+	// Labels are admin-only: the filter is ignored and chips hidden for users.
+	rec = do(http.MethodGet, "/ui/requests/table?label=Book+Club", "", false)
+	if body := rec.Body.String(); !strings.Contains(body, "Emma") || strings.Contains(body, "?label=") {
+		t.Fatalf("user table: %s", body)
+	}
+
+	rec = do(http.MethodGet, "/requests/"+strconv.FormatInt(dune, 10), "", true)
+	if !strings.Contains(rec.Body.String(), `value="Book Club, gift"`) {
+		t.Fatalf("detail page missing label editor: %s", rec.Body.String())
+	}
+}
+
+func TestMergeReadarrTags(t *testing.T) {
+	if got := mergeReadarrTags([]string{"1"}, nil); got.([]string)[0] != "1" {
+		t.Fatalf("defaults should pass through unchanged: %v", got)
+	}
+	if got := mergeReadarrTags([]string{"1", "x"}, []int{4, 1}); len(got.([]int)) != 2 {
+		t.Fatalf("merged = %v", got)
+	}
+	out := withReadarrTags(json.RawMessage(`{"title":"Dune","tags":[2]}`), []string{"1"}, []int{5})
+	if !strings.Contains(string(out), `"tags":[2,5]`) {
+		t.Fatalf("payload tags = %s", out)
+	}
+	out = withReadarrTags(json.RawMessage(`{"title":"Dune"}`), []string{"1"}, []int{5})
+	if !strings.Contains(string(out), `"tags":[1,5]`) {
+		t.Fatalf("payload without tags = %s", out)
+	}
+}
+
+func TestApprovalPropagatesLabelsAsReadarrTags(t *testing.T) {
+	var mu sync.Mutex
+	var bookTags []any
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/tag" && r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`[{"id":3,"label":"gift"}]`))
+		case r.URL.Path == "/api/v1/tag" && r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":7,"label":"book-club"}`))
+		case r.URL.Path == "/api/v1/book" && r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			var m map[string]any
+			_ = json.Unmarshal(body, &m)
+			mu.Lock()
+			bookTags, _ = m["tags"].([]any)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"id":101,"monitored":true,"statistics":{"bookFileCount":0}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	cfg.Requests.LabelsAsReadarrTags = true
+	_ = config.Save(s.cfgPath, cfg)
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+
+	body := []byte(`{"title":"Tagged Book","authors":["Alice"],"format":"ebook","provider_payload":"{\"title\":\"Tagged Book\",\"foreignBookId\":\"fb-tag\",\"foreignEditionId\":\"fe-tag\",\"author\":{\"name\":\"Alice\"}}"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	if rec.Code != http.StatusCreated || created.ID == 0 {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	if err := s.db.SetRequestLabels(context.Background(), created.ID, []string{"Book Club", "gift"}); err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(created.ID, 10)+"/approve", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		tags := bookTags
+		mu.Unlock()
+		if tags != nil {
+			if len(tags) != 2 || tags[0] != float64(7) || tags[1] != float64(3) {
+				t.Fatalf("book tags = %v", tags)
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for the book add")
+}
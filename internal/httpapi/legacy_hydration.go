@@ -0,0 +1,181 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	legacyHydrationStartupDelay = 3 * time.Minute
+	minLegacyHydrationInterval  = time.Hour
+	// legacyHydrationDelay spaces Readarr lookups so a large backlog does
+	// not flood Readarr or the metadata sources behind the enrichment chain.
+	legacyHydrationDelay = 2 * time.Second
+	maxLegacyHydrations  = 200
+	legacyHydrationAlert = "legacy-hydration"
+)
+
+var errHydrationInProgress = errors.New("hydration already in progress")
+
+// hydrationFailure is a request bulk hydration found no match for.
+type hydrationFailure struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Error string `json:"error"`
+}
+
+// hydrationRunView is the JSON and template shape of one bulk hydration.
+type hydrationRunView struct {
+	RanAt       time.Time          `json:"ran_at"`
+	Actor       string             `json:"actor"`
+	DurationMS  int64              `json:"duration_ms"`
+	Checked     int                `json:"checked"`
+	Hydrated    int                `json:"hydrated"`
+	NeedsReview int                `json:"needs_review"`
+	Failures    []hydrationFailure `json:"failures"`
+}
+
+// legacyHydrationInterval returns the repeat schedule: 0 runs only once after
+// startup and a negative value turns the task off.
+func (s *Server) legacyHydrationInterval() time.Duration {
+	v := strings.ToLower(strings.TrimSpace(s.settings.Get().Requests.HydrateInterval))
+	switch v {
+	case "":
+		return 0
+	case "off", "0", "false", "disabled":
+		return -1
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	if d < minLegacyHydrationInterval {
+		return minLegacyHydrationInterval
+	}
+	return d
+}
+
+// runLegacyHydrationLoop hydrates requests without a selection payload once
+// after startup, then on the configured schedule. The interval is re-read
+// after each wait so settings changes take effect without a restart.
+func (s *Server) runLegacyHydrationLoop(ctx context.Context) {
+	wait := legacyHydrationStartupDelay
+	ranOnce := false
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		interval := s.legacyHydrationInterval()
+		// Off, or startup-only and already done: check again later in
+		// case a schedule is set.
+		wait = minLegacyHydrationInterval
+		if interval < 0 || (interval == 0 && ranOnce) {
+			continue
+		}
+		if _, err := s.hydrateLegacyRequests(ctx, "system"); err != nil && !errors.Is(err, errHydrationInProgress) {
+			fmt.Printf("hydrate: scheduled run failed: %v\n", err)
+		}
+		ranOnce = true
+		if interval > 0 {
+			wait = interval
+		}
+	}
+}
+
+// hydrateLegacyRequests looks up pending and failed requests that have no
+// selection payload in Readarr, one at a time with a pause in between. The
+// run is recorded for the settings page and audited, and an admin alert
+// lists how many found no match. Only one run happens at a time.
+func (s *Server) hydrateLegacyRequests(ctx context.Context, actor string) (hydrationRunView, error) {
+	if !s.hydrationMu.TryLock() {
+		return hydrationRunView{}, errHydrationInProgress
+	}
+	defer s.hydrationMu.Unlock()
+
+	started := time.Now()
+	view := hydrationRunView{RanAt: started, Actor: actor, Failures: []hydrationFailure{}}
+	items, err := s.db.ListRequestsMissingPayload(ctx, maxLegacyHydrations)
+	if err != nil {
+		return view, err
+	}
+	for i := range items {
+		req := items[i]
+		inst := s.readarrInstanceForRequest(&req)
+		if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
+			continue
+		}
+		if view.Checked > 0 && s.hydrationDelay > 0 {
+			timer := time.NewTimer(s.hydrationDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return view, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		view.Checked++
+		needsReview, err := s.hydrateRequest(ctx, &req, actor)
+		if err != nil {
+			view.Failures = append(view.Failures, hydrationFailure{ID: req.ID, Title: req.Title, Error: err.Error()})
+			continue
+		}
+		view.Hydrated++
+		if needsReview {
+			view.NeedsReview++
+		}
+	}
+	view.DurationMS = time.Since(started).Milliseconds()
+	s.hydrationLast.Store(&view)
+
+	if view.Checked == 0 {
+		return view, nil
+	}
+	s.auditLog(ctx, actor, "requests.hydrated", nil, fmt.Sprintf("%d of %d hydrated, %d need review, %d failed", view.Hydrated, view.Checked, view.NeedsReview, len(view.Failures)))
+	if len(view.Failures) > 0 {
+		s.raiseAdminAlert(legacyHydrationAlert, "warning", "Older requests without a book",
+			fmt.Sprintf("%d of %d requests without a selected book found no Readarr match. Attach one from each request page or re-create them from Search.", len(view.Failures), view.Checked))
+	} else {
+		s.clearAdminAlert(legacyHydrationAlert)
+	}
+	return view, nil
+}
+
+// legacyHydrationSettingsView feeds the hydration card on /settings.
+func (s *Server) legacyHydrationSettingsView() map[string]any {
+	schedule := "Once after startup"
+	switch d := s.legacyHydrationInterval(); {
+	case d < 0:
+		schedule = "Off"
+	case d > 0:
+		schedule = "After startup, then every " + d.String()
+	}
+	return map[string]any{
+		"Schedule": schedule,
+		"Last":     s.hydrationLast.Load(),
+	}
+}
+
+func (s *Server) apiHydrateLegacyRequests() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Context().Value(ctxUser).(*session).Username
+		view, err := s.hydrateLegacyRequests(r.Context(), actor)
+		if errors.Is(err, errHydrationInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "hydrate failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("HX-Trigger", `{"request:updated": {}}`)
+		writeJSON(w, view, http.StatusOK)
+	}
+}
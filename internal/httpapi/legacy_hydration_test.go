@@ -0,0 +1,98 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestLegacyHydrationInterval(t *testing.T) {
+	s := newServerForTest(t)
+	cases := map[string]time.Duration{
+		"":      0,
+		"off":   -1,
+		"10m":   minLegacyHydrationInterval,
+		"6h":    6 * time.Hour,
+		"bogus": 0,
+	}
+	for v, want := range cases {
+		cfg := s.settings.Get()
+		cfg.Requests.HydrateInterval = v
+		if err := s.settings.Update(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.legacyHydrationInterval(); got != want {
+			t.Fatalf("%q: got %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestHydrateLegacyRequests(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("term") {
+		case "9780000000001":
+			_, _ = w.Write([]byte(`[{"title":"Isbn Book","foreignBookId":"fb-i","foreignEditionId":"fe-i","author":{"name":"Ida"}}]`))
+		case "Vague Book Vic":
+			_, _ = w.Write([]byte(`[{"title":"Vague Book Companion","foreignBookId":"fb-v","author":{"name":"Someone"}}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	s.hydrationDelay = 0
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "k"
+	cfg.Requests.EnrichmentChain = []string{"readarr"}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	create := func(r *db.Request) int64 {
+		r.RequesterEmail, r.Format = "user", "ebook"
+		id, err := s.db.CreateRequest(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	isbnID := create(&db.Request{Title: "Isbn Book", ISBN13: "9780000000001", Status: "pending"})
+	vagueID := create(&db.Request{Title: "Vague Book", Authors: []string{"Vic"}, Status: "error"})
+	missingID := create(&db.Request{Title: "Nowhere", Status: "pending"})
+	create(&db.Request{Title: "Done", Status: "approved"})
+
+	view, err := s.hydrateLegacyRequests(ctx, "system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Checked != 3 || view.Hydrated != 2 || view.NeedsReview != 1 || len(view.Failures) != 1 || view.Failures[0].ID != missingID {
+		t.Fatalf("run: %+v", view)
+	}
+	if got, _ := s.db.GetRequest(ctx, isbnID); len(got.ReadarrReq) == 0 || got.NeedsReview {
+		t.Fatalf("isbn request: review=%v %s", got.NeedsReview, got.ReadarrReq)
+	}
+	if got, _ := s.db.GetRequest(ctx, vagueID); !got.NeedsReview || got.Status != "error" {
+		t.Fatalf("vague request: review=%v status=%s", got.NeedsReview, got.Status)
+	}
+	if alerts := s.listAdminAlerts(); len(alerts) != 1 || alerts[0].Key != legacyHydrationAlert {
+		t.Fatalf("alerts: %+v", alerts)
+	}
+
+	// A manual run only revisits what is still missing.
+	req := httptest.NewRequest(http.MethodPost, "/api/requests/hydrate-missing", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	var again hydrationRunView
+	if err := json.Unmarshal(rec.Body.Bytes(), &again); err != nil || rec.Code != http.StatusOK || again.Checked != 1 || again.Actor != "admin" {
+		t.Fatalf("manual run: %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// itemLink is a deep link to an available book on a library server.
+type itemLink struct {
+	URL   string
+	Label string
+}
+
+// libraryLookupDelays are the waits before looking an item up again when
+// the library has not picked up the new file yet.
+var libraryLookupDelays = []time.Duration{time.Minute, 3 * time.Minute}
+
+func libraryLinkLabel(kind string) string {
+	switch kind {
+	case providers.LibraryAudiobookshelf:
+		return "🎧 Listen in Audiobookshelf"
+	case providers.LibraryKavita:
+		return "📖 Read in Kavita"
+	default:
+		return "📖 Open in Calibre-Web"
+	}
+}
+
+// libraryItemLink finds req's item on the library server configured for its
+// Readarr instance. It returns a zero link when none is configured or the
+// item cannot be found.
+func (s *Server) libraryItemLink(ctx context.Context, req *db.Request) itemLink {
+	lc := s.readarrConfigForRequest(req).Library
+	kind := providers.NormalizeLibraryKind(lc.Kind)
+	if kind == "" || strings.TrimSpace(lc.URL) == "" {
+		return itemLink{}
+	}
+	if it, ok := s.cachedLibraryItem(ctx, lc, req.Format, req.Title, req.Authors); ok {
+		return itemLink{URL: libraryPublicBase(lc) + it.Path, Label: libraryLinkLabel(kind)}
+	}
+	lib := providers.NewLibrary(providers.LibraryServer{
+		Kind:      kind,
+		BaseURL:   lc.URL,
+		PublicURL: lc.PublicURL,
+		APIKey:    lc.APIKey,
+		Username:  lc.Username,
+		Password:  lc.Password,
+		LibraryID: lc.LibraryID,
+	}, s.outboundHTTPClient(10*time.Second))
+	for attempt := 0; ; attempt++ {
+		u, err := lib.FindItemURL(ctx, req.Title, req.Authors)
+		if err == nil {
+			return itemLink{URL: u, Label: libraryLinkLabel(kind)}
+		}
+		if !errors.Is(err, providers.ErrLibraryItemNotFound) || attempt >= len(libraryLookupDelays) {
+			fmt.Printf("library: no %s link for request %d: %v\n", kind, req.ID, err)
+			return itemLink{}
+		}
+		select {
+		case <-ctx.Done():
+			return itemLink{}
+		case <-time.After(libraryLookupDelays[attempt]):
+		}
+	}
+}
+
+// notifyAvailable announces req as available to its requester and watchers,
+// linking to the item in the library when one is configured. The lookup may
+// wait for the library to scan the new file, so it runs in the background.
+func (s *Server) notifyAvailable(req db.Request) {
+	go func() {
+		link := s.libraryItemLink(context.Background(), &req)
+		s.SendAvailableNotification(req.RequesterEmail, req.Title, req.Authors, link)
+		s.notifyWatchers("available", req, link)
+	}()
+}
+
+// libraryConfigFromForm reads the library fields of a Readarr instance from
+// the settings form. Blank secrets keep the saved ones while a server URL is
+// set.
+func libraryConfigFromForm(r *http.Request, prefix string, cur config.LibraryConfig) config.LibraryConfig {
+	base := strings.TrimSpace(r.FormValue(prefix + "_lib_url"))
+	return config.LibraryConfig{
+		Kind:      providers.NormalizeLibraryKind(r.FormValue(prefix + "_lib_kind")),
+		URL:       base,
+		PublicURL: strings.TrimSpace(r.FormValue(prefix + "_lib_public_url")),
+		APIKey:    preserveSecretField(cur.APIKey, base, r.FormValue(prefix+"_lib_key")),
+		Username:  strings.TrimSpace(r.FormValue(prefix + "_lib_user")),
+		Password:  preserveSecretField(cur.Password, base, r.FormValue(prefix+"_lib_pass")),
+		LibraryID: strings.TrimSpace(r.FormValue(prefix + "_lib_id")),
+		Sync:      r.FormValue(prefix+"_lib_sync") == "on",
+	}
+}
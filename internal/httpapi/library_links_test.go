@@ -0,0 +1,62 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestAvailableNotificationLinksLibraryItem(t *testing.T) {
+	old := libraryLookupDelays
+	libraryLookupDelays = []time.Duration{10 * time.Millisecond}
+	defer func() { libraryLookupDelays = old }()
+
+	searches := 0
+	abs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		if searches == 1 {
+			// Not scanned yet on the first lookup.
+			w.Write([]byte(`{"book":[]}`))
+			return
+		}
+		w.Write([]byte(`{"book":[{"libraryItem":{"id":"li-1","media":{"metadata":{"title":"Dune","authorName":"Frank Herbert"}}}}]}`))
+	}))
+	defer abs.Close()
+
+	events := make(chan map[string]any, 2)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		events <- payload
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Audiobooks.Library = config.LibraryConfig{Kind: "audiobookshelf", URL: abs.URL, PublicURL: "https://abs.example", LibraryID: "lib1"}
+	cfg.Notifications.Webhook = config.WebhookConfig{Enabled: true, URL: hook.URL, EnableAvailableNotifications: true}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	s.notifyAvailable(db.Request{ID: 1, RequesterEmail: "alice", Title: "Dune", Authors: []string{"Frank Herbert"}, Format: "audiobook"})
+	select {
+	case ev := <-events:
+		if ev["event"] != "request.available" || ev["link"] != "https://abs.example/item/li-1" {
+			t.Fatalf("payload = %v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no available webhook arrived")
+	}
+
+	// The eBook instance has no library, so no lookup and no link.
+	if got := s.libraryItemLink(context.Background(), &db.Request{Title: "Dune", Format: "ebook"}); got.URL != "" {
+		t.Fatalf("ebook link = %+v", got)
+	}
+}
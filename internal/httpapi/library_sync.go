@@ -0,0 +1,85 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// libraryServerKey identifies the server a cached library item came from,
+// so a changed or group-specific library never reuses another's items.
+func libraryServerKey(lc config.LibraryConfig) string {
+	return strings.TrimRight(strings.TrimSpace(lc.URL), "/")
+}
+
+// libraryPublicBase is the address item paths are appended to in links.
+func libraryPublicBase(lc config.LibraryConfig) string {
+	if pub := strings.TrimRight(strings.TrimSpace(lc.PublicURL), "/"); pub != "" {
+		return pub
+	}
+	return libraryServerKey(lc)
+}
+
+// syncLibraryItems refreshes the local copy of the Kavita library attached
+// to kind's Readarr instance and returns how many items it holds. The cache
+// is emptied when sync is off so stale books stop showing as available. A
+// failing library is logged rather than failing the Readarr sync.
+func (s *Server) syncLibraryItems(ctx context.Context, kind string) int {
+	lc := s.readarrConfigForRequest(&db.Request{Format: kind}).Library
+	if !lc.Sync || providers.NormalizeLibraryKind(lc.Kind) != providers.LibraryKavita || libraryServerKey(lc) == "" {
+		if err := s.db.ClearLibraryItems(ctx, kind); err != nil {
+			fmt.Printf("library: clear %s cache: %v\n", kind, err)
+		}
+		return 0
+	}
+	libraryID, _ := strconv.Atoi(strings.TrimSpace(lc.LibraryID))
+	series, err := providers.NewKavita(lc.URL, lc.APIKey, s.outboundHTTPClient(30*time.Second)).ListSeries(ctx, libraryID)
+	if err != nil {
+		fmt.Printf("library: kavita %s sync failed: %v\n", kind, err)
+		return 0
+	}
+	items := make([]db.LibraryItem, 0, len(series))
+	for _, sr := range series {
+		items = append(items, db.LibraryItem{
+			SourceKind: kind,
+			Server:     libraryServerKey(lc),
+			ItemID:     strconv.Itoa(sr.ID),
+			Title:      sr.Name,
+			Path:       sr.Path(),
+		})
+		// A renamed series is still found under its file's title.
+		if orig := strings.TrimSpace(sr.OriginalName); orig != "" && db.LibraryTitleKey(orig) != db.LibraryTitleKey(sr.Name) {
+			items = append(items, db.LibraryItem{
+				SourceKind: kind,
+				Server:     libraryServerKey(lc),
+				ItemID:     strconv.Itoa(sr.ID),
+				Title:      orig,
+				Path:       sr.Path(),
+			})
+		}
+	}
+	if err := s.db.ReplaceLibraryItems(ctx, kind, items); err != nil {
+		fmt.Printf("library: store %s items: %v\n", kind, err)
+		return 0
+	}
+	return len(series)
+}
+
+// cachedLibraryItem returns the synced item matching title when lc is the
+// library that was synced.
+func (s *Server) cachedLibraryItem(ctx context.Context, lc config.LibraryConfig, kind, title string, authors []string) (*db.LibraryItem, bool) {
+	if !lc.Sync || libraryServerKey(lc) == "" {
+		return nil, false
+	}
+	it, err := s.db.FindLibraryItem(ctx, normalizeSyncKind(kind), title, authors)
+	if err != nil || it.Server != libraryServerKey(lc) {
+		return nil, false
+	}
+	return it, true
+}
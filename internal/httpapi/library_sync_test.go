@@ -0,0 +1,59 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestKavitaSyncFeedsBadgesAndLinks(t *testing.T) {
+	kavita := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/Plugin/authenticate":
+			w.Write([]byte(`{"token":"jwt"}`))
+		case "/api/Series/all-v2":
+			w.Write([]byte(`[{"id":7,"libraryId":2,"name":"The Left Hand of Darkness"},{"id":8,"libraryId":3,"name":"Comics"}]`))
+		default:
+			// Synced items must resolve without a live search.
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer kavita.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.Library = config.LibraryConfig{Kind: "kavita", URL: kavita.URL, PublicURL: "https://read.example/", APIKey: "kv-key", LibraryID: "2", Sync: true}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if n := s.syncLibraryItems(ctx, "ebook"); n != 1 {
+		t.Fatalf("synced %d series", n)
+	}
+	if got := s.loadCatalogState("ebook", "The Left Hand of Darkness", []string{"Ursula K. Le Guin"}, "", "", "", ""); got != "available" {
+		t.Fatalf("ebook state = %q", got)
+	}
+	if got := s.loadCatalogState("audiobook", "The Left Hand of Darkness", nil, "", "", "", ""); got != "" {
+		t.Fatalf("audiobook state = %q", got)
+	}
+	link := s.libraryItemLink(ctx, &db.Request{Title: "The left hand of darkness", Format: "ebook"})
+	if link.URL != "https://read.example/library/2/series/7" {
+		t.Fatalf("link = %+v", link)
+	}
+
+	// Turning sync off drops the cached books.
+	cfg.Readarr.Ebooks.Library.Sync = false
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	s.syncLibraryItems(ctx, "ebook")
+	if n, _ := s.db.CountLibraryItems(ctx, "ebook"); n != 0 {
+		t.Fatalf("%d items left after disabling sync", n)
+	}
+}
@@ -0,0 +1,324 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// linkFormatCounterpart links a freshly created pending request with a pending
+// request for the same book in the other format, if one exists, and returns
+// the counterpart's ID (0 when nothing was linked).
+func (s *Server) linkFormatCounterpart(ctx context.Context, req *db.Request) int64 {
+	if req == nil || req.ID == 0 || req.Status != "pending" || !hasOtherFormat(req.Format) {
+		return 0
+	}
+	other, err := s.db.FindFormatCounterpart(ctx, req)
+	if err != nil || other == nil {
+		return 0
+	}
+	if err := s.db.LinkRequests(ctx, req.ID, other.ID); err != nil {
+		return 0
+	}
+	req.LinkedRequestID = other.ID
+	return other.ID
+}
+
+// apiApproveLinkedRequests approves a request together with its linked
+// request in the other format, sending each to its own Readarr instance.
+// A linked request that is no longer pending is left untouched.
+func (s *Server) apiApproveLinkedRequests(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", 404)
+		return
+	}
+	if req.Status != "pending" {
+		http.Error(w, "only pending requests can be approved", 400)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	reqs := []*db.Request{req}
+	if req.LinkedRequestID > 0 {
+		if other, err := s.db.GetRequest(r.Context(), req.LinkedRequestID); err == nil && other.Status == "pending" {
+			reqs = append(reqs, other)
+		}
+	}
+	s.approveRequestGroup(w, r, reqs, "combined approval, ")
+}
+
+// apiApproveBothFormats approves a pending book request in both formats at
+// once: its pending linked request in the other format is approved with it,
+// or, without one, a linked copy carrying the same selection is created
+// first. Each request goes to its own Readarr instance and keeps its own
+// status.
+func (s *Server) apiApproveBothFormats(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", 404)
+		return
+	}
+	if req.Status != "pending" {
+		http.Error(w, "only pending requests can be approved", 400)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	if !hasOtherFormat(req.Format) {
+		http.Error(w, "only books have another format", http.StatusBadRequest)
+		return
+	}
+	username := r.Context().Value(ctxUser).(*session).Username
+	other, err := s.formatCounterpartForApproval(r.Context(), req, username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	s.approveRequestGroup(w, r, []*db.Request{req, other}, "both formats, ")
+}
+
+// formatCounterpartForApproval returns req's pending linked request in the
+// other format, creating and linking one when req has none. The copy keeps
+// req's requester, group, answers, and selection, minus the ids that only
+// mean something on req's Readarr instance.
+func (s *Server) formatCounterpartForApproval(ctx context.Context, req *db.Request, actor string) (*db.Request, error) {
+	if req.ReadarrInstance != "" && req.ReadarrInstance != normalizeSyncKind(req.Format) {
+		return nil, fmt.Errorf("request #%d is already sent to the %s instance", req.ID, req.ReadarrInstance)
+	}
+	if req.LinkedRequestID > 0 {
+		other, err := s.db.GetRequest(ctx, req.LinkedRequestID)
+		if err == nil {
+			if other.Status != "pending" {
+				return nil, fmt.Errorf("the %s is already requested as #%d (%s)", other.Format, other.ID, other.Status)
+			}
+			return other, nil
+		}
+	}
+	other := &db.Request{
+		RequesterEmail: req.RequesterEmail,
+		Title:          req.Title,
+		Authors:        req.Authors,
+		ISBN10:         req.ISBN10,
+		ISBN13:         req.ISBN13,
+		Format:         alternateFormat(req.Format),
+		Status:         "pending",
+		GroupName:      req.GroupName,
+		ExtraFields:    req.ExtraFields,
+		NeedsReview:    req.NeedsReview,
+		CoverURL:       req.CoverURL,
+		Source:         req.Source,
+		ReadarrReq:     s.withRequestTemplate(alternateFormat(req.Format), portableSelection(req.ReadarrReq)),
+	}
+	newID, err := s.db.CreateRequest(ctx, other)
+	if err != nil {
+		return nil, err
+	}
+	other.ID = newID
+	if err := s.db.LinkRequests(ctx, req.ID, newID); err != nil {
+		return nil, err
+	}
+	other.LinkedRequestID = req.ID
+	req.LinkedRequestID = newID
+	s.auditLog(ctx, actor, "request.created", &newID, fmt.Sprintf("%s of request %d for approval in both formats", other.Format, req.ID))
+	return other, nil
+}
+
+// portableSelection strips instance-specific ids (book, author, profiles,
+// root folder) from a stored selection payload so it can be added to the
+// other Readarr instance, which resolves the author by foreign id and fills
+// in its own defaults.
+func portableSelection(raw json.RawMessage) json.RawMessage {
+	var pmap map[string]any
+	if len(raw) == 0 || json.Unmarshal(raw, &pmap) != nil || pmap == nil {
+		return raw
+	}
+	local := []string{"id", "authorId", "qualityProfileId", "metadataProfileId", "rootFolderPath"}
+	for _, k := range local {
+		delete(pmap, k)
+	}
+	if am, ok := pmap["author"].(map[string]any); ok {
+		for _, k := range local {
+			delete(am, k)
+		}
+		if vm, ok := am["value"].(map[string]any); ok {
+			delete(vm, "id")
+		}
+	}
+	if eds, ok := pmap["editions"].([]any); ok {
+		for _, e := range eds {
+			if em, ok := e.(map[string]any); ok {
+				delete(em, "id")
+				delete(em, "bookId")
+			}
+		}
+	}
+	out, err := json.Marshal(pmap)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// approveRequestGroup approves reqs together with the add policy override
+// in r and writes the combined status along with each request's own.
+func (s *Server) approveRequestGroup(w http.ResponseWriter, r *http.Request, reqs []*db.Request, auditNote string) {
+	id := reqs[0].ID
+	override, err := parseAddPolicyOverride(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if override.Instance != "" && len(reqs) > 1 {
+		http.Error(w, "approve linked requests one at a time to send them to another Readarr instance", http.StatusBadRequest)
+		return
+	}
+
+	username := r.Context().Value(ctxUser).(*session).Username
+	statuses := make(map[string]string, len(reqs))
+	var failed []string
+	for _, item := range reqs {
+		status, err := s.approveRequest(r.Context(), item, username, auditNote, override)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("#%d: %v", item.ID, err))
+			status = "error"
+			if errors.Is(err, errReadarrOutOfSpace) {
+				status = "pending"
+			}
+		}
+		statuses[strconv.FormatInt(item.ID, 10)] = status
+	}
+	if len(failed) == len(reqs) {
+		http.Error(w, strings.Join(failed, "; "), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{"status": combinedStatus(statuses), "requests": statuses}, 200)
+}
+
+// combinedStatus folds per-request statuses into the single status shown for
+// a linked pair: any in-flight submission wins, then any error, then any
+// request still waiting for a second admin.
+func combinedStatus(statuses map[string]string) string {
+	out := "approved"
+	for _, st := range statuses {
+		switch st {
+		case "processing":
+			return "processing"
+		case "error":
+			out = "error"
+		case statusAwaitingSecondApproval:
+			if out != "error" {
+				out = st
+			}
+		}
+	}
+	return out
+}
+
+// apiRequestAlternateFormat creates a request for the other format of an
+// existing request ("I have the ebook, get the audiobook"). Identifiers are
+// copied from the original, but its stored selection payload is not: that was
+// resolved against the original format's Readarr instance, so the new request
+// is matched against the other instance from scratch.
+func (s *Server) apiRequestAlternateFormat(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	orig, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", 404)
+		return
+	}
+	u := r.Context().Value(ctxUser).(*session)
+	if !s.canAccessRequest(u, orig) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if !hasOtherFormat(orig.Format) {
+		http.Error(w, "only books have another format", http.StatusBadRequest)
+		return
+	}
+	s.createRequest(w, r, RequestPayload{
+		Title:   orig.Title,
+		Authors: orig.Authors,
+		ISBN10:  orig.ISBN10,
+		ISBN13:  orig.ISBN13,
+		Format:  alternateFormat(orig.Format),
+		Extra:   extrasMap(orig.ExtraFields),
+	})
+}
+
+// requestedBooks indexes the book requests in items that were not
+// declined by requester, format, and book, so a list can tell which books a
+// user already asked for in the other format.
+func requestedBooks(items []db.Request) map[string]bool {
+	out := make(map[string]bool, len(items))
+	for i := range items {
+		if items[i].Status == "declined" || !hasOtherFormat(items[i].Format) {
+			continue
+		}
+		for _, k := range requestedBookKeys(&items[i], items[i].Format) {
+			out[k] = true
+		}
+	}
+	return out
+}
+
+// requestedBookKeys are the keys req's book is indexed under for format.
+// Books match on ISBN-13, ISBN-10, or title plus authors, as in
+// db.FindFormatCounterpart.
+func requestedBookKeys(req *db.Request, format string) []string {
+	prefix := strings.ToLower(req.RequesterEmail) + "|" + normalizeSyncKind(format) + "|"
+	var keys []string
+	if req.ISBN13 != "" {
+		keys = append(keys, prefix+"isbn13:"+req.ISBN13)
+	}
+	if req.ISBN10 != "" {
+		keys = append(keys, prefix+"isbn10:"+req.ISBN10)
+	}
+	if t := strings.TrimSpace(req.Title); t != "" {
+		keys = append(keys, prefix+"title:"+strings.ToLower(t)+"|"+strings.ToLower(strings.Join(req.Authors, ", ")))
+	}
+	return keys
+}
+
+// otherFormatRequested reports whether req's requester already asked for
+// its book in the other format, according to requested.
+func otherFormatRequested(requested map[string]bool, req *db.Request) bool {
+	for _, k := range requestedBookKeys(req, alternateFormat(req.Format)) {
+		if requested[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOtherFormat reports whether a request format has an ebook/audiobook
+// counterpart; comics and magazines do not.
+func hasOtherFormat(format string) bool {
+	return format != formatComic && format != formatMagazine
+}
+
+// alternateFormat returns the other request format.
+func alternateFormat(format string) string {
+	if normalizeSyncKind(format) == "audiobook" {
+		return "ebook"
+	}
+	return "audiobook"
+}
@@ -0,0 +1,260 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestCrossFormatRequestsLinkAndApproveTogether(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = "", ""
+	cfg.Readarr.Audiobooks.BaseURL, cfg.Readarr.Audiobooks.APIKey = "", ""
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	h := s.Router()
+
+	create := func(format string) map[string]any {
+		body, _ := json.Marshal(map[string]any{"title": "Dune", "authors": []string{"Frank Herbert"}, "isbn13": "9780441013593", "format": format})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "user", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %s: %d %s", format, rec.Code, rec.Body.String())
+		}
+		var resp map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+	first := create("ebook")
+	if _, ok := first["linked_request_id"]; ok {
+		t.Fatalf("first request should not be linked: %v", first)
+	}
+	second := create("audiobook")
+	ebookID := int64(first["id"].(float64))
+	audioID := int64(second["id"].(float64))
+	if got, _ := second["linked_request_id"].(float64); int64(got) != ebookID {
+		t.Fatalf("expected link to %d, got %v", ebookID, second)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(audioID, 10)+"/approve-linked", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve-linked: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp["status"] != "approved" {
+		t.Fatalf("expected combined status approved, got %v", resp)
+	}
+	for _, id := range []int64{ebookID, audioID} {
+		got, err := s.db.GetRequest(context.Background(), id)
+		if err != nil || got.Status != "approved" {
+			t.Fatalf("request %d: expected approved, got %+v err=%v", id, got, err)
+		}
+	}
+
+	// Non-admins cannot use combined approval.
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(audioID, 10)+"/approve-linked", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin, got %d", rec.Code)
+	}
+}
+
+func TestCombinedStatus(t *testing.T) {
+	cases := []struct {
+		in   map[string]string
+		want string
+	}{
+		{map[string]string{"1": "approved", "2": "approved"}, "approved"},
+		{map[string]string{"1": "approved", "2": "error"}, "error"},
+		{map[string]string{"1": "processing", "2": "error"}, "processing"},
+	}
+	for _, c := range cases {
+		if got := combinedStatus(c.in); got != c.want {
+			t.Fatalf("combinedStatus(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRequestAlternateFormat(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	ctx := context.Background()
+	origID, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Dune", Authors: []string{"Frank Herbert"}, ISBN13: "9780441013593", Format: "ebook", Status: "queued", ExternalStatus: "available"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	path := "/api/v1/requests/" + strconv.FormatInt(origID, 10) + "/alternate"
+	offered := func() bool {
+		req := httptest.NewRequest(http.MethodGet, "/requests/"+strconv.FormatInt(origID, 10), nil)
+		req.AddCookie(makeCookie(t, s, "user", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return strings.Contains(rec.Body.String(), `id="request-alternate"`)
+	}
+	if !offered() {
+		t.Fatal("detail page of an available ebook does not offer the audiobook")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req.AddCookie(makeCookie(t, s, "someoneelse", false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for other user, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, path, nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("alternate: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	created, err := s.db.GetRequest(ctx, int64(resp["id"].(float64)))
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if created.Format != "audiobook" || created.ISBN13 != "9780441013593" || created.Title != "Dune" || created.RequesterEmail != "user" {
+		t.Fatalf("unexpected alternate request: %+v", created)
+	}
+	if offered() {
+		t.Fatal("detail page still offers the audiobook after it was requested")
+	}
+	req = httptest.NewRequest(http.MethodGet, "/ui/requests/table", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), path) {
+		t.Fatal("request list still offers the audiobook after it was requested")
+	}
+}
+
+func TestSearchPreselectsMostRequestedFormat(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	for _, f := range []string{"audiobook", "audiobook", "ebook"} {
+		if _, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Dune " + f, Format: f, Status: "approved"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/search", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `window.SCRIPTORUM_DEFAULT_FORMAT = "audiobook";`) {
+		t.Fatal("search page does not carry the user's usual format")
+	}
+}
+
+func TestApproveBothFormatsCreatesLinkedRequest(t *testing.T) {
+	var mu sync.Mutex
+	adds := map[string]map[string]any{}
+	fake := func(kind string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.URL.Path == "/api/v1/book" && r.Method == http.MethodPost:
+				var body map[string]any
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				mu.Lock()
+				adds[kind] = body
+				mu.Unlock()
+				_, _ = w.Write([]byte(`{"id":1,"authorId":3}`))
+			case r.URL.Path == "/api/v1/author/lookup":
+				_, _ = w.Write([]byte(`[]`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	}
+	ebooks, audiobooks := fake("ebook"), fake("audiobook")
+	defer ebooks.Close()
+	defer audiobooks.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = ebooks.URL, "k"
+	cfg.Readarr.Audiobooks.BaseURL, cfg.Readarr.Audiobooks.APIKey = audiobooks.URL, "k"
+	cfg.Readarr.MinFreeSpaceMB = -1
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Dune", Authors: []string{"Frank Herbert"}, Format: "ebook", Status: "pending",
+		ReadarrReq: []byte(`{"title":"Dune","foreignBookId":"fb-1","editions":[{"id":4,"foreignEditionId":"fe-1"}],"authorId":9,"author":{"id":9,"name":"Frank Herbert","foreignAuthorId":"fa-1"}}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := s.Router()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(id, 10)+"/approve-both", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve-both: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Requests map[string]string `json:"requests"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	orig, _ := s.db.GetRequest(ctx, id)
+	if orig.LinkedRequestID == 0 || len(resp.Requests) != 2 {
+		t.Fatalf("expected a linked audiobook request, got %+v %v", orig, resp)
+	}
+	child, err := s.db.GetRequest(ctx, orig.LinkedRequestID)
+	if err != nil || child.Format != "audiobook" || child.LinkedRequestID != id || child.RequesterEmail != "user" {
+		t.Fatalf("child = %+v, %v", child, err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		mu.Lock()
+		n := len(adds)
+		mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if adds["ebook"] == nil || adds["audiobook"] == nil {
+		t.Fatalf("expected an add on each instance, got %v", adds)
+	}
+	if author, _ := adds["audiobook"]["author"].(map[string]any); author["id"] != nil || author["foreignAuthorId"] != "fa-1" {
+		t.Fatalf("audiobook add carried the ebook instance's author: %v", adds["audiobook"])
+	}
+
+	// Once the other format is no longer pending, there is nothing to add.
+	_ = s.db.UpdateRequestStatus(ctx, id, "pending", "", "admin", nil, nil)
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(id, 10)+"/approve-both", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 with the audiobook already approved, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,436 @@
+package httpapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+const (
+	mailReplyPollInterval = 2 * time.Minute
+	mailReplyTimeout      = 30 * time.Second
+	// mailReplyMaxSize bounds one fetched message; replies are small.
+	mailReplyMaxSize = 1 << 20
+	// mailReplyRefTTL is how long a notification email can be answered.
+	mailReplyRefTTL = 7 * 24 * time.Hour
+)
+
+var (
+	mailReplyTagRe       = regexp.MustCompile(`\[#(\d+)-([0-9a-f]{1,16}-[0-9a-f]{16})\]`)
+	mailReplyMessageIDRe = regexp.MustCompile(`scriptorum-request-(\d+)\.([0-9a-f]{1,16}-[0-9a-f]{16})@`)
+	imapLiteralRe        = regexp.MustCompile(`\{(\d+)\}$`)
+)
+
+// mailReplyRef signs a request id, the address its notification email goes
+// to, and when the reference expires, for the subject tag and Message-ID of
+// that email, so a reply proves it answers that email, in time, from where
+// it was sent. The reference is the expiry in hex seconds and the signature,
+// e.g. "6712f0a0-1a2b3c4d5e6f7a8b". It is empty when there is no session
+// secret to sign with.
+func (s *Server) mailReplyRef(requestID int64, recipient string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 16)
+	sig := s.sign([]byte("mail-reply:" + strconv.FormatInt(requestID, 10) + ":" + strings.ToLower(strings.TrimSpace(recipient)) + ":" + exp))
+	if len(sig) < 8 {
+		return ""
+	}
+	return exp + "-" + hex.EncodeToString(sig[:8])
+}
+
+// checkMailReplyRef verifies that ref was issued for requestID to the
+// configured recipient, has not expired, and that from is one of the
+// addresses that email went to.
+func (s *Server) checkMailReplyRef(requestID int64, ref, from string) error {
+	recipient := s.settings.Get().Notifications.SMTP.ToEmail
+	expHex, _, _ := strings.Cut(ref, "-")
+	exp, err := strconv.ParseInt(expHex, 16, 64)
+	if err != nil {
+		return fmt.Errorf("bad reference for request #%d", requestID)
+	}
+	want := s.mailReplyRef(requestID, recipient, time.Unix(exp, 0))
+	if want == "" || subtle.ConstantTimeCompare([]byte(want), []byte(ref)) != 1 {
+		return fmt.Errorf("bad reference for request #%d", requestID)
+	}
+	if time.Now().Unix() > exp {
+		return fmt.Errorf("reference for request #%d has expired", requestID)
+	}
+	addrs, err := mail.ParseAddressList(recipient)
+	if err != nil {
+		return fmt.Errorf("bad recipient %q: %v", recipient, err)
+	}
+	for _, a := range addrs {
+		if strings.EqualFold(a.Address, from) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s was not sent the email for request #%d", from, requestID)
+}
+
+// mailReplyTag is appended to the subject, e.g.
+// "[#12-6712f0a0-1a2b3c4d5e6f7a8b]".
+func mailReplyTag(requestID int64, ref string) string {
+	return fmt.Sprintf("[#%d-%s]", requestID, ref)
+}
+
+func mailReplyMessageID(requestID int64, ref, fromEmail string) string {
+	domain := "scriptorum.local"
+	if _, d, ok := strings.Cut(fromEmail, "@"); ok && d != "" {
+		domain = d
+	}
+	return fmt.Sprintf("<scriptorum-request-%d.%s@%s>", requestID, ref, domain)
+}
+
+// mailReply is an approve or decline command read from a reply.
+type mailReply struct {
+	RequestID int64
+	Ref       string
+	From      string
+	Action    string // "approve" or "decline"
+	Reason    string
+}
+
+// parseMailReply reads the request reference from the subject or reply
+// headers and the command from the first line of the reply text. Quoted
+// lines are ignored.
+func parseMailReply(raw []byte) (*mailReply, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	out := &mailReply{}
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return nil, errors.New("no sender address")
+	}
+	out.From = strings.ToLower(from.Address)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	m := mailReplyTagRe.FindStringSubmatch(subject)
+	if m == nil {
+		m = mailReplyMessageIDRe.FindStringSubmatch(msg.Header.Get("In-Reply-To") + " " + msg.Header.Get("References"))
+	}
+	if m == nil {
+		return nil, errors.New("not a reply to a request email")
+	}
+	out.RequestID, _ = strconv.ParseInt(m[1], 10, 64)
+	out.Ref = m[2]
+
+	text, err := mailPlainText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ">") {
+			continue
+		}
+		word, rest, _ := strings.Cut(line, " ")
+		switch strings.ToLower(strings.TrimRight(word, ".,:!")) {
+		case "approve", "approved":
+			out.Action = "approve"
+		case "decline", "declined", "deny":
+			out.Action = "decline"
+			out.Reason = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(rest), ":-–—"))
+		default:
+			return nil, fmt.Errorf("unrecognised reply %q", truncateChars(line, 40))
+		}
+		return out, nil
+	}
+	return nil, errors.New("empty reply")
+}
+
+// mailPlainText returns the text/plain content of a message body, looking
+// inside multipart messages and undoing the transfer encoding.
+func mailPlainText(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", errors.New("no text part")
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := mailPlainText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err == nil {
+				return text, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", errors.New("no text part")
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	b, err := io.ReadAll(io.LimitReader(body, mailReplyMaxSize))
+	return strings.ReplaceAll(string(b), "\r\n", "\n"), err
+}
+
+// ----- Worker -----
+
+func (s *Server) runMailReplyLoop(ctx context.Context) {
+	ticker := time.NewTicker(mailReplyPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.settings.Get().Notifications.SMTP.Replies.Enabled {
+				continue
+			}
+			if err := s.pollMailReplies(ctx); err != nil {
+				fmt.Printf("mail replies: %v\n", err)
+			}
+		}
+	}
+}
+
+// pollMailReplies reads unseen replies from the configured mailbox and acts
+// on them.
+func (s *Server) pollMailReplies(ctx context.Context) error {
+	cfg := s.settings.Get().Notifications.SMTP.Replies
+	if strings.TrimSpace(cfg.Host) == "" {
+		return errors.New("no IMAP host configured")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 993
+	}
+	dialer := &net.Dialer{Timeout: mailReplyTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(port)), &tls.Config{
+		ServerName:         cfg.Host,
+		InsecureSkipVerify: s.outboundTLSInsecure(),
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * mailReplyTimeout))
+	return s.processMailReplies(ctx, newIMAPClient(conn), cfg.Username, cfg.Password, cfg.Mailbox)
+}
+
+// processMailReplies logs in over an open IMAP connection and handles every
+// unseen message that looks like a reply. Fetching marks each one seen, so
+// it is handled once whatever the outcome.
+func (s *Server) processMailReplies(ctx context.Context, c *imapClient, username, password, mailbox string) error {
+	if err := c.greeting(); err != nil {
+		return err
+	}
+	defer c.command("LOGOUT")
+	if _, err := c.command("LOGIN " + imapQuote(username) + " " + imapQuote(password)); err != nil {
+		return err
+	}
+	if strings.TrimSpace(mailbox) == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := c.command("SELECT " + imapQuote(mailbox)); err != nil {
+		return err
+	}
+	resps, err := c.command(`UID SEARCH UNSEEN OR SUBJECT "[#" HEADER In-Reply-To "scriptorum-request-"`)
+	if err != nil {
+		return err
+	}
+	var uids []string
+	for _, r := range resps {
+		if rest, ok := strings.CutPrefix(r.line, "* SEARCH"); ok {
+			uids = append(uids, strings.Fields(rest)...)
+		}
+	}
+	for _, uid := range uids {
+		resps, err := c.command("UID FETCH " + uid + " (BODY[])")
+		if err != nil {
+			return err
+		}
+		for _, r := range resps {
+			if len(r.literals) == 0 {
+				continue
+			}
+			if err := s.handleMailReply(ctx, r.literals[0]); err != nil {
+				fmt.Printf("mail replies: message %s: %v\n", uid, err)
+			}
+		}
+	}
+	return nil
+}
+
+// handleMailReply acts on one reply. It must carry an unexpired reference
+// to the request, come from an address the notification was sent to, and
+// that sender must be an enabled admin, or an admin of the request's group,
+// whose account email matches From.
+func (s *Server) handleMailReply(ctx context.Context, raw []byte) error {
+	reply, err := parseMailReply(raw)
+	if err != nil {
+		return err
+	}
+	if err := s.checkMailReplyRef(reply.RequestID, reply.Ref, reply.From); err != nil {
+		return err
+	}
+	req, err := s.db.GetRequest(ctx, reply.RequestID)
+	if err != nil {
+		return fmt.Errorf("request #%d not found", reply.RequestID)
+	}
+	actor, ok := s.mailReplyModerator(ctx, reply.From, req)
+	if !ok {
+		return fmt.Errorf("%s may not moderate request #%d", reply.From, req.ID)
+	}
+	if req.Status != "pending" {
+		return fmt.Errorf("request #%d is %s", req.ID, req.Status)
+	}
+	release, err := s.claimRequest(ctx, req.ID, req.UpdatedAt)
+	if errors.Is(err, db.ErrStaleRequest) {
+		return fmt.Errorf("request #%d was already handled", req.ID)
+	}
+	if err != nil {
+		return err
+	}
+	defer release()
+	id := req.ID
+	if reply.Action == "decline" {
+		if err := s.db.DeclineRequest(ctx, id, actor, reply.Reason); err != nil {
+			return err
+		}
+		details := "via email reply"
+		if reply.Reason != "" {
+			details += ": " + reply.Reason
+		}
+		s.auditLog(ctx, actor, "request.declined", &id, details)
+		s.releaseRequestAuthor(id, actor)
+		s.kickHomeAssistant()
+		return nil
+	}
+	if _, err := s.approveRequest(ctx, req, actor, "via email reply, ", addPolicyOverride{}); err != nil {
+		return err
+	}
+	s.kickHomeAssistant()
+	return nil
+}
+
+func (s *Server) mailReplyModerator(ctx context.Context, from string, req *db.Request) (string, bool) {
+	users, err := s.db.ListUsers(ctx)
+	if err != nil {
+		return "", false
+	}
+	for _, u := range users {
+		if u.Disabled || (!strings.EqualFold(u.Email, from) && !strings.EqualFold(u.Username, from)) {
+			continue
+		}
+		if u.IsAdmin {
+			return u.Username, true
+		}
+		if req.GroupName != "" && strings.EqualFold(u.GroupName, req.GroupName) && s.isGroupAdmin(u.Username, req.GroupName) {
+			return u.Username, true
+		}
+	}
+	return "", false
+}
+
+// ----- Minimal IMAP client -----
+
+// imapClient speaks just enough IMAP4rev1 to log in, search, and fetch.
+type imapClient struct {
+	conn io.ReadWriter
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse is one response line, with any literals it carried pulled
+// out in order.
+type imapResponse struct {
+	line     string
+	literals [][]byte
+}
+
+func newIMAPClient(conn io.ReadWriter) *imapClient {
+	return &imapClient{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *imapClient) greeting() error {
+	resp, err := c.read()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(resp.line, "* OK") && !strings.HasPrefix(resp.line, "* PREAUTH") {
+		return fmt.Errorf("imap: unexpected greeting %q", resp.line)
+	}
+	return nil
+}
+
+func (c *imapClient) read() (imapResponse, error) {
+	var resp imapResponse
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		resp.line += line
+		m := imapLiteralRe.FindStringSubmatch(line)
+		if m == nil {
+			return resp, nil
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n > mailReplyMaxSize {
+			return resp, fmt.Errorf("imap: %d byte literal is too large", n)
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return resp, err
+		}
+		resp.literals = append(resp.literals, buf)
+	}
+}
+
+// command sends cmd and returns the untagged responses before its tagged
+// completion, failing unless that completion is OK.
+func (c *imapClient) command(cmd string) ([]imapResponse, error) {
+	c.tag++
+	tag := "A" + strconv.Itoa(c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return nil, err
+	}
+	var untagged []imapResponse
+	for {
+		resp, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		if status, ok := strings.CutPrefix(resp.line, tag+" "); ok {
+			if !strings.HasPrefix(status, "OK") {
+				verb, _, _ := strings.Cut(cmd, " ")
+				return nil, fmt.Errorf("imap %s: %s", verb, status)
+			}
+			return untagged, nil
+		}
+		untagged = append(untagged, resp)
+	}
+}
+
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestParseMailReply(t *testing.T) {
+	raw := "From: Admin <Admin@Example.com>\r\n" +
+		"Subject: Re: New Book Request - Scriptorum\r\n" +
+		"In-Reply-To: <scriptorum-request-12.6712f0a0-1a2b3c4d5e6f7a8b@example.com>\r\n" +
+		"Content-Type: multipart/alternative; boundary=b\r\n\r\n" +
+		"--b\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n" +
+		"Decline: we already own a cop=\r\ny\r\n\r\n> Or reply with \"approve\"\r\n" +
+		"--b\r\nContent-Type: text/html\r\n\r\n<p>Decline</p>\r\n--b--\r\n"
+	got, err := parseMailReply([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mailReply{RequestID: 12, Ref: "6712f0a0-1a2b3c4d5e6f7a8b", From: "admin@example.com", Action: "decline", Reason: "we already own a copy"}
+	if *got != want {
+		t.Fatalf("got %+v, want %+v", *got, want)
+	}
+
+	if _, err := parseMailReply([]byte("From: a@example.com\r\nSubject: Re: [#12-6712f0a0-1a2b3c4d5e6f7a8b]\r\n\r\nSounds good to me\r\n")); err == nil {
+		t.Fatal("free-form reply should not parse as a command")
+	}
+	if _, err := parseMailReply([]byte("From: a@example.com\r\nSubject: lunch?\r\n\r\napprove\r\n")); err == nil {
+		t.Fatal("unrelated mail should not parse")
+	}
+}
+
+// fakeIMAP serves one mailbox holding msgs to processMailReplies.
+func fakeIMAP(t *testing.T, conn net.Conn, msgs []string) {
+	t.Helper()
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch {
+		case strings.HasPrefix(cmd, "UID SEARCH"):
+			uids := make([]string, len(msgs))
+			for i := range msgs {
+				uids[i] = strconv.Itoa(i + 1)
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(uids, " "))
+		case strings.HasPrefix(cmd, "UID FETCH"):
+			n, _ := strconv.Atoi(strings.Fields(cmd)[2])
+			fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", n, n, len(msgs[n-1]), msgs[n-1])
+		case cmd == "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+			return
+		}
+		fmt.Fprintf(conn, "%s OK done\r\n", tag)
+	}
+}
+
+func TestMailRepliesApproveAndDecline(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	for _, name := range []string{"admin", "boss"} {
+		if _, err := s.db.CreateUser(ctx, name, "hash", true, false); err != nil {
+			t.Fatal(err)
+		}
+		u, _ := s.db.GetUserByUsername(ctx, name)
+		if err := s.db.SetUserEmail(ctx, u.ID, name+"@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := s.settings.Get()
+	cfg.Notifications.SMTP.ToEmail = "Admin <admin@example.com>, books@example.com"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	to := cfg.Notifications.SMTP.ToEmail
+	valid := time.Now().Add(time.Hour)
+	newRequest := func(title string) int64 {
+		id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: title, Format: "ebook", Status: "pending"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	approveID, declineID, strangerID := newRequest("Dune"), newRequest("Emma"), newRequest("Ulysses")
+	reply := func(from string, id int64, ref, body string) string {
+		return "From: " + from + "\r\nSubject: Re: New Book Request " + mailReplyTag(id, ref) + "\r\n\r\n" + body + "\r\n"
+	}
+	msgs := []string{
+		reply("admin@example.com", approveID, s.mailReplyRef(approveID, to, valid), "Approve"),
+		reply("admin@example.com", declineID, s.mailReplyRef(declineID, to, valid), "decline - duplicate"),
+		reply("mallory@example.com", strangerID, s.mailReplyRef(strangerID, to, valid), "approve"),
+		reply("admin@example.com", strangerID, "ffffffff-0000000000000000", "approve"),
+		// An admin who was not sent the email cannot answer it, nor can
+		// anyone answer once the reference has expired or with one issued
+		// to another address.
+		reply("boss@example.com", strangerID, s.mailReplyRef(strangerID, to, valid), "approve"),
+		reply("admin@example.com", strangerID, s.mailReplyRef(strangerID, to, time.Now().Add(-time.Minute)), "approve"),
+		reply("boss@example.com", strangerID, s.mailReplyRef(strangerID, "boss@example.com", valid), "approve"),
+	}
+	client, server := net.Pipe()
+	go fakeIMAP(t, server, msgs)
+	if err := s.processMailReplies(ctx, newIMAPClient(client), "user", "pass", ""); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	if got, _ := s.db.GetRequest(ctx, approveID); got.Status != "approved" || got.ApproverEmail != "admin" {
+		t.Fatalf("approved request = %+v", got)
+	}
+	if got, _ := s.db.GetRequest(ctx, declineID); got.Status != "declined" || got.StatusReason != "duplicate" {
+		t.Fatalf("declined request = %+v", got)
+	}
+	if got, _ := s.db.GetRequest(ctx, strangerID); got.Status != "pending" {
+		t.Fatalf("request moved by a stranger, forged, expired, or misdirected reference: %+v", got)
+	}
+	events, _ := s.db.ListRequestAuditEvents(ctx, declineID, 10)
+	var sawDecline bool
+	for _, e := range events {
+		sawDecline = sawDecline || (e.EventType == "request.declined" && e.ActorEmail == "admin" && strings.Contains(e.Details, "via email reply"))
+	}
+	if !sawDecline {
+		t.Fatalf("history = %+v", events)
+	}
+}
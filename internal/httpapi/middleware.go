@@ -9,7 +9,10 @@ import (
 
 type ctxKey string
 
-const ctxUser ctxKey = "user"
+const (
+	ctxUser  ctxKey = "user"
+	ctxGroup ctxKey = "group"
+)
 
 // clientIP resolves the originating client IP for a request. Scriptorum is
 // typically self-hosted behind a single reverse proxy, so the proxy-supplied
@@ -39,6 +42,10 @@ func (s *Server) withUser(next http.Handler) http.Handler {
 			u = s.getSession(r)
 		}
 		ctx := r.Context()
+		// Sessions of deactivated accounts end on their next request.
+		if u != nil && s.userDisabled(ctx, u.Username) {
+			u = nil
+		}
 		if u != nil {
 			ctx = context.WithValue(ctx, ctxUser, u)
 		}
@@ -46,6 +53,12 @@ func (s *Server) withUser(next http.Handler) http.Handler {
 	})
 }
 
+// userDisabled reports whether username belongs to a deactivated account.
+func (s *Server) userDisabled(ctx context.Context, username string) bool {
+	u, err := s.db.GetUserByUsername(ctx, username)
+	return err == nil && u.Disabled
+}
+
 func (s *Server) requireLogin(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Context().Value(ctxUser) == nil {
@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// needByFieldKey is the request form key of the built-in need-by date.
+// Configured field keys never contain a hyphen, so it can't clash with one.
+const needByFieldKey = "need-by"
+
+// Days before a need-by date at which pending reminders escalate.
+const (
+	needBySoonDays   = 7
+	needByUrgentDays = 2
+)
+
+// needByField is the need-by date question added to the request form when
+// requests.ask_need_by is on.
+var needByField = config.RequestField{Key: needByFieldKey, Label: "Need by", Type: config.FieldDate}
+
+// Need-by states shown on request lists and the approval queue.
+const (
+	needBySoon    = "soon"
+	needByOverdue = "overdue"
+)
+
+// parseNeedBy checks a need-by date from the request form and returns it as
+// YYYY-MM-DD. Dates more than a day in the past are refused; the day of
+// slack covers requesters west of UTC.
+func parseNeedBy(v string, now time.Time) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	d, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return "", errors.New("need-by date must be a date (YYYY-MM-DD)")
+	}
+	if d.Before(now.UTC().AddDate(0, 0, -1).Truncate(24 * time.Hour)) {
+		return "", errors.New("need-by date is in the past")
+	}
+	return d.Format("2006-01-02"), nil
+}
+
+// needByDaysLeft is how many days remain from now's date until needBy,
+// negative once it has passed. ok is false when needBy is empty or invalid.
+func needByDaysLeft(needBy string, now time.Time) (days int, ok bool) {
+	d, err := time.Parse("2006-01-02", needBy)
+	if err != nil {
+		return 0, false
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	return int(d.Sub(today).Hours() / 24), true
+}
+
+// needByLevel is the reminder level a pending request's need-by date calls
+// for: 1 within a week, 2 within two days, and maxPendingReminders on the
+// date and after. It is 0 without a date or while the date is further off.
+func needByLevel(needBy string, now time.Time) int {
+	days, ok := needByDaysLeft(needBy, now)
+	switch {
+	case !ok || days > needBySoonDays:
+		return 0
+	case days <= 0:
+		return maxPendingReminders
+	case days <= needByUrgentDays:
+		return 2
+	}
+	return 1
+}
+
+// needByOpen reports whether r has a need-by date it may still miss: it is
+// neither available nor declined.
+func needByOpen(r db.Request) bool {
+	return r.NeedBy != "" && r.Status != "declined" && !strings.EqualFold(strings.TrimSpace(r.ExternalStatus), "available")
+}
+
+// needByState is needBySoon when r's need-by date is within a week,
+// needByOverdue once it has passed, and "" otherwise or once the request is
+// no longer open.
+func needByState(r db.Request, now time.Time) string {
+	days, ok := needByDaysLeft(r.NeedBy, now)
+	if !ok || !needByOpen(r) {
+		return ""
+	}
+	switch {
+	case days < 0:
+		return needByOverdue
+	case days <= needBySoonDays:
+		return needBySoon
+	}
+	return ""
+}
+
+// formatNeedBy renders a need-by date as "Mar 4" or, outside the current
+// year, "Mar 4, 2027".
+func formatNeedBy(needBy string, now time.Time) string {
+	d, err := time.Parse("2006-01-02", needBy)
+	if err != nil {
+		return needBy
+	}
+	if d.Year() == now.Year() {
+		return d.Format("Jan 2")
+	}
+	return d.Format("Jan 2, 2006")
+}
@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestNeedByParseAndLevels(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	if got, err := parseNeedBy(" 2026-10-20 ", now); err != nil || got != "2026-10-20" {
+		t.Fatalf("parseNeedBy = %q, %v", got, err)
+	}
+	if got, err := parseNeedBy("2026-10-16", now); err != nil || got != "2026-10-16" {
+		t.Fatalf("yesterday = %q, %v, want it allowed for requesters west of UTC", got, err)
+	}
+	for _, bad := range []string{"2026-10-10", "next week"} {
+		if _, err := parseNeedBy(bad, now); err == nil {
+			t.Errorf("parseNeedBy(%q) accepted", bad)
+		}
+	}
+	for needBy, want := range map[string]int{
+		"":           0,
+		"2026-11-30": 0,
+		"2026-10-24": 1,
+		"2026-10-19": 2,
+		"2026-10-17": maxPendingReminders,
+		"2026-10-01": maxPendingReminders,
+	} {
+		if got := needByLevel(needBy, now); got != want {
+			t.Errorf("needByLevel(%q) = %d, want %d", needBy, got, want)
+		}
+	}
+	if got := needByState(db.Request{NeedBy: "2026-10-16", Status: "approved"}, now); got != needByOverdue {
+		t.Fatalf("state = %q, want overdue", got)
+	}
+	if got := needByState(db.Request{NeedBy: "2026-10-16", Status: "approved", ExternalStatus: "available"}, now); got != "" {
+		t.Fatalf("available request state = %q", got)
+	}
+	if formatNeedBy("2026-10-20", now) != "Oct 20" || formatNeedBy("2027-01-05", now) != "Jan 5, 2027" {
+		t.Fatal("unexpected need-by labels")
+	}
+}
+
+func TestNeedByRequestsLeadQueueAndEscalate(t *testing.T) {
+	events := make(chan map[string]any, 8)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		events <- payload
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Requests.AskNeedBy = true
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.URL = hook.URL
+	cfg.Notifications.Webhook.EnableRequestNotifications = true
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	user := makeCookie(t, s, "user", false)
+	create := func(body map[string]any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(user)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := create(map[string]any{"title": "Emma", "format": "ebook"}); rec.Code != http.StatusCreated {
+		t.Fatalf("undated request: %d %s", rec.Code, rec.Body.String())
+	}
+	soon := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	if rec := create(map[string]any{"title": "Dune", "format": "ebook", "extra": map[string]string{needByFieldKey: soon}}); rec.Code != http.StatusCreated {
+		t.Fatalf("dated request: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := create(map[string]any{"title": "Ulysses", "format": "ebook", "need_by": "2001-01-01"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("past need-by: %d %s", rec.Code, rec.Body.String())
+	}
+
+	ctx := context.Background()
+	items, err := s.pendingQueue(ctx, "admin")
+	if err != nil || len(items) != 2 {
+		t.Fatalf("queue = %+v, %v", items, err)
+	}
+	if items[0].Title != "Dune" || items[0].NeedBy != soon || items[0].NeedByState != needBySoon || items[1].NeedBy != "" {
+		t.Fatalf("queue order = %+v", items)
+	}
+	full, _ := s.db.GetRequest(ctx, items[0].ID)
+	if len(full.ExtraFields) != 0 {
+		t.Fatalf("need-by stored as an extra answer: %+v", full.ExtraFields)
+	}
+
+	// Age reminders are off, but the date two days out still escalates.
+	s.sendPendingReminders(ctx, time.Now())
+	for reminded := false; !reminded; {
+		select {
+		case ev := <-events:
+			if ev["event"] != "request.reminder" {
+				continue // the request.created events
+			}
+			reqs, _ := ev["requests"].([]any)
+			if ev["level"] != float64(2) || len(reqs) != 1 || reqs[0].(map[string]any)["needBy"] != soon {
+				t.Fatalf("reminder = %+v", ev)
+			}
+			reminded = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reminder sent")
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ui/requests/table", nil)
+	req.AddCookie(user)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `data-need-by="soon"`) {
+		t.Fatalf("requests table lacks the need-by badge: %s", rec.Body.String())
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +28,8 @@ func (s *Server) mountNotifications(r chi.Router) {
 		"toJSON":        func(v any) string { b, _ := json.Marshal(v); return string(b) },
 		"authorsText":   authorsText,
 		"truncateChars": truncateChars,
+		"brand":         s.branding,
+		"asset":         assetURL,
 	}
 	u := &notificationsUI{tpl: template.Must(template.New("tpl").Funcs(funcMap).ParseFS(tplFS, "web/templates/*.html"))}
 
@@ -37,6 +41,8 @@ func (s *Server) mountNotifications(r chi.Router) {
 		rt.Post("/api/notifications/test-smtp", s.apiTestSMTP())
 		rt.Post("/api/notifications/test-discord", s.apiTestDiscord())
 		rt.Post("/api/notifications/test-webhook", s.apiTestWebhook())
+		rt.Post("/api/notifications/test-apprise", s.apiTestApprise())
+		rt.Post("/api/notifications/test-all", s.apiTestAllNotifications())
 	})
 }
 
@@ -86,74 +92,120 @@ type approvalTokenData struct {
 	Action    string // "approve" or "decline"
 }
 
-// handleApprovalToken handles one-click approvals/declines via secure tokens
-func (s *Server) handleApprovalToken(w http.ResponseWriter, r *http.Request) {
-	token := chi.URLParam(r, "token")
+// errApprovalToken carries the HTTP status for a token that could not be
+// redeemed.
+type errApprovalToken struct {
+	code int
+	msg  string
+}
+
+func (e *errApprovalToken) Error() string { return e.msg }
 
-	s.tokenMutex.RLock()
+// redeemApprovalToken carries out the approval or decline token stands for
+// and uses the token up. The token is claimed, looked up and deleted under
+// one lock, before anything is done, so of two concurrent clicks only one
+// acts; it is put back only when acting failed in a way a later click could
+// get past. statusMessage is "declined", "approved", or "queued". Failures
+// are *errApprovalToken.
+func (s *Server) redeemApprovalToken(ctx context.Context, token string) (tokenData approvalTokenData, statusMessage string, err error) {
+	s.tokenMutex.Lock()
 	tokenData, exists := s.approvalTokens[token]
-	s.tokenMutex.RUnlock()
+	delete(s.approvalTokens, token)
+	s.tokenMutex.Unlock()
 
 	if !exists {
 		// Log for debugging
 		if s.settings.Get().Debug {
-			fmt.Printf("DEBUG: Token not found: %s. Available tokens: %d\n", token, len(s.approvalTokens))
+			fmt.Printf("DEBUG: Token not found: %s\n", token)
 		}
-		http.Error(w, "Invalid approval token", 404)
-		return
+		return tokenData, "", &errApprovalToken{404, "Invalid approval token"}
 	}
 
 	if time.Now().After(tokenData.ExpiresAt) {
-		s.tokenMutex.Lock()
-		delete(s.approvalTokens, token)
-		s.tokenMutex.Unlock()
 		if s.settings.Get().Debug {
 			fmt.Printf("DEBUG: Token expired: %s\n", token)
 		}
-		http.Error(w, "Approval token has expired", http.StatusGone)
-		return
+		return tokenData, "", &errApprovalToken{http.StatusGone, "Approval token has expired"}
+	}
+	restore := func() {
+		s.tokenMutex.Lock()
+		s.approvalTokens[token] = tokenData
+		s.tokenMutex.Unlock()
 	}
 
-	// Process the action based on token type
-	var color, emoji, actionText string
-	var statusMessage string
+	req, err := s.db.GetRequest(ctx, tokenData.RequestID)
+	if err != nil {
+		return tokenData, "", &errApprovalToken{404, "Request not found"}
+	}
+	if req.Status != "pending" {
+		return tokenData, "", &errApprovalToken{http.StatusConflict, "Request is already " + req.Status}
+	}
+	// A link can't tell who clicked it, so it can't count towards two
+	// admin approvals.
+	if tokenData.Action != "decline" && s.needsTwoApprovals(ctx, req) {
+		return tokenData, "", &errApprovalToken{http.StatusConflict, "This request needs approval from two admins; approve it in Scriptorum"}
+	}
+	// Claim the request so a web click, email reply, or Discord press on
+	// the same request cannot act on it at the same time.
+	release, err := s.claimRequest(ctx, req.ID, req.UpdatedAt)
+	if errors.Is(err, db.ErrStaleRequest) {
+		return tokenData, "", &errApprovalToken{http.StatusConflict, "Request was already handled"}
+	}
+	if err != nil {
+		restore()
+		return tokenData, "", &errApprovalToken{500, "Failed to update request status"}
+	}
+	defer release()
 
+	// Process the action based on token type
 	if tokenData.Action == "decline" {
 		// For decline, just update the status
-		err := s.db.UpdateRequestStatus(r.Context(), tokenData.RequestID, "declined", "declined via notification", "system", nil, nil)
+		err := s.db.UpdateRequestStatus(ctx, req.ID, "declined", "declined via notification", "system", nil, nil)
 		if err != nil {
-			http.Error(w, "Failed to update request status", 500)
-			return
+			restore()
+			return tokenData, "", &errApprovalToken{500, "Failed to update request status"}
 		}
-		color = "#ef4444"
-		emoji = "❌"
-		actionText = "Declined"
+		s.releaseRequestAuthor(req.ID, "system")
 		statusMessage = "declined"
 	} else {
-		// For approve, run the full approval logic
-		req, err := s.db.GetRequest(r.Context(), tokenData.RequestID)
-		if err != nil {
-			http.Error(w, "Request not found", 404)
-			return
-		}
-
 		// Call the same approval logic as the API
-		approvalResult := s.processApproval(r.Context(), req, "system")
+		approvalResult := s.processApproval(ctx, req, "system")
 		if approvalResult.Error != nil {
-			http.Error(w, "Failed to approve request: "+approvalResult.Error.Error(), 500)
-			return
+			restore()
+			return tokenData, "", &errApprovalToken{500, "Failed to approve request: " + approvalResult.Error.Error()}
 		}
+		statusMessage = approvalResult.Status // "approved" or "queued"
+	}
+	return tokenData, statusMessage, nil
+}
 
-		color = "#10b981"
-		emoji = "✅"
-		actionText = "Approved"
-		statusMessage = approvalResult.Status + " via notification" // Will be "approved via notification" or "queued via notification"
+// apiApprovalToken redeems a notification token from a client that posts
+// to it directly, such as an ntfy http action button.
+func (s *Server) apiApprovalToken(w http.ResponseWriter, r *http.Request) {
+	tokenData, status, err := s.redeemApprovalToken(r.Context(), chi.URLParam(r, "token"))
+	var tokenErr *errApprovalToken
+	if errors.As(err, &tokenErr) {
+		http.Error(w, tokenErr.msg, tokenErr.code)
+		return
 	}
+	writeJSON(w, map[string]any{"id": tokenData.RequestID, "status": status}, http.StatusOK)
+}
 
-	// Clean up the token
-	s.tokenMutex.Lock()
-	delete(s.approvalTokens, token)
-	s.tokenMutex.Unlock()
+// handleApprovalToken handles one-click approvals/declines via secure tokens
+func (s *Server) handleApprovalToken(w http.ResponseWriter, r *http.Request) {
+	tokenData, status, err := s.redeemApprovalToken(r.Context(), chi.URLParam(r, "token"))
+	var tokenErr *errApprovalToken
+	if errors.As(err, &tokenErr) {
+		http.Error(w, tokenErr.msg, tokenErr.code)
+		return
+	}
+
+	color, emoji, actionText := "#10b981", "✅", "Approved"
+	statusMessage := status + " via notification" // Will be "approved via notification" or "queued via notification"
+	if tokenData.Action == "decline" {
+		color, emoji, actionText = "#ef4444", "❌", "Declined"
+		statusMessage = "declined"
+	}
 
 	// Send success response
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -161,7 +213,7 @@ func (s *Server) handleApprovalToken(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`<!DOCTYPE html>
 <html>
 <head>
-	<title>Request ` + actionText + ` - Scriptorum</title>
+	<title>Request ` + actionText + ` - ` + template.HTMLEscapeString(s.instanceName()) + `</title>
 	<style>
 		body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: #1e293b; color: #e2e8f0; margin: 0; padding: 2rem; text-align: center; }
 		.container { max-width: 500px; margin: 0 auto; background: #334155; padding: 2rem; border-radius: 12px; }
@@ -188,28 +240,7 @@ type ApprovalResult struct {
 
 // processApproval handles the approval logic shared between API and notification approval
 func (s *Server) processApproval(ctx context.Context, req *db.Request, username string) *ApprovalResult {
-	var inst providers.ReadarrInstance
-	if req.Format == "audiobook" {
-		c := s.settings.Get().Readarr.Audiobooks
-		inst = providers.ReadarrInstance{
-			BaseURL:                 c.BaseURL,
-			APIKey:                  c.APIKey,
-			DefaultQualityProfileID: c.DefaultQualityProfileID,
-			DefaultRootFolderPath:   c.DefaultRootFolderPath,
-			DefaultTags:             c.DefaultTags,
-			InsecureSkipVerify:      c.InsecureSkipVerify,
-		}
-	} else {
-		c := s.settings.Get().Readarr.Ebooks
-		inst = providers.ReadarrInstance{
-			BaseURL:                 c.BaseURL,
-			APIKey:                  c.APIKey,
-			DefaultQualityProfileID: c.DefaultQualityProfileID,
-			DefaultRootFolderPath:   c.DefaultRootFolderPath,
-			DefaultTags:             c.DefaultTags,
-			InsecureSkipVerify:      c.InsecureSkipVerify,
-		}
-	}
+	inst := s.readarrInstanceForRequest(req)
 
 	// If Readarr not configured, approve without sending
 	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
@@ -222,15 +253,24 @@ func (s *Server) processApproval(ctx context.Context, req *db.Request, username
 		return &ApprovalResult{Status: "approved", Error: nil}
 	}
 
+	if err := s.checkReadarrSpace(ctx, inst); err != nil {
+		return &ApprovalResult{Status: "", Error: err}
+	}
+	if req.NeedsReview {
+		return &ApprovalResult{Status: "", Error: errNeedsReview}
+	}
+
 	ra := providers.NewReadarrWithDB(inst, s.db.SQL())
 
 	reqCtx, cancel := context.WithTimeout(ctx, 12*time.Second)
 	defer cancel()
 
-	if matched, err := s.tryCompleteApprovalFromCatalogMatch(reqCtx, req, inst, username, " via notification", true); matched {
-		return &ApprovalResult{Status: "queued", Error: nil}
-	} else if err != nil {
-		return &ApprovalResult{Status: "", Error: err}
+	if !s.usesGroupReadarr(req) {
+		if matched, err := s.tryCompleteApprovalFromCatalogMatch(reqCtx, req, inst, username, " via notification", true); matched {
+			return &ApprovalResult{Status: "queued", Error: nil}
+		} else if err != nil {
+			return &ApprovalResult{Status: "", Error: err}
+		}
 	}
 
 	// Require an exact selection payload saved at request-time
@@ -253,13 +293,18 @@ func (s *Server) processApproval(ctx context.Context, req *db.Request, username
 				name = n
 			}
 			if name != "" {
-				if aid, err := ra.FindAuthorIDByName(reqCtx, name); err == nil && aid != 0 {
+				fid, _ := a["foreignAuthorId"].(string)
+				if aid, err := ra.FindAuthorID(reqCtx, name, fid); err == nil && aid != 0 {
 					a["id"] = aid
 				}
 			}
 			cand["author"] = a
 		}
 	}
+	authorProbe := probeAddAuthor(cand)
+
+	// Admin labels ride along as Readarr tags when enabled.
+	labelTags := s.labelReadarrTags(reqCtx, ra, req)
 
 	// Try to add the book to Readarr
 	var payload []byte
@@ -270,7 +315,7 @@ func (s *Server) processApproval(ctx context.Context, req *db.Request, username
 		var raw map[string]any
 		if json.Unmarshal(req.ReadarrReq, &raw) == nil {
 			if _, ok := raw["authorTitle"]; ok || raw["author"] != nil || raw["editions"] != nil || raw["addOptions"] != nil {
-				payload, respBody, err = ra.AddBookRaw(reqCtx, req.ReadarrReq)
+				payload, respBody, err = ra.AddBookRaw(reqCtx, withReadarrTags(req.ReadarrReq, inst.DefaultTags, labelTags))
 			}
 		}
 	}
@@ -281,7 +326,7 @@ func (s *Server) processApproval(ctx context.Context, req *db.Request, username
 			QualityProfileID: inst.DefaultQualityProfileID,
 			RootFolderPath:   inst.DefaultRootFolderPath,
 			SearchForMissing: true,
-			Tags:             inst.DefaultTags,
+			Tags:             mergeReadarrTags(inst.DefaultTags, labelTags),
 		})
 	}
 
@@ -311,6 +356,7 @@ func (s *Server) processApproval(ctx context.Context, req *db.Request, username
 						}
 						// Persist matched id/availability before flipping to "queued".
 						_ = s.db.UpdateRequestExternalStatus(ctx, req.ID, externalStatus, int64(bid), fmt.Sprintf("already in Readarr; monitoring enabled for id %d via notification", bid))
+						s.backfillRequestIdentifiers(ctx, req.ID, gotBody)
 						_ = s.db.UpdateRequestStatus(ctx, req.ID, "queued", fmt.Sprintf("already in Readarr; monitoring enabled for id %d via notification", bid), username, payload, respBody)
 
 						// Send notification for approved request
@@ -336,6 +382,10 @@ func (s *Server) processApproval(ctx context.Context, req *db.Request, username
 		}
 
 		_ = s.db.UpdateRequestStatus(ctx, req.ID, "error", err.Error(), "system", payload, respBody)
+		if !authorProbe.Existed {
+			s.recordAddAuthor(ctx, req, ra, inst, authorProbe, respBody)
+			s.releaseRequestAuthor(req.ID, "system")
+		}
 		return &ApprovalResult{Status: "", Error: err}
 	}
 
@@ -349,7 +399,9 @@ func (s *Server) processApproval(ctx context.Context, req *db.Request, username
 		}
 		_ = s.db.UpdateRequestExternalStatus(ctx, req.ID, status, bid, "sent to Readarr via notification")
 	}
+	s.backfillRequestIdentifiers(ctx, req.ID, respBody)
 	_ = s.db.UpdateRequestStatus(ctx, req.ID, "queued", "sent to Readarr via notification", username, payload, respBody)
+	s.recordAddAuthor(ctx, req, ra, inst, authorProbe, respBody)
 
 	// Start background monitoring task for successful additions
 	if respBody != nil {
@@ -412,11 +464,19 @@ type notificationsUI struct{ tpl *template.Template }
 
 func (u *notificationsUI) handleNotifications(s *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		deliveries, _ := s.db.ListNotificationLog(r.Context(), 50)
 		data := map[string]any{
-			"Notifications": s.settings.Get().Notifications,
-			"UserName":      s.userName(r),
-			"IsAdmin":       true,
-			"CSRFToken":     s.getCSRFToken(r),
+			"Notifications":     s.settings.Get().Notifications,
+			"Deliveries":        deliveries,
+			"WebhookEndpoints":  webhookEndpointURLs(s.settings.Get().Notifications.Webhook.Endpoints),
+			"AppriseURLs":       strings.Join(s.settings.Get().Notifications.Apprise.URLs, "\n"),
+			"DiscordModerators": formatDiscordModerators(s.settings.Get().Notifications.Discord.Moderators),
+			"HomeAssistant":     s.settings.Get().HomeAssistant,
+			"HAPrefix":          s.haEntityPrefix(),
+			"NtfyDefault":       s.defaultNtfyServer(),
+			"UserName":          s.userName(r),
+			"IsAdmin":           true,
+			"CSRFToken":         s.getCSRFToken(r),
 		}
 		_ = u.tpl.ExecuteTemplate(w, "notifications.html", data)
 	}
@@ -427,6 +487,11 @@ func (u *notificationsUI) handleNotificationsSave(s *Server) http.HandlerFunc {
 		_ = r.ParseForm()
 		cur := *s.settings.Get()
 
+		cur.Notifications.BatchWindowSeconds = 0
+		if n, err := strconv.Atoi(strings.TrimSpace(r.FormValue("batch_window_seconds"))); err == nil && n > 0 {
+			cur.Notifications.BatchWindowSeconds = min(n, maxBatchWindowSeconds)
+		}
+
 		// Update notification settings - enable/disable providers
 		cur.Notifications.Ntfy.Enabled = r.FormValue("ntfy_enabled") == "on"
 		cur.Notifications.SMTP.Enabled = r.FormValue("smtp_enabled") == "on"
@@ -435,17 +500,21 @@ func (u *notificationsUI) handleNotificationsSave(s *Server) http.HandlerFunc {
 		// Update ntfy settings
 		cur.Notifications.Ntfy.Server = strings.TrimSpace(r.FormValue("ntfy_server"))
 		if cur.Notifications.Ntfy.Server == "" {
-			cur.Notifications.Ntfy.Server = "https://ntfy.sh"
+			cur.Notifications.Ntfy.Server = s.defaultNtfyServer()
 		}
 		cur.Notifications.Ntfy.Topic = strings.TrimSpace(r.FormValue("ntfy_topic"))
 		cur.Notifications.Ntfy.Username = strings.TrimSpace(r.FormValue("ntfy_username"))
 		if v := strings.TrimSpace(r.FormValue("ntfy_password")); v != "" {
 			cur.Notifications.Ntfy.Password = v
 		}
+		if r.FormValue("ntfy_clear_password") == "on" {
+			cur.Notifications.Ntfy.Password = ""
+		}
 		cur.Notifications.Ntfy.EnableRequestNotifications = r.FormValue("ntfy_enable_request_notifications") == "on"
 		cur.Notifications.Ntfy.EnableApprovalNotifications = r.FormValue("ntfy_enable_approval_notifications") == "on"
 		cur.Notifications.Ntfy.EnableAvailableNotifications = r.FormValue("ntfy_enable_available_notifications") == "on"
 		cur.Notifications.Ntfy.EnableSystemNotifications = r.FormValue("ntfy_enable_system_notifications") == "on"
+		cur.Notifications.Ntfy.QuietHours = quietHoursFromForm(r, "ntfy")
 
 		// Update SMTP settings
 		cur.Notifications.SMTP.Host = strings.TrimSpace(r.FormValue("smtp_host"))
@@ -460,36 +529,85 @@ func (u *notificationsUI) handleNotificationsSave(s *Server) http.HandlerFunc {
 		}
 		cur.Notifications.SMTP.FromEmail = strings.TrimSpace(r.FormValue("smtp_from_email"))
 		cur.Notifications.SMTP.FromName = strings.TrimSpace(r.FormValue("smtp_from_name"))
-		if cur.Notifications.SMTP.FromName == "" {
-			cur.Notifications.SMTP.FromName = "Scriptorum"
-		}
 		cur.Notifications.SMTP.ToEmail = strings.TrimSpace(r.FormValue("smtp_to_email"))
 		cur.Notifications.SMTP.EnableTLS = r.FormValue("smtp_enable_tls") == "on"
 		cur.Notifications.SMTP.EnableRequestNotifications = r.FormValue("smtp_enable_request_notifications") == "on"
 		cur.Notifications.SMTP.EnableApprovalNotifications = r.FormValue("smtp_enable_approval_notifications") == "on"
 		cur.Notifications.SMTP.EnableAvailableNotifications = r.FormValue("smtp_enable_available_notifications") == "on"
 		cur.Notifications.SMTP.EnableSystemNotifications = r.FormValue("smtp_enable_system_notifications") == "on"
+		cur.Notifications.SMTP.QuietHours = quietHoursFromForm(r, "smtp")
+		cur.Notifications.SMTP.Replies.Enabled = r.FormValue("smtp_replies_enabled") == "on"
+		cur.Notifications.SMTP.Replies.Host = strings.TrimSpace(r.FormValue("smtp_replies_host"))
+		cur.Notifications.SMTP.Replies.Port = 0
+		if port, err := strconv.Atoi(strings.TrimSpace(r.FormValue("smtp_replies_port"))); err == nil && port > 0 {
+			cur.Notifications.SMTP.Replies.Port = port
+		}
+		cur.Notifications.SMTP.Replies.Mailbox = strings.TrimSpace(r.FormValue("smtp_replies_mailbox"))
+		cur.Notifications.SMTP.Replies.Username = strings.TrimSpace(r.FormValue("smtp_replies_username"))
+		if v := strings.TrimSpace(r.FormValue("smtp_replies_password")); v != "" {
+			cur.Notifications.SMTP.Replies.Password = v
+		}
 
 		// Update Discord settings
-		cur.Notifications.Discord.WebhookURL = strings.TrimSpace(r.FormValue("discord_webhook_url"))
-		cur.Notifications.Discord.Username = strings.TrimSpace(r.FormValue("discord_username"))
-		if cur.Notifications.Discord.Username == "" {
-			cur.Notifications.Discord.Username = "Scriptorum"
+		if v := strings.TrimSpace(r.FormValue("discord_webhook_url")); v != "" {
+			cur.Notifications.Discord.WebhookURL = v
 		}
+		if r.FormValue("discord_clear_webhook_url") == "on" {
+			cur.Notifications.Discord.WebhookURL = ""
+		}
+		cur.Notifications.Discord.Username = strings.TrimSpace(r.FormValue("discord_username"))
 		cur.Notifications.Discord.EnableRequestNotifications = r.FormValue("discord_enable_request_notifications") == "on"
 		cur.Notifications.Discord.EnableApprovalNotifications = r.FormValue("discord_enable_approval_notifications") == "on"
 		cur.Notifications.Discord.EnableAvailableNotifications = r.FormValue("discord_enable_available_notifications") == "on"
 		cur.Notifications.Discord.EnableSystemNotifications = r.FormValue("discord_enable_system_notifications") == "on"
+		cur.Notifications.Discord.QuietHours = quietHoursFromForm(r, "discord")
+		cur.Notifications.Discord.PublicKey = strings.TrimSpace(r.FormValue("discord_public_key"))
+		cur.Notifications.Discord.Moderators = parseDiscordModerators(r.FormValue("discord_moderators"))
 
 		// Update generic webhook settings
 		cur.Notifications.Webhook.Enabled = r.FormValue("webhook_enabled") == "on"
 		cur.Notifications.Webhook.URL = strings.TrimSpace(r.FormValue("webhook_url"))
+		if v := strings.TrimSpace(r.FormValue("webhook_secret")); v != "" {
+			cur.Notifications.Webhook.Secret = v
+		}
+		if r.FormValue("webhook_clear_secret") == "on" {
+			cur.Notifications.Webhook.Secret = ""
+		}
+		cur.Notifications.Webhook.Template = strings.TrimSpace(r.FormValue("webhook_template"))
+		cur.Notifications.Webhook.Endpoints = parseWebhookEndpoints(r.FormValue("webhook_endpoints"), cur.Notifications.Webhook.Endpoints)
 		cur.Notifications.Webhook.EnableRequestNotifications = r.FormValue("webhook_enable_request_notifications") == "on"
 		cur.Notifications.Webhook.EnableApprovalNotifications = r.FormValue("webhook_enable_approval_notifications") == "on"
 		cur.Notifications.Webhook.EnableAvailableNotifications = r.FormValue("webhook_enable_available_notifications") == "on"
 		cur.Notifications.Webhook.EnableSystemNotifications = r.FormValue("webhook_enable_system_notifications") == "on"
+		cur.Notifications.Webhook.QuietHours = quietHoursFromForm(r, "webhook")
+
+		// Update Apprise settings
+		cur.Notifications.Apprise.Enabled = r.FormValue("apprise_enabled") == "on"
+		cur.Notifications.Apprise.ServerURL = strings.TrimSpace(r.FormValue("apprise_server_url"))
+		cur.Notifications.Apprise.ConfigKey = strings.TrimSpace(r.FormValue("apprise_config_key"))
+		cur.Notifications.Apprise.URLs = parseAppriseURLs(r.FormValue("apprise_urls"))
+		cur.Notifications.Apprise.Tag = strings.TrimSpace(r.FormValue("apprise_tag"))
+		cur.Notifications.Apprise.EnableRequestNotifications = r.FormValue("apprise_enable_request_notifications") == "on"
+		cur.Notifications.Apprise.EnableApprovalNotifications = r.FormValue("apprise_enable_approval_notifications") == "on"
+		cur.Notifications.Apprise.EnableAvailableNotifications = r.FormValue("apprise_enable_available_notifications") == "on"
+		cur.Notifications.Apprise.EnableSystemNotifications = r.FormValue("apprise_enable_system_notifications") == "on"
+		cur.Notifications.Apprise.QuietHours = quietHoursFromForm(r, "apprise")
+
+		// Update Home Assistant settings
+		cur.HomeAssistant.Enabled = r.FormValue("ha_enabled") == "on"
+		cur.HomeAssistant.URL = strings.TrimSpace(r.FormValue("ha_url"))
+		if v := strings.TrimSpace(r.FormValue("ha_token")); v != "" {
+			cur.HomeAssistant.Token = v
+		}
+		cur.HomeAssistant.EntityPrefix = strings.TrimSpace(r.FormValue("ha_entity_prefix"))
+		if cur.HomeAssistant.Enabled && (cur.HomeAssistant.APIToken == "" || r.FormValue("ha_regenerate_api_token") == "on") {
+			if tok, err := randomToken(24); err == nil {
+				cur.HomeAssistant.APIToken = tok
+			}
+		}
 
 		_ = s.settings.Update(&cur)
+		s.kickHomeAssistant()
 		http.Redirect(w, r, "/notifications", http.StatusFound)
 	}
 }
@@ -510,7 +628,16 @@ func (s *Server) apiTestNtfy() http.HandlerFunc {
 		}
 
 		if req.Server == "" {
-			req.Server = "https://ntfy.sh"
+			req.Server = s.defaultNtfyServer()
+		}
+		// The form never shows the saved password, so a blank one means
+		// "use it" for the saved server and user.
+		if saved := s.settings.Get().Notifications.Ntfy; req.Password == "" && req.Username == saved.Username && strings.TrimRight(req.Server, "/") == strings.TrimRight(saved.Server, "/") {
+			req.Password = saved.Password
+		}
+		if req.Server == "" {
+			writeJSON(w, map[string]any{"success": false, "error": "Server is required in offline mode"}, 400)
+			return
 		}
 		if req.Topic == "" {
 			writeJSON(w, map[string]any{"success": false, "error": "Topic is required"}, 400)
@@ -518,19 +645,19 @@ func (s *Server) apiTestNtfy() http.HandlerFunc {
 		}
 
 		// Send test notification
-		testMessage := "🧪 **Test Notification**\n\n✅ *Configuration is working correctly!*\n\n🔔 You will receive notifications for:\n• New book requests\n• Request approvals\n• System alerts\n\n💡 *Click the button below to visit Scriptorum*"
+		testMessage := "🧪 **Test Notification**\n\n✅ *Configuration is working correctly!*\n\n🔔 You will receive notifications for:\n• New book requests\n• Request approvals\n• System alerts\n\n💡 *Click the button below to visit " + s.instanceName() + "*"
 
 		// Create test action button
 		currentCfg := s.settings.Get()
 		testActions := []map[string]string{
 			{
 				"action": "view",
-				"label":  "🌐 Open Scriptorum",
+				"label":  "🌐 Open " + s.instanceName(),
 				"url":    currentCfg.ServerURL,
 			},
 		}
 
-		err := s.sendNtfyNotificationWithActions(req.Server, req.Topic, req.Username, req.Password, "🎯 Scriptorum Test", testMessage, "default", testActions)
+		err := s.sendNtfyNotificationWithActions(req.Server, req.Topic, req.Username, req.Password, "🎯 "+s.instanceName()+" Test", testMessage, "default", testActions)
 		if err != nil {
 			writeJSON(w, map[string]any{"success": false, "error": err.Error()}, 500)
 			return
@@ -549,6 +676,7 @@ func (s *Server) sendNtfyNotification(server, topic, username, password, title,
 func (s *Server) sendNtfyNotificationWithActions(server, topic, username, password, title, message, priority string, actions []map[string]string) error {
 	// For JSON publishing, POST to the root URL, not the topic URL
 	url := strings.TrimRight(server, "/")
+	s.throttleSend("ntfy", url)
 
 	// Convert priority string to proper format
 	var priorityValue interface{}
@@ -605,22 +733,43 @@ func (s *Server) sendNtfyNotificationWithActions(server, topic, username, passwo
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("ntfy server returned error: %d", resp.StatusCode)
+		return &notifyStatusError{What: "ntfy server", Code: resp.StatusCode}
 	}
 
 	return nil
 }
 
 // sendSMTPNotification sends a notification via SMTP email
+// emailAccent is the header color of branded emails.
+func (s *Server) emailAccent() string {
+	if c := s.branding().Accent(); c != "" {
+		return c
+	}
+	return "#3b82f6"
+}
+
 func (s *Server) sendSMTPNotification(smtpConfig config.SMTPConfig, subject, htmlBody, textBody string) error {
+	return s.sendSMTPMessage(smtpConfig, subject, htmlBody, textBody, nil)
+}
+
+// sendSMTPMessage sends an email with extra headers, such as the Message-ID
+// that replies to request emails are matched by.
+func (s *Server) sendSMTPMessage(smtpConfig config.SMTPConfig, subject, htmlBody, textBody string, headers map[string]string) error {
 	if smtpConfig.Host == "" || smtpConfig.FromEmail == "" || smtpConfig.ToEmail == "" {
 		return fmt.Errorf("SMTP configuration incomplete: missing host, from_email, or to_email")
 	}
 
+	fromName := smtpConfig.FromName
+	if fromName == "" {
+		fromName = s.instanceName()
+	}
 	m := gomail.NewMessage()
-	m.SetHeader("From", fmt.Sprintf("%s <%s>", smtpConfig.FromName, smtpConfig.FromEmail))
+	m.SetHeader("From", m.FormatAddress(smtpConfig.FromEmail, fromName))
 	m.SetHeader("To", smtpConfig.ToEmail)
 	m.SetHeader("Subject", subject)
+	for k, v := range headers {
+		m.SetHeader(k, v)
+	}
 
 	if htmlBody != "" {
 		m.SetBody("text/html", htmlBody)
@@ -668,20 +817,26 @@ func (s *Server) apiTestSMTP() http.HandlerFunc {
 		if req.Port == 0 {
 			req.Port = 587 // Default to 587
 		}
+		// The form never shows the saved password, so a blank one means
+		// "use it" for the saved host and user.
+		if saved := s.settings.Get().Notifications.SMTP; req.Password == "" && req.Host == saved.Host && req.Username == saved.Username {
+			req.Password = saved.Password
+		}
+		name := s.instanceName()
 		if req.FromName == "" {
-			req.FromName = "Scriptorum Test"
+			req.FromName = name + " Test"
 		}
 
 		// Create test email content
-		subject := "🧪 Scriptorum SMTP Test"
+		subject := "🧪 " + name + " SMTP Test"
 		htmlBody := `<!DOCTYPE html>
 <html>
 <head>
-	<title>Scriptorum Test Email</title>
+	<title>` + template.HTMLEscapeString(name) + ` Test Email</title>
 	<style>
 		body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; color: #333; }
 		.container { max-width: 600px; margin: 0 auto; padding: 20px; }
-		.header { background: #3b82f6; color: white; padding: 20px; border-radius: 8px 8px 0 0; }
+		.header { background: ` + s.emailAccent() + `; color: white; padding: 20px; border-radius: 8px 8px 0 0; }
 		.content { background: #f8fafc; padding: 20px; border-radius: 0 0 8px 8px; }
 		.success { color: #10b981; font-weight: bold; }
 	</style>
@@ -689,7 +844,7 @@ func (s *Server) apiTestSMTP() http.HandlerFunc {
 <body>
 	<div class="container">
 		<div class="header">
-			<h1>🎯 Scriptorum SMTP Test</h1>
+			<h1>🎯 ` + template.HTMLEscapeString(name) + ` SMTP Test</h1>
 		</div>
 		<div class="content">
 			<p><span class="success">✅ Configuration is working correctly!</span></p>
@@ -705,7 +860,7 @@ func (s *Server) apiTestSMTP() http.HandlerFunc {
 </body>
 </html>`
 
-		textBody := `🧪 Scriptorum SMTP Test
+		textBody := `🧪 ` + name + ` SMTP Test
 
 ✅ Configuration is working correctly!
 
@@ -739,9 +894,17 @@ func (s *Server) apiTestSMTP() http.HandlerFunc {
 
 // sendDiscordNotification sends a notification via Discord webhook
 func (s *Server) sendDiscordNotification(webhookURL, username, title, message string, color int) error {
+	return s.sendDiscordMessage(webhookURL, username, title, message, color, nil)
+}
+
+// sendDiscordMessage posts an embed with optional message components, such
+// as the approval buttons. Discord only honours components when the webhook
+// URL asks for them and, for buttons, when an application owns the webhook.
+func (s *Server) sendDiscordMessage(webhookURL, username, title, message string, color int, components []map[string]any) error {
 	if webhookURL == "" {
 		return fmt.Errorf("discord webhook URL is required")
 	}
+	s.throttleSend("discord", webhookURL)
 
 	embed := map[string]any{
 		"title":       title,
@@ -749,14 +912,26 @@ func (s *Server) sendDiscordNotification(webhookURL, username, title, message st
 		"color":       color,
 		"timestamp":   time.Now().Format(time.RFC3339),
 		"footer": map[string]string{
-			"text": "Scriptorum",
+			"text": s.instanceName(),
 		},
 	}
 
+	if username == "" {
+		username = s.instanceName()
+	}
 	payload := map[string]any{
 		"username": username,
 		"embeds":   []map[string]any{embed},
 	}
+	if len(components) > 0 {
+		payload["components"] = components
+		if u, err := url.Parse(webhookURL); err == nil {
+			q := u.Query()
+			q.Set("with_components", "true")
+			u.RawQuery = q.Encode()
+			webhookURL = u.String()
+		}
+	}
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -778,7 +953,7 @@ func (s *Server) sendDiscordNotification(webhookURL, username, title, message st
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("discord webhook returned error: %d", resp.StatusCode)
+		return &notifyStatusError{What: "discord webhook", Code: resp.StatusCode}
 	}
 
 	return nil
@@ -788,39 +963,16 @@ func (s *Server) sendDiscordNotification(webhookURL, username, title, message st
 // HTTP endpoint. Unlike the Discord/ntfy senders this carries no chat-app
 // formatting opinions; the payload is just the event data.
 func (s *Server) sendWebhookNotification(url string, payload map[string]any) error {
-	if strings.TrimSpace(url) == "" {
-		return fmt.Errorf("webhook URL is required")
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := s.outboundHTTPClient(10 * time.Second)
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send webhook notification: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook endpoint returned error: %d", resp.StatusCode)
-	}
-	return nil
+	return s.postWebhook(config.WebhookEndpoint{URL: url}, payload)
 }
 
 // apiTestWebhook tests the generic webhook configuration by posting a test event
 func (s *Server) apiTestWebhook() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
-			URL string `json:"url"`
+			URL      string `json:"url"`
+			Secret   string `json:"secret"`
+			Template string `json:"template"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSON(w, map[string]any{"success": false, "error": "Invalid request"}, 400)
@@ -830,10 +982,14 @@ func (s *Server) apiTestWebhook() http.HandlerFunc {
 			writeJSON(w, map[string]any{"success": false, "error": "webhook URL is required"}, 400)
 			return
 		}
+		// The form never shows the saved secret, so a blank one means "use it".
+		if req.Secret == "" {
+			req.Secret = s.settings.Get().Notifications.Webhook.Secret
+		}
 
-		err := s.sendWebhookNotification(req.URL, map[string]any{
+		err := s.postWebhook(config.WebhookEndpoint{URL: req.URL, Secret: req.Secret, Template: req.Template}, map[string]any{
 			"event":     "system.test",
-			"title":     "Scriptorum Webhook Test",
+			"title":     s.instanceName() + " Webhook Test",
 			"message":   "Configuration is working correctly.",
 			"timestamp": time.Now().Format(time.RFC3339),
 		})
@@ -858,17 +1014,21 @@ func (s *Server) apiTestDiscord() http.HandlerFunc {
 			return
 		}
 
+		// The form never shows the saved URL, so a blank one means "use it".
+		if req.WebhookURL == "" {
+			req.WebhookURL = s.settings.Get().Notifications.Discord.WebhookURL
+		}
 		if req.WebhookURL == "" {
 			writeJSON(w, map[string]any{"success": false, "error": "webhook URL is required"}, 400)
 			return
 		}
 
 		if req.Username == "" {
-			req.Username = "Scriptorum Test"
+			req.Username = s.instanceName() + " Test"
 		}
 
 		// Send test notification
-		title := "🧪 Scriptorum Discord Test"
+		title := "🧪 " + s.instanceName() + " Discord Test"
 		message := "✅ **Configuration is working correctly!**\n\n🔔 You will receive Discord notifications for:\n• New book requests\n• Request approvals\n• System alerts\n\n💡 *This is a test message to verify your Discord webhook configuration.*"
 		color := 0x3b82f6 // Blue color
 
@@ -887,41 +1047,61 @@ func (s *Server) SendRequestNotification(requestID int64, username, title string
 	cfg := s.settings.Get()
 
 	authorsStr := strings.Join(authors, ", ")
+	extras := s.requestExtrasForNotification(requestID)
+	s.kickHomeAssistant()
 
-	// Send to all enabled providers
-	if cfg.Notifications.Ntfy.Enabled && cfg.Notifications.Ntfy.EnableRequestNotifications {
-		s.sendRequestNotificationNtfy(cfg, requestID, username, title, authorsStr)
+	// The generic webhook carries one event per request, so it is never batched.
+	if cfg.Notifications.Webhook.Enabled && cfg.Notifications.Webhook.EnableRequestNotifications {
+		s.sendRequestNotificationWebhook(cfg, requestID, username, title, authors, extras)
 	}
 
-	if cfg.Notifications.SMTP.Enabled && cfg.Notifications.SMTP.EnableRequestNotifications {
-		s.sendRequestNotificationSMTP(cfg, requestID, username, title, authorsStr)
-	}
+	s.queueNotification(notifyKindRequest, notifyEvent{RequestID: requestID, Username: username, Title: title, Authors: authors, send: func() {
+		if cfg.Notifications.Ntfy.Enabled && cfg.Notifications.Ntfy.EnableRequestNotifications {
+			s.sendRequestNotificationNtfy(cfg, requestID, username, title, authorsStr, extras)
+		}
+		if cfg.Notifications.SMTP.Enabled && cfg.Notifications.SMTP.EnableRequestNotifications {
+			s.sendRequestNotificationSMTP(cfg, requestID, username, title, authorsStr, extras)
+		}
+		if cfg.Notifications.Discord.Enabled && cfg.Notifications.Discord.EnableRequestNotifications {
+			s.sendRequestNotificationDiscord(cfg, requestID, username, title, authorsStr, extras)
+		}
+		if cfg.Notifications.Apprise.Enabled && cfg.Notifications.Apprise.EnableRequestNotifications {
+			s.sendRequestNotificationApprise(cfg, requestID, username, title, authorsStr, extras)
+		}
+	}})
+}
 
-	if cfg.Notifications.Discord.Enabled && cfg.Notifications.Discord.EnableRequestNotifications {
-		s.sendRequestNotificationDiscord(cfg, requestID, username, title, authorsStr)
+// requestExtrasForNotification loads the request form answers of a new
+// request; notifications go out without them if the lookup fails.
+func (s *Server) requestExtrasForNotification(requestID int64) []db.RequestExtra {
+	if s.db == nil {
+		return nil
 	}
-
-	if cfg.Notifications.Webhook.Enabled && cfg.Notifications.Webhook.EnableRequestNotifications {
-		s.sendRequestNotificationWebhook(cfg, requestID, username, title, authors)
+	req, err := s.db.GetRequest(context.Background(), requestID)
+	if err != nil {
+		return nil
 	}
+	return req.ExtraFields
 }
 
 // sendRequestNotificationWebhook posts a generic JSON event for new requests
-func (s *Server) sendRequestNotificationWebhook(cfg *config.Config, requestID int64, username, title string, authors []string) {
-	go func() {
-		_ = s.sendWebhookNotification(cfg.Notifications.Webhook.URL, map[string]any{
-			"event":     "request.created",
-			"requestId": requestID,
-			"title":     title,
-			"authors":   authors,
-			"requester": username,
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-	}()
+func (s *Server) sendRequestNotificationWebhook(cfg *config.Config, requestID int64, username, title string, authors []string, extras []db.RequestExtra) {
+	payload := map[string]any{
+		"event":     "request.created",
+		"requestId": requestID,
+		"title":     title,
+		"authors":   authors,
+		"requester": username,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if len(extras) > 0 {
+		payload["extraFields"] = extras
+	}
+	s.sendWebhookEvent(cfg, payload)
 }
 
 // sendRequestNotificationNtfy sends ntfy notification for new requests
-func (s *Server) sendRequestNotificationNtfy(cfg *config.Config, requestID int64, username, title, authorsStr string) {
+func (s *Server) sendRequestNotificationNtfy(cfg *config.Config, requestID int64, username, title, authorsStr string, extras []db.RequestExtra) {
 	// Always get fresh configuration to ensure server_url is current
 	currentCfg := s.settings.Get()
 
@@ -931,31 +1111,38 @@ func (s *Server) sendRequestNotificationNtfy(cfg *config.Config, requestID int64
 	}
 	message += fmt.Sprintf("\n🙋 Requested by: **%s**", username)
 	message += fmt.Sprintf("\n🆔 Request ID: **#%d**", requestID)
-	message += "\n\n💡 *Click 'Approve' or 'Decline' to act on this specific request, or 'View' to see all requests.*"
-
-	// Create action buttons with individual request approval and decline
+	for _, e := range extras {
+		message += fmt.Sprintf("\n📝 %s: %s", e.Label, e.Value)
+	}
+	// The buttons post the tokens straight to Scriptorum so one tap acts
+	// without opening a browser; the links in the message open the landing
+	// page instead, for clients without action support.
 	approvalToken := s.generateApprovalToken(requestID)
 	declineToken := s.generateDeclineToken(requestID)
+	message += fmt.Sprintf("\n\n💡 *Tap 'Approve' or 'Decline' to act on this request.* If the buttons don't work: [approve](%s/approve/%s) · [decline](%s/approve/%s)",
+		currentCfg.ServerURL, approvalToken, currentCfg.ServerURL, declineToken)
 	actions := []map[string]string{
 		{
-			"action": "view",
-			"label":  "📋 View All Requests",
-			"url":    currentCfg.ServerURL + "/requests",
+			"action": "http",
+			"label":  fmt.Sprintf("✅ Approve #%d", requestID),
+			"url":    fmt.Sprintf("%s/api/v1/approve/%s", currentCfg.ServerURL, approvalToken),
+			"method": "POST",
 		},
 		{
-			"action": "view",
-			"label":  fmt.Sprintf("✅ Approve Request #%d", requestID),
-			"url":    fmt.Sprintf("%s/approve/%s", currentCfg.ServerURL, approvalToken),
+			"action": "http",
+			"label":  fmt.Sprintf("❌ Decline #%d", requestID),
+			"url":    fmt.Sprintf("%s/api/v1/approve/%s", currentCfg.ServerURL, declineToken),
+			"method": "POST",
 		},
 		{
 			"action": "view",
-			"label":  fmt.Sprintf("❌ Decline Request #%d", requestID),
-			"url":    fmt.Sprintf("%s/approve/%s", currentCfg.ServerURL, declineToken),
+			"label":  "📋 View All Requests",
+			"url":    currentCfg.ServerURL + "/requests",
 		},
 	}
 
-	go func() {
-		_ = s.sendNtfyNotificationWithActions(
+	s.deliverNotification("request.created", "ntfy", "", func() error {
+		return s.sendNtfyNotificationWithActions(
 			currentCfg.Notifications.Ntfy.Server,
 			currentCfg.Notifications.Ntfy.Topic,
 			currentCfg.Notifications.Ntfy.Username,
@@ -965,15 +1152,25 @@ func (s *Server) sendRequestNotificationNtfy(cfg *config.Config, requestID int64
 			"default",
 			actions,
 		)
-	}()
+	})
 }
 
 // sendRequestNotificationSMTP sends email notification for new requests
-func (s *Server) sendRequestNotificationSMTP(cfg *config.Config, requestID int64, username, title, authorsStr string) {
+func (s *Server) sendRequestNotificationSMTP(cfg *config.Config, requestID int64, username, title, authorsStr string, extras []db.RequestExtra) {
 	currentCfg := s.settings.Get()
 	approvalToken := s.generateApprovalToken(requestID)
 	declineToken := s.generateDeclineToken(requestID)
-	subject := "📚 New Book Request - Scriptorum"
+	subject := "📚 New Book Request - " + s.instanceName()
+	// With reply handling on, the subject and Message-ID carry a signed
+	// reference so a reply can be matched back to this request.
+	var headers map[string]string
+	replyHintHTML, replyHintText := "", ""
+	if ref := s.mailReplyRef(requestID, cfg.Notifications.SMTP.ToEmail, time.Now().Add(mailReplyRefTTL)); ref != "" && cfg.Notifications.SMTP.Replies.Enabled {
+		subject += " " + mailReplyTag(requestID, ref)
+		headers = map[string]string{"Message-ID": mailReplyMessageID(requestID, ref, cfg.Notifications.SMTP.FromEmail)}
+		replyHintHTML = `<p style="text-align: center; color: #6b7280;">Or reply with <strong>approve</strong> or <strong>decline</strong> followed by a reason.</p>`
+		replyHintText = "\n\nOr reply with \"approve\" or \"decline <reason>\"."
+	}
 
 	// HTML email content
 	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
@@ -983,7 +1180,7 @@ func (s *Server) sendRequestNotificationSMTP(cfg *config.Config, requestID int64
 	<style>
 		body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; color: #333; margin: 0; padding: 20px; }
 		.container { max-width: 600px; margin: 0 auto; background: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1); }
-		.header { background: #3b82f6; color: white; padding: 20px; text-align: center; }
+		.header { background: %s; color: white; padding: 20px; text-align: center; }
 		.content { padding: 20px; }
 		.book-info { background: #f8fafc; padding: 15px; border-radius: 6px; margin: 15px 0; }
 		.actions { text-align: center; margin: 20px 0; }
@@ -1004,54 +1201,54 @@ func (s *Server) sendRequestNotificationSMTP(cfg *config.Config, requestID int64
 				%s
 				<p><strong>🙋 Requested by:</strong> %s</p>
 				<p><strong>🆔 Request ID:</strong> #%d</p>
+				%s
 			</div>
 			<div class="actions">
 				<a href="%s/approve/%s" class="button approve">✅ Approve Request</a>
 				<a href="%s/approve/%s" class="button decline">❌ Decline Request</a>
 				<a href="%s/requests" class="button view">📋 View All Requests</a>
 			</div>
+			%s
 		</div>
 	</div>
 </body>
-</html>`, title,
+</html>`, s.emailAccent(), title,
 		func() string {
 			if authorsStr != "" {
 				return fmt.Sprintf("<p><strong>👤 Author(s):</strong> %s</p>", authorsStr)
 			}
 			return ""
 		}(),
-		username, requestID, currentCfg.ServerURL, approvalToken, currentCfg.ServerURL, declineToken, currentCfg.ServerURL)
+		username, requestID, extrasHTML(extras), currentCfg.ServerURL, approvalToken, currentCfg.ServerURL, declineToken, currentCfg.ServerURL, replyHintHTML)
 
 	// Plain text content
-	textBody := fmt.Sprintf(`📚 New Book Request - Scriptorum
+	textBody := fmt.Sprintf(`📚 New Book Request - %s
 
 📖 %s
 %s🙋 Requested by: %s
 🆔 Request ID: #%d
-
+%s
 Actions:
 • Approve: %s/approve/%s
 • Decline: %s/approve/%s  
-• View All Requests: %s/requests`,
-		title,
+• View All Requests: %s/requests%s`,
+		s.instanceName(), title,
 		func() string {
 			if authorsStr != "" {
 				return fmt.Sprintf("👤 Author(s): %s\n", authorsStr)
 			}
 			return ""
 		}(),
-		username, requestID, currentCfg.ServerURL, approvalToken, currentCfg.ServerURL, declineToken, currentCfg.ServerURL)
+		username, requestID, extrasText(extras), currentCfg.ServerURL, approvalToken, currentCfg.ServerURL, declineToken, currentCfg.ServerURL, replyHintText)
 
-	go func() {
-		_ = s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
-	}()
+	s.deliverNotification("request.created", "smtp", "", func() error {
+		return s.sendSMTPMessage(cfg.Notifications.SMTP, subject, htmlBody, textBody, headers)
+	})
 }
 
 // sendRequestNotificationDiscord sends Discord notification for new requests
-func (s *Server) sendRequestNotificationDiscord(cfg *config.Config, requestID int64, username, title, authorsStr string) {
+func (s *Server) sendRequestNotificationDiscord(cfg *config.Config, requestID int64, username, title, authorsStr string, extras []db.RequestExtra) {
 	currentCfg := s.settings.Get()
-	approvalToken := s.generateApprovalToken(requestID)
-	declineToken := s.generateDeclineToken(requestID)
 	embedTitle := "📚 New Book Request"
 	message := fmt.Sprintf("📖 **%s**", title)
 	if authorsStr != "" {
@@ -1059,54 +1256,67 @@ func (s *Server) sendRequestNotificationDiscord(cfg *config.Config, requestID in
 	}
 	message += fmt.Sprintf("\n🙋 **Requested by:** %s", username)
 	message += fmt.Sprintf("\n🆔 **Request ID:** #%d", requestID)
-	message += fmt.Sprintf("\n\n[✅ Approve Request](%s/approve/%s) | [❌ Decline Request](%s/approve/%s) | [📋 View All Requests](%s/requests)",
-		currentCfg.ServerURL, approvalToken, currentCfg.ServerURL, declineToken, currentCfg.ServerURL)
+	for _, e := range extras {
+		message += fmt.Sprintf("\n📝 **%s:** %s", e.Label, e.Value)
+	}
+	// With an application public key the buttons below are answered by the
+	// interactions endpoint; otherwise fall back to one-time token links.
+	var components []map[string]any
+	if strings.TrimSpace(cfg.Notifications.Discord.PublicKey) != "" {
+		components = discordRequestButtons(requestID, currentCfg.ServerURL)
+	} else {
+		approvalToken := s.generateApprovalToken(requestID)
+		declineToken := s.generateDeclineToken(requestID)
+		message += fmt.Sprintf("\n\n[✅ Approve Request](%s/approve/%s) | [❌ Decline Request](%s/approve/%s) | [📋 View All Requests](%s/requests)",
+			currentCfg.ServerURL, approvalToken, currentCfg.ServerURL, declineToken, currentCfg.ServerURL)
+	}
 
 	color := 0x3b82f6 // Blue color for new requests
 
-	go func() {
-		_ = s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color)
-	}()
+	s.deliverNotification("request.created", "discord", "", func() error {
+		return s.sendDiscordMessage(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color, components)
+	})
 }
 
 // SendApprovalNotification sends a notification when a request is approved
 func (s *Server) SendApprovalNotification(username, title string, authors []string) {
 	cfg := s.settings.Get()
+	s.kickHomeAssistant()
 
 	authorsStr := strings.Join(authors, ", ")
 
-	// Send to all enabled providers
-	if cfg.Notifications.Ntfy.Enabled && cfg.Notifications.Ntfy.EnableApprovalNotifications {
-		s.sendApprovalNotificationNtfy(cfg, username, title, authorsStr)
-	}
-
-	if cfg.Notifications.SMTP.Enabled && cfg.Notifications.SMTP.EnableApprovalNotifications {
-		s.sendApprovalNotificationSMTP(cfg, username, title, authorsStr)
-	}
-
-	if cfg.Notifications.Discord.Enabled && cfg.Notifications.Discord.EnableApprovalNotifications {
-		s.sendApprovalNotificationDiscord(cfg, username, title, authorsStr)
-	}
-
 	if cfg.Notifications.Webhook.Enabled && cfg.Notifications.Webhook.EnableApprovalNotifications {
 		s.sendApprovalNotificationWebhook(cfg, username, title, authors)
 	}
 
+	s.queueNotification(notifyKindApproved, notifyEvent{Username: username, Title: title, Authors: authors, send: func() {
+		if cfg.Notifications.Ntfy.Enabled && cfg.Notifications.Ntfy.EnableApprovalNotifications {
+			s.sendApprovalNotificationNtfy(cfg, username, title, authorsStr)
+		}
+		if cfg.Notifications.SMTP.Enabled && cfg.Notifications.SMTP.EnableApprovalNotifications {
+			s.sendApprovalNotificationSMTP(cfg, username, title, authorsStr)
+		}
+		if cfg.Notifications.Discord.Enabled && cfg.Notifications.Discord.EnableApprovalNotifications {
+			s.sendApprovalNotificationDiscord(cfg, username, title, authorsStr)
+		}
+		if cfg.Notifications.Apprise.Enabled && cfg.Notifications.Apprise.EnableApprovalNotifications {
+			s.sendApprovalNotificationApprise(cfg, username, title, authorsStr)
+		}
+	}})
+
 	// Also alert the requester on their own configured channels.
-	s.notifyUserPersonal("approved", username, title, authors)
+	s.notifyUserPersonal("approved", username, title, authors, itemLink{})
 }
 
 // sendApprovalNotificationWebhook posts a generic JSON event for approved requests
 func (s *Server) sendApprovalNotificationWebhook(cfg *config.Config, username, title string, authors []string) {
-	go func() {
-		_ = s.sendWebhookNotification(cfg.Notifications.Webhook.URL, map[string]any{
-			"event":     "request.approved",
-			"title":     title,
-			"authors":   authors,
-			"requester": username,
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-	}()
+	s.sendWebhookEvent(cfg, map[string]any{
+		"event":     "request.approved",
+		"title":     title,
+		"authors":   authors,
+		"requester": username,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
 }
 
 // sendApprovalNotificationNtfy sends ntfy notification for approved requests
@@ -1128,8 +1338,8 @@ func (s *Server) sendApprovalNotificationNtfy(cfg *config.Config, username, titl
 		},
 	}
 
-	go func() {
-		_ = s.sendNtfyNotificationWithActions(
+	s.deliverNotification("request.approved", "ntfy", "", func() error {
+		return s.sendNtfyNotificationWithActions(
 			cfg.Notifications.Ntfy.Server,
 			cfg.Notifications.Ntfy.Topic,
 			cfg.Notifications.Ntfy.Username,
@@ -1139,12 +1349,12 @@ func (s *Server) sendApprovalNotificationNtfy(cfg *config.Config, username, titl
 			"default",
 			actions,
 		)
-	}()
+	})
 }
 
 // sendApprovalNotificationSMTP sends email notification for approved requests
 func (s *Server) sendApprovalNotificationSMTP(cfg *config.Config, username, title, authorsStr string) {
-	subject := "✅ Request Approved - Scriptorum"
+	subject := "✅ Request Approved - " + s.instanceName()
 
 	// HTML email content
 	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
@@ -1189,7 +1399,7 @@ func (s *Server) sendApprovalNotificationSMTP(cfg *config.Config, username, titl
 		username, s.cfg.ServerURL)
 
 	// Plain text content
-	textBody := fmt.Sprintf(`✅ Request Approved - Scriptorum
+	textBody := fmt.Sprintf(`✅ Request Approved - %s
 
 🎉 %s
 %s✅ Approved for: %s
@@ -1197,7 +1407,7 @@ func (s *Server) sendApprovalNotificationSMTP(cfg *config.Config, username, titl
 📚 Your request has been processed and should be available soon!
 
 View All Requests: %s/requests`,
-		title,
+		s.instanceName(), title,
 		func() string {
 			if authorsStr != "" {
 				return fmt.Sprintf("👤 Author(s): %s\n", authorsStr)
@@ -1206,9 +1416,9 @@ View All Requests: %s/requests`,
 		}(),
 		username, s.cfg.ServerURL)
 
-	go func() {
-		_ = s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
-	}()
+	s.deliverNotification("request.approved", "smtp", "", func() error {
+		return s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
+	})
 }
 
 // sendApprovalNotificationDiscord sends Discord notification for approved requests
@@ -1224,17 +1434,19 @@ func (s *Server) sendApprovalNotificationDiscord(cfg *config.Config, username, t
 
 	color := 0x10b981 // Green color for approved requests
 
-	go func() {
-		_ = s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color)
-	}()
+	s.deliverNotification("request.approved", "discord", "", func() error {
+		return s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color)
+	})
 }
 
 // notifyUserPersonal sends approved/available alerts to a requester's own
-// configured channels, honoring their per-event opt-ins. event is "approved"
-// or "available". Each channel is best-effort and independent: Discord and
+// configured channels, honoring their per-event opt-ins. event is "approved",
+// "available", or "expired", which is always sent since the requester has
+// to renew the request to keep it; item links an available book in the
+// library, or the page to renew an expired request on. Each channel is best-effort and independent: Discord and
 // generic webhooks are fully self-contained (the user supplies the full URL),
 // while email and ntfy ride on the admin-configured SMTP/ntfy transport.
-func (s *Server) notifyUserPersonal(event, requesterUsername, title string, authors []string) {
+func (s *Server) notifyUserPersonal(event, requesterUsername, title string, authors []string, item itemLink) {
 	if strings.TrimSpace(requesterUsername) == "" {
 		return
 	}
@@ -1251,6 +1463,7 @@ func (s *Server) notifyUserPersonal(event, requesterUsername, title string, auth
 		if !u.NotifyOnAvailable {
 			return
 		}
+	case "expired":
 	default:
 		return
 	}
@@ -1258,9 +1471,12 @@ func (s *Server) notifyUserPersonal(event, requesterUsername, title string, auth
 	cfg := s.settings.Get()
 	authorsStr := strings.Join(authors, ", ")
 	var verb, emoji string
-	if event == "available" {
+	switch event {
+	case "available":
 		verb, emoji = "is now available", "📗"
-	} else {
+	case "expired":
+		verb, emoji = "expired without approval", "⌛"
+	default:
 		verb, emoji = "was approved", "✅"
 	}
 	subject := fmt.Sprintf("%s \"%s\" %s", emoji, title, verb)
@@ -1268,6 +1484,9 @@ func (s *Server) notifyUserPersonal(event, requesterUsername, title string, auth
 	if authorsStr != "" {
 		body += " (by " + authorsStr + ")"
 	}
+	if event == "expired" {
+		body += ". Renew it to put it back in the approval queue."
+	}
 	link := strings.TrimSpace(cfg.ServerURL)
 
 	// Email via the admin-configured SMTP transport, overriding the recipient.
@@ -1275,86 +1494,117 @@ func (s *Server) notifyUserPersonal(event, requesterUsername, title string, auth
 		smtpCfg := cfg.Notifications.SMTP
 		smtpCfg.ToEmail = e
 		html := fmt.Sprintf("<p>%s</p>", body)
+		text := body
+		if item.URL != "" {
+			html += fmt.Sprintf(`<p><a href="%s">%s</a></p>`, item.URL, item.Label)
+			text += "\n\n" + item.Label + ": " + item.URL
+		}
 		if link != "" {
 			html += fmt.Sprintf(`<p><a href="%s/requests">View your requests</a></p>`, link)
 		}
-		go func() { _ = s.sendSMTPNotification(smtpCfg, subject, html, body) }()
+		s.deliverNotification("request."+event, "smtp", "user:"+u.Username, func() error { return s.sendSMTPNotification(smtpCfg, subject, html, text) })
 	}
 
 	// Personal ntfy topic on the admin's ntfy server.
-	if topic := strings.TrimSpace(u.NotifyNtfyTopic); topic != "" {
-		server := strings.TrimSpace(cfg.Notifications.Ntfy.Server)
-		if server == "" {
-			server = "https://ntfy.sh"
+	server := strings.TrimSpace(cfg.Notifications.Ntfy.Server)
+	if server == "" {
+		server = s.defaultNtfyServer()
+	}
+	if topic := strings.TrimSpace(u.NotifyNtfyTopic); topic != "" && server != "" {
+		var actions []map[string]string
+		if item.URL != "" {
+			actions = append(actions, map[string]string{"action": "view", "label": item.Label, "url": item.URL})
 		}
-		go func() {
-			_ = s.sendNtfyNotification(server, topic, cfg.Notifications.Ntfy.Username, cfg.Notifications.Ntfy.Password, subject, body, "default")
-		}()
+		s.deliverNotification("request."+event, "ntfy", "user:"+u.Username, func() error {
+			return s.sendNtfyNotificationWithActions(server, topic, cfg.Notifications.Ntfy.Username, cfg.Notifications.Ntfy.Password, subject, body, "default", actions)
+		})
 	}
 
 	// Self-contained personal Discord webhook.
 	if wh := strings.TrimSpace(u.NotifyDiscordWebhook); wh != "" {
 		msg := body
+		if item.URL != "" {
+			msg += fmt.Sprintf("\n\n[%s](%s)", item.Label, item.URL)
+		}
 		if link != "" {
 			msg += fmt.Sprintf("\n\n[📋 View your requests](%s/requests)", link)
 		}
-		go func() { _ = s.sendDiscordNotification(wh, "Scriptorum", subject, msg, 0x10b981) }()
+		s.deliverNotification("request."+event, "discord", "user:"+u.Username, func() error { return s.sendDiscordNotification(wh, s.instanceName(), subject, msg, 0x10b981) })
 	}
 
+	// Web Push to the browsers and installed apps the user turned it on in.
+	push := webPushMessage{Title: subject, Body: body, URL: "/requests"}
+	if item.URL != "" {
+		push.URL = item.URL
+	}
+	s.pushToUser("request."+event, u.Username, push)
+
 	// Self-contained personal generic webhook.
 	if wh := strings.TrimSpace(u.NotifyWebhookURL); wh != "" {
-		go func() {
-			_ = s.sendWebhookNotification(wh, map[string]any{
-				"event":     "request." + event,
-				"title":     title,
-				"authors":   authors,
-				"requester": u.Username,
-				"timestamp": time.Now().Format(time.RFC3339),
-			})
-		}()
+		payload := map[string]any{
+			"event":     "request." + event,
+			"title":     title,
+			"authors":   authors,
+			"requester": u.Username,
+			"timestamp": time.Now().Format(time.RFC3339),
+		}
+		if item.URL != "" {
+			payload["link"] = item.URL
+		}
+		s.deliverNotification("request."+event, "webhook", "user:"+u.Username, func() error {
+			return s.sendWebhookNotification(wh, payload)
+		})
 	}
 }
 
 // SendAvailableNotification announces that a previously-requested title has
 // finished downloading and is now available in the library. It reuses the
 // per-channel "approval" notification toggle, since it is part of the same
-// request-fulfillment lifecycle (approved -> available).
-func (s *Server) SendAvailableNotification(username, title string, authors []string) {
+// request-fulfillment lifecycle (approved -> available). item, when set,
+// links the book on the library server it can be read or listened to on.
+func (s *Server) SendAvailableNotification(username, title string, authors []string, item itemLink) {
 	cfg := s.settings.Get()
 	authorsStr := strings.Join(authors, ", ")
 
-	if cfg.Notifications.Ntfy.Enabled && cfg.Notifications.Ntfy.EnableAvailableNotifications {
-		s.sendAvailableNotificationNtfy(cfg, username, title, authorsStr)
-	}
-	if cfg.Notifications.SMTP.Enabled && cfg.Notifications.SMTP.EnableAvailableNotifications {
-		s.sendAvailableNotificationSMTP(cfg, username, title, authorsStr)
-	}
-	if cfg.Notifications.Discord.Enabled && cfg.Notifications.Discord.EnableAvailableNotifications {
-		s.sendAvailableNotificationDiscord(cfg, username, title, authorsStr)
-	}
 	if cfg.Notifications.Webhook.Enabled && cfg.Notifications.Webhook.EnableAvailableNotifications {
-		s.sendAvailableNotificationWebhook(cfg, username, title, authors)
+		s.sendAvailableNotificationWebhook(cfg, username, title, authors, item)
 	}
+	s.queueNotification(notifyKindAvailable, notifyEvent{Username: username, Title: title, Authors: authors, Link: item, send: func() {
+		if cfg.Notifications.Ntfy.Enabled && cfg.Notifications.Ntfy.EnableAvailableNotifications {
+			s.sendAvailableNotificationNtfy(cfg, username, title, authorsStr, item)
+		}
+		if cfg.Notifications.SMTP.Enabled && cfg.Notifications.SMTP.EnableAvailableNotifications {
+			s.sendAvailableNotificationSMTP(cfg, username, title, authorsStr, item)
+		}
+		if cfg.Notifications.Discord.Enabled && cfg.Notifications.Discord.EnableAvailableNotifications {
+			s.sendAvailableNotificationDiscord(cfg, username, title, authorsStr, item)
+		}
+		if cfg.Notifications.Apprise.Enabled && cfg.Notifications.Apprise.EnableAvailableNotifications {
+			s.sendAvailableNotificationApprise(cfg, username, title, authorsStr, item)
+		}
+	}})
 
 	// Also alert the requester on their own configured channels.
-	s.notifyUserPersonal("available", username, title, authors)
+	s.notifyUserPersonal("available", username, title, authors, item)
 }
 
 // sendAvailableNotificationWebhook posts a generic JSON event for available titles
-func (s *Server) sendAvailableNotificationWebhook(cfg *config.Config, username, title string, authors []string) {
-	go func() {
-		_ = s.sendWebhookNotification(cfg.Notifications.Webhook.URL, map[string]any{
-			"event":     "request.available",
-			"title":     title,
-			"authors":   authors,
-			"requester": username,
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-	}()
+func (s *Server) sendAvailableNotificationWebhook(cfg *config.Config, username, title string, authors []string, item itemLink) {
+	payload := map[string]any{
+		"event":     "request.available",
+		"title":     title,
+		"authors":   authors,
+		"requester": username,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if item.URL != "" {
+		payload["link"] = item.URL
+	}
+	s.sendWebhookEvent(cfg, payload)
 }
 
 // sendAvailableNotificationNtfy sends ntfy notification for available titles
-func (s *Server) sendAvailableNotificationNtfy(cfg *config.Config, username, title, authorsStr string) {
+func (s *Server) sendAvailableNotificationNtfy(cfg *config.Config, username, title, authorsStr string, item itemLink) {
 	message := fmt.Sprintf("📗 **%s**", title)
 	if authorsStr != "" {
 		message += fmt.Sprintf("\n👤 *by %s*", authorsStr)
@@ -1370,9 +1620,12 @@ func (s *Server) sendAvailableNotificationNtfy(cfg *config.Config, username, tit
 			"url":    currentCfg.ServerURL + "/requests",
 		},
 	}
+	if item.URL != "" {
+		actions = append([]map[string]string{{"action": "view", "label": item.Label, "url": item.URL}}, actions...)
+	}
 
-	go func() {
-		_ = s.sendNtfyNotificationWithActions(
+	s.deliverNotification("request.available", "ntfy", "", func() error {
+		return s.sendNtfyNotificationWithActions(
 			cfg.Notifications.Ntfy.Server,
 			cfg.Notifications.Ntfy.Topic,
 			cfg.Notifications.Ntfy.Username,
@@ -1382,12 +1635,12 @@ func (s *Server) sendAvailableNotificationNtfy(cfg *config.Config, username, tit
 			"default",
 			actions,
 		)
-	}()
+	})
 }
 
 // sendAvailableNotificationSMTP sends email notification for available titles
-func (s *Server) sendAvailableNotificationSMTP(cfg *config.Config, username, title, authorsStr string) {
-	subject := "📗 Book Available - Scriptorum"
+func (s *Server) sendAvailableNotificationSMTP(cfg *config.Config, username, title, authorsStr string, item itemLink) {
+	subject := "📗 Book Available - " + s.instanceName()
 
 	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
 <html>
@@ -1416,7 +1669,7 @@ func (s *Server) sendAvailableNotificationSMTP(cfg *config.Config, username, tit
 				<p>🎧 <em>Your book has finished downloading and is ready to read.</em></p>
 			</div>
 			<div class="actions">
-				<a href="%s/requests" class="button">📋 View All Requests</a>
+				%s<a href="%s/requests" class="button">📋 View All Requests</a>
 			</div>
 		</div>
 	</div>
@@ -1428,32 +1681,46 @@ func (s *Server) sendAvailableNotificationSMTP(cfg *config.Config, username, tit
 			}
 			return ""
 		}(),
-		username, s.cfg.ServerURL)
+		username,
+		func() string {
+			if item.URL != "" {
+				return fmt.Sprintf(`<a href="%s" class="button" style="background: #10b981;">%s</a>`, item.URL, item.Label)
+			}
+			return ""
+		}(),
+		s.cfg.ServerURL)
 
-	textBody := fmt.Sprintf(`📗 Book Available - Scriptorum
+	textBody := fmt.Sprintf(`📗 Book Available - %s
 
 %s
 %s📥 Now available for: %s
 
 🎧 Your book has finished downloading and is ready to read.
 
-View All Requests: %s/requests`,
-		title,
+%sView All Requests: %s/requests`,
+		s.instanceName(), title,
 		func() string {
 			if authorsStr != "" {
 				return fmt.Sprintf("👤 Author(s): %s\n", authorsStr)
 			}
 			return ""
 		}(),
-		username, s.cfg.ServerURL)
+		username,
+		func() string {
+			if item.URL != "" {
+				return fmt.Sprintf("%s: %s\n", item.Label, item.URL)
+			}
+			return ""
+		}(),
+		s.cfg.ServerURL)
 
-	go func() {
-		_ = s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
-	}()
+	s.deliverNotification("request.available", "smtp", "", func() error {
+		return s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
+	})
 }
 
 // sendAvailableNotificationDiscord sends Discord notification for available titles
-func (s *Server) sendAvailableNotificationDiscord(cfg *config.Config, username, title, authorsStr string) {
+func (s *Server) sendAvailableNotificationDiscord(cfg *config.Config, username, title, authorsStr string, item itemLink) {
 	embedTitle := "📗 Book Available"
 	message := fmt.Sprintf("📗 **%s**", title)
 	if authorsStr != "" {
@@ -1461,13 +1728,17 @@ func (s *Server) sendAvailableNotificationDiscord(cfg *config.Config, username,
 	}
 	message += fmt.Sprintf("\n📥 **Now available for:** %s", username)
 	message += "\n\n🎧 *Your book has finished downloading and is ready to read.*"
-	message += "\n\n[📋 View All Requests](" + s.cfg.ServerURL + "/requests)"
+	message += "\n\n"
+	if item.URL != "" {
+		message += fmt.Sprintf("[%s](%s) | ", item.Label, item.URL)
+	}
+	message += "[📋 View All Requests](" + s.cfg.ServerURL + "/requests)"
 
 	color := 0x10b981 // Green for available
 
-	go func() {
-		_ = s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color)
-	}()
+	s.deliverNotification("request.available", "discord", "", func() error {
+		return s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color)
+	})
 }
 
 // SendSystemNotification sends a system notification
@@ -1487,6 +1758,10 @@ func (s *Server) SendSystemNotification(title, message string) {
 		s.sendSystemNotificationDiscord(cfg, title, message)
 	}
 
+	if cfg.Notifications.Apprise.Enabled && cfg.Notifications.Apprise.EnableSystemNotifications {
+		s.sendSystemNotificationApprise(cfg, title, message)
+	}
+
 	if cfg.Notifications.Webhook.Enabled && cfg.Notifications.Webhook.EnableSystemNotifications {
 		s.sendSystemNotificationWebhook(cfg, title, message)
 	}
@@ -1494,20 +1769,18 @@ func (s *Server) SendSystemNotification(title, message string) {
 
 // sendSystemNotificationWebhook posts a generic JSON event for system alerts
 func (s *Server) sendSystemNotificationWebhook(cfg *config.Config, title, message string) {
-	go func() {
-		_ = s.sendWebhookNotification(cfg.Notifications.Webhook.URL, map[string]any{
-			"event":     "system.alert",
-			"title":     title,
-			"message":   message,
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-	}()
+	s.sendWebhookEvent(cfg, map[string]any{
+		"event":     "system.alert",
+		"title":     title,
+		"message":   message,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
 }
 
 // sendSystemNotificationNtfy sends ntfy notification for system alerts
 func (s *Server) sendSystemNotificationNtfy(cfg *config.Config, title, message string) {
-	go func() {
-		_ = s.sendNtfyNotification(
+	s.deliverNotification("system.alert", "ntfy", "", func() error {
+		return s.sendNtfyNotification(
 			cfg.Notifications.Ntfy.Server,
 			cfg.Notifications.Ntfy.Topic,
 			cfg.Notifications.Ntfy.Username,
@@ -1516,12 +1789,12 @@ func (s *Server) sendSystemNotificationNtfy(cfg *config.Config, title, message s
 			message,
 			"high",
 		)
-	}()
+	})
 }
 
 // sendSystemNotificationSMTP sends email notification for system alerts
 func (s *Server) sendSystemNotificationSMTP(cfg *config.Config, title, message string) {
-	subject := fmt.Sprintf("🚨 %s - Scriptorum", title)
+	subject := fmt.Sprintf("🚨 %s - %s", title, s.instanceName())
 
 	// HTML email content
 	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
@@ -1548,23 +1821,23 @@ func (s *Server) sendSystemNotificationSMTP(cfg *config.Config, title, message s
 				<p>%s</p>
 			</div>
 			<div class="actions">
-				<a href="%s" class="button">🌐 Open Scriptorum</a>
+				<a href="%s" class="button">🌐 Open %s</a>
 			</div>
 		</div>
 	</div>
 </body>
-</html>`, title, message, s.cfg.ServerURL)
+</html>`, title, message, s.cfg.ServerURL, template.HTMLEscapeString(s.instanceName()))
 
 	// Plain text content
-	textBody := fmt.Sprintf(`🚨 %s - Scriptorum
+	textBody := fmt.Sprintf(`🚨 %s - %s
 
 %s
 
-Open Scriptorum: %s`, title, message, s.cfg.ServerURL)
+Open %s: %s`, title, s.instanceName(), message, s.instanceName(), s.cfg.ServerURL)
 
-	go func() {
-		_ = s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
-	}()
+	s.deliverNotification("system.alert", "smtp", "", func() error {
+		return s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
+	})
 }
 
 // sendSystemNotificationDiscord sends Discord notification for system alerts
@@ -1572,7 +1845,7 @@ func (s *Server) sendSystemNotificationDiscord(cfg *config.Config, title, messag
 	embedTitle := fmt.Sprintf("🚨 %s", title)
 	color := 0xef4444 // Red color for system alerts
 
-	go func() {
-		_ = s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color)
-	}()
+	s.deliverNotification("system.alert", "discord", "", func() error {
+		return s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, embedTitle, message, color)
+	})
 }
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
 )
 
 func TestNotificationsPage(t *testing.T) {
@@ -651,3 +656,185 @@ func TestApprovalHandler(t *testing.T) {
 		t.Error("Decline token should expire within 1 hour")
 	}
 }
+
+func TestNtfyHTTPActionApprovesWithoutBrowser(t *testing.T) {
+	server := newServerForTest(t)
+	bodies := make(chan []byte, 1)
+	ntfyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- b
+	}))
+	defer ntfyServer.Close()
+
+	cfg := server.settings.Get()
+	cfg.ServerURL = "https://books.example.com"
+	cfg.Notifications.Ntfy.Enabled = true
+	cfg.Notifications.Ntfy.Server = ntfyServer.URL
+	cfg.Notifications.Ntfy.Topic = "requests"
+	cfg.Notifications.Ntfy.EnableRequestNotifications = true
+	server.settings.Update(cfg)
+
+	id, err := server.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SendRequestNotification(id, "alice", "Dune", []string{"Frank Herbert"})
+
+	var payload struct {
+		Message string              `json:"message"`
+		Actions []map[string]string `json:"actions"`
+	}
+	select {
+	case b := <-bodies:
+		if err := json.Unmarshal(b, &payload); err != nil {
+			t.Fatalf("ntfy payload: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ntfy notification sent")
+	}
+	if len(payload.Actions) != 3 || payload.Actions[0]["action"] != "http" || payload.Actions[0]["method"] != "POST" {
+		t.Fatalf("actions = %+v", payload.Actions)
+	}
+	approveURL := payload.Actions[0]["url"]
+	if !strings.HasPrefix(approveURL, "https://books.example.com/api/v1/approve/") {
+		t.Fatalf("approve url = %q", approveURL)
+	}
+	if !strings.Contains(payload.Message, "https://books.example.com/approve/") {
+		t.Fatalf("message has no fallback link: %s", payload.Message)
+	}
+
+	h := server.Router()
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, strings.TrimPrefix(approveURL, "https://books.example.com"), nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := post(); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"approved"`) {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := server.db.GetRequest(context.Background(), id); got.Status != "approved" {
+		t.Fatalf("status = %q", got.Status)
+	}
+	if rec := post(); rec.Code != http.StatusNotFound {
+		t.Fatalf("reused token: %d", rec.Code)
+	}
+}
+
+func TestApprovalTokenConcurrentRedeemActsOnce(t *testing.T) {
+	server := newServerForTest(t)
+	id, err := server.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := server.generateApprovalToken(id)
+
+	const n = 8
+	var wg sync.WaitGroup
+	codes := make(chan int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := server.redeemApprovalToken(context.Background(), token)
+			var tokenErr *errApprovalToken
+			switch {
+			case err == nil:
+				codes <- http.StatusOK
+			case errors.As(err, &tokenErr):
+				codes <- tokenErr.code
+			default:
+				codes <- 0
+			}
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	counts := map[int]int{}
+	for c := range codes {
+		counts[c]++
+	}
+	if counts[http.StatusOK] != 1 || counts[http.StatusNotFound] != n-1 {
+		t.Fatalf("redeem results = %v, want one 200 and %d 404s", counts, n-1)
+	}
+	if got, _ := server.db.GetRequest(context.Background(), id); got.Status != "approved" {
+		t.Fatalf("status = %q", got.Status)
+	}
+}
+
+func TestApprovalChannelsRaceActsOnce(t *testing.T) {
+	server := newServerForTest(t)
+	ctx := context.Background()
+	if _, err := server.db.CreateUser(ctx, "admin", "hash", true, false); err != nil {
+		t.Fatal(err)
+	}
+	admin, _ := server.db.GetUserByUsername(ctx, "admin")
+	if err := server.db.SetUserEmail(ctx, admin.ID, "admin@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := server.settings.Get()
+	cfg.Notifications.SMTP.ToEmail = "admin@example.com"
+	if err := server.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	id, err := server.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	declineToken := server.generateDeclineToken(id)
+	reply := "From: admin@example.com\r\nSubject: Re: New Book Request " +
+		mailReplyTag(id, server.mailReplyRef(id, "admin@example.com", time.Now().Add(time.Hour))) + "\r\n\r\napprove\r\n"
+	h := server.Router()
+	cookie := makeCookie(t, server, "admin", true)
+	loaded, _ := server.db.GetRequest(ctx, id)
+
+	// An ntfy decline, an email approval, and a web decline from a page
+	// showing the pending request land at once; exactly one of them may
+	// change the request.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var won []string
+	act := func(name string, f func() bool) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if f() {
+				mu.Lock()
+				won = append(won, name)
+				mu.Unlock()
+			}
+		}()
+	}
+	act("token", func() bool {
+		_, _, err := server.redeemApprovalToken(ctx, declineToken)
+		return err == nil
+	})
+	act("email", func() bool { return server.handleMailReply(ctx, []byte(reply)) == nil })
+	act("web", func() bool {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+itoa(id)+"/decline", nil)
+		req.Header.Set("If-Match", `"`+requestVersion(loaded)+`"`)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code == http.StatusOK
+	})
+	wg.Wait()
+	if len(won) != 1 {
+		t.Fatalf("channels that acted = %v, want exactly one", won)
+	}
+	got, _ := server.db.GetRequest(ctx, id)
+	if want := map[string]string{"token": "declined", "email": "approved", "web": "declined"}[won[0]]; got.Status != want {
+		t.Fatalf("%s won but status = %q", won[0], got.Status)
+	}
+
+	// A decline link for a request that was approved since is refused.
+	approved, _ := server.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Emma", Format: "ebook", Status: "approved"})
+	_, _, err = server.redeemApprovalToken(ctx, server.generateDeclineToken(approved))
+	var tokenErr *errApprovalToken
+	if !errors.As(err, &tokenErr) || tokenErr.code != http.StatusConflict {
+		t.Fatalf("stale decline link = %v", err)
+	}
+	if got, _ := server.db.GetRequest(ctx, approved); got.Status != "approved" {
+		t.Fatalf("stale decline link changed the request: %q", got.Status)
+	}
+}
@@ -0,0 +1,193 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// Apprise notification types, which pick the icon and colour downstream.
+const (
+	appriseInfo    = "info"
+	appriseSuccess = "success"
+	appriseWarning = "warning"
+	appriseFailure = "failure"
+)
+
+// appriseNotifyURL is where a notification is posted: /notify/{key} for a
+// configuration stored on the server, or /notify with URLs in the body.
+func appriseNotifyURL(ac config.AppriseConfig) (string, error) {
+	server := strings.TrimRight(strings.TrimSpace(ac.ServerURL), "/")
+	if server == "" {
+		return "", fmt.Errorf("apprise server URL is required")
+	}
+	if key := strings.TrimSpace(ac.ConfigKey); key != "" {
+		return server + "/notify/" + url.PathEscape(key), nil
+	}
+	if len(appriseURLs(ac.URLs)) == 0 {
+		return "", fmt.Errorf("apprise config key or at least one URL is required")
+	}
+	return server + "/notify", nil
+}
+
+// appriseURLs drops blank entries from a list of Apprise service URLs.
+func appriseURLs(urls []string) []string {
+	var out []string
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// parseAppriseURLs reads the settings textarea: one URL per line, or
+// separated by commas or spaces as Apprise itself accepts them.
+func parseAppriseURLs(text string) []string {
+	return appriseURLs(strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ',' || r == ' ' || r == '\t'
+	}))
+}
+
+// sendAppriseNotification posts a Markdown notification to the Apprise API
+// server, which relays it to every service it is configured for.
+func (s *Server) sendAppriseNotification(ac config.AppriseConfig, title, body, notifyType string) error {
+	endpoint, err := appriseNotifyURL(ac)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"title":  title,
+		"body":   body,
+		"type":   notifyType,
+		"format": "markdown",
+	}
+	if strings.TrimSpace(ac.ConfigKey) != "" {
+		if tag := strings.TrimSpace(ac.Tag); tag != "" {
+			payload["tag"] = tag
+		}
+	} else {
+		payload["urls"] = strings.Join(appriseURLs(ac.URLs), ",")
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Apprise payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create Apprise request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := s.outboundHTTPClient(15 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Apprise notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return &notifyStatusError{What: "apprise server", Code: resp.StatusCode}
+	}
+	return nil
+}
+
+// apiTestApprise tests the Apprise configuration by sending a test message
+func (s *Server) apiTestApprise() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ServerURL string `json:"server_url"`
+			ConfigKey string `json:"config_key"`
+			URLs      string `json:"urls"`
+			Tag       string `json:"tag"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, map[string]any{"success": false, "error": "Invalid request"}, 400)
+			return
+		}
+
+		ac := config.AppriseConfig{ServerURL: req.ServerURL, ConfigKey: req.ConfigKey, URLs: parseAppriseURLs(req.URLs), Tag: req.Tag}
+		if _, err := appriseNotifyURL(ac); err != nil {
+			writeJSON(w, map[string]any{"success": false, "error": err.Error()}, 400)
+			return
+		}
+
+		title := "🧪 " + s.instanceName() + " Apprise Test"
+		message := "✅ **Configuration is working correctly!**\n\nThis is a test message to verify your Apprise configuration."
+		if err := s.sendAppriseNotification(ac, title, message, appriseInfo); err != nil {
+			writeJSON(w, map[string]any{"success": false, "error": err.Error()}, 500)
+			return
+		}
+		writeJSON(w, map[string]any{"success": true}, 200)
+	}
+}
+
+// sendRequestNotificationApprise sends an Apprise notification for new requests
+func (s *Server) sendRequestNotificationApprise(cfg *config.Config, requestID int64, username, title, authorsStr string, extras []db.RequestExtra) {
+	serverURL := s.settings.Get().ServerURL
+	message := fmt.Sprintf("📖 **%s**", title)
+	if authorsStr != "" {
+		message += fmt.Sprintf("\n👤 **Author(s):** %s", authorsStr)
+	}
+	message += fmt.Sprintf("\n🙋 **Requested by:** %s", username)
+	message += fmt.Sprintf("\n🆔 **Request ID:** #%d", requestID)
+	for _, e := range extras {
+		message += fmt.Sprintf("\n📝 **%s:** %s", e.Label, e.Value)
+	}
+	message += fmt.Sprintf("\n\n[✅ Approve Request](%s/approve/%s) | [❌ Decline Request](%s/approve/%s) | [📋 View All Requests](%s/requests)",
+		serverURL, s.generateApprovalToken(requestID), serverURL, s.generateDeclineToken(requestID), serverURL)
+
+	s.deliverNotification("request.created", "apprise", "", func() error {
+		return s.sendAppriseNotification(cfg.Notifications.Apprise, "📚 New Book Request", message, appriseInfo)
+	})
+}
+
+// sendApprovalNotificationApprise sends an Apprise notification for approved requests
+func (s *Server) sendApprovalNotificationApprise(cfg *config.Config, username, title, authorsStr string) {
+	message := fmt.Sprintf("🎉 **%s**", title)
+	if authorsStr != "" {
+		message += fmt.Sprintf("\n👤 **Author(s):** %s", authorsStr)
+	}
+	message += fmt.Sprintf("\n✅ **Approved for:** %s", username)
+	message += "\n\n[📋 View All Requests](" + s.cfg.ServerURL + "/requests)"
+
+	s.deliverNotification("request.approved", "apprise", "", func() error {
+		return s.sendAppriseNotification(cfg.Notifications.Apprise, "✅ Request Approved", message, appriseSuccess)
+	})
+}
+
+// sendAvailableNotificationApprise sends an Apprise notification for available titles
+func (s *Server) sendAvailableNotificationApprise(cfg *config.Config, username, title, authorsStr string, item itemLink) {
+	message := fmt.Sprintf("📗 **%s**", title)
+	if authorsStr != "" {
+		message += fmt.Sprintf("\n👤 **Author(s):** %s", authorsStr)
+	}
+	message += fmt.Sprintf("\n📥 **Now available for:** %s", username)
+	message += "\n\n"
+	if item.URL != "" {
+		message += fmt.Sprintf("[%s](%s) | ", item.Label, item.URL)
+	}
+	message += "[📋 View All Requests](" + s.cfg.ServerURL + "/requests)"
+
+	s.deliverNotification("request.available", "apprise", "", func() error {
+		return s.sendAppriseNotification(cfg.Notifications.Apprise, "📗 Book Available", message, appriseSuccess)
+	})
+}
+
+// sendSystemNotificationApprise sends an Apprise notification for system alerts
+func (s *Server) sendSystemNotificationApprise(cfg *config.Config, title, message string) {
+	s.deliverNotification("system.alert", "apprise", "", func() error {
+		return s.sendAppriseNotification(cfg.Notifications.Apprise, "🚨 "+title, message, appriseFailure)
+	})
+}
@@ -0,0 +1,85 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+type appriseHit struct {
+	path string
+	body map[string]any
+}
+
+func TestAppriseNotificationsUseConfigKeyOrURLs(t *testing.T) {
+	hits := make(chan appriseHit, 4)
+	apprise := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		hits <- appriseHit{r.URL.Path, body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apprise.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Notifications.Apprise = config.AppriseConfig{
+		Enabled:                   true,
+		ServerURL:                 apprise.URL + "/",
+		ConfigKey:                 "books",
+		Tag:                       "admins",
+		EnableSystemNotifications: true,
+	}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	s.SendSystemNotification("Readarr down", "details")
+	var h appriseHit
+	select {
+	case h = <-hits:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no Apprise post arrived")
+	}
+	if h.path != "/notify/books" || h.body["title"] != "🚨 Readarr down" || h.body["type"] != appriseFailure || h.body["tag"] != "admins" || h.body["urls"] != nil {
+		t.Fatalf("stateful post = %+v", h)
+	}
+
+	ac := config.AppriseConfig{ServerURL: apprise.URL, URLs: []string{"tgram://a/b", " ", "pover://c@d"}}
+	if err := s.sendAppriseNotification(ac, "t", "b", appriseInfo); err != nil {
+		t.Fatal(err)
+	}
+	h = <-hits
+	if h.path != "/notify" || h.body["urls"] != "tgram://a/b,pover://c@d" || h.body["format"] != "markdown" {
+		t.Fatalf("stateless post = %+v", h)
+	}
+}
+
+func TestSendAppriseNotificationErrors(t *testing.T) {
+	s := newServerForTest(t)
+	if err := s.sendAppriseNotification(config.AppriseConfig{ServerURL: "http://apprise"}, "t", "b", appriseInfo); err == nil {
+		t.Fatal("expected an error without a config key or URLs")
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFailedDependency)
+	}))
+	defer failing.Close()
+	err := s.sendAppriseNotification(config.AppriseConfig{ServerURL: failing.URL, ConfigKey: "k"}, "t", "b", appriseInfo)
+	if err == nil || isTransientNotifyError(err) {
+		t.Fatalf("err = %v, want a permanent error", err)
+	}
+}
+
+func TestParseAppriseURLs(t *testing.T) {
+	got := parseAppriseURLs("tgram://a/b\r\n\nslack://x/y, pover://c@d")
+	want := []string{"tgram://a/b", "slack://x/y", "pover://c@d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v", got)
+	}
+}
@@ -0,0 +1,234 @@
+package httpapi
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notification kinds that can be batched.
+const (
+	notifyKindRequest   = "request"
+	notifyKindApproved  = "approved"
+	notifyKindAvailable = "available"
+)
+
+// maxBatchWindowSeconds caps notifications.batch_window_seconds.
+const maxBatchWindowSeconds = 600
+
+// maxSummaryLines caps how many titles a batch summary lists.
+const maxSummaryLines = 15
+
+// notifyEvent is one request, approval, or availability notification. send
+// delivers it on its own when it turns out not to be part of a burst.
+type notifyEvent struct {
+	RequestID int64
+	Username  string
+	Title     string
+	Authors   []string
+	// Link points availability events at the book in the library.
+	Link itemLink
+	send func()
+}
+
+// notifyBatchWindow is how long the first event of a burst waits for more
+// before a summary goes out; 0 sends every event right away.
+func (s *Server) notifyBatchWindow() time.Duration {
+	n := s.settings.Get().Notifications.BatchWindowSeconds
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// queueNotification sends ev now, or holds it for the batch window so a burst
+// of the same kind (a bulk import, approve-all) becomes one message.
+func (s *Server) queueNotification(kind string, ev notifyEvent) {
+	window := s.notifyBatchWindow()
+	if window <= 0 {
+		ev.send()
+		return
+	}
+	s.notifyBatchMu.Lock()
+	defer s.notifyBatchMu.Unlock()
+	if s.notifyBatch == nil {
+		s.notifyBatch = map[string][]notifyEvent{}
+	}
+	s.notifyBatch[kind] = append(s.notifyBatch[kind], ev)
+	if len(s.notifyBatch[kind]) == 1 {
+		time.AfterFunc(window, func() { s.flushNotifications(kind) })
+	}
+}
+
+// flushNotifications sends the events held for kind: a lone event as usual,
+// several as one summary.
+func (s *Server) flushNotifications(kind string) {
+	s.notifyBatchMu.Lock()
+	events := s.notifyBatch[kind]
+	delete(s.notifyBatch, kind)
+	s.notifyBatchMu.Unlock()
+	switch len(events) {
+	case 0:
+		return
+	case 1:
+		events[0].send()
+		return
+	}
+	s.sendNotificationSummary(kind, events)
+}
+
+// sendNotificationSummary sends one message listing a burst of events to
+// each chat provider that has kind's notifications on.
+func (s *Server) sendNotificationSummary(kind string, events []notifyEvent) {
+	cfg := s.settings.Get()
+	n := len(events)
+	var heading, event string
+	var color int
+	var ntfyOn, smtpOn, discordOn, appriseOn bool
+	switch kind {
+	case notifyKindRequest:
+		heading, event, color = fmt.Sprintf("📚 %d New Book Requests", n), "request.created", 0x3b82f6
+		ntfyOn = cfg.Notifications.Ntfy.EnableRequestNotifications
+		smtpOn = cfg.Notifications.SMTP.EnableRequestNotifications
+		discordOn = cfg.Notifications.Discord.EnableRequestNotifications
+		appriseOn = cfg.Notifications.Apprise.EnableRequestNotifications
+	case notifyKindApproved:
+		heading, event, color = fmt.Sprintf("✅ %d Requests Approved", n), "request.approved", 0x10b981
+		ntfyOn = cfg.Notifications.Ntfy.EnableApprovalNotifications
+		smtpOn = cfg.Notifications.SMTP.EnableApprovalNotifications
+		discordOn = cfg.Notifications.Discord.EnableApprovalNotifications
+		appriseOn = cfg.Notifications.Apprise.EnableApprovalNotifications
+	case notifyKindAvailable:
+		heading, event, color = fmt.Sprintf("📗 %d Books Now Available", n), "request.available", 0x10b981
+		ntfyOn = cfg.Notifications.Ntfy.EnableAvailableNotifications
+		smtpOn = cfg.Notifications.SMTP.EnableAvailableNotifications
+		discordOn = cfg.Notifications.Discord.EnableAvailableNotifications
+		appriseOn = cfg.Notifications.Apprise.EnableAvailableNotifications
+	default:
+		return
+	}
+
+	var md, text, html strings.Builder
+	for i, ev := range events {
+		if i == maxSummaryLines {
+			more := fmt.Sprintf("…and %d more", n-maxSummaryLines)
+			md.WriteString("\n" + more)
+			text.WriteString(more + "\n")
+			fmt.Fprintf(&html, "<li>%s</li>", more)
+			break
+		}
+		detail := ev.Username
+		if ev.RequestID > 0 {
+			detail = fmt.Sprintf("%s, #%d", ev.Username, ev.RequestID)
+		}
+		by := ""
+		if len(ev.Authors) > 0 {
+			by = " by " + strings.Join(ev.Authors, ", ")
+		}
+		fmt.Fprintf(&md, "\n• **%s**%s (%s)", ev.Title, by, detail)
+		fmt.Fprintf(&text, "• %s%s (%s)", ev.Title, by, detail)
+		fmt.Fprintf(&html, "<li><strong>%s</strong>%s (%s)", template.HTMLEscapeString(ev.Title), template.HTMLEscapeString(by), template.HTMLEscapeString(detail))
+		if ev.Link.URL != "" {
+			fmt.Fprintf(&md, " [%s](%s)", ev.Link.Label, ev.Link.URL)
+			fmt.Fprintf(&text, " %s", ev.Link.URL)
+			fmt.Fprintf(&html, ` <a href="%s">%s</a>`, template.HTMLEscapeString(ev.Link.URL), template.HTMLEscapeString(ev.Link.Label))
+		}
+		text.WriteString("\n")
+		html.WriteString("</li>")
+	}
+	link := strings.TrimSpace(cfg.ServerURL) + "/requests"
+
+	if cfg.Notifications.Ntfy.Enabled && ntfyOn {
+		ntfy := cfg.Notifications.Ntfy
+		actions := []map[string]string{{"action": "view", "label": "📋 View All Requests", "url": link}}
+		s.deliverNotification(event, "ntfy", "", func() error {
+			return s.sendNtfyNotificationWithActions(ntfy.Server, ntfy.Topic, ntfy.Username, ntfy.Password, heading, strings.TrimPrefix(md.String(), "\n"), "default", actions)
+		})
+	}
+	if cfg.Notifications.SMTP.Enabled && smtpOn {
+		smtpCfg := cfg.Notifications.SMTP
+		htmlBody := fmt.Sprintf(`<h2>%s</h2><ul>%s</ul><p><a href="%s">View all requests</a></p>`, heading, html.String(), link)
+		textBody := fmt.Sprintf("%s\n\n%s\nView all requests: %s", heading, text.String(), link)
+		s.deliverNotification(event, "smtp", "", func() error {
+			return s.sendSMTPNotification(smtpCfg, heading+" - "+s.instanceName(), htmlBody, textBody)
+		})
+	}
+	if cfg.Notifications.Discord.Enabled && discordOn {
+		discord := cfg.Notifications.Discord
+		message := strings.TrimPrefix(md.String(), "\n") + fmt.Sprintf("\n\n[📋 View All Requests](%s)", link)
+		s.deliverNotification(event, "discord", "", func() error {
+			return s.sendDiscordNotification(discord.WebhookURL, discord.Username, heading, message, color)
+		})
+	}
+	if cfg.Notifications.Apprise.Enabled && appriseOn {
+		apprise := cfg.Notifications.Apprise
+		message := strings.TrimPrefix(md.String(), "\n") + fmt.Sprintf("\n\n[📋 View All Requests](%s)", link)
+		s.deliverNotification(event, "apprise", "", func() error {
+			return s.sendAppriseNotification(apprise, heading, message, appriseInfo)
+		})
+	}
+}
+
+// sendRate is a token bucket: Burst sends at once, then one per Every.
+type sendRate struct {
+	Burst int
+	Every time.Duration
+}
+
+// notifyRates keep each provider under its service's limits: Discord allows
+// about 30 webhook posts a minute per channel, and ntfy.sh a burst of 60 per
+// client and then one every 5 seconds.
+var notifyRates = map[string]sendRate{
+	"discord": {Burst: 5, Every: 2 * time.Second},
+	"ntfy":    {Burst: 30, Every: 5 * time.Second},
+}
+
+type sendBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// sendLimiter holds one token bucket per provider target.
+type sendLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*sendBucket
+}
+
+// reserve takes a send slot for key and returns how long to wait before
+// using it.
+func (l *sendLimiter) reserve(key string, rate sendRate, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = map[string]*sendBucket{}
+	}
+	b := l.buckets[key]
+	if b == nil {
+		b = &sendBucket{tokens: float64(rate.Burst), last: now}
+		l.buckets[key] = b
+	}
+	if now.After(b.last) {
+		b.tokens = math.Min(float64(rate.Burst), b.tokens+float64(now.Sub(b.last))/float64(rate.Every))
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(rate.Every))
+}
+
+// throttleSend blocks until provider may send to target again. Senders run
+// in their own goroutines, so waiting here only delays the message.
+func (s *Server) throttleSend(provider, target string) {
+	rate, ok := notifyRates[provider]
+	if !ok {
+		return
+	}
+	if d := s.notifyLimiter.reserve(provider+"|"+target, rate, time.Now()); d > 0 {
+		time.Sleep(d)
+	}
+}
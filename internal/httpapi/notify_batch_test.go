@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchedRequestNotificationsSendOneSummary(t *testing.T) {
+	posts := make(chan string, 10)
+	discord := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Embeds []struct {
+				Title       string `json:"title"`
+				Description string `json:"description"`
+			} `json:"embeds"`
+		}
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &body)
+		if len(body.Embeds) > 0 {
+			posts <- body.Embeds[0].Title + "\n" + body.Embeds[0].Description
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer discord.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Notifications.Discord.Enabled = true
+	cfg.Notifications.Discord.WebhookURL = discord.URL
+	cfg.Notifications.Discord.EnableRequestNotifications = true
+	cfg.Notifications.BatchWindowSeconds = 600
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, title := range []string{"One", "Two", "Three"} {
+		s.SendRequestNotification(int64(i+1), "user", title, []string{"A"})
+	}
+	select {
+	case p := <-posts:
+		t.Fatalf("sent before the window closed: %q", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.flushNotifications(notifyKindRequest)
+	select {
+	case p := <-posts:
+		if !strings.HasPrefix(p, "📚 3 New Book Requests") || !strings.Contains(p, "**Three** by A (user, #3)") {
+			t.Fatalf("summary = %q", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no summary sent")
+	}
+	select {
+	case p := <-posts:
+		t.Fatalf("extra message: %q", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A lone event goes out in its usual form.
+	s.SendRequestNotification(4, "user", "Four", nil)
+	s.flushNotifications(notifyKindRequest)
+	select {
+	case p := <-posts:
+		if !strings.HasPrefix(p, "📚 New Book Request") || !strings.Contains(p, "Four") {
+			t.Fatalf("single = %q", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no single notification sent")
+	}
+}
+
+func TestSendLimiterPacesAfterBurst(t *testing.T) {
+	var l sendLimiter
+	rate := sendRate{Burst: 2, Every: time.Second}
+	now := time.Unix(1000, 0)
+	if d := l.reserve("k", rate, now); d != 0 {
+		t.Fatalf("first wait = %v", d)
+	}
+	if d := l.reserve("k", rate, now); d != 0 {
+		t.Fatalf("second wait = %v", d)
+	}
+	if d := l.reserve("k", rate, now); d != time.Second {
+		t.Fatalf("third wait = %v", d)
+	}
+	if d := l.reserve("k", rate, now); d != 2*time.Second {
+		t.Fatalf("fourth wait = %v", d)
+	}
+	if d := l.reserve("other", rate, now); d != 0 {
+		t.Fatalf("other key wait = %v", d)
+	}
+	if d := l.reserve("k", rate, now.Add(10*time.Second)); d != 0 {
+		t.Fatalf("wait after refill = %v", d)
+	}
+}
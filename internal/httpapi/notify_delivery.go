@@ -0,0 +1,153 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// notifyRetryDelays are the waits before each retry of a delivery that
+// failed for a transient reason. Once they run out the delivery is failed.
+var notifyRetryDelays = []time.Duration{30 * time.Second, 2 * time.Minute, 10 * time.Minute}
+
+// notificationLogTTL is how long delivery records are kept.
+const notificationLogTTL = 30 * 24 * time.Hour
+
+// notifyStatusError is an HTTP error status from a notification endpoint.
+type notifyStatusError struct {
+	What string
+	Code int
+}
+
+func (e *notifyStatusError) Error() string {
+	return fmt.Sprintf("%s returned error: %d", e.What, e.Code)
+}
+
+// isTransientNotifyError reports whether a failed send is worth retrying:
+// network errors, timeouts, rate limiting, server errors, and temporary SMTP
+// replies. Bad configuration and rejected payloads are not.
+func isTransientNotifyError(err error) bool {
+	var se *notifyStatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusTooManyRequests || se.Code == http.StatusRequestTimeout || se.Code >= 500
+	}
+	var te *textproto.Error
+	if errors.As(err, &te) {
+		return te.Code >= 400 && te.Code < 500
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// deliverNotification sends one notification in the background, records it
+// in the delivery log, and retries transient failures with backoff. During
+// the provider's quiet hours it is held and sent when they end. event is the
+// notification type ("request.created"), provider the channel, and target a
+// short, secret-free description of the recipient.
+func (s *Server) deliverNotification(event, provider, target string, send func() error) {
+	var id int64
+	if s.db != nil {
+		var err error
+		if id, err = s.db.CreateNotificationLog(context.Background(), event, provider, target); err != nil {
+			fmt.Printf("notify: failed to log %s via %s: %v\n", event, provider, err)
+		}
+	}
+	if wait := s.quietHoursWait(event, provider, target, time.Now()); wait > 0 {
+		if id > 0 {
+			reason := "held for quiet hours until " + time.Now().Add(wait).Format("15:04")
+			if err := s.db.UpdateNotificationLog(context.Background(), id, db.NotifyHeld, reason, 0); err != nil {
+				fmt.Printf("notify: failed to update delivery %d: %v\n", id, err)
+			}
+		}
+		time.AfterFunc(wait, func() { s.attemptDelivery(id, event, provider, send, 1) })
+		return
+	}
+	go s.attemptDelivery(id, event, provider, send, 1)
+}
+
+// quietHoursWait is how long a delivery must wait for provider's quiet hours
+// to end. Personal notifications go to the requester's own channels and are
+// never held; system alerts skip the window when the provider allows it.
+func (s *Server) quietHoursWait(event, provider, target string, now time.Time) time.Duration {
+	if strings.HasPrefix(target, "user:") {
+		return 0
+	}
+	n := s.settings.Get().Notifications
+	var q config.QuietHours
+	switch provider {
+	case "ntfy":
+		q = n.Ntfy.QuietHours
+	case "smtp":
+		q = n.SMTP.QuietHours
+	case "discord":
+		q = n.Discord.QuietHours
+	case "webhook":
+		q = n.Webhook.QuietHours
+	case "apprise":
+		q = n.Apprise.QuietHours
+	}
+	if q.UrgentOverrides && event == "system.alert" {
+		return 0
+	}
+	return q.Remaining(now)
+}
+
+// quietHoursFromForm reads a provider's quiet hours fields. Times that do
+// not parse as HH:MM are dropped, which turns the window off.
+func quietHoursFromForm(r *http.Request, prefix string) config.QuietHours {
+	q := config.QuietHours{UrgentOverrides: r.FormValue(prefix+"_quiet_urgent") == "on"}
+	if _, ok := config.ParseClock(r.FormValue(prefix + "_quiet_start")); ok {
+		q.Start = strings.TrimSpace(r.FormValue(prefix + "_quiet_start"))
+	}
+	if _, ok := config.ParseClock(r.FormValue(prefix + "_quiet_end")); ok {
+		q.End = strings.TrimSpace(r.FormValue(prefix + "_quiet_end"))
+	}
+	return q
+}
+
+func (s *Server) attemptDelivery(id int64, event, provider string, send func() error, attempt int) {
+	err := send()
+	status, errText := db.NotifySent, ""
+	retry := false
+	if err != nil {
+		errText = err.Error()
+		status = db.NotifyFailed
+		if attempt <= len(notifyRetryDelays) && isTransientNotifyError(err) {
+			status, retry = db.NotifyRetrying, true
+		}
+		fmt.Printf("notify: %s via %s failed (attempt %d): %v\n", event, provider, attempt, err)
+	}
+	// Record the outcome before scheduling the retry so a quick retry
+	// cannot be overwritten by this attempt's status.
+	if id > 0 {
+		if uerr := s.db.UpdateNotificationLog(context.Background(), id, status, errText, attempt); uerr != nil {
+			fmt.Printf("notify: failed to update delivery %d: %v\n", id, uerr)
+		}
+	}
+	if retry {
+		time.AfterFunc(notifyRetryDelays[attempt-1], func() { s.attemptDelivery(id, event, provider, send, attempt+1) })
+	}
+}
+
+// pruneNotificationLog drops delivery records older than notificationLogTTL.
+func (s *Server) pruneNotificationLog(ctx context.Context) {
+	if _, err := s.db.PruneNotificationLog(ctx, time.Now().Add(-notificationLogTTL)); err != nil {
+		fmt.Printf("notify: prune failed: %v\n", err)
+	}
+}
+
+// abandonStaleDeliveries fails deliveries a previous run left pending or
+// waiting on a retry.
+func (s *Server) abandonStaleDeliveries(ctx context.Context) {
+	if err := s.db.AbandonNotificationLog(ctx, "interrupted by restart"); err != nil {
+		fmt.Printf("notify: failed to close out unfinished deliveries: %v\n", err)
+	}
+}
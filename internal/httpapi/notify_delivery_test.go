@@ -0,0 +1,139 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// waitForDelivery polls the delivery log until the newest entry settles.
+func waitForDelivery(t *testing.T, s *Server, status string) db.NotificationLogEntry {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		list, _ := s.db.ListNotificationLog(context.Background(), 1)
+		if len(list) == 1 && list[0].Status == status {
+			return list[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	list, _ := s.db.ListNotificationLog(context.Background(), 1)
+	t.Fatalf("delivery never reached %q: %+v", status, list)
+	return db.NotificationLogEntry{}
+}
+
+func TestDeliveryRetriesTransientFailures(t *testing.T) {
+	prev := notifyRetryDelays
+	notifyRetryDelays = []time.Duration{5 * time.Millisecond, 5 * time.Millisecond}
+	t.Cleanup(func() { notifyRetryDelays = prev })
+
+	var calls atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	s.deliverNotification("system.alert", "webhook", "", func() error {
+		return s.sendWebhookNotification(hook.URL, map[string]any{"event": "system.alert"})
+	})
+	got := waitForDelivery(t, s, db.NotifySent)
+	if got.Attempts != 2 || got.Event != "system.alert" || got.Provider != "webhook" || got.Error != "" {
+		t.Fatalf("delivery = %+v", got)
+	}
+}
+
+func TestDeliveryGivesUpOnPermanentFailures(t *testing.T) {
+	var calls atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	s.deliverNotification("request.created", "discord", "", func() error {
+		return s.sendDiscordNotification(hook.URL, "Scriptorum", "t", "m", 0)
+	})
+	got := waitForDelivery(t, s, db.NotifyFailed)
+	if got.Attempts != 1 || got.Error != "discord webhook returned error: 404" || calls.Load() != 1 {
+		t.Fatalf("delivery = %+v calls=%d", got, calls.Load())
+	}
+
+	h := s.Router()
+	req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `data-delivery-status="failed"`) || !strings.Contains(rec.Body.String(), "discord webhook returned error: 404") {
+		t.Fatalf("notifications page missing delivery log: %d", rec.Code)
+	}
+}
+
+func TestIsTransientNotifyError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&notifyStatusError{What: "ntfy server", Code: 429}, true},
+		{&notifyStatusError{What: "ntfy server", Code: 502}, true},
+		{&notifyStatusError{What: "ntfy server", Code: 401}, false},
+		{&textproto.Error{Code: 421, Msg: "try later"}, true},
+		{&textproto.Error{Code: 550, Msg: "no such user"}, false},
+		{errors.New("SMTP configuration incomplete"), false},
+	}
+	for _, c := range cases {
+		if got := isTransientNotifyError(c.err); got != c.want {
+			t.Errorf("isTransientNotifyError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+	_, err := http.Get("http://127.0.0.1:1/")
+	if err == nil || !isTransientNotifyError(err) {
+		t.Errorf("connection error %v should be transient", err)
+	}
+}
+
+func TestDeliveryHeldDuringQuietHours(t *testing.T) {
+	s := newServerForTest(t)
+	now := time.Now()
+	cfg := s.settings.Get()
+	cfg.Notifications.Ntfy.QuietHours = config.QuietHours{
+		Start:           now.Add(-time.Hour).Format("15:04"),
+		End:             now.Add(time.Hour).Format("15:04"),
+		UrgentOverrides: true,
+	}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int32
+	send := func() error { calls.Add(1); return nil }
+	s.deliverNotification("request.created", "ntfy", "", send)
+	got := waitForDelivery(t, s, db.NotifyHeld)
+	if !strings.Contains(got.Error, "quiet hours") || calls.Load() != 0 {
+		t.Fatalf("held delivery = %+v, calls = %d", got, calls.Load())
+	}
+
+	s.deliverNotification("system.alert", "ntfy", "", send)
+	waitForDelivery(t, s, db.NotifySent)
+	s.deliverNotification("request.approved", "ntfy", "user:alice", send)
+	waitForDelivery(t, s, db.NotifySent)
+	s.deliverNotification("request.created", "discord", "", send)
+	waitForDelivery(t, s, db.NotifySent)
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+}
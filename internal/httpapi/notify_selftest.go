@@ -0,0 +1,103 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NotificationTestResult is the outcome of sending the synthetic test event
+// to one enabled notification provider.
+type NotificationTestResult struct {
+	// Provider is "ntfy", "smtp", "discord", "apprise", or "webhook".
+	Provider string `json:"provider"`
+	// Target tells a provider's destinations apart, such as each webhook
+	// endpoint's name.
+	Target     string `json:"target,omitempty"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// TestNotifications sends a synthetic "system.test" event through every
+// enabled notification provider with its saved settings, one after another,
+// and reports how each went. Unlike the per-provider test buttons it never
+// uses unsaved form values, and unlike real events it skips batching, quiet
+// hours, retries, and the delivery log, so the result is the provider's own
+// answer.
+func (s *Server) TestNotifications() []NotificationTestResult {
+	cfg := s.settings.Get()
+	n := cfg.Notifications
+	name := s.instanceName()
+	title := "🧪 " + name + " notification self-test"
+	message := "✅ **Configuration is working correctly!**\n\nThis is a test event sent to every enabled provider."
+	var out []NotificationTestResult
+	run := func(provider, target string, send func() error) {
+		started := time.Now()
+		err := send()
+		res := NotificationTestResult{Provider: provider, Target: target, OK: err == nil, DurationMS: time.Since(started).Milliseconds()}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		out = append(out, res)
+	}
+
+	if n.Ntfy.Enabled {
+		server := strings.TrimSpace(n.Ntfy.Server)
+		if server == "" {
+			server = s.defaultNtfyServer()
+		}
+		run("ntfy", n.Ntfy.Topic, func() error {
+			return s.sendNtfyNotification(server, n.Ntfy.Topic, n.Ntfy.Username, n.Ntfy.Password, title, message, "default")
+		})
+	}
+	if n.SMTP.Enabled {
+		run("smtp", n.SMTP.ToEmail, func() error {
+			return s.sendSMTPNotification(n.SMTP, title, "", strings.ReplaceAll(message, "**", ""))
+		})
+	}
+	if n.Discord.Enabled {
+		run("discord", "", func() error {
+			return s.sendDiscordNotification(n.Discord.WebhookURL, n.Discord.Username, title, message, 0x3b82f6)
+		})
+	}
+	if n.Apprise.Enabled {
+		run("apprise", "", func() error {
+			return s.sendAppriseNotification(n.Apprise, title, message, appriseInfo)
+		})
+	}
+	if n.Webhook.Enabled {
+		for _, ep := range n.Webhook.Targets() {
+			run("webhook", webhookTargetLabel(ep), func() error {
+				return s.postWebhook(ep, map[string]any{
+					"event":     "system.test",
+					"title":     name + " notification self-test",
+					"message":   "Configuration is working correctly.",
+					"timestamp": time.Now().Format(time.RFC3339),
+				})
+			})
+		}
+	}
+	return out
+}
+
+// NotificationTestsPassed reports whether every result succeeded.
+func NotificationTestsPassed(results []NotificationTestResult) bool {
+	for _, r := range results {
+		if !r.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// apiTestAllNotifications runs TestNotifications for the "Test all" button.
+func (s *Server) apiTestAllNotifications() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := s.TestNotifications()
+		if results == nil {
+			results = []NotificationTestResult{}
+		}
+		writeJSON(w, map[string]any{"success": NotificationTestsPassed(results), "results": results}, 200)
+	}
+}
@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func TestTestNotificationsReportsEachProvider(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" || r.URL.Path == "/ok" && r.Header.Get(webhookEventHeader) != "system.test" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	if got := s.TestNotifications(); len(got) != 0 {
+		t.Fatalf("results with nothing enabled = %+v", got)
+	}
+	cfg := s.settings.Get()
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.URL = hook.URL + "/ok"
+	cfg.Notifications.Webhook.Endpoints = []config.WebhookEndpoint{{Name: "broken", URL: hook.URL + "/broken"}}
+	cfg.Notifications.Discord.Enabled = true
+	cfg.Notifications.Discord.WebhookURL = hook.URL + "/discord"
+	cfg.Notifications.SMTP.Enabled = false
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/notifications/test-all", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	var resp struct {
+		Success bool                     `json:"success"`
+		Results []NotificationTestResult `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Success || len(resp.Results) != 3 {
+		t.Fatalf("results = %+v", resp)
+	}
+	want := []struct {
+		provider string
+		ok       bool
+	}{{"discord", true}, {"webhook", true}, {"webhook", false}}
+	for i, w := range want {
+		if r := resp.Results[i]; r.Provider != w.provider || r.OK != w.ok || (!r.OK && r.Error == "") {
+			t.Fatalf("result %d = %+v, want %s ok=%v", i, r, w.provider, w.ok)
+		}
+	}
+	if resp.Results[2].Target != "broken" {
+		t.Fatalf("webhook target = %q", resp.Results[2].Target)
+	}
+}
@@ -0,0 +1,157 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+// Webhook request headers. The signature is "sha256=" and the hex
+// HMAC-SHA256 of the body, keyed with the endpoint's secret.
+const (
+	webhookEventHeader     = "X-Scriptorum-Event"
+	webhookSignatureHeader = "X-Scriptorum-Signature"
+)
+
+var webhookTemplateFuncs = template.FuncMap{
+	// json encodes a value as a JSON literal, so templates can splice in
+	// strings and lists without worrying about quoting.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": strings.Join,
+}
+
+// renderWebhookBody builds the request body for payload: the payload itself,
+// or tpl executed with the payload's fields, which must produce valid JSON.
+func renderWebhookBody(tpl string, payload map[string]any) ([]byte, error) {
+	if strings.TrimSpace(tpl) == "" {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+		return b, nil
+	}
+	t, err := template.New("webhook").Funcs(webhookTemplateFuncs).Parse(tpl)
+	if err != nil {
+		return nil, fmt.Errorf("webhook template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("webhook template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook template did not produce valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// signWebhookBody returns the signature header value for body.
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookTargetLabel names an endpoint in the delivery log without leaking
+// tokens that may sit in its path or query.
+func webhookTargetLabel(ep config.WebhookEndpoint) string {
+	if name := strings.TrimSpace(ep.Name); name != "" {
+		return name
+	}
+	if u, err := url.Parse(ep.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return ""
+}
+
+// sendWebhookEvent posts payload to every configured webhook endpoint. The
+// payload's "event" field names the delivery.
+func (s *Server) sendWebhookEvent(cfg *config.Config, payload map[string]any) {
+	event, _ := payload["event"].(string)
+	for _, ep := range cfg.Notifications.Webhook.Targets() {
+		s.deliverNotification(event, "webhook", webhookTargetLabel(ep), func() error {
+			return s.postWebhook(ep, payload)
+		})
+	}
+}
+
+// postWebhook renders, signs, and POSTs payload to one endpoint.
+func (s *Server) postWebhook(ep config.WebhookEndpoint, payload map[string]any) error {
+	if strings.TrimSpace(ep.URL) == "" {
+		return fmt.Errorf("webhook URL is required")
+	}
+	body, err := renderWebhookBody(ep.Template, payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if event, _ := payload["event"].(string); event != "" {
+		req.Header.Set(webhookEventHeader, event)
+	}
+	if ep.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhookBody(ep.Secret, body))
+	}
+
+	client := s.outboundHTTPClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return &notifyStatusError{What: "webhook endpoint", Code: resp.StatusCode}
+	}
+	return nil
+}
+
+// parseWebhookEndpoints reads the extra endpoint URLs from the settings form,
+// one per line. Endpoints already configured for a URL keep their name,
+// secret, and template.
+func parseWebhookEndpoints(text string, existing []config.WebhookEndpoint) []config.WebhookEndpoint {
+	byURL := make(map[string]config.WebhookEndpoint, len(existing))
+	for _, ep := range existing {
+		byURL[strings.TrimSpace(ep.URL)] = ep
+	}
+	var out []config.WebhookEndpoint
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		u := strings.TrimSpace(line)
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		ep, ok := byURL[u]
+		if !ok {
+			ep = config.WebhookEndpoint{URL: u}
+		}
+		out = append(out, ep)
+	}
+	return out
+}
+
+// webhookEndpointURLs lists the extra endpoint URLs for the settings form.
+func webhookEndpointURLs(eps []config.WebhookEndpoint) string {
+	urls := make([]string, 0, len(eps))
+	for _, ep := range eps {
+		urls = append(urls, ep.URL)
+	}
+	return strings.Join(urls, "\n")
+}
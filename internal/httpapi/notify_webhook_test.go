@@ -0,0 +1,87 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+type webhookHit struct {
+	path, event, signature, body string
+}
+
+func TestWebhookEventsReachEveryEndpointSigned(t *testing.T) {
+	hits := make(chan webhookHit, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		hits <- webhookHit{r.URL.Path, r.Header.Get(webhookEventHeader), r.Header.Get(webhookSignatureHeader), string(b)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.EnableSystemNotifications = true
+	cfg.Notifications.Webhook.URL = hook.URL + "/plain"
+	cfg.Notifications.Webhook.Secret = "s3cret"
+	cfg.Notifications.Webhook.Endpoints = []config.WebhookEndpoint{
+		{URL: hook.URL + "/ha", Template: `{"message": {{ json .title }}, "kind": {{ json .event }}}`},
+	}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	s.SendSystemNotification("Readarr down", "details")
+	got := map[string]webhookHit{}
+	for i := 0; i < 2; i++ {
+		select {
+		case h := <-hits:
+			got[h.path] = h
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only %d webhook posts arrived", i)
+		}
+	}
+	for path, h := range got {
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(h.body))
+		if h.signature != "sha256="+hex.EncodeToString(mac.Sum(nil)) || h.event != "system.alert" {
+			t.Fatalf("%s: event=%q signature=%q", path, h.event, h.signature)
+		}
+	}
+	if !strings.Contains(got["/plain"].body, `"title":"Readarr down"`) {
+		t.Fatalf("plain body = %s", got["/plain"].body)
+	}
+	if got["/ha"].body != `{"message": "Readarr down", "kind": "system.alert"}` {
+		t.Fatalf("templated body = %s", got["/ha"].body)
+	}
+}
+
+func TestRenderWebhookBodyRejectsInvalidJSON(t *testing.T) {
+	if _, err := renderWebhookBody(`{"t": {{ .title }}}`, map[string]any{"title": "a b"}); err == nil || !strings.Contains(err.Error(), "valid JSON") {
+		t.Fatalf("err = %v", err)
+	}
+	b, err := renderWebhookBody(`{"a": {{ json (join .authors ", ") }}}`, map[string]any{"authors": []string{"X", `Y "Z"`}})
+	if err != nil || string(b) != `{"a": "X, Y \"Z\""}` {
+		t.Fatalf("body=%s err=%v", b, err)
+	}
+}
+
+func TestParseWebhookEndpointsKeepsSettings(t *testing.T) {
+	existing := []config.WebhookEndpoint{{Name: "ha", URL: "https://a.example/hook", Secret: "x"}}
+	got := parseWebhookEndpoints(" https://a.example/hook \n\nhttps://b.example/hook\nhttps://b.example/hook", existing)
+	if len(got) != 2 || got[0].Secret != "x" || got[0].Name != "ha" || got[1].URL != "https://b.example/hook" || got[1].Secret != "" {
+		t.Fatalf("endpoints = %+v", got)
+	}
+	if webhookEndpointURLs(got) != "https://a.example/hook\nhttps://b.example/hook" {
+		t.Fatalf("urls = %q", webhookEndpointURLs(got))
+	}
+}
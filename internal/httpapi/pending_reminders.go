@@ -0,0 +1,256 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+const (
+	pendingReminderSweepInterval = 10 * time.Minute
+	// maxPendingReminders is how many times one request is escalated; the
+	// last reminder goes out at maxPendingReminders x the threshold.
+	maxPendingReminders = 3
+	// maxReminderLines caps how many requests one reminder lists.
+	maxReminderLines = 10
+)
+
+// pendingReminderThreshold is the configured age that triggers the first
+// reminder; 0 means reminders are off.
+func (s *Server) pendingReminderThreshold() time.Duration {
+	h := s.settings.Get().Requests.ReminderAfterHours
+	if h <= 0 {
+		return 0
+	}
+	return time.Duration(h) * time.Hour
+}
+
+// pendingAgeLevel is how many reminder thresholds age has crossed, capped at
+// maxPendingReminders. It is 0 when reminders are off or the request is fresh.
+func pendingAgeLevel(age, threshold time.Duration) int {
+	if threshold <= 0 || age < threshold {
+		return 0
+	}
+	level := int(age / threshold)
+	if level > maxPendingReminders {
+		level = maxPendingReminders
+	}
+	return level
+}
+
+// formatPendingAge renders how long a request has waited, e.g. "45m", "7h",
+// or "3d 4h".
+func formatPendingAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	days := int(d.Hours()) / 24
+	if hours := int(d.Hours()) % 24; hours > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dd", days)
+}
+
+func (s *Server) runPendingReminderLoop(ctx context.Context) {
+	ticker := time.NewTicker(pendingReminderSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expireStaleRequests(ctx, time.Now())
+			s.sendPendingReminders(ctx, time.Now())
+		}
+	}
+}
+
+// sendPendingReminders sends one nudge covering every pending request that
+// crossed a new reminder threshold since the last sweep, by age or by an
+// approaching need-by date. The nudge takes the priority of the most overdue
+// request in it.
+func (s *Server) sendPendingReminders(ctx context.Context, now time.Time) {
+	threshold := s.pendingReminderThreshold()
+	cutoff := now.Add(-threshold)
+	if threshold == 0 {
+		// Age reminders are off; only need-by dates escalate.
+		cutoff = time.Time{}
+	}
+	pending, err := s.db.ListPendingReminders(ctx, cutoff)
+	if err != nil {
+		fmt.Printf("requests: list pending reminders: %v\n", err)
+		return
+	}
+	var due []db.PendingReminder
+	level := 0
+	for _, p := range pending {
+		l := max(pendingAgeLevel(now.Sub(p.CreatedAt), threshold), needByLevel(p.NeedBy, now))
+		if l <= p.RemindersSent {
+			continue
+		}
+		if err := s.db.SetRemindersSent(ctx, p.ID, l); err != nil {
+			fmt.Printf("requests: record reminder for #%d: %v\n", p.ID, err)
+			continue
+		}
+		due = append(due, p)
+		if l > level {
+			level = l
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+	s.SendPendingReminderNotification(level, due, now)
+}
+
+// SendPendingReminderNotification nudges admins about requests waiting for
+// approval. It uses each provider's request notification toggle.
+func (s *Server) SendPendingReminderNotification(level int, items []db.PendingReminder, now time.Time) {
+	cfg := s.settings.Get()
+	title := "⏰ 1 request awaiting approval"
+	if len(items) != 1 {
+		title = fmt.Sprintf("⏰ %d requests awaiting approval", len(items))
+	}
+	lines := make([]string, 0, len(items))
+	for i, p := range items {
+		if i == maxReminderLines {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(items)-i))
+			break
+		}
+		line := fmt.Sprintf("#%d %s (%s) — waiting %s", p.ID, p.Title, p.RequesterEmail, formatPendingAge(now.Sub(p.CreatedAt)))
+		if days, ok := needByDaysLeft(p.NeedBy, now); ok && days < 0 {
+			line += ", overdue since " + formatNeedBy(p.NeedBy, now)
+		} else if ok {
+			line += ", needed by " + formatNeedBy(p.NeedBy, now)
+		}
+		lines = append(lines, line)
+	}
+
+	if cfg.Notifications.Ntfy.Enabled && cfg.Notifications.Ntfy.EnableRequestNotifications {
+		s.sendPendingReminderNtfy(cfg, level, title, lines)
+	}
+	if cfg.Notifications.SMTP.Enabled && cfg.Notifications.SMTP.EnableRequestNotifications {
+		s.sendPendingReminderSMTP(cfg, title, lines)
+	}
+	if cfg.Notifications.Discord.Enabled && cfg.Notifications.Discord.EnableRequestNotifications {
+		s.sendPendingReminderDiscord(cfg, level, title, lines)
+	}
+	if cfg.Notifications.Apprise.Enabled && cfg.Notifications.Apprise.EnableRequestNotifications {
+		s.sendPendingReminderApprise(cfg, level, title, lines)
+	}
+	if cfg.Notifications.Webhook.Enabled && cfg.Notifications.Webhook.EnableRequestNotifications {
+		s.sendPendingReminderWebhook(cfg, level, items, now)
+	}
+}
+
+// pendingReminderPriority maps an escalation level to an ntfy priority.
+func pendingReminderPriority(level int) string {
+	switch {
+	case level >= 3:
+		return "urgent"
+	case level == 2:
+		return "high"
+	default:
+		return "default"
+	}
+}
+
+func (s *Server) sendPendingReminderNtfy(cfg *config.Config, level int, title string, lines []string) {
+	message := strings.Join(lines, "\n")
+	actions := []map[string]string{
+		{
+			"action": "view",
+			"label":  "📋 Review Pending Requests",
+			"url":    cfg.ServerURL + "/requests",
+		},
+	}
+	s.deliverNotification("request.reminder", "ntfy", "", func() error {
+		return s.sendNtfyNotificationWithActions(
+			cfg.Notifications.Ntfy.Server,
+			cfg.Notifications.Ntfy.Topic,
+			cfg.Notifications.Ntfy.Username,
+			cfg.Notifications.Ntfy.Password,
+			title,
+			message,
+			pendingReminderPriority(level),
+			actions,
+		)
+	})
+}
+
+func (s *Server) sendPendingReminderSMTP(cfg *config.Config, title string, lines []string) {
+	subject := title + " - Scriptorum"
+	var items strings.Builder
+	for _, l := range lines {
+		items.WriteString("<li>" + html.EscapeString(l) + "</li>")
+	}
+	link := cfg.ServerURL + "/requests"
+	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; color: #333;">
+	<h2>%s</h2>
+	<ul>%s</ul>
+	<p><a href="%s">Review pending requests</a></p>
+</body>
+</html>`, html.EscapeString(title), items.String(), html.EscapeString(link))
+	textBody := fmt.Sprintf("%s\n\n%s\n\nReview pending requests: %s", title, strings.Join(lines, "\n"), link)
+	s.deliverNotification("request.reminder", "smtp", "", func() error {
+		return s.sendSMTPNotification(cfg.Notifications.SMTP, subject, htmlBody, textBody)
+	})
+}
+
+func (s *Server) sendPendingReminderDiscord(cfg *config.Config, level int, title string, lines []string) {
+	color := 0xf59e0b // Amber for a first reminder
+	switch {
+	case level >= 3:
+		color = 0xef4444 // Red
+	case level == 2:
+		color = 0xf97316 // Orange
+	}
+	message := strings.Join(lines, "\n")
+	s.deliverNotification("request.reminder", "discord", "", func() error {
+		return s.sendDiscordNotification(cfg.Notifications.Discord.WebhookURL, cfg.Notifications.Discord.Username, title, message, color)
+	})
+}
+
+func (s *Server) sendPendingReminderApprise(cfg *config.Config, level int, title string, lines []string) {
+	notifyType := appriseWarning
+	if level >= 3 {
+		notifyType = appriseFailure
+	}
+	message := strings.Join(lines, "\n") + "\n\n[📋 Review Pending Requests](" + cfg.ServerURL + "/requests)"
+	s.deliverNotification("request.reminder", "apprise", "", func() error {
+		return s.sendAppriseNotification(cfg.Notifications.Apprise, title, message, notifyType)
+	})
+}
+
+func (s *Server) sendPendingReminderWebhook(cfg *config.Config, level int, items []db.PendingReminder, now time.Time) {
+	requests := make([]map[string]any, 0, len(items))
+	for _, p := range items {
+		item := map[string]any{
+			"requestId":  p.ID,
+			"title":      p.Title,
+			"requester":  p.RequesterEmail,
+			"format":     p.Format,
+			"ageSeconds": int64(now.Sub(p.CreatedAt).Seconds()),
+		}
+		if p.NeedBy != "" {
+			item["needBy"] = p.NeedBy
+		}
+		requests = append(requests, item)
+	}
+	s.sendWebhookEvent(cfg, map[string]any{
+		"event":     "request.reminder",
+		"level":     level,
+		"requests":  requests,
+		"timestamp": now.Format(time.RFC3339),
+	})
+}
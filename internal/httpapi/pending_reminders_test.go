@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestPendingAgeLevelAndFormat(t *testing.T) {
+	cases := []struct {
+		age, threshold time.Duration
+		want           int
+	}{
+		{5 * time.Hour, 0, 0},
+		{5 * time.Hour, 24 * time.Hour, 0},
+		{25 * time.Hour, 24 * time.Hour, 1},
+		{49 * time.Hour, 24 * time.Hour, 2},
+		{30 * 24 * time.Hour, 24 * time.Hour, maxPendingReminders},
+	}
+	for _, c := range cases {
+		if got := pendingAgeLevel(c.age, c.threshold); got != c.want {
+			t.Errorf("pendingAgeLevel(%v, %v) = %d, want %d", c.age, c.threshold, got, c.want)
+		}
+	}
+	for d, want := range map[time.Duration]string{
+		45 * time.Minute: "45m",
+		7 * time.Hour:    "7h",
+		48 * time.Hour:   "2d",
+		76 * time.Hour:   "3d 4h",
+	} {
+		if got := formatPendingAge(d); got != want {
+			t.Errorf("formatPendingAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+	if pendingReminderPriority(1) != "default" || pendingReminderPriority(2) != "high" || pendingReminderPriority(3) != "urgent" {
+		t.Fatal("unexpected reminder priorities")
+	}
+}
+
+func TestSendPendingRemindersEscalates(t *testing.T) {
+	events := make(chan map[string]any, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		events <- payload
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Requests.ReminderAfterHours = 24
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.URL = hook.URL
+	cfg.Notifications.Webhook.EnableRequestNotifications = true
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Dune", Format: "ebook", Status: "pending"})
+	_, _ = s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Emma", Format: "ebook", Status: "pending"})
+	created := time.Now().UTC().Add(-30 * time.Hour)
+	if err := s.db.Exec(ctx, `UPDATE requests SET created_at=? WHERE id=?`, created.Format(time.RFC3339Nano), id); err != nil {
+		t.Fatal(err)
+	}
+	receive := func() map[string]any {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reminder sent")
+			return nil
+		}
+	}
+
+	now := time.Now()
+	s.sendPendingReminders(ctx, now)
+	ev := receive()
+	reqs, _ := ev["requests"].([]any)
+	if ev["event"] != "request.reminder" || ev["level"] != float64(1) || len(reqs) != 1 {
+		t.Fatalf("first reminder = %+v", ev)
+	}
+
+	// Nothing new crossed a threshold, so a second sweep stays quiet.
+	s.sendPendingReminders(ctx, now.Add(time.Hour))
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected repeat reminder: %+v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	s.sendPendingReminders(ctx, now.Add(20*time.Hour))
+	if ev := receive(); ev["level"] != float64(2) {
+		t.Fatalf("escalated reminder = %+v", ev)
+	}
+}
+
+func TestRequestsTableShowsPendingAge(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Requests.ReminderAfterHours = 24
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Dune", Format: "ebook", Status: "pending"})
+	_ = s.db.Exec(ctx, `UPDATE requests SET created_at=? WHERE id=?`, time.Now().UTC().Add(-50*time.Hour).Format(time.RFC3339Nano), id)
+
+	req := httptest.NewRequest(http.MethodGet, "/ui/requests/table", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `data-pending-age="2"`) || !strings.Contains(rec.Body.String(), "2d 2h") {
+		t.Fatalf("table: %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,212 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Search sources the search page fans out to. The names are what
+// search.disabled_providers lists.
+const (
+	providerReadarrEbooks     = "readarr_ebooks"
+	providerReadarrAudiobooks = "readarr_audiobooks"
+	providerOpenLibrary       = "openlibrary"
+	providerAmazon            = "amazon"
+	providerMylar             = "mylar"
+)
+
+var searchProviderLabels = []struct{ Name, Label string }{
+	{providerReadarrEbooks, "Readarr eBooks"},
+	{providerReadarrAudiobooks, "Readarr audiobooks"},
+	{providerOpenLibrary, "Open Library"},
+	{providerAmazon, "Amazon (public pages)"},
+	{providerMylar, "Mylar (comics)"},
+}
+
+func isSearchProvider(name string) bool {
+	for _, p := range searchProviderLabels {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// providerLatencySamples is how many recent calls the average latency
+// covers.
+const providerLatencySamples = 20
+
+// providerStats is what the provider health page knows about one source
+// since the server started.
+type providerStats struct {
+	calls       int
+	failures    int
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastErr     string
+	latencies   []time.Duration
+}
+
+// providerHealthView is one row of the provider health page.
+type providerHealthView struct {
+	Name         string    `json:"name"`
+	Label        string    `json:"label"`
+	Configured   bool      `json:"configured"`
+	Enabled      bool      `json:"enabled"`
+	Calls        int       `json:"calls"`
+	Failures     int       `json:"failures"`
+	LastSuccess  time.Time `json:"last_success"`
+	LastFailure  time.Time `json:"last_failure"`
+	LastError    string    `json:"last_error,omitempty"`
+	AvgLatencyMs int64     `json:"avg_latency_ms"`
+}
+
+// Failing reports whether the most recent call failed.
+func (v providerHealthView) Failing() bool {
+	return !v.LastFailure.IsZero() && v.LastFailure.After(v.LastSuccess)
+}
+
+// providerEnabled reports whether the search fan-out queries the source.
+func (s *Server) providerEnabled(name string) bool {
+	cfg := s.settings.Get()
+	return cfg == nil || cfg.SearchProviderEnabled(name)
+}
+
+// offline reports whether offline mode keeps Scriptorum off the public
+// internet.
+func (s *Server) offline() bool {
+	cfg := s.settings.Get()
+	return cfg != nil && cfg.Offline
+}
+
+// defaultNtfyServer is the ntfy server used when none is configured: ntfy.sh,
+// or none at all in offline mode.
+func (s *Server) defaultNtfyServer() string {
+	if s.offline() {
+		return ""
+	}
+	return "https://ntfy.sh"
+}
+
+// recordProviderCall folds one search call into the source's stats. Calls
+// cut short because the user went away are not the provider's fault and
+// are ignored.
+func (s *Server) recordProviderCall(name string, started time.Time, err error) {
+	if name != providerReadarrEbooks && name != providerReadarrAudiobooks {
+		s.countProviderCall(name)
+	}
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	s.providerStatsMu.Lock()
+	defer s.providerStatsMu.Unlock()
+	if s.providerStats == nil {
+		s.providerStats = make(map[string]*providerStats)
+	}
+	st := s.providerStats[name]
+	if st == nil {
+		st = &providerStats{}
+		s.providerStats[name] = st
+	}
+	now := time.Now()
+	st.calls++
+	if err != nil {
+		st.failures++
+		st.lastFailure = now
+		st.lastErr = err.Error()
+	} else {
+		st.lastSuccess = now
+	}
+	st.latencies = append(st.latencies, now.Sub(started))
+	if len(st.latencies) > providerLatencySamples {
+		st.latencies = st.latencies[len(st.latencies)-providerLatencySamples:]
+	}
+}
+
+func (s *Server) providerHealthViews() []providerHealthView {
+	cfg := s.settings.Get()
+	s.providerStatsMu.Lock()
+	defer s.providerStatsMu.Unlock()
+	out := make([]providerHealthView, 0, len(searchProviderLabels))
+	for _, p := range searchProviderLabels {
+		v := providerHealthView{Name: p.Name, Label: p.Label, Configured: true, Enabled: true}
+		if cfg != nil {
+			v.Enabled = cfg.SearchProviderEnabled(p.Name)
+			switch p.Name {
+			case providerReadarrEbooks:
+				v.Configured = strings.TrimSpace(cfg.Readarr.Ebooks.BaseURL) != "" && strings.TrimSpace(cfg.Readarr.Ebooks.APIKey) != ""
+			case providerReadarrAudiobooks:
+				v.Configured = strings.TrimSpace(cfg.Readarr.Audiobooks.BaseURL) != "" && strings.TrimSpace(cfg.Readarr.Audiobooks.APIKey) != ""
+			case providerMylar:
+				v.Configured = cfg.Mylar.Enabled()
+			}
+		}
+		if st := s.providerStats[p.Name]; st != nil {
+			v.Calls, v.Failures = st.calls, st.failures
+			v.LastSuccess, v.LastFailure, v.LastError = st.lastSuccess, st.lastFailure, st.lastErr
+			var total time.Duration
+			for _, d := range st.latencies {
+				total += d
+			}
+			if len(st.latencies) > 0 {
+				v.AvgLatencyMs = (total / time.Duration(len(st.latencies))).Milliseconds()
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func (u *ui) handleProviders(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]any{
+			"UserName":  s.userName(r),
+			"IsAdmin":   true,
+			"CSRFToken": s.getCSRFToken(r),
+			"Providers": s.providerHealthViews(),
+			"Quotas":    s.providerQuotaViews(),
+		}
+		_ = u.tpl.ExecuteTemplate(w, "providers.html", data)
+	}
+}
+
+// handleProviderToggle turns a search source on or off. The search page
+// reads the setting on every query, so it applies immediately.
+func (s *Server) handleProviderToggle(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	name := strings.TrimSpace(r.FormValue("name"))
+	if !isSearchProvider(name) {
+		http.Error(w, "unknown provider", http.StatusBadRequest)
+		return
+	}
+	enable := r.FormValue("enabled") == "true"
+	cur := *s.settings.Get()
+	disabled := make([]string, 0, len(cur.Search.DisabledProviders)+1)
+	for _, d := range cur.Search.DisabledProviders {
+		if !strings.EqualFold(strings.TrimSpace(d), name) {
+			disabled = append(disabled, d)
+		}
+	}
+	if !enable {
+		disabled = append(disabled, name)
+	}
+	cur.Search.DisabledProviders = disabled
+	if err := s.settings.Update(&cur); err != nil {
+		http.Error(w, "saving settings failed", http.StatusInternalServerError)
+		return
+	}
+	state := "disabled"
+	if enable {
+		state = "enabled"
+	}
+	s.auditLog(r.Context(), r.Context().Value(ctxUser).(*session).Username, "settings.provider_toggled", nil, name+" "+state)
+	http.Redirect(w, r, "/providers", http.StatusFound)
+}
+
+// apiProviderHealth returns the provider health page's data as JSON.
+func (s *Server) apiProviderHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"providers": s.providerHealthViews(), "quotas": s.providerQuotaViews()}, http.StatusOK)
+}
@@ -0,0 +1,163 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+func TestRecordProviderCall(t *testing.T) {
+	s := newServerForTest(t)
+	s.recordProviderCall(providerOpenLibrary, time.Now().Add(-40*time.Millisecond), nil)
+	s.recordProviderCall(providerOpenLibrary, time.Now().Add(-20*time.Millisecond), errors.New("timeout"))
+
+	var ol providerHealthView
+	for _, v := range s.providerHealthViews() {
+		if v.Name == providerOpenLibrary {
+			ol = v
+		}
+	}
+	if ol.Calls != 2 || ol.Failures != 1 || ol.LastError != "timeout" {
+		t.Fatalf("unexpected stats: %+v", ol)
+	}
+	if !ol.Failing() {
+		t.Fatalf("expected failing after a failed last call")
+	}
+	if ol.AvgLatencyMs < 20 {
+		t.Fatalf("avg latency too low: %d", ol.AvgLatencyMs)
+	}
+
+	s.recordProviderCall(providerOpenLibrary, time.Now(), nil)
+	for _, v := range s.providerHealthViews() {
+		if v.Name == providerOpenLibrary && v.Failing() {
+			t.Fatalf("expected healthy after a successful call: %+v", v)
+		}
+	}
+}
+
+func TestProviderToggleSkipsDisabledProvider(t *testing.T) {
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	var olCalls int32
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&olCalls, 1)
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(`{"docs":[]}`))}, nil
+	}))
+	s := newServerForTest(t)
+	h := s.Router()
+	admin := makeCookie(t, s, "admin", true)
+
+	toggle := func(name, enabled string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		form := url.Values{"name": {name}, "enabled": {enabled}}
+		req := httptest.NewRequest(http.MethodPost, "/providers/toggle", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := toggle(providerOpenLibrary, "false", makeCookie(t, s, "user", false)); rec.Code == http.StatusFound {
+		t.Fatalf("non-admin toggled a provider")
+	}
+	if rec := toggle("bogus", "false", admin); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown provider: %d", rec.Code)
+	}
+	for _, name := range []string{providerOpenLibrary, providerAmazon} {
+		if rec := toggle(name, "false", admin); rec.Code != http.StatusFound {
+			t.Fatalf("toggle %s: %d %s", name, rec.Code, rec.Body.String())
+		}
+	}
+	if s.providerEnabled(providerOpenLibrary) {
+		t.Fatalf("openlibrary still enabled: %v", s.settings.Get().Search.DisabledProviders)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ui/search?q=dune", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("search: %d", rec.Code)
+	}
+	if n := atomic.LoadInt32(&olCalls); n != 0 {
+		t.Fatalf("disabled Open Library was queried %d times", n)
+	}
+
+	if rec := toggle(providerOpenLibrary, "true", admin); rec.Code != http.StatusFound {
+		t.Fatalf("re-enable: %d", rec.Code)
+	}
+	if !s.providerEnabled(providerOpenLibrary) || s.providerEnabled(providerAmazon) {
+		t.Fatalf("unexpected disabled list: %v", s.settings.Get().Search.DisabledProviders)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/providers/health", nil)
+	req.AddCookie(admin)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var out struct {
+		Providers []providerHealthView `json:"providers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || len(out.Providers) != len(searchProviderLabels) {
+		t.Fatalf("health: %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/providers", nil)
+	req.AddCookie(admin)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Open Library") {
+		t.Fatalf("providers page: %d", rec.Code)
+	}
+}
+
+func TestOfflineModeTurnsOffPublicProviders(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Offline = true
+	cfg.ISBNdb.APIKey = "isbndb-key"
+	cfg.Requests.EnrichmentChain = []string{"readarr", "openlibrary"}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if s.providerEnabled(providerOpenLibrary) || s.providerEnabled(providerAmazon) || !s.providerEnabled(providerReadarrEbooks) {
+		t.Fatal("offline mode should leave only Readarr searches on")
+	}
+	if s.chainSource(config.EnrichOpenLibrary) != nil || s.chainSource(config.EnrichISBNdb) != nil {
+		t.Fatal("offline mode should skip the public enrichment sources")
+	}
+	if got := s.defaultNtfyServer(); got != "" {
+		t.Fatalf("ntfy default = %q", got)
+	}
+
+	h := s.Router()
+	req := httptest.NewRequest(http.MethodGet, "/ui/search", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, "Start typing to search") || strings.Contains(body, "Discovery shelves") {
+		t.Fatalf("discovery in offline mode: %d %s", rec.Code, body)
+	}
+
+	// The hidden public provider settings survive a save.
+	form := url.Values{"offline": {"on"}, "search_dedupe": {"identifier"}}
+	req = httptest.NewRequest(http.MethodPost, "/settings/save", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("settings save: %d %s", rec.Code, rec.Body.String())
+	}
+	if got := s.settings.Get(); !got.Offline || got.ISBNdb.APIKey != "isbndb-key" || len(got.Requests.EnrichmentChain) != 2 {
+		t.Fatalf("saved settings: offline=%v isbndb=%q chain=%v", got.Offline, got.ISBNdb.APIKey, got.Requests.EnrichmentChain)
+	}
+}
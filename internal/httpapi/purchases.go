@@ -0,0 +1,228 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// spendReportMonths is how many months the dashboard spend report
+	// covers; the API accepts up to maxSpendReportMonths.
+	spendReportMonths    = 6
+	maxSpendReportMonths = 36
+	maxPurchaseSource    = 100
+)
+
+// parseCost reads an amount such as "12.99", "$12.99", or "12,99" into
+// cents. An empty string is zero.
+func parseCost(in string) (int64, error) {
+	in = strings.TrimSpace(in)
+	if in == "" {
+		return 0, nil
+	}
+	s := strings.TrimFunc(in, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 && len(s)-i-1 <= 2 {
+		s = strings.NewReplacer(".", "", ",", "").Replace(s[:i]) + "." + s[i+1:]
+	} else {
+		s = strings.NewReplacer(".", "", ",", "").Replace(s)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 || f > 1e9 {
+		return 0, errors.New("cost must be an amount such as 12.99")
+	}
+	return int64(f*100 + 0.5), nil
+}
+
+// formatCost renders cents with the configured currency symbol.
+func (s *Server) formatCost(cents int64) string {
+	sym := strings.TrimSpace(s.settings.Get().Requests.Currency)
+	if sym == "" {
+		sym = "$"
+	}
+	return fmt.Sprintf("%s%d.%02d", sym, cents/100, cents%100)
+}
+
+// recordPurchase stores the cost and source an admin entered for request
+// id and notes it in the request history.
+func (s *Server) recordPurchase(ctx context.Context, actor string, id int64, cost, source string) error {
+	cents, err := parseCost(cost)
+	if err != nil {
+		return err
+	}
+	source = strings.Join(strings.Fields(source), " ")
+	if len([]rune(source)) > maxPurchaseSource {
+		return fmt.Errorf("source is too long (maximum %d characters)", maxPurchaseSource)
+	}
+	if err := s.db.SetRequestPurchase(ctx, id, cents, source); err != nil {
+		return fmt.Errorf("db: %w", err)
+	}
+	details := "cleared"
+	if cents > 0 || source != "" {
+		details = s.formatCost(cents)
+		if source != "" {
+			details += " from " + source
+		}
+	}
+	s.auditLog(ctx, actor, "request.purchase", &id, details)
+	return nil
+}
+
+// apiSetRequestPurchase records what a request cost. It accepts JSON
+// {"cost": "12.99", "source": "..."} or a form with the same fields; an
+// empty cost and source clear the purchase.
+func (s *Server) apiSetRequestPurchase(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	var in struct {
+		Cost   json.Number `json:"cost"`
+		Source string      `json:"source"`
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		_ = r.ParseForm()
+		in.Cost, in.Source = json.Number(r.FormValue("cost")), r.FormValue("source")
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	username := r.Context().Value(ctxUser).(*session).Username
+	if err := s.recordPurchase(r.Context(), username, id, in.Cost.String(), in.Source); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req, _ = s.db.GetRequest(r.Context(), id)
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{"id": id, "purchaseCents": req.PurchaseCents, "purchaseSource": req.PurchaseSource}, http.StatusOK)
+}
+
+// spendLine is one requester's or format's share of a month's spend.
+type spendLine struct {
+	Name   string `json:"name"`
+	Cents  int64  `json:"cents"`
+	Amount string `json:"amount"`
+	Count  int    `json:"count"`
+}
+
+// spendMonth is one month of the spend report.
+type spendMonth struct {
+	Month    string      `json:"month"` // YYYY-MM
+	Label    string      `json:"label"`
+	Cents    int64       `json:"cents"`
+	Amount   string      `json:"amount"`
+	Count    int         `json:"count"`
+	ByFormat []spendLine `json:"byFormat"`
+	ByUser   []spendLine `json:"byUser"`
+}
+
+// spendReport totals recorded purchases for the last months calendar
+// months, including the current one, newest first. Months without
+// purchases are left out.
+func (s *Server) spendReport(ctx context.Context, months int) ([]spendMonth, error) {
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-months, 0)
+	rows, err := s.db.SpendByMonth(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	out := []spendMonth{}
+	byFormat := map[string]*spendLine{}
+	byUser := map[string]*spendLine{}
+	add := func(m map[string]*spendLine, name string, row db.SpendRow) {
+		l := m[name]
+		if l == nil {
+			l = &spendLine{Name: name}
+			m[name] = l
+		}
+		l.Cents += row.Cents
+		l.Count += row.Count
+	}
+	flush := func() {
+		if len(out) == 0 {
+			return
+		}
+		m := &out[len(out)-1]
+		m.Amount = s.formatCost(m.Cents)
+		m.ByFormat, m.ByUser = s.spendLines(byFormat), s.spendLines(byUser)
+		byFormat, byUser = map[string]*spendLine{}, map[string]*spendLine{}
+	}
+	for _, row := range rows {
+		if len(out) == 0 || out[len(out)-1].Month != row.Month {
+			flush()
+			label := row.Month
+			if t, err := time.Parse("2006-01", row.Month); err == nil {
+				label = t.Format("January 2006")
+			}
+			out = append(out, spendMonth{Month: row.Month, Label: label})
+		}
+		m := &out[len(out)-1]
+		m.Cents += row.Cents
+		m.Count += row.Count
+		add(byFormat, row.Format, row)
+		add(byUser, row.Requester, row)
+	}
+	flush()
+	return out, nil
+}
+
+// spendLines orders a month's shares by amount, largest first.
+func (s *Server) spendLines(m map[string]*spendLine) []spendLine {
+	out := make([]spendLine, 0, len(m))
+	for _, l := range m {
+		l.Amount = s.formatCost(l.Cents)
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cents != out[j].Cents {
+			return out[i].Cents > out[j].Cents
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// apiSpendReport returns monthly spend per requester and format. ?months=
+// picks how many months back to go (default 6, at most 36).
+func (s *Server) apiSpendReport(w http.ResponseWriter, r *http.Request) {
+	months := spendReportMonths
+	if n, err := strconv.Atoi(r.URL.Query().Get("months")); err == nil && n > 0 {
+		months = min(n, maxSpendReportMonths)
+	}
+	report, err := s.spendReport(r.Context(), months)
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"months": report}, http.StatusOK)
+}
+
+// handleSpendReport renders the dashboard spend panel for admins.
+func (u *ui) handleSpendReport(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, _ := s.spendReport(r.Context(), spendReportMonths)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = u.tpl.ExecuteTemplate(w, "spend_report", map[string]any{"Months": report})
+	}
+}
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestParseCost(t *testing.T) {
+	cases := map[string]int64{"": 0, "12.99": 1299, "$12.99": 1299, "12,99 €": 1299, "1,299.50": 129950, "1.299": 129900, "7": 700}
+	for in, want := range cases {
+		if got, err := parseCost(in); err != nil || got != want {
+			t.Errorf("parseCost(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := parseCost("twelve"); err == nil {
+		t.Error("parseCost accepted a word")
+	}
+}
+
+func TestPurchaseTrackingAndSpendReport(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	h := s.Router()
+	admin := makeCookie(t, s, "admin", true)
+	a, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "audiobook", Status: "pending"})
+	b, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "bob", Title: "Emma", Format: "ebook", Status: "pending"})
+
+	form := url.Values{"purchase_cost": {"$24.99"}, "purchase_source": {"Audible"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(a, 10)+"/approve", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(admin)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/requests/"+strconv.FormatInt(b, 10)+"/purchase", strings.NewReader(`{"cost":"9.99","source":"Kobo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(admin)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("purchase: %d %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := s.db.GetRequest(ctx, a); got.Status != "approved" || got.PurchaseCents != 2499 || got.PurchaseSource != "Audible" {
+		t.Fatalf("approved request = %+v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/spend", nil)
+	req.AddCookie(admin)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var out struct {
+		Months []spendMonth `json:"months"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || len(out.Months) != 1 {
+		t.Fatalf("spend: %d %s", rec.Code, rec.Body.String())
+	}
+	m := out.Months[0]
+	if m.Cents != 3498 || m.Amount != "$34.98" || m.Count != 2 || len(m.ByUser) != 2 || m.ByUser[0].Name != "alice" || m.ByFormat[1] != (spendLine{"ebook", 999, "$9.99", 1}) {
+		t.Fatalf("month = %+v", m)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ui/admin/spend", nil)
+	req.AddCookie(admin)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "$34.98") || !strings.Contains(rec.Body.String(), "alice $24.99") {
+		t.Fatalf("panel: %s", rec.Body.String())
+	}
+}
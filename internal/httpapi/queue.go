@@ -0,0 +1,199 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxQueueItems caps how many requests the mobile queue shows at once.
+const maxQueueItems = 200
+
+// queueItem is one request on the mobile approval queue.
+type queueItem struct {
+	ID            int64     `json:"id"`
+	Title         string    `json:"title"`
+	Authors       []string  `json:"authors"`
+	Format        string    `json:"format"`
+	Requester     string    `json:"requester"`
+	CoverURL      string    `json:"coverUrl,omitempty"`
+	FirstApprover string    `json:"firstApprover,omitempty"`
+	NeedsReview   bool      `json:"needsReview,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	// NeedBy is the requester's need-by date, NeedByLabel its display
+	// form, and NeedByState needBySoon or needByOverdue as it nears.
+	NeedBy      string `json:"needBy,omitempty"`
+	NeedByLabel string `json:"needByLabel,omitempty"`
+	NeedByState string `json:"needByState,omitempty"`
+}
+
+// changed reports whether the card for it needs redrawing.
+func (it queueItem) changed(old queueItem) bool {
+	return it.FirstApprover != old.FirstApprover || it.NeedsReview != old.NeedsReview || it.NeedByState != old.NeedByState
+}
+
+// pendingQueue returns the pending requests admin can act on: those with a
+// need-by date first, soonest first, then the rest oldest first. Requests
+// admin already gave the first of two approvals are left out; they wait for
+// someone else.
+func (s *Server) pendingQueue(ctx context.Context, admin string) ([]queueItem, error) {
+	reqs, err := s.db.ListRequestsByStatus(ctx, "pending", maxQueueItems)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]queueItem, 0, len(reqs))
+	for _, r := range reqs {
+		if strings.EqualFold(r.FirstApprover, admin) {
+			continue
+		}
+		out = append(out, queueItem{
+			ID: r.ID, Title: r.Title, Authors: r.Authors, Format: r.Format, Requester: r.RequesterEmail,
+			CoverURL: s.displayCover(r.CoverURL, r.ISBN13, r.ISBN10), FirstApprover: r.FirstApprover, NeedsReview: r.NeedsReview,
+			CreatedAt: r.CreatedAt,
+		})
+		if r.NeedBy != "" {
+			it := &out[len(out)-1]
+			it.NeedBy, it.NeedByLabel, it.NeedByState = r.NeedBy, formatNeedBy(r.NeedBy, now), needByState(r, now)
+		}
+	}
+	// YYYY-MM-DD dates sort as strings.
+	slices.SortStableFunc(out, func(a, b queueItem) int {
+		switch {
+		case a.NeedBy == b.NeedBy:
+			return 0
+		case a.NeedBy == "":
+			return 1
+		case b.NeedBy == "":
+			return -1
+		}
+		return strings.Compare(a.NeedBy, b.NeedBy)
+	})
+	return out, nil
+}
+
+// queueEvent is one server-sent event on the queue stream: the full queue
+// when the stream opens, then cards added or changed and ids removed.
+type queueEvent struct {
+	Name string
+	Data any
+}
+
+// watchQueue sends admin's queue, then polls it and sends the differences.
+// The channel closes with ctx.
+func (s *Server) watchQueue(ctx context.Context, admin string) <-chan queueEvent {
+	out := make(chan queueEvent)
+	go func() {
+		defer close(out)
+		send := func(ev queueEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		items, err := s.pendingQueue(ctx, admin)
+		if err != nil {
+			return
+		}
+		if !send(queueEvent{"snapshot", items}) {
+			return
+		}
+		prev := make(map[int64]queueItem, len(items))
+		for _, it := range items {
+			prev[it.ID] = it
+		}
+		ticker := time.NewTicker(graphqlPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			items, err := s.pendingQueue(ctx, admin)
+			if err != nil {
+				continue
+			}
+			cur := make(map[int64]queueItem, len(items))
+			for _, it := range items {
+				cur[it.ID] = it
+				if old, seen := prev[it.ID]; seen && !it.changed(old) {
+					continue
+				}
+				if !send(queueEvent{"upsert", it}) {
+					return
+				}
+			}
+			for id := range prev {
+				if _, ok := cur[id]; ok {
+					continue
+				}
+				if !send(queueEvent{"remove", map[string]int64{"id": id}}) {
+					return
+				}
+			}
+			prev = cur
+		}
+	}()
+	return out
+}
+
+// apiQueueEvents streams the caller's approval queue as server-sent events
+// so the mobile queue stays current while other admins work through it.
+func (s *Server) apiQueueEvents(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxUser).(*session).Username
+	events := s.watchQueue(r.Context(), username)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	heartbeat := time.NewTicker(graphqlSSEHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, _ = w.Write([]byte(": keep-alive\n\n"))
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			b, _ := json.Marshal(ev.Data)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, b)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// handleQueue renders one of the pages built on the pending queue: the
+// swipeable mobile queue or the keyboard triage list.
+func (u *ui) handleQueue(s *Server, page string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := r.Context().Value(ctxUser).(*session).Username
+		items, err := s.pendingQueue(r.Context(), username)
+		if err != nil {
+			http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data := map[string]any{
+			"UserName":  s.userName(r),
+			"IsAdmin":   true,
+			"CSRFToken": s.getCSRFToken(r),
+			"Items":     items,
+		}
+		_ = u.tpl.ExecuteTemplate(w, page, data)
+	}
+}
@@ -0,0 +1,103 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestQueuePageAndEvents(t *testing.T) {
+	s := newServerForTest(t)
+	srv := httptest.NewServer(s.Router())
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	newRequest := func(title, status, first string) int64 {
+		id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: title, Format: "ebook", Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != "" {
+			if _, err := s.db.RecordFirstApproval(ctx, id, first, "first approval"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return id
+	}
+	dune := newRequest("Dune", "pending", "")
+	newRequest("Held For Others", "pending", "bob")
+	newRequest("Held By Me", "pending", "admin")
+	newRequest("Done", "approved", "")
+	cookie := makeCookie(t, s, "admin", true)
+
+	get := func(path string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		req.AddCookie(cookie)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	for _, path := range []string{"/requests/queue", "/requests/triage"} {
+		page := get(path)
+		body, _ := io.ReadAll(page.Body)
+		page.Body.Close()
+		if page.StatusCode != http.StatusOK || !strings.Contains(string(body), "Dune") || !strings.Contains(string(body), "Held For Others") ||
+			strings.Contains(string(body), "Held By Me") || strings.Contains(string(body), ">Done<") {
+			t.Fatalf("%s %d: %s", path, page.StatusCode, body)
+		}
+	}
+
+	resp := get("/api/v1/requests/queue/events")
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, ct)
+	}
+	events := bufio.NewScanner(resp.Body)
+	next := func() (string, string) {
+		var name string
+		for events.Scan() {
+			line := events.Text()
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = v
+			}
+			if v, ok := strings.CutPrefix(line, "data: "); ok {
+				return name, v
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return "", ""
+	}
+	if name, data := next(); name != "snapshot" || !strings.Contains(data, `"Dune"`) || strings.Contains(data, "Held By Me") {
+		t.Fatalf("first event %s: %s", name, data)
+	}
+
+	// Another admin declines Dune and a new request arrives.
+	if err := s.db.DeclineRequest(ctx, dune, "bob", "no"); err != nil {
+		t.Fatal(err)
+	}
+	emma := newRequest("Emma", "pending", "")
+	seen := map[string]string{}
+	for len(seen) < 2 {
+		name, data := next()
+		seen[name] = data
+	}
+	if seen["remove"] != `{"id":`+strconv.FormatInt(dune, 10)+`}` || !strings.Contains(seen["upsert"], `"id":`+strconv.FormatInt(emma, 10)) {
+		t.Fatalf("events = %v", seen)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/requests/queue/events", nil)
+	req.AddCookie(makeCookie(t, s, "alice", false))
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-admin stream: %v %v", resp, err)
+	}
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"gitea.knapp/jacoknapp/scriptorum/internal/util"
+)
+
+// quickISBNPattern finds bare ISBNs inside store URLs and page titles that
+// ParseBookURL does not know, e.g. /p/books/dune/9780441013593.
+var quickISBNPattern = regexp.MustCompile(`(?:^|[^0-9])(97[89][0-9]{10}|[0-9]{9}[0-9Xx])(?:$|[^0-9])`)
+
+// quickRequestIdentifier picks the identifier to look up for a bookmarklet
+// call: the isbn parameter, then a recognised store link, then an ISBN found
+// in the URL or page title. It returns "" when there is none.
+func quickRequestIdentifier(isbn, link, title string) string {
+	for _, v := range []string{isbn, link} {
+		if _, ok := providers.ParseBookIdentifier(v); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	for _, v := range []string{link, title} {
+		for _, m := range quickISBNPattern.FindAllStringSubmatch(v, -1) {
+			if ref, ok := providers.ParseBookIdentifier(m[1]); ok && ref.Source == "isbn" {
+				return m[1]
+			}
+		}
+	}
+	return ""
+}
+
+// quickRequestBook resolves the bookmarklet parameters to a book. Without
+// an identifier, or when the lookup finds nothing, the page title stands in
+// so the user can still request it by name.
+func (s *Server) quickRequestBook(ctx context.Context, isbn, link, title, author string) bulkRow {
+	if id := quickRequestIdentifier(isbn, link, title); id != "" {
+		if row := s.resolveBulkRow(ctx, 1, id); row.Matched {
+			return row
+		}
+	}
+	row := bulkRow{Line: 1, Input: util.FirstNonEmpty(strings.TrimSpace(link), strings.TrimSpace(isbn)), Title: cleanPageTitle(title)}
+	if a := strings.TrimSpace(author); a != "" {
+		row.Authors = []string{a}
+	}
+	if ref, ok := providers.ParseBookIdentifier(isbn); ok {
+		row.ISBN10, row.ISBN13, row.ASIN = ref.ISBN10, ref.ISBN13, ref.ASIN
+	}
+	row.Status = "not_found"
+	return row
+}
+
+// cleanPageTitle trims store decorations off a document title, such as
+// "Dune: Frank Herbert: 9780441013593: Amazon.com: Books" or
+// "Dune by Frank Herbert | Goodreads".
+func cleanPageTitle(title string) string {
+	title = strings.TrimSpace(title)
+	for _, sep := range []string{" | ", ": ", " - "} {
+		if i := strings.Index(title, sep); i > 0 {
+			title = title[:i]
+		}
+	}
+	if i := strings.LastIndex(title, " by "); i > 0 {
+		title = title[:i]
+	}
+	return strings.TrimSpace(title)
+}
+
+// handleQuickRequest renders the bookmarklet landing page: the book found
+// for the url, title, and isbn query parameters with a one-click request
+// button, or the bookmarklet itself when called without any.
+func (u *ui) handleQuickRequest(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ses := r.Context().Value(ctxUser).(*session)
+		q := r.URL.Query()
+		link, title, isbn := strings.TrimSpace(q.Get("url")), strings.TrimSpace(q.Get("title")), strings.TrimSpace(q.Get("isbn"))
+		data := map[string]any{
+			"UserName":      s.userName(r),
+			"IsAdmin":       ses.Admin,
+			"CanModerate":   s.canModerateRequests(r),
+			"CSRFToken":     s.getCSRFToken(r),
+			"Format":        bulkFormat(q.Get("format")),
+			"RequestFields": s.requestFields(),
+			"Extra":         map[string]string{},
+			"Source":        link,
+		}
+		if link != "" || title != "" || isbn != "" {
+			book := s.quickRequestBook(r.Context(), isbn, link, title, q.Get("author"))
+			if book.Title == "" && book.ISBN13 == "" && book.ISBN10 == "" && book.ASIN == "" {
+				data["Error"] = "No book found on that page. Try the search instead."
+			} else {
+				data["Book"] = book
+				data["Quota"] = s.requestQuota(r.Context(), ses.Username, requestGroup(r))
+			}
+		}
+		_ = u.tpl.ExecuteTemplate(w, "quick_request.html", data)
+	}
+}
+
+// handleQuickRequestCreate requests the confirmed book through
+// createBulkRows, so quota, duplicate, and auto-approval rules apply as for
+// any request, and shows the outcome.
+func (u *ui) handleQuickRequestCreate(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ses := r.Context().Value(ctxUser).(*session)
+		_ = r.ParseForm()
+		var book bulkRow
+		if err := json.Unmarshal([]byte(r.FormValue("row")), &book); err != nil {
+			http.Error(w, "invalid book", http.StatusBadRequest)
+			return
+		}
+		format := bulkFormat(r.FormValue("format"))
+		extra := s.extrasFromForm(r)
+		data := map[string]any{
+			"UserName":      s.userName(r),
+			"IsAdmin":       ses.Admin,
+			"CanModerate":   s.canModerateRequests(r),
+			"CSRFToken":     s.getCSRFToken(r),
+			"Format":        format,
+			"RequestFields": s.requestFields(),
+			"Extra":         extra,
+			"Book":          book,
+		}
+		if _, err := s.collectRequestExtras(extra); err != nil {
+			data["FieldError"] = err.Error()
+		} else {
+			data["Result"] = s.createBulkRows(withRequestSource(r, sourceBookmarklet), format, []bulkRow{book}, extra)[0]
+		}
+		_ = u.tpl.ExecuteTemplate(w, "quick_request.html", data)
+	}
+}
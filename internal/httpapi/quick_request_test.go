@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestQuickRequestIdentifier(t *testing.T) {
+	cases := []struct{ isbn, link, title, want string }{
+		{"978-0-441-01359-3", "https://example.com/", "", "978-0-441-01359-3"},
+		{"", "https://www.amazon.com/dp/B08FHBV4ZX", "", "https://www.amazon.com/dp/B08FHBV4ZX"},
+		{"", "https://bookshop.example/p/books/dune/9780441013593?ean=1", "", "9780441013593"},
+		{"", "https://example.com/books/12345", "Dune (ISBN 9780441013593)", "9780441013593"},
+		{"", "https://example.com/order/1234567890", "", ""},
+	}
+	for _, c := range cases {
+		if got := quickRequestIdentifier(c.isbn, c.link, c.title); got != c.want {
+			t.Errorf("quickRequestIdentifier(%q, %q, %q) = %q, want %q", c.isbn, c.link, c.title, got, c.want)
+		}
+	}
+	if got := cleanPageTitle("Dune by Frank Herbert | Goodreads"); got != "Dune" {
+		t.Errorf("cleanPageTitle = %q", got)
+	}
+}
+
+func TestQuickRequestByTitle(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	cookie := makeCookie(t, s, "alice", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/quick-request?"+url.Values{"url": {"https://example.com/books/dune"}, "title": {"Dune by Frank Herbert | Example Books"}, "author": {"Frank Herbert"}}.Encode(), nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Dune") || !strings.Contains(body, `action="/quick-request"`) {
+		t.Fatalf("confirm page: %d %s", rec.Code, body)
+	}
+
+	form := url.Values{"row": {`{"line":1,"title":"Dune","authors":["Frank Herbert"],"status":"not_found"}`}, "format": {"audiobook"}}
+	req = httptest.NewRequest(http.MethodPost, "/quick-request", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "View request #") {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	items, err := s.db.ListRequests(context.Background(), "alice", 10)
+	if err != nil || len(items) != 1 || items[0].Title != "Dune" || items[0].Format != "audiobook" {
+		t.Fatalf("requests = %+v, %v", items, err)
+	}
+}
@@ -0,0 +1,92 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// requestQuota is how much of the pending-request cap a user has used. A
+// request counts against the cap until it is approved or declined.
+type requestQuota struct {
+	Unlimited bool `json:"unlimited"`
+	Limit     int  `json:"limit"`
+	Pending   int  `json:"pending"`
+	Remaining int  `json:"remaining"`
+}
+
+// requestCost estimates what submitting Count requests would do to the
+// quota. Warn is set when they would use most of what is left, Exceeds when
+// some of them would be refused.
+type requestCost struct {
+	Count     int  `json:"count"`
+	Remaining int  `json:"remaining_after"`
+	Warn      bool `json:"warn"`
+	Exceeds   bool `json:"exceeds"`
+	// Allowed is how many of the Count requests fit in the quota.
+	Allowed int `json:"allowed"`
+}
+
+// requestQuota returns username's quota under their own pending cap, or
+// group's when they have none.
+func (s *Server) requestQuota(ctx context.Context, username, group string) requestQuota {
+	limit := s.maxPendingForGroup(group)
+	if u, err := s.db.GetUserByUsername(ctx, username); err == nil && u.MaxPending > 0 {
+		limit = u.MaxPending
+	}
+	if limit <= 0 {
+		return requestQuota{Unlimited: true}
+	}
+	pending, _ := s.db.CountPendingRequestsByUser(ctx, username)
+	q := requestQuota{Limit: limit, Pending: pending, Remaining: limit - pending}
+	if q.Remaining < 0 {
+		q.Remaining = 0
+	}
+	return q
+}
+
+// estimate returns the cost of n more requests. More than one request that
+// uses over half of the remaining slots is worth a warning.
+func (q requestQuota) estimate(n int) requestCost {
+	if n < 0 {
+		n = 0
+	}
+	c := requestCost{Count: n, Allowed: n}
+	if q.Unlimited {
+		c.Remaining = -1
+		return c
+	}
+	c.Remaining = q.Remaining - n
+	if c.Remaining < 0 {
+		c.Remaining = 0
+		c.Allowed = q.Remaining
+		c.Exceeds = true
+	}
+	c.Warn = c.Exceeds || (n > 1 && n*2 > q.Remaining)
+	return c
+}
+
+// apiMe describes the signed-in user and their request quota. With
+// ?count=N it also estimates what N more requests would cost.
+func (s *Server) apiMe(w http.ResponseWriter, r *http.Request) {
+	u := r.Context().Value(ctxUser).(*session)
+	group := requestGroup(r)
+	q := s.requestQuota(r.Context(), u.Username, group)
+	out := map[string]any{
+		"username": u.Username,
+		"name":     u.Name,
+		"admin":    u.Admin,
+		"group":    group,
+		"quota":    q,
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("count")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "count must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		out["estimate"] = q.estimate(n)
+	}
+	writeJSON(w, out, http.StatusOK)
+}
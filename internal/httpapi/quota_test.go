@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestRequestQuotaEstimate(t *testing.T) {
+	q := requestQuota{Limit: 10, Pending: 4, Remaining: 6}
+	if c := q.estimate(1); c.Warn || c.Exceeds || c.Remaining != 5 {
+		t.Fatalf("single request: %+v", c)
+	}
+	if c := q.estimate(4); !c.Warn || c.Exceeds || c.Remaining != 2 {
+		t.Fatalf("series of 4: %+v", c)
+	}
+	if c := q.estimate(8); !c.Exceeds || c.Allowed != 6 || c.Remaining != 0 {
+		t.Fatalf("series of 8: %+v", c)
+	}
+	if c := (requestQuota{Unlimited: true}).estimate(50); c.Warn || c.Allowed != 50 {
+		t.Fatalf("unlimited: %+v", c)
+	}
+}
+
+func TestMeExposesQuota(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Requests.MaxPendingPerUser = 3
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	for _, title := range []string{"Dune", "Emma"} {
+		_, _ = s.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "user", Title: title, Format: "ebook", Status: "pending"})
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(makeCookie(t, s, "user", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/api/v1/me?count=3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("me: %d %s", rec.Code, rec.Body.String())
+	}
+	var me struct {
+		Username string       `json:"username"`
+		Quota    requestQuota `json:"quota"`
+		Estimate requestCost  `json:"estimate"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &me)
+	if me.Username != "user" || me.Quota.Limit != 3 || me.Quota.Pending != 2 || me.Quota.Remaining != 1 {
+		t.Fatalf("me = %+v", me)
+	}
+	if !me.Estimate.Exceeds || me.Estimate.Allowed != 1 {
+		t.Fatalf("estimate = %+v", me.Estimate)
+	}
+	if rec := get("/api/v1/me?count=x"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad count: %d", rec.Code)
+	}
+	if body := get("/search").Body.String(); !strings.Contains(body, "1 of 3 request slots left") {
+		t.Fatalf("search page missing quota line: %s", body)
+	}
+}
@@ -0,0 +1,186 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+const readarrAuthRecheckInterval = time.Minute
+
+func readarrAuthAlertKey(inst providers.ReadarrInstance) string {
+	return "readarr-auth|" + strings.TrimRight(strings.TrimSpace(inst.BaseURL), "/")
+}
+
+// readarrInstanceLabel names an instance for admin-facing messages, e.g.
+// "ebooks" or "smiths audiobooks", falling back to its base URL.
+func (s *Server) readarrInstanceLabel(inst providers.ReadarrInstance) string {
+	base := strings.TrimRight(strings.TrimSpace(inst.BaseURL), "/")
+	same := func(u string) bool { return base != "" && strings.TrimRight(strings.TrimSpace(u), "/") == base }
+	cfg := s.settings.Get()
+	switch {
+	case same(cfg.Readarr.Ebooks.BaseURL):
+		return "ebooks"
+	case same(cfg.Readarr.Audiobooks.BaseURL):
+		return "audiobooks"
+	}
+	for _, g := range cfg.Groups {
+		if same(g.Readarr.Ebooks.BaseURL) {
+			return g.Name + " ebooks"
+		}
+		if same(g.Readarr.Audiobooks.BaseURL) {
+			return g.Name + " audiobooks"
+		}
+	}
+	return base
+}
+
+// configuredReadarrInstances lists every Readarr instance with a base URL and
+// API key: the top-level ebooks/audiobooks instances and any group mappings.
+func (s *Server) configuredReadarrInstances() []config.ReadarrInstance {
+	cfg := s.settings.Get()
+	all := []config.ReadarrInstance{cfg.Readarr.Ebooks, cfg.Readarr.Audiobooks}
+	for _, g := range cfg.Groups {
+		all = append(all, g.Readarr.Ebooks, g.Readarr.Audiobooks)
+	}
+	out := make([]config.ReadarrInstance, 0, len(all))
+	for _, c := range all {
+		if strings.TrimSpace(c.BaseURL) != "" && strings.TrimSpace(c.APIKey) != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// noteReadarrError inspects an error returned by the Readarr client. Auth
+// failures pause approvals for the instance, raise an admin banner, and send a
+// one-time system notification. It reports whether err was an auth failure.
+func (s *Server) noteReadarrError(inst providers.ReadarrInstance, err error) bool {
+	if !errors.Is(err, providers.ErrReadarrUnauthorized) {
+		return false
+	}
+	s.readarrAuthMu.Lock()
+	if s.readarrAuthFailed == nil {
+		s.readarrAuthFailed = make(map[string]string)
+	}
+	s.readarrAuthFailed[readarrAuthAlertKey(inst)] = inst.APIKey
+	s.readarrAuthMu.Unlock()
+
+	label := s.readarrInstanceLabel(inst)
+	title := fmt.Sprintf("Readarr %s instance rejected API key", label)
+	msg := "Approvals for this instance are paused. Update the API key in Settings; queued approvals resume automatically once Readarr accepts it."
+	if s.raiseAdminAlert(readarrAuthAlertKey(inst), "error", title, msg) {
+		s.auditLog(context.Background(), "system", "readarr.auth_failed", nil, label)
+		go s.SendSystemNotification(title, msg)
+	}
+	return true
+}
+
+// readarrAuthPaused reports whether inst is known to have a rejected API key.
+// Changing the key in settings unpauses the instance immediately.
+func (s *Server) readarrAuthPaused(inst providers.ReadarrInstance) bool {
+	s.readarrAuthMu.Lock()
+	defer s.readarrAuthMu.Unlock()
+	key, ok := s.readarrAuthFailed[readarrAuthAlertKey(inst)]
+	return ok && key == inst.APIKey
+}
+
+func (s *Server) clearReadarrAuthFailure(inst providers.ReadarrInstance) {
+	s.readarrAuthMu.Lock()
+	delete(s.readarrAuthFailed, readarrAuthAlertKey(inst))
+	s.readarrAuthMu.Unlock()
+	if s.clearAdminAlert(readarrAuthAlertKey(inst)) {
+		label := s.readarrInstanceLabel(inst)
+		s.auditLog(context.Background(), "system", "readarr.auth_restored", nil, label)
+		go s.SendSystemNotification(fmt.Sprintf("Readarr %s instance accepted API key", label), "Paused approvals are resuming.")
+	}
+}
+
+// parkApproval holds a job whose Readarr instance rejected the API key.
+func (s *Server) parkApproval(job approvalJob) {
+	s.readarrAuthMu.Lock()
+	s.parkedApprovals = append(s.parkedApprovals, job)
+	s.readarrAuthMu.Unlock()
+	reason := fmt.Sprintf("paused: Readarr %s rejected the API key", s.readarrInstanceLabel(job.inst))
+	_ = s.db.UpdateRequestStatus(context.Background(), job.id, "processing", reason, "system", nil, nil)
+}
+
+// resumeParkedApprovals re-queues parked approvals whose instance is usable
+// again, either because the API key changed or because a probe succeeded.
+func (s *Server) resumeParkedApprovals(ctx context.Context) {
+	s.readarrAuthMu.Lock()
+	parked := s.parkedApprovals
+	s.parkedApprovals = nil
+	failed := make(map[string]string, len(s.readarrAuthFailed))
+	for k, v := range s.readarrAuthFailed {
+		failed[k] = v
+	}
+	s.readarrAuthMu.Unlock()
+
+	// Probe each instance that is still marked as failing with its current key.
+	healthy := make(map[string]bool)
+	probe := func(inst providers.ReadarrInstance) bool {
+		k := readarrAuthAlertKey(inst) + "|" + inst.APIKey
+		if ok, seen := healthy[k]; seen {
+			return ok
+		}
+		pctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := providers.NewReadarrWithDB(inst, s.db.SQL()).PingLookup(pctx)
+		cancel()
+		ok := err == nil
+		if ok {
+			s.clearReadarrAuthFailure(inst)
+		} else {
+			s.noteReadarrError(inst, err)
+		}
+		healthy[k] = ok
+		return ok
+	}
+
+	var still []approvalJob
+	for _, job := range parked {
+		inst := s.readarrInstanceForRequest(job.req)
+		if s.readarrAuthPaused(inst) && !probe(inst) {
+			still = append(still, job)
+			continue
+		}
+		job.inst = inst
+		if err := s.enqueueAsyncApproval(job.id, job.req, job.inst, job.username); err != nil {
+			still = append(still, job)
+		}
+	}
+	// Re-check every failing instance, even with nothing parked behind it, so
+	// the banner clears once the key is fixed.
+	for _, c := range s.configuredReadarrInstances() {
+		inst := s.toProviderInstance(c)
+		if _, ok := failed[readarrAuthAlertKey(inst)]; ok {
+			probe(inst)
+		}
+	}
+
+	if len(still) > 0 {
+		s.readarrAuthMu.Lock()
+		s.parkedApprovals = append(still, s.parkedApprovals...)
+		s.readarrAuthMu.Unlock()
+	}
+}
+
+// runReadarrAuthRecovery periodically retries parked approvals until ctx is
+// cancelled.
+func (s *Server) runReadarrAuthRecovery(ctx context.Context) {
+	ticker := time.NewTicker(readarrAuthRecheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.resumeParkedApprovals(ctx)
+		}
+	}
+}
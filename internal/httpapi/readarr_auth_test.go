@@ -0,0 +1,110 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestReadarrAuthFailureParksApprovalUntilKeyWorks(t *testing.T) {
+	var accept atomic.Bool
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !accept.Load() {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/book" && r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":7,"monitored":true}`))
+		case r.URL.Path == "/api/v1/book/lookup":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "old-key"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	ctx := context.Background()
+	req := &db.Request{RequesterEmail: "user", Title: "Locked", Authors: []string{"A"}, Format: "ebook", Status: "pending",
+		ReadarrReq: []byte(`{"title":"Locked","foreignBookId":"fb","editions":[{"foreignEditionId":"fe","monitored":true}],"author":{"name":"A","id":1}}`)}
+	id, err := s.db.CreateRequest(ctx, req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	req.ID = id
+
+	s.processAsyncApproval(id, req, s.readarrInstanceForRequest(req), "admin")
+
+	got, _ := s.db.GetRequest(ctx, id)
+	if got.Status != "processing" || !strings.Contains(got.StatusReason, "rejected the API key") {
+		t.Fatalf("expected parked request, got %q / %q", got.Status, got.StatusReason)
+	}
+	alerts := s.listAdminAlerts()
+	if len(alerts) != 1 || !strings.Contains(alerts[0].Title, "Readarr ebooks instance rejected API key") {
+		t.Fatalf("expected one auth alert, got %+v", alerts)
+	}
+	if !s.readarrAuthPaused(s.readarrInstanceForRequest(req)) {
+		t.Fatalf("instance should be paused")
+	}
+
+	// Still rejected: the job stays parked.
+	s.resumeParkedApprovals(ctx)
+	if len(s.parkedApprovals) != 1 {
+		t.Fatalf("expected job to stay parked, got %d", len(s.parkedApprovals))
+	}
+
+	// Readarr accepts the key again: the probe clears the alert and the job
+	// is resubmitted through the approval queue.
+	accept.Store(true)
+	s.resumeParkedApprovals(ctx)
+	if len(s.listAdminAlerts()) != 0 {
+		t.Fatalf("expected alert cleared")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if got, _ := s.db.GetRequest(ctx, id); got.Status == "queued" {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for resumed approval")
+}
+
+func TestReadarrAuthPauseLiftsWhenKeyChanges(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Audiobooks.BaseURL = "http://readarr-audio:8787"
+	cfg.Readarr.Audiobooks.APIKey = "old"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	inst := s.readarrInstanceForRequest(&db.Request{Format: "audiobook"})
+	if s.noteReadarrError(inst, context.Canceled) {
+		t.Fatalf("non-auth errors must not pause the instance")
+	}
+	s.readarrAuthFailed = map[string]string{readarrAuthAlertKey(inst): "old"}
+	if !s.readarrAuthPaused(inst) {
+		t.Fatalf("expected paused with the rejected key")
+	}
+	inst.APIKey = "new"
+	if s.readarrAuthPaused(inst) {
+		t.Fatalf("expected pause lifted after key change")
+	}
+	if got := s.readarrInstanceLabel(inst); got != "audiobooks" {
+		t.Fatalf("label = %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// readarrCacheExportVersion is the version of the cache export file this
+// server writes and the newest it can import.
+const readarrCacheExportVersion = 1
+
+// readarrCacheImportLimit bounds an uploaded cache export; the book cache
+// carries each book's Readarr JSON, so large libraries run to tens of MB.
+const readarrCacheImportLimit = 256 << 20
+
+// readarrCacheExport is the file the cache export downloads and the cache
+// import reads back.
+type readarrCacheExport struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+	// Instances maps each format to the Readarr base URL its authors were
+	// cached under, so an import can move them to the importing server's
+	// instances when the URL changed with the move.
+	Instances map[string]string `json:"instances"`
+	db.ReadarrCache
+}
+
+// readarrCacheScopes returns the author cache scope of each configured
+// Readarr instance by format.
+func (s *Server) readarrCacheScopes() map[string]string {
+	out := map[string]string{}
+	for _, kind := range []string{"ebook", "audiobook"} {
+		if inst, ok := s.readarrInstanceForFormat(kind); ok {
+			out[kind] = providers.AuthorCacheScope(inst)
+		}
+	}
+	return out
+}
+
+// apiReadarrCacheExport downloads the cached Readarr authors and books as
+// JSON, for loading into another install with apiReadarrCacheImport.
+func (s *Server) apiReadarrCacheExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cache, err := s.db.ExportReadarrCache(r.Context())
+		if err != nil {
+			http.Error(w, "failed to read the Readarr cache", http.StatusInternalServerError)
+			return
+		}
+		out := readarrCacheExport{
+			Version:      readarrCacheExportVersion,
+			ExportedAt:   time.Now().UTC(),
+			Instances:    s.readarrCacheScopes(),
+			ReadarrCache: *cache,
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="scriptorum-readarr-cache.json"`)
+		writeJSON(w, out, http.StatusOK)
+	}
+}
+
+// apiReadarrCacheImport loads a cache export, uploaded as the "file" form
+// field or sent as the JSON body. Authors cached under an instance whose
+// base URL has since changed are moved to the current URL for that format.
+func (s *Server) apiReadarrCacheImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, readarrCacheImportLimit)
+		var body io.Reader = r.Body
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				http.Error(w, "could not read the upload", http.StatusBadRequest)
+				return
+			}
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, "choose an export file to import", http.StatusBadRequest)
+				return
+			}
+			defer f.Close()
+			body = f
+		}
+		var in readarrCacheExport
+		if err := json.NewDecoder(body).Decode(&in); err != nil {
+			var tooBig *http.MaxBytesError
+			if errors.As(err, &tooBig) {
+				http.Error(w, "export file is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "not a Readarr cache export: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if in.Version < 1 || in.Version > readarrCacheExportVersion {
+			http.Error(w, fmt.Sprintf("unsupported export version %d", in.Version), http.StatusBadRequest)
+			return
+		}
+		moved := rescopeReadarrAuthors(in.Authors, in.Instances, s.readarrCacheScopes())
+		authors, books, err := s.db.ImportReadarrCache(r.Context(), &in.ReadarrCache)
+		if err != nil {
+			http.Error(w, "import failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		actor := r.Context().Value(ctxUser).(*session).Username
+		s.auditLog(r.Context(), actor, "readarr.cache_imported", nil, fmt.Sprintf("authors=%d, books=%d, moved=%d", authors, books, moved))
+		writeJSON(w, map[string]int{"authors": authors, "books": books, "moved": moved}, http.StatusOK)
+	}
+}
+
+// rescopeReadarrAuthors moves authors cached under an exported instance's
+// base URL to the current base URL of the same format, and returns how many
+// moved.
+func rescopeReadarrAuthors(authors []db.ReadarrAuthor, exported, current map[string]string) int {
+	moves := map[string]string{}
+	for kind, from := range exported {
+		from = strings.TrimRight(strings.TrimSpace(from), "/")
+		if to := current[kind]; from != "" && to != "" && from != to {
+			if _, taken := moves[from]; !taken {
+				moves[from] = to
+			}
+		}
+	}
+	moved := 0
+	for i := range authors {
+		if to, ok := moves[strings.TrimRight(strings.TrimSpace(authors[i].BaseURL), "/")]; ok {
+			authors[i].BaseURL = to
+			moved++
+		}
+	}
+	return moved
+}
@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestReadarrCacheExportImport(t *testing.T) {
+	ctx := context.Background()
+	withEbooks := func(base string) *Server {
+		s := newServerForTest(t)
+		cfg := *s.settings.Get()
+		cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = base, "k"
+		if err := s.settings.Update(&cfg); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	old := withEbooks("http://old-host:8787/")
+	if _, err := old.db.SQL().Exec(`INSERT INTO readarr_authors (name, readarr_id, foreign_author_id, base_url) VALUES ('frank herbert', 7, 'fa-1', 'http://old-host:8787')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.db.ReplaceReadarrBooks(ctx, "ebook", []db.ReadarrBook{{SourceKind: "ebook", ReadarrID: 11, Title: "Dune", AuthorName: "Frank Herbert", ISBN13: "9780441013593", BookFileCount: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	oldRouter := old.Router()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/readarr/cache/export", nil)
+	req.AddCookie(makeCookie(t, old, "alice", false))
+	oldRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin export = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/readarr/cache/export", nil)
+	req.AddCookie(makeCookie(t, old, "admin", true))
+	oldRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export = %d %s", rec.Code, rec.Body.String())
+	}
+	export := rec.Body.Bytes()
+
+	fresh := withEbooks("http://new-host:8787")
+	freshRouter := fresh.Router()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", "scriptorum-readarr-cache.json")
+	_, _ = fw.Write(export)
+	_ = mw.Close()
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/readarr/cache/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.AddCookie(makeCookie(t, fresh, "admin", true))
+	freshRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import = %d %s", rec.Code, rec.Body.String())
+	}
+	var out map[string]int
+	_ = json.Unmarshal(rec.Body.Bytes(), &out)
+	if out["authors"] != 1 || out["books"] != 1 || out["moved"] != 1 {
+		t.Fatalf("import summary = %v", out)
+	}
+
+	var id int
+	if err := fresh.db.SQL().QueryRow(`SELECT readarr_id FROM readarr_authors WHERE base_url = 'http://new-host:8787' AND foreign_author_id = 'fa-1'`).Scan(&id); err != nil || id != 7 {
+		t.Fatalf("author not moved to the new instance: id=%d err=%v", id, err)
+	}
+	book, err := fresh.db.FindReadarrBookMatch(ctx, db.ReadarrMatchQuery{SourceKind: "ebook", ISBN13: "9780441013593"})
+	if err != nil || book.ReadarrID != 11 || book.Availability() != "available" {
+		t.Fatalf("imported book = %+v, %v", book, err)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/readarr/cache/import", bytes.NewReader([]byte(`{"version": 9}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(makeCookie(t, fresh, "admin", true))
+	freshRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("future version = %d", rec.Code)
+	}
+}
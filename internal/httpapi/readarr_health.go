@@ -0,0 +1,124 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+const (
+	readarrHealthInterval = time.Minute
+	readarrHealthTimeout  = 10 * time.Second
+	// readarrHealthThreshold is how many consecutive checks must disagree
+	// with the reported state before a transition is announced, so a single
+	// dropped request or restart does not page admins.
+	readarrHealthThreshold = 3
+)
+
+// readarrHealthState is the flap-suppressed health of one instance.
+type readarrHealthState struct {
+	unhealthy bool   // state last announced
+	streak    int    // consecutive checks contradicting unhealthy
+	lastErr   string // most recent failure
+}
+
+func readarrHealthAlertKey(inst providers.ReadarrInstance) string {
+	return "readarr-health|" + strings.TrimRight(strings.TrimSpace(inst.BaseURL), "/")
+}
+
+// recordReadarrHealth folds one check result into the instance state and
+// reports whether the announced state flipped.
+func (s *Server) recordReadarrHealth(key string, err error) (st readarrHealthState, changed bool) {
+	s.readarrHealthMu.Lock()
+	defer s.readarrHealthMu.Unlock()
+	if s.readarrHealth == nil {
+		s.readarrHealth = make(map[string]*readarrHealthState)
+	}
+	cur := s.readarrHealth[key]
+	if cur == nil {
+		cur = &readarrHealthState{}
+		s.readarrHealth[key] = cur
+	}
+	if err != nil {
+		cur.lastErr = err.Error()
+	}
+	if (err != nil) == cur.unhealthy {
+		cur.streak = 0
+		return *cur, false
+	}
+	cur.streak++
+	if cur.streak < readarrHealthThreshold {
+		return *cur, false
+	}
+	cur.unhealthy = !cur.unhealthy
+	cur.streak = 0
+	return *cur, true
+}
+
+// checkReadarrHealth probes every configured instance once. Rejected API keys
+// are left to the auth handling in readarr_auth.go and do not count here.
+func (s *Server) checkReadarrHealth(ctx context.Context) {
+	seen := make(map[string]bool)
+	for _, c := range s.configuredReadarrInstances() {
+		inst := s.toProviderInstance(c)
+		key := readarrHealthAlertKey(inst)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		pctx, cancel := context.WithTimeout(ctx, readarrHealthTimeout)
+		_, err := providers.NewReadarrWithDB(inst, s.db.SQL()).SystemStatus(pctx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if s.noteReadarrError(inst, err) {
+			continue
+		}
+		st, changed := s.recordReadarrHealth(key, err)
+		if !changed {
+			continue
+		}
+		label := s.readarrInstanceLabel(inst)
+		if st.unhealthy {
+			title := fmt.Sprintf("Readarr %s instance is unreachable", label)
+			msg := fmt.Sprintf("The last %d health checks failed: %s", readarrHealthThreshold, st.lastErr)
+			s.raiseAdminAlert(key, "warning", title, msg)
+			s.auditLog(context.Background(), "system", "readarr.unhealthy", nil, label+": "+st.lastErr)
+			go s.SendSystemNotification(title, msg)
+		} else {
+			s.clearAdminAlert(key)
+			s.auditLog(context.Background(), "system", "readarr.healthy", nil, label)
+			go s.SendSystemNotification(fmt.Sprintf("Readarr %s instance recovered", label), "Health checks are passing again.")
+		}
+	}
+
+	// Forget instances that were removed from settings.
+	s.readarrHealthMu.Lock()
+	for key := range s.readarrHealth {
+		if !seen[key] {
+			delete(s.readarrHealth, key)
+			s.clearAdminAlert(key)
+		}
+	}
+	s.readarrHealthMu.Unlock()
+}
+
+// runReadarrHealthLoop checks Readarr health every readarrHealthInterval until
+// ctx is cancelled.
+func (s *Server) runReadarrHealthLoop(ctx context.Context) {
+	ticker := time.NewTicker(readarrHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkReadarrHealth(ctx)
+		}
+	}
+}
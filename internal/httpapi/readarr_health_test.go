@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadarrHealthCheckSuppressesFlapping(t *testing.T) {
+	var down atomic.Bool
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "restarting", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"0.4.1"}`))
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "k"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	ctx := context.Background()
+	check := func(fail bool) {
+		down.Store(fail)
+		s.checkReadarrHealth(ctx)
+	}
+
+	// Alternating results never reach the threshold.
+	for _, fail := range []bool{true, false, true, true, false, true} {
+		check(fail)
+	}
+	if n := len(s.listAdminAlerts()); n != 0 {
+		t.Fatalf("flapping instance should not alert, got %d alerts", n)
+	}
+
+	for i := 0; i < readarrHealthThreshold; i++ {
+		check(true)
+	}
+	alerts := s.listAdminAlerts()
+	if len(alerts) != 1 || alerts[0].Title != "Readarr ebooks instance is unreachable" {
+		t.Fatalf("expected unreachable alert, got %+v", alerts)
+	}
+
+	// One good check is not enough to announce recovery.
+	check(false)
+	if len(s.listAdminAlerts()) != 1 {
+		t.Fatalf("alert should persist until recovery is confirmed")
+	}
+	check(false)
+	check(false)
+	if n := len(s.listAdminAlerts()); n != 0 {
+		t.Fatalf("expected alert cleared after recovery, got %d", n)
+	}
+
+	events, _ := s.db.ListAuditEvents(ctx, 20)
+	var unhealthy, healthy int
+	for _, ev := range events {
+		switch ev.EventType {
+		case "readarr.unhealthy":
+			unhealthy++
+		case "readarr.healthy":
+			healthy++
+		}
+	}
+	if unhealthy != 1 || healthy != 1 {
+		t.Fatalf("expected one transition each way, got unhealthy=%d healthy=%d", unhealthy, healthy)
+	}
+}
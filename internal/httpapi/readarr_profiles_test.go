@@ -93,3 +93,91 @@ func TestAPIReadarrProfilesReturnsFriendlyErrors(t *testing.T) {
 		t.Fatalf("expected api key to stay redacted, got %q", body)
 	}
 }
+
+func TestReadarrRootFolderPickerAndSaveValidation(t *testing.T) {
+	s := newServerForTest(t)
+	r := s.Router()
+
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rootfolder" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"path":"/books","accessible":true,"freeSpace":2147483648,"totalSpace":4294967296}]`))
+	}))
+	defer readarr.Close()
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "key"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/readarr/folders?kind=ebooks", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var folders []readarrRootFolderView
+	if err := json.Unmarshal(rec.Body.Bytes(), &folders); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if len(folders) != 1 || folders[0].Path != "/books" || folders[0].FreeText != "2.0 GB" {
+		t.Fatalf("folders = %+v", folders)
+	}
+
+	save := func(root string) int {
+		form := url.Values{"ra_ebooks_base": {readarr.URL}, "ra_ebooks_root": {root}}
+		req := httptest.NewRequest(http.MethodPost, "/settings/save", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(makeCookie(t, s, "admin", true))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := save("/missing"); code != http.StatusBadRequest {
+		t.Fatalf("unknown root folder saved with code %d", code)
+	}
+	if code := save("/books/"); code != http.StatusFound || s.settings.Get().Readarr.Ebooks.DefaultRootFolderPath != "/books" {
+		t.Fatalf("save code=%d root=%q", code, s.settings.Get().Readarr.Ebooks.DefaultRootFolderPath)
+	}
+}
+
+func TestSettingsTestReadarrReportsCapabilities(t *testing.T) {
+	s := newServerForTest(t)
+	r := s.Router()
+
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/book/lookup":
+			_, _ = w.Write([]byte(`[]`))
+		case "/api/v1/system/status":
+			_, _ = w.Write([]byte(`{"appName":"Readarr","version":"0.4.1.2648"}`))
+		case "/api/v1/qualityprofile/1":
+			_, _ = w.Write([]byte(`{"id":1,"name":"eBook"}`))
+		case "/api/v1/rootfolder":
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer readarr.Close()
+
+	form := url.Values{"kind": {"ebooks"}, "use_overrides": {"true"}, "base_url": {readarr.URL}, "api_key": {"k"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/test-readarr", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var report readarrCapabilityReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if !report.LookupOK || report.Version != "0.4.1.2648" || report.ProfileCount != 1 || report.Profiles[1] != "eBook" {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.OK || len(report.Errors) != 1 || !strings.HasPrefix(report.Errors[0], "Root folders: ") {
+		t.Fatalf("root folder failure not reported: %+v", report)
+	}
+}
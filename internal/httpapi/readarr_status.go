@@ -0,0 +1,164 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+const (
+	defaultMinFreeSpaceMB = 1024
+	readarrStatusTimeout  = 10 * time.Second
+	readarrSpaceTimeout   = 5 * time.Second
+)
+
+// errReadarrOutOfSpace is wrapped when an approval is refused because the
+// target root folder is below the configured free space threshold.
+var errReadarrOutOfSpace = errors.New("readarr root folder is out of space")
+
+type readarrRootFolderView struct {
+	Path       string `json:"path"`
+	Accessible bool   `json:"accessible"`
+	FreeSpace  int64  `json:"free_space"`
+	TotalSpace int64  `json:"total_space"`
+	FreeText   string `json:"free_text"`
+	TotalText  string `json:"total_text"`
+	Low        bool   `json:"low"`
+	Target     bool   `json:"target"`
+}
+
+// readarrStatusView is the admin dashboard summary for one Readarr instance.
+type readarrStatusView struct {
+	Label       string                         `json:"label"`
+	Version     string                         `json:"version,omitempty"`
+	Error       string                         `json:"error,omitempty"`
+	RootFolders []readarrRootFolderView        `json:"root_folders"`
+	Health      []providers.ReadarrHealthCheck `json:"health"`
+}
+
+// minFreeSpaceBytes returns the approval threshold; 0 disables the check.
+func (s *Server) minFreeSpaceBytes() int64 {
+	mb := s.settings.Get().Readarr.MinFreeSpaceMB
+	switch {
+	case mb < 0:
+		return 0
+	case mb == 0:
+		mb = defaultMinFreeSpaceMB
+	}
+	return int64(mb) * 1024 * 1024
+}
+
+// readarrStatus gathers version, root folder space, and health checks for c.
+// Lookup failures are reported in the view rather than returned.
+func (s *Server) readarrStatus(ctx context.Context, c config.ReadarrInstance) readarrStatusView {
+	inst := s.toProviderInstance(c)
+	view := readarrStatusView{Label: s.readarrInstanceLabel(inst)}
+	ra := providers.NewReadarrWithDB(inst, s.db.SQL())
+
+	st, err := ra.SystemStatus(ctx)
+	if err != nil {
+		s.noteReadarrError(inst, err)
+		view.Error = err.Error()
+		return view
+	}
+	view.Version = st.Version
+
+	if folders, err := ra.RootFolders(ctx); err != nil {
+		view.Error = err.Error()
+	} else {
+		min := s.minFreeSpaceBytes()
+		target := providers.TargetRootFolder(folders, inst.DefaultRootFolderPath)
+		for i, f := range folders {
+			view.RootFolders = append(view.RootFolders, readarrRootFolderView{
+				Path:       f.Path,
+				Accessible: f.Accessible,
+				FreeSpace:  f.FreeSpace,
+				TotalSpace: f.TotalSpace,
+				FreeText:   formatBytes(f.FreeSpace),
+				TotalText:  formatBytes(f.TotalSpace),
+				Low:        min > 0 && f.FreeSpace < min,
+				Target:     target == &folders[i],
+			})
+		}
+	}
+	if checks, err := ra.Health(ctx); err == nil {
+		view.Health = checks
+	}
+	return view
+}
+
+// collectReadarrStatus queries every configured instance concurrently.
+func (s *Server) collectReadarrStatus(ctx context.Context) []readarrStatusView {
+	instances := s.configuredReadarrInstances()
+	out := make([]readarrStatusView, len(instances))
+	var wg sync.WaitGroup
+	for i, c := range instances {
+		wg.Add(1)
+		go func(i int, c config.ReadarrInstance) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, readarrStatusTimeout)
+			defer cancel()
+			out[i] = s.readarrStatus(cctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	return out
+}
+
+// checkReadarrSpace refuses an approval when the root folder inst adds books
+// to has less free space than the configured minimum. Lookup failures do not
+// block approvals; the submission itself will surface them.
+func (s *Server) checkReadarrSpace(ctx context.Context, inst providers.ReadarrInstance) error {
+	min := s.minFreeSpaceBytes()
+	if min <= 0 {
+		return nil
+	}
+	cctx, cancel := context.WithTimeout(ctx, readarrSpaceTimeout)
+	defer cancel()
+	folders, err := providers.NewReadarrWithDB(inst, s.db.SQL()).RootFolders(cctx)
+	if err != nil {
+		s.noteReadarrError(inst, err)
+		return nil
+	}
+	target := providers.TargetRootFolder(folders, inst.DefaultRootFolderPath)
+	if target == nil || target.TotalSpace == 0 || target.FreeSpace >= min {
+		return nil
+	}
+	return fmt.Errorf("%w: Readarr %s root folder %s has %s free (minimum %s); free up space before approving",
+		errReadarrOutOfSpace, s.readarrInstanceLabel(inst), target.Path, formatBytes(target.FreeSpace), formatBytes(min))
+}
+
+// handleReadarrStatus renders the dashboard panel for admins.
+func (u *ui) handleReadarrStatus(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = u.tpl.ExecuteTemplate(w, "readarr_status", map[string]any{"Instances": s.collectReadarrStatus(r.Context())})
+	}
+}
+
+// apiReadarrStatus returns the same data as the dashboard panel as JSON.
+func (s *Server) apiReadarrStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"instances": s.collectReadarrStatus(r.Context())}, http.StatusOK)
+	}
+}
+
+// formatBytes renders n using binary units, e.g. "1.5 GB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTP"[exp])
+}
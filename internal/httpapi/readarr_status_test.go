@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func newStatusTestServer(t *testing.T, free int64) *Server {
+	t.Helper()
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/system/status":
+			_, _ = w.Write([]byte(`{"version":"0.4.1"}`))
+		case "/api/v1/rootfolder":
+			_, _ = w.Write([]byte(`[{"path":"/books","accessible":true,"freeSpace":` + strconv.FormatInt(free, 10) + `,"totalSpace":10737418240}]`))
+		case "/api/v1/health":
+			_, _ = w.Write([]byte(`[{"source":"DownloadClientCheck","type":"error","message":"No download client is available"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(readarr.Close)
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "k"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	return s
+}
+
+func TestReadarrStatusPanelShowsVersionSpaceAndHealth(t *testing.T) {
+	s := newStatusTestServer(t, 5<<30)
+	h := s.Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/ui/admin/readarr-status", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("panel: %d %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"Readarr ebooks", "v0.4.1", "/books", "5.0 GB free of 10.0 GB", "No download client is available"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("panel missing %q:\n%s", want, body)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ui/admin/readarr-status", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code == http.StatusOK {
+		t.Fatalf("non-admin should not see the panel")
+	}
+}
+
+func TestApprovalBlockedWhenRootFolderOutOfSpace(t *testing.T) {
+	s := newStatusTestServer(t, 10<<20)
+	h := s.Router()
+	ctx := context.Background()
+	id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Full Disk", Authors: []string{"A"}, Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(id, 10)+"/approve", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusInsufficientStorage || !strings.Contains(rr.Body.String(), "/books has 10.0 MB free") {
+		t.Fatalf("expected out-of-space refusal, got %d %s", rr.Code, rr.Body.String())
+	}
+	if got, _ := s.db.GetRequest(ctx, id); got.Status != "pending" {
+		t.Fatalf("request should stay pending, got %q", got.Status)
+	}
+
+	// A negative threshold disables the check.
+	cfg := s.settings.Get()
+	cfg.Readarr.MinFreeSpaceMB = -1
+	_ = s.settings.Update(cfg)
+	if err := s.checkReadarrSpace(ctx, s.readarrInstanceForRequest(&db.Request{Format: "ebook"})); err != nil {
+		t.Fatalf("check should be disabled: %v", err)
+	}
+}
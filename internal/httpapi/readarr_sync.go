@@ -21,12 +21,15 @@ type readarrSyncSummary struct {
 	Imported        int    `json:"imported"`
 	Reconciled      int    `json:"reconciled"`
 	MatchedRequests int    `json:"matchedRequests"`
+	// LibraryItems counts the series copied from a synced Kavita library.
+	LibraryItems int `json:"libraryItems,omitempty"`
 }
 
 const (
 	readarrAutoSyncInterval     = 15 * time.Minute
 	readarrAutoSyncStartupDelay = 45 * time.Second
 	readarrAutoSyncTimeout      = 10 * time.Minute
+	readarrWarmCacheTimeout     = 30 * time.Second
 )
 
 var errReadarrSyncInProgress = errors.New("readarr sync already in progress")
@@ -65,11 +68,23 @@ func (s *Server) StartBackgroundTasks(ctx context.Context) {
 		ctx = context.Background()
 	}
 	s.backgroundTasks.Do(func() {
+		s.abandonStaleDeliveries(ctx)
 		go s.recoverProcessingApprovals(ctx)
 		go s.runReadarrSyncLoop(ctx, readarrAutoSyncStartupDelay, s.readarrSyncInterval())
 		go s.reloadSearchQueue(ctx)
 		go s.runSearchDispatchLoop(ctx)
 		go s.runSecurityJanitor(ctx)
+		go s.runReadarrAuthRecovery(ctx)
+		go s.runReadarrHealthLoop(ctx)
+		go s.runDBMaintenanceLoop(ctx)
+		go s.runPendingReminderLoop(ctx)
+		go s.runHomeAssistantLoop(ctx)
+		go s.runSubscriptionLoop(ctx)
+		go s.runLegacyHydrationLoop(ctx)
+		go s.runMailReplyLoop(ctx)
+		if s.settings.Get().Readarr.WarmCache {
+			go s.warmReadarrCaches(ctx)
+		}
 	})
 }
 
@@ -112,6 +127,32 @@ func (s *Server) runAutomaticReadarrSync(parent context.Context) {
 	}
 }
 
+// warmReadarrCaches loads the settings lists add payloads are built from for
+// every configured instance, logging the instances that fail.
+func (s *Server) warmReadarrCaches(ctx context.Context) {
+	if s.needsSetup() {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, c := range s.configuredReadarrInstances() {
+		inst := s.toProviderInstance(c)
+		key := readarrHealthAlertKey(inst)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		wctx, cancel := context.WithTimeout(ctx, readarrWarmCacheTimeout)
+		err := providers.NewReadarrWithDB(inst, s.db.SQL()).WarmCache(wctx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			fmt.Printf("readarr %s: warming cache failed: %v\n", s.readarrInstanceLabel(inst), err)
+		}
+	}
+}
+
 // searchDispatchJob is a pending Readarr search command enqueued by the Search
 // button handler and dispatched by the background worker.
 type searchDispatchJob struct {
@@ -233,6 +274,7 @@ drained:
 		}
 
 		body, err := ra.SearchBooks(ctx, ids)
+		s.noteReadarrError(inst, err)
 		for _, job := range formatJobs {
 			if err != nil {
 				if s.settings.Get().Debug {
@@ -367,6 +409,7 @@ func (s *Server) syncReadarrCatalog(ctx context.Context, requestedKind, actor st
 		ra := providers.NewReadarrWithDB(inst, s.db.SQL())
 		list, err := ra.ListBooks(ctx)
 		if err != nil {
+			s.noteReadarrError(inst, err)
 			return nil, fmt.Errorf("%s sync failed: %w", kind, err)
 		}
 		books := make([]db.ReadarrBook, 0, len(list))
@@ -393,6 +436,7 @@ func (s *Server) syncReadarrCatalog(ctx context.Context, requestedKind, actor st
 			return nil, fmt.Errorf("%s import failed: %w", kind, err)
 		}
 		s.clearCatalogMatchCache()
+		pruneAuthorCache(ra, list)
 		reconciled, matched, err := s.reconcileRequestsAgainstCatalog(ctx, kind, inst, actor)
 		if err != nil {
 			return nil, fmt.Errorf("%s reconcile failed: %w", kind, err)
@@ -402,11 +446,28 @@ func (s *Server) syncReadarrCatalog(ctx context.Context, requestedKind, actor st
 			Imported:        len(books),
 			Reconciled:      reconciled,
 			MatchedRequests: matched,
+			LibraryItems:    s.syncLibraryItems(ctx, kind),
 		})
 	}
 	return summaries, nil
 }
 
+// pruneAuthorCache drops cached author ids that no longer own a book in the
+// library, so a deleted Readarr author is looked up again next time. It skips
+// listings without author ids rather than emptying the cache.
+func pruneAuthorCache(ra *providers.Readarr, list []providers.CatalogBook) {
+	live := map[int]bool{}
+	for _, book := range list {
+		if book.AuthorId > 0 {
+			live[book.AuthorId] = true
+		}
+	}
+	if len(list) > 0 && len(live) == 0 {
+		return
+	}
+	ra.PruneAuthorCache(live)
+}
+
 func formatDuration(d time.Duration) string {
 	if d%time.Hour == 0 {
 		return fmt.Sprintf("%dh", int(d.Hours()))
@@ -441,7 +502,11 @@ func formatReadarrSyncSummary(summaries []readarrSyncSummary) string {
 	}
 	parts := make([]string, 0, len(summaries))
 	for _, item := range summaries {
-		parts = append(parts, fmt.Sprintf("%s: %d imported, %d matched", syncKindDisplay(item.Kind), item.Imported, item.MatchedRequests))
+		part := fmt.Sprintf("%s: %d imported, %d matched", syncKindDisplay(item.Kind), item.Imported, item.MatchedRequests)
+		if item.LibraryItems > 0 {
+			part += fmt.Sprintf(", %d in Kavita", item.LibraryItems)
+		}
+		parts = append(parts, part)
 	}
 	return strings.Join(parts, " | ")
 }
@@ -465,7 +530,7 @@ func (s *Server) reconcileRequestsAgainstCatalog(ctx context.Context, kind strin
 	reconciled := 0
 	matched := 0
 	for _, req := range requests {
-		if normalizeSyncKind(req.Format) != kind {
+		if normalizeSyncKind(requestReadarrKind(&req)) != kind {
 			continue
 		}
 		match, err := s.findCatalogMatch(ctx, kind, req.Title, req.Authors, req.ISBN10, req.ISBN13, "", req.ReadarrReq)
@@ -482,7 +547,7 @@ func (s *Server) reconcileRequestsAgainstCatalog(ctx context.Context, kind strin
 			if err := s.db.UpdateRequestExternalStatus(ctx, req.ID, match.Availability(), match.ReadarrID, reason); err != nil {
 				return reconciled, matched, err
 			}
-			if cover := s.requestCoverFromPayload(req.Format, match.ReadarrData); cover != "" {
+			if cover := s.requestCoverFromPayload(requestReadarrKind(&req), match.ReadarrData); cover != "" {
 				_ = s.db.UpdateRequestCover(ctx, req.ID, cover)
 			}
 			continue
@@ -504,6 +569,9 @@ func (s *Server) findCatalogMatch(ctx context.Context, kind, title string, autho
 }
 
 func (s *Server) findCatalogMatchForPayload(kind string, p RequestPayload) (*db.ReadarrBook, error) {
+	if kind == formatComic || kind == formatMagazine {
+		return nil, nil
+	}
 	return s.findCatalogMatch(context.Background(), kind, p.Title, p.Authors, p.ISBN10, p.ISBN13, p.ASIN, []byte(p.ProviderPayload))
 }
 
@@ -517,6 +585,12 @@ func (s *Server) toProviderInstance(c config.ReadarrInstance) providers.ReadarrI
 		DefaultRootFolderPath:   c.DefaultRootFolderPath,
 		DefaultTags:             c.DefaultTags,
 		InsecureSkipVerify:      c.InsecureSkipVerify || s.outboundTLSInsecure(),
+		AddPolicy: providers.AddPolicy{
+			Monitor:    providers.NormalizeMonitorPolicy(c.Monitor),
+			AddType:    providers.NormalizeAddType(c.AddType),
+			SkipSearch: !c.SearchOnAddEnabled(),
+		},
+		CompatRetry: c.CompatRetry,
 	}
 }
 
@@ -616,6 +690,15 @@ func (s *Server) loadCatalogState(kind, title string, authors []string, isbn10,
 	sctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 	match, err := s.findCatalogMatch(sctx, kind, title, authors, isbn10, isbn13, asin, []byte(payload))
+	if err == nil && match != nil && match.Availability() == "available" {
+		return "available"
+	}
+	// Books already on a synced library count as available even when
+	// Readarr never downloaded them.
+	lc := s.readarrConfigForRequest(&db.Request{Format: kind}).Library
+	if _, ok := s.cachedLibraryItem(sctx, lc, kind, strings.TrimSpace(title), authors); ok {
+		return "available"
+	}
 	if err != nil || match == nil {
 		return ""
 	}
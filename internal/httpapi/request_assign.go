@@ -0,0 +1,98 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+// activeUsers lists the accounts that may be picked, e.g. as a request's
+// new requester; deactivated ones are left out.
+func (s *Server) activeUsers(ctx context.Context) []db.User {
+	users, _ := s.db.ListUsers(ctx)
+	out := make([]db.User, 0, len(users))
+	for _, u := range users {
+		if !u.Disabled {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// apiReassignRequest hands a request to another user, e.g. one an admin
+// created on someone's behalf. The request moves to the new requester's
+// group and pending cap, and later approval and availability notifications
+// go to them. A linked other-format request from the same requester moves
+// with it. It accepts JSON {"username": "..."} or a form field "username".
+func (s *Server) apiReassignRequest(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", 404)
+		return
+	}
+	var username string
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var in struct {
+			Username string `json:"username"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", 400)
+			return
+		}
+		username = in.Username
+	} else {
+		username = r.FormValue("username")
+	}
+	username = strings.TrimSpace(username)
+	if username == "" {
+		http.Error(w, "username is required", 400)
+		return
+	}
+	target, err := s.db.GetUserByUsername(r.Context(), username)
+	if err != nil || target == nil {
+		http.Error(w, "unknown user", 400)
+		return
+	}
+	if target.Disabled {
+		http.Error(w, "user is deactivated", 400)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+
+	previous := req.RequesterEmail
+	if !strings.EqualFold(previous, target.Username) {
+		ids := []int64{req.ID}
+		if req.LinkedRequestID > 0 {
+			if linked, err := s.db.GetRequest(r.Context(), req.LinkedRequestID); err == nil && strings.EqualFold(linked.RequesterEmail, previous) {
+				ids = append(ids, linked.ID)
+			}
+		}
+		actor := r.Context().Value(ctxUser).(*session).Username
+		for _, rid := range ids {
+			if err := s.db.ReassignRequest(r.Context(), rid, target.Username, target.GroupName); err != nil {
+				http.Error(w, "failed to reassign request", 500)
+				return
+			}
+			s.auditLog(r.Context(), actor, "request.reassigned", &rid, previous+" → "+target.Username)
+		}
+	}
+
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{
+		"id":        id,
+		"requester": strings.ToLower(target.Username),
+		"previous":  previous,
+		"group":     target.GroupName,
+		"quota":     s.requestQuota(r.Context(), target.Username, target.GroupName),
+	}, 200)
+}
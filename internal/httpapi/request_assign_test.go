@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestReassignRequestMovesOwnershipAndQuota(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	bobID, err := s.db.CreateUser(ctx, "bob", "x", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.SetUserGroup(ctx, bobID, "smiths"); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Gift", Authors: []string{"A"}, Format: "ebook", Status: "pending"})
+	other, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Gift", Authors: []string{"A"}, Format: "audiobook", Status: "pending"})
+	_ = s.db.LinkRequests(ctx, id, other)
+	h := s.Router()
+
+	put := func(body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/requests/"+strconv.FormatInt(id, 10)+"/requester", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "admin", admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := put(`{"username":"bob"}`, false); rec.Code == http.StatusOK {
+		t.Fatalf("non-admin reassigned a request")
+	}
+	if rec := put(`{"username":"nobody"}`, true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown user: %d %s", rec.Code, rec.Body.String())
+	}
+	rec := put(`{"username":"Bob"}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reassign: %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, rid := range []int64{id, other} {
+		got, _ := s.db.GetRequest(ctx, rid)
+		if got.RequesterEmail != "bob" || got.GroupName != "smiths" {
+			t.Fatalf("request %d requester=%q group=%q", rid, got.RequesterEmail, got.GroupName)
+		}
+	}
+	if n, _ := s.db.CountPendingRequestsByUser(ctx, "user"); n != 0 {
+		t.Fatalf("old requester still has %d pending", n)
+	}
+	if n, _ := s.db.CountPendingRequestsByUser(ctx, "bob"); n != 2 {
+		t.Fatalf("new requester has %d pending", n)
+	}
+	history, _ := s.db.ListRequestAuditEvents(ctx, id, 10)
+	if len(history) != 1 || history[0].EventType != "request.reassigned" || history[0].Details != "user → bob" {
+		t.Fatalf("history = %+v", history)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/requests/"+strconv.FormatInt(id, 10), nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	page := httptest.NewRecorder()
+	h.ServeHTTP(page, req)
+	if !strings.Contains(page.Body.String(), "request.reassigned") || !strings.Contains(page.Body.String(), `id="request-requester"`) {
+		t.Fatalf("detail page missing requester section: %d", page.Code)
+	}
+}
@@ -0,0 +1,266 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"gitea.knapp/jacoknapp/scriptorum/internal/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// requestAuthorRef is the author a request's stored Readarr payload points at.
+type requestAuthorRef struct {
+	Name            string `json:"name"`
+	ForeignAuthorID string `json:"foreignAuthorId"`
+	ReadarrID       int    `json:"readarrAuthorId"`
+}
+
+// authorCandidate is a Readarr lookup result as shown by the author tool.
+type authorCandidate struct {
+	providers.AuthorMatch
+	Selected bool `json:"selected"`
+}
+
+// storedRequestAuthor reads the author out of a stored selection payload,
+// falling back to the request's first author name.
+func storedRequestAuthor(req *db.Request) requestAuthorRef {
+	var ref requestAuthorRef
+	var pmap map[string]any
+	if len(req.ReadarrReq) > 0 && json.Unmarshal(req.ReadarrReq, &pmap) == nil {
+		if am, ok := pmap["author"].(map[string]any); ok {
+			ref.Name, _ = am["name"].(string)
+			ref.ForeignAuthorID, _ = am["foreignAuthorId"].(string)
+			ref.ReadarrID = readarrIntValue(am["id"])
+		}
+		if ref.ReadarrID == 0 {
+			ref.ReadarrID = readarrIntValue(pmap["authorId"])
+		}
+	}
+	if strings.TrimSpace(ref.Name) == "" && len(req.Authors) > 0 {
+		ref.Name = req.Authors[0]
+	}
+	ref.Name = strings.TrimSpace(ref.Name)
+	ref.ForeignAuthorID = strings.TrimSpace(ref.ForeignAuthorID)
+	return ref
+}
+
+// readarrIntValue reads an id that may be stored as a number or a string.
+func readarrIntValue(v any) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+// loadAuthorToolRequest fetches the {id} request and a Readarr client for
+// its instance, writing the error response when either is missing.
+func (s *Server) loadAuthorToolRequest(w http.ResponseWriter, r *http.Request) (*db.Request, *providers.Readarr, bool) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", 404)
+		return nil, nil, false
+	}
+	inst := s.readarrInstanceForRequest(req)
+	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
+		http.Error(w, "readarr not configured", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return req, providers.NewReadarrWithDB(inst, s.db.SQL()), true
+}
+
+// authorCandidates looks term up in Readarr and marks the stored author.
+func authorCandidates(ctx context.Context, ra *providers.Readarr, term string, ref requestAuthorRef) ([]authorCandidate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 12*time.Second)
+	defer cancel()
+	matches, err := ra.LookupAuthors(ctx, term)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]authorCandidate, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, authorCandidate{
+			AuthorMatch: m,
+			Selected:    ref.ForeignAuthorID != "" && m.ForeignAuthorID == ref.ForeignAuthorID,
+		})
+	}
+	return out, nil
+}
+
+// apiGetRequestAuthor shows which author a request resolves to and the
+// Readarr authors it could be confused with. "ambiguous" means more than one
+// author carries the stored name; "mismatch" means the stored payload does
+// not point at any of them or carries a Readarr id that belongs to another.
+func (s *Server) apiGetRequestAuthor(w http.ResponseWriter, r *http.Request) {
+	req, ra, ok := s.loadAuthorToolRequest(w, r)
+	if !ok {
+		return
+	}
+	ref := storedRequestAuthor(req)
+	term := strings.TrimSpace(util.FirstNonEmpty(r.URL.Query().Get("term"), ref.Name))
+	out := map[string]any{
+		"id":         req.ID,
+		"author":     ref,
+		"candidates": []authorCandidate{},
+		"ambiguous":  false,
+		"mismatch":   false,
+	}
+	if id, found := ra.CachedAuthorID(ref.ForeignAuthorID); found {
+		out["cachedAuthorId"] = id
+	}
+	if term == "" {
+		writeJSON(w, out, 200)
+		return
+	}
+	cands, err := authorCandidates(r.Context(), ra, term, ref)
+	if err != nil {
+		s.noteReadarrError(s.readarrInstanceForRequest(req), err)
+		http.Error(w, "author lookup failed", http.StatusBadGateway)
+		return
+	}
+	out["candidates"] = cands
+	sameName := map[string]bool{}
+	selected := false
+	for _, c := range cands {
+		if strings.EqualFold(c.Name, ref.Name) && c.ForeignAuthorID != "" {
+			sameName[c.ForeignAuthorID] = true
+		}
+		if c.Selected {
+			selected = true
+			if ref.ReadarrID > 0 && c.ID > 0 && c.ID != ref.ReadarrID {
+				out["mismatch"] = true
+			}
+		}
+	}
+	out["ambiguous"] = len(sameName) > 1
+	if len(cands) > 0 && !selected {
+		out["mismatch"] = true
+	}
+	writeJSON(w, out, 200)
+}
+
+// apiSetRequestAuthor points a request's stored payload at the Readarr
+// author with the given foreignAuthorId. It accepts JSON
+// {"foreignAuthorId": "...", "term": "..."} or the same form fields; term
+// overrides the lookup name when the right author is listed under another.
+func (s *Server) apiSetRequestAuthor(w http.ResponseWriter, r *http.Request) {
+	req, ra, ok := s.loadAuthorToolRequest(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		ForeignAuthorID string `json:"foreignAuthorId"`
+		Term            string `json:"term"`
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", 400)
+			return
+		}
+	} else {
+		_ = r.ParseForm()
+		in.ForeignAuthorID = r.FormValue("foreignAuthorId")
+		in.Term = r.FormValue("term")
+	}
+	in.ForeignAuthorID = strings.TrimSpace(in.ForeignAuthorID)
+	if in.ForeignAuthorID == "" {
+		http.Error(w, "foreignAuthorId is required", 400)
+		return
+	}
+	if req.Status == "queued" || req.Status == "processing" {
+		http.Error(w, "request is being added to Readarr; try again when it finishes", http.StatusConflict)
+		return
+	}
+	var pmap map[string]any
+	if len(req.ReadarrReq) == 0 || json.Unmarshal(req.ReadarrReq, &pmap) != nil || pmap == nil {
+		http.Error(w, "request has no stored Readarr selection", http.StatusBadRequest)
+		return
+	}
+
+	ref := storedRequestAuthor(req)
+	cands, err := authorCandidates(r.Context(), ra, strings.TrimSpace(util.FirstNonEmpty(in.Term, ref.Name, in.ForeignAuthorID)), ref)
+	if err != nil {
+		s.noteReadarrError(s.readarrInstanceForRequest(req), err)
+		http.Error(w, "author lookup failed", http.StatusBadGateway)
+		return
+	}
+	var pick *providers.AuthorMatch
+	for i := range cands {
+		if cands[i].ForeignAuthorID == in.ForeignAuthorID {
+			pick = &cands[i].AuthorMatch
+			break
+		}
+	}
+	if pick == nil {
+		http.Error(w, "author not found in Readarr lookup", http.StatusBadRequest)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+
+	applyRequestAuthor(pmap, *pick)
+	payload, _ := json.Marshal(pmap)
+	if err := s.db.UpdateRequestPayload(r.Context(), req.ID, payload); err != nil {
+		http.Error(w, "db: "+err.Error(), 500)
+		return
+	}
+	ra.CacheAuthor(pick.Name, pick.ForeignAuthorID, pick.ID)
+
+	username := r.Context().Value(ctxUser).(*session).Username
+	s.auditLog(r.Context(), username, "request.author_remapped", &req.ID,
+		fmt.Sprintf("%s (%s) -> %s (%s)", ref.Name, util.FirstNonEmpty(ref.ForeignAuthorID, "none"), pick.Name, pick.ForeignAuthorID))
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(req.ID, 10)+`}}`)
+	writeJSON(w, map[string]any{
+		"id": req.ID,
+		"author": requestAuthorRef{
+			Name:            pick.Name,
+			ForeignAuthorID: pick.ForeignAuthorID,
+			ReadarrID:       pick.ID,
+		},
+	}, 200)
+}
+
+// applyRequestAuthor rewrites the author in a stored selection payload. The
+// Readarr id is dropped when the author is not in the library yet so the
+// add creates it from foreignAuthorId instead of reusing a stale id.
+func applyRequestAuthor(pmap map[string]any, m providers.AuthorMatch) {
+	am, _ := pmap["author"].(map[string]any)
+	if am == nil {
+		am = map[string]any{}
+	}
+	set := func(a map[string]any) {
+		a["name"] = m.Name
+		a["foreignAuthorId"] = m.ForeignAuthorID
+		if m.ID > 0 {
+			a["id"] = m.ID
+		} else {
+			delete(a, "id")
+		}
+	}
+	set(am)
+	if vm, ok := am["value"].(map[string]any); ok {
+		set(vm)
+	}
+	pmap["author"] = am
+	if m.ID > 0 {
+		pmap["authorId"] = m.ID
+	} else {
+		delete(pmap, "authorId")
+	}
+}
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestRequestAuthorToolShowsAndFixesMapping(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/author/lookup" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id":11,"name":"John Smith","foreignAuthorId":"fa-1"},{"name":"John Smith","foreignAuthorId":"fa-2"}]`))
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	_ = config.Save(s.cfgPath, cfg)
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.db.CreateRequest(context.Background(), &db.Request{
+		RequesterEmail: "user@example.com",
+		Title:          "Some History",
+		Authors:        []string{"John Smith"},
+		Format:         "ebook",
+		Status:         "pending",
+		ReadarrReq:     []byte(`{"title":"Some History","foreignBookId":"fb-1","authorId":11,"author":{"id":11,"name":"John Smith","foreignAuthorId":"fa-1"}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	path := "/api/v1/requests/" + strconv.FormatInt(id, 10) + "/author"
+	do := func(method, body string, admin bool) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "admin", admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "", false); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin get: %d", rec.Code)
+	}
+	rec := do(http.MethodGet, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: %d %s", rec.Code, rec.Body.String())
+	}
+	var view struct {
+		Author     requestAuthorRef  `json:"author"`
+		Candidates []authorCandidate `json:"candidates"`
+		Ambiguous  bool              `json:"ambiguous"`
+		Mismatch   bool              `json:"mismatch"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &view)
+	if view.Author.ForeignAuthorID != "fa-1" || len(view.Candidates) != 2 || !view.Candidates[0].Selected || !view.Ambiguous || view.Mismatch {
+		t.Fatalf("view = %+v", view)
+	}
+
+	if rec := do(http.MethodPut, `{"foreignAuthorId":"fa-9"}`, true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown author: %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, `{"foreignAuthorId":"fa-2"}`, true); rec.Code != http.StatusOK {
+		t.Fatalf("put: %d %s", rec.Code, rec.Body.String())
+	}
+	stored, _ := s.db.GetRequest(context.Background(), id)
+	var pmap map[string]any
+	_ = json.Unmarshal(stored.ReadarrReq, &pmap)
+	am, _ := pmap["author"].(map[string]any)
+	if am["foreignAuthorId"] != "fa-2" || am["id"] != nil || pmap["authorId"] != nil || pmap["foreignBookId"] != "fb-1" {
+		t.Fatalf("stored payload = %s", stored.ReadarrReq)
+	}
+
+	if err := s.db.UpdateRequestStatus(context.Background(), id, "queued", "", "admin", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(http.MethodPut, `{"foreignAuthorId":"fa-1"}`, true); rec.Code != http.StatusConflict {
+		t.Fatalf("queued request: %d", rec.Code)
+	}
+}
@@ -0,0 +1,181 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/attachments"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// attachmentView adds the fields the request detail template needs to render
+// an attachment.
+type attachmentView struct {
+	db.RequestAttachment
+	Href     string
+	IsImage  bool
+	SizeText string
+	// CanRemove is set for the uploader and the request's admins.
+	CanRemove bool
+}
+
+func attachmentViews(items []db.RequestAttachment) []attachmentView {
+	out := make([]attachmentView, 0, len(items))
+	for _, a := range items {
+		v := attachmentView{RequestAttachment: a, Href: a.URL}
+		if a.Kind == "file" {
+			v.Href = "/api/v1/requests/" + strconv.FormatInt(a.RequestID, 10) + "/attachments/" + strconv.FormatInt(a.ID, 10)
+			v.IsImage = strings.HasPrefix(a.ContentType, "image/")
+			v.SizeText = formatBytes(a.Size)
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// attachmentsData is shared by the detail page and its attachments partial.
+func (s *Server) attachmentsData(r *http.Request, req *db.Request) map[string]any {
+	list, _ := s.db.ListRequestAttachments(r.Context(), req.ID)
+	cfg := s.settings.Get().Attachments
+	_, err := s.attachmentStore()
+	allowed := cfg.AllowedTypes
+	if len(allowed) == 0 {
+		allowed = attachments.DefaultAllowedTypes
+	}
+	views := attachmentViews(list)
+	ses, _ := r.Context().Value(ctxUser).(*session)
+	admin := s.canAdminRequest(r, req)
+	for i := range views {
+		views[i].CanRemove = admin || (ses != nil && strings.EqualFold(views[i].UploadedBy, ses.Username))
+	}
+	return map[string]any{
+		"RequestID":    req.ID,
+		"Attachments":  views,
+		"CanAttach":    len(list) < maxAttachmentsPerRequest,
+		"FilesEnabled": !errors.Is(err, attachments.ErrDisabled),
+		"MaxSize":      formatBytes(attachments.MaxSize(cfg)),
+		"AllowedTypes": strings.Join(allowed, ", "),
+		"MaxCount":     maxAttachmentsPerRequest,
+	}
+}
+
+func (u *ui) handleRequestDetail(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := s.loadAccessibleRequest(w, r)
+		if !ok {
+			return
+		}
+		ses := r.Context().Value(ctxUser).(*session)
+		data := s.attachmentsData(r, req)
+		data["UserName"] = s.userName(r)
+		data["IsAdmin"] = ses.Admin
+		data["CanModerate"] = s.canModerateRequests(r)
+		data["CanRenew"] = req.ExpiredAt != nil && (ses.Admin || strings.EqualFold(ses.Username, req.RequesterEmail))
+		data["InstanceChoices"] = s.readarrInstanceChoices(req)
+		data["CanApproveBoth"] = hasOtherFormat(req.Format) && req.ReadarrInstance == "" && data["InstanceChoices"] != nil
+		data["OtherFormat"] = alternateFormat(req.Format)
+		data["CSRFToken"] = s.getCSRFToken(r)
+		item := s.buildRequestListItems(r.Context(), []db.Request{*req})[0]
+		if item.AlternateEligible {
+			// The list only sees its own rows; ask the database whether the
+			// other format was requested already.
+			if has, err := s.db.HasFormatRequest(r.Context(), req, alternateFormat(req.Format)); err != nil || has {
+				item.AlternateEligible = false
+			}
+		}
+		data["Item"] = item
+		data["PurchaseCost"] = ""
+		if req.PurchasedAt != nil {
+			data["PurchaseAmount"] = s.formatCost(req.PurchaseCents)
+			data["PurchaseCost"] = fmt.Sprintf("%d.%02d", req.PurchaseCents/100, req.PurchaseCents%100)
+		}
+		if data["CanModerate"] == true {
+			if note, err := s.db.GetRequestAdminNote(r.Context(), req.ID); err == nil {
+				data["AdminNote"] = note
+			}
+			data["StatusChoices"] = s.statusChoices()
+		}
+		if ses.Admin {
+			labels, _ := s.db.GetRequestLabels(r.Context(), req.ID)
+			data["Labels"] = strings.Join(labels, ", ")
+			data["Author"] = storedRequestAuthor(req)
+			data["HasPayload"] = len(req.ReadarrReq) > 0
+			if stored, err := s.db.GetRequestCandidate(r.Context(), req.ID); err == nil {
+				data["PayloadDiff"] = payloadDiff(stored, req.ReadarrReq)
+			}
+			inst := s.readarrInstanceForRequest(req)
+			data["CanPickEdition"] = strings.TrimSpace(inst.BaseURL) != "" && strings.TrimSpace(inst.APIKey) != ""
+			data["Selection"] = storedRequestSelection(req)
+			data["SelectionTerm"] = selectionTerm(req)
+			if s.authorCleanupMode() != authorCleanupOff {
+				if link, ok := s.orphanAuthor(r.Context(), req); ok {
+					data["OrphanAuthor"] = link
+				}
+			}
+			data["Users"] = s.activeUsers(r.Context())
+			data["History"], _ = s.db.ListRequestAuditEvents(r.Context(), req.ID, 20)
+			data["Watchers"], _ = s.db.ListRequestWatchers(r.Context(), req.ID)
+			data["Duplicates"], _ = s.db.FindDuplicateRequests(r.Context(), req)
+		}
+		_ = u.tpl.ExecuteTemplate(w, "request_detail.html", data)
+	}
+}
+
+// instanceChoice is one Readarr instance the approval form can send a
+// request to. The format's own instance posts an empty value while the
+// request has no override, so approving with it changes nothing.
+type instanceChoice struct {
+	Value    string
+	Label    string
+	Default  bool
+	Selected bool
+}
+
+// readarrInstanceChoices lists the configured Readarr instances req could be
+// sent to, marking the one its format maps to. It is empty unless there is
+// a choice to make.
+func (s *Server) readarrInstanceChoices(req *db.Request) []instanceChoice {
+	if req.Format == formatComic {
+		return nil
+	}
+	plain := *req
+	plain.ReadarrInstance = ""
+	def := normalizeSyncKind(requestReadarrKind(&plain))
+	if req.Format == formatMagazine {
+		def = normalizeSyncKind(s.settings.Get().Magazines.ReadarrFormat())
+	}
+	var out []instanceChoice
+	for _, c := range []instanceChoice{{Value: "ebook", Label: "Ebooks"}, {Value: "audiobook", Label: "Audiobooks"}} {
+		routed := *req
+		routed.ReadarrInstance = c.Value
+		if inst := s.readarrInstanceForRequest(&routed); strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
+			continue
+		}
+		c.Default = c.Value == def
+		c.Selected = c.Value == req.ReadarrInstance || (req.ReadarrInstance == "" && c.Default)
+		if c.Default && req.ReadarrInstance == "" {
+			c.Value = ""
+		}
+		out = append(out, c)
+	}
+	if len(out) < 2 {
+		return nil
+	}
+	return out
+}
+
+// handleRequestAttachments renders just the attachment list so the detail
+// page can refresh it after an upload or removal.
+func (u *ui) handleRequestAttachments(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := s.loadAccessibleRequest(w, r)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = u.tpl.ExecuteTemplate(w, "request_attachments", s.attachmentsData(r, req))
+	}
+}
@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// maxFailedRequests caps how many failed requests the Errors page and bulk
+// retry look at.
+const maxFailedRequests = 500
+
+// failedRequestView is one request on the Errors page.
+type failedRequestView struct {
+	db.Request
+	// Failure is the classified Readarr error, nil when unrecognised.
+	Failure *providers.ReadarrFailure
+	// Payload is the stored selection payload, indented for reading.
+	Payload string
+}
+
+func (s *Server) failedRequests(ctx context.Context) ([]failedRequestView, error) {
+	items, err := s.db.ListRequestsByStatus(ctx, "error", maxFailedRequests)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]failedRequestView, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		v := failedRequestView{Request: items[i]}
+		if f, ok := providers.ClassifyReadarrError(v.StatusReason); ok {
+			v.Failure = &f
+		}
+		if len(v.ReadarrReq) > 0 {
+			var buf bytes.Buffer
+			if json.Indent(&buf, v.ReadarrReq, "", "  ") == nil {
+				v.Payload = buf.String()
+			} else {
+				v.Payload = string(v.ReadarrReq)
+			}
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (u *ui) handleRequestErrors(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items, err := s.failedRequests(r.Context())
+		if err != nil {
+			http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data := map[string]any{
+			"UserName":  s.userName(r),
+			"IsAdmin":   true,
+			"CSRFToken": s.getCSRFToken(r),
+			"Items":     items,
+		}
+		_ = u.tpl.ExecuteTemplate(w, "request_errors.html", data)
+	}
+}
+
+// retryFailure is a request bulk retry could not send again.
+type retryFailure struct {
+	ID    int64  `json:"id"`
+	Error string `json:"error"`
+}
+
+// apiRetryFailedRequests retries requests in error state: the ids given, or
+// every failed request when none are. Ids of requests no longer in error
+// state are skipped.
+func (s *Server) apiRetryFailedRequests(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	isForm := strings.Contains(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	if isForm {
+		_ = r.ParseForm()
+		for _, v := range r.Form["ids"] {
+			if id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil && id > 0 {
+				ids = append(ids, id)
+			}
+		}
+	} else if r.ContentLength != 0 {
+		var body struct {
+			IDs []int64 `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		ids = body.IDs
+	}
+
+	failed, err := s.db.ListRequestsByStatus(r.Context(), "error", maxFailedRequests)
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	want := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	username := r.Context().Value(ctxUser).(*session).Username
+	retried := 0
+	failures := []retryFailure{}
+	for i := range failed {
+		req := failed[i]
+		if len(want) > 0 && !want[req.ID] {
+			continue
+		}
+		if _, err := s.retryRequest(r.Context(), &req, username); err != nil {
+			failures = append(failures, retryFailure{ID: req.ID, Error: err.Error()})
+			continue
+		}
+		retried++
+		s.auditLog(r.Context(), username, "request.retried", &req.ID, "bulk retry from Errors")
+	}
+
+	if isForm {
+		http.Redirect(w, r, "/requests/errors", http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("HX-Trigger", `{"request:updated": {}}`)
+	writeJSON(w, map[string]any{"retried": retried, "failed": failures}, http.StatusOK)
+}
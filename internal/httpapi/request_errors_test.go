@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestRequestErrorsPageAndBulkRetry(t *testing.T) {
+	s := newServerForRetryTest(t)
+	h := s.Router()
+	ctx := context.Background()
+	failed := func(title, reason, payload string) int64 {
+		r := &db.Request{RequesterEmail: "user", Title: title, Format: "ebook", Status: "error", StatusReason: reason}
+		if payload != "" {
+			r.ReadarrReq = json.RawMessage(payload)
+		}
+		id, err := s.db.CreateRequest(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	withPayload := failed("Dune", "readarr add: HTTP 400: Root folder '/books' does not exist", `{"foreignBookId":"1"}`)
+	noPayload := failed("Emma", "lookup failed", "")
+	okID, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "user", Title: "Fine", Format: "ebook", Status: "approved"})
+
+	req := httptest.NewRequest(http.MethodGet, "/requests/errors", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK {
+		t.Fatalf("non-admin saw the Errors page")
+	}
+
+	admin := makeCookie(t, s, "admin", true)
+	req = httptest.NewRequest(http.MethodGet, "/requests/errors", nil)
+	req.AddCookie(admin)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Root folder missing on the Readarr server") || !strings.Contains(body, "foreignBookId") || !strings.Contains(body, "Emma") || strings.Contains(body, "Fine") {
+		t.Fatalf("errors page: %d %s", rec.Code, body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/requests/retry-errors", bytes.NewBufferString(`{"ids":[`+strconv.FormatInt(withPayload, 10)+`,`+strconv.FormatInt(noPayload, 10)+`,`+strconv.FormatInt(okID, 10)+`]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(admin)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var out struct {
+		Retried int            `json:"retried"`
+		Failed  []retryFailure `json:"failed"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("bulk retry: %d %s", rec.Code, rec.Body.String())
+	}
+	if out.Retried != 1 || len(out.Failed) != 1 || out.Failed[0].ID != noPayload {
+		t.Fatalf("bulk retry result: %+v", out)
+	}
+	if got, _ := s.db.GetRequest(ctx, okID); got.Status != "approved" {
+		t.Fatalf("request outside the error state was touched: %s", got.Status)
+	}
+}
@@ -0,0 +1,112 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// expireActionArchive moves expired requests to the "expired" status; any
+// other requests.expire_action declines them.
+const expireActionArchive = "archive"
+
+// requestExpiry is how long a request may stay pending before it expires;
+// 0 means requests never expire.
+func (s *Server) requestExpiry() time.Duration {
+	days := s.settings.Get().Requests.ExpireAfterDays
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// expireStaleRequests closes every request pending longer than the expiry
+// age, counted from its last renewal, and tells each requester how to renew
+// it. It runs with the pending reminder sweep.
+func (s *Server) expireStaleRequests(ctx context.Context, now time.Time) {
+	age := s.requestExpiry()
+	if age == 0 {
+		return
+	}
+	cutoff := now.Add(-age)
+	pending, err := s.db.ListPendingReminders(ctx, cutoff)
+	if err != nil {
+		fmt.Printf("requests: list pending for expiry: %v\n", err)
+		return
+	}
+	status := "declined"
+	if strings.EqualFold(strings.TrimSpace(s.settings.Get().Requests.ExpireAction), expireActionArchive) {
+		status = "expired"
+	}
+	reason := fmt.Sprintf("expired after %d days without approval", s.settings.Get().Requests.ExpireAfterDays)
+	expired := 0
+	for _, p := range pending {
+		if p.CreatedAt.After(cutoff) {
+			continue
+		}
+		ok, err := s.db.ExpireRequest(ctx, p.ID, status, reason)
+		if err != nil {
+			fmt.Printf("requests: expire #%d: %v\n", p.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		expired++
+		id := p.ID
+		s.auditLog(ctx, "system", "request.expired", &id, reason)
+		link := itemLink{Label: "🔁 Renew request", URL: strings.TrimRight(strings.TrimSpace(s.settings.Get().ServerURL), "/") + "/requests/" + strconv.FormatInt(id, 10)}
+		go s.notifyUserPersonal("expired", p.RequesterEmail, p.Title, nil, link)
+	}
+	if expired > 0 {
+		s.kickHomeAssistant()
+	}
+}
+
+// apiRenewRequest puts an expired request back in the approval queue. Only
+// its requester and admins may renew it, and the requester's pending quota
+// applies as it does to a new request.
+func (s *Server) apiRenewRequest(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.loadAccessibleRequest(w, r)
+	if !ok {
+		return
+	}
+	u := r.Context().Value(ctxUser).(*session)
+	if !u.Admin && !strings.EqualFold(u.Username, req.RequesterEmail) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if req.ExpiredAt == nil {
+		http.Error(w, "only expired requests can be renewed", http.StatusConflict)
+		return
+	}
+	if quota := s.requestQuota(r.Context(), req.RequesterEmail, req.GroupName); !quota.Unlimited && quota.Remaining <= 0 {
+		http.Error(w, fmt.Sprintf("you already have %d pending request(s), which is the maximum allowed", quota.Pending), http.StatusTooManyRequests)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	renewed, err := s.db.RenewRequest(r.Context(), req.ID)
+	if errors.Is(err, db.ErrStatusTransition) || err == nil && !renewed {
+		http.Error(w, "this request can no longer be renewed", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id := req.ID
+	s.auditLog(r.Context(), u.Username, "request.renewed", &id, "")
+	s.kickHomeAssistant()
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{"id": id, "status": "pending"}, http.StatusOK)
+}
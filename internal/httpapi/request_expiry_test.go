@@ -0,0 +1,87 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestExpireStaleRequestsAndRenew(t *testing.T) {
+	events := make(chan map[string]any, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		events <- payload
+	}))
+	defer hook.Close()
+
+	s := newServerForTest(t)
+	ctx := context.Background()
+	cfg := *s.settings.Get()
+	cfg.Requests.ExpireAfterDays = 7
+	cfg.Requests.ExpireAction = expireActionArchive
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	uid, err := s.db.CreateUser(ctx, "alice", "hash", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No opt-in covers expiry; the notice goes out regardless.
+	if err := s.db.UpdateUserNotificationPrefs(ctx, uid, "", "", "", hook.URL, false, false); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+
+	s.expireStaleRequests(ctx, time.Now().Add(6*24*time.Hour))
+	if req, _ := s.db.GetRequest(ctx, id); req.Status != "pending" {
+		t.Fatalf("request expired early: %+v", req)
+	}
+	s.expireStaleRequests(ctx, time.Now().Add(8*24*time.Hour))
+	req, _ := s.db.GetRequest(ctx, id)
+	if req.Status != "expired" || req.ExpiredAt == nil {
+		t.Fatalf("stale request = %+v", req)
+	}
+	select {
+	case ev := <-events:
+		if ev["event"] != "request.expired" || !strings.HasSuffix(fmt.Sprint(ev["link"]), "/requests/"+itoa(id)) {
+			t.Fatalf("expiry notice = %v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("requester was not told about the expiry")
+	}
+
+	h := s.Router()
+	renew := func(cookie *http.Cookie) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+itoa(id)+"/renew", nil)
+		r.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+	if code := renew(makeCookie(t, s, "bob", false)); code != http.StatusForbidden {
+		t.Fatalf("someone else renewed = %d", code)
+	}
+	alice := makeCookie(t, s, "alice", false)
+	if code := renew(alice); code != http.StatusOK {
+		t.Fatalf("renew = %d", code)
+	}
+	if req, _ := s.db.GetRequest(ctx, id); req.Status != "pending" || req.ExpiredAt != nil {
+		t.Fatalf("renewed request = %+v", req)
+	}
+	if code := renew(alice); code != http.StatusConflict {
+		t.Fatalf("second renew = %d", code)
+	}
+	// Renewing restarts the clock.
+	s.expireStaleRequests(ctx, time.Now().Add(6*24*time.Hour))
+	if req, _ := s.db.GetRequest(ctx, id); req.Status != "pending" {
+		t.Fatalf("renewed request expired early: %+v", req)
+	}
+}
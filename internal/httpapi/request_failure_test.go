@@ -0,0 +1,39 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestRequestFailuresShowFriendlyMessage(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	raw := `add book failed (HTTP 400 Bad Request) from http://readarr/api/v1/book: [{"propertyName":"RootFolderPath","errorMessage":"Root folder '/books' does not exist"}]`
+	id, _ := s.db.CreateRequest(context.Background(), &db.Request{RequesterEmail: "user", Title: "Dune", Format: "ebook", Status: "error", StatusReason: raw})
+	get := func(path string, admin bool) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(makeCookie(t, s, map[bool]string{true: "admin", false: "user"}[admin], admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	body := get("/ui/requests/table", true)
+	if !strings.Contains(body, "Root folder missing on the Readarr server") || !strings.Contains(body, `data-failure="root_folder"`) || !strings.Contains(body, "Raw error") || !strings.Contains(body, "RootFolderPath") {
+		t.Fatalf("admin table: %s", body)
+	}
+	body = get("/ui/requests/table", false)
+	if !strings.Contains(body, "Root folder missing on the Readarr server") || strings.Contains(body, "RootFolderPath") {
+		t.Fatalf("user table should hide the raw error: %s", body)
+	}
+	body = get("/requests/"+strconv.FormatInt(id, 10), true)
+	if !strings.Contains(body, "Root folder missing on the Readarr server") || !strings.Contains(body, "Raw error") {
+		t.Fatalf("detail page: %s", body)
+	}
+}
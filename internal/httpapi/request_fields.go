@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// maxExtraValueLength caps one extra request field answer, in characters.
+const maxExtraValueLength = 1000
+
+// requestFields returns the configured extra request form fields, and the
+// need-by date when requesters are asked for one.
+func (s *Server) requestFields() []config.RequestField {
+	cfg := s.settings.Get()
+	fields := config.NormalizeRequestFields(cfg.Requests.ExtraFields)
+	if cfg.Requests.AskNeedBy {
+		fields = append(fields, needByField)
+	}
+	return fields
+}
+
+// collectRequestExtras checks answers against the configured fields and
+// returns them in field order with their labels. Answers to fields that are
+// not configured are dropped, and so is the need-by date, which is stored
+// on the request itself.
+func (s *Server) collectRequestExtras(values map[string]string) ([]db.RequestExtra, error) {
+	var out []db.RequestExtra
+	for _, f := range s.requestFields() {
+		v := strings.TrimSpace(values[f.Key])
+		if v == "" {
+			if f.Required {
+				return nil, fmt.Errorf("%s is required", f.Label)
+			}
+			continue
+		}
+		if len([]rune(v)) > maxExtraValueLength {
+			return nil, fmt.Errorf("%s is too long (maximum %d characters)", f.Label, maxExtraValueLength)
+		}
+		if f.Type == config.FieldDate {
+			if _, err := time.Parse("2006-01-02", v); err != nil {
+				return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD)", f.Label)
+			}
+		}
+		if f.Key == needByFieldKey {
+			continue
+		}
+		if f.Type != config.FieldTextarea {
+			v = strings.Join(strings.Fields(v), " ")
+		}
+		out = append(out, db.RequestExtra{Key: f.Key, Label: f.Label, Value: v})
+	}
+	return out, nil
+}
+
+// extrasFromForm reads extra field answers posted as extra_<key>.
+func (s *Server) extrasFromForm(r *http.Request) map[string]string {
+	out := map[string]string{}
+	for _, f := range s.requestFields() {
+		if v := r.FormValue("extra_" + f.Key); v != "" {
+			out[f.Key] = v
+		}
+	}
+	return out
+}
+
+// extrasMap turns stored answers back into a key/value map.
+func extrasMap(extras []db.RequestExtra) map[string]string {
+	out := make(map[string]string, len(extras))
+	for _, e := range extras {
+		out[e.Key] = e.Value
+	}
+	return out
+}
+
+// formatRequestFields renders fields for the settings textarea, one per
+// line as "Label | type | required".
+func formatRequestFields(fields []config.RequestField) string {
+	lines := make([]string, 0, len(fields))
+	for _, f := range fields {
+		line := f.Label + " | " + f.Type
+		if f.Required {
+			line += " | required"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseRequestFields reads the settings textarea. Existing fields keep their
+// key when their label is unchanged, so stored answers still match.
+func parseRequestFields(text string, existing []config.RequestField) []config.RequestField {
+	keys := make(map[string]string, len(existing))
+	for _, f := range existing {
+		keys[strings.ToLower(f.Label)] = f.Key
+	}
+	var out []config.RequestField
+	for _, line := range strings.Split(text, "\n") {
+		parts := strings.Split(line, "|")
+		f := config.RequestField{Label: strings.TrimSpace(parts[0])}
+		if f.Label == "" {
+			continue
+		}
+		for i, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			switch {
+			case strings.EqualFold(p, "required"):
+				f.Required = true
+			case i == 0:
+				f.Type = p
+			}
+		}
+		f.Key = keys[strings.ToLower(f.Label)]
+		out = append(out, f)
+	}
+	return config.NormalizeRequestFields(out)
+}
+
+// extrasHTML renders answers as paragraphs for the request email. Answers
+// are user input, so they are escaped.
+func extrasHTML(extras []db.RequestExtra) string {
+	var b strings.Builder
+	for _, e := range extras {
+		fmt.Fprintf(&b, "<p><strong>📝 %s:</strong> %s</p>", template.HTMLEscapeString(e.Label), template.HTMLEscapeString(e.Value))
+	}
+	return b.String()
+}
+
+// extrasText renders answers as lines for plain-text notifications.
+func extrasText(extras []db.RequestExtra) string {
+	var b strings.Builder
+	for _, e := range extras {
+		fmt.Fprintf(&b, "📝 %s: %s\n", e.Label, e.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func TestRequestExtraFieldsAreValidatedAndStored(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Requests.ExtraFields = []config.RequestField{
+		{Label: "Reason for request", Required: true},
+		{Label: "Needed by", Type: config.FieldDate},
+	}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "user", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"title":"Book","authors":["A"],"format":"ebook"}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Reason for request is required") {
+		t.Fatalf("missing required field: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(`{"title":"Book","authors":["A"],"format":"ebook","extra":{"reason_for_request":"x","needed_by":"soon"}}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Needed by must be a date") {
+		t.Fatalf("bad date: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := post(`{"title":"Book","authors":["A"],"format":"ebook","extra":{"reason_for_request":"  Book <club>  ","needed_by":"2026-11-01","ignored":"x"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	stored, err := s.db.GetRequest(context.Background(), created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.ExtraFields) != 2 || stored.ExtraFields[0].Value != "Book <club>" || stored.ExtraFields[1].Label != "Needed by" {
+		t.Fatalf("stored extras = %+v", stored.ExtraFields)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/requests/"+strconv.FormatInt(created.ID, 10), nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	page := httptest.NewRecorder()
+	h.ServeHTTP(page, req)
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "Book &lt;club&gt;") || !strings.Contains(page.Body.String(), "Reason for request") {
+		t.Fatalf("detail page missing extras: %d", page.Code)
+	}
+}
+
+func TestParseRequestFieldsKeepsKeys(t *testing.T) {
+	existing := []config.RequestField{{Key: "why", Label: "Reason", Required: true}}
+	text := formatRequestFields(existing) + "\nDue | date\n | text\nNotes | textarea | required"
+	got := parseRequestFields(text, existing)
+	if len(got) != 3 || got[0].Key != "why" || !got[0].Required || got[1].Type != config.FieldDate || got[2].Key != "notes" || !got[2].Required || got[2].Type != config.FieldTextarea {
+		t.Fatalf("parseRequestFields = %+v", got)
+	}
+	if formatRequestFields(got) != "Reason | text | required\nDue | date\nNotes | textarea | required" {
+		t.Fatalf("format = %q", formatRequestFields(got))
+	}
+}
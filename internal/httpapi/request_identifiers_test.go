@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestReadarrIdentifiersFromResponse(t *testing.T) {
+	body := []byte(`{"id":7,"foreignBookId":"234225","editions":[
+		{"foreignEditionId":"1","isbn13":"","asin":"","monitored":false},
+		{"foreignEditionId":"2","isbn13":"0441172717","asin":"b00b7nppy8","monitored":true}]}`)
+	want := db.RequestIdentifiers{ISBN10: "0441172717", ISBN13: "9780441172719", ASIN: "B00B7NPPY8", ForeignBookID: "234225"}
+	if got := readarrIdentifiersFromResponse(body); got != want {
+		t.Fatalf("identifiers = %+v, want %+v", got, want)
+	}
+	if got := readarrIdentifiersFromResponse([]byte(`[{"foreignBookId":"9","editions":[{"isbn13":"not an isbn"}]}]`)); got != (db.RequestIdentifiers{ForeignBookID: "9"}) {
+		t.Fatalf("list identifiers = %+v", got)
+	}
+	if got := readarrIdentifiersFromResponse([]byte(`oops`)); got != (db.RequestIdentifiers{}) {
+		t.Fatalf("bad body identifiers = %+v", got)
+	}
+}
+
+func TestApprovalBackfillsIdentifiersFromReadarr(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/book" && r.Method == http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":101,"monitored":true,"foreignBookId":"fb-1","editions":[{"foreignEditionId":"fe-1","isbn13":"9780441172719","asin":"B00B7NPPY8","monitored":true}]}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	body := []byte(`{"title":"Dune","authors":["Frank Herbert"],"format":"ebook","provider_payload":"{\"title\":\"Dune\",\"foreignBookId\":\"fb-1\",\"foreignEditionId\":\"fe-1\",\"author\":{\"name\":\"Frank Herbert\"}}"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	ctx := context.Background()
+	// An earlier request for the same book known only by its ASIN.
+	earlier, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "amy", Title: "Dune (Audible edition)", Format: "ebook", Status: "pending"})
+	_ = s.db.BackfillRequestIdentifiers(ctx, earlier, db.RequestIdentifiers{ASIN: "B00B7NPPY8"})
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(created.ID, 10)+"/approve", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, err := s.db.GetRequest(ctx, created.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status == "queued" {
+			if got.ISBN13 != "9780441172719" || got.ASIN != "B00B7NPPY8" || got.ForeignBookID != "fb-1" {
+				t.Fatalf("identifiers not backfilled: %+v", got)
+			}
+			dups, _ := s.db.FindDuplicateRequests(ctx, got)
+			if len(dups) != 1 || dups[0].ID != earlier {
+				t.Fatalf("duplicates after backfill = %+v", dups)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("approval did not finish: %+v", got)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,79 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// apiMergeRequests folds a duplicate request into the one in the URL: the
+// duplicate's attachments, labels, and history move over, its requester and
+// watchers are notified about the kept request from now on, and the
+// duplicate is deleted. Both requests must be for the same format.
+func (s *Server) apiMergeRequests(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	keep, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", 404)
+		return
+	}
+	var dupID int64
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var in struct {
+			DuplicateID int64 `json:"duplicate_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", 400)
+			return
+		}
+		dupID = in.DuplicateID
+	} else {
+		dupID, _ = strconv.ParseInt(r.FormValue("duplicate_id"), 10, 64)
+	}
+	if dupID <= 0 || dupID == keep.ID {
+		http.Error(w, "duplicate_id must name another request", 400)
+		return
+	}
+	dup, err := s.db.GetRequest(r.Context(), dupID)
+	if err != nil {
+		http.Error(w, "duplicate not found", 404)
+		return
+	}
+	if dup.Format != keep.Format {
+		http.Error(w, "requests are for different formats", 400)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, keep)
+	if !ok {
+		return
+	}
+	defer release()
+	if err := s.db.MergeRequests(r.Context(), keep.ID, dup.ID); err != nil {
+		http.Error(w, "failed to merge requests", 500)
+		return
+	}
+	actor := r.Context().Value(ctxUser).(*session).Username
+	s.auditLog(r.Context(), actor, "request.merged", &keep.ID, fmt.Sprintf("#%d %s (%s)", dup.ID, dup.Title, dup.RequesterEmail))
+
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(keep.ID, 10)+`}}`)
+	writeJSON(w, map[string]any{"id": keep.ID, "merged": dup.ID}, 200)
+}
+
+// notifyWatchers sends event's personal notification to everyone watching
+// req besides its requester, such as the requesters of duplicates merged
+// into it.
+func (s *Server) notifyWatchers(event string, req db.Request, item itemLink) {
+	watchers, _ := s.db.ListRequestWatchers(context.Background(), req.ID)
+	for _, u := range watchers {
+		if !strings.EqualFold(u, req.RequesterEmail) {
+			s.notifyUserPersonal(event, u, req.Title, req.Authors, item)
+		}
+	}
+}
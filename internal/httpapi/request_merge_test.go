@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestMergeDuplicateRequests(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	keep, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", ISBN13: "9780441172719", Format: "ebook", Status: "pending"})
+	dup, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "bob", Title: "Dune", ISBN13: "9780441172719", Format: "ebook", Status: "pending"})
+	audio, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "bob", Title: "Dune", ISBN13: "9780441172719", Format: "audiobook", Status: "pending"})
+
+	h := s.Router()
+	path := "/requests/" + strconv.FormatInt(keep, 10)
+	get := func(admin bool) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(makeCookie(t, s, map[bool]string{true: "admin", false: "alice"}[admin], admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	if body := get(true); !strings.Contains(body, "Possible duplicates") || !strings.Contains(body, `name="duplicate_id" value="`+strconv.FormatInt(dup, 10)+`"`) {
+		t.Fatalf("detail page lacks the duplicate: %s", body)
+	}
+	if strings.Contains(get(false), "Possible duplicates") {
+		t.Fatal("requester sees the merge tool")
+	}
+
+	merge := func(user string, admin bool, dupID int64) int {
+		form := url.Values{"duplicate_id": {strconv.FormatInt(dupID, 10)}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(keep, 10)+"/merge", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(makeCookie(t, s, user, admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := merge("alice", false, dup); code != http.StatusForbidden {
+		t.Fatalf("non-admin merge = %d", code)
+	}
+	if code := merge("admin", true, audio); code != http.StatusBadRequest {
+		t.Fatalf("cross-format merge = %d", code)
+	}
+	if code := merge("admin", true, dup); code != http.StatusOK {
+		t.Fatalf("merge = %d", code)
+	}
+	if _, err := s.db.GetRequest(ctx, dup); err == nil {
+		t.Fatal("duplicate not deleted")
+	}
+	if w, _ := s.db.ListRequestWatchers(ctx, keep); len(w) != 1 || w[0] != "bob" {
+		t.Fatalf("watchers = %q", w)
+	}
+	if body := get(true); strings.Contains(body, "Possible duplicates") || !strings.Contains(body, "request.merged") || !strings.Contains(body, "Also notified: bob") {
+		t.Fatalf("detail page after merge: %s", body)
+	}
+}
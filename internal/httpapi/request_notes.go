@@ -0,0 +1,73 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxAdminNote caps the internal note admins keep on a request.
+const maxAdminNote = 2000
+
+// apiGetRequestNote returns the internal note on a request. Only admins
+// and group admins reach it; the note is never part of the request itself,
+// so requesters and their notifications never see it.
+func (s *Server) apiGetRequestNote(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	note, err := s.db.GetRequestAdminNote(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, note, http.StatusOK)
+}
+
+// apiSetRequestNote replaces the internal note on a request. It accepts
+// JSON {"note": "..."} or a form with the same field; an empty note clears
+// it.
+func (s *Server) apiSetRequestNote(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	var in struct {
+		Note string `json:"note"`
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		_ = r.ParseForm()
+		in.Note = r.FormValue("note")
+	}
+	in.Note = strings.TrimSpace(in.Note)
+	if len([]rune(in.Note)) > maxAdminNote {
+		http.Error(w, fmt.Sprintf("note is too long (maximum %d characters)", maxAdminNote), http.StatusBadRequest)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	username := r.Context().Value(ctxUser).(*session).Username
+	if err := s.db.SetRequestAdminNote(r.Context(), id, in.Note, username); err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	details := "cleared"
+	if in.Note != "" {
+		details = "updated"
+	}
+	s.auditLog(r.Context(), username, "request.note", &id, details)
+	note, _ := s.db.GetRequestAdminNote(r.Context(), id)
+	writeJSON(w, note, http.StatusOK)
+}
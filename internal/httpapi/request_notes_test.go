@@ -0,0 +1,59 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestRequestAdminNoteHiddenFromRequester(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	h := s.Router()
+	admin := makeCookie(t, s, "admin", true)
+	alice := makeCookie(t, s, "alice", false)
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	path := "/api/v1/requests/" + itoa(id) + "/note"
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPut, path, `{"note":"`+strings.Repeat("x", maxAdminNote+1)+`"}`, admin); rec.Code != http.StatusBadRequest {
+		t.Fatalf("long note = %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, path, `{"note":"waiting for paperback release"}`, alice); rec.Code != http.StatusForbidden {
+		t.Fatalf("requester set note = %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, path, `{"note":"waiting for paperback release"}`, admin); rec.Code != http.StatusOK {
+		t.Fatalf("set note = %d %s", rec.Code, rec.Body.String())
+	}
+	var note db.AdminNote
+	if rec := do(http.MethodGet, path, "", admin); rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &note) != nil || note.Text != "waiting for paperback release" || note.UpdatedBy != "admin" {
+		t.Fatalf("get note = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, path, "", alice); rec.Code != http.StatusForbidden {
+		t.Fatalf("requester read note = %d", rec.Code)
+	}
+
+	if rec := do(http.MethodGet, "/requests/"+itoa(id), "", admin); !strings.Contains(rec.Body.String(), "waiting for paperback release") {
+		t.Fatal("admin detail page lacks the note")
+	}
+	if rec := do(http.MethodGet, "/requests/"+itoa(id), "", alice); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "paperback") {
+		t.Fatalf("requester detail page = %d, shows the note: %v", rec.Code, strings.Contains(rec.Body.String(), "paperback"))
+	}
+	if rec := do(http.MethodGet, "/api/v1/requests?scope=mine", "", alice); strings.Contains(rec.Body.String(), "paperback") {
+		t.Fatal("requester's request list carries the note")
+	}
+}
@@ -0,0 +1,138 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// payloadDiffRow is one field of the comparison between the selection
+// payload stored for a request and the payload sent to Readarr.
+type payloadDiffRow struct {
+	Path   string
+	Stored string
+	Sent   string
+	// Kind is "same", "added" (only in the sent payload), "removed" (only
+	// in the stored one), or "changed".
+	Kind string
+	// Note says which step of the add pipeline sets the field, when known.
+	Note string
+}
+
+// payloadFieldNotes explain the fields the add pipeline fills in, matched
+// by path prefix with array indices written as []. The first match wins.
+var payloadFieldNotes = []struct{ prefix, note string }{
+	{"author.id", "existing Readarr author looked up by name"},
+	{"author.value.qualityProfileId", "quality profile resolved from the instance"},
+	{"author.qualityProfileId", "quality profile resolved from the instance"},
+	{"qualityProfileId", "quality profile resolved from the instance"},
+	{"author.value.rootFolderPath", "instance root folder"},
+	{"author.rootFolderPath", "instance root folder"},
+	{"rootFolderPath", "instance root folder"},
+	{"author.value.metadataProfileId", "metadata profile default"},
+	{"author.metadataProfileId", "metadata profile default"},
+	{"metadataProfileId", "metadata profile default"},
+	{"author.addOptions", "instance add policy"},
+	{"author.value.addOptions", "instance add policy"},
+	{"author", "author enrichment"},
+	{"addOptions", "instance add policy"},
+	{"tags", "instance default tags and request labels"},
+	{"monitored", "selected books are always monitored"},
+	{"editions", "edition built from foreignEditionId"},
+}
+
+var payloadIndexRE = regexp.MustCompile(`\[\d+\]`)
+
+func payloadFieldNote(path string) string {
+	generic := payloadIndexRE.ReplaceAllString(path, "[]")
+	for _, n := range payloadFieldNotes {
+		if generic == n.prefix || strings.HasPrefix(generic, n.prefix+".") || strings.HasPrefix(generic, n.prefix+"[") {
+			return n.note
+		}
+	}
+	return ""
+}
+
+// flattenPayload maps each leaf of a decoded JSON value to its path, such
+// as author.addOptions.monitor or editions[0].foreignEditionId. Empty
+// objects and arrays are leaves too so they still show up.
+func flattenPayload(prefix string, v any, out map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 && prefix != "" {
+			out[prefix] = "{}"
+		}
+		for k, e := range t {
+			flattenPayload(join(k), e, out)
+		}
+	case []any:
+		if len(t) == 0 {
+			out[prefix] = "[]"
+		}
+		for i, e := range t {
+			flattenPayload(prefix+"["+strconv.Itoa(i)+"]", e, out)
+		}
+	default:
+		b, _ := json.Marshal(t)
+		out[prefix] = string(b)
+	}
+}
+
+// payloadDiff compares the stored selection payload with the one sent,
+// field by field in path order. It returns nil when either is missing or
+// not a JSON object, or when nothing changed.
+func payloadDiff(stored, sent []byte) []payloadDiffRow {
+	if len(stored) == 0 || len(sent) == 0 || bytes.Equal(stored, sent) {
+		return nil
+	}
+	var a, b map[string]any
+	if json.Unmarshal(stored, &a) != nil || json.Unmarshal(sent, &b) != nil {
+		return nil
+	}
+	left, right := map[string]string{}, map[string]string{}
+	flattenPayload("", a, left)
+	flattenPayload("", b, right)
+	paths := make([]string, 0, len(left)+len(right))
+	for p := range left {
+		paths = append(paths, p)
+	}
+	for p := range right {
+		if _, ok := left[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+	rows := make([]payloadDiffRow, 0, len(paths))
+	changed := false
+	for _, p := range paths {
+		l, inLeft := left[p]
+		r, inRight := right[p]
+		row := payloadDiffRow{Path: p, Stored: l, Sent: r, Kind: "same"}
+		switch {
+		case !inLeft:
+			row.Kind = "added"
+		case !inRight:
+			row.Kind = "removed"
+		case l != r:
+			row.Kind = "changed"
+		}
+		if row.Kind != "same" {
+			changed = true
+			row.Note = payloadFieldNote(p)
+		}
+		rows = append(rows, row)
+	}
+	if !changed {
+		return nil
+	}
+	return rows
+}
@@ -0,0 +1,76 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestPayloadDiff(t *testing.T) {
+	stored := []byte(`{"title":"Dune","foreignBookId":"fb-1","author":{"name":"Frank Herbert"},"editions":[],"disambiguation":"x"}`)
+	sent := []byte(`{"title":"Dune","foreignBookId":"fb-1","qualityProfileId":3,"rootFolderPath":"/books","author":{"name":"Frank Herbert","id":7,"qualityProfileId":3},"editions":[{"foreignEditionId":"fe-1"}],"addOptions":{"searchForNewBook":true}}`)
+	rows := payloadDiff(stored, sent)
+	got := map[string]payloadDiffRow{}
+	for _, r := range rows {
+		got[r.Path] = r
+	}
+	for path, want := range map[string]payloadDiffRow{
+		"title":                        {Kind: "same"},
+		"qualityProfileId":             {Kind: "added", Sent: "3", Note: "quality profile resolved from the instance"},
+		"rootFolderPath":               {Kind: "added", Sent: `"/books"`, Note: "instance root folder"},
+		"author.id":                    {Kind: "added", Note: "existing Readarr author looked up by name"},
+		"author.qualityProfileId":      {Kind: "added", Note: "quality profile resolved from the instance"},
+		"editions":                     {Kind: "removed", Stored: "[]", Note: "edition built from foreignEditionId"},
+		"editions[0].foreignEditionId": {Kind: "added", Note: "edition built from foreignEditionId"},
+		"addOptions.searchForNewBook":  {Kind: "added", Note: "instance add policy"},
+		"disambiguation":               {Kind: "removed", Stored: `"x"`},
+	} {
+		r, ok := got[path]
+		if !ok || r.Kind != want.Kind || (want.Sent != "" && r.Sent != want.Sent) || (want.Stored != "" && r.Stored != want.Stored) || r.Note != want.Note {
+			t.Errorf("%s = %+v, want %+v", path, r, want)
+		}
+	}
+	if payloadDiff(stored, stored) != nil || payloadDiff(nil, sent) != nil || payloadDiff(stored, []byte("not json")) != nil {
+		t.Fatal("expected no diff for identical, missing, or invalid payloads")
+	}
+}
+
+func TestRequestDetailShowsPayloadDiff(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending",
+		ReadarrReq: []byte(`{"title":"Dune","foreignBookId":"fb-1"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The add pipeline replaces the stored payload with what it sent.
+	if err := s.db.UpdateRequestStatus(ctx, id, "queued", "sent to Readarr", "admin", []byte(`{"title":"Dune","foreignBookId":"fb-1","rootFolderPath":"/books"}`), []byte(`{"id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := s.db.GetRequestCandidate(ctx, id); string(stored) != `{"title":"Dune","foreignBookId":"fb-1"}` {
+		t.Fatalf("candidate = %s", stored)
+	}
+
+	h := s.Router()
+	page := func(admin bool) string {
+		req := httptest.NewRequest(http.MethodGet, "/requests/"+strconv.FormatInt(id, 10), nil)
+		req.AddCookie(makeCookie(t, s, map[bool]string{true: "admin", false: "alice"}[admin], admin))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("detail: %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+	if body := page(true); !strings.Contains(body, "Stored vs. sent payload") || !strings.Contains(body, "instance root folder") || !strings.Contains(body, "&#34;/books&#34;") {
+		t.Fatalf("admin page lacks the diff: %s", body)
+	}
+	if strings.Contains(page(false), "Stored vs. sent payload") {
+		t.Fatal("requester sees the payload diff")
+	}
+}
@@ -0,0 +1,227 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"gitea.knapp/jacoknapp/scriptorum/internal/util"
+)
+
+// errNeedsReview stops approval of a request whose Readarr selection was
+// guessed until an admin confirms the edition.
+var errNeedsReview = errors.New("the matched book is a guess; confirm the edition on the request page before approving")
+
+// needsReviewBlocks reports whether req may not be sent to Readarr yet. The
+// flag only matters when a Readarr instance would receive the add.
+func (s *Server) needsReviewBlocks(req *db.Request) bool {
+	if !req.NeedsReview {
+		return false
+	}
+	inst := s.readarrInstanceForRequest(req)
+	return strings.TrimSpace(inst.BaseURL) != "" && strings.TrimSpace(inst.APIKey) != ""
+}
+
+// readarrSelectionPayload builds the stored selection payload for a Readarr
+// lookup result, pinned to its edition.
+func readarrSelectionPayload(b providers.LookupBook) map[string]any {
+	return map[string]any{
+		"title":     b.Title,
+		"titleSlug": b.TitleSlug,
+		"author":    lookupBookAuthor(b),
+		// include one monitored edition to pin selection
+		"editions":         []any{map[string]any{"foreignEditionId": b.ForeignEditionId, "monitored": true}},
+		"foreignBookId":    b.ForeignBookId,
+		"foreignEditionId": b.ForeignEditionId,
+		// provider will backfill remaining defaults if missing
+		"monitored":         true,
+		"metadataProfileId": 1,
+	}
+}
+
+// storedSelection is the book a request's stored payload points at.
+type storedSelection struct {
+	Title            string `json:"title"`
+	ForeignBookID    string `json:"foreignBookId"`
+	ForeignEditionID string `json:"foreignEditionId"`
+}
+
+func storedRequestSelection(req *db.Request) storedSelection {
+	var sel storedSelection
+	if len(req.ReadarrReq) > 0 {
+		_ = json.Unmarshal(req.ReadarrReq, &sel)
+	}
+	return sel
+}
+
+// bookCandidate is a Readarr lookup result as shown by the edition picker.
+type bookCandidate struct {
+	Title            string `json:"title"`
+	Author           string `json:"author"`
+	Year             string `json:"year,omitempty"`
+	Disambiguation   string `json:"disambiguation,omitempty"`
+	ForeignBookID    string `json:"foreignBookId"`
+	ForeignEditionID string `json:"foreignEditionId,omitempty"`
+	Selected         bool   `json:"selected"`
+}
+
+// selectionTerm is the default lookup for the picker: the request's ISBN,
+// else its title and first author.
+func selectionTerm(req *db.Request) string {
+	h := bookHints{Title: strings.TrimSpace(req.Title), ISBN13: strings.TrimSpace(req.ISBN13), ISBN10: strings.TrimSpace(req.ISBN10)}
+	if len(req.Authors) > 0 {
+		h.Author = strings.TrimSpace(req.Authors[0])
+	}
+	return util.FirstNonEmpty(h.isbn(), h.titleAuthor())
+}
+
+// lookupBookCandidates looks term up in Readarr. Results without a foreign
+// book id cannot be added and are left out.
+func lookupBookCandidates(ctx context.Context, ra *providers.Readarr, term string) ([]providers.LookupBook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 12*time.Second)
+	defer cancel()
+	list, err := ra.LookupByTerm(ctx, term)
+	if err != nil {
+		return nil, err
+	}
+	out := list[:0]
+	for _, b := range list {
+		if strings.TrimSpace(b.ForeignBookId) != "" {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// apiGetRequestCandidates lists the Readarr books a request could be added
+// as, marking the stored selection. Pass ?term= to search something else.
+func (s *Server) apiGetRequestCandidates(w http.ResponseWriter, r *http.Request) {
+	req, ra, ok := s.loadAuthorToolRequest(w, r)
+	if !ok {
+		return
+	}
+	sel := storedRequestSelection(req)
+	term := strings.TrimSpace(util.FirstNonEmpty(r.URL.Query().Get("term"), selectionTerm(req)))
+	out := map[string]any{
+		"id":          req.ID,
+		"needsReview": req.NeedsReview,
+		"selection":   sel,
+		"term":        term,
+		"candidates":  []bookCandidate{},
+	}
+	if term == "" {
+		writeJSON(w, out, 200)
+		return
+	}
+	list, err := lookupBookCandidates(r.Context(), ra, term)
+	if err != nil {
+		s.noteReadarrError(s.readarrInstanceForRequest(req), err)
+		http.Error(w, "book lookup failed", http.StatusBadGateway)
+		return
+	}
+	cands := make([]bookCandidate, 0, len(list))
+	for _, b := range list {
+		c := bookCandidate{
+			Title:            b.Title,
+			Author:           authorNameFromLookupBook(b),
+			Disambiguation:   b.Disambiguation,
+			ForeignBookID:    b.ForeignBookId,
+			ForeignEditionID: b.ForeignEditionId,
+			Selected:         sel.ForeignBookID != "" && b.ForeignBookId == sel.ForeignBookID,
+		}
+		if len(b.ReleaseDate) >= 4 {
+			c.Year = b.ReleaseDate[:4]
+		}
+		cands = append(cands, c)
+	}
+	out["candidates"] = cands
+	writeJSON(w, out, 200)
+}
+
+// apiSetRequestSelection stores the Readarr book with the given
+// foreignBookId as the request's selection and clears the review flag. It
+// accepts JSON {"foreignBookId": "...", "term": "..."} or the same form
+// fields; the book must appear in the lookup for term.
+func (s *Server) apiSetRequestSelection(w http.ResponseWriter, r *http.Request) {
+	req, ra, ok := s.loadAuthorToolRequest(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		ForeignBookID string `json:"foreignBookId"`
+		Term          string `json:"term"`
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", 400)
+			return
+		}
+	} else {
+		_ = r.ParseForm()
+		in.ForeignBookID = r.FormValue("foreignBookId")
+		in.Term = r.FormValue("term")
+	}
+	in.ForeignBookID = strings.TrimSpace(in.ForeignBookID)
+	if in.ForeignBookID == "" {
+		http.Error(w, "foreignBookId is required", 400)
+		return
+	}
+	if req.Status == "queued" || req.Status == "processing" {
+		http.Error(w, "request is being added to Readarr; try again when it finishes", http.StatusConflict)
+		return
+	}
+
+	list, err := lookupBookCandidates(r.Context(), ra, strings.TrimSpace(util.FirstNonEmpty(in.Term, selectionTerm(req))))
+	if err != nil {
+		s.noteReadarrError(s.readarrInstanceForRequest(req), err)
+		http.Error(w, "book lookup failed", http.StatusBadGateway)
+		return
+	}
+	var pick *providers.LookupBook
+	for i := range list {
+		if list[i].ForeignBookId == in.ForeignBookID {
+			pick = &list[i]
+			break
+		}
+	}
+	if pick == nil {
+		http.Error(w, "book not found in Readarr lookup", http.StatusBadRequest)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+
+	prev := storedRequestSelection(req)
+	payload, _ := json.Marshal(readarrSelectionPayload(*pick))
+	payload = s.withRequestTemplate(req.Format, payload)
+	if err := s.db.SetRequestSelection(r.Context(), req.ID, payload, false); err != nil {
+		http.Error(w, "db: "+err.Error(), 500)
+		return
+	}
+	if cover := s.requestCoverFromPayload(req.Format, payload); cover != "" {
+		_ = s.db.UpdateRequestCover(r.Context(), req.ID, cover)
+	}
+
+	username := r.Context().Value(ctxUser).(*session).Username
+	s.auditLog(r.Context(), username, "request.selection_confirmed", &req.ID,
+		fmt.Sprintf("%s -> %s (%s)", util.FirstNonEmpty(prev.ForeignBookID, "none"), pick.ForeignBookId, pick.Title))
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(req.ID, 10)+`}}`)
+	writeJSON(w, map[string]any{
+		"id": req.ID,
+		"selection": storedSelection{
+			Title:            pick.Title,
+			ForeignBookID:    pick.ForeignBookId,
+			ForeignEditionID: pick.ForeignEditionId,
+		},
+	}, 200)
+}
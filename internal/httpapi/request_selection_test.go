@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func TestMatchNeedsReview(t *testing.T) {
+	cases := []struct {
+		name  string
+		steps []enrichmentStep
+		want  bool
+	}{
+		{"identifier match", []enrichmentStep{{Source: config.EnrichReadarr, Filled: []string{"foreignBookId"}}}, false},
+		{"title guess", []enrichmentStep{{Source: config.EnrichReadarr, Filled: []string{"foreignBookId"}, Guess: true}}, true},
+		{"no foreign id", []enrichmentStep{{Source: config.EnrichReadarr}}, true},
+	}
+	for _, c := range cases {
+		if got := matchNeedsReview(c.steps); got != c.want {
+			t.Fatalf("%s: got %v", c.name, got)
+		}
+	}
+}
+
+func TestGuessedSelectionNeedsReviewBeforeApproval(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"title":"Quiet Book: A Summary","foreignBookId":"fb-sum","foreignEditionId":"fe-sum","author":{"name":"Study Guides"}},
+			{"title":"Quiet Book","foreignBookId":"fb-q","foreignEditionId":"fe-q","author":{"name":"A. Author"}}
+		]`))
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api/v1/requests", `{"title":"Quiet Book","authors":["Ann Author"],"format":"ebook"}`, makeCookie(t, s, "user", false))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	stored, _ := s.db.GetRequest(context.Background(), created.ID)
+	if !stored.NeedsReview || storedRequestSelection(stored).ForeignBookID != "fb-sum" {
+		t.Fatalf("expected a guessed selection flagged for review: %+v %s", stored.NeedsReview, stored.ReadarrReq)
+	}
+
+	admin := makeCookie(t, s, "admin", true)
+	base := "/api/v1/requests/" + strconv.FormatInt(created.ID, 10)
+	if rec := do(http.MethodPost, base+"/approve", "", admin); rec.Code != http.StatusConflict {
+		t.Fatalf("approve before review: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, base+"/candidates", "", admin)
+	var listed struct {
+		NeedsReview bool            `json:"needsReview"`
+		Candidates  []bookCandidate `json:"candidates"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || !listed.NeedsReview || len(listed.Candidates) != 2 || !listed.Candidates[0].Selected {
+		t.Fatalf("candidates: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodPut, base+"/selection", `{"foreignBookId":"fb-missing"}`, admin); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown book: %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, base+"/selection", `{"foreignBookId":"fb-q"}`, admin); rec.Code != http.StatusOK {
+		t.Fatalf("confirm: %d %s", rec.Code, rec.Body.String())
+	}
+	stored, _ = s.db.GetRequest(context.Background(), created.ID)
+	if stored.NeedsReview || storedRequestSelection(stored).ForeignEditionID != "fe-q" || s.needsReviewBlocks(stored) {
+		t.Fatalf("after confirm: %+v %s", stored.NeedsReview, stored.ReadarrReq)
+	}
+}
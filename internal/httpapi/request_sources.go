@@ -0,0 +1,159 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// Request sources: the channel a request arrived through.
+const (
+	sourceWeb          = "web"
+	sourceAPI          = "api"
+	sourceBulk         = "bulk"
+	sourceBookmarklet  = "bookmarklet"
+	sourceWebhook      = "webhook"
+	sourceGuest        = "guest"
+	sourceSubscription = "subscription"
+)
+
+// ctxSource carries the source a nested createRequest call should record,
+// for callers that request on someone's behalf.
+const ctxSource ctxKey = "source"
+
+// Source policies from requests.source_policies.
+const (
+	sourcePolicyAuto   = "auto"
+	sourcePolicyReview = "review"
+)
+
+type requestSourceOption struct {
+	Key   string
+	Label string
+}
+
+// requestSources lists every source in display order.
+var requestSources = []requestSourceOption{
+	{sourceWeb, "Web"},
+	{sourceAPI, "API"},
+	{sourceBulk, "Bulk import"},
+	{sourceBookmarklet, "Bookmarklet"},
+	{sourceWebhook, "Inbound webhook"},
+	{sourceGuest, "Guest link"},
+	{sourceSubscription, "Subscription"},
+}
+
+// requestSourceLabel names a source for display. Requests made before
+// sources were recorded have none.
+func requestSourceLabel(source string) string {
+	if source == "" {
+		return "Unknown"
+	}
+	for _, o := range requestSources {
+		if o.Key == source {
+			return o.Label
+		}
+	}
+	return source
+}
+
+// withRequestSource marks r so createRequest records source.
+func withRequestSource(r *http.Request, source string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ctxSource, source))
+}
+
+// requestSource is the channel r arrived through. Without an explicit
+// source, the pages' own form posts and fetches (which send HX-Request) are
+// "web" and everything else is an API client.
+func requestSource(r *http.Request) string {
+	if v, _ := r.Context().Value(ctxSource).(string); v != "" {
+		return v
+	}
+	if strings.EqualFold(r.Header.Get("HX-Request"), "true") || strings.Contains(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return sourceWeb
+	}
+	return sourceAPI
+}
+
+// sourcePolicy returns "auto", "review", or "" for a source.
+func (s *Server) sourcePolicy(source string) string {
+	switch p := s.settings.Get().Requests.SourcePolicies[source]; p {
+	case sourcePolicyAuto, sourcePolicyReview:
+		return p
+	}
+	return ""
+}
+
+// applySourcePolicy adjusts the requester's own auto-approve setting for
+// the source of req, auditing a request it holds back.
+func (s *Server) applySourcePolicy(ctx context.Context, req *db.Request, autoApprove bool) bool {
+	switch s.sourcePolicy(req.Source) {
+	case sourcePolicyAuto:
+		return true
+	case sourcePolicyReview:
+		if autoApprove {
+			s.auditLog(ctx, "system", "request.auto_approve_held", &req.ID, "requests from "+requestSourceLabel(req.Source)+" need review")
+		}
+		return false
+	}
+	return autoApprove
+}
+
+type requestSourcePolicyRow struct {
+	Key    string
+	Label  string
+	Policy string
+}
+
+// requestSourcePolicyRows pairs each source with its configured policy for
+// the settings page.
+func (s *Server) requestSourcePolicyRows() []requestSourcePolicyRow {
+	out := make([]requestSourcePolicyRow, len(requestSources))
+	for i, o := range requestSources {
+		out[i] = requestSourcePolicyRow{Key: o.Key, Label: o.Label, Policy: s.sourcePolicy(o.Key)}
+	}
+	return out
+}
+
+// sourcePoliciesFromForm reads the settings form's source_policy_<key>
+// selects, keeping only the sources with a policy.
+func sourcePoliciesFromForm(r *http.Request) map[string]string {
+	var out map[string]string
+	for _, o := range requestSources {
+		switch p := r.FormValue("source_policy_" + o.Key); p {
+		case sourcePolicyAuto, sourcePolicyReview:
+			if out == nil {
+				out = map[string]string{}
+			}
+			out[o.Key] = p
+		}
+	}
+	return out
+}
+
+type requestSourceCount struct {
+	Source string `json:"source"`
+	Label  string `json:"label"`
+	Count  int    `json:"count"`
+}
+
+// requestSourceCounts counts the requests in scope by source, in display
+// order, leaving out sources with none.
+func (s *Server) requestSourceCounts(ctx context.Context, scope db.RequestScope) ([]requestSourceCount, error) {
+	counts, err := s.db.CountRequestsBySource(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	var out []requestSourceCount
+	for _, o := range requestSources {
+		if n := counts[o.Key]; n > 0 {
+			out = append(out, requestSourceCount{Source: o.Key, Label: o.Label, Count: n})
+		}
+	}
+	if n := counts[""]; n > 0 {
+		out = append(out, requestSourceCount{Label: requestSourceLabel(""), Count: n})
+	}
+	return out, nil
+}
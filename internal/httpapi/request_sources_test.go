@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestRequestSourcesRecordedAndPolicies(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "alice", "x", false, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.CreateUser(ctx, "bob", "x", false, false); err != nil {
+		t.Fatal(err)
+	}
+	cfg := *s.settings.Get()
+	cfg.Requests.SourcePolicies = map[string]string{sourceAPI: sourcePolicyReview, sourceWeb: sourcePolicyAuto}
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	router := s.Router()
+	create := func(user, title string, fromPage bool) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", strings.NewReader(`{"title":"`+title+`","authors":["A"],"format":"ebook"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if fromPage {
+			req.Header.Set("HX-Request", "true")
+		}
+		req.AddCookie(makeCookie(t, s, user, false))
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %q = %d %s", title, rec.Code, rec.Body.String())
+		}
+	}
+	// alice auto-approves, but API requests always wait for review; bob
+	// does not, but requests from the pages are always auto-approved.
+	create("alice", "From API", false)
+	create("bob", "From page", true)
+
+	items, err := s.db.ListRequestsPage(ctx, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][2]string{}
+	for _, it := range items {
+		got[it.Title] = [2]string{it.Source, it.Status}
+	}
+	if got["From API"] != [2]string{sourceAPI, "pending"} || got["From page"] != [2]string{sourceWeb, "approved"} {
+		t.Fatalf("sources and statuses = %v", got)
+	}
+	events, _ := s.db.ListAuditEvents(ctx, 20)
+	held := false
+	for _, e := range events {
+		held = held || (e.EventType == "request.auto_approve_held" && strings.Contains(e.Details, "API"))
+	}
+	if !held {
+		t.Fatalf("API hold not audited: %+v", events)
+	}
+
+	counts, err := s.requestSourceCounts(ctx, db.RequestScope{})
+	if err != nil || len(counts) != 2 || counts[0].Source != sourceWeb || counts[1].Source != sourceAPI {
+		t.Fatalf("counts = %+v, %v", counts, err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/requests?source=api", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	router.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "From API") || strings.Contains(body, "From page") || !strings.Contains(body, "via API") {
+		t.Fatalf("filtered list = %d", rec.Code)
+	}
+}
+
+func TestRequestSourceFromRequest(t *testing.T) {
+	for _, tc := range []struct {
+		header, contentType, want string
+	}{
+		{"true", "application/json", sourceWeb},
+		{"", "application/x-www-form-urlencoded", sourceWeb},
+		{"", "application/json", sourceAPI},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/requests", nil)
+		r.Header.Set("HX-Request", tc.header)
+		r.Header.Set("Content-Type", tc.contentType)
+		if got := requestSource(r); got != tc.want {
+			t.Errorf("requestSource(%q, %q) = %q, want %q", tc.header, tc.contentType, got, tc.want)
+		}
+		if got := requestSource(withRequestSource(r, sourceGuest)); got != sourceGuest {
+			t.Errorf("explicit source = %q", got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// requestTemplateFormats are the formats whose selections go to Readarr and
+// so can have a payload template.
+var requestTemplateFormats = []string{"ebook", "audiobook"}
+
+// withRequestTemplate merges the payload template configured for format into
+// a selection payload about to be stored on a request. A payload that is
+// empty or not a JSON object comes back unchanged.
+func (s *Server) withRequestTemplate(format string, payload json.RawMessage) json.RawMessage {
+	tpl := s.settings.Get().Requests.Templates[strings.ToLower(strings.TrimSpace(format))]
+	if len(tpl) == 0 || len(payload) == 0 {
+		return payload
+	}
+	var m map[string]any
+	if err := json.Unmarshal(payload, &m); err != nil || m == nil {
+		return payload
+	}
+	mergePayloadTemplate(m, tpl)
+	b, err := json.Marshal(m)
+	if err != nil {
+		return payload
+	}
+	return b
+}
+
+// mergePayloadTemplate copies tpl into dst. Where both hold an object the
+// two are merged key by key; otherwise the template's value wins.
+func mergePayloadTemplate(dst, tpl map[string]any) {
+	for k, v := range tpl {
+		sub, ok := v.(map[string]any)
+		if have, isMap := dst[k].(map[string]any); ok && isMap {
+			mergePayloadTemplate(have, sub)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// requestTemplateView is one format's template on the settings page.
+type requestTemplateView struct {
+	Format string
+	Label  string
+	JSON   string
+}
+
+func requestTemplateViews(templates map[string]map[string]any) []requestTemplateView {
+	out := make([]requestTemplateView, 0, len(requestTemplateFormats))
+	for _, f := range requestTemplateFormats {
+		v := requestTemplateView{Format: f, Label: "eBooks"}
+		if f == "audiobook" {
+			v.Label = "Audiobooks"
+		}
+		if tpl := templates[f]; len(tpl) > 0 {
+			if b, err := json.MarshalIndent(tpl, "", "  "); err == nil {
+				v.JSON = string(b)
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// parseRequestTemplate reads a template from the settings page: a JSON
+// object, or nothing to clear it.
+func parseRequestTemplate(text string) (map[string]any, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	var tpl map[string]any
+	if err := json.Unmarshal([]byte(text), &tpl); err != nil || tpl == nil {
+		return nil, fmt.Errorf("template must be a JSON object")
+	}
+	return tpl, nil
+}
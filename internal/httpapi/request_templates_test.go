@@ -0,0 +1,67 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestTemplatesMergeIntoNewRequests(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Requests.Templates = map[string]map[string]any{
+		"audiobook": {"anyEditionOk": false, "author": map[string]any{"metadataProfileId": 2}},
+	}
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	create := func(format string) map[string]any {
+		body := []byte(`{"title":"Dune","authors":["Frank Herbert"],"format":"` + format + `","provider_payload":"{\"title\":\"Dune\",\"foreignBookId\":\"fb-1\",\"anyEditionOk\":true,\"author\":{\"authorName\":\"Frank Herbert\",\"metadataProfileId\":1}}"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/requests", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(makeCookie(t, s, "alice", false))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %s = %d %s", format, rec.Code, rec.Body.String())
+		}
+		var out struct {
+			ID int64 `json:"id"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		stored, err := s.db.GetRequest(context.Background(), out.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(stored.ReadarrReq, &payload); err != nil {
+			t.Fatalf("stored payload %s: %v", stored.ReadarrReq, err)
+		}
+		return payload
+	}
+
+	audio := create("audiobook")
+	author, _ := audio["author"].(map[string]any)
+	if audio["anyEditionOk"] != false || author["metadataProfileId"] != float64(2) || author["authorName"] != "Frank Herbert" || audio["foreignBookId"] != "fb-1" {
+		t.Fatalf("audiobook payload = %v", audio)
+	}
+	if ebook := create("ebook"); ebook["anyEditionOk"] != true {
+		t.Fatalf("ebook payload picked up the audiobook template: %v", ebook)
+	}
+}
+
+func TestParseRequestTemplate(t *testing.T) {
+	if tpl, err := parseRequestTemplate("  "); err != nil || tpl != nil {
+		t.Fatalf("empty = %v, %v", tpl, err)
+	}
+	if _, err := parseRequestTemplate(`[1, 2]`); err == nil {
+		t.Fatal("array accepted as a template")
+	}
+	if tpl, err := parseRequestTemplate(`{"anyEditionOk": false}`); err != nil || tpl["anyEditionOk"] != false {
+		t.Fatalf("object = %v, %v", tpl, err)
+	}
+}
@@ -0,0 +1,107 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// requestVersion is the token a client echoes back to show which copy of a
+// request it acted on: the request's updatedAt, as the API returns it.
+func requestVersion(req *db.Request) string {
+	return req.UpdatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// requestLocks serialises changes to one request. A version claim alone
+// would let a caller that reads the request after another's claim, but
+// before its write, claim the still-pending request again.
+type requestLocks struct {
+	mu    sync.Mutex
+	locks map[int64]*requestLock
+}
+
+type requestLock struct {
+	sync.Mutex
+	users int
+}
+
+// lock blocks until no one else holds request id and returns the release.
+func (l *requestLocks) lock(id int64) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[int64]*requestLock)
+	}
+	rl := l.locks[id]
+	if rl == nil {
+		rl = &requestLock{}
+		l.locks[id] = rl
+	}
+	rl.users++
+	l.mu.Unlock()
+	rl.Lock()
+	return func() {
+		rl.Unlock()
+		l.mu.Lock()
+		if rl.users--; rl.users == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// claimRequest claims req at version seen for one change. Until release is
+// called no other claim on req can go through, so the caller's write lands
+// before anyone else compares versions. It returns db.ErrStaleRequest when
+// req moved on since seen.
+func (s *Server) claimRequest(ctx context.Context, id int64, seen time.Time) (release func(), err error) {
+	unlock := s.requestLocks.lock(id)
+	if err := s.db.ClaimRequestVersion(ctx, id, seen); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// claimRequestVersion refuses a change made against a stale copy of req,
+// so two admins acting on the same request do not silently undo each
+// other. The version comes from an If-Match header or a "version" query or
+// form value; callers that send neither are held to the copy of req the
+// handler just loaded. Of two concurrent changes only one gets through. On
+// a mismatch it writes 409 with X-Request-Stale set, which the UI answers
+// by refreshing, and returns false. Handlers call it after their own
+// validation, just before they change anything, and defer release.
+func (s *Server) claimRequestVersion(w http.ResponseWriter, r *http.Request, req *db.Request) (release func(), ok bool) {
+	seen := req.UpdatedAt
+	v := strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-Match")), "W/")
+	v = strings.Trim(v, `"`)
+	if v == "" {
+		v = strings.TrimSpace(r.FormValue("version"))
+	}
+	if v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			http.Error(w, "version must be the request's updatedAt timestamp", http.StatusBadRequest)
+			return nil, false
+		}
+		seen = t
+	}
+	release, err := s.claimRequest(r.Context(), req.ID, seen)
+	if err == nil {
+		return release, true
+	}
+	if !errors.Is(err, db.ErrStaleRequest) {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if cur, err := s.db.GetRequest(r.Context(), req.ID); err == nil {
+		w.Header().Set("ETag", `"`+requestVersion(cur)+`"`)
+	}
+	w.Header().Set("X-Request-Stale", "true")
+	http.Error(w, "this request was changed by someone else; reload it and try again", http.StatusConflict)
+	return nil, false
+}
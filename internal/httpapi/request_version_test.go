@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestRequestVersionConflict(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	h := s.Router()
+	admin := makeCookie(t, s, "admin", true)
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	req, _ := s.db.GetRequest(ctx, id)
+	version := requestVersion(req)
+	stale := req.UpdatedAt.Add(-time.Second).Format(time.RFC3339Nano)
+	do := func(method, path, ifMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		r.AddCookie(admin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+	base := "/api/v1/requests/" + itoa(id)
+
+	rec := do(http.MethodGet, "/ui/requests/table", "")
+	if !strings.Contains(rec.Body.String(), `name="version" value="`+version+`"`) {
+		t.Fatalf("table does not carry the version %s", version)
+	}
+	rec = do(http.MethodPost, base+"/decline", `"`+stale+`"`)
+	if rec.Code != http.StatusConflict || rec.Header().Get("X-Request-Stale") != "true" || rec.Header().Get("ETag") != `"`+version+`"` {
+		t.Fatalf("stale decline = %d %v", rec.Code, rec.Header())
+	}
+	if got, _ := s.db.GetRequest(ctx, id); got.Status != "pending" {
+		t.Fatalf("stale decline changed the request: %+v", got)
+	}
+	if rec := do(http.MethodPost, base+"/decline?version=yesterday", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad version = %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, base+"/decline?version="+version, ""); rec.Code != http.StatusOK {
+		t.Fatalf("current decline = %d %s", rec.Code, rec.Body.String())
+	}
+	// The decline moved the version on, so an approve from the same page
+	// is refused.
+	if rec := do(http.MethodPost, base+"/approve", `"`+version+`"`); rec.Code != http.StatusConflict || rec.Header().Get("X-Request-Stale") != "true" {
+		t.Fatalf("approve after decline = %d %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := s.db.GetRequest(ctx, id); got.Status != "declined" {
+		t.Fatalf("request = %+v", got)
+	}
+}
+
+func TestRequestVersionWithoutVersionIsClaimed(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	h := s.Router()
+	admin := makeCookie(t, s, "admin", true)
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	req, _ := s.db.GetRequest(ctx, id)
+	put := func(body, ifMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, "/api/v1/requests/"+itoa(id)+"/note", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		r.AddCookie(admin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	if rec := put(`{"note":"first"}`, ""); rec.Code != http.StatusOK {
+		t.Fatalf("note without version = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := put(`{"note":"second"}`, `"`+requestVersion(req)+`"`); rec.Code != http.StatusConflict {
+		t.Fatalf("note on a stale version = %d %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := s.db.GetRequestAdminNote(ctx, id); got.Text != "first" {
+		t.Fatalf("stale note was saved: %q", got.Text)
+	}
+
+	// A handler that loaded the request before another change landed is
+	// refused even when its caller sent no version.
+	if err := s.db.ClaimRequestVersion(ctx, id, req.UpdatedAt); err != db.ErrStaleRequest {
+		t.Fatalf("claim on a stale copy = %v", err)
+	}
+}
+
+func TestClaimRequestVersionConcurrent(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	req, _ := s.db.GetRequest(ctx, id)
+
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.db.ClaimRequestVersion(ctx, id, req.UpdatedAt)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	won := 0
+	for err := range errs {
+		switch err {
+		case nil:
+			won++
+		case db.ErrStaleRequest:
+		default:
+			t.Fatalf("claim: %v", err)
+		}
+	}
+	if won != 1 {
+		t.Fatalf("%d concurrent claims succeeded, want 1", won)
+	}
+}
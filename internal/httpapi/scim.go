@@ -0,0 +1,638 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// scimActor is recorded in the audit log for changes the identity provider
+// makes.
+const scimActor = "scim"
+
+// scimFilterPattern matches the only filter identity providers need to find
+// an existing resource: attr eq "value".
+var scimFilterPattern = regexp.MustCompile(`^(?i)(userName|displayName)\s+eq\s+"([^"]*)"$`)
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type scimUser struct {
+	Schemas  []string    `json:"schemas"`
+	ID       string      `json:"id"`
+	UserName string      `json:"userName"`
+	Active   bool        `json:"active"`
+	Emails   []scimEmail `json:"emails,omitempty"`
+	Groups   []scimRef   `json:"groups,omitempty"`
+	Meta     scimMeta    `json:"meta"`
+}
+
+type scimGroup struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id"`
+	DisplayName string    `json:"displayName"`
+	Members     []scimRef `json:"members"`
+	Meta        scimMeta  `json:"meta"`
+}
+
+// scimPatch is a PatchOp message. Values stay raw because their shape
+// depends on the path.
+type scimPatch struct {
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+// writeSCIM writes v with the SCIM media type.
+func writeSCIM(w http.ResponseWriter, v any, code int) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// scimError writes a SCIM error message; scimType may be empty.
+func scimError(w http.ResponseWriter, code int, scimType, detail string) {
+	body := map[string]any{"schemas": []string{scimErrorSchema}, "status": strconv.Itoa(code), "detail": detail}
+	if scimType != "" {
+		body["scimType"] = scimType
+	}
+	writeSCIM(w, body, code)
+}
+
+// scimBool reads a boolean that some providers send as "True" or "False".
+func scimBool(raw json.RawMessage) (bool, bool) {
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		return b, true
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if v, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+			return v, true
+		}
+	}
+	return false, false
+}
+
+// requireSCIM admits calls carrying the configured SCIM token as a bearer
+// token. The endpoints do not exist while SCIM is off.
+func (s *Server) requireSCIM(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc := s.settings.Get().SCIM
+		want := strings.TrimSpace(sc.Token)
+		if !sc.Enabled || want == "" {
+			http.NotFound(w, r)
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(want)) != 1 {
+			scimError(w, http.StatusUnauthorized, "", "unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// mountSCIM adds the SCIM 2.0 endpoints identity providers provision
+// accounts through. Like Home Assistant's, they authenticate with their own
+// token rather than a session.
+func (s *Server) mountSCIM(r chi.Router) {
+	r.Route("/scim/v2", func(rt chi.Router) {
+		rt.Get("/ServiceProviderConfig", s.requireSCIM(s.scimServiceProviderConfig))
+		rt.Get("/Users", s.requireSCIM(s.scimListUsers))
+		rt.Post("/Users", s.requireSCIM(s.scimCreateUser))
+		rt.Get("/Users/{id}", s.requireSCIM(s.scimGetUser))
+		rt.Put("/Users/{id}", s.requireSCIM(s.scimReplaceUser))
+		rt.Patch("/Users/{id}", s.requireSCIM(s.scimPatchUser))
+		rt.Delete("/Users/{id}", s.requireSCIM(s.scimDeleteUser))
+		rt.Get("/Groups", s.requireSCIM(s.scimListGroups))
+		rt.Post("/Groups", s.requireSCIM(s.scimCreateGroup))
+		rt.Get("/Groups/{id}", s.requireSCIM(s.scimGetGroup))
+		rt.Put("/Groups/{id}", s.requireSCIM(s.scimReplaceGroup))
+		rt.Patch("/Groups/{id}", s.requireSCIM(s.scimPatchGroup))
+		rt.Delete("/Groups/{id}", s.requireSCIM(s.scimDeleteGroup))
+	})
+}
+
+func (s *Server) scimServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	writeSCIM(w, map[string]any{
+		"schemas":               []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":                 map[string]bool{"supported": true},
+		"bulk":                  map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":                map[string]any{"supported": true, "maxResults": userImportMaxRows},
+		"changePassword":        map[string]bool{"supported": false},
+		"sort":                  map[string]bool{"supported": false},
+		"etag":                  map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]any{{"type": "oauthbearertoken", "name": "Bearer token", "description": "The token from Scriptorum's settings page", "primary": true}},
+	}, http.StatusOK)
+}
+
+// scimUserResource renders u with the groups its role and household map to.
+func (s *Server) scimUserResource(u *db.User) scimUser {
+	out := scimUser{
+		Schemas:  []string{scimUserSchema},
+		ID:       strconv.FormatInt(u.ID, 10),
+		UserName: u.Username,
+		Active:   !u.Disabled,
+		Meta:     scimMeta{ResourceType: "User", Created: u.Created.UTC().Format(time.RFC3339), Location: "/scim/v2/Users/" + strconv.FormatInt(u.ID, 10)},
+	}
+	if u.Email != "" {
+		out.Emails = []scimEmail{{Value: u.Email, Primary: true}}
+	}
+	if admin := strings.TrimSpace(s.settings.Get().SCIM.AdminGroup); admin != "" && u.IsAdmin {
+		out.Groups = append(out.Groups, scimRef{Value: scimGroupID(admin), Display: admin})
+	}
+	if g, ok := s.settings.Get().Group(u.GroupName); ok {
+		out.Groups = append(out.Groups, scimRef{Value: scimGroupID(g.Name), Display: g.Name})
+	}
+	return out
+}
+
+// scimUserByID loads the user named by the {id} path parameter, writing a
+// 404 when there is none.
+func (s *Server) scimUserByID(w http.ResponseWriter, r *http.Request) (*db.User, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err == nil {
+		if u, err := s.db.GetUserByID(r.Context(), id); err == nil {
+			return u, true
+		}
+	}
+	scimError(w, http.StatusNotFound, "", "user not found")
+	return nil, false
+}
+
+// scimFilter returns the value of an attr eq "value" filter on attr, or an
+// error for any filter it cannot evaluate.
+func scimFilter(r *http.Request, attr string) (string, bool, error) {
+	f := strings.TrimSpace(r.URL.Query().Get("filter"))
+	if f == "" {
+		return "", false, nil
+	}
+	m := scimFilterPattern.FindStringSubmatch(f)
+	if m == nil || !strings.EqualFold(m[1], attr) {
+		return "", false, fmt.Errorf("unsupported filter %q", f)
+	}
+	return m[2], true, nil
+}
+
+// scimList writes a ListResponse page of resources using startIndex and
+// count.
+func scimList(w http.ResponseWriter, r *http.Request, all []any) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if start < 1 {
+		start = 1
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 0 {
+		count = len(all)
+	}
+	page := []any{}
+	if start-1 < len(all) {
+		page = all[start-1:]
+	}
+	if len(page) > count {
+		page = page[:count]
+	}
+	writeSCIM(w, map[string]any{
+		"schemas":      []string{scimListSchema},
+		"totalResults": len(all),
+		"startIndex":   start,
+		"itemsPerPage": len(page),
+		"Resources":    page,
+	}, http.StatusOK)
+}
+
+func (s *Server) scimListUsers(w http.ResponseWriter, r *http.Request) {
+	name, filtered, err := scimFilter(r, "userName")
+	if err != nil {
+		scimError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	users, err := s.db.ListUsers(r.Context())
+	if err != nil {
+		scimError(w, http.StatusInternalServerError, "", "failed to list users")
+		return
+	}
+	out := []any{}
+	// ListUsers is newest first; providers page more predictably oldest first.
+	for i := len(users) - 1; i >= 0; i-- {
+		if filtered && !strings.EqualFold(users[i].Username, name) {
+			continue
+		}
+		out = append(out, s.scimUserResource(&users[i]))
+	}
+	scimList(w, r, out)
+}
+
+func (s *Server) scimGetUser(w http.ResponseWriter, r *http.Request) {
+	if u, ok := s.scimUserByID(w, r); ok {
+		writeSCIM(w, s.scimUserResource(u), http.StatusOK)
+	}
+}
+
+// scimUserInput is the part of a User resource Scriptorum keeps.
+type scimUserInput struct {
+	UserName string          `json:"userName"`
+	Active   json.RawMessage `json:"active"`
+	Emails   []scimEmail     `json:"emails"`
+}
+
+// primaryEmail is the primary address, or the first one listed.
+func (in scimUserInput) primaryEmail() string {
+	email := ""
+	for _, e := range in.Emails {
+		if email == "" || e.Primary {
+			email = e.Value
+		}
+	}
+	return strings.TrimSpace(email)
+}
+
+// active defaults to true when the provider leaves it out.
+func (in scimUserInput) active() bool {
+	if len(in.Active) == 0 {
+		return true
+	}
+	v, ok := scimBool(in.Active)
+	return !ok || v
+}
+
+// scimCreateUser creates an account that signs in through OAuth.
+func (s *Server) scimCreateUser(w http.ResponseWriter, r *http.Request) {
+	var in scimUserInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.UserName) == "" {
+		scimError(w, http.StatusBadRequest, "invalidValue", "userName is required")
+		return
+	}
+	username := strings.ToLower(strings.TrimSpace(in.UserName))
+	if _, err := s.db.GetUserByUsername(r.Context(), username); err == nil {
+		scimError(w, http.StatusConflict, "uniqueness", "user already exists")
+		return
+	}
+	id, err := s.db.CreateUser(r.Context(), username, oauthPlaceholderHash, s.isAdminUsername(username), false)
+	if err != nil {
+		scimError(w, http.StatusInternalServerError, "", "failed to create user")
+		return
+	}
+	if email := in.primaryEmail(); email != "" {
+		_ = s.db.SetUserEmail(r.Context(), id, email)
+	}
+	if !in.active() {
+		_ = s.db.SetUserDisabled(r.Context(), id, true)
+	}
+	s.auditLog(r.Context(), scimActor, "user.created", nil, fmt.Sprintf("username=%s, active=%t", username, in.active()))
+	u, _ := s.db.GetUserByID(r.Context(), id)
+	writeSCIM(w, s.scimUserResource(u), http.StatusCreated)
+}
+
+// scimReplaceUser applies a full User resource. Usernames are the key
+// requests are filed under, so they cannot change.
+func (s *Server) scimReplaceUser(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.scimUserByID(w, r)
+	if !ok {
+		return
+	}
+	var in scimUserInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", "invalid user")
+		return
+	}
+	if name := strings.TrimSpace(in.UserName); name != "" && !strings.EqualFold(name, u.Username) {
+		scimError(w, http.StatusBadRequest, "mutability", "userName cannot be changed")
+		return
+	}
+	_ = s.db.SetUserEmail(r.Context(), u.ID, in.primaryEmail())
+	s.scimSetActive(r.Context(), u, in.active())
+	u, _ = s.db.GetUserByID(r.Context(), u.ID)
+	writeSCIM(w, s.scimUserResource(u), http.StatusOK)
+}
+
+// scimPatchUser handles the patches providers send: active, the email, and
+// a whole-resource replace without a path.
+func (s *Server) scimPatchUser(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.scimUserByID(w, r)
+	if !ok {
+		return
+	}
+	var in scimPatch
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", "invalid patch")
+		return
+	}
+	for _, op := range in.Operations {
+		kind := strings.ToLower(op.Op)
+		if kind != "add" && kind != "replace" {
+			continue
+		}
+		path := strings.ToLower(strings.TrimSpace(op.Path))
+		switch {
+		case path == "":
+			var v scimUserInput
+			if json.Unmarshal(op.Value, &v) != nil {
+				scimError(w, http.StatusBadRequest, "invalidValue", "invalid value")
+				return
+			}
+			if len(v.Active) > 0 {
+				s.scimSetActive(r.Context(), u, v.active())
+			}
+			if email := v.primaryEmail(); email != "" {
+				_ = s.db.SetUserEmail(r.Context(), u.ID, email)
+			}
+		case path == "active":
+			active, ok := scimBool(op.Value)
+			if !ok {
+				scimError(w, http.StatusBadRequest, "invalidValue", "active must be a boolean")
+				return
+			}
+			s.scimSetActive(r.Context(), u, active)
+		case strings.HasPrefix(path, "emails"):
+			var email string
+			if json.Unmarshal(op.Value, &email) != nil {
+				var list []scimEmail
+				_ = json.Unmarshal(op.Value, &list)
+				email = scimUserInput{Emails: list}.primaryEmail()
+			}
+			if email = strings.TrimSpace(email); email != "" {
+				_ = s.db.SetUserEmail(r.Context(), u.ID, email)
+			}
+		}
+	}
+	u, _ = s.db.GetUserByID(r.Context(), u.ID)
+	writeSCIM(w, s.scimUserResource(u), http.StatusOK)
+}
+
+// scimDeleteUser deactivates the account rather than deleting it, so its
+// request history stays intact.
+func (s *Server) scimDeleteUser(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.scimUserByID(w, r)
+	if !ok {
+		return
+	}
+	s.scimSetActive(r.Context(), u, false)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// scimSetActive deactivates or reactivates u, recording a change.
+func (s *Server) scimSetActive(ctx context.Context, u *db.User, active bool) {
+	if u.Disabled == !active {
+		return
+	}
+	_ = s.db.SetUserDisabled(ctx, u.ID, !active)
+	if !active {
+		_, _ = s.db.DeleteUserSessions(ctx, u.Username, "")
+	}
+	s.auditLog(ctx, scimActor, "user.updated", nil, fmt.Sprintf("user id %d, active=%t", u.ID, active))
+}
+
+// scimGroupID is the id a group is known by: its lowercased name, so the
+// same group resolves whatever case the provider uses.
+func scimGroupID(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// scimGroupName resolves the {id} path parameter to a display name: the
+// admin group, a household group, or (for groups Scriptorum ignores) the id
+// itself.
+func (s *Server) scimGroupName(r *http.Request) string {
+	id, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		id = chi.URLParam(r, "id")
+	}
+	cfg := s.settings.Get()
+	if admin := strings.TrimSpace(cfg.SCIM.AdminGroup); admin != "" && scimGroupID(admin) == scimGroupID(id) {
+		return admin
+	}
+	if g, ok := cfg.Group(id); ok {
+		return g.Name
+	}
+	return id
+}
+
+// scimGroupResource lists the members of the group called name. Groups that
+// map to neither the admin role nor a household group have no members.
+func (s *Server) scimGroupResource(ctx context.Context, name string) scimGroup {
+	out := scimGroup{
+		Schemas:     []string{scimGroupSchema},
+		ID:          scimGroupID(name),
+		DisplayName: name,
+		Members:     []scimRef{},
+		Meta:        scimMeta{ResourceType: "Group", Location: "/scim/v2/Groups/" + url.PathEscape(scimGroupID(name))},
+	}
+	cfg := s.settings.Get()
+	isAdmin := strings.TrimSpace(cfg.SCIM.AdminGroup) != "" && scimGroupID(cfg.SCIM.AdminGroup) == out.ID
+	_, isHousehold := cfg.Group(name)
+	if !isAdmin && !isHousehold {
+		return out
+	}
+	users, _ := s.db.ListUsers(ctx)
+	for i := len(users) - 1; i >= 0; i-- {
+		u := users[i]
+		if (isAdmin && u.IsAdmin) || (isHousehold && strings.EqualFold(u.GroupName, name)) {
+			out.Members = append(out.Members, scimRef{Value: strconv.FormatInt(u.ID, 10), Display: u.Username})
+		}
+	}
+	return out
+}
+
+func (s *Server) scimListGroups(w http.ResponseWriter, r *http.Request) {
+	name, filtered, err := scimFilter(r, "displayName")
+	if err != nil {
+		scimError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	cfg := s.settings.Get()
+	var names []string
+	if admin := strings.TrimSpace(cfg.SCIM.AdminGroup); admin != "" {
+		names = append(names, admin)
+	}
+	for _, g := range cfg.Groups {
+		names = append(names, g.Name)
+	}
+	out := []any{}
+	for _, n := range names {
+		if filtered && !strings.EqualFold(n, name) {
+			continue
+		}
+		out = append(out, s.scimGroupResource(r.Context(), n))
+	}
+	scimList(w, r, out)
+}
+
+func (s *Server) scimGetGroup(w http.ResponseWriter, r *http.Request) {
+	writeSCIM(w, s.scimGroupResource(r.Context(), s.scimGroupName(r)), http.StatusOK)
+}
+
+// scimGroupInput is a Group resource as providers send it.
+type scimGroupInput struct {
+	DisplayName string    `json:"displayName"`
+	Members     []scimRef `json:"members"`
+}
+
+// scimCreateGroup accepts any group so providers can push their whole
+// directory; only the admin group and household groups grant anything.
+func (s *Server) scimCreateGroup(w http.ResponseWriter, r *http.Request) {
+	var in scimGroupInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.DisplayName) == "" {
+		scimError(w, http.StatusBadRequest, "invalidValue", "displayName is required")
+		return
+	}
+	name := strings.TrimSpace(in.DisplayName)
+	if g, ok := s.settings.Get().Group(name); ok {
+		name = g.Name
+	}
+	s.scimSetMembers(r.Context(), name, in.Members, true)
+	writeSCIM(w, s.scimGroupResource(r.Context(), name), http.StatusCreated)
+}
+
+func (s *Server) scimReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	var in scimGroupInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", "invalid group")
+		return
+	}
+	name := s.scimGroupName(r)
+	s.scimSetMembers(r.Context(), name, in.Members, true)
+	writeSCIM(w, s.scimGroupResource(r.Context(), name), http.StatusOK)
+}
+
+// scimMemberPathPattern matches a remove of one member by id, as in
+// members[value eq "12"].
+var scimMemberPathPattern = regexp.MustCompile(`^(?i)members\[value\s+eq\s+"([^"]+)"\]$`)
+
+// scimPatchGroup adds, removes, or replaces members.
+func (s *Server) scimPatchGroup(w http.ResponseWriter, r *http.Request) {
+	var in scimPatch
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", "invalid patch")
+		return
+	}
+	name := s.scimGroupName(r)
+	for _, op := range in.Operations {
+		var members []scimRef
+		path := strings.TrimSpace(op.Path)
+		if m := scimMemberPathPattern.FindStringSubmatch(path); m != nil {
+			members = []scimRef{{Value: m[1]}}
+		} else if strings.EqualFold(path, "members") {
+			_ = json.Unmarshal(op.Value, &members)
+		} else if path == "" {
+			var v scimGroupInput
+			_ = json.Unmarshal(op.Value, &v)
+			members = v.Members
+		} else {
+			continue
+		}
+		switch strings.ToLower(op.Op) {
+		case "add":
+			s.scimSetMembers(r.Context(), name, members, false)
+		case "replace":
+			s.scimSetMembers(r.Context(), name, members, true)
+		case "remove":
+			if len(members) == 0 && strings.EqualFold(path, "members") {
+				s.scimSetMembers(r.Context(), name, nil, true)
+			}
+			s.scimRemoveMembers(r.Context(), name, members)
+		}
+	}
+	writeSCIM(w, s.scimGroupResource(r.Context(), name), http.StatusOK)
+}
+
+// scimDeleteGroup removes everyone from the group.
+func (s *Server) scimDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	s.scimSetMembers(r.Context(), s.scimGroupName(r), nil, true)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// scimGrant gives or takes away what membership of the group called name
+// means: the admin role or the household group. Session cookies carry the
+// admin flag, so a change signs the user out everywhere to take effect.
+func (s *Server) scimGrant(ctx context.Context, name string, u *db.User, member bool) {
+	cfg := s.settings.Get()
+	if admin := strings.TrimSpace(cfg.SCIM.AdminGroup); admin != "" && scimGroupID(admin) == scimGroupID(name) {
+		if u.IsAdmin != member {
+			_ = s.db.SetUserAdmin(ctx, u.ID, member)
+			_, _ = s.db.DeleteUserSessions(ctx, u.Username, "")
+			s.auditLog(ctx, scimActor, "user.updated", nil, fmt.Sprintf("user id %d, admin=%t", u.ID, member))
+		}
+		return
+	}
+	g, ok := cfg.Group(name)
+	if !ok {
+		return
+	}
+	in := strings.EqualFold(u.GroupName, g.Name)
+	switch {
+	case member && !in:
+		_ = s.db.SetUserGroup(ctx, u.ID, g.Name)
+		_, _ = s.db.DeleteUserSessions(ctx, u.Username, "")
+		s.auditLog(ctx, scimActor, "user.updated", nil, fmt.Sprintf("user id %d, group=%s", u.ID, g.Name))
+	case !member && in:
+		_ = s.db.SetUserGroup(ctx, u.ID, "")
+		_, _ = s.db.DeleteUserSessions(ctx, u.Username, "")
+		s.auditLog(ctx, scimActor, "user.updated", nil, fmt.Sprintf("user id %d, group=", u.ID))
+	}
+}
+
+// scimSetMembers adds members to the group called name. With exclusive set,
+// current members not listed are removed, as a PUT replaces the list. Local
+// accounts with a password are not the provider's to know about, so they
+// are never removed that way.
+func (s *Server) scimSetMembers(ctx context.Context, name string, members []scimRef, exclusive bool) {
+	listed := map[string]bool{}
+	for _, m := range members {
+		listed[m.Value] = true
+	}
+	users, _ := s.db.ListUsers(ctx)
+	for i := range users {
+		u := &users[i]
+		if listed[strconv.FormatInt(u.ID, 10)] {
+			s.scimGrant(ctx, name, u, true)
+		} else if exclusive && u.Hash == oauthPlaceholderHash {
+			s.scimGrant(ctx, name, u, false)
+		}
+	}
+}
+
+// scimRemoveMembers takes the listed members out of the group called name.
+func (s *Server) scimRemoveMembers(ctx context.Context, name string, members []scimRef) {
+	for _, m := range members {
+		id, err := strconv.ParseInt(m.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		if u, err := s.db.GetUserByID(ctx, id); err == nil {
+			s.scimGrant(ctx, name, u, false)
+		}
+	}
+}
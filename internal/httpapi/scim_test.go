@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func TestSCIMProvisionsUsersAndRoles(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer scim-token")
+		req.Header.Set("Content-Type", "application/scim+json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Off by default.
+	if rec := call(http.MethodGet, "/scim/v2/Users", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled: %d", rec.Code)
+	}
+	cfg := s.settings.Get()
+	cfg.SCIM = config.SCIMConfig{Enabled: true, Token: "scim-token", AdminGroup: "Book Admins"}
+	cfg.Groups = []config.GroupConfig{{Name: "Kids"}}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := call(http.MethodPost, "/scim/v2/Users", `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"Alice","active":true,"emails":[{"value":"alice@example.com","primary":true}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	var alice scimUser
+	_ = json.Unmarshal(rec.Body.Bytes(), &alice)
+	if alice.UserName != "alice" || !alice.Active || len(alice.Emails) != 1 {
+		t.Fatalf("created = %+v", alice)
+	}
+	if rec := call(http.MethodPost, "/scim/v2/Users", `{"userName":"alice"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate: %d", rec.Code)
+	}
+	rec = call(http.MethodGet, `/scim/v2/Users?filter=userName+eq+%22ALICE%22`, "")
+	if !strings.Contains(rec.Body.String(), `"totalResults":1`) {
+		t.Fatalf("filter: %s", rec.Body.String())
+	}
+
+	// Group membership maps to the admin role and household groups.
+	rec = call(http.MethodPatch, "/scim/v2/Groups/book%20admins", `{"Operations":[{"op":"add","path":"members","value":[{"value":"`+alice.ID+`"}]}]}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"display":"alice"`) {
+		t.Fatalf("admin group: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodPost, "/scim/v2/Groups", `{"displayName":"kids","members":[{"value":"`+alice.ID+`"}]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create group: %d", rec.Code)
+	}
+	ctx := context.Background()
+	u, _ := s.db.GetUserByUsername(ctx, "alice")
+	if !u.IsAdmin || !strings.EqualFold(u.GroupName, "kids") || !s.requesterIsAdmin("alice") {
+		t.Fatalf("after groups: %+v", u)
+	}
+	adminCookie := makeCookie(t, s, "alice", true)
+	adminPage := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/requests/labels", nil)
+		req.AddCookie(adminCookie)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := adminPage(); code != http.StatusOK {
+		t.Fatalf("admin cookie before demotion: %d", code)
+	}
+	rec = call(http.MethodPatch, "/scim/v2/Groups/book%20admins", `{"Operations":[{"op":"remove","path":"members[value eq \"`+alice.ID+`\"]"}]}`)
+	if u, _ = s.db.GetUserByUsername(ctx, "alice"); u.IsAdmin {
+		t.Fatalf("still admin: %s", rec.Body.String())
+	}
+	// The demotion signs alice out, so a cookie issued while she was an
+	// admin no longer opens admin pages.
+	if code := adminPage(); code != http.StatusUnauthorized {
+		t.Fatalf("demoted admin cookie on an admin route: %d", code)
+	}
+
+	// Deactivation blocks the session; DELETE keeps the account.
+	cookie := makeCookie(t, s, "alice", false)
+	if rec := call(http.MethodPatch, "/scim/v2/Users/"+alice.ID, `{"Operations":[{"op":"replace","path":"active","value":"False"}]}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"active":false`) {
+		t.Fatalf("deactivate: %d %s", rec.Code, rec.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/search", nil)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/login" {
+		t.Fatalf("disabled session: %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+	if rec := call(http.MethodDelete, "/scim/v2/Users/"+alice.ID, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d", rec.Code)
+	}
+	if u, err := s.db.GetUserByUsername(ctx, "alice"); err != nil || !u.Disabled {
+		t.Fatalf("after delete: %+v, %v", u, err)
+	}
+
+	if rec := call(http.MethodGet, "/scim/v2/Users?filter=emails+co+%22x%22", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad filter: %d", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", rr.Code)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,12 +30,14 @@ func (s *Server) mountSearch(r chi.Router) {
 		"csrfToken":     func(r *http.Request) string { return s.getCSRFToken(r) },
 		"authorsText":   authorsText,
 		"truncateChars": truncateChars,
+		"brand":         s.branding,
+		"asset":         assetURL,
 	}
 	u := &searchUI{tpl: template.Must(template.New("tpl").Funcs(funcMap).ParseFS(tplFS, "web/templates/*.html"))}
 	r.Get("/ui/search", u.handleSearch(s))
 	// Readarr cover proxy (fetch fresh each call). Search UI will link images here
 	r.Get("/ui/readarr-cover", s.requireLogin(s.serveReadarrCover()))
-	if !s.disableDiscoveryWarmup {
+	if !s.disableDiscoveryWarmup && s.providerEnabled(providerOpenLibrary) {
 		s.triggerDiscoveryRefresh(u)
 	}
 }
@@ -83,6 +87,54 @@ type searchItem struct {
 	DiscoveryLabel           string
 	EbookState               string
 	AudiobookState           string
+	// ForeignBookID is the Readarr work id, for the foreign_book_id dedupe
+	// strategy.
+	ForeignBookID string
+	// RankDebug explains the item's dedupe keys and score when debug mode
+	// is on.
+	RankDebug string
+	// Sources are the search sources that returned the item, for ordering
+	// results by the user's preferred source.
+	Sources []string
+}
+
+// addSource notes that source returned si.
+func (si *searchItem) addSource(source string) {
+	if !slices.Contains(si.Sources, source) {
+		si.Sources = append(si.Sources, source)
+	}
+}
+
+// Formats reports which formats Readarr returned a payload for: "ebook" or
+// "audiobook" when only one did, "both", or "" for results from other
+// sources. The request modal pre-selects the only format, or offers a
+// chooser with each format's availability when there are two.
+func (si searchItem) Formats() string {
+	ebook := strings.TrimSpace(si.ProviderEbookPayload) != ""
+	audio := strings.TrimSpace(si.ProviderAudiobookPayload) != ""
+	switch {
+	case ebook && audio:
+		return "both"
+	case audio:
+		return "audiobook"
+	case ebook:
+		return "ebook"
+	default:
+		return ""
+	}
+}
+
+// EbookAddable reports whether the eBook Readarr instance returned this
+// result, so approving an eBook request can add it without a new lookup.
+func (si searchItem) EbookAddable() bool {
+	f := si.Formats()
+	return f == "ebook" || f == "both"
+}
+
+// AudiobookAddable is EbookAddable for the audiobook instance.
+func (si searchItem) AudiobookAddable() bool {
+	f := si.Formats()
+	return f == "audiobook" || f == "both"
 }
 
 type discoveryCategory struct {
@@ -170,20 +222,53 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 				limit = n
 			}
 		}
+		prefs := s.requestSearchPrefs(r)
+		filters := parseSearchFilters(r.URL.Query())
 		if q == "" {
-			data := s.cachedDiscoverySearchData(r.Context(), u)
+			// Discovery shelves come from Open Library.
+			data := map[string]any{"IsDiscovery": true, "DiscoveryOff": true}
+			if s.searchEnabled(prefs, providerOpenLibrary) {
+				data = s.cachedDiscoverySearchData(r.Context(), u)
+			}
 			w.Header().Set("Content-Type", "text/html; charset=utf-8")
 			_ = u.tpl.ExecuteTemplate(w, "search_partial.html", data)
 			return
 		}
 
+		// search.timeout bounds the whole search; sources still working when
+		// it passes are left out rather than holding up the page.
+		ctx := r.Context()
+		if d := s.searchTimeout(); d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+
+		// A pasted Amazon, Goodreads, or Google Books link searches every
+		// provider for the identifiers it carries instead of the URL text.
+		searchQ := q
+		var link *bookLinkSearch
+		if ref, ok := providers.ParseBookURL(q); ok {
+			ls := s.resolveBookLink(ctx, ref)
+			link = &ls
+			if ls.query != "" {
+				searchQ = ls.query
+			}
+		}
+
 		// Query OpenLibrary in parallel with the Readarr lookups so the extra
 		// source doesn't add latency to the search page.
 		olCh := make(chan []providers.BookItem, 1)
 		go func() {
-			olCtx, olCancel := context.WithTimeout(r.Context(), 6*time.Second)
+			if !s.searchEnabled(prefs, providerOpenLibrary) {
+				olCh <- nil
+				return
+			}
+			olCtx, olCancel := context.WithTimeout(ctx, 6*time.Second)
 			defer olCancel()
-			books, err := providers.NewOpenLibrary().Search(olCtx, q, limit, page)
+			started := time.Now()
+			books, err := providers.NewOpenLibrary().SearchWithLanguages(olCtx, filters.openLibraryQuery(searchQ), s.searchPageSize(providerOpenLibrary, limit), page, filters.openLibraryLanguages())
+			s.recordProviderCall(providerOpenLibrary, started, err)
 			if err != nil {
 				olCh <- nil
 				return
@@ -191,17 +276,26 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 			olCh <- books
 		}()
 
+		// Comics come from Mylar and are listed apart from the books.
+		comicCh := make(chan []comicSearchItem, 1)
+		go func() { comicCh <- s.searchComics(ctx, searchQ) }()
+
 		items := []searchItem{}
 		// Index by dedupe key to merge ebook/audiobook payloads for the same work
-		idx := map[string]int{}
+		dedupe, order := s.searchSettings()
+		dd := newSearchDeduper(dedupe)
 
 		// Helper to upsert item by key and attach payloads
 		upsert := func(si searchItem, ebook bool, payload string) {
-			k := dedupeKey(si.BookItem)
-			if k == "" {
+			if len(dd.keys(si)) == 0 {
 				return
 			}
-			if i, ok := idx[k]; ok {
+			source := providerReadarrAudiobooks
+			if ebook {
+				source = providerReadarrEbooks
+			}
+			if i, ok := dd.find(si); ok {
+				items[i].addSource(source)
 				// attach payload
 				if ebook {
 					if payload != "" {
@@ -223,6 +317,12 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 				if items[i].BookItem.Series == "" && si.BookItem.Series != "" {
 					items[i].BookItem.Series = si.BookItem.Series
 				}
+				if len(items[i].Languages) == 0 {
+					items[i].Languages = si.Languages
+				}
+				if len(items[i].Subjects) == 0 {
+					items[i].Subjects = si.Subjects
+				}
 				return
 			}
 			if ebook {
@@ -232,11 +332,19 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 			}
 			// Do not set Provider label so UI won't display source instance
 			si.Provider = ""
-			idx[k] = len(items)
+			si.addSource(source)
+			dd.add(si, len(items))
 			items = append(items, si)
 		}
 
 		asin := providers.ExtractASINFromInput(q)
+		readarrTerm := q
+		if asin != "" {
+			readarrTerm = asin
+		}
+		if link != nil && link.readarr != "" {
+			readarrTerm = link.readarr
+		}
 		cfg := s.settings.Get()
 		// Build instances
 		var instE, instA providers.ReadarrInstance
@@ -250,17 +358,13 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 		}
 
 		// Query Readarr ebooks
-		if strings.TrimSpace(instE.BaseURL) != "" && strings.TrimSpace(instE.APIKey) != "" && (asin != "" || q != "") {
+		if strings.TrimSpace(instE.BaseURL) != "" && strings.TrimSpace(instE.APIKey) != "" && (asin != "" || q != "") && s.searchEnabled(prefs, providerReadarrEbooks) && filters.queriesReadarr("ebook") {
 			ra := providers.NewReadarrWithDB(instE, s.db.SQL())
-			term := q
-			if asin != "" {
-				term = asin
-			}
-			if list, err := ra.LookupByTerm(r.Context(), term); err == nil {
-				for _, b := range list {
-					if !isRenderableSearchBook(b.Title, b.Disambiguation) {
-						continue
-					}
+			started := time.Now()
+			list, err := ra.LookupByTerm(ctx, readarrTerm)
+			s.recordProviderCall(providerReadarrEbooks, started, err)
+			if err == nil {
+				for _, b := range s.readarrSearchPage(providerReadarrEbooks, list, page) {
 					var author map[string]any
 					if b.Author != nil {
 						author = b.Author
@@ -317,22 +421,18 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 					cover = s.normalizeRequestCover("ebook", cover)
 					lbIsbn10, lbIsbn13, _ := extractIdentifiers(b)
 					cover = appendCoverIsbnFallback(cover, lbIsbn13, lbIsbn10)
-					upsert(searchItem{BookItem: providers.BookItem{Title: b.Title, Authors: authors, CoverSmall: cover, CoverMedium: cover, Series: b.SeriesTitle}, Provider: "readarr-ebook"}, true, string(cjson))
+					upsert(searchItem{BookItem: providers.BookItem{Title: b.Title, Authors: authors, CoverSmall: cover, CoverMedium: cover, Series: b.SeriesTitle, FirstPublishYear: readarrReleaseYear(b), Rating: readarrRating(b), Languages: readarrLanguages(b), Subjects: b.Genres}, Provider: "readarr-ebook", ForeignBookID: b.ForeignBookId}, true, string(cjson))
 				}
 			}
 		}
 		// Query Readarr audiobooks
-		if strings.TrimSpace(instA.BaseURL) != "" && strings.TrimSpace(instA.APIKey) != "" && (asin != "" || q != "") {
+		if strings.TrimSpace(instA.BaseURL) != "" && strings.TrimSpace(instA.APIKey) != "" && (asin != "" || q != "") && s.searchEnabled(prefs, providerReadarrAudiobooks) && filters.queriesReadarr("audiobook") {
 			ra := providers.NewReadarrWithDB(instA, s.db.SQL())
-			term := q
-			if asin != "" {
-				term = asin
-			}
-			if list, err := ra.LookupByTerm(r.Context(), term); err == nil {
-				for _, b := range list {
-					if !isRenderableSearchBook(b.Title, b.Disambiguation) {
-						continue
-					}
+			started := time.Now()
+			list, err := ra.LookupByTerm(ctx, readarrTerm)
+			s.recordProviderCall(providerReadarrAudiobooks, started, err)
+			if err == nil {
+				for _, b := range s.readarrSearchPage(providerReadarrAudiobooks, list, page) {
 					var author map[string]any
 					if b.Author != nil {
 						author = b.Author
@@ -388,36 +488,42 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 					cover = s.normalizeRequestCover("audiobook", cover)
 					lbIsbn10, lbIsbn13, _ := extractIdentifiers(b)
 					cover = appendCoverIsbnFallback(cover, lbIsbn13, lbIsbn10)
-					upsert(searchItem{BookItem: providers.BookItem{Title: b.Title, Authors: authors, CoverSmall: cover, CoverMedium: cover, Series: b.SeriesTitle}, Provider: "readarr-audiobook"}, false, string(cjson))
+					upsert(searchItem{BookItem: providers.BookItem{Title: b.Title, Authors: authors, CoverSmall: cover, CoverMedium: cover, Series: b.SeriesTitle, FirstPublishYear: readarrReleaseYear(b), Rating: readarrRating(b), Languages: readarrLanguages(b), Subjects: b.Genres}, Provider: "readarr-audiobook", ForeignBookID: b.ForeignBookId}, false, string(cjson))
 				}
 			}
 		}
 
 		// If Readarr produced nothing, fall back to Amazon before merging the
 		// OpenLibrary results (Amazon is the only source that resolves ASINs).
-		if len(items) == 0 {
-			ap := providers.NewAmazonPublic("www.amazon.com")
+		if len(items) == 0 && s.searchEnabled(prefs, providerAmazon) {
+			market := s.amazonMarketplace()
+			if link != nil {
+				market = link.amazonMarketplace(market)
+			}
+			ap := providers.NewAmazonPublic(market)
 			if asin != "" {
-				if book, err := ap.GetByASIN(r.Context(), asin); err == nil && book != nil {
-					si := searchItem{BookItem: providers.BookItem{ASIN: book.ASIN, Title: book.Title, Authors: book.Authors, ISBN10: book.ISBN10, ISBN13: book.ISBN13, CoverSmall: book.Image, CoverMedium: book.Image}}
-					if k := dedupeKey(si.BookItem); k != "" {
-						idx[k] = len(items)
-					}
+				started := time.Now()
+				book, err := ap.GetByASIN(ctx, asin)
+				s.recordProviderCall(providerAmazon, started, err)
+				if err == nil && book != nil {
+					si := searchItem{BookItem: providers.BookItem{ASIN: book.ASIN, Title: book.Title, Authors: book.Authors, ISBN10: book.ISBN10, ISBN13: book.ISBN13, CoverSmall: book.Image, CoverMedium: book.Image}, Sources: []string{providerAmazon}}
+					dd.add(si, len(items))
 					items = append(items, si)
 				}
-			} else if q != "" {
-				if pubItems, err := ap.SearchBooks(r.Context(), q, page, limit); err == nil {
+			} else if searchQ != "" {
+				started := time.Now()
+				pubItems, err := ap.SearchBooks(ctx, searchQ, page, s.searchPageSize(providerAmazon, limit))
+				s.recordProviderCall(providerAmazon, started, err)
+				if err == nil {
 					for _, b := range pubItems {
 						if !isRenderableSearchBook(b.Title) {
 							continue
 						}
-						si := searchItem{BookItem: providers.BookItem{ASIN: b.ASIN, Title: b.Title, Authors: b.Authors, CoverSmall: b.Image, CoverMedium: b.Image}}
-						if k := dedupeKey(si.BookItem); k != "" {
-							if _, exists := idx[k]; exists {
-								continue
-							}
-							idx[k] = len(items)
+						si := searchItem{BookItem: providers.BookItem{ASIN: b.ASIN, Title: b.Title, Authors: b.Authors, CoverSmall: b.Image, CoverMedium: b.Image}, Sources: []string{providerAmazon}}
+						if _, exists := dd.find(si); exists {
+							continue
 						}
+						dd.add(si, len(items))
 						items = append(items, si)
 					}
 				}
@@ -427,9 +533,24 @@ func (u *searchUI) handleSearch(s *Server) http.HandlerFunc {
 		// Merge the OpenLibrary results gathered in parallel: fill identifier
 		// and cover gaps on items Readarr already returned, and append books
 		// the other sources don't know about.
-		items = mergeOpenLibrarySearchItems(items, idx, <-olCh)
+		items = mergeOpenLibrarySearchItems(items, dd, <-olCh)
+		comics := <-comicCh
 
-		data := map[string]any{"Query": q, "Items": items}
+		var filteredOut int
+		items, filteredOut = filters.apply(items)
+
+		data := map[string]any{"Query": q, "FilteredOut": filteredOut, "TimedOut": errors.Is(ctx.Err(), context.DeadlineExceeded)}
+		if link != nil {
+			items = link.prefill(items)
+			data["LinkSource"] = link.ref.Label()
+		}
+		if order == orderSource {
+			orderBySource(items, prefs)
+		}
+		ses, _ := r.Context().Value(ctxUser).(*session)
+		rankSearchItems(items, searchQ, order, dedupe, cfg != nil && cfg.Debug && ses != nil && ses.Admin)
+		data["Items"] = items
+		data["Comics"] = comics
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		decorateSearchItems(s, items)
 		_ = u.tpl.ExecuteTemplate(w, "search_partial.html", data)
@@ -683,48 +804,26 @@ func (u *searchUI) loadDiscoveryCategories(ctx context.Context, languageCodes ..
 }
 
 // mergeOpenLibrarySearchItems folds OpenLibrary search results into the list
-// built from Readarr/Amazon. Books matching an existing item (by ASIN, ISBN13,
-// or title+author) fill that item's identifier, cover, and metadata gaps; the
-// rest are appended as OpenLibrary-only results.
-func mergeOpenLibrarySearchItems(items []searchItem, idx map[string]int, books []providers.BookItem) []searchItem {
+// built from Readarr/Amazon. Books the deduper matches to an existing item
+// fill that item's identifier, cover, and metadata gaps; the rest are
+// appended as OpenLibrary-only results.
+func mergeOpenLibrarySearchItems(items []searchItem, dd *searchDeduper, books []providers.BookItem) []searchItem {
 	for _, b := range books {
 		if !isRenderableSearchBook(b.Title) {
 			continue
 		}
-		matched := -1
-		for _, k := range []string{
-			"ASIN:" + strings.TrimSpace(strings.ToUpper(b.ASIN)),
-			"ISBN13:" + strings.TrimSpace(strings.ToUpper(b.ISBN13)),
-			titleAuthorKey(b),
-		} {
-			if k == "ASIN:" || k == "ISBN13:" || k == "" {
-				continue
-			}
-			if i, ok := idx[k]; ok {
-				matched = i
-				break
-			}
-		}
-		if matched >= 0 {
-			fillSearchItemFromOpenLibrary(&items[matched], b)
-			continue
-		}
 		si := openLibrarySearchItem(b, "")
-		k := dedupeKey(si.BookItem)
-		if k == "" {
+		si.Sources = []string{providerOpenLibrary}
+		if i, ok := dd.find(si); ok {
+			fillSearchItemFromOpenLibrary(&items[i], b)
+			items[i].addSource(providerOpenLibrary)
 			continue
 		}
-		if _, exists := idx[k]; exists {
+		// Registering every key (not just the most specific) lets
+		// identifier-less duplicates from other sources merge into this item.
+		if !dd.add(si, len(items)) {
 			continue
 		}
-		idx[k] = len(items)
-		// Register the title/author key too so identifier-less duplicates from
-		// other sources still merge into this item.
-		if ta := titleAuthorKey(si.BookItem); ta != "" && ta != k {
-			if _, exists := idx[ta]; !exists {
-				idx[ta] = len(items)
-			}
-		}
 		items = append(items, si)
 	}
 	return items
@@ -757,6 +856,18 @@ func fillSearchItemFromOpenLibrary(si *searchItem, b providers.BookItem) {
 	if strings.TrimSpace(si.Series) == "" {
 		si.Series = b.Series
 	}
+	if si.FirstPublishYear == 0 {
+		si.FirstPublishYear = b.FirstPublishYear
+	}
+	if len(si.Languages) == 0 {
+		si.Languages = b.Languages
+	}
+	if len(si.Subjects) == 0 {
+		si.Subjects = b.Subjects
+	}
+	if si.Rating == 0 {
+		si.Rating = b.Rating
+	}
 	if strings.TrimSpace(si.DetailsPayload) == "" {
 		si.DetailsPayload = buildOpenLibraryDetailsPayload(b)
 	}
@@ -1200,6 +1311,8 @@ func hasDiscoveryMetadata(book providers.BookItem) bool {
 func decorateSearchItems(s *Server, items []searchItem) {
 	stateCache := make(map[string]string, len(items)*2)
 	for i := range items {
+		items[i].CoverSmall = s.displayCover(items[i].CoverSmall, items[i].ISBN13, items[i].ISBN10)
+		items[i].CoverMedium = s.displayCover(items[i].CoverMedium, items[i].ISBN13, items[i].ISBN10)
 		if items[i].ProviderPayload == "" {
 			items[i].ProviderPayload = mergeProviderPayloads(items[i].ProviderEbookPayload, items[i].ProviderAudiobookPayload)
 		}
@@ -1226,12 +1339,19 @@ func cachedCatalogState(stateCache map[string]string, s *Server, kind, title str
 // reverse-proxy in front of Readarr rejects MediaCoverProxy requests).
 func (s *Server) serveReadarrCover() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if s.coverImages() != coversProxy {
+			http.NotFound(w, r)
+			return
+		}
 		remote := strings.TrimSpace(r.URL.Query().Get("u"))
 		if remote == "" {
 			http.Error(w, "missing url", http.StatusBadRequest)
 			return
 		}
-		fallback := openLibraryCoverFallbackURL(r.URL.Query().Get("isbn"))
+		fallback := ""
+		if s.providerEnabled(providerOpenLibrary) {
+			fallback = openLibraryCoverFallbackURL(r.URL.Query().Get("isbn"))
+		}
 		// validate URL
 		ru, err := url.Parse(remote)
 		if err != nil || !(ru.Scheme == "http" || ru.Scheme == "https") {
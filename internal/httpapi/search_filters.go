@@ -0,0 +1,193 @@
+package httpapi
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// Genre filter values.
+const (
+	genreFiction    = "fiction"
+	genreNonfiction = "nonfiction"
+)
+
+// searchFilters narrow a book search. Open Library gets the language and
+// year range as query parameters, the format filter skips the other
+// Readarr instance, and every result is then checked against the metadata
+// it carries. Results missing the metadata a filter needs are kept, since
+// Amazon and many Readarr results don't list a language or subjects.
+type searchFilters struct {
+	// Format is "ebook", "audiobook", or "" for both.
+	Format string
+	// Language is a MARC language code such as "eng".
+	Language string
+	YearFrom int
+	YearTo   int
+	// Genre is genreFiction, genreNonfiction, or "".
+	Genre string
+}
+
+// parseSearchFilters reads the filter controls of the search form. Unknown
+// values are ignored, and a reversed year range is swapped.
+func parseSearchFilters(v url.Values) searchFilters {
+	var f searchFilters
+	switch fm := strings.ToLower(strings.TrimSpace(v.Get("format"))); fm {
+	case "ebook", "audiobook":
+		f.Format = fm
+	}
+	lang := strings.ToLower(strings.TrimSpace(v.Get("language")))
+	for _, o := range discoveryLanguageOptions {
+		if o.Code == lang {
+			f.Language = lang
+		}
+	}
+	year := func(key string) int {
+		n, err := strconv.Atoi(strings.TrimSpace(v.Get(key)))
+		if err != nil || n < 1000 || n > 9999 {
+			return 0
+		}
+		return n
+	}
+	f.YearFrom, f.YearTo = year("year_from"), year("year_to")
+	if f.YearFrom > 0 && f.YearTo > 0 && f.YearFrom > f.YearTo {
+		f.YearFrom, f.YearTo = f.YearTo, f.YearFrom
+	}
+	switch g := strings.ToLower(strings.TrimSpace(v.Get("genre"))); g {
+	case genreFiction, genreNonfiction:
+		f.Genre = g
+	}
+	return f
+}
+
+// active reports whether any filter is set.
+func (f searchFilters) active() bool {
+	return f.Format != "" || f.Language != "" || f.YearFrom > 0 || f.YearTo > 0 || f.Genre != ""
+}
+
+// queriesReadarr reports whether the search should look up the Readarr
+// instance for the format.
+func (f searchFilters) queriesReadarr(format string) bool {
+	return f.Format == "" || f.Format == format
+}
+
+// openLibraryQuery adds the year range to an Open Library search using its
+// field syntax.
+func (f searchFilters) openLibraryQuery(q string) string {
+	if f.YearFrom == 0 && f.YearTo == 0 {
+		return q
+	}
+	from, to := "*", "*"
+	if f.YearFrom > 0 {
+		from = strconv.Itoa(f.YearFrom)
+	}
+	if f.YearTo > 0 {
+		to = strconv.Itoa(f.YearTo)
+	}
+	return q + " first_publish_year:[" + from + " TO " + to + "]"
+}
+
+// openLibraryLanguages is the language list for the Open Library search.
+func (f searchFilters) openLibraryLanguages() []string {
+	if f.Language == "" {
+		return nil
+	}
+	return []string{f.Language}
+}
+
+// keep reports whether si passes the filters.
+func (f searchFilters) keep(si searchItem) bool {
+	switch si.Formats() {
+	case "ebook", "audiobook":
+		if f.Format != "" && si.Formats() != f.Format {
+			return false
+		}
+	}
+	if y := si.FirstPublishYear; y > 0 && (f.YearFrom > 0 && y < f.YearFrom || f.YearTo > 0 && y > f.YearTo) {
+		return false
+	}
+	if f.Language != "" && len(si.Languages) > 0 && !searchLanguageMatches(si.Languages, f.Language) {
+		return false
+	}
+	if g := bookGenre(si.Subjects); f.Genre != "" && g != "" && g != f.Genre {
+		return false
+	}
+	return true
+}
+
+// apply drops the items that fail the filters and returns how many it
+// dropped.
+func (f searchFilters) apply(items []searchItem) ([]searchItem, int) {
+	if !f.active() {
+		return items, 0
+	}
+	kept := items[:0]
+	for _, si := range items {
+		if f.keep(si) {
+			kept = append(kept, si)
+		}
+	}
+	return kept, len(items) - len(kept)
+}
+
+// searchLanguageMatches reports whether any of langs, given as MARC codes
+// or English names ("eng", "English"), is code.
+func searchLanguageMatches(langs []string, code string) bool {
+	for _, l := range langs {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l == code {
+			return true
+		}
+		for _, o := range discoveryLanguageOptions {
+			if o.Code == code && strings.EqualFold(o.Label, l) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nonfictionSubjects mark a book as non-fiction when none of its subjects
+// say fiction.
+var nonfictionSubjects = []string{"biography", "history", "memoir", "science", "self-help", "business", "cooking"}
+
+// bookGenre classifies a book as fiction or non-fiction from its subjects,
+// or returns "" when the subjects don't say.
+func bookGenre(subjects []string) string {
+	fiction := false
+	for _, s := range subjects {
+		s = strings.ToLower(s)
+		switch {
+		case strings.Contains(s, "nonfiction"), strings.Contains(s, "non-fiction"):
+			return genreNonfiction
+		case strings.Contains(s, "fiction"), strings.Contains(s, "novel"), strings.Contains(s, "fantasy"):
+			fiction = true
+		}
+	}
+	if fiction {
+		return genreFiction
+	}
+	for _, s := range subjects {
+		s = strings.ToLower(s)
+		for _, nf := range nonfictionSubjects {
+			if strings.Contains(s, nf) {
+				return genreNonfiction
+			}
+		}
+	}
+	return ""
+}
+
+// readarrLanguages lists the edition languages of a Readarr lookup result.
+func readarrLanguages(b providers.LookupBook) []string {
+	var out []string
+	for _, e := range b.Editions {
+		m, _ := e.(map[string]any)
+		if l, _ := m["language"].(string); strings.TrimSpace(l) != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
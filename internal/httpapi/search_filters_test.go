@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -416,3 +417,91 @@ func TestSearchUIFiltersBadReadarrResultsAndNormalizesCover(t *testing.T) {
 		t.Fatalf("expected proxied cover url in body: %s", body)
 	}
 }
+
+func TestParseSearchFilters(t *testing.T) {
+	f := parseSearchFilters(url.Values{"format": {"Audiobook"}, "language": {"ENG"}, "year_from": {"2010"}, "year_to": {"1990"}, "genre": {"nonfiction"}})
+	if f != (searchFilters{Format: "audiobook", Language: "eng", YearFrom: 1990, YearTo: 2010, Genre: genreNonfiction}) {
+		t.Fatalf("filters = %+v", f)
+	}
+	if f := parseSearchFilters(url.Values{"format": {"vinyl"}, "language": {"xx"}, "year_from": {"99"}}); f.active() {
+		t.Fatalf("unknown values should be ignored: %+v", f)
+	}
+	if got := (searchFilters{YearFrom: 2000}).openLibraryQuery("dune"); got != "dune first_publish_year:[2000 TO *]" {
+		t.Fatalf("openLibraryQuery = %q", got)
+	}
+}
+
+func TestSearchFiltersKeep(t *testing.T) {
+	item := func(year int, langs, subjects []string, ebook bool) searchItem {
+		si := searchItem{BookItem: providers.BookItem{Title: "Dune", FirstPublishYear: year, Languages: langs, Subjects: subjects}}
+		if ebook {
+			si.ProviderEbookPayload = "{}"
+		}
+		return si
+	}
+	f := searchFilters{Format: "audiobook", Language: "fre", YearFrom: 1960, YearTo: 1970, Genre: genreFiction}
+	for _, tc := range []struct {
+		name string
+		si   searchItem
+		want bool
+	}{
+		{"matching", item(1965, []string{"French"}, []string{"Science fiction"}, false), true},
+		{"unknown metadata", item(0, nil, nil, false), true},
+		{"wrong format", item(1965, nil, nil, true), false},
+		{"too new", item(1984, nil, nil, false), false},
+		{"wrong language", item(1965, []string{"eng"}, nil, false), false},
+		{"non-fiction", item(1965, nil, []string{"Biography"}, false), false},
+	} {
+		if got := f.keep(tc.si); got != tc.want {
+			t.Errorf("%s: keep = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSearchUIAppliesFilters(t *testing.T) {
+	var lookups int
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[
+			{"title":"Dune","foreignBookId":"fb-1","foreignEditionId":"fe-1","author":{"name":"Frank Herbert"},"releaseDate":"1965-08-01T00:00:00Z","genres":["Science Fiction"]},
+			{"title":"Dune Reimagined","foreignBookId":"fb-2","foreignEditionId":"fe-2","author":{"name":"Someone"},"releaseDate":"2021-01-01T00:00:00Z"}
+		]`)
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	cfg.Readarr.Audiobooks.BaseURL = readarr.URL
+	cfg.Readarr.Audiobooks.APIKey = "test-key"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	var olQuery string
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		olQuery = r.URL.Query().Get("q") + "|" + r.URL.Query().Get("language")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"docs":[]}`)),
+			Header:     make(http.Header),
+		}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ui/search?q=dune&format=ebook&language=eng&year_to=2000", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if !strings.Contains(body, "Frank Herbert") || strings.Contains(body, "Dune Reimagined") || !strings.Contains(body, "1 hidden by filters") {
+		t.Fatalf("filters not applied: %s", body)
+	}
+	if lookups != 1 {
+		t.Fatalf("Readarr lookups = %d, want only the eBook instance", lookups)
+	}
+	if olQuery != "dune first_publish_year:[* TO 2000]|eng" {
+		t.Fatalf("Open Library query = %q", olQuery)
+	}
+}
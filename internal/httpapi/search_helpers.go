@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 
 	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
@@ -51,16 +52,6 @@ var blockedSearchTitleSnippets = []string{
 	"all-in-one",
 }
 
-func dedupeKey(b providers.BookItem) string {
-	if s := strings.TrimSpace(strings.ToUpper(b.ASIN)); s != "" {
-		return "ASIN:" + s
-	}
-	if s := strings.TrimSpace(strings.ToUpper(b.ISBN13)); s != "" {
-		return "ISBN13:" + s
-	}
-	return titleAuthorKey(b)
-}
-
 // titleAuthorKey returns the title/author dedupe key for a book, or "" when
 // the title is empty. It is used to match items across sources that carry
 // different identifiers (e.g. Readarr lookups without ISBNs vs OpenLibrary).
@@ -146,3 +137,25 @@ func isRenderableSearchBook(title string, extras ...string) bool {
 	}
 	return true
 }
+
+// readarrReleaseYear returns the year of a lookup result's release date
+// ("2021-05-04T00:00:00Z"), or 0 when it is missing.
+func readarrReleaseYear(b providers.LookupBook) int {
+	if len(b.ReleaseDate) < 4 {
+		return 0
+	}
+	y, err := strconv.Atoi(b.ReleaseDate[:4])
+	if err != nil || y < 1000 {
+		return 0
+	}
+	return y
+}
+
+// readarrRating returns the average rating out of 5 from a lookup result's
+// ratings object ({"votes": n, "value": 4.2}), or 0 when it has none.
+func readarrRating(b providers.LookupBook) float64 {
+	if v, ok := b.Ratings["value"].(float64); ok && v > 0 {
+		return v
+	}
+	return 0
+}
@@ -7,17 +7,18 @@ import (
 )
 
 func TestDedupeKey(t *testing.T) {
+	dd := newSearchDeduper("")
 	b := providers.BookItem{ASIN: "B012345678", Title: " The Book ", Authors: []string{"Alice"}}
-	if k := dedupeKey(b); k != "ASIN:B012345678" {
+	if k := dd.keys(searchItem{BookItem: b})[0]; k != "ASIN:B012345678" {
 		t.Fatalf("key=%s", k)
 	}
 	b.ASIN = ""
 	b.ISBN13 = "9781234567897"
-	if k := dedupeKey(b); k != "ISBN13:9781234567897" {
+	if k := dd.keys(searchItem{BookItem: b})[0]; k != "ISBN13:9781234567897" {
 		t.Fatalf("key=%s", k)
 	}
 	b.ISBN13 = ""
-	if k := dedupeKey(b); k != "TA:the book:alice" {
+	if k := dd.keys(searchItem{BookItem: b})[0]; k != "TA:the book:alice" {
 		t.Fatalf("key=%s", k)
 	}
 }
@@ -59,3 +60,23 @@ func TestPickDiscoveryBooksEnforcesMinYearStrictly(t *testing.T) {
 		t.Fatalf("expected strict min-year filter to return empty, got %+v", strictEmpty)
 	}
 }
+
+func TestSearchItemFormats(t *testing.T) {
+	cases := []struct {
+		ebook, audio, want string
+	}{
+		{"", "", ""},
+		{`{"title":"Dune"}`, "", "ebook"},
+		{"", `{"title":"Dune"}`, "audiobook"},
+		{`{"title":"Dune"}`, `{"title":"Dune"}`, "both"},
+	}
+	for _, c := range cases {
+		si := searchItem{ProviderEbookPayload: c.ebook, ProviderAudiobookPayload: c.audio}
+		if got := si.Formats(); got != c.want {
+			t.Fatalf("Formats(%q, %q) = %q, want %q", c.ebook, c.audio, got, c.want)
+		}
+		if si.EbookAddable() != (c.want == "ebook" || c.want == "both") || si.AudiobookAddable() != (c.want == "audiobook" || c.want == "both") {
+			t.Fatalf("addable flags wrong for %q", c.want)
+		}
+	}
+}
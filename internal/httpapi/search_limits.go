@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// minSearchTimeout keeps a mistyped search.timeout from cutting off every
+// source before it can answer.
+const minSearchTimeout = time.Second
+
+// searchTimeout is the deadline for one search across every source, or 0
+// when search.timeout is unset or not a duration.
+func (s *Server) searchTimeout() time.Duration {
+	v := strings.TrimSpace(s.settings.Get().Search.Timeout)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	if d < minSearchTimeout {
+		return minSearchTimeout
+	}
+	return d
+}
+
+// searchPageSize is how many results a paged source (Open Library, Amazon)
+// returns per page: the page size, or a smaller configured limit.
+func (s *Server) searchPageSize(provider string, pageSize int) int {
+	if n := s.settings.Get().Search.ResultLimit(provider); n > 0 && n < pageSize {
+		return n
+	}
+	return pageSize
+}
+
+// readarrSearchPage keeps the lookup results worth rendering and, when
+// search.result_limits caps the provider, only those on page.
+func (s *Server) readarrSearchPage(provider string, list []providers.LookupBook, page int) []providers.LookupBook {
+	out := make([]providers.LookupBook, 0, len(list))
+	for _, b := range list {
+		if isRenderableSearchBook(b.Title, b.Disambiguation) {
+			out = append(out, b)
+		}
+	}
+	limit := s.settings.Get().Search.ResultLimit(provider)
+	if limit <= 0 {
+		return out
+	}
+	return pageOf(out, page, limit)
+}
+
+// pageOf returns the 1-based page of list with limit items per page.
+func pageOf[T any](list []T, page, limit int) []T {
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	if start >= len(list) {
+		return nil
+	}
+	return list[start:min(start+limit, len(list))]
+}
+
+type searchLimitRow struct {
+	Name  string
+	Label string
+	Limit int
+}
+
+// searchLimitRows pairs each search source with its result limit for the
+// settings page.
+func (s *Server) searchLimitRows() []searchLimitRow {
+	cfg := s.settings.Get().Search
+	out := make([]searchLimitRow, len(searchProviderLabels))
+	for i, p := range searchProviderLabels {
+		out[i] = searchLimitRow{Name: p.Name, Label: p.Label, Limit: cfg.ResultLimit(p.Name)}
+	}
+	return out
+}
+
+// searchLimitsFromForm reads the settings form's search_limit_<source>
+// inputs, keeping only positive limits.
+func searchLimitsFromForm(r *http.Request) map[string]int {
+	var out map[string]int
+	for _, p := range searchProviderLabels {
+		n, err := strconv.Atoi(strings.TrimSpace(r.FormValue("search_limit_" + p.Name)))
+		if err != nil || n <= 0 {
+			continue
+		}
+		if out == nil {
+			out = map[string]int{}
+		}
+		out[p.Name] = n
+	}
+	return out
+}
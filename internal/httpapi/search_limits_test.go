@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchReadarrResultLimitPages(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var books []string
+		for i := 1; i <= 5; i++ {
+			books = append(books, fmt.Sprintf(`{"title":"Dune Part %d","foreignBookId":"fb-%d"}`, i, i))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(books, ",") + "]"))
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = readarr.URL, "k"
+	cfg.Search.DisabledProviders = []string{providerOpenLibrary, providerAmazon}
+	cfg.Search.ResultLimits = map[string]int{providerReadarrEbooks: 2}
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	router := s.Router()
+	search := func(page int) string {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/ui/search?q=dune&page=%d", page), nil)
+		req.AddCookie(makeCookie(t, s, "user", false))
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("page %d = %d", page, rec.Code)
+		}
+		return rec.Body.String()
+	}
+	for page, want := range map[int][]int{1: {1, 2}, 2: {3, 4}, 3: {5}, 4: nil} {
+		body := search(page)
+		for i := 1; i <= 5; i++ {
+			has := strings.Contains(body, fmt.Sprintf("Dune Part %d", i))
+			wanted := false
+			for _, w := range want {
+				wanted = wanted || w == i
+			}
+			if has != wanted {
+				t.Errorf("page %d shows part %d = %v, want %v", page, i, has, wanted)
+			}
+		}
+	}
+}
+
+func TestSearchTimeoutSkipsSlowSources(t *testing.T) {
+	release := make(chan struct{})
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer readarr.Close()
+	defer close(release)
+
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = readarr.URL, "k"
+	cfg.Search.DisabledProviders = []string{providerOpenLibrary, providerAmazon}
+	cfg.Search.Timeout = "1s"
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ui/search?q=dune", nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	s.Router().ServeHTTP(rec, req)
+	if took := time.Since(started); took > 5*time.Second {
+		t.Fatalf("search took %s despite the timeout", took)
+	}
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "took too long") {
+		t.Fatalf("search = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSearchTimeoutSetting(t *testing.T) {
+	s := newServerForTest(t)
+	for in, want := range map[string]time.Duration{"": 0, "soon": 0, "-5s": 0, "10ms": minSearchTimeout, "8s": 8 * time.Second} {
+		cfg := *s.settings.Get()
+		cfg.Search.Timeout = in
+		if err := s.settings.Update(&cfg); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.searchTimeout(); got != want {
+			t.Errorf("searchTimeout(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
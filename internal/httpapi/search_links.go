@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// bookLinkSearch is how a search for a pasted Amazon, Goodreads, or Google
+// Books link is run: a Readarr lookup term, a keyword query for OpenLibrary
+// and Amazon, and the book the link itself describes when it could be
+// resolved directly (Google Books volumes).
+type bookLinkSearch struct {
+	ref     providers.BookRef
+	readarr string
+	query   string
+	book    *providers.BookItem
+}
+
+// resolveBookLink turns a parsed link into search terms. Google Books volume
+// ids are looked up first since no other provider understands them, unless
+// offline mode rules out the public internet.
+func (s *Server) resolveBookLink(ctx context.Context, ref providers.BookRef) bookLinkSearch {
+	ls := bookLinkSearch{ref: ref}
+	if ref.GoogleVolumeID != "" && !s.offline() {
+		gctx, cancel := context.WithTimeout(ctx, 6*time.Second)
+		book, err := providers.NewGoogleBooks().Volume(gctx, ref.GoogleVolumeID)
+		cancel()
+		if err == nil {
+			ls.book = book
+			if ls.ref.ISBN13 == "" {
+				ls.ref.ISBN13 = book.ISBN13
+			}
+			if ls.ref.ISBN10 == "" {
+				ls.ref.ISBN10 = book.ISBN10
+			}
+		}
+	}
+	ls.readarr = ls.ref.ReadarrTerm()
+	switch {
+	case ls.ref.ISBN13 != "":
+		ls.query = ls.ref.ISBN13
+	case ls.ref.ISBN10 != "":
+		ls.query = ls.ref.ISBN10
+	case ls.ref.GoodreadsID != "":
+		ls.query = "id_goodreads:" + ls.ref.GoodreadsID
+	case ls.ref.ASIN != "":
+		ls.query = ls.ref.ASIN
+	case ls.book != nil:
+		ls.query = strings.TrimSpace(ls.book.Title + " " + authorsText(ls.book.Authors))
+	}
+	if ls.readarr == "" {
+		ls.readarr = ls.query
+	}
+	return ls
+}
+
+// amazonMarketplace keeps Amazon fallbacks on the store the link came from,
+// or the configured store for links from elsewhere.
+func (ls bookLinkSearch) amazonMarketplace(fallback string) string {
+	if ls.ref.Source == "amazon" {
+		if market := providers.NormalizeAmazonMarketplace(ls.ref.Host); market != "" {
+			return market
+		}
+	}
+	return fallback
+}
+
+// amazonMarketplace is the Amazon store searches fall back to.
+func (s *Server) amazonMarketplace() string {
+	if cfg := s.settings.Get(); cfg != nil {
+		if market := providers.NormalizeAmazonMarketplace(cfg.AmazonPublic.Marketplace); market != "" {
+			return market
+		}
+	}
+	return "www.amazon.com"
+}
+
+// prefill copies the link's identifiers onto a lone result so the request
+// form carries them even when the provider left them blank, and offers the
+// resolved volume when no provider matched.
+func (ls bookLinkSearch) prefill(items []searchItem) []searchItem {
+	if len(items) == 0 && ls.book != nil {
+		return []searchItem{{BookItem: *ls.book}}
+	}
+	if len(items) != 1 {
+		return items
+	}
+	it := &items[0].BookItem
+	if it.ISBN13 == "" {
+		it.ISBN13 = ls.ref.ISBN13
+	}
+	if it.ISBN10 == "" {
+		it.ISBN10 = ls.ref.ISBN10
+	}
+	if it.ASIN == "" {
+		it.ASIN = ls.ref.ASIN
+	}
+	return items
+}
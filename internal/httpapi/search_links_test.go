@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+func TestSearchUIResolvesPastedGoodreadsLink(t *testing.T) {
+	var mu sync.Mutex
+	var readarrTerm, olQuery string
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/book/lookup" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		readarrTerm = r.URL.Query().Get("term")
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[{"title":"Dune","foreignBookId":"fb-1","foreignEditionId":"fe-1","author":{"name":"Frank Herbert"}}]`)
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL = readarr.URL
+	cfg.Readarr.Ebooks.APIKey = "test-key"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	t.Cleanup(providers.TestDisableOLRateLimiter())
+	installOpenLibraryTestClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		// Discovery shelves warm in the background through the same
+		// client; only the search call matters here.
+		if r.URL.Path == "/search.json" {
+			mu.Lock()
+			olQuery = r.URL.Query().Get("q")
+			mu.Unlock()
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"docs":[],"numFound":0}`)),
+			Header:     make(http.Header),
+		}, nil
+	}))
+
+	q := url.QueryEscape("https://www.goodreads.com/book/show/44767458-dune")
+	req := httptest.NewRequest(http.MethodGet, "/ui/search?q="+q, nil)
+	req.AddCookie(makeCookie(t, s, "user", false))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", rec.Code, rec.Body.String())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if readarrTerm != "goodreads:44767458" {
+		t.Fatalf("readarr term = %q", readarrTerm)
+	}
+	if olQuery != "id_goodreads:44767458" {
+		t.Fatalf("openlibrary query = %q", olQuery)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "From your Goodreads link") || !strings.Contains(body, `name="title" value="Dune"`) {
+		t.Fatalf("expected resolved result in body: %s", body)
+	}
+}
+
+func TestBookLinkPrefillFillsLoneResult(t *testing.T) {
+	ls := bookLinkSearch{}
+	ls.ref.ISBN13, ls.ref.ASIN = "9780441013593", "0441013597"
+	items := ls.prefill([]searchItem{{}})
+	if items[0].ISBN13 != "9780441013593" || items[0].ASIN != "0441013597" {
+		t.Fatalf("lone result not prefilled: %+v", items[0].BookItem)
+	}
+	items = ls.prefill([]searchItem{{}, {}})
+	if items[0].ISBN13 != "" {
+		t.Fatal("ambiguous results should not be prefilled")
+	}
+}
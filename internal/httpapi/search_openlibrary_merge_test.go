@@ -20,7 +20,8 @@ func TestMergeOpenLibraryFillsGapsOnMatchingItem(t *testing.T) {
 		ProviderEbookPayload: `{"title":"Project Hail Mary"}`,
 	}
 	items := []searchItem{readarrItem}
-	idx := map[string]int{dedupeKey(readarrItem.BookItem): 0}
+	dd := newSearchDeduper("")
+	dd.add(readarrItem, 0)
 
 	ol := providers.BookItem{
 		Title:       "Project Hail Mary",
@@ -29,7 +30,7 @@ func TestMergeOpenLibraryFillsGapsOnMatchingItem(t *testing.T) {
 		ISBN10:      "0593135202",
 		CoverMedium: "https://covers.openlibrary.org/b/id/1-M.jpg",
 	}
-	merged := mergeOpenLibrarySearchItems(items, idx, []providers.BookItem{ol})
+	merged := mergeOpenLibrarySearchItems(items, dd, []providers.BookItem{ol})
 
 	if len(merged) != 1 {
 		t.Fatalf("expected 1 merged item, got %d", len(merged))
@@ -54,7 +55,7 @@ func TestMergeOpenLibraryFillsGapsOnMatchingItem(t *testing.T) {
 // registered so later identifier-less duplicates still merge.
 func TestMergeOpenLibraryAppendsUnknownBooks(t *testing.T) {
 	items := []searchItem{}
-	idx := map[string]int{}
+	dd := newSearchDeduper("")
 
 	ol := providers.BookItem{
 		Title:       "Obscure Tome",
@@ -62,7 +63,7 @@ func TestMergeOpenLibraryAppendsUnknownBooks(t *testing.T) {
 		ISBN13:      "9780316274147",
 		CoverMedium: "https://covers.openlibrary.org/b/id/2-M.jpg",
 	}
-	merged := mergeOpenLibrarySearchItems(items, idx, []providers.BookItem{ol})
+	merged := mergeOpenLibrarySearchItems(items, dd, []providers.BookItem{ol})
 
 	if len(merged) != 1 {
 		t.Fatalf("expected appended OL item, got %d items", len(merged))
@@ -70,16 +71,16 @@ func TestMergeOpenLibraryAppendsUnknownBooks(t *testing.T) {
 	if merged[0].Title != "Obscure Tome" || merged[0].DetailsPayload == "" {
 		t.Fatalf("expected OL search item with details payload, got %+v", merged[0])
 	}
-	if _, ok := idx["ISBN13:9780316274147"]; !ok {
+	if _, ok := dd.idx["ISBN13:9780316274147"]; !ok {
 		t.Fatalf("expected isbn13 key registered")
 	}
-	if _, ok := idx[titleAuthorKey(ol)]; !ok {
+	if _, ok := dd.idx[titleAuthorKey(ol)]; !ok {
 		t.Fatalf("expected title/author key registered")
 	}
 
 	// A second copy of the same book (no matter the key it matches by) merges
 	// rather than duplicating.
-	merged = mergeOpenLibrarySearchItems(merged, idx, []providers.BookItem{{
+	merged = mergeOpenLibrarySearchItems(merged, dd, []providers.BookItem{{
 		Title:   "Obscure Tome",
 		Authors: []string{"Nobody Famous"},
 	}})
@@ -90,7 +91,7 @@ func TestMergeOpenLibraryAppendsUnknownBooks(t *testing.T) {
 
 // Junk titles (compilations, study guides) are filtered from OL results too.
 func TestMergeOpenLibrarySkipsNonRenderableTitles(t *testing.T) {
-	merged := mergeOpenLibrarySearchItems(nil, map[string]int{}, []providers.BookItem{{
+	merged := mergeOpenLibrarySearchItems(nil, newSearchDeduper(""), []providers.BookItem{{
 		Title:   "Project Hail Mary Study Guide",
 		Authors: []string{"Test Author"},
 		ISBN13:  "9780000000001",
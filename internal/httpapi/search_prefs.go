@@ -0,0 +1,179 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// bookSearchProviders are the book sources in their built-in priority: the
+// "source" result order lists Readarr's results first, then Amazon's, then
+// Open Library's.
+var bookSearchProviders = []string{providerReadarrEbooks, providerReadarrAudiobooks, providerAmazon, providerOpenLibrary}
+
+// searchPrefs is which book sources one search queries and whose results
+// are listed first.
+type searchPrefs struct {
+	// order holds every book source, highest priority first.
+	order []string
+	// off are the sources the user turned off for themselves.
+	off map[string]bool
+}
+
+// parseSearchProviders reads a comma-separated source list such as
+// "openlibrary,readarr_ebooks,-amazon": sources in priority order, those
+// prefixed with "-" turned off. Unknown names are dropped and sources left
+// out follow in fallback's order.
+func parseSearchProviders(v string, fallback []string) searchPrefs {
+	p := searchPrefs{off: map[string]bool{}}
+	for _, f := range strings.Split(v, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		name := strings.TrimPrefix(f, "-")
+		if !slices.Contains(bookSearchProviders, name) || slices.Contains(p.order, name) {
+			continue
+		}
+		p.order = append(p.order, name)
+		if name != f {
+			p.off[name] = true
+		}
+	}
+	for _, name := range append(slices.Clone(fallback), bookSearchProviders...) {
+		if slices.Contains(bookSearchProviders, name) && !slices.Contains(p.order, name) {
+			p.order = append(p.order, name)
+		}
+	}
+	return p
+}
+
+// String formats the preferences the way parseSearchProviders reads them.
+func (p searchPrefs) String() string {
+	out := make([]string, len(p.order))
+	for i, name := range p.order {
+		out[i] = name
+		if p.off[name] {
+			out[i] = "-" + name
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// rank is name's position in the priority order.
+func (p searchPrefs) rank(name string) int {
+	if i := slices.Index(p.order, name); i >= 0 {
+		return i
+	}
+	return len(p.order)
+}
+
+// adminSearchOrder is the admin's default source priority.
+func (s *Server) adminSearchOrder() []string {
+	if cfg := s.settings.Get(); cfg != nil {
+		return parseSearchProviders(strings.Join(cfg.Search.ProviderOrder, ","), nil).order
+	}
+	return bookSearchProviders
+}
+
+// searchPrefsFor returns username's source preferences, or the admin
+// defaults when they have none.
+func (s *Server) searchPrefsFor(ctx context.Context, username string) searchPrefs {
+	var saved string
+	if username != "" {
+		if u, err := s.db.GetUserByUsername(ctx, username); err == nil {
+			saved = u.SearchProviders
+		}
+	}
+	return parseSearchProviders(saved, s.adminSearchOrder())
+}
+
+// requestSearchPrefs returns the signed-in user's source preferences.
+func (s *Server) requestSearchPrefs(r *http.Request) searchPrefs {
+	ses, _ := r.Context().Value(ctxUser).(*session)
+	if ses == nil {
+		return parseSearchProviders("", s.adminSearchOrder())
+	}
+	return s.searchPrefsFor(r.Context(), ses.Username)
+}
+
+// searchEnabled reports whether a search with prefs queries the source: the
+// admin has not turned it off and neither has the user.
+func (s *Server) searchEnabled(prefs searchPrefs, name string) bool {
+	return s.providerEnabled(name) && !prefs.off[name]
+}
+
+// orderBySource lists items by the best-ranked source that returned each,
+// keeping the order within a source.
+func orderBySource(items []searchItem, prefs searchPrefs) {
+	best := func(si searchItem) int {
+		r := len(prefs.order)
+		for _, src := range si.Sources {
+			r = min(r, prefs.rank(src))
+		}
+		return r
+	}
+	slices.SortStableFunc(items, func(a, b searchItem) int { return best(a) - best(b) })
+}
+
+// searchSourceView is one row of the account page's search source list.
+type searchSourceView struct {
+	Name     string
+	Label    string
+	Position int
+	On       bool
+	// AdminOff sources are turned off for everyone on the Providers page.
+	AdminOff bool
+}
+
+// searchSourceViews lists the book sources in prefs order for the account
+// page.
+func (s *Server) searchSourceViews(prefs searchPrefs) []searchSourceView {
+	out := make([]searchSourceView, 0, len(prefs.order))
+	for i, name := range prefs.order {
+		v := searchSourceView{Name: name, Label: name, Position: i + 1, On: !prefs.off[name], AdminOff: !s.providerEnabled(name)}
+		for _, l := range searchProviderLabels {
+			if l.Name == name {
+				v.Label = l.Label
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// handleAccountSearchSave stores the signed-in user's search sources from
+// the account page: a position and an on/off box per source, or a reset
+// back to the admin defaults.
+func (u *ui) handleAccountSearchSave(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ses, _ := r.Context().Value(ctxUser).(*session)
+		if ses == nil {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		acct, err := s.db.GetUserByUsername(r.Context(), ses.Username)
+		if err != nil || acct == nil {
+			http.Error(w, "account not found", http.StatusNotFound)
+			return
+		}
+		_ = r.ParseForm()
+		saved := ""
+		if r.FormValue("reset") == "" {
+			prefs := searchPrefs{order: slices.Clone(bookSearchProviders), off: map[string]bool{}}
+			pos := func(name string) int {
+				n, _ := strconv.Atoi(r.FormValue("search_pos_" + name))
+				return n
+			}
+			slices.SortStableFunc(prefs.order, func(a, b string) int { return pos(a) - pos(b) })
+			for _, name := range prefs.order {
+				prefs.off[name] = r.FormValue("search_on_"+name) != "on"
+			}
+			saved = prefs.String()
+		}
+		if err := s.db.SetUserSearchProviders(r.Context(), acct.ID, saved); err != nil {
+			http.Error(w, "failed to save settings", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/account?saved=1", http.StatusFound)
+	}
+}
@@ -0,0 +1,103 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+func TestParseSearchProviders(t *testing.T) {
+	p := parseSearchProviders(" OpenLibrary, -amazon, bogus, openlibrary", []string{providerReadarrAudiobooks})
+	if got := p.String(); got != "openlibrary,-amazon,readarr_audiobooks,readarr_ebooks" {
+		t.Fatalf("String() = %q", got)
+	}
+	if !p.off[providerAmazon] || p.off[providerOpenLibrary] {
+		t.Fatalf("off = %v", p.off)
+	}
+	if got := parseSearchProviders("", nil).String(); got != strings.Join(bookSearchProviders, ",") {
+		t.Fatalf("empty list = %q, want the built-in order", got)
+	}
+}
+
+func TestOrderBySource(t *testing.T) {
+	item := func(title string, sources ...string) searchItem {
+		return searchItem{BookItem: providers.BookItem{Title: title}, Sources: sources}
+	}
+	items := []searchItem{
+		item("readarr", providerReadarrEbooks),
+		item("both", providerReadarrAudiobooks, providerOpenLibrary),
+		item("ol", providerOpenLibrary),
+		item("amazon", providerAmazon),
+	}
+	orderBySource(items, parseSearchProviders("openlibrary,amazon", nil))
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Title)
+	}
+	if strings.Join(got, ",") != "both,ol,amazon,readarr" {
+		t.Fatalf("order = %v", got)
+	}
+}
+
+func TestAccountSearchSourcesSaveAndFallback(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "alice", "hash", false, false); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	cfg := s.settings.Get()
+	cfg.Search.ProviderOrder = []string{providerOpenLibrary}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	if got := s.searchPrefsFor(ctx, "alice").order[0]; got != providerOpenLibrary {
+		t.Fatalf("default first source = %q, want the admin's", got)
+	}
+
+	h := s.Router()
+	cookie := makeCookie(t, s, "alice", false)
+	post := func(form url.Values) {
+		req := httptest.NewRequest(http.MethodPost, "/account/search/save", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("save: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	post(url.Values{
+		"search_pos_readarr_ebooks":     {"1"},
+		"search_pos_readarr_audiobooks": {"2"},
+		"search_pos_amazon":             {"4"},
+		"search_pos_openlibrary":        {"3"},
+		"search_on_readarr_ebooks":      {"on"},
+		"search_on_readarr_audiobooks":  {"on"},
+	})
+	u, _ := s.db.GetUserByUsername(ctx, "alice")
+	if u.SearchProviders != "readarr_ebooks,readarr_audiobooks,-openlibrary,-amazon" {
+		t.Fatalf("saved = %q", u.SearchProviders)
+	}
+	prefs := s.searchPrefsFor(ctx, "alice")
+	if s.searchEnabled(prefs, providerOpenLibrary) || !s.searchEnabled(prefs, providerReadarrEbooks) {
+		t.Fatalf("prefs not applied: %+v", prefs)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/account", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if body := rec.Body.String(); !strings.Contains(body, "Search sources") || !strings.Contains(body, "Reset to defaults") {
+		t.Fatalf("account page lacks the search sources: %s", body)
+	}
+
+	post(url.Values{"reset": {"1"}})
+	if got := s.searchPrefsFor(ctx, "alice").String(); got != "openlibrary,readarr_ebooks,readarr_audiobooks,amazon" {
+		t.Fatalf("after reset = %q, want the admin defaults", got)
+	}
+}
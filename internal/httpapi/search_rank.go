@@ -0,0 +1,286 @@
+package httpapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+// Search dedupe strategies; see config.SearchConfig.
+const (
+	dedupeIdentifier    = "identifier"
+	dedupeTitleAuthor   = "title_author"
+	dedupeForeignBookID = "foreign_book_id"
+	dedupeISBN          = "isbn"
+)
+
+// Search result orders; see config.SearchConfig.
+const (
+	orderSource    = "source"
+	orderRelevance = "relevance"
+	orderYear      = "year"
+	orderRating    = "rating"
+)
+
+// searchSettings returns the configured dedupe strategy and order, falling
+// back to the defaults for blank or unknown values.
+func (s *Server) searchSettings() (dedupe, order string) {
+	var sc config.SearchConfig
+	if cfg := s.settings.Get(); cfg != nil {
+		sc = cfg.Search
+	}
+	return normalizeSearchDedupe(sc.Dedupe), normalizeSearchOrder(sc.Order)
+}
+
+func normalizeSearchDedupe(v string) string {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case dedupeTitleAuthor, dedupeForeignBookID, dedupeISBN:
+		return v
+	}
+	return dedupeIdentifier
+}
+
+func normalizeSearchOrder(v string) string {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case orderRelevance, orderYear, orderRating:
+		return v
+	}
+	return orderSource
+}
+
+// searchDeduper indexes merged search results by every key the dedupe
+// strategy treats as "the same book", so a later result matching any of them
+// merges into the earlier one.
+type searchDeduper struct {
+	strategy string
+	idx      map[string]int
+}
+
+func newSearchDeduper(strategy string) *searchDeduper {
+	return &searchDeduper{strategy: normalizeSearchDedupe(strategy), idx: map[string]int{}}
+}
+
+// keys lists the keys identifying si, most specific first. An item with no
+// keys cannot be deduplicated and is dropped by callers.
+func (d *searchDeduper) keys(si searchItem) []string {
+	b := si.BookItem
+	asin := strings.TrimSpace(strings.ToUpper(b.ASIN))
+	isbn13 := strings.TrimSpace(strings.ToUpper(b.ISBN13))
+	isbn10 := strings.TrimSpace(strings.ToUpper(b.ISBN10))
+	var out []string
+	add := func(prefix, v string) {
+		if v != "" {
+			out = append(out, prefix+v)
+		}
+	}
+	switch d.strategy {
+	case dedupeTitleAuthor:
+		add("", titleAuthorKey(b))
+	case dedupeISBN:
+		add("ISBN13:", isbn13)
+		add("ISBN10:", isbn10)
+		add("ASIN:", asin)
+		if len(out) == 0 {
+			add("", titleAuthorKey(b))
+		}
+	case dedupeForeignBookID:
+		add("FB:", strings.TrimSpace(si.ForeignBookID))
+		add("ASIN:", asin)
+		add("ISBN13:", isbn13)
+		if si.ForeignBookID == "" {
+			add("", titleAuthorKey(b))
+		}
+	default:
+		add("ASIN:", asin)
+		add("ISBN13:", isbn13)
+		add("", titleAuthorKey(b))
+	}
+	return out
+}
+
+// aliases are extra keys registered for an item but never used to look it
+// up: a Readarr work keeps its title/author so identifier-less results from
+// other sources can still fill its gaps under foreign_book_id.
+func (d *searchDeduper) aliases(si searchItem) []string {
+	if d.strategy == dedupeForeignBookID && si.ForeignBookID != "" {
+		if ta := titleAuthorKey(si.BookItem); ta != "" {
+			return []string{ta}
+		}
+	}
+	return nil
+}
+
+// find returns the index of an earlier item si duplicates.
+func (d *searchDeduper) find(si searchItem) (int, bool) {
+	for _, k := range d.keys(si) {
+		if i, ok := d.idx[k]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// add registers si at index i under its keys and aliases, leaving keys
+// already claimed by earlier items alone. It reports false when si has no
+// keys at all.
+func (d *searchDeduper) add(si searchItem, i int) bool {
+	keys := d.keys(si)
+	if len(keys) == 0 {
+		return false
+	}
+	for _, k := range append(keys, d.aliases(si)...) {
+		if _, exists := d.idx[k]; !exists {
+			d.idx[k] = i
+		}
+	}
+	return true
+}
+
+// searchScorePart is one named contribution to a result's relevance score.
+type searchScorePart struct {
+	Name   string
+	Points float64
+}
+
+// searchScore is a result's relevance for a query, with the breakdown kept
+// so it can be shown in debug output.
+type searchScore struct {
+	Total float64
+	Parts []searchScorePart
+}
+
+func (sc *searchScore) add(name string, points float64) {
+	if points == 0 {
+		return
+	}
+	sc.Parts = append(sc.Parts, searchScorePart{Name: name, Points: points})
+	sc.Total += points
+}
+
+func (sc searchScore) String() string {
+	parts := make([]string, 0, len(sc.Parts))
+	for _, p := range sc.Parts {
+		parts = append(parts, p.Name+" "+strconv.FormatFloat(p.Points, 'f', 1, 64))
+	}
+	return fmt.Sprintf("%.1f (%s)", sc.Total, strings.Join(parts, ", "))
+}
+
+// Relevance weights. An exact identifier hit outranks any text match; title
+// words matter more than author words; having a Readarr payload (directly
+// requestable) and a cover break ties between otherwise similar results.
+const (
+	scoreIdentifier  = 100
+	scoreExactTitle  = 30
+	scoreTitleWords  = 40
+	scoreAuthorWords = 20
+	scoreReadarr     = 8
+	scoreCover       = 2
+	scorePerStar     = 1
+)
+
+// scoreSearchItem rates how well si answers query q.
+func scoreSearchItem(q string, si searchItem) searchScore {
+	var sc searchScore
+	compact := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(q)))
+	if compact != "" {
+		for _, id := range []string{si.ISBN13, si.ISBN10, si.ASIN} {
+			if id != "" && strings.EqualFold(strings.TrimSpace(id), compact) {
+				sc.add("identifier", scoreIdentifier)
+				break
+			}
+		}
+	}
+
+	qWords := searchWords(q)
+	if len(qWords) > 0 {
+		titleWords := searchWords(si.Title)
+		if strings.Join(titleWords, " ") == strings.Join(qWords, " ") {
+			sc.add("exact title", scoreExactTitle)
+		}
+		sc.add("title", scoreTitleWords*wordCoverage(qWords, titleWords))
+		sc.add("author", scoreAuthorWords*wordCoverage(qWords, searchWords(strings.Join(si.Authors, " "))))
+	}
+	if si.ProviderEbookPayload != "" || si.ProviderAudiobookPayload != "" {
+		sc.add("readarr", scoreReadarr)
+	}
+	if si.CoverMedium != "" || si.CoverSmall != "" {
+		sc.add("cover", scoreCover)
+	}
+	if si.Rating > 0 {
+		sc.add("rating", scorePerStar*si.Rating)
+	}
+	return sc
+}
+
+// searchWords lower-cases s and splits it into letter/digit words.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// wordCoverage is the fraction of query words found among words.
+func wordCoverage(query, words []string) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	have := make(map[string]bool, len(words))
+	for _, w := range words {
+		have[w] = true
+	}
+	hits := 0
+	for _, w := range query {
+		if have[w] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(query))
+}
+
+// rankSearchItems orders items for display. Sorting is stable, so ties keep
+// the source order; "source" leaves the list untouched. When debug is set,
+// each item's RankDebug explains its key and score.
+func rankSearchItems(items []searchItem, q, order, strategy string, debug bool) {
+	scores := make([]searchScore, len(items))
+	for i := range items {
+		scores[i] = scoreSearchItem(q, items[i])
+		if debug {
+			keys := newSearchDeduper(strategy).keys(items[i])
+			items[i].RankDebug = fmt.Sprintf("keys=%s score=%s year=%d rating=%.2f", strings.Join(keys, "|"), scores[i], items[i].FirstPublishYear, items[i].Rating)
+		}
+	}
+	if order == orderSource {
+		return
+	}
+	perm := make([]int, len(items))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(a, b int) bool {
+		x, y := items[perm[a]], items[perm[b]]
+		switch order {
+		case orderYear:
+			if x.FirstPublishYear != y.FirstPublishYear {
+				// Unknown years sort last.
+				if x.FirstPublishYear == 0 || y.FirstPublishYear == 0 {
+					return y.FirstPublishYear == 0
+				}
+				return x.FirstPublishYear > y.FirstPublishYear
+			}
+		case orderRating:
+			if x.Rating != y.Rating {
+				return x.Rating > y.Rating
+			}
+		}
+		return scores[perm[a]].Total > scores[perm[b]].Total
+	})
+	sorted := make([]searchItem, len(items))
+	for i, p := range perm {
+		sorted[i] = items[p]
+	}
+	copy(items, sorted)
+}
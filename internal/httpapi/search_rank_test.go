@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+// mergeAll runs items through a deduper the way handleSearch does and
+// returns how many distinct results remain.
+func mergeAll(strategy string, items ...searchItem) int {
+	dd := newSearchDeduper(strategy)
+	n := 0
+	for _, si := range items {
+		if _, ok := dd.find(si); ok {
+			continue
+		}
+		if dd.add(si, n) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSearchDedupeStrategies(t *testing.T) {
+	duneA := searchItem{BookItem: providers.BookItem{Title: "Dune", Authors: []string{"Frank Herbert"}}, ForeignBookID: "1"}
+	duneB := searchItem{BookItem: providers.BookItem{Title: "Dune", Authors: []string{"Frank Herbert"}}, ForeignBookID: "2"}
+	paperback := searchItem{BookItem: providers.BookItem{Title: "Dune", Authors: []string{"Frank Herbert"}, ISBN13: "9780441013593"}}
+	hardcover := searchItem{BookItem: providers.BookItem{Title: "Dune", Authors: []string{"Frank Herbert"}, ISBN13: "9780593099322"}}
+	kindle := searchItem{BookItem: providers.BookItem{Title: "Dune", Authors: []string{"Frank Herbert"}, ASIN: "B00B7NPRY8"}}
+
+	cases := []struct {
+		strategy string
+		items    []searchItem
+		want     int
+	}{
+		{"", []searchItem{duneA, duneB}, 1},
+		{"identifier", []searchItem{paperback, hardcover, kindle}, 1},
+		{"title_author", []searchItem{paperback, kindle}, 1},
+		{"foreign_book_id", []searchItem{duneA, duneB}, 2},
+		// Identifier-less results still merge into the first Readarr work.
+		{"foreign_book_id", []searchItem{duneA, duneB, {BookItem: providers.BookItem{Title: "Dune", Authors: []string{"Frank Herbert"}}}}, 2},
+		{"isbn", []searchItem{paperback, hardcover, kindle}, 3},
+		{"isbn", []searchItem{paperback, paperback}, 1},
+	}
+	for _, c := range cases {
+		if got := mergeAll(c.strategy, c.items...); got != c.want {
+			t.Errorf("strategy %q: %d results, want %d", c.strategy, got, c.want)
+		}
+	}
+
+	if got := mergeAll("", searchItem{}); got != 0 {
+		t.Fatalf("keyless item should be dropped, got %d", got)
+	}
+	if normalizeSearchDedupe(" ISBN ") != dedupeISBN || normalizeSearchDedupe("bogus") != dedupeIdentifier {
+		t.Fatal("unexpected dedupe normalization")
+	}
+	if normalizeSearchOrder("Year") != orderYear || normalizeSearchOrder("") != orderSource {
+		t.Fatal("unexpected order normalization")
+	}
+}
+
+func TestScoreSearchItem(t *testing.T) {
+	exact := searchItem{BookItem: providers.BookItem{Title: "Project Hail Mary", Authors: []string{"Andy Weir"}, CoverMedium: "c"}}
+	partial := searchItem{BookItem: providers.BookItem{Title: "Mary Poppins", Authors: []string{"P. L. Travers"}}}
+	if a, b := scoreSearchItem("project hail mary", exact), scoreSearchItem("project hail mary", partial); a.Total <= b.Total {
+		t.Fatalf("exact title should outrank partial: %v vs %v", a, b)
+	}
+
+	byISBN := searchItem{BookItem: providers.BookItem{Title: "Dune", ISBN13: "9780441013593"}}
+	sc := scoreSearchItem("978-0-441-01359-3", byISBN)
+	if sc.Total < scoreIdentifier || !strings.Contains(sc.String(), "identifier 100.0") {
+		t.Fatalf("identifier hit not scored: %v", sc)
+	}
+
+	author := scoreSearchItem("weir", exact)
+	if len(author.Parts) == 0 || author.Parts[0].Name != "author" || author.Parts[0].Points != scoreAuthorWords {
+		t.Fatalf("author match not scored: %+v", author)
+	}
+
+	requestable := exact
+	requestable.ProviderEbookPayload = "{}"
+	requestable.Rating = 4.5
+	if got := scoreSearchItem("project hail mary", requestable).Total - scoreSearchItem("project hail mary", exact).Total; got != scoreReadarr+4.5 {
+		t.Fatalf("readarr and rating bonus = %v", got)
+	}
+}
+
+func TestRankSearchItems(t *testing.T) {
+	items := func() []searchItem {
+		return []searchItem{
+			{BookItem: providers.BookItem{Title: "Dune Messiah", FirstPublishYear: 1969, Rating: 3.9}},
+			{BookItem: providers.BookItem{Title: "Dune", FirstPublishYear: 1965, Rating: 4.3}},
+			{BookItem: providers.BookItem{Title: "Dune: Part Two Screenplay"}},
+			{BookItem: providers.BookItem{Title: "Hunters of Dune", FirstPublishYear: 2006, Rating: 3.6}},
+		}
+	}
+	titles := func(list []searchItem) string {
+		var out []string
+		for _, it := range list {
+			out = append(out, it.Title)
+		}
+		return strings.Join(out, "|")
+	}
+
+	cases := map[string]string{
+		orderSource:    "Dune Messiah|Dune|Dune: Part Two Screenplay|Hunters of Dune",
+		orderYear:      "Hunters of Dune|Dune Messiah|Dune|Dune: Part Two Screenplay",
+		orderRating:    "Dune|Dune Messiah|Hunters of Dune|Dune: Part Two Screenplay",
+		orderRelevance: "Dune|Dune Messiah|Hunters of Dune|Dune: Part Two Screenplay",
+	}
+	for order, want := range cases {
+		list := items()
+		rankSearchItems(list, "dune", order, dedupeIdentifier, false)
+		if got := titles(list); got != want {
+			t.Errorf("order %s: got %s want %s", order, got, want)
+		}
+		if list[0].RankDebug != "" {
+			t.Errorf("order %s: debug text without debug mode", order)
+		}
+	}
+
+	list := items()
+	rankSearchItems(list, "dune", orderSource, dedupeIdentifier, true)
+	if !strings.Contains(list[1].RankDebug, "keys=TA:dune:") || !strings.Contains(list[1].RankDebug, "exact title") {
+		t.Fatalf("debug text = %q", list[1].RankDebug)
+	}
+}
@@ -0,0 +1,170 @@
+package httpapi
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// revealableSecrets are the secrets Scriptorum generates for other systems
+// to present back to it. The settings pages show them masked; an admin who
+// needs to copy one reveals it on demand, so they never sit in rendered
+// HTML.
+var revealableSecrets = map[string]func(*config.Config) string{
+	"import_list_token":       func(c *config.Config) string { return c.ImportList.Token },
+	"inbound_webhook_token":   func(c *config.Config) string { return c.InboundWebhook.Token },
+	"inbound_webhook_secret":  func(c *config.Config) string { return c.InboundWebhook.Secret },
+	"homeassistant_api_token": func(c *config.Config) string { return c.HomeAssistant.APIToken },
+	"scim_token":              func(c *config.Config) string { return c.SCIM.Token },
+	"guest_link": func(c *config.Config) string {
+		if c.GuestRequests.Token == "" {
+			return ""
+		}
+		return strings.TrimRight(c.ServerURL, "/") + "/guest/" + c.GuestRequests.Token
+	},
+}
+
+// apiRevealSecret returns one generated secret for the settings pages' Reveal
+// buttons. Each reveal is audited.
+func (s *Server) apiRevealSecret() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		get, ok := revealableSecrets[name]
+		if !ok {
+			http.Error(w, "unknown secret", http.StatusNotFound)
+			return
+		}
+		v := get(s.settings.Get())
+		if v == "" {
+			http.Error(w, "not generated yet; save the settings first", http.StatusNotFound)
+			return
+		}
+		actor := r.Context().Value(ctxUser).(*session).Username
+		s.auditLog(r.Context(), actor, "settings.secret_revealed", nil, name)
+		writeJSON(w, map[string]string{"value": v}, http.StatusOK)
+	}
+}
+
+// apiRotateReadarrKey replaces the API key of the ebooks or audiobooks
+// instance, but only after Readarr accepts the new key, so a typo cannot
+// break approvals. The key itself is never echoed or logged.
+func (s *Server) apiRotateReadarrKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		kind := r.FormValue("kind")
+		key := strings.TrimSpace(r.FormValue("api_key"))
+		cur := *s.settings.Get()
+		var target *config.ReadarrInstance
+		switch kind {
+		case "ebooks":
+			target = &cur.Readarr.Ebooks
+		case "audiobooks":
+			target = &cur.Readarr.Audiobooks
+		default:
+			http.Error(w, "missing kind", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(target.BaseURL) == "" {
+			http.Error(w, "Save the Base URL before rotating the API key.", http.StatusBadRequest)
+			return
+		}
+		if key == "" {
+			http.Error(w, "Enter the new API key first.", http.StatusBadRequest)
+			return
+		}
+		if key == target.APIKey {
+			http.Error(w, "That is the key already saved.", http.StatusBadRequest)
+			return
+		}
+		next := *target
+		next.APIKey = key
+		inst := s.toProviderInstance(next)
+		ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+		defer cancel()
+		ra := providers.NewReadarrWithDB(inst, s.db.SQL())
+		if err := ra.PingLookup(ctx); err != nil {
+			http.Error(w, readarrProbeMessage(err)+" The saved key was kept.", http.StatusBadGateway)
+			return
+		}
+		st, err := ra.SystemStatus(ctx)
+		if err != nil {
+			http.Error(w, readarrProbeMessage(err)+" The saved key was kept.", http.StatusBadGateway)
+			return
+		}
+		target.APIKey = key
+		if err := s.settings.Update(&cur); err != nil {
+			http.Error(w, "saving settings failed", http.StatusInternalServerError)
+			return
+		}
+		s.clearReadarrAuthFailure(inst)
+		go s.resumeParkedApprovals(context.Background())
+		actor := r.Context().Value(ctxUser).(*session).Username
+		s.auditLog(r.Context(), actor, "settings.readarr_key_rotated", nil, s.readarrInstanceLabel(inst))
+		writeJSON(w, map[string]string{"app_name": st.AppName, "version": st.Version}, http.StatusOK)
+	}
+}
+
+// secretQueryParams are query parameters that carry credentials, such as
+// the import list's ?apikey=.
+var secretQueryParams = []string{"apikey", "api_key", "token"}
+
+// secretPathPrefixes are routes whose last path segment is a credential.
+var secretPathPrefixes = []string{"/guest/", "/approve/", "/api/v1/approve/"}
+
+// redactRequestURI masks credentials in a request URI for logging.
+func redactRequestURI(uri string) string {
+	path, query, hasQuery := strings.Cut(uri, "?")
+	for _, p := range secretPathPrefixes {
+		if strings.HasPrefix(path, p) && len(path) > len(p) {
+			path = p + "***"
+			break
+		}
+	}
+	if !hasQuery {
+		return path
+	}
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return path + "?***"
+	}
+	masked := false
+	for k := range q {
+		for _, secret := range secretQueryParams {
+			if strings.EqualFold(k, secret) {
+				q[k] = []string{"***"}
+				masked = true
+			}
+		}
+	}
+	if !masked {
+		return path + "?" + query
+	}
+	return path + "?" + strings.ReplaceAll(q.Encode(), "=%2A%2A%2A", "=***")
+}
+
+// redactingLogFormatter is chi's request log with credentials in the URI
+// masked.
+type redactingLogFormatter struct{ middleware.LogFormatter }
+
+func (f redactingLogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	if masked := redactRequestURI(r.RequestURI); masked != r.RequestURI {
+		r = r.WithContext(r.Context())
+		r.RequestURI = masked
+	}
+	return f.LogFormatter.NewLogEntry(r)
+}
+
+// requestLogger logs each request like middleware.Logger, minus secrets.
+var requestLogger = middleware.RequestLogger(redactingLogFormatter{&middleware.DefaultLogFormatter{
+	Logger:  log.New(os.Stdout, "", log.LstdFlags),
+	NoColor: false,
+}})
@@ -0,0 +1,144 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSettingsPagesNeverRenderSecrets(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = "http://readarr:8787", "readarr-key-1234"
+	cfg.ImportList.Enabled, cfg.ImportList.Token = true, "import-list-token-5678"
+	cfg.InboundWebhook.Secret = "inbound-secret-9012"
+	cfg.Notifications.Ntfy.Password = "ntfy-password-3456"
+	cfg.Notifications.SMTP.Password = "smtp-password-7890"
+	cfg.Notifications.Discord.WebhookURL = "https://discord.com/api/webhooks/1/discord-token-1357"
+	cfg.HomeAssistant.APIToken = "ha-api-token-2468"
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	router := s.Router()
+	secrets := []string{"readarr-key-1234", "import-list-token-5678", "inbound-secret-9012", "ntfy-password-3456", "smtp-password-7890", "discord-token-1357", "ha-api-token-2468"}
+	for _, page := range []string{"/settings", "/notifications"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, page, nil)
+		req.AddCookie(makeCookie(t, s, "admin", true))
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d", page, rec.Code)
+		}
+		for _, secret := range secrets {
+			if strings.Contains(rec.Body.String(), secret) {
+				t.Fatalf("%s renders %q", page, secret)
+			}
+		}
+	}
+
+	// Saving the notifications form with the masked fields left blank keeps
+	// the saved secrets.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/notifications/save", strings.NewReader(url.Values{"discord_enabled": {"on"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	router.ServeHTTP(rec, req)
+	if got := s.settings.Get().Notifications; got.Discord.WebhookURL != cfg.Notifications.Discord.WebhookURL || got.Ntfy.Password != cfg.Notifications.Ntfy.Password || got.SMTP.Password != cfg.Notifications.SMTP.Password {
+		t.Fatalf("blank fields cleared saved secrets: %d %+v", rec.Code, got)
+	}
+
+	reveal := func(name string, admin bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/settings/secrets/"+name+"/reveal", nil)
+		req.AddCookie(makeCookie(t, s, "admin", admin))
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := reveal("import_list_token", false); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin reveal = %d", rec.Code)
+	}
+	rec = reveal("import_list_token", true)
+	var out map[string]string
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &out) != nil || out["value"] != "import-list-token-5678" {
+		t.Fatalf("reveal = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := reveal("readarr_key", true); rec.Code != http.StatusNotFound {
+		t.Fatalf("Readarr keys must not be revealable: %d", rec.Code)
+	}
+}
+
+func TestRotateReadarrKeySavesOnlyAcceptedKeys(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "new-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/system/status" {
+			_, _ = w.Write([]byte(`{"appName":"Readarr","version":"0.4.0"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = readarr.URL, "old-key"
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	router := s.Router()
+	rotate := func(key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/readarr/rotate-key", strings.NewReader(url.Values{"kind": {"ebooks"}, "api_key": {key}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(makeCookie(t, s, "admin", true))
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := rotate("typo-key")
+	if rec.Code != http.StatusBadGateway || s.settings.Get().Readarr.Ebooks.APIKey != "old-key" {
+		t.Fatalf("rejected key: %d %s, saved %q", rec.Code, rec.Body.String(), s.settings.Get().Readarr.Ebooks.APIKey)
+	}
+	if strings.Contains(rec.Body.String(), "typo-key") {
+		t.Fatalf("error echoes the key: %s", rec.Body.String())
+	}
+	rec = rotate("new-key")
+	if rec.Code != http.StatusOK || s.settings.Get().Readarr.Ebooks.APIKey != "new-key" {
+		t.Fatalf("accepted key: %d %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "new-key") {
+		t.Fatalf("response echoes the key: %s", rec.Body.String())
+	}
+	events, _ := s.db.ListAuditEvents(t.Context(), 10)
+	found := false
+	for _, e := range events {
+		if e.EventType == "settings.readarr_key_rotated" {
+			found = !strings.Contains(e.Details, "new-key")
+		}
+	}
+	if !found {
+		t.Fatalf("rotation not audited: %+v", events)
+	}
+}
+
+func TestRedactRequestURI(t *testing.T) {
+	for in, want := range map[string]string{
+		"/importlist/ebook/list.json?apikey=abc&x=1": "/importlist/ebook/list.json?apikey=***&x=1",
+		"/guest/abc123":              "/guest/***",
+		"/approve/tok":               "/approve/***",
+		"/search?q=dune&page=2":      "/search?q=dune&page=2",
+		"/api/v1/requests?Token=abc": "/api/v1/requests?Token=***",
+		"/api/v1/approve/tok?x=1":    "/api/v1/approve/***?x=1",
+		"/settings":                  "/settings",
+	} {
+		if got := redactRequestURI(in); got != want {
+			t.Errorf("redactRequestURI(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
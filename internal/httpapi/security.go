@@ -17,11 +17,11 @@ func (s *Server) securityHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Content Security Policy - restrictive policy that allows HTMX and inline styles
 		csp := "default-src 'self'; " +
-			// htmx is vendored under /static; inline scripts/handlers in templates
+			// htmx and all CSS are embedded under /static; inline scripts/handlers in templates
 			// still require 'unsafe-inline' (removing it needs a nonce refactor).
 			"script-src 'self' 'unsafe-inline'; " +
-			"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com https://cdn.jsdelivr.net; " +
-			"font-src 'self' https://fonts.gstatic.com https://cdn.jsdelivr.net; " +
+			"style-src 'self' 'unsafe-inline'; " +
+			"font-src 'self'; " +
 			"img-src 'self' data: https:; " +
 			"connect-src 'self'; " +
 			"form-action 'self'; " +
@@ -137,6 +137,11 @@ func (s *Server) csrfProtection(next http.Handler) http.Handler {
 			strings.HasPrefix(r.URL.Path, "/login") ||
 			strings.HasPrefix(r.URL.Path, "/logout") ||
 			strings.HasPrefix(r.URL.Path, "/static/") ||
+			strings.HasPrefix(r.URL.Path, "/api/homeassistant/") ||
+			strings.HasPrefix(r.URL.Path, "/scim/") ||
+			strings.HasPrefix(r.URL.Path, "/api/v1/approve/") ||
+			r.URL.Path == "/api/v1/webhooks/inbound" ||
+			r.URL.Path == "/api/v1/discord/interactions" ||
 			r.URL.Path == "/healthz" {
 			next.ServeHTTP(w, r)
 			return
@@ -257,6 +262,10 @@ func (s *Server) runSecurityJanitor(ctx context.Context) {
 			// Largest rate-limit window is 15 minutes; drop anything older.
 			s.rateLimiter.cleanup(15 * time.Minute)
 			s.pruneAuditEvents(ctx)
+			s.pruneIdempotencyKeys(ctx)
+			s.pruneNotificationLog(ctx)
+			s.pruneSessions(ctx)
+			s.pruneUsageStats(ctx)
 		}
 	}
 }
@@ -290,6 +299,10 @@ func (s *Server) rateLimiting(next http.Handler) http.Handler {
 			// Stricter limits for auth endpoints
 			maxRequests = 10
 			window = 15 * time.Minute
+		case strings.HasPrefix(r.URL.Path, "/guest/") && r.Method == http.MethodPost:
+			// The guest form is open to anyone holding the link
+			maxRequests = 10
+			window = 15 * time.Minute
 		case strings.HasPrefix(r.URL.Path, "/api/"):
 			// API endpoints
 			maxRequests = 100
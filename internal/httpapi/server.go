@@ -4,10 +4,10 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
-	"io/fs"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,6 +15,7 @@ import (
 
 	"gitea.knapp/jacoknapp/scriptorum/internal/config"
 	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/graphql"
 	"gitea.knapp/jacoknapp/scriptorum/internal/settings"
 )
 
@@ -63,6 +64,49 @@ type Server struct {
 	disableDiscoveryAsync  bool // For testing purposes
 	approvalTokens         map[string]approvalTokenData
 	tokenMutex             sync.RWMutex
+	adminAlertsMu          sync.RWMutex
+	adminAlerts            map[string]adminAlert
+	// readarrAuthFailed maps a Readarr base URL to the API key it rejected.
+	// Approvals for that instance are parked until the key changes or a
+	// probe succeeds again.
+	readarrAuthMu     sync.Mutex
+	readarrAuthFailed map[string]string
+	parkedApprovals   []approvalJob
+	// readarrHealth tracks reachability per Readarr base URL for the
+	// periodic health check.
+	readarrHealthMu sync.Mutex
+	readarrHealth   map[string]*readarrHealthState
+
+	// providerStats feeds the provider health page, keyed by search source;
+	// providerQuota counts metered providers' calls per UTC day.
+	providerStatsMu sync.Mutex
+	providerStats   map[string]*providerStats
+	providerQuota   map[string]*providerQuota
+
+	graphqlOnce sync.Once
+	graphql     *graphql.Schema
+	// dbMaintenanceMu is held while the database is being optimized;
+	// dbMaintenanceLast is the most recent run, shown on /settings.
+	dbMaintenanceMu   sync.Mutex
+	dbMaintenanceLast atomic.Pointer[dbMaintenanceView]
+	// hydrationMu is held during a bulk hydration of requests without a
+	// selection payload; hydrationLast is the most recent run, shown on
+	// /settings. hydrationDelay spaces the Readarr lookups.
+	hydrationMu    sync.Mutex
+	hydrationLast  atomic.Pointer[hydrationRunView]
+	hydrationDelay time.Duration
+	// notifyBatch holds chat notifications by kind during the batch window;
+	// notifyLimiter paces sends per provider target.
+	notifyBatchMu sync.Mutex
+	notifyBatch   map[string][]notifyEvent
+	notifyLimiter sendLimiter
+	// haKick asks the Home Assistant publish loop to push states now.
+	haKick chan struct{}
+	// vapidMu guards creating the Web Push key pair on first use.
+	vapidMu sync.Mutex
+	// requestLocks is held per request from claiming its version until the
+	// change is written.
+	requestLocks requestLocks
 }
 
 type catalogMatchCacheEntry struct {
@@ -93,9 +137,14 @@ func NewServer(cfg *config.Config, database *db.DB, cfgPath string) *Server {
 		approvalQueueInterval: approvalQueueInterval,
 		approvalQueueJitter:   approvalQueueJitter,
 		approvalQueueMaxWait:  approvalQueueMaxWait,
+		hydrationDelay:        legacyHydrationDelay,
 		// Buffer up to 256 pending search dispatch jobs so button clicks never block.
 		searchDispatchQueue: make(chan searchDispatchJob, 256),
+		haKick:              make(chan struct{}, 1),
 	}
+	database.SetStatusCheck(func(from, to string) error {
+		return config.CheckStatusTransition(s.settings.Get().Requests.Statuses, from, to)
+	})
 	_ = s.initOIDC()
 	return s
 }
@@ -107,8 +156,9 @@ func (s *Server) Router() http.Handler {
 	r.Use(s.securityHeaders)
 	r.Use(s.dynamicNoStore)
 	r.Use(s.rateLimiting)
-	r.Use(middleware.RequestID, middleware.RealIP, middleware.Logger, middleware.Recoverer)
+	r.Use(middleware.RequestID, middleware.RealIP, requestLogger, middleware.Recoverer)
 	r.Use(s.withUser)
+	r.Use(s.withGroup)
 	if !s.disableCSRF {
 		r.Use(s.csrfProtection)
 	}
@@ -140,8 +190,11 @@ func (s *Server) Router() http.Handler {
 		http.Redirect(w, r, "/login", http.StatusFound)
 	})
 	// Serve embedded static files under /static from the web/static folder
-	sub, _ := fs.Sub(staticFS, "web/static")
-	r.Handle("/static/*", s.staticCache(http.StripPrefix("/static/", http.FileServer(http.FS(sub)))))
+	r.Handle("/static/*", assets)
+	// The manifest and service worker make the site installable; the
+	// worker must live at the root to control every page.
+	r.Get("/manifest.webmanifest", s.handleManifest)
+	r.Get("/sw.js", s.handleServiceWorker)
 
 	// Protect the main application routes behind authentication by default.
 	// Individual sub-mounts may still apply admin middleware where needed.
@@ -168,20 +221,43 @@ func (s *Server) Router() http.Handler {
 		s.mountSearch(rt)
 		s.mountSettings(rt)
 		s.mountNotifications(rt)
+		s.mountGraphQL(rt)
+		s.mountWebPush(rt)
 	})
 
 	// Public approval token endpoint for one-click approvals from notifications
 	// Keep this public so emailed/ntfy approval links can be used without login.
 	r.Get("/approve/{token}", s.handleApprovalToken)
+	// Clients that can post without a browser (ntfy http actions) redeem the
+	// same tokens here and get JSON back.
+	r.Post("/api/v1/approve/{token}", s.apiApprovalToken)
 
-	return r
-}
+	// Read-only wishlist links are public by design; minting and revoking
+	// them still requires login.
+	s.mountWishlistShares(r)
 
-func (s *Server) staticCache(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Cache-Control", "public, max-age=86400")
-		next.ServeHTTP(w, r)
-	})
+	// The uploaded logo appears on the login page, so it is public too.
+	r.Get(brandingLogoPath, s.handleBrandingLogo)
+
+	// Home Assistant authenticates with its own bearer token, not a session.
+	s.mountHomeAssistant(r)
+
+	// Identity providers provision accounts with the SCIM token.
+	s.mountSCIM(r)
+
+	// External tools file requests with the inbound webhook token.
+	s.mountInboundWebhook(r)
+
+	// Other Readarr instances read approvals with the import list token.
+	s.mountImportList(r)
+
+	// Guests without an account request books through the guest link.
+	s.mountGuest(r)
+
+	// Discord signs button presses with the application key.
+	s.mountDiscordInteractions(r)
+
+	return r
 }
 
 func (s *Server) setupGate(next http.Handler) http.Handler {
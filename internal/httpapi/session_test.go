@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionsRememberIdleAndRevoke(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Sessions.IdleMinutes = 30
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	ctx := context.Background()
+	hash, _ := s.hashPassword("correct-horse", s.settings.Get().Auth.Salt)
+	if _, err := s.db.CreateUser(ctx, "dora", hash, false, false); err != nil {
+		t.Fatal(err)
+	}
+	login := func(remember bool) *http.Cookie {
+		form := url.Values{"username": {"dora"}, "password": {"correct-horse"}}
+		if remember {
+			form.Set("remember", "on")
+		}
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == s.sessionCookieName() {
+				return c
+			}
+		}
+		t.Fatalf("no session cookie: %d %s", rec.Code, rec.Header().Get("Location"))
+		return nil
+	}
+	get := func(path string, c *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(c)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	signedIn := func(c *http.Cookie) bool { return get("/account", c).Code == http.StatusOK }
+
+	plain := login(false)
+	if plain.MaxAge != 0 {
+		t.Fatalf("plain cookie MaxAge = %d, want a browser-session cookie", plain.MaxAge)
+	}
+	remembered := login(true)
+	if remembered.MaxAge < int((29 * 24 * time.Hour).Seconds()) {
+		t.Fatalf("remembered cookie MaxAge = %d", remembered.MaxAge)
+	}
+	if !signedIn(plain) || !signedIn(remembered) {
+		t.Fatal("fresh sessions rejected")
+	}
+
+	// An hour without requests ends the plain session but not the remembered one.
+	sessions, _ := s.db.ListUserSessions(ctx, "dora")
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %+v", sessions)
+	}
+	for _, ss := range sessions {
+		_ = s.db.TouchSession(ctx, ss.ID, time.Now().Add(-time.Hour))
+	}
+	if signedIn(plain) {
+		t.Fatal("idle session still valid")
+	}
+	if !signedIn(remembered) {
+		t.Fatal("remembered session hit the idle timeout")
+	}
+
+	// Signing out other devices revokes them server-side.
+	other := login(false)
+	req := httptest.NewRequest(http.MethodPost, "/account/sessions/revoke", nil)
+	req.AddCookie(remembered)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Location") != "/account?signed_out=1" {
+		t.Fatalf("revoke: %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	if signedIn(other) || !signedIn(remembered) {
+		t.Fatal("wrong sessions revoked")
+	}
+
+	// Logging out ends the session even if the cookie is replayed.
+	get("/logout", remembered)
+	if signedIn(remembered) {
+		t.Fatal("logged-out session still valid")
+	}
+}
+
+func TestSessionCookieWithoutRecordIsRejected(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	// A validly signed cookie with no session id, as issued before
+	// sessions were recorded, must not bypass revocation.
+	b, _ := json.Marshal(&session{Username: "admin", Name: "A", Admin: true, Exp: 9999999999})
+	val := base64.RawURLEncoding.EncodeToString(b) + "." + base64.RawURLEncoding.EncodeToString(s.sign(b))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/requests", nil)
+	req.AddCookie(&http.Cookie{Name: s.sessionCookieName(), Value: val})
+	if s.getSession(req) != nil {
+		t.Fatal("sid-less cookie was accepted")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("sid-less cookie: %d", rec.Code)
+	}
+}
@@ -1,11 +1,14 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"gitea.knapp/jacoknapp/scriptorum/internal/config"
 	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
@@ -72,6 +75,8 @@ func (s *Server) mountSettings(r chi.Router) {
 		"toJSON":        func(v any) string { b, _ := json.Marshal(v); return string(b) },
 		"authorsText":   authorsText,
 		"truncateChars": truncateChars,
+		"brand":         s.branding,
+		"asset":         assetURL,
 	}
 	u := &settingsUI{tpl: template.Must(template.New("tpl").Funcs(funcMap).ParseFS(tplFS, "web/templates/*.html"))}
 	r.Group(func(rt chi.Router) {
@@ -80,7 +85,19 @@ func (s *Server) mountSettings(r chi.Router) {
 		rt.Post("/settings/save", u.handleSettingsSave(s))
 		rt.Get("/api/readarr/profiles", s.apiReadarrProfiles())
 		rt.Post("/api/readarr/profiles", s.apiReadarrProfiles())
+		rt.Get("/api/readarr/folders", s.apiReadarrRootFolders())
+		rt.Post("/api/readarr/folders", s.apiReadarrRootFolders())
+		rt.Post("/api/settings/test-readarr", s.apiSettingsTestReadarr())
+		rt.Post("/api/settings/secrets/{name}/reveal", s.apiRevealSecret())
+		rt.Post("/api/readarr/rotate-key", s.apiRotateReadarrKey())
 		rt.Post("/api/readarr/sync", s.apiReadarrSync())
+		rt.Get("/api/readarr/cache/export", s.apiReadarrCacheExport())
+		rt.Post("/api/readarr/cache/import", s.apiReadarrCacheImport())
+		rt.Post("/api/db/optimize", s.apiDBOptimize())
+		rt.Post("/api/requests/hydrate-missing", s.apiHydrateLegacyRequests())
+		rt.Post("/api/settings/logo", s.apiUploadLogo())
+		rt.Delete("/api/settings/logo", s.apiUploadLogo())
+		rt.Get("/api/readarr/status", s.apiReadarrStatus())
 		// Debug endpoint for admins to inspect runtime Readarr settings (API keys redacted)
 		rt.Get("/api/readarr/debug", s.apiReadarrDebug())
 	})
@@ -98,8 +115,22 @@ func (u *settingsUI) handleSettings(s *Server) http.HandlerFunc {
 			"IsAdmin":                    true,
 			"CSRFToken":                  s.getCSRFToken(r),
 			"ReadarrSync":                s.readarrSyncView(),
+			"DBMaintenance":              s.dbMaintenanceSettingsView(),
+			"LegacyHydration":            s.legacyHydrationSettingsView(),
+			"EnrichmentChain":            strings.Join(config.NormalizeEnrichmentChain(cfg.Requests.EnrichmentChain), ", "),
+			"RequestFields":              formatRequestFields(config.NormalizeRequestFields(cfg.Requests.ExtraFields)),
+			"RequestStatuses":            formatRequestStatuses(config.NormalizeRequestStatuses(cfg.Requests.Statuses)),
+			"RequestTemplates":           requestTemplateViews(cfg.Requests.Templates),
+			"TwoPersonFormats":           twoPersonFormatSet(cfg.Requests.TwoPersonApproval.Formats),
+			"TwoPersonLabels":            strings.Join(cfg.Requests.TwoPersonApproval.Labels, ", "),
+			"SourcePolicies":             s.requestSourcePolicyRows(),
+			"SearchLimits":               s.searchLimitRows(),
 			"DiscoveryLanguageOptions":   discoveryLanguageOptions,
 			"DiscoveryLanguageSelection": discoveryLanguageSelectedMap(cfg.Discovery.Languages),
+			"AmazonMarketplaces":         providers.AmazonMarketplaces(),
+			"AmazonMarketplace":          s.amazonMarketplace(),
+			"SearchProviderOrder":        strings.Join(cfg.Search.ProviderOrder, ","),
+			"ISBNdbQuota":                s.providerQuotaViews(),
 			"Events":                     events,
 		}
 		_ = u.tpl.ExecuteTemplate(w, "settings.html", data)
@@ -116,6 +147,9 @@ func (u *settingsUI) handleSettingsSave(s *Server) http.HandlerFunc {
 		audioKey := preserveSecretField(cur.Readarr.Audiobooks.APIKey, audioBase, r.FormValue("ra_audio_key"))
 		// General
 		cur.Debug = (r.FormValue("debug") == "on")
+		// The public provider settings are only on the form outside offline mode.
+		publicShown := !cur.Offline
+		cur.Offline = r.FormValue("offline") == "on"
 		cur.ServerURL = strings.TrimSpace(r.FormValue("server_url"))
 		cur.Readarr.Ebooks.BaseURL = ebooksBase
 		cur.Readarr.Ebooks.APIKey = ebooksKey
@@ -123,6 +157,36 @@ func (u *settingsUI) handleSettingsSave(s *Server) http.HandlerFunc {
 		cur.Readarr.Audiobooks.BaseURL = audioBase
 		cur.Readarr.Audiobooks.APIKey = audioKey
 		cur.Readarr.Audiobooks.InsecureSkipVerify = (r.FormValue("ra_audio_insecure") == "on")
+		cur.Readarr.Ebooks.Monitor = providers.NormalizeMonitorPolicy(r.FormValue("ra_ebooks_monitor"))
+		cur.Readarr.Ebooks.AddType = providers.NormalizeAddType(r.FormValue("ra_ebooks_add_type"))
+		cur.Readarr.Ebooks.SearchOnAdd = boolPtr(r.FormValue("ra_ebooks_search_on_add") == "on")
+		cur.Readarr.Ebooks.CompatRetry = r.FormValue("ra_ebooks_compat_retry") == "on"
+		cur.Readarr.Audiobooks.Monitor = providers.NormalizeMonitorPolicy(r.FormValue("ra_audio_monitor"))
+		cur.Readarr.Audiobooks.AddType = providers.NormalizeAddType(r.FormValue("ra_audio_add_type"))
+		cur.Readarr.Audiobooks.SearchOnAdd = boolPtr(r.FormValue("ra_audio_search_on_add") == "on")
+		cur.Readarr.Audiobooks.CompatRetry = r.FormValue("ra_audio_compat_retry") == "on"
+		cur.Readarr.Ebooks.Library = libraryConfigFromForm(r, "ra_ebooks", cur.Readarr.Ebooks.Library)
+		cur.Readarr.Audiobooks.Library = libraryConfigFromForm(r, "ra_audio", cur.Readarr.Audiobooks.Library)
+		// Root folders are picked from the instance's list; reject paths the
+		// instance does not have.
+		if _, ok := r.Form["ra_ebooks_root"]; ok {
+			root := strings.TrimSpace(r.FormValue("ra_ebooks_root"))
+			root, err := s.validateRootFolder(r.Context(), s.toProviderInstance(cur.Readarr.Ebooks), root)
+			if err != nil {
+				http.Error(w, "eBooks: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			cur.Readarr.Ebooks.DefaultRootFolderPath = root
+		}
+		if _, ok := r.Form["ra_audio_root"]; ok {
+			root := strings.TrimSpace(r.FormValue("ra_audio_root"))
+			root, err := s.validateRootFolder(r.Context(), s.toProviderInstance(cur.Readarr.Audiobooks), root)
+			if err != nil {
+				http.Error(w, "Audiobooks: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			cur.Readarr.Audiobooks.DefaultRootFolderPath = root
+		}
 		// Save quality profile selections
 		if v := strings.TrimSpace(r.FormValue("ra_ebooks_qp")); v != "" {
 			if i, err := strconv.Atoi(v); err == nil {
@@ -134,6 +198,16 @@ func (u *settingsUI) handleSettingsSave(s *Server) http.HandlerFunc {
 				cur.Readarr.Audiobooks.DefaultQualityProfileID = i
 			}
 		}
+		if v := strings.TrimSpace(r.FormValue("ra_ebooks_admin_qp")); v != "" {
+			if i, err := strconv.Atoi(v); err == nil && i >= 0 {
+				cur.Readarr.Ebooks.AdminQualityProfileID = i
+			}
+		}
+		if v := strings.TrimSpace(r.FormValue("ra_audio_admin_qp")); v != "" {
+			if i, err := strconv.Atoi(v); err == nil && i >= 0 {
+				cur.Readarr.Audiobooks.AdminQualityProfileID = i
+			}
+		}
 
 		// OAuth settings (merged into the settings form)
 		vEnabled := strings.ToLower(strings.TrimSpace(r.FormValue("oauth_enabled")))
@@ -164,7 +238,82 @@ func (u *settingsUI) handleSettingsSave(s *Server) http.HandlerFunc {
 		cur.OAuth.Scopes = parseCSV(r.FormValue("oauth_scopes"))
 		cur.OAuth.UsernameClaim = strings.TrimSpace(r.FormValue("oauth_username_claim"))
 		cur.OAuth.AutoCreateUsers = r.FormValue("oauth_autocreate") == "on"
-		cur.Discovery.Languages = config.NormalizeDiscoveryLanguages(r.Form["discovery_languages"])
+		sessionField := func(name string) int {
+			n, err := strconv.Atoi(strings.TrimSpace(r.FormValue(name)))
+			if err != nil || n < 0 {
+				return 0
+			}
+			return n
+		}
+		cur.Sessions.LifetimeHours = sessionField("session_lifetime_hours")
+		cur.Sessions.RememberDays = sessionField("session_remember_days")
+		cur.Sessions.IdleMinutes = sessionField("session_idle_minutes")
+		cur.SCIM.Enabled = r.FormValue("scim_enabled") == "on"
+		cur.SCIM.AdminGroup = strings.TrimSpace(r.FormValue("scim_admin_group"))
+		if cur.SCIM.Enabled && (cur.SCIM.Token == "" || r.FormValue("scim_regenerate_token") == "on") {
+			if tok, err := randomToken(24); err == nil {
+				cur.SCIM.Token = tok
+			}
+		}
+		cur.Branding.InstanceName = strings.TrimSpace(r.FormValue("instance_name"))
+		cur.Branding.AccentColor = config.NormalizeAccentColor(r.FormValue("accent_color"))
+		if logo := strings.TrimSpace(r.FormValue("logo_url")); logo == "" || strings.HasPrefix(logo, "/") || strings.HasPrefix(logo, "https://") || strings.HasPrefix(logo, "http://") {
+			cur.Branding.LogoURL = logo
+		}
+		cur.Search.Dedupe = normalizeSearchDedupe(r.FormValue("search_dedupe"))
+		cur.Search.Order = normalizeSearchOrder(r.FormValue("search_order"))
+		cur.Search.CoverImages = normalizeCoverImages(r.FormValue("search_cover_images"))
+		if v, ok := r.Form["search_provider_order"]; ok {
+			cur.Search.ProviderOrder = nil
+			if strings.TrimSpace(strings.Join(v, "")) != "" {
+				cur.Search.ProviderOrder = parseSearchProviders(strings.Join(v, ","), nil).order
+			}
+		}
+		if r.Form.Has("search_timeout") {
+			if v := strings.TrimSpace(r.FormValue("search_timeout")); v == "" {
+				cur.Search.Timeout = ""
+			} else if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				cur.Search.Timeout = v
+			}
+		}
+		if r.Form.Has("search_limit_" + providerReadarrEbooks) {
+			cur.Search.ResultLimits = searchLimitsFromForm(r)
+		}
+		if publicShown {
+			cur.Discovery.Languages = config.NormalizeDiscoveryLanguages(r.Form["discovery_languages"])
+			if key := strings.TrimSpace(r.FormValue("isbndb_key")); key != "" {
+				cur.ISBNdb.APIKey = key
+			} else if r.FormValue("isbndb_remove") == "on" {
+				cur.ISBNdb.APIKey = ""
+			}
+			if base := strings.TrimSpace(r.FormValue("isbndb_base_url")); base == "" || strings.HasPrefix(base, "https://") || strings.HasPrefix(base, "http://") {
+				cur.ISBNdb.BaseURL = base
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(r.FormValue("isbndb_daily_limit"))); err == nil && n >= 0 {
+				cur.ISBNdb.DailyLimit = n
+			} else if strings.TrimSpace(r.FormValue("isbndb_daily_limit")) == "" {
+				cur.ISBNdb.DailyLimit = 0
+			}
+		}
+		if base := strings.TrimSpace(r.FormValue("mylar_base_url")); r.Form.Has("mylar_base_url") && (base == "" || strings.HasPrefix(base, "https://") || strings.HasPrefix(base, "http://")) {
+			cur.Mylar.BaseURL = base
+		}
+		if key := strings.TrimSpace(r.FormValue("mylar_key")); key != "" {
+			cur.Mylar.APIKey = key
+		} else if r.FormValue("mylar_remove") == "on" {
+			cur.Mylar.APIKey = ""
+		}
+		if r.Form.Has("magazines_backend") {
+			switch v := r.FormValue("magazines_backend"); v {
+			case "ebooks", "audiobooks":
+				cur.Magazines.Backend = v
+			default:
+				cur.Magazines.Backend = ""
+			}
+		}
+		if r.Form.Has("amazon_marketplace") {
+			cur.AmazonPublic.Marketplace = providers.NormalizeAmazonMarketplace(r.FormValue("amazon_marketplace"))
+		}
 		if v := strings.TrimSpace(r.FormValue("max_pending_per_user")); v != "" {
 			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
 				cur.Requests.MaxPendingPerUser = n
@@ -172,6 +321,105 @@ func (u *settingsUI) handleSettingsSave(s *Server) http.HandlerFunc {
 		} else {
 			cur.Requests.MaxPendingPerUser = 0
 		}
+		cur.Requests.LabelsAsReadarrTags = r.FormValue("labels_as_readarr_tags") == "on"
+		cur.Requests.AskNeedBy = r.FormValue("ask_need_by") == "on"
+		if publicShown {
+			cur.Requests.EnrichmentChain = config.NormalizeEnrichmentChain([]string{r.FormValue("enrichment_chain")})
+		}
+		if r.Form.Has("hydrate_interval") {
+			cur.Requests.HydrateInterval = strings.TrimSpace(r.FormValue("hydrate_interval"))
+		}
+		if r.Form.Has("author_cleanup") {
+			cur.Requests.AuthorCleanup = strings.TrimSpace(r.FormValue("author_cleanup"))
+		}
+		if r.Form.Has("request_currency") {
+			cur.Requests.Currency = strings.TrimSpace(r.FormValue("request_currency"))
+		}
+		if r.Form.Has("two_person_labels") {
+			cur.Requests.TwoPersonApproval.Formats = nil
+			for _, f := range twoPersonFormatChoices {
+				if r.FormValue("two_person_format_"+f) == "on" {
+					cur.Requests.TwoPersonApproval.Formats = append(cur.Requests.TwoPersonApproval.Formats, f)
+				}
+			}
+			if labels, err := normalizeRequestLabels([]string{r.FormValue("two_person_labels")}); err == nil {
+				cur.Requests.TwoPersonApproval.Labels = labels
+			}
+		}
+		if r.Form.Has("source_policy_web") {
+			cur.Requests.SourcePolicies = sourcePoliciesFromForm(r)
+		}
+		cur.InboundWebhook.Enabled = r.FormValue("inbound_webhook_enabled") == "on"
+		cur.InboundWebhook.DefaultRequester = strings.TrimSpace(r.FormValue("inbound_webhook_default_requester"))
+		if r.FormValue("inbound_webhook_signed") != "on" {
+			cur.InboundWebhook.Secret = ""
+		} else if cur.InboundWebhook.Secret == "" || r.FormValue("inbound_webhook_regenerate_secret") == "on" {
+			if tok, err := randomToken(32); err == nil {
+				cur.InboundWebhook.Secret = tok
+			}
+		}
+		if v := strings.TrimSpace(r.FormValue("inbound_webhook_signature_tolerance")); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				cur.InboundWebhook.SignatureToleranceSeconds = n
+			}
+		} else {
+			cur.InboundWebhook.SignatureToleranceSeconds = 0
+		}
+		if cur.InboundWebhook.Enabled && (cur.InboundWebhook.Token == "" || r.FormValue("inbound_webhook_regenerate_token") == "on") {
+			if tok, err := randomToken(24); err == nil {
+				cur.InboundWebhook.Token = tok
+			}
+		}
+		cur.GuestRequests.Enabled = r.FormValue("guest_requests_enabled") == "on"
+		if cur.GuestRequests.Enabled && (cur.GuestRequests.Token == "" || r.FormValue("guest_requests_regenerate_token") == "on") {
+			if tok, err := randomToken(24); err == nil {
+				cur.GuestRequests.Token = tok
+			}
+		}
+		cur.ImportList.Enabled = r.FormValue("import_list_enabled") == "on"
+		if cur.ImportList.Enabled && (cur.ImportList.Token == "" || r.FormValue("import_list_regenerate_token") == "on") {
+			if tok, err := randomToken(24); err == nil {
+				cur.ImportList.Token = tok
+			}
+		}
+		cur.Requests.ExtraFields = parseRequestFields(r.FormValue("request_fields"), config.NormalizeRequestFields(cur.Requests.ExtraFields))
+		cur.Requests.Statuses = parseRequestStatuses(r.FormValue("request_statuses"), config.NormalizeRequestStatuses(cur.Requests.Statuses))
+		// cur shares its maps with the live settings, so templates are
+		// rebuilt rather than edited in place.
+		templates := make(map[string]map[string]any, len(requestTemplateFormats))
+		for _, f := range requestTemplateFormats {
+			tpl := cur.Requests.Templates[f]
+			if r.Form.Has("request_template_" + f) {
+				var err error
+				if tpl, err = parseRequestTemplate(r.FormValue("request_template_" + f)); err != nil {
+					http.Error(w, "Request template for "+f+"s: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if tpl != nil {
+				templates[f] = tpl
+			}
+		}
+		cur.Requests.Templates = templates
+		if v := strings.TrimSpace(r.FormValue("reminder_after_hours")); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				cur.Requests.ReminderAfterHours = n
+			}
+		} else {
+			cur.Requests.ReminderAfterHours = 0
+		}
+		if v := strings.TrimSpace(r.FormValue("expire_after_days")); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				cur.Requests.ExpireAfterDays = n
+			}
+		} else {
+			cur.Requests.ExpireAfterDays = 0
+		}
+		cur.Requests.ExpireAction = ""
+		if r.FormValue("expire_action") == expireActionArchive {
+			cur.Requests.ExpireAction = expireActionArchive
+		}
+		cur.Maintenance.Interval = strings.TrimSpace(r.FormValue("maintenance_interval"))
 		if v := strings.TrimSpace(r.FormValue("audit_retention_days")); v != "" {
 			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
 				cur.Audit.RetentionDays = n
@@ -231,32 +479,42 @@ func readarrProbeMessage(err error) string {
 	}
 }
 
+// readarrInstanceFromProbe resolves the instance a settings-page probe
+// targets: the saved ebooks or audiobooks instance, overlaid with the
+// unsaved form values when use_overrides is set. The message is non-empty
+// when the instance cannot be probed.
+func (s *Server) readarrInstanceFromProbe(r *http.Request) (providers.ReadarrInstance, string, int) {
+	_ = r.ParseForm()
+	var inst providers.ReadarrInstance
+	cfg := s.settings.Get()
+	switch r.FormValue("kind") {
+	case "ebooks":
+		c := cfg.Readarr.Ebooks
+		inst = providers.ReadarrInstance{BaseURL: c.BaseURL, APIKey: c.APIKey, InsecureSkipVerify: c.InsecureSkipVerify}
+	case "audiobooks":
+		c := cfg.Readarr.Audiobooks
+		inst = providers.ReadarrInstance{BaseURL: c.BaseURL, APIKey: c.APIKey, InsecureSkipVerify: c.InsecureSkipVerify}
+	default:
+		return inst, "missing kind", http.StatusBadRequest
+	}
+	if readarrTruthy(r.FormValue("use_overrides")) {
+		submittedBase := strings.TrimSpace(r.FormValue("base_url"))
+		inst.BaseURL = submittedBase
+		inst.APIKey = preserveSecretField(inst.APIKey, submittedBase, r.FormValue("api_key"))
+		inst.InsecureSkipVerify = readarrTruthy(r.FormValue("insecure"))
+	}
+	if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
+		return inst, "Readarr is not fully configured yet. Add the Base URL and API key first.", http.StatusBadRequest
+	}
+	return inst, "", 0
+}
+
 // apiReadarrProfiles returns quality profiles for a given instance (ebooks|audiobooks)
 func (s *Server) apiReadarrProfiles() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		_ = r.ParseForm()
-		kind := r.FormValue("kind")
-		var inst providers.ReadarrInstance
-		cfg := s.settings.Get()
-		switch kind {
-		case "ebooks":
-			c := cfg.Readarr.Ebooks
-			inst = providers.ReadarrInstance{BaseURL: c.BaseURL, APIKey: c.APIKey, InsecureSkipVerify: c.InsecureSkipVerify}
-		case "audiobooks":
-			c := cfg.Readarr.Audiobooks
-			inst = providers.ReadarrInstance{BaseURL: c.BaseURL, APIKey: c.APIKey, InsecureSkipVerify: c.InsecureSkipVerify}
-		default:
-			http.Error(w, "missing kind", http.StatusBadRequest)
-			return
-		}
-		if readarrTruthy(r.FormValue("use_overrides")) {
-			submittedBase := strings.TrimSpace(r.FormValue("base_url"))
-			inst.BaseURL = submittedBase
-			inst.APIKey = preserveSecretField(inst.APIKey, submittedBase, r.FormValue("api_key"))
-			inst.InsecureSkipVerify = readarrTruthy(r.FormValue("insecure"))
-		}
-		if strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
-			http.Error(w, "Readarr is not fully configured yet. Add the Base URL and API key first.", http.StatusBadRequest)
+		inst, msg, code := s.readarrInstanceFromProbe(r)
+		if msg != "" {
+			http.Error(w, msg, code)
 			return
 		}
 		ra := providers.NewReadarrWithDB(inst, s.db.SQL())
@@ -272,6 +530,116 @@ func (s *Server) apiReadarrProfiles() http.HandlerFunc {
 	}
 }
 
+// apiReadarrRootFolders returns an instance's root folders with free space
+// for the settings dropdown.
+func (s *Server) apiReadarrRootFolders() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inst, msg, code := s.readarrInstanceFromProbe(r)
+		if msg != "" {
+			http.Error(w, msg, code)
+			return
+		}
+		folders, err := providers.NewReadarrWithDB(inst, s.db.SQL()).RootFolders(r.Context())
+		if err != nil {
+			http.Error(w, readarrProbeMessage(err), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, s.rootFolderViews(folders), http.StatusOK)
+	}
+}
+
+func (s *Server) rootFolderViews(folders []providers.ReadarrRootFolder) []readarrRootFolderView {
+	min := s.minFreeSpaceBytes()
+	out := make([]readarrRootFolderView, 0, len(folders))
+	for _, f := range folders {
+		out = append(out, readarrRootFolderView{
+			Path:       f.Path,
+			Accessible: f.Accessible,
+			FreeSpace:  f.FreeSpace,
+			TotalSpace: f.TotalSpace,
+			FreeText:   formatBytes(f.FreeSpace),
+			TotalText:  formatBytes(f.TotalSpace),
+			Low:        min > 0 && f.FreeSpace < min,
+		})
+	}
+	return out
+}
+
+// readarrCapabilityReport is what the settings page shows after testing an
+// instance: whether lookups work and what the instance offers.
+type readarrCapabilityReport struct {
+	OK           bool                    `json:"ok"`
+	AppName      string                  `json:"app_name,omitempty"`
+	Version      string                  `json:"version,omitempty"`
+	LookupOK     bool                    `json:"lookup_ok"`
+	ProfileCount int                     `json:"profile_count"`
+	Profiles     map[int]string          `json:"profiles"`
+	RootFolders  []readarrRootFolderView `json:"root_folders"`
+	Errors       []string                `json:"errors,omitempty"`
+}
+
+// apiSettingsTestReadarr probes an instance, usually with the unsaved form
+// values, and reports each capability separately so a partial failure (say,
+// a key that cannot list root folders) is visible before saving.
+func (s *Server) apiSettingsTestReadarr() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inst, msg, code := s.readarrInstanceFromProbe(r)
+		if msg != "" {
+			http.Error(w, msg, code)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+		defer cancel()
+		ra := providers.NewReadarrWithDB(inst, s.db.SQL())
+		report := readarrCapabilityReport{Profiles: map[int]string{}, RootFolders: []readarrRootFolderView{}}
+		if err := ra.PingLookup(ctx); err != nil {
+			report.Errors = append(report.Errors, "Lookup: "+readarrProbeMessage(err))
+		} else {
+			report.LookupOK = true
+		}
+		if st, err := ra.SystemStatus(ctx); err != nil {
+			report.Errors = append(report.Errors, "Version: "+readarrProbeMessage(err))
+		} else {
+			report.AppName, report.Version = st.AppName, st.Version
+		}
+		if qps, err := ra.GetQualityProfilesByID(ctx); err != nil {
+			report.Errors = append(report.Errors, "Quality profiles: "+readarrProbeMessage(err))
+		} else {
+			report.Profiles, report.ProfileCount = qps, len(qps)
+		}
+		if folders, err := ra.RootFolders(ctx); err != nil {
+			report.Errors = append(report.Errors, "Root folders: "+readarrProbeMessage(err))
+		} else if len(folders) == 0 {
+			report.Errors = append(report.Errors, "Root folders: none are set up in Readarr, so books cannot be added.")
+		} else {
+			report.RootFolders = s.rootFolderViews(folders)
+		}
+		report.OK = report.LookupOK && len(report.Errors) == 0
+		writeJSON(w, report, http.StatusOK)
+	}
+}
+
+// validateRootFolder checks that path is one of inst's root folders and
+// returns it spelled the way Readarr does. An unreachable instance is not an
+// error so a Readarr outage does not block saving unrelated settings.
+func (s *Server) validateRootFolder(ctx context.Context, inst providers.ReadarrInstance, path string) (string, error) {
+	if path == "" || strings.TrimSpace(inst.BaseURL) == "" || strings.TrimSpace(inst.APIKey) == "" {
+		return path, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	folders, err := providers.NewReadarrWithDB(inst, s.db.SQL()).RootFolders(ctx)
+	if err != nil {
+		return path, nil
+	}
+	for _, f := range folders {
+		if strings.TrimRight(f.Path, "/") == strings.TrimRight(path, "/") {
+			return f.Path, nil
+		}
+	}
+	return "", fmt.Errorf("root folder %q does not exist on %s", path, inst.BaseURL)
+}
+
 // apiReadarrDebug returns the effective Readarr configuration (API keys masked) so
 // admins can verify that InsecureSkipVerify and BaseURL are set as expected.
 func (s *Server) apiReadarrDebug() http.HandlerFunc {
@@ -295,6 +663,8 @@ func (s *Server) apiReadarrDebug() http.HandlerFunc {
 			"debug":      cfg.Debug,
 			"ebooks":     inst{BaseURL: cfg.Readarr.Ebooks.BaseURL, APIKeyMasked: mask(cfg.Readarr.Ebooks.APIKey), InsecureSkipVerify: cfg.Readarr.Ebooks.InsecureSkipVerify},
 			"audiobooks": inst{BaseURL: cfg.Readarr.Audiobooks.BaseURL, APIKeyMasked: mask(cfg.Readarr.Audiobooks.APIKey), InsecureSkipVerify: cfg.Readarr.Audiobooks.InsecureSkipVerify},
+			// Starts of the last Readarr responses, captured while debug is on.
+			"recent_responses": providers.RecentReadarrResponses(),
 		}
 		writeJSON(w, out, http.StatusOK)
 	}
@@ -315,3 +685,5 @@ func (s *Server) userEmail(r *http.Request) string {
 	}
 	return strings.ToLower(u.Username)
 }
+
+func boolPtr(b bool) *bool { return &b }
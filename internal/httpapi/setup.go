@@ -22,7 +22,7 @@ func (s *Server) mountSetup(r chi.Router) {
 	if !s.needsSetup() {
 		return
 	}
-	u := &setupUI{tpl: template.Must(template.ParseFS(setupFS, "web/setup/*.html"))}
+	u := &setupUI{tpl: template.Must(template.New("setup").Funcs(template.FuncMap{"asset": assetURL}).ParseFS(setupFS, "web/setup/*.html"))}
 	// Mount under /setup and apply the setupGate so the wizard is only accessible when needed
 	r.Route("/setup", func(rr chi.Router) {
 		rr.Use(s.setupGate)
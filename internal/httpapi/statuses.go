@@ -0,0 +1,213 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxStatusReason caps the reason given when moving a request to a custom
+// status.
+const maxStatusReason = 200
+
+// builtinStatusLabels are the names the request list shows for the built-in
+// statuses.
+var builtinStatusLabels = map[string]string{
+	"pending":    "Pending",
+	"processing": "Approving",
+	"approved":   "Approved",
+	"queued":     "Requested",
+	"available":  "Available",
+	"declined":   "Declined",
+	"expired":    "Expired",
+	"error":      "Needs Attention",
+}
+
+// statusOption is one status as offered by filters and the status API.
+type statusOption struct {
+	Key    string `json:"key"`
+	Label  string `json:"label"`
+	Color  string `json:"color,omitempty"`
+	Custom bool   `json:"custom,omitempty"`
+}
+
+// customStatuses returns the admin-defined statuses by key.
+func (s *Server) customStatuses() map[string]*config.RequestStatus {
+	list := config.NormalizeRequestStatuses(s.settings.Get().Requests.Statuses)
+	out := make(map[string]*config.RequestStatus, len(list))
+	for i := range list {
+		out[list[i].Key] = &list[i]
+	}
+	return out
+}
+
+// statusOptions lists the built-in statuses in workflow order, then the
+// custom ones in the order they are configured.
+func (s *Server) statusOptions() []statusOption {
+	out := make([]statusOption, 0, len(config.BuiltinStatuses))
+	for _, st := range config.BuiltinStatuses {
+		out = append(out, statusOption{Key: st, Label: builtinStatusLabels[st]})
+	}
+	for _, st := range config.NormalizeRequestStatuses(s.settings.Get().Requests.Statuses) {
+		out = append(out, statusOption{Key: st.Key, Label: st.Label, Color: st.Color, Custom: true})
+	}
+	return out
+}
+
+// statusChoices lists what apiSetRequestStatus accepts: pending, then the
+// custom statuses. It is empty when none are configured.
+func (s *Server) statusChoices() []statusOption {
+	var out []statusOption
+	for _, opt := range s.statusOptions() {
+		if opt.Custom {
+			out = append(out, opt)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return append([]statusOption{{Key: "pending", Label: builtinStatusLabels["pending"]}}, out...)
+}
+
+// formatRequestStatuses renders statuses for the settings textarea, one per
+// line as "Label | color | from: a, b | to: c".
+func formatRequestStatuses(statuses []config.RequestStatus) string {
+	lines := make([]string, 0, len(statuses))
+	for _, st := range statuses {
+		line := st.Label + " | " + st.Color
+		if len(st.From) > 0 {
+			line += " | from: " + strings.Join(st.From, ", ")
+		}
+		if len(st.To) > 0 {
+			line += " | to: " + strings.Join(st.To, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseRequestStatuses reads the settings textarea. Existing statuses keep
+// their key when their label is unchanged, so requests in them still match.
+func parseRequestStatuses(text string, existing []config.RequestStatus) []config.RequestStatus {
+	keys := make(map[string]string, len(existing))
+	for _, st := range existing {
+		keys[strings.ToLower(st.Label)] = st.Key
+	}
+	list := func(v string) []string {
+		var out []string
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				out = append(out, k)
+			}
+		}
+		return out
+	}
+	var out []config.RequestStatus
+	for _, line := range strings.Split(text, "\n") {
+		parts := strings.Split(line, "|")
+		st := config.RequestStatus{Label: strings.TrimSpace(parts[0])}
+		if st.Label == "" {
+			continue
+		}
+		for i, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			name, value, _ := strings.Cut(p, ":")
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "from":
+				st.From = list(value)
+			case "to":
+				st.To = list(value)
+			default:
+				if i == 0 {
+					st.Color = p
+				}
+			}
+		}
+		st.Key = keys[strings.ToLower(st.Label)]
+		out = append(out, st)
+	}
+	return config.NormalizeRequestStatuses(out)
+}
+
+// checkStatusTransition applies the custom status rules to a move from one
+// status to another. The database runs the same rules on every change;
+// callers that go on to do more than change the status check first.
+func (s *Server) checkStatusTransition(from, to string) error {
+	if err := config.CheckStatusTransition(s.settings.Get().Requests.Statuses, from, to); err != nil {
+		return fmt.Errorf("%w: %v", db.ErrStatusTransition, err)
+	}
+	return nil
+}
+
+func (s *Server) apiListStatuses(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.statusOptions(), http.StatusOK)
+}
+
+// apiSetRequestStatus moves a request into one of the custom statuses, or
+// back to pending. It accepts JSON {"status": "on_hold", "reason": "..."}
+// or a form with the same fields. Approving and declining keep their own
+// endpoints; the custom status rules apply to those too.
+func (s *Server) apiSetRequestStatus(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	req, err := s.db.GetRequest(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	release, ok := s.claimRequestVersion(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+	var in struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		_ = r.ParseForm()
+		in.Status, in.Reason = r.FormValue("status"), r.FormValue("reason")
+	}
+	status := strings.ToLower(strings.TrimSpace(in.Status))
+	label := "Pending"
+	if st, ok := s.customStatuses()[status]; ok {
+		label = st.Label
+	} else if status != "pending" {
+		http.Error(w, "status must be pending or a custom status", http.StatusBadRequest)
+		return
+	}
+	reason := strings.Join(strings.Fields(in.Reason), " ")
+	if len([]rune(reason)) > maxStatusReason {
+		http.Error(w, fmt.Sprintf("reason is too long (maximum %d characters)", maxStatusReason), http.StatusBadRequest)
+		return
+	}
+	username := r.Context().Value(ctxUser).(*session).Username
+	err = s.db.UpdateRequestStatus(r.Context(), id, status, reason, username, nil, nil)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, db.ErrStatusTransition) {
+			code = http.StatusConflict
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	details := req.Status + " → " + status
+	if reason != "" {
+		details += ": " + reason
+	}
+	s.auditLog(r.Context(), username, "request.status", &id, details)
+	s.kickHomeAssistant()
+	w.Header().Set("HX-Trigger", `{"request:updated": {"id": `+strconv.FormatInt(id, 10)+`}}`)
+	writeJSON(w, map[string]any{"id": id, "status": status, "label": label}, http.StatusOK)
+}
@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestParseRequestStatusesRoundTrip(t *testing.T) {
+	text := "On hold | amber | to: pending\nPurchasing | sky | from: approved, queued\n\nAwaiting release"
+	got := parseRequestStatuses(text, []config.RequestStatus{{Key: "hold", Label: "On hold"}})
+	if len(got) != 3 || got[0].Key != "hold" || got[0].Color != "amber" || len(got[0].To) != 1 || len(got[1].From) != 2 || got[2].Color != "slate" {
+		t.Fatalf("parsed = %+v", got)
+	}
+	want := "On hold | amber | to: pending\nPurchasing | sky | from: approved, queued\nAwaiting release | slate"
+	if out := formatRequestStatuses(got); out != want {
+		t.Fatalf("formatted = %q, want %q", out, want)
+	}
+}
+
+func TestCustomRequestStatuses(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	cfg := *s.settings.Get()
+	cfg.Requests.Statuses = []config.RequestStatus{{Label: "On hold", Color: "amber", To: []string{"pending"}}}
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	admin := makeCookie(t, s, "admin", true)
+	alice := makeCookie(t, s, "alice", false)
+	id, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Dune", Format: "ebook", Status: "pending"})
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	path := "/api/v1/requests/" + itoa(id) + "/status"
+
+	if rec := do(http.MethodPut, path, `{"status":"on_hold"}`, alice); rec.Code != http.StatusForbidden {
+		t.Fatalf("requester set status = %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, path, `{"status":"queued"}`, admin); rec.Code != http.StatusBadRequest {
+		t.Fatalf("built-in status = %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, path, `{"status":"on_hold","reason":"waiting for the paperback"}`, admin); rec.Code != http.StatusOK {
+		t.Fatalf("set status = %d %s", rec.Code, rec.Body.String())
+	}
+	if req, _ := s.db.GetRequest(ctx, id); req.Status != "on_hold" || req.StatusReason != "waiting for the paperback" {
+		t.Fatalf("request = %+v", req)
+	}
+	if rec := do(http.MethodPost, "/api/v1/requests/"+itoa(id)+"/approve", "", admin); rec.Code != http.StatusConflict {
+		t.Fatalf("approve from on hold = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/v1/requests/"+itoa(id)+"/decline", "", admin); rec.Code != http.StatusConflict {
+		t.Fatalf("decline from on hold = %d", rec.Code)
+	}
+
+	rec := do(http.MethodGet, "/requests?status=on_hold", "", alice)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "On hold") || !strings.Contains(rec.Body.String(), "Dune") {
+		t.Fatalf("filtered list = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ui/requests/table?status=pending", "", alice); strings.Contains(rec.Body.String(), "Dune") {
+		t.Fatal("pending filter shows the request on hold")
+	}
+	var opts []statusOption
+	if rec := do(http.MethodGet, "/api/v1/statuses", "", alice); json.Unmarshal(rec.Body.Bytes(), &opts) != nil || len(opts) != len(config.BuiltinStatuses)+1 || !opts[len(opts)-1].Custom {
+		t.Fatalf("statuses = %s", rec.Body.String())
+	}
+
+	if rec := do(http.MethodPut, path, `{"status":"pending"}`, admin); rec.Code != http.StatusOK {
+		t.Fatalf("back to pending = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/v1/requests/"+itoa(id)+"/decline", "", admin); rec.Code != http.StatusOK {
+		t.Fatalf("decline = %d", rec.Code)
+	}
+}
@@ -0,0 +1,246 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// formatMagazine is the request format for periodicals. Requesting one
+// subscribes the requester, and each later issue becomes its own request.
+const formatMagazine = "magazine"
+
+// subscriptionSweepInterval is how often due issues are turned into requests.
+const subscriptionSweepInterval = time.Hour
+
+// subscriptionFrequencies are the schedules a magazine can be requested on,
+// in the order the subscribe form lists them.
+var subscriptionFrequencies = []string{"weekly", "biweekly", "monthly", "bimonthly", "quarterly"}
+
+// normalizeFrequency returns the schedule for v, defaulting to monthly, and
+// false for anything it does not know.
+func normalizeFrequency(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return "monthly", true
+	}
+	for _, f := range subscriptionFrequencies {
+		if v == f {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// nextIssueAt is when the issue after the one at from comes out.
+func nextIssueAt(frequency string, from time.Time) time.Time {
+	switch frequency {
+	case "weekly":
+		return from.AddDate(0, 0, 7)
+	case "biweekly":
+		return from.AddDate(0, 0, 14)
+	case "bimonthly":
+		return from.AddDate(0, 2, 0)
+	case "quarterly":
+		return from.AddDate(0, 3, 0)
+	default:
+		return from.AddDate(0, 1, 0)
+	}
+}
+
+// issueTitle names the issue of title out at at, e.g. "Wired – March 2026",
+// "The Economist – Mar 7, 2026", or "Granta – Q1 2026".
+func issueTitle(title, frequency string, at time.Time) string {
+	var issue string
+	switch frequency {
+	case "weekly", "biweekly":
+		issue = at.Format("Jan 2, 2006")
+	case "quarterly":
+		issue = fmt.Sprintf("Q%d %d", (int(at.Month())-1)/3+1, at.Year())
+	default:
+		issue = at.Format("January 2006")
+	}
+	return strings.TrimSpace(title) + " – " + issue
+}
+
+// startSubscription records the subscription behind a magazine request;
+// first is the request for the current issue.
+func (s *Server) startSubscription(ctx context.Context, first *db.Request, title, frequency string) (int64, error) {
+	now := time.Now().UTC()
+	sub := &db.Subscription{
+		RequesterEmail: first.RequesterEmail,
+		Title:          strings.TrimSpace(title),
+		Frequency:      frequency,
+		GroupName:      first.GroupName,
+		NextIssueAt:    nextIssueAt(frequency, now),
+		LastIssueAt:    now,
+		IssueCount:     1,
+		LastRequestID:  first.ID,
+	}
+	if len(first.Authors) > 0 {
+		sub.Publisher = first.Authors[0]
+	}
+	id, err := s.db.CreateSubscription(ctx, sub)
+	if err != nil {
+		return 0, err
+	}
+	s.auditLog(ctx, first.RequesterEmail, "subscription.created", &first.ID, fmt.Sprintf("subscription %d: %s, %s", id, sub.Title, frequency))
+	return id, nil
+}
+
+func (s *Server) runSubscriptionLoop(ctx context.Context) {
+	ticker := time.NewTicker(subscriptionSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.issueDueSubscriptions(ctx, time.Now().UTC())
+		}
+	}
+}
+
+// issueDueSubscriptions creates a request for every issue that came out
+// since the last sweep. Issues missed while the server was down are skipped
+// rather than requested in a burst. Requesters with auto-approve get the
+// issue approved straight away, as with their other requests, unless the
+// subscription source policy says otherwise. Subscriptions of disabled or
+// deleted users are paused instead of issued.
+func (s *Server) issueDueSubscriptions(ctx context.Context, now time.Time) {
+	subs, err := s.db.ListDueSubscriptions(ctx, now)
+	if err != nil {
+		fmt.Printf("subscriptions: list due: %v\n", err)
+		return
+	}
+	for _, sub := range subs {
+		u, err := s.db.GetUserByUsername(ctx, sub.RequesterEmail)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("subscriptions: look up requester for #%d: %v\n", sub.ID, err)
+			continue
+		}
+		if u == nil || u.Disabled {
+			if err := s.db.SetSubscriptionActive(ctx, sub.ID, false, time.Time{}); err != nil {
+				fmt.Printf("subscriptions: pause #%d: %v\n", sub.ID, err)
+				continue
+			}
+			s.auditLog(ctx, "system", "subscription.paused", nil, fmt.Sprintf("subscription %d: %s, requester disabled or removed", sub.ID, sub.Title))
+			continue
+		}
+		req := &db.Request{
+			RequesterEmail: sub.RequesterEmail,
+			Title:          issueTitle(sub.Title, sub.Frequency, sub.NextIssueAt),
+			Format:         formatMagazine,
+			Status:         "pending",
+			GroupName:      sub.GroupName,
+			Source:         sourceSubscription,
+		}
+		if sub.Publisher != "" {
+			req.Authors = []string{sub.Publisher}
+		}
+		id, err := s.db.CreateRequest(ctx, req)
+		if err != nil {
+			fmt.Printf("subscriptions: create issue for #%d: %v\n", sub.ID, err)
+			continue
+		}
+		req.ID = id
+		next := nextIssueAt(sub.Frequency, sub.NextIssueAt)
+		for !next.After(now) {
+			next = nextIssueAt(sub.Frequency, next)
+		}
+		if err := s.db.RecordSubscriptionIssue(ctx, sub.ID, id, sub.NextIssueAt, next); err != nil {
+			fmt.Printf("subscriptions: record issue for #%d: %v\n", sub.ID, err)
+		}
+		s.auditLog(ctx, "system", "subscription.issue", &id, fmt.Sprintf("subscription %d", sub.ID))
+
+		if s.applySourcePolicy(ctx, req, u.AutoApprove) && !s.needsTwoApprovals(ctx, req) {
+			if _, err := s.approveRequest(ctx, req, sub.RequesterEmail, "auto-approved; ", addPolicyOverride{}); err == nil {
+				continue
+			}
+		}
+		s.SendRequestNotification(id, sub.RequesterEmail, req.Title, req.Authors)
+	}
+}
+
+// canManageSubscription mirrors canAccessRequest: admins, the group's
+// admins, and the subscriber.
+func (s *Server) canManageSubscription(u *session, sub *db.Subscription) bool {
+	if u == nil || sub == nil {
+		return false
+	}
+	return u.Admin || s.isGroupAdmin(u.Username, sub.GroupName) || strings.EqualFold(sub.RequesterEmail, u.Username)
+}
+
+// visibleSubscriptions lists what u may see: everything for admins, their
+// own subscriptions otherwise.
+func (s *Server) visibleSubscriptions(ctx context.Context, u *session) ([]db.Subscription, error) {
+	if u.Admin {
+		return s.db.ListSubscriptions(ctx, "")
+	}
+	return s.db.ListSubscriptions(ctx, u.Username)
+}
+
+func (s *Server) apiListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	u := r.Context().Value(ctxUser).(*session)
+	subs, err := s.visibleSubscriptions(r.Context(), u)
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if subs == nil {
+		subs = []db.Subscription{}
+	}
+	writeJSON(w, subs, http.StatusOK)
+}
+
+func (u *ui) handleSubscriptions(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ses := r.Context().Value(ctxUser).(*session)
+		subs, _ := s.visibleSubscriptions(r.Context(), ses)
+		data := map[string]any{
+			"UserName":      s.userName(r),
+			"IsAdmin":       ses.Admin,
+			"CSRFToken":     s.getCSRFToken(r),
+			"Subscriptions": subs,
+			"Frequencies":   subscriptionFrequencies,
+		}
+		_ = u.tpl.ExecuteTemplate(w, "subscriptions.html", data)
+	}
+}
+
+// handleSubscriptionToggle pauses or resumes a subscription. A resumed
+// subscription picks up with the next issue due from today.
+func (s *Server) handleSubscriptionToggle(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	sub, err := s.db.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	u := r.Context().Value(ctxUser).(*session)
+	if !s.canManageSubscription(u, sub) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	_ = r.ParseForm()
+	active := r.FormValue("active") == "true"
+	if err := s.db.SetSubscriptionActive(r.Context(), id, active, nextIssueAt(sub.Frequency, time.Now().UTC())); err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	event := "subscription.paused"
+	if active {
+		event = "subscription.resumed"
+	}
+	s.auditLog(r.Context(), u.Username, event, nil, fmt.Sprintf("subscription %d: %s", id, sub.Title))
+	http.Redirect(w, r, "/subscriptions", http.StatusFound)
+}
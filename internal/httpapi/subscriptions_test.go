@@ -0,0 +1,158 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestIssueTitleAndSchedule(t *testing.T) {
+	at := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)
+	cases := map[string]string{
+		"weekly":    "Wired – Mar 7, 2026",
+		"monthly":   "Wired – March 2026",
+		"quarterly": "Wired – Q1 2026",
+	}
+	for freq, want := range cases {
+		if got := issueTitle("Wired", freq, at); got != want {
+			t.Fatalf("%s: got %q, want %q", freq, got, want)
+		}
+	}
+	if got := nextIssueAt("biweekly", at); !got.Equal(at.AddDate(0, 0, 14)) {
+		t.Fatalf("biweekly next = %v", got)
+	}
+	if f, ok := normalizeFrequency(""); !ok || f != "monthly" {
+		t.Fatalf("default frequency = %q", f)
+	}
+	if _, ok := normalizeFrequency("daily"); ok {
+		t.Fatalf("daily accepted")
+	}
+}
+
+func TestMagazineRequestCreatesSubscription(t *testing.T) {
+	s := newServerForTest(t)
+	if _, err := s.db.CreateUser(context.Background(), "reader", "x", false, false); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	reader := makeCookie(t, s, "reader", false)
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if strings.HasPrefix(body, "{") {
+			req.Header.Set("Content-Type", "application/json")
+		} else if body != "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/v1/requests", `{"title":"Wired","format":"magazine","frequency":"daily"}`, reader); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad frequency: %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/v1/requests", `{"title":"Wired","authors":["Condé Nast"],"format":"magazine","frequency":"weekly"}`, reader)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID             int64 `json:"id"`
+		SubscriptionID int64 `json:"subscription_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.SubscriptionID == 0 {
+		t.Fatalf("create response: %s", rec.Body.String())
+	}
+	first, _ := s.db.GetRequest(context.Background(), created.ID)
+	if first.Format != formatMagazine || !strings.HasPrefix(first.Title, "Wired – ") {
+		t.Fatalf("first issue: %+v", first)
+	}
+
+	// The next issue comes due.
+	past := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339Nano)
+	if err := s.db.Exec(context.Background(), `UPDATE subscriptions SET next_issue_at=? WHERE id=?`, past, created.SubscriptionID); err != nil {
+		t.Fatal(err)
+	}
+	s.issueDueSubscriptions(context.Background(), time.Now().UTC())
+	sub, err := s.db.GetSubscription(context.Background(), created.SubscriptionID)
+	if err != nil || sub.IssueCount != 2 || sub.LastRequestID == created.ID || !sub.NextIssueAt.After(time.Now()) {
+		t.Fatalf("after sweep: %+v %v", sub, err)
+	}
+	issue, _ := s.db.GetRequest(context.Background(), sub.LastRequestID)
+	if issue.Status != "pending" || issue.RequesterEmail != "reader" || len(issue.Authors) != 1 || issue.Authors[0] != "Condé Nast" {
+		t.Fatalf("second issue: %+v", issue)
+	}
+
+	// Without a magazine backend, approving only marks the issue approved.
+	admin := makeCookie(t, s, "admin", true)
+	if rec := do(http.MethodPost, "/api/v1/requests/"+strconv.FormatInt(issue.ID, 10)+"/approve", "", admin); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "approved") {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+
+	other := makeCookie(t, s, "other", false)
+	if rec := do(http.MethodGet, "/api/v1/subscriptions", "", other); rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("other user's list: %d %s", rec.Code, rec.Body.String())
+	}
+	toggle := "/subscriptions/" + strconv.FormatInt(sub.ID, 10) + "/toggle"
+	if rec := do(http.MethodPost, toggle, url.Values{"active": {"false"}}.Encode(), other); rec.Code != http.StatusForbidden {
+		t.Fatalf("other user paused: %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, toggle, url.Values{"active": {"false"}}.Encode(), reader); rec.Code != http.StatusFound {
+		t.Fatalf("pause: %d %s", rec.Code, rec.Body.String())
+	}
+	if sub, _ := s.db.GetSubscription(context.Background(), sub.ID); sub.Active {
+		t.Fatalf("still active after pause")
+	}
+	if rec := do(http.MethodGet, "/subscriptions", "", reader); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Wired") || !strings.Contains(rec.Body.String(), "Resume") {
+		t.Fatalf("subscriptions page: %d", rec.Code)
+	}
+}
+
+func TestDueSubscriptionsOfDisabledOrMissingUsersArePaused(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	id, err := s.db.CreateUser(ctx, "gone", "x", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.CreateUser(ctx, "reader", "x", false, false); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().UTC().Add(-time.Hour)
+	subs := map[string]int64{}
+	for _, who := range []string{"gone", "nobody", "reader"} {
+		sid, err := s.db.CreateSubscription(ctx, &db.Subscription{RequesterEmail: who, Title: "Wired", Frequency: "weekly", Active: true, NextIssueAt: past})
+		if err != nil {
+			t.Fatal(err)
+		}
+		subs[who] = sid
+	}
+	if err := s.db.SetUserDisabled(ctx, id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	s.issueDueSubscriptions(ctx, time.Now().UTC())
+	for who, sid := range subs {
+		sub, err := s.db.GetSubscription(ctx, sid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		issued := who == "reader"
+		if sub.Active != issued || (sub.IssueCount > 0) != issued {
+			t.Errorf("%s: active=%v issues=%d", who, sub.Active, sub.IssueCount)
+		}
+	}
+	reqs, _ := s.db.ListRequests(ctx, "", 10)
+	if len(reqs) != 1 || reqs[0].RequesterEmail != "reader" {
+		t.Fatalf("requests = %+v", reqs)
+	}
+}
@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+// statusAwaitingSecondApproval is returned for an approval that only
+// recorded the first of the two a request needs.
+const statusAwaitingSecondApproval = "awaiting_second_approval"
+
+// twoPersonFormatChoices are the formats offered on the settings page.
+var twoPersonFormatChoices = []string{"ebook", "audiobook", formatComic, formatMagazine}
+
+var errSameApprover = errors.New("this request needs a second approval from a different admin")
+
+// needsTwoApprovals reports whether req matches the two-person approval
+// policy by format or by one of its labels.
+func (s *Server) needsTwoApprovals(ctx context.Context, req *db.Request) bool {
+	policy := s.settings.Get().Requests.TwoPersonApproval
+	if !policy.Enabled() {
+		return false
+	}
+	for _, f := range policy.Formats {
+		if strings.EqualFold(strings.TrimSpace(f), req.Format) {
+			return true
+		}
+	}
+	if len(policy.Labels) == 0 {
+		return false
+	}
+	labels := req.Labels
+	if labels == nil {
+		labels, _ = s.db.GetRequestLabels(ctx, req.ID)
+	}
+	for _, want := range policy.Labels {
+		for _, l := range labels {
+			if strings.EqualFold(strings.TrimSpace(want), l) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// holdForSecondApproval applies the two-person approval policy to actor's
+// approval of req. The first approval is recorded and reported as held; a
+// second one from the same admin fails with errSameApprover. When approval
+// may go ahead, note names the first approver for the audit entry.
+func (s *Server) holdForSecondApproval(ctx context.Context, req *db.Request, actor string) (held bool, note string, err error) {
+	if !s.needsTwoApprovals(ctx, req) {
+		return false, "", nil
+	}
+	if req.FirstApprover == "" {
+		reason := fmt.Sprintf("awaiting second approval (first by %s)", strings.ToLower(actor))
+		ok, err := s.db.RecordFirstApproval(ctx, req.ID, actor, reason)
+		if err != nil {
+			return false, "", err
+		}
+		if ok {
+			req.FirstApprover = strings.ToLower(actor)
+			req.StatusReason = reason
+			id := req.ID
+			s.auditLog(ctx, actor, "request.first_approval", &id, "awaiting a second admin")
+			return true, "", nil
+		}
+		// Another admin got there first; judge this approval against theirs.
+		fresh, err := s.db.GetRequest(ctx, req.ID)
+		if err != nil {
+			return false, "", err
+		}
+		if fresh.FirstApprover == "" {
+			return false, "", fmt.Errorf("request is %s", fresh.Status)
+		}
+		req.FirstApprover = fresh.FirstApprover
+	}
+	if strings.EqualFold(req.FirstApprover, actor) {
+		return true, "", errSameApprover
+	}
+	return false, "second approval, first by " + req.FirstApprover + "; ", nil
+}
+
+// twoPersonFormatSet marks the configured formats for the settings page.
+func twoPersonFormatSet(formats []string) map[string]bool {
+	out := make(map[string]bool, len(formats))
+	for _, f := range formats {
+		out[strings.ToLower(strings.TrimSpace(f))] = true
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestTwoPersonApproval(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	cfg := s.settings.Get()
+	cfg.Requests.TwoPersonApproval.Formats = []string{"audiobook"}
+	cfg.Requests.TwoPersonApproval.Labels = []string{"Purchase Required"}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	first := makeCookie(t, s, "admin1", true)
+	second := makeCookie(t, s, "admin2", true)
+
+	if _, err := s.db.CreateUser(ctx, "alice", "hash", false, true); err != nil {
+		t.Fatal(err)
+	}
+	post := func(path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := post("/api/v1/requests", `{"title":"Dune","authors":["Frank Herbert"],"format":"audiobook"}`, makeCookie(t, s, "alice", false)); rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	reqs, _ := s.db.ListRequests(ctx, "alice", 10)
+	if len(reqs) != 1 || reqs[0].Status != "pending" {
+		t.Fatalf("auto-approve not held: %+v", reqs)
+	}
+	approve := "/api/v1/requests/" + strconv.FormatInt(reqs[0].ID, 10) + "/approve"
+
+	if rec := post(approve, "", first); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), statusAwaitingSecondApproval) {
+		t.Fatalf("first approval: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(approve, "", first); rec.Code != http.StatusConflict {
+		t.Fatalf("same admin twice: %d %s", rec.Code, rec.Body.String())
+	}
+	got, _ := s.db.GetRequest(ctx, reqs[0].ID)
+	if got.Status != "pending" || got.FirstApprover != "admin1" || got.FirstApprovedAt == nil {
+		t.Fatalf("after first approval: %+v", got)
+	}
+	if rec := post(approve, "", second); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "approved") {
+		t.Fatalf("second approval: %d %s", rec.Code, rec.Body.String())
+	}
+	got, _ = s.db.GetRequest(ctx, reqs[0].ID)
+	if got.Status != "approved" || got.ApproverEmail != "admin2" || got.FirstApprover != "admin1" {
+		t.Fatalf("after second approval: %+v", got)
+	}
+	events, _ := s.db.ListRequestAuditEvents(ctx, got.ID, 20)
+	var sawFirst, sawSecond bool
+	for _, e := range events {
+		sawFirst = sawFirst || (e.EventType == "request.first_approval" && e.ActorEmail == "admin1")
+		sawSecond = sawSecond || (e.EventType == "request.approved" && e.ActorEmail == "admin2" && strings.Contains(e.Details, "first by admin1"))
+	}
+	if !sawFirst || !sawSecond {
+		t.Fatalf("history = %+v", events)
+	}
+
+	// A label puts an ebook under the policy too.
+	id, err := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "alice", Title: "Emma", Format: "ebook", Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ebook, _ := s.db.GetRequest(ctx, id)
+	if s.needsTwoApprovals(ctx, ebook) {
+		t.Fatal("unlabelled ebook needs two approvals")
+	}
+	if err := s.db.SetRequestLabels(ctx, id, []string{"purchase required"}); err != nil {
+		t.Fatal(err)
+	}
+	if !s.needsTwoApprovals(ctx, ebook) {
+		t.Fatal("labelled ebook should need two approvals")
+	}
+}
@@ -12,7 +12,9 @@ import (
 	"strings"
 	"time"
 
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
 	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
 	"gitea.knapp/jacoknapp/scriptorum/internal/util"
 	"github.com/go-chi/chi/v5"
 )
@@ -23,39 +25,74 @@ func (s *Server) mountUI(r chi.Router) {
 		"csrfToken":     func(r *http.Request) string { return s.getCSRFToken(r) },
 		"authorsText":   authorsText,
 		"truncateChars": truncateChars,
+		"brand":         s.branding,
+		"asset":         assetURL,
 	}
 	u := &ui{tpl: template.Must(template.New("tpl").Funcs(funcMap).ParseFS(tplFS, "web/templates/*.html"))}
 	r.Group(func(rt chi.Router) {
 		rt.Use(s.withUser)
 		rt.Get("/", s.requireLogin(func(w http.ResponseWriter, r *http.Request) {
 			ses := r.Context().Value(ctxUser).(*session)
-			mine := ""
-			if ses == nil || !ses.Admin {
-				mine = s.userEmail(r)
+			scope := s.requestListScope(r)
+			items, _ := s.db.ListRequestsPageScoped(r.Context(), scope, 200)
+			data := map[string]any{"UserName": s.userName(r), "IsAdmin": ses != nil && ses.Admin, "CanModerate": s.canModerateRequests(r), "Items": s.buildRequestListItems(r.Context(), items), "FallbackAll": false, "CSRFToken": s.getCSRFToken(r), "Label": scope.Label, "Status": scope.Status, "Source": scope.Source, "StatusOptions": s.statusOptions()}
+			if ses != nil && ses.Admin {
+				data["LabelOptions"], _ = s.db.ListLabels(r.Context())
+				data["SourceOptions"], _ = s.requestSourceCounts(r.Context(), s.requestScope(r))
+				s.guestQueueData(r.Context(), data)
 			}
-			items, _ := s.db.ListRequestsPage(r.Context(), mine, 200)
-			data := map[string]any{"UserName": s.userName(r), "IsAdmin": ses != nil && ses.Admin, "Items": s.buildRequestListItems(r.Context(), items), "FallbackAll": false, "CSRFToken": s.getCSRFToken(r)}
 			_ = u.tpl.ExecuteTemplate(w, "requests.html", data)
 		}))
 		rt.Get("/dashboard", s.requireLogin(u.handleDashboard(s)))
 		rt.Get("/search", s.requireLogin(u.handleHome(s)))
 		rt.Get("/requests", s.requireLogin(func(w http.ResponseWriter, r *http.Request) {
 			ses := r.Context().Value(ctxUser).(*session)
-			mine := ""
-			if ses == nil || !ses.Admin {
-				mine = s.userEmail(r)
+			scope := s.requestListScope(r)
+			items, _ := s.db.ListRequestsPageScoped(r.Context(), scope, 200)
+			data := map[string]any{"UserName": s.userName(r), "IsAdmin": ses != nil && ses.Admin, "CanModerate": s.canModerateRequests(r), "Items": s.buildRequestListItems(r.Context(), items), "FallbackAll": false, "CSRFToken": s.getCSRFToken(r), "Label": scope.Label, "Status": scope.Status, "Source": scope.Source, "StatusOptions": s.statusOptions()}
+			if ses != nil && ses.Admin {
+				data["LabelOptions"], _ = s.db.ListLabels(r.Context())
+				data["SourceOptions"], _ = s.requestSourceCounts(r.Context(), s.requestScope(r))
+				s.guestQueueData(r.Context(), data)
 			}
-			items, _ := s.db.ListRequestsPage(r.Context(), mine, 200)
-			data := map[string]any{"UserName": s.userName(r), "IsAdmin": ses != nil && ses.Admin, "Items": s.buildRequestListItems(r.Context(), items), "FallbackAll": false, "CSRFToken": s.getCSRFToken(r)}
 			_ = u.tpl.ExecuteTemplate(w, "requests.html", data)
 		}))
+		rt.Get("/requests/bulk", s.requireLogin(u.handleBulkPage(s)))
+		rt.Get("/authors", s.requireLogin(u.handleAuthor(s)))
+		rt.Get("/quick-request", s.requireLogin(u.handleQuickRequest(s)))
+		rt.Post("/quick-request", s.requireLogin(u.handleQuickRequestCreate(s)))
+		rt.Get("/requests/errors", s.requireAdmin(u.handleRequestErrors(s)))
+		rt.Get("/requests/queue", s.requireAdmin(u.handleQueue(s, "queue.html")))
+		rt.Get("/requests/triage", s.requireAdmin(u.handleQueue(s, "triage.html")))
+		rt.Get("/requests/guests", s.requireAdmin(u.handleGuestRequests(s)))
+		rt.Post("/requests/guests/{id}/convert", s.requireAdmin(s.handleGuestConvert))
+		rt.Post("/requests/guests/{id}/reject", s.requireAdmin(s.handleGuestReject))
+		rt.Get("/subscriptions", s.requireLogin(u.handleSubscriptions(s)))
+		rt.Post("/subscriptions/{id}/toggle", s.requireLogin(s.handleSubscriptionToggle))
+		rt.Get("/requests/{id}", s.requireLogin(u.handleRequestDetail(s)))
 		rt.Get("/account", s.requireLogin(u.handleAccount(s)))
 		rt.Post("/account/save", s.requireLogin(u.handleAccountSave(s)))
+		rt.Post("/account/sessions/revoke", s.requireLogin(u.handleAccountSignOutOthers(s)))
+		rt.Post("/account/push/remove", s.requireLogin(u.handleAccountPushRemove(s)))
+		rt.Post("/account/search/save", s.requireLogin(u.handleAccountSearchSave(s)))
 		rt.HandleFunc("/users", s.requireAdmin(u.handleUsers(s)))
 		rt.Get("/audit", s.requireAdmin(u.handleAudit(s)))
 		rt.Get("/audit/export", s.requireAdmin(u.handleAuditExport(s)))
+		rt.Get("/providers", s.requireAdmin(u.handleProviders(s)))
+		rt.Get("/denylist", s.requireAdmin(u.handleDenylist(s)))
+		rt.Post("/denylist/add", s.requireAdmin(s.handleDenylistAdd))
+		rt.Post("/denylist/delete", s.requireAdmin(s.handleDenylistDelete))
+		rt.Post("/providers/toggle", s.requireAdmin(s.handleProviderToggle))
+		rt.Get("/api/providers/health", s.requireAdmin(s.apiProviderHealth))
 	})
 	r.Get("/ui/requests/table", s.requireLogin(u.handleRequestsTable(s)))
+	r.Get("/ui/requests/{id}/attachments", s.requireLogin(u.handleRequestAttachments(s)))
+	r.Post("/ui/requests/bulk/resolve", s.requireLogin(u.handleBulkResolve(s)))
+	r.Post("/ui/requests/bulk/create", s.requireLogin(u.handleBulkCreate(s)))
+	r.Get("/ui/admin/alerts", s.requireAdmin(u.handleAdminAlerts(s)))
+	r.Get("/ui/admin/readarr-status", s.requireAdmin(u.handleReadarrStatus(s)))
+	r.Get("/ui/admin/spend", s.requireAdmin(u.handleSpendReport(s)))
+	r.Get("/ui/admin/usage", s.requireAdmin(u.handleUsageStats(s)))
 	r.Group(func(rt chi.Router) {
 		rt.Use(func(next http.Handler) http.Handler { return s.requireAdmin(next.ServeHTTP) })
 		rt.Post("/users/delete", func(w http.ResponseWriter, r *http.Request) {
@@ -90,7 +127,17 @@ func (s *Server) mountUI(r chi.Router) {
 					_ = s.db.SetUserAdmin(r.Context(), id, admin)
 					// Update auto-approve status
 					_ = s.db.SetUserAutoApprove(r.Context(), id, autoApprove)
-					s.auditLog(r.Context(), actor, "user.updated", nil, fmt.Sprintf("user id %d, admin=%t, autoApprove=%t", id, admin, autoApprove))
+					maxPending, _ := strconv.Atoi(strings.TrimSpace(r.FormValue("max_pending")))
+					_ = s.db.SetUserMaxPending(r.Context(), id, maxPending)
+					details := fmt.Sprintf("user id %d, admin=%t, autoApprove=%t, maxPending=%d", id, admin, autoApprove, maxPending)
+					// Only touch group membership when groups are configured, so
+					// the field being absent from the form never clears it.
+					if len(s.settings.Get().Groups) > 0 {
+						group := s.formGroup(r)
+						_ = s.db.SetUserGroup(r.Context(), id, group)
+						details += ", group=" + group
+					}
+					s.auditLog(r.Context(), actor, "user.updated", nil, details)
 
 					// Update password if provided and confirmed
 					if password != "" {
@@ -104,12 +151,57 @@ func (s *Server) mountUI(r chi.Router) {
 						}
 						hash, _ := s.hashPassword(password, s.settings.Get().Auth.Salt)
 						_ = s.db.UpdateUserPassword(r.Context(), id, hash)
+						// A reset password ends sessions signed in with the old one.
+						if u, err := s.db.GetUserByID(r.Context(), id); err == nil {
+							_, _ = s.db.DeleteUserSessions(r.Context(), u.Username, "")
+						}
 						s.auditLog(r.Context(), actor, "user.password_reset", nil, fmt.Sprintf("user id %d", id))
 					}
 				}
 			}
 			http.Redirect(w, r, "/users", http.StatusFound)
 		})
+		rt.Post("/users/import", s.handleUserImport)
+		// Deactivation blocks sign-in but keeps the account and its history.
+		rt.Post("/users/deactivate", func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			actor := r.Context().Value(ctxUser).(*session).Username
+			if n, err := strconv.ParseInt(r.FormValue("id"), 10, 64); err == nil {
+				if u, err := s.db.GetUserByID(r.Context(), n); err == nil {
+					if !u.Disabled && strings.EqualFold(u.Username, actor) {
+						http.Redirect(w, r, "/users?error="+url.QueryEscape("you cannot deactivate your own account"), http.StatusFound)
+						return
+					}
+					_ = s.db.SetUserDisabled(r.Context(), n, !u.Disabled)
+					if !u.Disabled {
+						_, _ = s.db.DeleteUserSessions(r.Context(), u.Username, "")
+					}
+					s.auditLog(r.Context(), actor, "user.updated", nil, fmt.Sprintf("user id %d, active=%t", n, u.Disabled))
+				}
+			}
+			http.Redirect(w, r, "/users", http.StatusFound)
+		})
+		rt.Post("/users/anonymize", func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			actor := r.Context().Value(ctxUser).(*session).Username
+			n, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+			if err != nil {
+				http.Redirect(w, r, "/users", http.StatusFound)
+				return
+			}
+			if u, err := s.db.GetUserByID(r.Context(), n); err == nil && strings.EqualFold(u.Username, actor) {
+				http.Redirect(w, r, "/users?error="+url.QueryEscape("you cannot anonymize your own account"), http.StatusFound)
+				return
+			}
+			alias, err := s.db.AnonymizeUser(r.Context(), n)
+			if err != nil {
+				http.Redirect(w, r, "/users?error="+url.QueryEscape("failed to anonymize user"), http.StatusFound)
+				return
+			}
+			// The audit entry names only the id and alias, never the old name.
+			s.auditLog(r.Context(), actor, "user.anonymized", nil, fmt.Sprintf("user id %d, now %s", n, alias))
+			http.Redirect(w, r, "/users?notice="+url.QueryEscape("User anonymized as "+alias+"."), http.StatusFound)
+		})
 		rt.Post("/users/toggle", func(w http.ResponseWriter, r *http.Request) {
 			_ = r.ParseForm()
 			if id := r.FormValue("id"); id != "" {
@@ -134,14 +226,23 @@ type ui struct{ tpl *template.Template }
 
 func (u *ui) handleHome(s *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		name, isAdmin := "", false
+		name, isAdmin, defaultFormat := "", false, ""
+		quota := requestQuota{Unlimited: true}
 		if ses, ok := r.Context().Value(ctxUser).(*session); ok && ses != nil {
 			name, isAdmin = ses.Name, ses.Admin
+			quota = s.requestQuota(r.Context(), ses.Username, requestGroup(r))
+			defaultFormat, _ = s.db.MostRequestedFormat(r.Context(), ses.Username)
 		}
 		data := map[string]any{
 			"UserName":  name,
 			"IsAdmin":   isAdmin,
 			"CSRFToken": s.getCSRFToken(r),
+			"Quota":     quota,
+			// The request dialog pre-selects the format the user asks for most.
+			"DefaultFormat": defaultFormat,
+			// A nil slice would render as null in the page script.
+			"RequestFields":   append([]config.RequestField{}, s.requestFields()...),
+			"LanguageOptions": discoveryLanguageOptions,
 		}
 		_ = u.tpl.ExecuteTemplate(w, "home.html", data)
 	}
@@ -158,12 +259,8 @@ func (u *ui) handleDashboard(s *Server) http.HandlerFunc {
 func (u *ui) handleRequestsTable(s *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ses := r.Context().Value(ctxUser).(*session)
-		mine := ""
-		if ses == nil || !ses.Admin {
-			mine = s.userEmail(r)
-		}
-		items, _ := s.db.ListRequestsPage(r.Context(), mine, 200)
-		data := map[string]any{"Items": s.buildRequestListItems(r.Context(), items), "IsAdmin": ses != nil && ses.Admin, "FallbackAll": false}
+		items, _ := s.db.ListRequestsPageScoped(r.Context(), s.requestListScope(r), 200)
+		data := map[string]any{"Items": s.buildRequestListItems(r.Context(), items), "IsAdmin": ses != nil && ses.Admin, "CanModerate": s.canModerateRequests(r), "FallbackAll": false}
 		_ = u.tpl.ExecuteTemplate(w, "requests_table", data)
 	}
 }
@@ -182,10 +279,48 @@ type requestListItem struct {
 	// SearchDispatchPending is true when a Search click has queued a dispatch
 	// job but the background worker has not sent it to Readarr yet.
 	SearchDispatchPending bool
+	// AlternateEligible is true when the book has been approved or is already
+	// in Readarr, so the requester may ask for the other format, and they
+	// have not asked for it yet.
+	AlternateEligible bool
+	// Linked is the request for the same book in the other format, when the
+	// two were requested separately and linked for combined approval.
+	Linked *db.Request
+	// PendingAge is how long a pending request has waited, and AgeLevel how
+	// many reminder thresholds that age has crossed (0 when fresh or when
+	// reminders are off).
+	PendingAge string
+	AgeLevel   int
+	// Failure explains a recognised Readarr error on a request in "error"
+	// status; the raw StatusReason stays available behind a toggle.
+	Failure *providers.ReadarrFailure
+	// NeedsSelection blocks approval until a Readarr selection is attached.
+	// Magazine issues handled outside Readarr never get one.
+	NeedsSelection bool
+	// NeedByLabel shows the need-by date of an open request, and
+	// NeedByState marks it needBySoon or needByOverdue.
+	NeedByLabel string
+	NeedByState string
+	// Custom is the admin-defined status the request is in, if any.
+	Custom *config.RequestStatus
+	// SourceLabel names the channel the request arrived through.
+	SourceLabel string
+	// Version is sent back with approve, decline and status changes so a
+	// change made from a stale page is refused.
+	Version string
 }
 
 func (s *Server) buildRequestListItems(ctx context.Context, items []db.Request) []requestListItem {
 	matchedBooks := s.requestListMatchedBooks(ctx, items)
+	byID := make(map[int64]db.Request, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	threshold := s.pendingReminderThreshold()
+	magazinesByHand := s.settings.Get().Magazines.ReadarrFormat() == ""
+	now := time.Now()
+	custom := s.customStatuses()
+	requested := requestedBooks(items)
 	out := make([]requestListItem, 0, len(items))
 	for _, item := range items {
 		cover := s.requestListCoverData(item, matchedBooks)
@@ -196,11 +331,48 @@ func (s *Server) buildRequestListItems(ctx context.Context, items []db.Request)
 			item.MatchedReadarrID > 0 &&
 			(item.Status == "approved" || item.Status == "queued" || item.Status == "processing")
 		searchDispatchPending := item.Status == "queued" && strings.Contains(strings.ToLower(item.StatusReason), "dispatch pending")
+		var linked *db.Request
+		if item.LinkedRequestID > 0 {
+			if other, ok := byID[item.LinkedRequestID]; ok {
+				linked = &other
+			} else if other, err := s.db.GetRequest(ctx, item.LinkedRequestID); err == nil {
+				linked = other
+			}
+		}
+		var pendingAge string
+		var ageLevel int
+		if item.Status == "pending" && !item.CreatedAt.IsZero() {
+			age := time.Since(item.CreatedAt)
+			pendingAge = formatPendingAge(age)
+			ageLevel = pendingAgeLevel(age, threshold)
+		}
+		var failure *providers.ReadarrFailure
+		if item.Status == "error" {
+			if f, ok := providers.ClassifyReadarrError(item.StatusReason); ok {
+				failure = &f
+			}
+		}
+		var needByLabel string
+		if needByOpen(item) {
+			needByLabel = formatNeedBy(item.NeedBy, now)
+		}
 		out = append(out, requestListItem{
 			Request:               item,
 			Cover:                 cover,
 			SearchEligible:        searchEligible,
 			SearchDispatchPending: searchDispatchPending,
+			Linked:                linked,
+			PendingAge:            pendingAge,
+			AgeLevel:              ageLevel,
+			Failure:               failure,
+			NeedsSelection:        !item.HasReadarrReq && !(item.Format == formatMagazine && magazinesByHand),
+			NeedByLabel:           needByLabel,
+			NeedByState:           needByState(item, now),
+			Custom:                custom[item.Status],
+			SourceLabel:           requestSourceLabel(item.Source),
+			Version:               requestVersion(&item),
+			AlternateEligible: linked == nil && hasOtherFormat(item.Format) && (strings.EqualFold(strings.TrimSpace(item.ExternalStatus), "available") ||
+				item.Status == "approved" || item.Status == "queued") && !otherFormatRequested(requested, &item),
 		})
 	}
 	return out
@@ -212,7 +384,7 @@ func (s *Server) requestListMatchedBooks(ctx context.Context, items []db.Request
 		if item.MatchedReadarrID <= 0 {
 			continue
 		}
-		kind := normalizeSyncKind(item.Format)
+		kind := normalizeSyncKind(requestReadarrKind(&item))
 		idsByKind[kind] = append(idsByKind[kind], item.MatchedReadarrID)
 	}
 
@@ -237,20 +409,26 @@ func requestListMatchedBookKey(format string, readarrID int64) string {
 }
 
 func (s *Server) requestListCoverData(req db.Request, matchedBooks map[string]db.ReadarrBook) string {
+	return s.displayCover(s.requestListCoverSource(req, matchedBooks), req.ISBN13, req.ISBN10)
+}
+
+// requestListCoverSource finds a request's cover: the stored one, then its
+// Readarr payloads, then the matched Readarr book.
+func (s *Server) requestListCoverSource(req db.Request, matchedBooks map[string]db.ReadarrBook) string {
 	if cover := strings.TrimSpace(req.CoverURL); cover != "" {
 		if normalized := s.normalizeRequestCover(req.Format, cover); normalized != "" {
 			return appendCoverIsbnFallback(normalized, req.ISBN13, req.ISBN10)
 		}
 	}
-	if cover := s.requestCoverFromPayload(req.Format, req.ReadarrResp); cover != "" {
+	if cover := s.requestCoverFromPayload(requestReadarrKind(&req), req.ReadarrResp); cover != "" {
 		return appendCoverIsbnFallback(cover, req.ISBN13, req.ISBN10)
 	}
 	if cover := s.requestCoverFromPayload(req.Format, req.ReadarrReq); cover != "" {
 		return appendCoverIsbnFallback(cover, req.ISBN13, req.ISBN10)
 	}
-	if key := requestListMatchedBookKey(req.Format, req.MatchedReadarrID); key != "" {
+	if key := requestListMatchedBookKey(requestReadarrKind(&req), req.MatchedReadarrID); key != "" {
 		if book, ok := matchedBooks[key]; ok && len(book.ReadarrData) > 0 {
-			if cover := s.requestCoverFromPayload(req.Format, book.ReadarrData); cover != "" {
+			if cover := s.requestCoverFromPayload(requestReadarrKind(&req), book.ReadarrData); cover != "" {
 				return appendCoverIsbnFallback(cover, req.ISBN13, req.ISBN10)
 			}
 		}
@@ -396,9 +574,13 @@ func (u *ui) handleUsers(s *Server) http.HandlerFunc {
 					return
 				}
 				hash, _ := s.hashPassword(password, s.settings.Get().Auth.Salt)
-				_, _ = s.db.CreateUser(r.Context(), username, hash, admin, autoApprove)
+				id, err := s.db.CreateUser(r.Context(), username, hash, admin, autoApprove)
+				group := s.formGroup(r)
+				if err == nil && group != "" {
+					_ = s.db.SetUserGroup(r.Context(), id, group)
+				}
 				actor := r.Context().Value(ctxUser).(*session).Username
-				s.auditLog(r.Context(), actor, "user.created", nil, fmt.Sprintf("username=%s, admin=%t, autoApprove=%t", username, admin, autoApprove))
+				s.auditLog(r.Context(), actor, "user.created", nil, fmt.Sprintf("username=%s, admin=%t, autoApprove=%t, group=%s", username, admin, autoApprove, group))
 			}
 			http.Redirect(w, r, "/users", http.StatusFound)
 			return
@@ -408,7 +590,12 @@ func (u *ui) handleUsers(s *Server) http.HandlerFunc {
 			"UserName":  s.userName(r),
 			"IsAdmin":   true,
 			"Users":     users,
+			"Groups":    s.settings.Get().Groups,
 			"CSRFToken": s.getCSRFToken(r),
+			"Notice":    r.URL.Query().Get("notice"),
+			"Error":     r.URL.Query().Get("error"),
+			// Directory is set when users can be imported from the IdP.
+			"Directory": strings.TrimSpace(s.settings.Get().OAuth.Directory.URL) != "",
 		}
 		_ = u.tpl.ExecuteTemplate(w, "users.html", data)
 	}
@@ -446,10 +633,24 @@ func (u *ui) handleAccount(s *Server) http.HandlerFunc {
 				if sv := strings.TrimSpace(s.settings.Get().Notifications.Ntfy.Server); sv != "" {
 					return sv
 				}
-				return "https://ntfy.sh"
+				return s.defaultNtfyServer()
 			}(),
 			"SMTPConfigured": strings.TrimSpace(s.settings.Get().Notifications.SMTP.Host) != "",
+			"SessionID":      ses.ID,
+			"SignedOut":      r.URL.Query().Get("signed_out"),
+		}
+		if shares, err := s.db.ListWishlistShares(r.Context(), ses.Username); err == nil {
+			data["WishlistShares"] = s.wishlistShareViews(shares)
 		}
+		data["Sessions"], _ = s.db.ListUserSessions(r.Context(), ses.Username)
+		data["PushSubscriptions"], _ = s.db.ListPushSubscriptions(r.Context(), ses.Username)
+		data["SearchSources"] = s.searchSourceViews(s.searchPrefsFor(r.Context(), ses.Username))
+		data["SearchSourcesCustom"] = acct != nil && acct.SearchProviders != ""
+		positions := make([]int, len(bookSearchProviders))
+		for i := range positions {
+			positions[i] = i + 1
+		}
+		data["SearchPositions"] = positions
 		_ = u.tpl.ExecuteTemplate(w, "account.html", data)
 	}
 }
@@ -483,6 +684,44 @@ func (u *ui) handleAccountSave(s *Server) http.HandlerFunc {
 	}
 }
 
+// handleAccountSignOutOthers revokes every session of the logged-in user
+// except the one making the request.
+func (u *ui) handleAccountSignOutOthers(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ses, _ := r.Context().Value(ctxUser).(*session)
+		if ses == nil {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		n, err := s.db.DeleteUserSessions(r.Context(), ses.Username, ses.ID)
+		if err != nil {
+			http.Error(w, "failed to sign out other sessions", http.StatusInternalServerError)
+			return
+		}
+		s.auditLog(r.Context(), ses.Username, "user.sessions_revoked", nil, fmt.Sprintf("%d session(s)", n))
+		http.Redirect(w, r, "/account?signed_out="+strconv.FormatInt(n, 10), http.StatusFound)
+	}
+}
+
+// handleAccountPushRemove turns push off for one of the logged-in user's
+// devices.
+func (u *ui) handleAccountPushRemove(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ses, _ := r.Context().Value(ctxUser).(*session)
+		if ses == nil {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		_ = r.ParseForm()
+		id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err := s.db.DeletePushSubscription(r.Context(), ses.Username, id); err != nil {
+			http.Error(w, "failed to remove device", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/account", http.StatusFound)
+	}
+}
+
 // handleAuditExport streams the audit log as a CSV download.
 func (u *ui) handleAuditExport(s *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
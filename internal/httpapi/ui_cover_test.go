@@ -2,11 +2,14 @@ package httpapi
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
 
 	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
 )
 
 func configureReadarrForCoverTests(t *testing.T, s *Server, baseURL string) {
@@ -152,3 +155,48 @@ func TestRequestListCoverDataFallsBackToStoredRequestPayload(t *testing.T) {
 		t.Fatalf("unexpected proxied payload cover URL: %q", q.Get("u"))
 	}
 }
+
+func TestCoverImagesModes(t *testing.T) {
+	s := makeTestServer(t)
+	configureReadarrForCoverTests(t, s, "https://readarr.example.internal")
+	proxied := "/ui/readarr-cover?u=" + url.QueryEscape("https://readarr.example.internal/MediaCover/12.jpg") + "&isbn=9780441172719"
+	external := "https://covers.openlibrary.org/b/id/11200092-M.jpg"
+	req := db.Request{ID: 1, Title: "Dune", Format: "ebook", ISBN13: "9780441172719", CoverURL: "https://readarr.example.internal/MediaCover/12.jpg"}
+
+	if got := s.requestListCoverData(req, nil); !strings.HasPrefix(got, "/ui/readarr-cover?") {
+		t.Fatalf("proxy mode cover = %q", got)
+	}
+
+	cfg := s.settings.Get()
+	cfg.Search.CoverImages = "direct"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.displayCover(proxied, "", ""); got != "https://covers.openlibrary.org/b/isbn/9780441172719-M.jpg" {
+		t.Fatalf("direct proxied cover = %q", got)
+	}
+	if got := s.displayCover(external, "", ""); got != external {
+		t.Fatalf("direct external cover = %q", got)
+	}
+	if got := s.displayCover("/ui/readarr-cover?u=x", "", ""); got != "" {
+		t.Fatalf("direct cover without an ISBN = %q", got)
+	}
+	if got := s.requestListCoverData(req, nil); strings.Contains(got, "readarr-cover") || got == "" {
+		t.Fatalf("direct request cover = %q", got)
+	}
+	rec := httptest.NewRecorder()
+	s.serveReadarrCover()(rec, httptest.NewRequest(http.MethodGet, proxied, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("proxy route with direct covers = %d", rec.Code)
+	}
+
+	cfg.Search.CoverImages = "none"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	items := []searchItem{{BookItem: providers.BookItem{Title: "Dune", CoverSmall: external, CoverMedium: proxied}}}
+	decorateSearchItems(s, items)
+	if items[0].CoverSmall != "" || items[0].CoverMedium != "" {
+		t.Fatalf("covers with none = %+v", items[0].BookItem)
+	}
+}
@@ -0,0 +1,158 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+const (
+	// usageReportDays is how many UTC days, today included, the dashboard
+	// usage panel covers.
+	usageReportDays = 7
+	// usageStatsTTL is how long daily cache and provider counters are kept.
+	usageStatsTTL = 90 * 24 * time.Hour
+)
+
+// metadataSourceLabels names the providers counted outside the search
+// fan-out.
+var metadataSourceLabels = map[string]string{
+	config.EnrichISBNdb:      "ISBNdb",
+	config.EnrichGoogleBooks: "Google Books",
+	config.EnrichAudnex:      "Audnex",
+}
+
+// countProviderCall adds one outbound call to name's count for today.
+// Readarr clients count their own calls, one per HTTP request.
+func (s *Server) countProviderCall(name string) {
+	if err := s.db.CountProviderCall(context.Background(), name); err != nil {
+		fmt.Printf("usage: failed to count a %s call: %v\n", name, err)
+	}
+}
+
+// cacheUsageRow is one cache type in the usage panel.
+type cacheUsageRow struct {
+	CacheType string `json:"cacheType"`
+	Hits      int64  `json:"hits"`
+	Misses    int64  `json:"misses"`
+	HitRate   int    `json:"hitRate"` // percent
+}
+
+// providerUsageRow is one provider's daily calls in the usage panel,
+// oldest day first.
+type providerUsageRow struct {
+	Name  string  `json:"name"`
+	Label string  `json:"label"`
+	Calls []int64 `json:"calls"`
+	Total int64   `json:"total"`
+}
+
+// usageReport is the dashboard usage panel.
+type usageReport struct {
+	Days      []string           `json:"days"` // YYYY-MM-DD, oldest first
+	Caches    []cacheUsageRow    `json:"caches"`
+	Providers []providerUsageRow `json:"providers"`
+}
+
+// usageReport totals cache hits and misses and the daily provider calls of
+// the last usageReportDays UTC days.
+func (s *Server) usageReport(ctx context.Context) (*usageReport, error) {
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, 1-usageReportDays)
+	out := &usageReport{Caches: []cacheUsageRow{}, Providers: []providerUsageRow{}}
+	dayIndex := map[string]int{}
+	for i := 0; i < usageReportDays; i++ {
+		day := since.AddDate(0, 0, i).Format("2006-01-02")
+		dayIndex[day] = i
+		out.Days = append(out.Days, day)
+	}
+
+	caches, err := s.db.CacheStatsSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range caches {
+		row := cacheUsageRow{CacheType: c.CacheType, Hits: c.Hits, Misses: c.Misses}
+		if total := c.Hits + c.Misses; total > 0 {
+			row.HitRate = int(c.Hits * 100 / total)
+		}
+		out.Caches = append(out.Caches, row)
+	}
+
+	calls, err := s.db.ProviderCallsSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	labels := s.providerUsageLabels()
+	for _, c := range calls {
+		i, ok := dayIndex[c.Day]
+		if !ok {
+			continue
+		}
+		if len(out.Providers) == 0 || out.Providers[len(out.Providers)-1].Name != c.Provider {
+			label := labels[c.Provider]
+			if label == "" {
+				label = strings.TrimPrefix(c.Provider, "readarr:")
+			}
+			out.Providers = append(out.Providers, providerUsageRow{Name: c.Provider, Label: label, Calls: make([]int64, usageReportDays)})
+		}
+		p := &out.Providers[len(out.Providers)-1]
+		p.Calls[i] += c.Calls
+		p.Total += c.Calls
+	}
+	return out, nil
+}
+
+// providerUsageLabels names every provider the usage counters know,
+// including the configured Readarr instances by their base URL.
+func (s *Server) providerUsageLabels() map[string]string {
+	labels := map[string]string{}
+	for _, p := range searchProviderLabels {
+		labels[p.Name] = p.Label
+	}
+	for name, label := range metadataSourceLabels {
+		labels[name] = label
+	}
+	for _, kind := range []string{"ebook", "audiobook"} {
+		if inst, ok := s.readarrInstanceForFormat(kind); ok {
+			label := "Readarr eBooks"
+			if kind == "audiobook" {
+				label = "Readarr audiobooks"
+			}
+			labels[providers.ReadarrUsageName(inst)] = label
+		}
+	}
+	return labels
+}
+
+// pruneUsageStats drops cache and provider counters older than
+// usageStatsTTL.
+func (s *Server) pruneUsageStats(ctx context.Context) {
+	if _, err := s.db.PruneUsageStats(ctx, time.Now().Add(-usageStatsTTL)); err != nil {
+		fmt.Printf("usage: prune failed: %v\n", err)
+	}
+}
+
+// apiUsageStats returns the dashboard usage panel as JSON.
+func (s *Server) apiUsageStats(w http.ResponseWriter, r *http.Request) {
+	report, err := s.usageReport(r.Context())
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, report, http.StatusOK)
+}
+
+// handleUsageStats renders the dashboard usage panel for admins.
+func (u *ui) handleUsageStats(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, _ := s.usageReport(r.Context())
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = u.tpl.ExecuteTemplate(w, "usage_stats", report)
+	}
+}
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/providers"
+)
+
+func TestUsageStatsCountCacheAndProviderCalls(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"title":"Dune","foreignBookId":"fb-1"}]`))
+	}))
+	defer readarr.Close()
+
+	s := newServerForTest(t)
+	cfg := *s.settings.Get()
+	cfg.Readarr.Ebooks.BaseURL, cfg.Readarr.Ebooks.APIKey = readarr.URL, "k"
+	if err := s.settings.Update(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	inst, _ := s.readarrInstanceForFormat("ebook")
+	ra := providers.NewReadarrWithDB(inst, s.db.SQL())
+	for i := 0; i < 3; i++ {
+		if _, err := ra.LookupByTerm(context.Background(), "dune"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.recordProviderCall(providerOpenLibrary, time.Now(), nil)
+	s.recordProviderCall(providerReadarrEbooks, time.Now(), nil)
+
+	report, err := s.usageReport(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Caches) != 1 || report.Caches[0] != (cacheUsageRow{CacheType: "lookup", Hits: 2, Misses: 1, HitRate: 66}) {
+		t.Fatalf("caches = %+v", report.Caches)
+	}
+	calls := map[string]int64{}
+	for _, p := range report.Providers {
+		calls[p.Label] = p.Total
+		if p.Calls[len(p.Calls)-1] != p.Total {
+			t.Fatalf("%s calls not counted today: %v", p.Label, p.Calls)
+		}
+	}
+	// Readarr's one real lookup is counted by its client, not the search.
+	if len(calls) != 2 || calls["Readarr eBooks"] != 1 || calls["Open Library"] != 1 {
+		t.Fatalf("provider calls = %v", calls)
+	}
+
+	router := s.Router()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/usage", nil)
+	req.AddCookie(makeCookie(t, s, "alice", false))
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin usage = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/usage", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	router.ServeHTTP(rec, req)
+	var out usageReport
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &out) != nil || len(out.Days) != usageReportDays {
+		t.Fatalf("usage = %d %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/ui/admin/usage", nil)
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	router.ServeHTTP(rec, req)
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, "lookup") || !strings.Contains(body, "66%") {
+		t.Fatalf("usage panel = %d %s", rec.Code, body)
+	}
+}
@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestDeactivateAndAnonymizeUser(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	ctx := context.Background()
+	hash, _ := s.hashPassword("correct-horse", s.settings.Get().Auth.Salt)
+	id, err := s.db.CreateUser(ctx, "carol", hash, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.db.SetUserEmail(ctx, id, "carol@example.com")
+	rid, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "carol", Title: "Dune", Format: "ebook", Status: "pending"})
+	bid, _ := s.db.CreateRequest(ctx, &db.Request{RequesterEmail: "bob", Title: "Emma", Format: "ebook", Status: "pending"})
+	subID, _ := s.db.CreateSubscription(ctx, &db.Subscription{RequesterEmail: "carol", Title: "Wired", Frequency: "weekly", Active: true})
+
+	post := func(path string, form url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	admin := makeCookie(t, s, "admin", true)
+	uid := url.Values{"id": {strconv.FormatInt(id, 10)}}
+
+	// Rows in every other table and column that can name carol.
+	for _, q := range []string{
+		`UPDATE requests SET approver_email='carol', first_approver='carol', admin_note='ok', admin_note_by='carol' WHERE id=` + strconv.FormatInt(bid, 10),
+		`INSERT INTO request_attachments (request_id, kind, uploaded_by, created_at) VALUES (1, 'link', 'carol', '2026-01-01T00:00:00Z')`,
+		`INSERT INTO audit_events (ts, actor_email, event_type, details) VALUES ('2026-01-01T00:00:00Z', 'carol', 'request.created', '')`,
+		`INSERT INTO idempotency_keys (username, idem_key, fingerprint, created_at) VALUES ('carol', 'k', 'f', '2026-01-01T00:00:00Z')`,
+		`INSERT INTO sessions (id, username, created_at, last_seen_at, expires_at) VALUES ('sid', 'carol', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z', '2099-01-01T00:00:00Z')`,
+		`INSERT INTO push_subscriptions (username, endpoint, p256dh, auth, created_at) VALUES ('carol', 'https://push.example/1', 'k', 'a', '2026-01-01T00:00:00Z')`,
+		`INSERT INTO request_watchers (request_id, username) VALUES (` + strconv.FormatInt(bid, 10) + `, 'carol')`,
+		`INSERT INTO denylist (kind, value, created_by, created_at) VALUES ('title', 'Spam', 'carol', '2026-01-01T00:00:00Z')`,
+		`INSERT INTO guest_requests (created_at, name, title, status, decided_by) VALUES ('2026-01-01T00:00:00Z', 'Gail', 'Persuasion', 'declined', 'carol')`,
+		`INSERT INTO wishlist_shares (token, username, created_at) VALUES ('carol-share', 'carol', '2026-01-01T00:00:00Z')`,
+		`INSERT INTO notification_log (created_at, updated_at, event, provider, target, status) VALUES ('2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z', 'request.approved', 'smtp', 'user:carol', 'sent')`,
+	} {
+		if err := s.db.Exec(ctx, q); err != nil {
+			t.Fatalf("seed %q: %v", q, err)
+		}
+	}
+
+	if rec := post("/users/deactivate", uid, admin); rec.Code != http.StatusFound {
+		t.Fatalf("deactivate: %d", rec.Code)
+	}
+	rec := post("/login", url.Values{"username": {"carol"}, "password": {"correct-horse"}}, nil)
+	if !strings.Contains(rec.Header().Get("Location"), "Account+disabled") {
+		t.Fatalf("login while deactivated: %s", rec.Header().Get("Location"))
+	}
+	for _, u := range s.activeUsers(ctx) {
+		if u.Username == "carol" {
+			t.Fatal("deactivated user offered in pickers")
+		}
+	}
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/requests/"+strconv.FormatInt(rid, 10)+"/requester", strings.NewReader(`{"username":"carol"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(admin)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("reassign to deactivated: %d", rr.Code)
+	}
+
+	if rec := post("/users/anonymize", uid, admin); !strings.Contains(rec.Header().Get("Location"), "former-user-") {
+		t.Fatalf("anonymize: %s", rec.Header().Get("Location"))
+	}
+	u, err := s.db.GetUserByID(ctx, id)
+	if err != nil || u.Username != "former-user-"+strconv.FormatInt(id, 10) || u.Email != "" || !u.Disabled {
+		t.Fatalf("anonymized user = %+v, %v", u, err)
+	}
+	req1, _ := s.db.GetRequest(ctx, rid)
+	if req1.RequesterEmail != u.Username {
+		t.Fatalf("request requester = %q", req1.RequesterEmail)
+	}
+	if n, _ := s.db.CountPendingRequestsByUser(ctx, "carol"); n != 0 {
+		t.Fatalf("carol still has %d requests", n)
+	}
+	if n, _ := s.db.CountPendingRequestsByUser(ctx, "bob"); n != 1 {
+		t.Fatalf("bob's request touched: %d", n)
+	}
+	if _, err := s.db.GetUserByUsername(ctx, "carol"); err == nil {
+		t.Fatal("carol still exists")
+	}
+	if sub, _ := s.db.GetSubscription(ctx, subID); sub == nil || sub.RequesterEmail != u.Username || sub.Active {
+		t.Fatalf("subscription after anonymize = %+v", sub)
+	}
+	// Nothing that identifies carol is left behind.
+	for _, c := range []struct{ table, column string }{
+		{"users", "username"},
+		{"requests", "requester_email"},
+		{"requests", "approver_email"},
+		{"requests", "first_approver"},
+		{"requests", "admin_note_by"},
+		{"request_attachments", "uploaded_by"},
+		{"audit_events", "actor_email"},
+		{"idempotency_keys", "username"},
+		{"sessions", "username"},
+		{"push_subscriptions", "username"},
+		{"subscriptions", "requester_email"},
+		{"request_watchers", "username"},
+		{"denylist", "created_by"},
+		{"guest_requests", "decided_by"},
+		{"wishlist_shares", "username"},
+		{"notification_log", "target"},
+	} {
+		var n int
+		if err := s.db.SQL().QueryRowContext(ctx, `SELECT COUNT(1) FROM `+c.table+` WHERE `+c.column+` IN ('carol', 'user:carol')`).Scan(&n); err != nil || n != 0 {
+			t.Errorf("%s.%s still names carol: %d, %v", c.table, c.column, n, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userImportRow is one account to pre-provision, from a CSV upload or the
+// identity provider's directory.
+type userImportRow struct {
+	Username    string
+	Email       string
+	Admin       bool
+	AutoApprove bool
+	Group       string
+	MaxPending  int
+	// Partial rows come from the directory, which knows nothing of
+	// auto-approve or caps, so updates leave those alone.
+	Partial bool
+}
+
+// userImportMaxRows bounds a single import so a runaway directory or file
+// cannot stall the users page.
+const userImportMaxRows = 5000
+
+// parseUserImportCSV reads rows with a header naming any of username
+// (required), email, role (admin or user), auto_approve, group, and
+// max_pending, in any order.
+func parseUserImportCSV(r io.Reader) ([]userImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	if _, ok := col["username"]; !ok {
+		return nil, errors.New("the header must include a username column")
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var rows []userImportRow
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) >= userImportMaxRows {
+			return nil, fmt.Errorf("imports are limited to %d users", userImportMaxRows)
+		}
+		row := userImportRow{
+			Username:    field(rec, "username"),
+			Email:       field(rec, "email"),
+			AutoApprove: readarrTruthy(field(rec, "auto_approve")),
+			Group:       field(rec, "group"),
+		}
+		if row.Username == "" {
+			continue
+		}
+		switch role := strings.ToLower(field(rec, "role")); role {
+		case "admin":
+			row.Admin = true
+		case "", "user":
+		default:
+			return nil, fmt.Errorf("line %d: unknown role %q", line, role)
+		}
+		if v := field(rec, "max_pending"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("line %d: max_pending must be a whole number", line)
+			}
+			row.MaxPending = n
+		}
+		rows = append(rows, row)
+	}
+}
+
+// fetchDirectoryUsers lists the active users of the SCIM 2.0 directory in
+// oauth.directory. Members of its admin group become admins, and the first
+// of a user's directory groups that names a household group is used.
+func (s *Server) fetchDirectoryUsers(ctx context.Context) ([]userImportRow, error) {
+	cfg := s.settings.Get()
+	dir := cfg.OAuth.Directory
+	base := strings.TrimRight(strings.TrimSpace(dir.URL), "/")
+	if base == "" {
+		return nil, errors.New("no directory is configured")
+	}
+	cl := s.outboundHTTPClient(20 * time.Second)
+	var rows []userImportRow
+	for start := 1; ; {
+		var page struct {
+			TotalResults int `json:"totalResults"`
+			Resources    []struct {
+				UserName string `json:"userName"`
+				Active   *bool  `json:"active"`
+				Emails   []struct {
+					Value   string `json:"value"`
+					Primary bool   `json:"primary"`
+				} `json:"emails"`
+				Groups []struct {
+					Display string `json:"display"`
+				} `json:"groups"`
+			} `json:"Resources"`
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/Users?"+url.Values{"startIndex": {strconv.Itoa(start)}, "count": {"100"}}.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/scim+json, application/json")
+		if tok := strings.TrimSpace(dir.Token); tok != "" {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+		resp, err := cl.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				return fmt.Errorf("directory returned http %d", resp.StatusCode)
+			}
+			return json.NewDecoder(resp.Body).Decode(&page)
+		}()
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range page.Resources {
+			if res.UserName == "" || (res.Active != nil && !*res.Active) {
+				continue
+			}
+			row := userImportRow{Username: res.UserName, Partial: true}
+			for _, e := range res.Emails {
+				if row.Email == "" || e.Primary {
+					row.Email = e.Value
+				}
+			}
+			for _, g := range res.Groups {
+				if dir.AdminGroup != "" && strings.EqualFold(g.Display, dir.AdminGroup) {
+					row.Admin = true
+				}
+				if hg, ok := cfg.Group(g.Display); ok && row.Group == "" {
+					row.Group = hg.Name
+				}
+			}
+			rows = append(rows, row)
+		}
+		start += len(page.Resources)
+		if len(page.Resources) == 0 || start > page.TotalResults || len(rows) >= userImportMaxRows {
+			return rows, nil
+		}
+	}
+}
+
+// importUsers creates the accounts in rows that do not exist yet and updates
+// the role, group, and cap of those that do. New accounts get an unusable
+// password, so they sign in through OAuth until an admin sets one. Rows
+// naming an unknown group are skipped and reported.
+func (s *Server) importUsers(ctx context.Context, rows []userImportRow, actor string) (created, updated int, problems []string) {
+	for _, row := range rows {
+		username := strings.ToLower(strings.TrimSpace(row.Username))
+		group := ""
+		if strings.TrimSpace(row.Group) != "" {
+			g, ok := s.settings.Get().Group(row.Group)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown group %q", username, row.Group))
+				continue
+			}
+			group = g.Name
+		}
+		admin := row.Admin || s.isAdminUsername(username)
+		var id int64
+		if u, err := s.db.GetUserByUsername(ctx, username); err == nil {
+			id = u.ID
+			_ = s.db.SetUserAdmin(ctx, id, admin)
+			if !row.Partial {
+				_ = s.db.SetUserAutoApprove(ctx, id, row.AutoApprove)
+				_ = s.db.SetUserMaxPending(ctx, id, row.MaxPending)
+			}
+			updated++
+		} else {
+			id, err = s.db.CreateUser(ctx, username, oauthPlaceholderHash, admin, row.AutoApprove)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", username, err))
+				continue
+			}
+			created++
+			_ = s.db.SetUserMaxPending(ctx, id, row.MaxPending)
+		}
+		_ = s.db.SetUserGroup(ctx, id, group)
+		_ = s.db.SetUserEmailIfEmpty(ctx, username, row.Email)
+	}
+	s.auditLog(ctx, actor, "user.imported", nil, fmt.Sprintf("created=%d, updated=%d, skipped=%d", created, updated, len(problems)))
+	return created, updated, problems
+}
+
+// handleUserImport imports users from the directory (source=directory) or
+// from an uploaded or pasted CSV, then returns to /users with a summary.
+func (s *Server) handleUserImport(w http.ResponseWriter, r *http.Request) {
+	back := func(key, msg string) {
+		http.Redirect(w, r, "/users?"+key+"="+url.QueryEscape(msg), http.StatusFound)
+	}
+	if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		back("error", "could not read the upload")
+		return
+	}
+	var rows []userImportRow
+	var err error
+	if r.FormValue("source") == "directory" {
+		rows, err = s.fetchDirectoryUsers(r.Context())
+	} else if f, _, ferr := r.FormFile("csv_file"); ferr == nil {
+		defer f.Close()
+		rows, err = parseUserImportCSV(f)
+	} else {
+		rows, err = parseUserImportCSV(strings.NewReader(r.FormValue("csv")))
+	}
+	if err != nil {
+		back("error", "import failed: "+err.Error())
+		return
+	}
+	actor := r.Context().Value(ctxUser).(*session).Username
+	created, updated, problems := s.importUsers(r.Context(), rows, actor)
+	msg := fmt.Sprintf("Imported %d new and updated %d existing users.", created, updated)
+	if len(problems) > 0 {
+		msg += " Skipped: " + strings.Join(problems, "; ")
+	}
+	back("notice", msg)
+}
@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/config"
+)
+
+func TestUserImportCSVCreatesAndUpdatesUsers(t *testing.T) {
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.Groups = []config.GroupConfig{{Name: "Kids"}}
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "bob", "hash", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseUserImportCSV(strings.NewReader("username,role\nzed,owner\n")); err == nil {
+		t.Fatal("unknown role accepted")
+	}
+
+	csv := "\ufeffUsername,Email,Role,Auto_Approve,Group,Max_Pending\n" +
+		"Alice,alice@example.com,admin,yes,,\n" +
+		"bob,bob@example.com,user,no,kids,2\n" +
+		"carol,,user,,Nowhere,\n"
+	form := url.Values{"_csrf_token": {s.getCSRFToken(httptest.NewRequest("GET", "/", nil))}, "csv": {csv}}
+	req := httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(makeCookie(t, s, "admin", true))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("import: %d %s", rec.Code, rec.Body.String())
+	}
+	loc, _ := url.Parse(rec.Header().Get("Location"))
+	if notice := loc.Query().Get("notice"); !strings.Contains(notice, "1 new and updated 1") || !strings.Contains(notice, "carol") {
+		t.Fatalf("redirect = %s", loc)
+	}
+
+	alice, err := s.db.GetUserByUsername(ctx, "alice")
+	if err != nil || !alice.IsAdmin || !alice.AutoApprove || alice.Email != "alice@example.com" {
+		t.Fatalf("alice = %+v, %v", alice, err)
+	}
+	bob, _ := s.db.GetUserByUsername(ctx, "bob")
+	if !strings.EqualFold(bob.GroupName, "kids") || bob.MaxPending != 2 {
+		t.Fatalf("bob = %+v", bob)
+	}
+	if q := s.requestQuota(ctx, "bob", bob.GroupName); q.Limit != 2 {
+		t.Fatalf("bob quota = %+v", q)
+	}
+	if _, err := s.db.GetUserByUsername(ctx, "carol"); err == nil {
+		t.Fatal("carol was created despite an unknown group")
+	}
+}
+
+func TestUserImportFromDirectory(t *testing.T) {
+	dir := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer scim-token" || r.URL.Path != "/scim/v2/Users" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("startIndex") != "1" {
+			w.Write([]byte(`{"totalResults":3,"Resources":[{"userName":"dana","emails":[{"value":"dana@example.com","primary":true}]}]}`))
+			return
+		}
+		w.Write([]byte(`{"totalResults":3,"Resources":[
+			{"userName":"erin","active":true,"groups":[{"display":"Scriptorum Admins"}]},
+			{"userName":"gone","active":false}]}`))
+	}))
+	defer dir.Close()
+
+	s := newServerForTest(t)
+	cfg := s.settings.Get()
+	cfg.OAuth.Directory.URL = dir.URL + "/scim/v2/"
+	cfg.OAuth.Directory.Token = "scim-token"
+	cfg.OAuth.Directory.AdminGroup = "scriptorum admins"
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "dana", "hash", false, true); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := s.fetchDirectoryUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	created, updated, problems := s.importUsers(ctx, rows, "admin")
+	if created != 1 || updated != 1 || len(problems) != 0 {
+		t.Fatalf("created=%d updated=%d problems=%v", created, updated, problems)
+	}
+	if erin, err := s.db.GetUserByUsername(ctx, "erin"); err != nil || !erin.IsAdmin {
+		t.Fatalf("erin = %+v, %v", erin, err)
+	}
+	// Directory rows leave per-user settings alone.
+	if dana, _ := s.db.GetUserByUsername(ctx, "dana"); !dana.AutoApprove || dana.Email != "dana@example.com" {
+		t.Fatalf("dana = %+v", dana)
+	}
+}
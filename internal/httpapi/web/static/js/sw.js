@@ -0,0 +1,38 @@
+// Service worker: shows Web Push notifications and opens the linked page
+// when one is tapped. Pages are not cached for offline use.
+self.addEventListener('install', function () {
+	self.skipWaiting();
+});
+
+self.addEventListener('activate', function (event) {
+	event.waitUntil(self.clients.claim());
+});
+
+self.addEventListener('push', function (event) {
+	var data = {};
+	if (event.data) {
+		try {
+			data = event.data.json();
+		} catch (e) {
+			data = { body: event.data.text() };
+		}
+	}
+	event.waitUntil(self.registration.showNotification(data.title || 'Scriptorum', {
+		body: data.body || '',
+		icon: '/static/icon.svg',
+		data: { url: data.url || '/requests' }
+	}));
+});
+
+self.addEventListener('notificationclick', function (event) {
+	event.notification.close();
+	var url = new URL((event.notification.data && event.notification.data.url) || '/requests', self.location.origin).href;
+	event.waitUntil(self.clients.matchAll({ type: 'window', includeUncontrolled: true }).then(function (wins) {
+		for (var i = 0; i < wins.length; i++) {
+			if (new URL(wins[i].url).origin === self.location.origin && 'focus' in wins[i]) {
+				return wins[i].focus().then(function (w) { return w.navigate(url); });
+			}
+		}
+		return self.clients.openWindow(url);
+	}));
+});
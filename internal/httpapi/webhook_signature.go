@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webhookTimestampHeader carries the Unix time a signed inbound call was
+// made; the signature covers it so an old call cannot be replayed with a
+// fresh timestamp.
+const webhookTimestampHeader = "X-Scriptorum-Timestamp"
+
+// defaultWebhookSignatureTolerance is how far a signed call's timestamp may
+// be from the server's clock when the webhook sets no tolerance of its own.
+const defaultWebhookSignatureTolerance = 5 * time.Minute
+
+var (
+	errWebhookUnsigned  = errors.New("webhook call is not signed")
+	errWebhookStale     = errors.New("webhook timestamp is outside the allowed window")
+	errWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// signInboundWebhook returns the signature header value for a call made at
+// timestamp with body: the same "sha256=" HMAC outbound webhooks use, over
+// "<timestamp>.<body>".
+func signInboundWebhook(secret, timestamp string, body []byte) string {
+	return signWebhookBody(secret, append([]byte(timestamp+"."), body...))
+}
+
+// verifyWebhookSignature checks the signature headers of an inbound webhook
+// call against secret. tolerance bounds the clock difference in either
+// direction; 0 uses defaultWebhookSignatureTolerance.
+func verifyWebhookSignature(secret string, tolerance time.Duration, h http.Header, body []byte, now time.Time) error {
+	ts := strings.TrimSpace(h.Get(webhookTimestampHeader))
+	sig := strings.TrimSpace(h.Get(webhookSignatureHeader))
+	if ts == "" || sig == "" {
+		return errWebhookUnsigned
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errWebhookStale
+	}
+	if tolerance <= 0 {
+		tolerance = defaultWebhookSignatureTolerance
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return errWebhookStale
+	}
+	if !hmac.Equal([]byte(strings.ToLower(sig)), []byte(signInboundWebhook(secret, ts, body))) {
+		return errWebhookSignature
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"title":"Dune"}`)
+	headers := func(ts int64, sig string) http.Header {
+		h := http.Header{}
+		h.Set(webhookTimestampHeader, strconv.FormatInt(ts, 10))
+		h.Set(webhookSignatureHeader, sig)
+		return h
+	}
+	ts := now.Unix()
+	good := signInboundWebhook("secret", strconv.FormatInt(ts, 10), body)
+	cases := []struct {
+		name      string
+		h         http.Header
+		body      []byte
+		tolerance time.Duration
+		want      error
+	}{
+		{"valid", headers(ts, good), body, 0, nil},
+		{"unsigned", http.Header{}, body, 0, errWebhookUnsigned},
+		{"tampered body", headers(ts, good), []byte(`{"title":"Emma"}`), 0, errWebhookSignature},
+		{"replayed timestamp", headers(ts+1, good), body, 0, errWebhookSignature},
+		{"too old", headers(ts-301, signInboundWebhook("secret", strconv.FormatInt(ts-301, 10), body)), body, 0, errWebhookStale},
+		{"within custom tolerance", headers(ts-600, signInboundWebhook("secret", strconv.FormatInt(ts-600, 10), body)), body, 15 * time.Minute, nil},
+		{"from the future", headers(ts+600, signInboundWebhook("secret", strconv.FormatInt(ts+600, 10), body)), body, 0, errWebhookStale},
+	}
+	for _, c := range cases {
+		if err := verifyWebhookSignature("secret", c.tolerance, c.h, c.body, now); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
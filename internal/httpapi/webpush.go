@@ -0,0 +1,371 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// webPushTTL is how long a push service keeps a message for a device
+	// that is offline.
+	webPushTTL       = 24 * time.Hour
+	webPushRecord    = 4096
+	maxPushEndpoint  = 2048
+	maxPushUserAgent = 200
+)
+
+var b64url = base64.RawURLEncoding
+
+// webPushMessage is the JSON the service worker turns into a notification.
+type webPushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url,omitempty"`
+}
+
+// vapidKey returns the server's VAPID signing key and its public half as
+// the browser wants it, creating and saving the pair on first use.
+func (s *Server) vapidKey() (*ecdsa.PrivateKey, string, error) {
+	s.vapidMu.Lock()
+	defer s.vapidMu.Unlock()
+	cfg := s.settings.Get()
+	wp := cfg.Notifications.WebPush
+	if wp.PrivateKey != "" {
+		d, err := b64url.DecodeString(wp.PrivateKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("web push: bad vapid_private_key: %w", err)
+		}
+		key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d)
+		if err != nil {
+			return nil, "", fmt.Errorf("web push: bad vapid_private_key: %w", err)
+		}
+		pub, err := key.PublicKey.Bytes()
+		if err != nil {
+			return nil, "", err
+		}
+		return key, b64url.EncodeToString(pub), nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	d, err := key.Bytes()
+	if err != nil {
+		return nil, "", err
+	}
+	pub, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, "", err
+	}
+	cfg.Notifications.WebPush.PrivateKey = b64url.EncodeToString(d)
+	cfg.Notifications.WebPush.PublicKey = b64url.EncodeToString(pub)
+	if err := s.settings.Update(cfg); err != nil {
+		return nil, "", err
+	}
+	return key, cfg.Notifications.WebPush.PublicKey, nil
+}
+
+// vapidAuthorization signs the RFC 8292 JWT that identifies this server to
+// the push service behind endpoint.
+func (s *Server) vapidAuthorization(endpoint string) (string, error) {
+	key, pub, err := s.vapidKey()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" && u.Scheme != "http" {
+		return "", errors.New("web push: bad endpoint")
+	}
+	subject := strings.TrimSpace(s.settings.Get().Notifications.WebPush.Subject)
+	if subject == "" {
+		subject = strings.TrimSpace(s.settings.Get().ServerURL)
+	}
+	if subject == "" {
+		subject = "mailto:admin@localhost"
+	}
+	header := b64url.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, _ := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	unsigned := header + "." + b64url.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, sig, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	raw := make([]byte, 64)
+	r.FillBytes(raw[:32])
+	sig.FillBytes(raw[32:])
+	return "vapid t=" + unsigned + "." + b64url.EncodeToString(raw) + ", k=" + pub, nil
+}
+
+// encryptWebPush encrypts plaintext for a subscription's keys with the
+// aes128gcm content coding of RFC 8291, as a single record.
+func encryptWebPush(p256dh, auth string, plaintext []byte) ([]byte, error) {
+	uaBytes, err := b64url.DecodeString(strings.TrimRight(p256dh, "="))
+	if err != nil {
+		return nil, errors.New("web push: bad p256dh key")
+	}
+	secret, err := b64url.DecodeString(strings.TrimRight(auth, "="))
+	if err != nil || len(secret) == 0 {
+		return nil, errors.New("web push: bad auth secret")
+	}
+	uaPub, err := ecdh.P256().NewPublicKey(uaBytes)
+	if err != nil {
+		return nil, errors.New("web push: bad p256dh key")
+	}
+	asPriv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := asPriv.ECDH(uaPub)
+	if err != nil {
+		return nil, err
+	}
+	asBytes := asPriv.PublicKey().Bytes()
+	ikm, err := hkdf.Key(sha256.New, shared, secret, "WebPush: info\x00"+string(uaBytes)+string(asBytes), 32)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext)+1+gcm.Overhead() > webPushRecord {
+		return nil, errors.New("web push: message too long")
+	}
+	var out bytes.Buffer
+	out.Write(salt)
+	_ = binary.Write(&out, binary.BigEndian, uint32(webPushRecord))
+	out.WriteByte(byte(len(asBytes)))
+	out.Write(asBytes)
+	// 0x02 marks the last (and only) record.
+	out.Write(gcm.Seal(nil, nonce, append(plaintext, 0x02), nil))
+	return out.Bytes(), nil
+}
+
+// sendWebPush delivers msg to one subscription. A subscription the push
+// service no longer knows is forgotten.
+func (s *Server) sendWebPush(sub db.PushSubscription, msg webPushMessage) error {
+	payload, _ := json.Marshal(msg)
+	body, err := encryptWebPush(sub.P256DH, sub.Auth, payload)
+	if err != nil {
+		return err
+	}
+	authz, err := s.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authz)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(webPushTTL.Seconds())))
+	resp, err := s.outboundHTTPClient(10 * time.Second).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send web push: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		_ = s.db.DeletePushEndpoint(context.Background(), sub.Endpoint)
+	}
+	if resp.StatusCode >= 400 {
+		return &notifyStatusError{What: "push service", Code: resp.StatusCode}
+	}
+	return nil
+}
+
+// pushToUser sends msg to every device username turned push on for.
+func (s *Server) pushToUser(event, username string, msg webPushMessage) {
+	subs, err := s.db.ListPushSubscriptions(context.Background(), username)
+	if err != nil {
+		return
+	}
+	for _, sub := range subs {
+		s.deliverNotification(event, "webpush", "user:"+username, func() error { return s.sendWebPush(sub, msg) })
+	}
+}
+
+// ----- API -----
+
+func (s *Server) mountWebPush(r chi.Router) {
+	r.Route("/api/v1/push", func(pr chi.Router) {
+		pr.Get("/key", s.requireLogin(s.apiPushKey))
+		pr.Post("/subscriptions", s.requireLogin(s.apiPushSubscribe))
+		pr.Delete("/subscriptions", s.requireLogin(s.apiPushUnsubscribe))
+		pr.Post("/test", s.requireLogin(s.apiPushTest))
+	})
+}
+
+// apiPushKey returns the VAPID public key for PushManager.subscribe.
+func (s *Server) apiPushKey(w http.ResponseWriter, r *http.Request) {
+	_, pub, err := s.vapidKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"publicKey": pub}, http.StatusOK)
+}
+
+// pushSubscriptionJSON is PushSubscription.toJSON() from the browser.
+type pushSubscriptionJSON struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256DH string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// apiPushSubscribe saves the calling browser's push subscription for the
+// signed-in user.
+func (s *Server) apiPushSubscribe(w http.ResponseWriter, r *http.Request) {
+	var in pushSubscriptionJSON
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	u, err := url.Parse(in.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" || len(in.Endpoint) > maxPushEndpoint {
+		http.Error(w, "endpoint must be an https URL", http.StatusBadRequest)
+		return
+	}
+	if _, err := encryptWebPush(in.Keys.P256DH, in.Keys.Auth, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	username := r.Context().Value(ctxUser).(*session).Username
+	id, err := s.db.SavePushSubscription(r.Context(), db.PushSubscription{
+		Username:  username,
+		Endpoint:  in.Endpoint,
+		P256DH:    in.Keys.P256DH,
+		Auth:      in.Keys.Auth,
+		UserAgent: truncateChars(r.UserAgent(), maxPushUserAgent),
+	})
+	if err != nil {
+		http.Error(w, "db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"id": id}, http.StatusCreated)
+}
+
+// apiPushUnsubscribe forgets the browser whose endpoint is in the body, if
+// it belongs to the signed-in user.
+func (s *Server) apiPushUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	var in pushSubscriptionJSON
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	username := r.Context().Value(ctxUser).(*session).Username
+	subs, _ := s.db.ListPushSubscriptions(r.Context(), username)
+	for _, sub := range subs {
+		if sub.Endpoint == in.Endpoint {
+			_ = s.db.DeletePushSubscription(r.Context(), username, sub.ID)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiPushTest sends a test notification to all of the user's devices.
+func (s *Server) apiPushTest(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxUser).(*session).Username
+	subs, err := s.db.ListPushSubscriptions(r.Context(), username)
+	if err != nil || len(subs) == 0 {
+		http.Error(w, "push is not turned on for any of your devices", http.StatusBadRequest)
+		return
+	}
+	msg := webPushMessage{Title: s.instanceName(), Body: "Push notifications are working.", URL: "/account"}
+	sent := 0
+	var lastErr error
+	for _, sub := range subs {
+		if err := s.sendWebPush(sub, msg); err != nil {
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		writeJSON(w, map[string]any{"success": false, "error": lastErr.Error()}, http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, map[string]any{"success": true, "sent": sent}, http.StatusOK)
+}
+
+// ----- Manifest and service worker -----
+
+// handleServiceWorker serves the service worker from the site root so it
+// controls every page.
+func (s *Server) handleServiceWorker(w http.ResponseWriter, r *http.Request) {
+	js, _ := staticFS.ReadFile("web/static/js/sw.js")
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(js)
+}
+
+// handleManifest serves the web app manifest with the instance's name,
+// accent color, and uploaded logo.
+func (s *Server) handleManifest(w http.ResponseWriter, r *http.Request) {
+	name := s.instanceName()
+	theme := s.branding().Accent()
+	if theme == "" {
+		theme = "#7c3aed"
+	}
+	icons := []map[string]string{{"src": "/static/icon.svg", "sizes": "any", "type": "image/svg+xml", "purpose": "any"}}
+	if logo := s.uploadedLogo(); logo != "" {
+		ct, _ := logoContentType(logo)
+		icons = append([]map[string]string{{"src": brandingLogoPath, "sizes": "any", "type": ct}}, icons...)
+	}
+	w.Header().Set("Content-Type", "application/manifest+json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"name":             name,
+		"short_name":       name,
+		"start_url":        "/search",
+		"scope":            "/",
+		"display":          "standalone",
+		"background_color": "#0b0b13",
+		"theme_color":      theme,
+		"icons":            icons,
+	})
+}
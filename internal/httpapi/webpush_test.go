@@ -0,0 +1,153 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// decryptWebPush is the browser's side of encryptWebPush.
+func decryptWebPush(t *testing.T, priv *ecdh.PrivateKey, auth, body []byte) []byte {
+	t.Helper()
+	salt, idLen := body[:16], int(body[20])
+	asPub, err := ecdh.P256().NewPublicKey(body[21 : 21+idLen])
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, _ := priv.ECDH(asPub)
+	ikm, _ := hkdf.Key(sha256.New, shared, auth, "WebPush: info\x00"+string(priv.PublicKey().Bytes())+string(asPub.Bytes()), 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, body[21+idLen:], nil)
+	if err != nil || plain[len(plain)-1] != 0x02 {
+		t.Fatalf("decrypt: %v", err)
+	}
+	return plain[:len(plain)-1]
+}
+
+// checkVAPID verifies the JWT in a vapid Authorization header.
+func checkVAPID(t *testing.T, header string) {
+	t.Helper()
+	tok, key, ok := strings.Cut(strings.TrimPrefix(header, "vapid t="), ", k=")
+	if !ok {
+		t.Fatalf("authorization = %q", header)
+	}
+	pubBytes, _ := b64url.DecodeString(key)
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), pubBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(tok, ".")
+	sig, _ := b64url.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Fatalf("VAPID signature does not verify: %q", header)
+	}
+}
+
+func TestWebPushSubscribeAndDeliver(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	cfg := s.settings.Get()
+	cfg.InsecureSkipVerify = true
+	if err := s.settings.Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Router()
+	cookie := makeCookie(t, s, "alice", false)
+
+	browserKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	got := make(chan webPushMessage, 1)
+	gone := false
+	push := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gone {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		checkVAPID(t, r.Header.Get("Authorization"))
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var msg webPushMessage
+		if err := json.Unmarshal(decryptWebPush(t, browserKey, auth, body), &msg); err != nil {
+			t.Error(err)
+		}
+		got <- msg
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer push.Close()
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Test Phone")
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := call(http.MethodGet, "/api/v1/push/key", "")
+	if rec.Code != http.StatusOK || s.settings.Get().Notifications.WebPush.PublicKey == "" || !strings.Contains(rec.Body.String(), s.settings.Get().Notifications.WebPush.PublicKey) {
+		t.Fatalf("key: %d %s", rec.Code, rec.Body.String())
+	}
+	sub := `{"endpoint":"` + push.URL + `/send/abc","keys":{"p256dh":"` + b64url.EncodeToString(browserKey.PublicKey().Bytes()) + `","auth":"` + b64url.EncodeToString(auth) + `"}}`
+	if rec := call(http.MethodPost, "/api/v1/push/subscriptions", `{"endpoint":"http://push.example.com/x","keys":{"p256dh":"x","auth":"y"}}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("plain http endpoint: %d", rec.Code)
+	}
+	if rec := call(http.MethodPost, "/api/v1/push/subscriptions", sub); rec.Code != http.StatusCreated {
+		t.Fatalf("subscribe: %d %s", rec.Code, rec.Body.String())
+	}
+	subs, _ := s.db.ListPushSubscriptions(ctx, "alice")
+	if len(subs) != 1 || subs[0].UserAgent != "Test Phone" {
+		t.Fatalf("subscriptions = %+v", subs)
+	}
+
+	if rec := call(http.MethodPost, "/api/v1/push/test", ""); rec.Code != http.StatusOK {
+		t.Fatalf("test push: %d %s", rec.Code, rec.Body.String())
+	}
+	if msg := <-got; msg.Title != s.instanceName() || msg.URL != "/account" {
+		t.Fatalf("test message = %+v", msg)
+	}
+
+	// A subscription the push service has dropped is forgotten.
+	gone = true
+	if err := s.sendWebPush(subs[0], webPushMessage{Title: "x"}); err == nil {
+		t.Fatal("expected an error for a gone subscription")
+	}
+	if subs, _ := s.db.ListPushSubscriptions(ctx, "alice"); len(subs) != 0 {
+		t.Fatalf("gone subscription kept: %+v", subs)
+	}
+}
+
+func TestManifestAndServiceWorker(t *testing.T) {
+	s := newServerForTest(t)
+	h := s.Router()
+	for path, want := range map[string]string{
+		"/manifest.webmanifest": `"display":"standalone"`,
+		"/sw.js":                "showNotification",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("%s: %d %s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
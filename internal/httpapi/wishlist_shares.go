@@ -0,0 +1,183 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+// wishlistShareTokenBytes is the random length of a share token.
+const wishlistShareTokenBytes = 24
+
+type wishlistShareView struct {
+	db.WishlistShare
+	URL string `json:"url"`
+}
+
+type sharedWishlistItem struct {
+	Title   string
+	Authors string
+	Format  string
+	Status  string
+}
+
+// mountWishlistShares serves the public read-only wishlist page and the
+// endpoints users mint and revoke their share links with. The page itself
+// sits outside the login gate so family members can open it.
+func (s *Server) mountWishlistShares(r chi.Router) {
+	funcMap := template.FuncMap{
+		"toJSON":        func(v any) string { b, _ := json.Marshal(v); return string(b) },
+		"csrfToken":     func(r *http.Request) string { return s.getCSRFToken(r) },
+		"authorsText":   authorsText,
+		"truncateChars": truncateChars,
+		"brand":         s.branding,
+		"asset":         assetURL,
+	}
+	tpl := template.Must(template.New("tpl").Funcs(funcMap).ParseFS(tplFS, "web/templates/*.html"))
+
+	r.Get("/shared/wishlist/{token}", s.handleSharedWishlist(tpl))
+	r.Get("/api/v1/wishlist/shares", s.requireLogin(s.apiListWishlistShares))
+	r.Post("/api/v1/wishlist/shares", s.requireLogin(s.apiCreateWishlistShare))
+	r.Delete("/api/v1/wishlist/shares/{token}", s.requireLogin(s.apiRevokeWishlistShare))
+	r.Post("/account/wishlist-shares", s.requireLogin(s.handleWishlistShareCreate))
+	r.Post("/account/wishlist-shares/{token}/revoke", s.requireLogin(s.handleWishlistShareRevoke))
+}
+
+// wishlistShareURL is the public link for a share token.
+func (s *Server) wishlistShareURL(token string) string {
+	return strings.TrimRight(s.settings.Get().ServerURL, "/") + "/shared/wishlist/" + token
+}
+
+func (s *Server) wishlistShareViews(shares []db.WishlistShare) []wishlistShareView {
+	out := make([]wishlistShareView, len(shares))
+	for i, sh := range shares {
+		out[i] = wishlistShareView{WishlistShare: sh, URL: s.wishlistShareURL(sh.Token)}
+	}
+	return out
+}
+
+// mintWishlistShare creates a new share link for the logged-in user.
+func (s *Server) mintWishlistShare(r *http.Request) (*db.WishlistShare, error) {
+	ses := r.Context().Value(ctxUser).(*session)
+	token, err := randomToken(wishlistShareTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := s.db.CreateWishlistShare(r.Context(), token, ses.Username)
+	if err != nil {
+		return nil, err
+	}
+	s.auditLog(r.Context(), ses.Username, "wishlist.share_created", nil, "")
+	return sh, nil
+}
+
+// revokeWishlistShare deletes one of the logged-in user's share links.
+func (s *Server) revokeWishlistShare(r *http.Request) (bool, error) {
+	ses := r.Context().Value(ctxUser).(*session)
+	ok, err := s.db.RevokeWishlistShare(r.Context(), ses.Username, chi.URLParam(r, "token"))
+	if err == nil && ok {
+		s.auditLog(r.Context(), ses.Username, "wishlist.share_revoked", nil, "")
+	}
+	return ok, err
+}
+
+func (s *Server) apiListWishlistShares(w http.ResponseWriter, r *http.Request) {
+	ses := r.Context().Value(ctxUser).(*session)
+	shares, err := s.db.ListWishlistShares(r.Context(), ses.Username)
+	if err != nil {
+		http.Error(w, "failed to list share links", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, s.wishlistShareViews(shares), http.StatusOK)
+}
+
+func (s *Server) apiCreateWishlistShare(w http.ResponseWriter, r *http.Request) {
+	sh, err := s.mintWishlistShare(r)
+	if err != nil {
+		http.Error(w, "failed to create share link", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, wishlistShareView{WishlistShare: *sh, URL: s.wishlistShareURL(sh.Token)}, http.StatusCreated)
+}
+
+func (s *Server) apiRevokeWishlistShare(w http.ResponseWriter, r *http.Request) {
+	ok, err := s.revokeWishlistShare(r)
+	if err != nil {
+		http.Error(w, "failed to revoke share link", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "share link not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleWishlistShareCreate(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.mintWishlistShare(r); err != nil {
+		http.Error(w, "failed to create share link", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/account#wishlist-sharing", http.StatusFound)
+}
+
+func (s *Server) handleWishlistShareRevoke(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.revokeWishlistShare(r); err != nil {
+		http.Error(w, "failed to revoke share link", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/account#wishlist-sharing", http.StatusFound)
+}
+
+// handleSharedWishlist renders a user's open and fulfilled requests for
+// anyone holding a live share token. Declined requests, notes and other
+// details stay private; unknown and revoked tokens get a plain 404.
+func (s *Server) handleSharedWishlist(tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sh, err := s.db.GetWishlistShare(r.Context(), chi.URLParam(r, "token"))
+		if err != nil {
+			http.Error(w, "failed to load wishlist", http.StatusInternalServerError)
+			return
+		}
+		if sh == nil {
+			http.NotFound(w, r)
+			return
+		}
+		owner, err := s.db.GetUserByUsername(r.Context(), sh.Username)
+		if err != nil || owner == nil {
+			http.NotFound(w, r)
+			return
+		}
+		reqs, err := s.db.ListRequests(r.Context(), sh.Username, 500)
+		if err != nil {
+			http.Error(w, "failed to load wishlist", http.StatusInternalServerError)
+			return
+		}
+		items := make([]sharedWishlistItem, 0, len(reqs))
+		for _, rq := range reqs {
+			if rq.Status == "declined" {
+				continue
+			}
+			items = append(items, sharedWishlistItem{Title: rq.Title, Authors: authorsText(rq.Authors), Format: rq.Format, Status: sharedWishlistStatus(rq.Status)})
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		_ = tpl.ExecuteTemplate(w, "wishlist_shared.html", map[string]any{"Owner": owner.Username, "Items": items})
+	}
+}
+
+// sharedWishlistStatus is the coarse status a shared wishlist shows: whether
+// a book is still wanted or already on its way.
+func sharedWishlistStatus(status string) string {
+	switch status {
+	case "available":
+		return "In the library"
+	case "pending":
+		return "Requested"
+	}
+	return "Approved"
+}
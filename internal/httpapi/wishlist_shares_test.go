@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitea.knapp/jacoknapp/scriptorum/internal/db"
+)
+
+func TestWishlistShareLinkLifecycle(t *testing.T) {
+	s := newServerForTest(t)
+	ctx := context.Background()
+	if _, err := s.db.CreateUser(ctx, "alice", "x", false, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, rq := range []db.Request{
+		{RequesterEmail: "alice", Title: "Wanted Book", Authors: []string{"A"}, Format: "ebook", Status: "pending"},
+		{RequesterEmail: "alice", Title: "Turned Down", Authors: []string{"B"}, Format: "ebook", Status: "declined"},
+		{RequesterEmail: "bob", Title: "Someone Else", Authors: []string{"C"}, Format: "ebook", Status: "pending"},
+	} {
+		if _, err := s.db.CreateRequest(ctx, &rq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := s.Router()
+	do := func(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	alice := makeCookie(t, s, "alice", false)
+
+	if rec := do(http.MethodPost, "/api/v1/wishlist/shares", nil); rec.Code == http.StatusCreated {
+		t.Fatal("minted a share link without logging in")
+	}
+	rec := do(http.MethodPost, "/api/v1/wishlist/shares", alice)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("mint = %d %s", rec.Code, rec.Body.String())
+	}
+	var sh wishlistShareView
+	if err := json.Unmarshal(rec.Body.Bytes(), &sh); err != nil || sh.Token == "" || !strings.HasSuffix(sh.URL, "/shared/wishlist/"+sh.Token) {
+		t.Fatalf("share = %+v, %v", sh, err)
+	}
+
+	rec = do(http.MethodGet, "/shared/wishlist/"+sh.Token, nil)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Wanted Book") || strings.Contains(body, "Turned Down") || strings.Contains(body, "Someone Else") {
+		t.Fatalf("shared page = %d %s", rec.Code, body)
+	}
+
+	if rec := do(http.MethodDelete, "/api/v1/wishlist/shares/"+sh.Token, makeCookie(t, s, "bob", false)); rec.Code != http.StatusNotFound {
+		t.Fatalf("revoking someone else's link = %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/v1/wishlist/shares/"+sh.Token, alice); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke = %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/shared/wishlist/"+sh.Token, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("revoked link = %d, want 404", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/shared/wishlist/not-a-token", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown link = %d, want 404", rec.Code)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -14,15 +17,69 @@ import (
 type AmazonPublic struct {
 	client *http.Client
 	market string // e.g., www.amazon.com
+	locale amazonLocale
+}
+
+// amazonLocale is how one Amazon store expects to be asked: the language its
+// pages should come back in and the search index that holds its books.
+type amazonLocale struct {
+	acceptLanguage string
+	searchIndex    string
+}
+
+// amazonMarketplaces are the stores the public scraper knows how to read.
+var amazonMarketplaces = map[string]amazonLocale{
+	"www.amazon.com":    {"en-US,en;q=0.9", "stripbooks-intl-ship"},
+	"www.amazon.co.uk":  {"en-GB,en;q=0.9", "stripbooks"},
+	"www.amazon.ca":     {"en-CA,en;q=0.9,fr-CA;q=0.5", "stripbooks"},
+	"www.amazon.com.au": {"en-AU,en;q=0.9", "stripbooks"},
+	"www.amazon.in":     {"en-IN,en;q=0.9", "stripbooks"},
+	"www.amazon.de":     {"de-DE,de;q=0.9,en;q=0.5", "stripbooks"},
+	"www.amazon.fr":     {"fr-FR,fr;q=0.9,en;q=0.5", "stripbooks"},
+	"www.amazon.it":     {"it-IT,it;q=0.9,en;q=0.5", "stripbooks"},
+	"www.amazon.es":     {"es-ES,es;q=0.9,en;q=0.5", "stripbooks"},
+}
+
+// AmazonMarketplaces lists the supported store hosts, www.amazon.com first.
+func AmazonMarketplaces() []string {
+	out := make([]string, 0, len(amazonMarketplaces))
+	for host := range amazonMarketplaces {
+		if host != "www.amazon.com" {
+			out = append(out, host)
+		}
+	}
+	sort.Strings(out)
+	return append([]string{"www.amazon.com"}, out...)
+}
+
+// NormalizeAmazonMarketplace turns "amazon.de", "www.amazon.de", or a store
+// URL into the store's host. Unknown stores return "".
+func NormalizeAmazonMarketplace(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if i := strings.Index(v, "://"); i >= 0 {
+		v = v[i+3:]
+	}
+	if i := strings.IndexAny(v, "/?#"); i >= 0 {
+		v = v[:i]
+	}
+	if !strings.HasPrefix(v, "www.") {
+		v = "www." + v
+	}
+	if _, ok := amazonMarketplaces[v]; ok {
+		return v
+	}
+	return ""
 }
 
 func NewAmazonPublic(marketplace string) *AmazonPublic {
-	if strings.TrimSpace(marketplace) == "" {
-		marketplace = "www.amazon.com"
+	market := NormalizeAmazonMarketplace(marketplace)
+	if market == "" {
+		market = "www.amazon.com"
 	}
 	return &AmazonPublic{
 		client: &http.Client{Timeout: 10 * time.Second},
-		market: marketplace,
+		market: market,
+		locale: amazonMarketplaces[market],
 	}
 }
 
@@ -40,21 +97,16 @@ func (a *AmazonPublic) GetByASIN(ctx context.Context, asin string) (*PublicBook,
 	if asin == "" {
 		return nil, errors.New("asin required")
 	}
-	url := "https://" + a.market + "/dp/" + asin
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return nil, errors.New(resp.Status)
+	key := a.market + "|dp|" + asin
+	if e, ok := amazonState.cached(key, false); ok {
+		return e.book, nil
 	}
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := a.fetch(ctx, "https://"+a.market+"/dp/"+asin)
 	if err != nil {
+		// Serve what the store said last time rather than nothing.
+		if e, ok := amazonState.cached(key, true); ok {
+			return e.book, nil
+		}
 		return nil, err
 	}
 
@@ -78,9 +130,9 @@ func (a *AmazonPublic) GetByASIN(ctx context.Context, asin string) (*PublicBook,
 	})
 
 	doc.Find("#detailBullets_feature_div li").Each(func(_ int, s *goquery.Selection) {
-		label := strings.TrimSpace(s.Find("span.a-text-bold").First().Text())
-		val := strings.TrimSpace(strings.ReplaceAll(s.Text(), label, ""))
-		label = strings.TrimSuffix(label, ":")
+		rawLabel := s.Find("span.a-text-bold").First().Text()
+		val := cleanAmazonDetail(strings.ReplaceAll(s.Text(), rawLabel, ""))
+		label := cleanAmazonDetail(rawLabel)
 		if strings.EqualFold(label, "ISBN-10") && b.ISBN10 == "" {
 			b.ISBN10 = onlyISBN(val)
 		}
@@ -90,18 +142,19 @@ func (a *AmazonPublic) GetByASIN(ctx context.Context, asin string) (*PublicBook,
 	})
 
 	doc.Find("#productDetailsTable .content ul li").Each(func(_ int, s *goquery.Selection) {
-		text := strings.TrimSpace(s.Text())
+		text := cleanAmazonDetail(s.Text())
 		if strings.Contains(text, "ISBN-10") && b.ISBN10 == "" {
-			b.ISBN10 = onlyISBN(text)
+			b.ISBN10 = onlyISBN(cleanAmazonDetail(strings.Replace(text, "ISBN-10", "", 1)))
 		}
 		if strings.Contains(text, "ISBN-13") && b.ISBN13 == "" {
-			b.ISBN13 = onlyISBN(text)
+			b.ISBN13 = onlyISBN(cleanAmazonDetail(strings.Replace(text, "ISBN-13", "", 1)))
 		}
 	})
 
 	if b.Title == "" && b.ISBN10 == "" && b.ISBN13 == "" {
 		return nil, errors.New("no book info detected")
 	}
+	amazonState.store(key, amazonCacheEntry{book: b})
 	return b, nil
 }
 
@@ -114,23 +167,19 @@ func (a *AmazonPublic) SearchBooks(ctx context.Context, q string, page int, limi
 	if page <= 0 {
 		page = 1
 	}
-	url := "https://" + a.market + "/s?k=" + strings.ReplaceAll(q, " ", "+") + "&i=stripbooks-intl-ship"
+	u := "https://" + a.market + "/s?k=" + url.QueryEscape(q) + "&i=" + a.locale.searchIndex
 	if page > 1 {
-		url += "&page=" + strconv.Itoa(page)
-	}
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return nil, err
+		u += "&page=" + strconv.Itoa(page)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return nil, errors.New(resp.Status)
+	key := a.market + "|s|" + strings.ToLower(strings.TrimSpace(q)) + "|" + strconv.Itoa(page) + "|" + strconv.Itoa(limit)
+	if e, ok := amazonState.cached(key, false); ok {
+		return e.items, nil
 	}
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := a.fetch(ctx, u)
 	if err != nil {
+		if e, ok := amazonState.cached(key, true); ok {
+			return e.items, nil
+		}
 		return nil, err
 	}
 	var items []PublicBook
@@ -150,15 +199,170 @@ func (a *AmazonPublic) SearchBooks(ctx context.Context, q string, page int, limi
 		var authors []string
 		s.Find(".a-row .a-size-base").Each(func(_ int, aSel *goquery.Selection) {
 			name := strings.TrimSpace(aSel.Text())
-			if name != "" && len(authors) < 3 {
+			if name != "" && !amazonBylineWords[strings.ToLower(name)] && len(authors) < 3 {
 				authors = append(authors, name)
 			}
 		})
 		items = append(items, PublicBook{ASIN: asin, Title: title, Authors: authors, Image: img})
 	})
+	amazonState.store(key, amazonCacheEntry{items: items})
 	return items, nil
 }
 
+// amazonBylineWords are the connectives Amazon's search results put between
+// author names ("by", "von", "de", ...), which are not authors themselves.
+var amazonBylineWords = map[string]bool{
+	"by": true, "and": true, "|": true, ",": true,
+	"von": true, "und": true,
+	"de": true, "et": true, "par": true,
+	"di": true, "e": true,
+	"y": true,
+}
+
+// ErrAmazonBlocked means Amazon answered with a robot check or told us to
+// slow down. The store is left alone for a while after that.
+var ErrAmazonBlocked = errors.New("amazon is blocking automated requests")
+
+// fetch downloads one page from the store, waiting for the store's rate
+// limiter first and refusing to call it while it is blocking us.
+func (a *AmazonPublic) fetch(ctx context.Context, u string) (*goquery.Document, error) {
+	if amazonState.blocked(a.market) {
+		return nil, ErrAmazonBlocked
+	}
+	if err := amazonState.limiter(a.market).Wait(ctx); err != nil {
+		return nil, err
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36")
+	req.Header.Set("Accept-Language", a.locale.acceptLanguage)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests {
+		amazonState.block(a.market)
+		return nil, ErrAmazonBlocked
+	}
+	if resp.StatusCode >= 400 {
+		return nil, errors.New(resp.Status)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Find("form[action*='validateCaptcha']").Length() > 0 || strings.Contains(strings.ToLower(doc.Find("title").Text()), "robot check") {
+		amazonState.block(a.market)
+		return nil, ErrAmazonBlocked
+	}
+	return doc, nil
+}
+
+// cleanAmazonDetail strips the direction marks, non-breaking spaces, and
+// colons Amazon's non-US stores wrap detail labels and values in.
+func cleanAmazonDetail(s string) string {
+	s = strings.NewReplacer("\u200e", "", "\u200f", "", "\u00a0", " ", ":", "").Replace(s)
+	return strings.TrimSpace(s)
+}
+
+const (
+	// amazonCacheTTL is how long a scraped page is reused without asking
+	// the store again.
+	amazonCacheTTL = 6 * time.Hour
+	// amazonCacheMax bounds the cache; the oldest entry makes room.
+	amazonCacheMax = 500
+	// amazonBlockCooldown is how long a store that blocked us is left alone.
+	amazonBlockCooldown = 10 * time.Minute
+)
+
+type amazonCacheEntry struct {
+	at    time.Time
+	book  *PublicBook
+	items []PublicBook
+}
+
+// amazonShared is the scraper state every AmazonPublic shares, since the
+// search page builds a new one per request: per-store rate limiters, block
+// cooldowns, and the page cache.
+type amazonShared struct {
+	mu           sync.Mutex
+	limiters     map[string]*tokenBucket
+	blockedUntil map[string]time.Time
+	cache        map[string]amazonCacheEntry
+	rate, burst  float64
+}
+
+var amazonState = newAmazonShared(1, 2) // 1 page/sec per store, bursts of 2
+
+func newAmazonShared(ratePerSec, burst float64) *amazonShared {
+	return &amazonShared{
+		limiters:     make(map[string]*tokenBucket),
+		blockedUntil: make(map[string]time.Time),
+		cache:        make(map[string]amazonCacheEntry),
+		rate:         ratePerSec,
+		burst:        burst,
+	}
+}
+
+func (st *amazonShared) limiter(market string) *tokenBucket {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	tb := st.limiters[market]
+	if tb == nil {
+		tb = newTokenBucket(st.rate, st.burst)
+		st.limiters[market] = tb
+	}
+	return tb
+}
+
+func (st *amazonShared) blocked(market string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return time.Now().Before(st.blockedUntil[market])
+}
+
+func (st *amazonShared) block(market string) {
+	st.mu.Lock()
+	st.blockedUntil[market] = time.Now().Add(amazonBlockCooldown)
+	st.mu.Unlock()
+}
+
+// cached returns the entry for key if it is fresh, or any entry at all when
+// stale is set.
+func (st *amazonShared) cached(key string, stale bool) (amazonCacheEntry, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e, ok := st.cache[key]
+	if !ok || (!stale && time.Since(e.at) > amazonCacheTTL) {
+		return amazonCacheEntry{}, false
+	}
+	return e, true
+}
+
+func (st *amazonShared) store(key string, e amazonCacheEntry) {
+	e.at = time.Now()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.cache[key]; !ok && len(st.cache) >= amazonCacheMax {
+		var oldest string
+		for k, v := range st.cache {
+			if oldest == "" || v.at.Before(st.cache[oldest].at) {
+				oldest = k
+			}
+		}
+		delete(st.cache, oldest)
+	}
+	st.cache[key] = e
+}
+
+// TestResetAmazonState clears the shared Amazon cache and cooldowns and
+// lifts the per-store rate limit. Call the returned function to restore it.
+func TestResetAmazonState() func() {
+	orig := amazonState
+	amazonState = newAmazonShared(100000, 10000)
+	return func() { amazonState = orig }
+}
+
 func onlyISBN(s string) string {
 	s = strings.TrimSpace(s)
 	s = strings.ReplaceAll(s, "ISBN-10:", "")
@@ -183,24 +387,14 @@ func contains(a []string, v string) bool {
 	return false
 }
 
-var asinRe = strings.NewReplacer("/dp/", " ", "/gp/product/", " ", "?", " ", "#", " ", "/", " ", "-", " ")
-
+// ExtractASINFromInput returns the ASIN from a pasted Amazon link, or the
+// input itself when it already looks like an ASIN.
 func ExtractASINFromInput(s string) string {
 	s = strings.TrimSpace(s)
-	if s == "" {
-		return ""
-	}
-	ls := strings.ToLower(s)
-	if strings.Contains(ls, "amazon.") {
-		s = asinRe.Replace(s)
-		parts := strings.Fields(s)
-		for _, p := range parts {
-			if len(p) == 10 {
-				return p
-			}
-		}
+	if ref, ok := ParseBookURL(s); ok {
+		return ref.ASIN
 	}
-	if len(s) == 10 {
+	if isASIN(s) {
 		return s
 	}
 	return ""
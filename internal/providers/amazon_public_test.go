@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -56,3 +57,97 @@ func TestAmazonPublicSearchBooks(t *testing.T) {
 		t.Fatalf("missing fields")
 	}
 }
+
+func TestNormalizeAmazonMarketplace(t *testing.T) {
+	cases := map[string]string{
+		"":                            "",
+		"amazon.co.uk":                "www.amazon.co.uk",
+		"WWW.Amazon.DE":               "www.amazon.de",
+		"https://www.amazon.ca/dp/B0": "www.amazon.ca",
+		"amazon.example":              "",
+	}
+	for in, want := range cases {
+		if got := NormalizeAmazonMarketplace(in); got != want {
+			t.Fatalf("NormalizeAmazonMarketplace(%q)=%q want %q", in, got, want)
+		}
+	}
+	if m := AmazonMarketplaces(); len(m) == 0 || m[0] != "www.amazon.com" {
+		t.Fatalf("marketplaces: %v", m)
+	}
+}
+
+func TestAmazonPublicRegionalStore(t *testing.T) {
+	t.Cleanup(TestResetAmazonState())
+	html := `<html><body><span id="productTitle">Der Schwarm</span>
+	<div id="bylineInfo"><span class="author"><a>Frank Schätzing</a></span></div>
+	<div id="detailBullets_feature_div"><li><span class="a-text-bold">ISBN-13 &rlm; : &lrm;</span> 978-3-596-16453-2</li></div>
+	</body></html>`
+	a := NewAmazonPublic("amazon.de")
+	a.client.Transport = rtFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host != "www.amazon.de" {
+			t.Fatalf("host: %s", r.URL.Host)
+		}
+		if !strings.HasPrefix(r.Header.Get("Accept-Language"), "de-DE") {
+			t.Fatalf("accept-language: %s", r.Header.Get("Accept-Language"))
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(html)), Header: make(http.Header)}, nil
+	})
+	b, err := a.GetByASIN(context.Background(), "3596164532")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if b.Title != "Der Schwarm" || b.ISBN13 != "9783596164532" {
+		t.Fatalf("book: %+v", b)
+	}
+}
+
+func TestAmazonPublicBlockedServesCache(t *testing.T) {
+	t.Cleanup(TestResetAmazonState())
+	var calls int32
+	blocked := false
+	a := NewAmazonPublic("www.amazon.co.uk")
+	a.client.Transport = rtFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		if blocked {
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`<html><head><title>Robot Check</title></head><body><form action="/errors/validateCaptcha"></form></body></html>`)), Header: make(http.Header)}, nil
+		}
+		if got := r.URL.Query().Get("i"); got != "stripbooks" {
+			t.Fatalf("search index: %q", got)
+		}
+		body := `<html><body><div class="s-result-item" data-asin="B0UK"><h2><a><span>UK Book</span></a></h2>
+		<div class="a-row"><span class="a-size-base">by</span><span class="a-size-base">Jane Doe</span></div></div></body></html>`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+	items, err := a.SearchBooks(context.Background(), "uk book", 1, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("items=%v err=%v", items, err)
+	}
+	if len(items[0].Authors) != 1 || items[0].Authors[0] != "Jane Doe" {
+		t.Fatalf("authors: %v", items[0].Authors)
+	}
+	// A fresh cache hit does not ask the store again.
+	if _, err := a.SearchBooks(context.Background(), "UK book", 1, 10); err != nil || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected cache hit, calls=%d err=%v", calls, err)
+	}
+
+	blocked = true
+	if _, err := a.SearchBooks(context.Background(), "other", 1, 10); err != ErrAmazonBlocked {
+		t.Fatalf("expected ErrAmazonBlocked, got %v", err)
+	}
+	n := atomic.LoadInt32(&calls)
+	if _, err := a.SearchBooks(context.Background(), "another", 1, 10); err != ErrAmazonBlocked || atomic.LoadInt32(&calls) != n {
+		t.Fatalf("expected cooldown without a request, err=%v", err)
+	}
+
+	// Expired entries are still served while the store is blocking us.
+	amazonState.mu.Lock()
+	for k, e := range amazonState.cache {
+		e.at = e.at.Add(-2 * amazonCacheTTL)
+		amazonState.cache[k] = e
+	}
+	amazonState.mu.Unlock()
+	items, err = a.SearchBooks(context.Background(), "uk book", 1, 10)
+	if err != nil || len(items) != 1 || items[0].ASIN != "B0UK" {
+		t.Fatalf("stale fallback: items=%v err=%v", items, err)
+	}
+}
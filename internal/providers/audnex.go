@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audnex reads Audible audiobook metadata by ASIN from the public Audnexus
+// API, which needs no key.
+type Audnex struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewAudnex() *Audnex {
+	return &Audnex{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: "https://api.audnex.us",
+	}
+}
+
+type audnexBook struct {
+	ASIN    string `json:"asin"`
+	Title   string `json:"title"`
+	ISBN    string `json:"isbn"`
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Summary     string `json:"summary"`
+	Image       string `json:"image"`
+	ReleaseDate string `json:"releaseDate"`
+}
+
+// Book looks up one audiobook by ASIN.
+func (a *Audnex) Book(ctx context.Context, asin string) (*BookItem, error) {
+	asin = strings.ToUpper(strings.TrimSpace(asin))
+	if asin == "" {
+		return nil, errors.New("asin required")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/books/"+url.PathEscape(asin), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, errors.New(resp.Status)
+	}
+	var b audnexBook
+	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(b.Title) == "" {
+		return nil, errors.New("audiobook has no title")
+	}
+	if b.ASIN = strings.TrimSpace(b.ASIN); b.ASIN == "" {
+		b.ASIN = asin
+	}
+	item := &BookItem{
+		ASIN:        b.ASIN,
+		Title:       strings.TrimSpace(b.Title),
+		Description: b.Summary,
+		CoverMedium: b.Image,
+	}
+	for _, au := range b.Authors {
+		if n := strings.TrimSpace(au.Name); n != "" {
+			item.Authors = append(item.Authors, n)
+		}
+	}
+	// Audible reports the ISBN of the audio edition in either length.
+	switch isbn := strings.ReplaceAll(strings.TrimSpace(b.ISBN), "-", ""); len(isbn) {
+	case 13:
+		item.ISBN13 = isbn
+	case 10:
+		item.ISBN10 = isbn
+	}
+	if len(b.ReleaseDate) >= 4 {
+		item.FirstPublishYear, _ = strconv.Atoi(b.ReleaseDate[:4])
+	}
+	return item, nil
+}
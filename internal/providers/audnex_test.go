@@ -0,0 +1,32 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAudnexBook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/books/B00TEST123" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"asin":"B00TEST123","title":"Project Hail Mary","isbn":"978-1-60-383123-4","authors":[{"asin":"A1","name":"Andy Weir"}],"releaseDate":"2021-05-04T00:00:00.000Z"}`))
+	}))
+	defer srv.Close()
+	a := &Audnex{client: srv.Client(), baseURL: srv.URL}
+
+	b, err := a.Book(context.Background(), " b00test123 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ASIN != "B00TEST123" || b.Title != "Project Hail Mary" || b.ISBN13 != "9781603831234" || len(b.Authors) != 1 || b.Authors[0] != "Andy Weir" || b.FirstPublishYear != 2021 {
+		t.Fatalf("book = %+v", b)
+	}
+	if _, err := a.Book(context.Background(), "B0MISSING"); err == nil {
+		t.Fatal("expected an error for an unknown ASIN")
+	}
+}
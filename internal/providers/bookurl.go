@@ -0,0 +1,246 @@
+package providers
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// BookRef is what a pasted store or catalog link says about a book. Source is
+// "amazon", "goodreads", or "googlebooks"; the other fields are set when the
+// link carries them.
+type BookRef struct {
+	Source string
+	// Host is the site the link pointed at, e.g. "www.amazon.co.uk", so
+	// lookups can use the same marketplace.
+	Host           string
+	ASIN           string
+	ISBN10         string
+	ISBN13         string
+	GoodreadsID    string
+	GoogleVolumeID string
+}
+
+// Label names the source for display, e.g. "Amazon".
+func (r BookRef) Label() string {
+	switch r.Source {
+	case "amazon":
+		return "Amazon"
+	case "goodreads":
+		return "Goodreads"
+	case "googlebooks":
+		return "Google Books"
+	}
+	return r.Source
+}
+
+// ReadarrTerm is the most precise Readarr lookup term for the reference, or
+// "" when Readarr cannot search by any of its identifiers.
+func (r BookRef) ReadarrTerm() string {
+	switch {
+	case r.GoodreadsID != "":
+		// Readarr's metadata is keyed by Goodreads edition ids.
+		return "goodreads:" + r.GoodreadsID
+	case r.ISBN13 != "":
+		return "isbn:" + r.ISBN13
+	case r.ISBN10 != "":
+		return "isbn:" + r.ISBN10
+	case r.ASIN != "":
+		// Readarr resolves bare ASINs the same way it always has for
+		// pasted Amazon links.
+		return r.ASIN
+	}
+	return ""
+}
+
+var (
+	asinPattern      = regexp.MustCompile(`^[A-Za-z0-9]{10}$`)
+	goodreadsIDRe    = regexp.MustCompile(`^(\d+)`)
+	googleVolumeIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]{6,}$`)
+)
+
+// ParseBookURL recognises Amazon product, Goodreads book, and Google Books
+// volume links. Scheme-less input such as "amazon.com/dp/B08N5WRWNW" is
+// accepted. It reports false for anything else, including bare identifiers.
+func ParseBookURL(s string) (BookRef, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, " \t\n") {
+		return BookRef{}, false
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return BookRef{}, false
+	}
+	host := strings.ToLower(u.Hostname())
+	segs := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	ref := BookRef{Host: host}
+
+	switch {
+	case hostIs(host, "amazon") || hostIs(host, "amzn"):
+		ref.Source = "amazon"
+		ref.ASIN = amazonASIN(segs)
+		if ref.ASIN == "" {
+			return BookRef{}, false
+		}
+		// Print books use their ISBN-10 as the ASIN.
+		if validISBN10(ref.ASIN) {
+			ref.ISBN10 = ref.ASIN
+			ref.ISBN13 = isbn10To13(ref.ASIN)
+		}
+	case hostIs(host, "goodreads"):
+		ref.Source = "goodreads"
+		for i := 0; i+2 < len(segs) && ref.GoodreadsID == ""; i++ {
+			if segs[i] == "book" && segs[i+1] == "show" {
+				if m := goodreadsIDRe.FindStringSubmatch(segs[i+2]); m != nil {
+					ref.GoodreadsID = m[1]
+				}
+			}
+		}
+		if ref.GoodreadsID == "" {
+			return BookRef{}, false
+		}
+	case host == "books.google.com" || strings.HasPrefix(host, "books.google.") ||
+		host == "play.google.com" || ((host == "www.google.com" || host == "google.com") && len(segs) > 0 && segs[0] == "books"):
+		ref.Source = "googlebooks"
+		q := u.Query()
+		if id := q.Get("id"); googleVolumeIDRe.MatchString(id) {
+			ref.GoogleVolumeID = id
+		}
+		// books.google.com/books/edition/<title>/<id>
+		if ref.GoogleVolumeID == "" && len(segs) >= 4 && segs[0] == "books" && segs[1] == "edition" && googleVolumeIDRe.MatchString(segs[3]) {
+			ref.GoogleVolumeID = segs[3]
+		}
+		// books?vid=ISBN9780441013593
+		if vid := strings.ToUpper(q.Get("vid")); strings.HasPrefix(vid, "ISBN") {
+			setISBN(&ref, strings.TrimPrefix(vid, "ISBN"))
+		}
+		if isbn := q.Get("isbn"); isbn != "" {
+			setISBN(&ref, isbn)
+		}
+		if ref.GoogleVolumeID == "" && ref.ISBN13 == "" && ref.ISBN10 == "" {
+			return BookRef{}, false
+		}
+	default:
+		return BookRef{}, false
+	}
+	return ref, true
+}
+
+// ParseBookIdentifier accepts a bare ISBN-13, ISBN-10 (hyphens and spaces
+// allowed), or ASIN, as well as anything ParseBookURL recognises. Source is
+// "isbn" or "asin" for bare identifiers.
+func ParseBookIdentifier(s string) (BookRef, bool) {
+	s = strings.TrimSpace(s)
+	if ref, ok := ParseBookURL(s); ok {
+		return ref, true
+	}
+	ref := BookRef{Source: "isbn"}
+	if setISBN(&ref, s); ref.ISBN13 != "" {
+		return ref, true
+	}
+	// All-digit ASINs are ISBN-10s, so a ten-digit string that failed the
+	// checksum above is a typo rather than an ASIN.
+	if isASIN(s) && strings.Trim(s, "0123456789") != "" {
+		return BookRef{Source: "asin", ASIN: strings.ToUpper(s)}, true
+	}
+	return BookRef{}, false
+}
+
+// hostIs reports whether host belongs to the named site in any country
+// domain, e.g. hostIs("www.amazon.co.uk", "amazon").
+func hostIs(host, site string) bool {
+	for _, label := range strings.Split(host, ".") {
+		if label == site {
+			return true
+		}
+	}
+	return false
+}
+
+// amazonASIN finds the ASIN in an Amazon product path: /dp/ASIN,
+// /<slug>/dp/ASIN, /gp/product/ASIN, /gp/aw/d/ASIN, or /exec/obidos/ASIN/ASIN.
+func amazonASIN(segs []string) string {
+	for i, seg := range segs {
+		switch seg {
+		case "dp", "product", "d", "ASIN":
+			if i+1 < len(segs) && isASIN(segs[i+1]) {
+				return strings.ToUpper(segs[i+1])
+			}
+		}
+	}
+	return ""
+}
+
+// isASIN reports whether s looks like an ASIN: ten letters and digits,
+// including at least one digit so ordinary ten-letter words don't qualify.
+func isASIN(s string) bool {
+	return asinPattern.MatchString(s) && strings.ContainsAny(s, "0123456789")
+}
+
+func setISBN(ref *BookRef, raw string) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(raw))
+	switch {
+	case len(isbn) == 13 && validISBN13(isbn):
+		ref.ISBN13 = isbn
+	case len(isbn) == 10 && validISBN10(isbn):
+		ref.ISBN10 = isbn
+		ref.ISBN13 = isbn10To13(isbn)
+	}
+}
+
+func validISBN10(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 10; i++ {
+		c := s[i]
+		var v int
+		switch {
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case (c == 'X' || c == 'x') && i == 9:
+			v = 10
+		default:
+			return false
+		}
+		sum += v * (10 - i)
+	}
+	return sum%11 == 0
+}
+
+func validISBN13(s string) bool {
+	if len(s) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		v := int(c - '0')
+		if i%2 == 1 {
+			v *= 3
+		}
+		sum += v
+	}
+	return sum%10 == 0
+}
+
+// isbn10To13 converts a valid ISBN-10 to its 978-prefixed ISBN-13.
+func isbn10To13(isbn10 string) string {
+	core := "978" + isbn10[:9]
+	sum := 0
+	for i := 0; i < 12; i++ {
+		v := int(core[i] - '0')
+		if i%2 == 1 {
+			v *= 3
+		}
+		sum += v
+	}
+	return core + string(rune('0'+(10-sum%10)%10))
+}
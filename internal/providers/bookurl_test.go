@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseBookURL(t *testing.T) {
+	cases := []struct {
+		in   string
+		want BookRef
+	}{
+		{"https://www.amazon.com/Project-Hail-Mary-Novel/dp/B08FHBV4ZX/ref=sr_1_1?keywords=x", BookRef{Source: "amazon", Host: "www.amazon.com", ASIN: "B08FHBV4ZX"}},
+		{"amazon.co.uk/gp/product/0441013597", BookRef{Source: "amazon", Host: "amazon.co.uk", ASIN: "0441013597", ISBN10: "0441013597", ISBN13: "9780441013593"}},
+		{"https://www.amazon.de/gp/aw/d/B08N5WRWNW", BookRef{Source: "amazon", Host: "www.amazon.de", ASIN: "B08N5WRWNW"}},
+		{"https://www.goodreads.com/book/show/44767458-dune", BookRef{Source: "goodreads", Host: "www.goodreads.com", GoodreadsID: "44767458"}},
+		{"https://www.goodreads.com/en/book/show/234225.Dune", BookRef{Source: "goodreads", Host: "www.goodreads.com", GoodreadsID: "234225"}},
+		{"https://books.google.com/books?id=B1hSG45JCX4C&printsec=frontcover", BookRef{Source: "googlebooks", Host: "books.google.com", GoogleVolumeID: "B1hSG45JCX4C"}},
+		{"https://www.google.com/books/edition/Dune/B1hSG45JCX4C?hl=en", BookRef{Source: "googlebooks", Host: "www.google.com", GoogleVolumeID: "B1hSG45JCX4C"}},
+		{"https://books.google.com/books?vid=ISBN9780441013593", BookRef{Source: "googlebooks", Host: "books.google.com", ISBN13: "9780441013593"}},
+	}
+	for _, c := range cases {
+		got, ok := ParseBookURL(c.in)
+		if !ok || got != c.want {
+			t.Errorf("ParseBookURL(%q) = %+v, %v; want %+v", c.in, got, ok, c.want)
+		}
+	}
+	for _, bad := range []string{"", "dune frank herbert", "B08N5WRWNW", "https://www.amazon.com/s?k=dune", "https://www.goodreads.com/author/show/58.Frank_Herbert", "https://example.com/dp/B08N5WRWNW"} {
+		if ref, ok := ParseBookURL(bad); ok {
+			t.Errorf("ParseBookURL(%q) = %+v, want no match", bad, ref)
+		}
+	}
+}
+
+func TestParseBookIdentifier(t *testing.T) {
+	cases := map[string]BookRef{
+		"978-0-441-01359-3":        {Source: "isbn", ISBN13: "9780441013593"},
+		" 0441013597 ":             {Source: "isbn", ISBN10: "0441013597", ISBN13: "9780441013593"},
+		"b08fhbv4zx":               {Source: "asin", ASIN: "B08FHBV4ZX"},
+		"amazon.com/dp/B08N5WRWNW": {Source: "amazon", Host: "amazon.com", ASIN: "B08N5WRWNW"},
+	}
+	for in, want := range cases {
+		if got, ok := ParseBookIdentifier(in); !ok || got != want {
+			t.Errorf("ParseBookIdentifier(%q) = %+v, %v; want %+v", in, got, ok, want)
+		}
+	}
+	// A mistyped ISBN-10 is not mistaken for an ASIN.
+	for _, bad := range []string{"", "0441013598", "9780441013590", "dune"} {
+		if ref, ok := ParseBookIdentifier(bad); ok {
+			t.Errorf("ParseBookIdentifier(%q) = %+v, want no match", bad, ref)
+		}
+	}
+}
+
+func TestBookRefReadarrTerm(t *testing.T) {
+	cases := map[string]BookRef{
+		"goodreads:234225":   {GoodreadsID: "234225", ISBN13: "9780441013593"},
+		"isbn:9780441013593": {ASIN: "0441013597", ISBN10: "0441013597", ISBN13: "9780441013593"},
+		"B08FHBV4ZX":         {ASIN: "B08FHBV4ZX"},
+		"":                   {GoogleVolumeID: "abc123"},
+	}
+	for want, ref := range cases {
+		if got := ref.ReadarrTerm(); got != want {
+			t.Errorf("%+v: got %q want %q", ref, got, want)
+		}
+	}
+}
+
+func TestGoogleBooksVolume(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/volumes/B1hSG45JCX4C" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"volumeInfo":{"title":"Dune","authors":["Frank Herbert"],"publishedDate":"1990-09-01",
+			"industryIdentifiers":[{"type":"ISBN_10","identifier":"0441172717"},{"type":"ISBN_13","identifier":"9780441172719"}],
+			"imageLinks":{"thumbnail":"http://books.google.com/thumb.jpg"}}}`)
+	}))
+	defer srv.Close()
+	g := &GoogleBooks{client: srv.Client(), baseURL: srv.URL}
+
+	book, err := g.Volume(context.Background(), "B1hSG45JCX4C")
+	if err != nil {
+		t.Fatalf("volume: %v", err)
+	}
+	if book.Title != "Dune" || book.ISBN13 != "9780441172719" || book.ISBN10 != "0441172717" || book.FirstPublishYear != 1990 || book.CoverMedium != "https://books.google.com/thumb.jpg" {
+		t.Fatalf("unexpected book: %+v", book)
+	}
+	if _, err := g.Volume(context.Background(), "missing"); err == nil {
+		t.Fatal("expected error for unknown volume")
+	}
+}
@@ -0,0 +1,146 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoogleBooks resolves Google Books volume ids through the public volumes
+// API, which needs no key for single lookups.
+type GoogleBooks struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewGoogleBooks() *GoogleBooks {
+	return &GoogleBooks{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: "https://www.googleapis.com/books/v1",
+	}
+}
+
+type googleVolume struct {
+	VolumeInfo struct {
+		Title               string   `json:"title"`
+		Subtitle            string   `json:"subtitle"`
+		Authors             []string `json:"authors"`
+		PublishedDate       string   `json:"publishedDate"`
+		Description         string   `json:"description"`
+		IndustryIdentifiers []struct {
+			Type       string `json:"type"`
+			Identifier string `json:"identifier"`
+		} `json:"industryIdentifiers"`
+		ImageLinks struct {
+			SmallThumbnail string `json:"smallThumbnail"`
+			Thumbnail      string `json:"thumbnail"`
+		} `json:"imageLinks"`
+	} `json:"volumeInfo"`
+}
+
+// Volume looks up one volume by id.
+func (g *GoogleBooks) Volume(ctx context.Context, id string) (*BookItem, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, errors.New("volume id required")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/volumes/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, errors.New(resp.Status)
+	}
+	var v googleVolume
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+	item := v.bookItem()
+	if item == nil {
+		return nil, errors.New("volume has no title")
+	}
+	return item, nil
+}
+
+// Search runs a volumes query such as "isbn:9780441013593" or
+// `intitle:"Dune" inauthor:"Frank Herbert"` and returns up to limit books.
+func (g *GoogleBooks) Search(ctx context.Context, q string, limit int) ([]BookItem, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+	params := url.Values{"q": {q}, "maxResults": {strconv.Itoa(limit)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/volumes?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, errors.New(resp.Status)
+	}
+	var out struct {
+		Items []googleVolume `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	books := make([]BookItem, 0, len(out.Items))
+	for _, v := range out.Items {
+		if item := v.bookItem(); item != nil {
+			books = append(books, *item)
+		}
+	}
+	return books, nil
+}
+
+// bookItem converts a volume, returning nil when it has no title.
+func (v googleVolume) bookItem() *BookItem {
+	info := v.VolumeInfo
+	if strings.TrimSpace(info.Title) == "" {
+		return nil
+	}
+	item := &BookItem{
+		Title:       strings.TrimSpace(info.Title),
+		Authors:     info.Authors,
+		Description: info.Description,
+		CoverSmall:  httpsURL(info.ImageLinks.SmallThumbnail),
+		CoverMedium: httpsURL(info.ImageLinks.Thumbnail),
+	}
+	if len(info.PublishedDate) >= 4 {
+		item.FirstPublishYear, _ = strconv.Atoi(info.PublishedDate[:4])
+	}
+	for _, ident := range info.IndustryIdentifiers {
+		switch ident.Type {
+		case "ISBN_13":
+			item.ISBN13 = ident.Identifier
+		case "ISBN_10":
+			item.ISBN10 = ident.Identifier
+		}
+	}
+	return item
+}
+
+// httpsURL upgrades Google's http image links so they load on https pages.
+func httpsURL(s string) string {
+	if strings.HasPrefix(s, "http://") {
+		return "https://" + strings.TrimPrefix(s, "http://")
+	}
+	return s
+}
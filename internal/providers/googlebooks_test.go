@@ -0,0 +1,33 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGoogleBooksSearch(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("q")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items":[
+			{"volumeInfo":{"title":""}},
+			{"volumeInfo":{"title":"Dune","authors":["Frank Herbert"],"publishedDate":"1990-09-01","industryIdentifiers":[{"type":"ISBN_13","identifier":"9780441172719"},{"type":"ISBN_10","identifier":"0441172717"}]}}
+		]}`))
+	}))
+	defer srv.Close()
+	g := &GoogleBooks{client: srv.Client(), baseURL: srv.URL}
+
+	books, err := g.Search(context.Background(), `intitle:"Dune"`, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotQuery != `intitle:"Dune"` {
+		t.Fatalf("query = %q", gotQuery)
+	}
+	if len(books) != 1 || books[0].ISBN13 != "9780441172719" || books[0].ISBN10 != "0441172717" || books[0].FirstPublishYear != 1990 {
+		t.Fatalf("books = %+v", books)
+	}
+}
@@ -0,0 +1,164 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrISBNdbQuota means ISBNdb refused the call because the plan's daily
+// quota is used up.
+var ErrISBNdbQuota = errors.New("isbndb daily quota reached")
+
+// ISBNdb looks books up in isbndb.com, which needs an API key but has
+// publisher-supplied ISBNs, publishers, and list prices.
+type ISBNdb struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewISBNdb returns a client for the given key. baseURL is only set for the
+// premium and pro plans, which have their own hosts.
+func NewISBNdb(apiKey, baseURL string) *ISBNdb {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = "https://api2.isbndb.com"
+	}
+	return &ISBNdb{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: baseURL,
+		apiKey:  strings.TrimSpace(apiKey),
+	}
+}
+
+// ISBNdbBook is one ISBNdb record.
+type ISBNdbBook struct {
+	Title         string   `json:"title"`
+	TitleLong     string   `json:"title_long"`
+	ISBN          string   `json:"isbn"`
+	ISBN13        string   `json:"isbn13"`
+	Authors       []string `json:"authors"`
+	Publisher     string   `json:"publisher"`
+	DatePublished string   `json:"date_published"`
+	Pages         int      `json:"pages"`
+	Binding       string   `json:"binding"`
+	Language      string   `json:"language"`
+	Image         string   `json:"image"`
+	Synopsis      string   `json:"synopsis"`
+	// MSRP is the list price without a currency; ISBNdb sends it as a
+	// number or a string depending on the record.
+	MSRP json.RawMessage `json:"msrp"`
+}
+
+// Price returns the list price, or "" when ISBNdb has none.
+func (b ISBNdbBook) Price() string {
+	v := strings.Trim(strings.TrimSpace(string(b.MSRP)), `"`)
+	if f, err := strconv.ParseFloat(v, 64); err != nil || f <= 0 {
+		return ""
+	}
+	return v
+}
+
+// BookItem converts the record for the enrichment chain.
+func (b ISBNdbBook) BookItem() *BookItem {
+	title := strings.TrimSpace(b.Title)
+	if title == "" {
+		title = strings.TrimSpace(b.TitleLong)
+	}
+	if title == "" {
+		return nil
+	}
+	item := &BookItem{
+		Title:       title,
+		Authors:     b.Authors,
+		ISBN13:      strings.TrimSpace(b.ISBN13),
+		Description: strings.TrimSpace(b.Synopsis),
+		CoverSmall:  b.Image,
+		CoverMedium: b.Image,
+	}
+	if isbn := strings.TrimSpace(b.ISBN); len(isbn) == 10 {
+		item.ISBN10 = isbn
+	}
+	if len(b.DatePublished) >= 4 {
+		item.FirstPublishYear, _ = strconv.Atoi(b.DatePublished[:4])
+	}
+	return item
+}
+
+// Book looks one ISBN-10 or ISBN-13 up. A book ISBNdb does not know returns
+// nil without an error.
+func (c *ISBNdb) Book(ctx context.Context, isbn string) (*ISBNdbBook, error) {
+	isbn = strings.ReplaceAll(strings.TrimSpace(isbn), "-", "")
+	if isbn == "" {
+		return nil, errors.New("isbn required")
+	}
+	var out struct {
+		Book ISBNdbBook `json:"book"`
+	}
+	found, err := c.get(ctx, "/book/"+url.PathEscape(isbn), &out)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &out.Book, nil
+}
+
+// Search runs a title/author keyword query and returns up to limit books.
+func (c *ISBNdb) Search(ctx context.Context, q string, limit int) ([]ISBNdbBook, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+	var out struct {
+		Books []ISBNdbBook `json:"books"`
+	}
+	path := "/books/" + url.PathEscape(q) + "?" + url.Values{"page": {"1"}, "pageSize": {strconv.Itoa(limit)}}.Encode()
+	if _, err := c.get(ctx, path, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Books) > limit {
+		out.Books = out.Books[:limit]
+	}
+	return out.Books, nil
+}
+
+// get reports found=false for a 404, which is how ISBNdb says it has no
+// such book.
+func (c *ISBNdb) get(ctx context.Context, path string, out any) (bool, error) {
+	if c.apiKey == "" {
+		return false, errors.New("isbndb api key not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return false, ErrISBNdbQuota
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return false, errors.New("isbndb rejected the api key")
+	case resp.StatusCode >= 400:
+		return false, errors.New(resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestISBNdbBookAndSearch(t *testing.T) {
+	c := NewISBNdb("secret", "")
+	c.client.Transport = rtFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("Authorization") != "secret" {
+			t.Fatalf("missing api key header")
+		}
+		status, body := 200, ""
+		switch {
+		case r.URL.Path == "/book/9780441013593":
+			body = `{"book":{"title":"Dune","isbn":"0441013597","isbn13":"9780441013593","authors":["Frank Herbert"],"publisher":"Ace","date_published":"2005-08-02","pages":528,"binding":"Paperback","msrp":"18.00"}}`
+		case r.URL.Path == "/book/9780000000000":
+			status = http.StatusNotFound
+		case r.URL.Path == "/book/9781111111111":
+			status = http.StatusTooManyRequests
+		case strings.HasPrefix(r.URL.Path, "/books/"):
+			if r.URL.Query().Get("pageSize") != "3" {
+				t.Fatalf("pageSize: %s", r.URL.RawQuery)
+			}
+			body = `{"total":1,"books":[{"title":"Dune","isbn13":"9780441013593","msrp":0}]}`
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+	ctx := context.Background()
+
+	b, err := c.Book(ctx, "978-0441013593")
+	if err != nil || b == nil {
+		t.Fatalf("book=%+v err=%v", b, err)
+	}
+	if b.Publisher != "Ace" || b.Price() != "18.00" || b.Pages != 528 {
+		t.Fatalf("unexpected book: %+v", b)
+	}
+	item := b.BookItem()
+	if item.ISBN10 != "0441013597" || item.ISBN13 != "9780441013593" || item.FirstPublishYear != 2005 {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+	if b, err := c.Book(ctx, "9780000000000"); b != nil || err != nil {
+		t.Fatalf("not found: book=%+v err=%v", b, err)
+	}
+	if _, err := c.Book(ctx, "9781111111111"); err != ErrISBNdbQuota {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	books, err := c.Search(ctx, "dune herbert", 3)
+	if err != nil || len(books) != 1 || books[0].Price() != "" {
+		t.Fatalf("search: %+v err=%v", books, err)
+	}
+	if _, err := NewISBNdb("", "").Book(ctx, "9780441013593"); err == nil {
+		t.Fatalf("expected error without a key")
+	}
+}
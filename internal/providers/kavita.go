@@ -0,0 +1,156 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// kavitaPageSize is how many series ListSeries asks for per page.
+const kavitaPageSize = 200
+
+// Kavita talks to a Kavita server's REST API. Requests authenticate with a
+// token exchanged for the user's API key.
+type Kavita struct {
+	base   string
+	apiKey string
+	cl     *http.Client
+	token  string
+}
+
+func NewKavita(baseURL, apiKey string, cl *http.Client) *Kavita {
+	return &Kavita{
+		base:   strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		apiKey: strings.TrimSpace(apiKey),
+		cl:     cl,
+	}
+}
+
+// KavitaSeries is one series. Kavita files a standalone book as a series of
+// one, so for ebooks a series is usually the book itself.
+type KavitaSeries struct {
+	ID        int    `json:"id"`
+	LibraryID int    `json:"libraryId"`
+	Name      string `json:"name"`
+	// OriginalName is the name in the file metadata when it was renamed.
+	OriginalName string `json:"originalName"`
+}
+
+// Path is the series page relative to the server URL.
+func (s KavitaSeries) Path() string {
+	return "/library/" + strconv.Itoa(s.LibraryID) + "/series/" + strconv.Itoa(s.ID)
+}
+
+// authenticate trades the API key for a bearer token, once per client.
+func (k *Kavita) authenticate(ctx context.Context) error {
+	if k.token != "" {
+		return nil
+	}
+	if k.base == "" || k.apiKey == "" {
+		return fmt.Errorf("kavita: url and api key are required")
+	}
+	var auth struct {
+		Token string `json:"token"`
+	}
+	path := "/api/Plugin/authenticate?pluginName=Scriptorum&apiKey=" + url.QueryEscape(k.apiKey)
+	if err := k.do(ctx, "POST", path, nil, &auth); err != nil {
+		return err
+	}
+	if auth.Token == "" {
+		return fmt.Errorf("kavita: authenticate returned no token")
+	}
+	k.token = auth.Token
+	return nil
+}
+
+func (k *Kavita) do(ctx context.Context, method, path string, body any, out any) error {
+	var rd *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, k.base+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if k.token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.token)
+	}
+	resp, err := k.cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("kavita %s: http %d", strings.SplitN(path, "?", 2)[0], resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Search returns the series whose names match q.
+func (k *Kavita) Search(ctx context.Context, q string) ([]KavitaSeries, error) {
+	if err := k.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	var out struct {
+		Series []struct {
+			SeriesID     int    `json:"seriesId"`
+			LibraryID    int    `json:"libraryId"`
+			Name         string `json:"name"`
+			OriginalName string `json:"originalName"`
+		} `json:"series"`
+	}
+	if err := k.do(ctx, "GET", "/api/Search/search?queryString="+url.QueryEscape(q), nil, &out); err != nil {
+		return nil, err
+	}
+	series := make([]KavitaSeries, 0, len(out.Series))
+	for _, s := range out.Series {
+		series = append(series, KavitaSeries{ID: s.SeriesID, LibraryID: s.LibraryID, Name: s.Name, OriginalName: s.OriginalName})
+	}
+	return series, nil
+}
+
+// ListSeries pages through every series the API key's user can see. A
+// libraryID above zero keeps only that library's series.
+func (k *Kavita) ListSeries(ctx context.Context, libraryID int) ([]KavitaSeries, error) {
+	if err := k.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	// An empty v2 filter matches everything, sorted by name.
+	filter := map[string]any{
+		"statements":  []any{},
+		"combination": 1,
+		"sortOptions": map[string]any{"sortField": 1, "isAscending": true},
+		"limitTo":     0,
+	}
+	var all []KavitaSeries
+	for page := 1; ; page++ {
+		var batch []KavitaSeries
+		path := fmt.Sprintf("/api/Series/all-v2?PageNumber=%d&PageSize=%d", page, kavitaPageSize)
+		if err := k.do(ctx, "POST", path, filter, &batch); err != nil {
+			return nil, err
+		}
+		for _, s := range batch {
+			if libraryID <= 0 || s.LibraryID == libraryID {
+				all = append(all, s)
+			}
+		}
+		if len(batch) < kavitaPageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKavitaListSeriesPagesAndFilters(t *testing.T) {
+	auths := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/Plugin/authenticate":
+			auths++
+			w.Write([]byte(`{"token":"jwt"}`))
+		case "/api/Series/all-v2":
+			if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer jwt" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			// A full first page forces a second request.
+			var page []string
+			if r.URL.Query().Get("PageNumber") == "1" {
+				for i := 1; i <= kavitaPageSize; i++ {
+					page = append(page, fmt.Sprintf(`{"id":%d,"libraryId":%d,"name":"Book %d"}`, i, 1+i%2, i))
+				}
+			} else {
+				page = append(page, `{"id":999,"libraryId":2,"name":"Dune","originalName":"Dune (1965)"}`)
+			}
+			w.Write([]byte("[" + strings.Join(page, ",") + "]"))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	kv := NewKavita(srv.URL+"/", "kv-key", srv.Client())
+	all, err := kv.ListSeries(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != kavitaPageSize+1 {
+		t.Fatalf("got %d series", len(all))
+	}
+	only, err := kv.ListSeries(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(only) != kavitaPageSize/2+1 || only[len(only)-1].OriginalName != "Dune (1965)" || only[len(only)-1].Path() != "/library/2/series/999" {
+		b, _ := json.Marshal(only[len(only)-1])
+		t.Fatalf("filtered %d series, last %s", len(only), b)
+	}
+	if auths != 1 {
+		t.Fatalf("authenticated %d times", auths)
+	}
+}
+
+func TestKavitaRequiresAPIKey(t *testing.T) {
+	if _, err := NewKavita("http://kavita.local", "", http.DefaultClient).Search(context.Background(), "Dune"); err == nil {
+		t.Fatal("expected an error without an api key")
+	}
+}
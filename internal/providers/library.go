@@ -0,0 +1,284 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Library server kinds a book can be linked to once it is available.
+const (
+	LibraryAudiobookshelf = "audiobookshelf"
+	LibraryKavita         = "kavita"
+	LibraryCalibreWeb     = "calibre-web"
+)
+
+// ErrLibraryItemNotFound means the library has no item matching the title,
+// often because it has not scanned the new file yet.
+var ErrLibraryItemNotFound = errors.New("library item not found")
+
+// LibraryServer is where a Readarr instance's books end up for reading or
+// listening.
+type LibraryServer struct {
+	Kind    string
+	BaseURL string
+	// PublicURL is the address readers open; links fall back to BaseURL.
+	PublicURL string
+	// APIKey is an Audiobookshelf API token or a Kavita API key.
+	APIKey string
+	// Username and Password log in to Calibre-Web's OPDS feed.
+	Username string
+	Password string
+	// LibraryID limits Audiobookshelf searches to one library.
+	LibraryID string
+}
+
+// NormalizeLibraryKind returns the canonical kind, or "" when unsupported.
+func NormalizeLibraryKind(kind string) string {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "audiobookshelf", "abs":
+		return LibraryAudiobookshelf
+	case "kavita":
+		return LibraryKavita
+	case "calibre-web", "calibreweb", "calibre_web":
+		return LibraryCalibreWeb
+	}
+	return ""
+}
+
+// Library looks up items on a LibraryServer.
+type Library struct {
+	srv LibraryServer
+	cl  *http.Client
+}
+
+func NewLibrary(srv LibraryServer, cl *http.Client) *Library {
+	srv.Kind = NormalizeLibraryKind(srv.Kind)
+	srv.BaseURL = strings.TrimRight(strings.TrimSpace(srv.BaseURL), "/")
+	srv.PublicURL = strings.TrimRight(strings.TrimSpace(srv.PublicURL), "/")
+	if srv.PublicURL == "" {
+		srv.PublicURL = srv.BaseURL
+	}
+	return &Library{srv: srv, cl: cl}
+}
+
+// libraryHit is one search result: its title, authors, and the path of its
+// page relative to the public URL.
+type libraryHit struct {
+	Title   string
+	Authors string
+	Path    string
+}
+
+// FindItemURL returns the public URL of the item matching title (and, when
+// the library reports authors, one of authors). It returns
+// ErrLibraryItemNotFound when nothing matches.
+func (l *Library) FindItemURL(ctx context.Context, title string, authors []string) (string, error) {
+	if l.srv.BaseURL == "" || l.srv.Kind == "" {
+		return "", fmt.Errorf("library server not configured")
+	}
+	var hits []libraryHit
+	var err error
+	switch l.srv.Kind {
+	case LibraryAudiobookshelf:
+		hits, err = l.searchAudiobookshelf(ctx, title)
+	case LibraryKavita:
+		hits, err = l.searchKavita(ctx, title)
+	case LibraryCalibreWeb:
+		hits, err = l.searchCalibreWeb(ctx, title)
+	}
+	if err != nil {
+		return "", err
+	}
+	if h, ok := bestLibraryHit(hits, title, authors); ok {
+		return l.srv.PublicURL + h.Path, nil
+	}
+	return "", ErrLibraryItemNotFound
+}
+
+// bestLibraryHit prefers an exact title match, then a title that contains
+// the other, skipping hits whose known authors share no one with authors.
+func bestLibraryHit(hits []libraryHit, title string, authors []string) (libraryHit, bool) {
+	want := normalizeLibraryTitle(title)
+	if want == "" {
+		return libraryHit{}, false
+	}
+	authorOK := func(h libraryHit) bool {
+		if strings.TrimSpace(h.Authors) == "" || len(authors) == 0 {
+			return true
+		}
+		got := strings.ToLower(h.Authors)
+		for _, a := range authors {
+			if a = strings.ToLower(strings.TrimSpace(a)); a != "" && strings.Contains(got, a) {
+				return true
+			}
+		}
+		return false
+	}
+	var partial *libraryHit
+	for i, h := range hits {
+		if h.Path == "" || !authorOK(h) {
+			continue
+		}
+		got := normalizeLibraryTitle(h.Title)
+		if got == want {
+			return h, true
+		}
+		if partial == nil && got != "" && (strings.Contains(got, want) || strings.Contains(want, got)) {
+			partial = &hits[i]
+		}
+	}
+	if partial != nil {
+		return *partial, true
+	}
+	return libraryHit{}, false
+}
+
+var libraryTitleJunk = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+func normalizeLibraryTitle(s string) string {
+	return strings.TrimSpace(libraryTitleJunk.ReplaceAllString(strings.ToLower(s), " "))
+}
+
+func (l *Library) getJSON(ctx context.Context, method, path, bearer string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, l.srv.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := l.cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s %s: http %d", l.srv.Kind, path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// searchAudiobookshelf searches the configured library, or every book
+// library when none is set. Items open at /item/{id}.
+func (l *Library) searchAudiobookshelf(ctx context.Context, title string) ([]libraryHit, error) {
+	libs := []string{strings.TrimSpace(l.srv.LibraryID)}
+	if libs[0] == "" {
+		var out struct {
+			Libraries []struct {
+				ID        string `json:"id"`
+				MediaType string `json:"mediaType"`
+			} `json:"libraries"`
+		}
+		if err := l.getJSON(ctx, "GET", "/api/libraries", l.srv.APIKey, &out); err != nil {
+			return nil, err
+		}
+		libs = libs[:0]
+		for _, lib := range out.Libraries {
+			if lib.MediaType == "" || lib.MediaType == "book" {
+				libs = append(libs, lib.ID)
+			}
+		}
+	}
+	var hits []libraryHit
+	for _, id := range libs {
+		var out struct {
+			Book []struct {
+				LibraryItem struct {
+					ID    string `json:"id"`
+					Media struct {
+						Metadata struct {
+							Title      string `json:"title"`
+							AuthorName string `json:"authorName"`
+						} `json:"metadata"`
+					} `json:"media"`
+				} `json:"libraryItem"`
+			} `json:"book"`
+		}
+		path := "/api/libraries/" + url.PathEscape(id) + "/search?limit=10&q=" + url.QueryEscape(title)
+		if err := l.getJSON(ctx, "GET", path, l.srv.APIKey, &out); err != nil {
+			return nil, err
+		}
+		for _, b := range out.Book {
+			md := b.LibraryItem.Media.Metadata
+			hits = append(hits, libraryHit{Title: md.Title, Authors: md.AuthorName, Path: "/item/" + url.PathEscape(b.LibraryItem.ID)})
+		}
+	}
+	return hits, nil
+}
+
+// searchKavita searches series by name. Series open at
+// /library/{libraryId}/series/{seriesId}.
+func (l *Library) searchKavita(ctx context.Context, title string) ([]libraryHit, error) {
+	series, err := NewKavita(l.srv.BaseURL, l.srv.APIKey, l.cl).Search(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]libraryHit, 0, len(series))
+	for _, sr := range series {
+		hits = append(hits, libraryHit{Title: sr.Name, Path: sr.Path()})
+	}
+	return hits, nil
+}
+
+// calibreBookID pulls the book id out of Calibre-Web OPDS download and cover
+// links.
+var calibreBookID = regexp.MustCompile(`/opds/(?:download|cover|cover_\d+_\d+|thumb_\d+_\d+)/(\d+)`)
+
+// searchCalibreWeb searches the OPDS feed, the only machine-readable API
+// Calibre-Web has. Books open at /book/{id}.
+func (l *Library) searchCalibreWeb(ctx context.Context, title string) ([]libraryHit, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", l.srv.BaseURL+"/opds/search/"+url.PathEscape(title), nil)
+	if err != nil {
+		return nil, err
+	}
+	if l.srv.Username != "" {
+		req.SetBasicAuth(l.srv.Username, l.srv.Password)
+	}
+	resp, err := l.cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("calibre-web opds search: http %d", resp.StatusCode)
+	}
+	var feed struct {
+		Entries []struct {
+			Title   string `xml:"title"`
+			Authors []struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Links []struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("calibre-web opds search: %w", err)
+	}
+	var hits []libraryHit
+	for _, e := range feed.Entries {
+		h := libraryHit{Title: e.Title}
+		var names []string
+		for _, a := range e.Authors {
+			names = append(names, a.Name)
+		}
+		h.Authors = strings.Join(names, ", ")
+		for _, link := range e.Links {
+			if m := calibreBookID.FindStringSubmatch(link.Href); m != nil {
+				h.Path = "/book/" + m[1]
+				break
+			}
+		}
+		hits = append(hits, h)
+	}
+	return hits, nil
+}
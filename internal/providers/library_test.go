@@ -0,0 +1,118 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLibraryAudiobookshelfFindsItem(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abs-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/libraries":
+			w.Write([]byte(`{"libraries":[{"id":"pod","mediaType":"podcast"},{"id":"lib1","mediaType":"book"}]}`))
+		case "/api/libraries/lib1/search":
+			if r.URL.Query().Get("q") != "Project Hail Mary" {
+				w.Write([]byte(`{"book":[]}`))
+				return
+			}
+			w.Write([]byte(`{"book":[
+				{"libraryItem":{"id":"li-other","media":{"metadata":{"title":"Project Hail Mary","authorName":"Someone Else"}}}},
+				{"libraryItem":{"id":"li-phm","media":{"metadata":{"title":"Project Hail Mary","authorName":"Andy Weir"}}}}]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	lib := NewLibrary(LibraryServer{Kind: "ABS", BaseURL: srv.URL + "/", PublicURL: "https://books.example/", APIKey: "abs-token"}, srv.Client())
+	got, err := lib.FindItemURL(context.Background(), "Project Hail Mary", []string{"Andy Weir"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "https://books.example/item/li-phm" {
+		t.Fatalf("url = %q", got)
+	}
+	if _, err := lib.FindItemURL(context.Background(), "Dune", nil); !errors.Is(err, ErrLibraryItemNotFound) {
+		t.Fatalf("missing item err = %v", err)
+	}
+}
+
+func TestLibraryKavitaFindsSeries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/Plugin/authenticate":
+			if r.Method != http.MethodPost || r.URL.Query().Get("apiKey") != "kv-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"jwt"}`))
+		case "/api/Search/search":
+			if r.Header.Get("Authorization") != "Bearer jwt" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"series":[{"seriesId":12,"libraryId":3,"name":"The Hobbit, or There and Back Again"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	lib := NewLibrary(LibraryServer{Kind: "kavita", BaseURL: srv.URL, APIKey: "kv-key"}, srv.Client())
+	got, err := lib.FindItemURL(context.Background(), "The Hobbit", []string{"J.R.R. Tolkien"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != srv.URL+"/library/3/series/12" {
+		t.Fatalf("url = %q", got)
+	}
+}
+
+func TestLibraryCalibreWebFindsBook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "reader" || p != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/opds/search/Emma" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <title>Emma</title>
+    <author><name>Jane Austen</name></author>
+    <link rel="http://opds-spec.org/image" href="/opds/cover/41"/>
+    <link rel="http://opds-spec.org/acquisition" href="/opds/download/41/epub/"/>
+  </entry>
+</feed>`))
+	}))
+	defer srv.Close()
+
+	lib := NewLibrary(LibraryServer{Kind: "calibre_web", BaseURL: srv.URL, Username: "reader", Password: "pw"}, srv.Client())
+	got, err := lib.FindItemURL(context.Background(), "Emma", []string{"Jane Austen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != srv.URL+"/book/41" {
+		t.Fatalf("url = %q", got)
+	}
+	if _, err := lib.FindItemURL(context.Background(), "Emma", []string{"Somebody Else"}); !errors.Is(err, ErrLibraryItemNotFound) {
+		t.Fatalf("author mismatch err = %v", err)
+	}
+}
+
+func TestNormalizeLibraryKind(t *testing.T) {
+	for in, want := range map[string]string{" Audiobookshelf ": LibraryAudiobookshelf, "CalibreWeb": LibraryCalibreWeb, "plex": ""} {
+		if got := NormalizeLibraryKind(in); got != want {
+			t.Errorf("NormalizeLibraryKind(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
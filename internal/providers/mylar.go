@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Mylar talks to a Mylar3 server's /api endpoint, which takes the API key and
+// command as query parameters.
+type Mylar struct {
+	base   string
+	apiKey string
+	cl     *http.Client
+}
+
+// NewMylar returns a client for the server at baseURL. A nil cl uses a client
+// with a 15 second timeout.
+func NewMylar(baseURL, apiKey string, cl *http.Client) *Mylar {
+	if cl == nil {
+		cl = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Mylar{
+		base:   strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		apiKey: strings.TrimSpace(apiKey),
+		cl:     cl,
+	}
+}
+
+// MylarComic is one series from Mylar's ComicVine search.
+type MylarComic struct {
+	ID          string `json:"comicid"`
+	Name        string `json:"name"`
+	Year        string `json:"comicyear"`
+	Publisher   string `json:"publisher"`
+	Issues      string `json:"issues"`
+	Image       string `json:"comicimage"`
+	Description string `json:"description"`
+	// HaveIt is "No" unless the series is already in Mylar's watchlist.
+	HaveIt string `json:"haveit"`
+}
+
+// Watched reports whether Mylar already watches the series.
+func (c MylarComic) Watched() bool {
+	v := strings.ToLower(strings.TrimSpace(c.HaveIt))
+	return v != "" && v != "no"
+}
+
+// FindComic searches ComicVine through Mylar for series named like name.
+func (m *Mylar) FindComic(ctx context.Context, name string) ([]MylarComic, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	var out []MylarComic
+	if err := m.call(ctx, url.Values{"cmd": {"findComic"}, "name": {name}}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AddComic adds the ComicVine series to Mylar's watchlist, which makes Mylar
+// search for its issues.
+func (m *Mylar) AddComic(ctx context.Context, comicID string) error {
+	comicID = strings.TrimSpace(comicID)
+	if comicID == "" {
+		return errors.New("comic id required")
+	}
+	return m.call(ctx, url.Values{"cmd": {"addComic"}, "id": {comicID}}, nil)
+}
+
+// call runs one API command. Mylar3 wraps results as {"success":..,"data":..}
+// and reports failures in the body with a 200 status; older builds return the
+// data bare.
+func (m *Mylar) call(ctx context.Context, q url.Values, out any) error {
+	if m.base == "" || m.apiKey == "" {
+		return errors.New("mylar not configured")
+	}
+	q.Set("apikey", m.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.base+"/api?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := m.cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return errors.New("mylar rejected the api key")
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("mylar %s: %s", q.Get("cmd"), resp.Status)
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("mylar %s: %w", q.Get("cmd"), err)
+	}
+	var env struct {
+		Success *bool           `json:"success"`
+		Data    json.RawMessage `json:"data"`
+		Error   struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(raw, &env) == nil && env.Success != nil {
+		if !*env.Success {
+			msg := strings.TrimSpace(env.Error.Message)
+			if msg == "" {
+				msg = "request failed"
+			}
+			return fmt.Errorf("mylar %s: %s", q.Get("cmd"), msg)
+		}
+		raw = env.Data
+	}
+	if out == nil || len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
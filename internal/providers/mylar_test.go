@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMylarFindAndAddComic(t *testing.T) {
+	var added string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api" || q.Get("apikey") != "my-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch q.Get("cmd") {
+		case "findComic":
+			if q.Get("name") != "saga" {
+				t.Errorf("name = %q", q.Get("name"))
+			}
+			w.Write([]byte(`{"success":true,"data":[{"comicid":"4050-48630","name":"Saga","comicyear":"2012","publisher":"Image","issues":"66","haveit":"No"},{"comicid":"4050-1","name":"Saga Classic","haveit":"Continuing"}]}`))
+		case "addComic":
+			if q.Get("id") == "bad" {
+				w.Write([]byte(`{"success":false,"error":{"code":404,"message":"comic not found"}}`))
+				return
+			}
+			added = q.Get("id")
+			w.Write([]byte(`{"success":true}`))
+		default:
+			t.Errorf("unexpected cmd %q", q.Get("cmd"))
+		}
+	}))
+	defer srv.Close()
+
+	m := NewMylar(srv.URL+"/", "my-key", srv.Client())
+	comics, err := m.FindComic(context.Background(), "saga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comics) != 2 || comics[0].ID != "4050-48630" || comics[0].Year != "2012" || comics[0].Watched() || !comics[1].Watched() {
+		t.Fatalf("unexpected comics: %+v", comics)
+	}
+	if err := m.AddComic(context.Background(), "4050-48630"); err != nil || added != "4050-48630" {
+		t.Fatalf("add: %v (added %q)", err, added)
+	}
+	if err := m.AddComic(context.Background(), "bad"); err == nil || !strings.Contains(err.Error(), "comic not found") {
+		t.Fatalf("expected Mylar's error, got %v", err)
+	}
+	if err := NewMylar(srv.URL, "wrong", srv.Client()).AddComic(context.Background(), "1"); err == nil || !strings.Contains(err.Error(), "api key") {
+		t.Fatalf("expected key error, got %v", err)
+	}
+}
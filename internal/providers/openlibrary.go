@@ -79,6 +79,8 @@ type OLDoc struct {
 	CoverEditionKey  string   `json:"cover_edition_key"`
 	FirstPublishYear int      `json:"first_publish_year"`
 	Key              string   `json:"key"`
+	RatingsAverage   float64  `json:"ratings_average"`
+	Subject          []string `json:"subject"`
 }
 type OLResp struct {
 	Docs []OLDoc `json:"docs"`
@@ -136,6 +138,47 @@ type OpenLibraryWorkDetails struct {
 	CoverMedium      string
 }
 
+// OLEditionResp is one edition as /books/{olid}.json and a work's
+// editions.json entries return it.
+type OLEditionResp struct {
+	Key            string   `json:"key"`
+	Title          string   `json:"title"`
+	Publishers     []string `json:"publishers"`
+	PublishDate    string   `json:"publish_date"`
+	ISBN10         []string `json:"isbn_10"`
+	ISBN13         []string `json:"isbn_13"`
+	NumberOfPages  int      `json:"number_of_pages"`
+	PhysicalFormat string   `json:"physical_format"`
+	Covers         []int    `json:"covers"`
+	Languages      []struct {
+		Key string `json:"key"`
+	} `json:"languages"`
+	Works []struct {
+		Key string `json:"key"`
+	} `json:"works"`
+}
+
+type OLEditionsResp struct {
+	Size    int             `json:"size"`
+	Entries []OLEditionResp `json:"entries"`
+}
+
+// OpenLibraryEdition is one printing of a work: who published it, when, in
+// which format and language, and under which ISBNs.
+type OpenLibraryEdition struct {
+	Key         string
+	WorkKey     string
+	Title       string
+	Publisher   string
+	PublishDate string
+	ISBN10      string
+	ISBN13      string
+	Pages       int
+	Format      string
+	Language    string
+	CoverMedium string
+}
+
 type BookItem struct {
 	ASIN                  string
 	Title                 string
@@ -151,6 +194,14 @@ type BookItem struct {
 	// Series is the series/collection title, when the source provider
 	// supplies one (currently only Readarr lookups do).
 	Series string
+	// Rating is the average reader rating out of 5, or 0 when unknown.
+	Rating float64
+	// Languages are the MARC language codes of the work's editions, when
+	// the source lists them.
+	Languages []string
+	// Subjects are the work's subjects or genres, when the source lists
+	// them.
+	Subjects []string
 }
 
 func (ol *OpenLibrary) Search(ctx context.Context, q string, limit, page int) ([]BookItem, error) {
@@ -232,6 +283,9 @@ func olDocsToBookItems(docs []OLDoc, languageCodes []string) []BookItem {
 			OpenLibraryEditionKey: d.CoverEditionKey,
 			CoverSmall:            cover,
 			CoverMedium:           cover,
+			Rating:                d.RatingsAverage,
+			Languages:             d.Language,
+			Subjects:              d.Subject,
 		})
 	}
 	return items
@@ -351,14 +405,13 @@ func (ol *OpenLibrary) SubjectWorks(ctx context.Context, subject string, limit i
 	return items, nil
 }
 
+// WorkDetails fetches a work's description, subjects, and first cover.
+// workKey may be "/works/OL123W" or the bare OLID "OL123W".
 func (ol *OpenLibrary) WorkDetails(ctx context.Context, workKey string) (*OpenLibraryWorkDetails, error) {
-	workKey = strings.TrimSpace(workKey)
+	workKey = normalizeOpenLibraryWorkKey(workKey)
 	if workKey == "" {
 		return nil, nil
 	}
-	if !strings.HasPrefix(workKey, "/") {
-		workKey = "/" + workKey
-	}
 	u := ol.apiURL(workKey + ".json")
 	var out OLWorkDetailsResp
 	if err := ol.getJSON(ctx, u, "work details", &out); err != nil {
@@ -380,6 +433,95 @@ func (ol *OpenLibrary) WorkDetails(ctx context.Context, workKey string) (*OpenLi
 	return details, nil
 }
 
+// WorkEditions lists up to limit editions of a work, as Open Library
+// orders them.
+func (ol *OpenLibrary) WorkEditions(ctx context.Context, workKey string, limit int) ([]OpenLibraryEdition, error) {
+	workKey = normalizeOpenLibraryWorkKey(workKey)
+	if workKey == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	u := ol.apiURL(workKey + "/editions.json?limit=" + strconv.Itoa(limit))
+	var out OLEditionsResp
+	if err := ol.getJSON(ctx, u, "work editions", &out); err != nil {
+		return nil, err
+	}
+	editions := make([]OpenLibraryEdition, 0, len(out.Entries))
+	for _, e := range out.Entries {
+		if len(editions) >= limit {
+			break
+		}
+		ed := openLibraryEditionFromResp(e)
+		if ed.WorkKey == "" {
+			ed.WorkKey = workKey
+		}
+		editions = append(editions, ed)
+	}
+	return editions, nil
+}
+
+// Edition fetches one edition by its OLID ("OL123M" or "/books/OL123M"),
+// including the work it belongs to.
+func (ol *OpenLibrary) Edition(ctx context.Context, olid string) (*OpenLibraryEdition, error) {
+	key := normalizeOpenLibraryEditionKey(olid)
+	if key == "" {
+		return nil, nil
+	}
+	var out OLEditionResp
+	if err := ol.getJSON(ctx, ol.apiURL("/books/"+url.PathEscape(key)+".json"), "edition", &out); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(out.Key) == "" && strings.TrimSpace(out.Title) == "" {
+		return nil, nil
+	}
+	ed := openLibraryEditionFromResp(out)
+	return &ed, nil
+}
+
+func openLibraryEditionFromResp(e OLEditionResp) OpenLibraryEdition {
+	ed := OpenLibraryEdition{
+		Key:         e.Key,
+		Title:       strings.TrimSpace(e.Title),
+		PublishDate: strings.TrimSpace(e.PublishDate),
+		Pages:       e.NumberOfPages,
+		Format:      strings.TrimSpace(e.PhysicalFormat),
+	}
+	if len(e.Publishers) > 0 {
+		ed.Publisher = strings.TrimSpace(e.Publishers[0])
+	}
+	if len(e.ISBN10) > 0 {
+		ed.ISBN10 = strings.TrimSpace(e.ISBN10[0])
+	}
+	if len(e.ISBN13) > 0 {
+		ed.ISBN13 = strings.TrimSpace(e.ISBN13[0])
+	}
+	if len(e.Languages) > 0 {
+		ed.Language = strings.TrimPrefix(e.Languages[0].Key, "/languages/")
+	}
+	if len(e.Works) > 0 {
+		ed.WorkKey = e.Works[0].Key
+	}
+	if len(e.Covers) > 0 && e.Covers[0] > 0 {
+		ed.CoverMedium = openLibraryCoverURL(e.Covers[0], "")
+	}
+	return ed
+}
+
+// normalizeOpenLibraryWorkKey turns "OL123W", "works/OL123W", or
+// "/works/OL123W" into "/works/OL123W".
+func normalizeOpenLibraryWorkKey(workKey string) string {
+	key := strings.Trim(strings.TrimSpace(workKey), "/")
+	if key == "" {
+		return ""
+	}
+	if !strings.Contains(key, "/") {
+		key = "works/" + key
+	}
+	return "/" + key
+}
+
 func openLibraryCoverURL(coverID int, coverEditionKey string) string {
 	if coverID != 0 {
 		return fmt.Sprintf("https://covers.openlibrary.org/b/id/%d-M.jpg", coverID)
@@ -139,3 +139,36 @@ func TestOpenLibraryWorkDetails(t *testing.T) {
 		t.Fatalf("unexpected cover: %+v", details)
 	}
 }
+
+func TestOpenLibraryEditions(t *testing.T) {
+	ol := NewOpenLibrary()
+	ol.cl.Transport = rtFunc(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		switch r.URL.Path {
+		case "/works/OL1W/editions.json":
+			body = `{"size":1,"entries":[{"key":"/books/OL2M","title":"Dune","publishers":["Ace"],"publish_date":"1990","isbn_10":["0441172717"],"number_of_pages":535,"covers":[42],"languages":[{"key":"/languages/eng"}]}]}`
+		case "/books/OL2M.json":
+			body = `{"key":"/books/OL2M","title":"Dune","works":[{"key":"/works/OL1W"}]}`
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+	editions, err := ol.WorkEditions(context.Background(), "OL1W", 5)
+	if err != nil || len(editions) != 1 {
+		t.Fatalf("editions=%+v err=%v", editions, err)
+	}
+	ed := editions[0]
+	if ed.Publisher != "Ace" || ed.ISBN10 != "0441172717" || ed.Pages != 535 || ed.Language != "eng" || ed.WorkKey != "/works/OL1W" || ed.CoverMedium == "" {
+		t.Fatalf("unexpected edition: %+v", ed)
+	}
+	got, err := ol.Edition(context.Background(), "/books/OL2M")
+	if err != nil || got == nil || got.WorkKey != "/works/OL1W" {
+		t.Fatalf("edition=%+v err=%v", got, err)
+	}
+	for in, want := range map[string]string{"OL1W": "/works/OL1W", "works/OL1W": "/works/OL1W", "/works/OL1W": "/works/OL1W", " ": ""} {
+		if got := normalizeOpenLibraryWorkKey(in); got != want {
+			t.Fatalf("normalizeOpenLibraryWorkKey(%q)=%q want %q", in, got, want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -44,9 +45,9 @@ const (
 				"statistics": {{ if (index .Candidate "statistics") }}{{ toJSON (index .Candidate "statistics") }}{{ else }}{"bookFileCount":0,"bookCount":0,"totalBookCount":0,"sizeOnDisk":0}{{ end }},
 				"added": {{ toJSON (index .Candidate "added") }},
 				"addOptions": {
-					"addType": {{ if and (index .Candidate "addOptions") (index (index .Candidate "addOptions") "addType") }}{{ toJSON (index (index .Candidate "addOptions") "addType") }}{{ else }}"automatic"{{ end }},
-					"searchForNewBook": {{ if and (index .Candidate "addOptions") (index (index .Candidate "addOptions") "searchForNewBook") }}{{ toJSON (index (index .Candidate "addOptions") "searchForNewBook") }}{{ else }}true{{ end }},
-					"monitor": "all",
+					"addType": "automatic",
+					"searchForNewBook": true,
+					"monitor": "none",
 					"monitored": true,
 					"booksToMonitor": [],
 					"searchForMissingBooks": {{ if .Opts.SearchForMissing }}true{{ else }}false{{ end }}
@@ -114,6 +115,10 @@ type ReadarrInstance struct {
 	DefaultRootFolderPath   string
 	DefaultTags             []string
 	InsecureSkipVerify      bool
+	AddPolicy               AddPolicy
+	// CompatRetry retries adds that fail validation with the payload
+	// variants in readarr_compat.go.
+	CompatRetry bool
 }
 
 type Readarr struct {
@@ -131,25 +136,27 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 	if pmap == nil {
 		return pmap
 	}
+	ctx, cancel := context.WithTimeout(ctx, readarrEnrichTimeout)
+	defer cancel()
+	en := r.loadEnrichment(ctx, pmap)
+	rootFolder := func(override string) string {
+		return validRootFolderPath(override, r.inst.DefaultRootFolderPath, en.rootFolders, en.rootErr)
+	}
 	// Defaults
 	// Resolve a valid quality profile id, preferring opts override, then configured/default, and validate against server
 	resolveQID := func() int {
 		// Helper to check existence of an id on server
 		exists := func(id int) bool {
-			if id == 0 {
+			if id == 0 || en.qualityErr != nil {
 				return false
 			}
-			if qps, err := r.fetchQualityProfiles(ctx); err == nil {
-				if _, ok := qps[id]; ok {
-					return true
-				}
-			}
-			return false
+			_, ok := en.qualityProfiles[id]
+			return ok
 		}
 		if opts.QualityProfileID != 0 && exists(opts.QualityProfileID) {
 			return opts.QualityProfileID
 		}
-		if qid := r.getValidQualityProfileID(ctx); qid != 0 {
+		if qid := validQualityProfileID(r.inst.DefaultQualityProfileID, en.qualityProfiles, en.qualityErr); qid != 0 {
 			return qid
 		}
 		// last resort, try configured default even if not validated
@@ -159,6 +166,7 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 		return 0
 	}
 	resolvedQID := resolveQID()
+	metaID := r.defaultMetadataProfileID()
 	// Keep top-level field consistent (some servers ignore this, but set it anyway)
 	if resolvedQID != 0 {
 		pmap["qualityProfileId"] = resolvedQID
@@ -167,12 +175,12 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 		delete(pmap, "qualityProfileId")
 	}
 	if pmap["metadataProfileId"] == nil || fmt.Sprint(pmap["metadataProfileId"]) == "" || fmt.Sprint(pmap["metadataProfileId"]) == "0" {
-		pmap["metadataProfileId"] = 1
+		pmap["metadataProfileId"] = metaID
 	}
 	if pmap["rootFolderPath"] == nil || fmt.Sprint(pmap["rootFolderPath"]) == "" {
-		rp := r.getValidRootFolderPath(ctx, opts.RootFolderPath)
+		rp := rootFolder(opts.RootFolderPath)
 		if rp == "" {
-			rp = r.getValidRootFolderPath(ctx, "")
+			rp = rootFolder("")
 		}
 		if rp == "" {
 			rp = r.inst.DefaultRootFolderPath
@@ -184,15 +192,21 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 	if _, ok := pmap["monitored"]; !ok {
 		pmap["monitored"] = true
 	}
-	if _, ok := pmap["addOptions"]; !ok {
-		pmap["addOptions"] = map[string]any{"addType": "automatic", "monitor": "all", "monitored": true, "booksToMonitor": []any{}, "searchForMissingBooks": true, "searchForNewBook": true}
-	} else {
-		// Keep the selected book monitored when it is added.
-		if ao, ok := pmap["addOptions"].(map[string]any); ok {
-			ao["monitor"] = "all"
-			ao["monitored"] = true
-			pmap["addOptions"] = ao
+	// The instance's add policy decides addType, search-on-add, and what
+	// else to monitor; the selected book itself is always monitored.
+	policy := r.inst.AddPolicy
+	if ao, ok := pmap["addOptions"].(map[string]any); ok {
+		for k, v := range policy.bookAddOptions(true) {
+			if k == "searchForMissingBooks" || k == "booksToMonitor" {
+				if _, has := ao[k]; has {
+					continue
+				}
+			}
+			ao[k] = v
 		}
+		pmap["addOptions"] = ao
+	} else {
+		pmap["addOptions"] = policy.bookAddOptions(true)
 	}
 	if pmap["tags"] == nil && len(r.inst.DefaultTags) > 0 {
 		pmap["tags"] = r.inst.DefaultTags
@@ -203,7 +217,7 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 		pmap["editions"] = []any{}
 	}
 	if eds, ok := pmap["editions"].([]any); ok && len(eds) == 0 {
-		if fe := strings.TrimSpace(fmt.Sprint(pmap["foreignEditionId"])); fe != "" {
+		if fe, _ := pmap["foreignEditionId"].(string); strings.TrimSpace(fe) != "" {
 			pmap["editions"] = []any{map[string]any{"foreignEditionId": fe, "monitored": true}}
 		}
 	}
@@ -299,9 +313,9 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 				}
 			}
 			if am["rootFolderPath"] == nil || am["rootFolderPath"] == "" {
-				rp := r.getValidRootFolderPath(ctx, opts.RootFolderPath)
+				rp := rootFolder(opts.RootFolderPath)
 				if rp == "" {
-					rp = r.getValidRootFolderPath(ctx, "")
+					rp = rootFolder("")
 				}
 				if rp != "" {
 					am["rootFolderPath"] = rp
@@ -317,33 +331,21 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 				}
 				// metadataProfileId (default to 1 like top-level author)
 				if vm["metadataProfileId"] == nil || fmt.Sprint(vm["metadataProfileId"]) == "" || fmt.Sprint(vm["metadataProfileId"]) == "0" {
-					vm["metadataProfileId"] = 1
+					vm["metadataProfileId"] = metaID
 				}
 				// rootFolderPath
 				if vm["rootFolderPath"] == nil || fmt.Sprint(vm["rootFolderPath"]) == "" {
-					rp := r.getValidRootFolderPath(ctx, opts.RootFolderPath)
+					rp := rootFolder(opts.RootFolderPath)
 					if rp == "" {
-						rp = r.getValidRootFolderPath(ctx, "")
+						rp = rootFolder("")
 					}
 					if rp != "" {
 						vm["rootFolderPath"] = rp
 					}
 				}
-				// Ensure addOptions.monitor is enforced in the nested value shape as well
-				if _, ok := vm["addOptions"].(map[string]any); !ok {
-					vm["addOptions"] = map[string]any{
-						"monitor":        "none",
-						"booksToMonitor": []any{},
-						"monitored":      true,
-					}
-				} else {
-					if vamo, ok := vm["addOptions"].(map[string]any); ok {
-						vamo["monitor"] = "none"
-						vm["addOptions"] = vamo
-					}
-				}
-				// Also set author.value.monitorNewItems for Readarr variants expecting it at the author level
-				vm["monitorNewItems"] = "none"
+				// Apply the monitor policy in the nested value shape as well, for
+				// Readarr variants that read it there.
+				policy.applyAuthorPolicy(vm, opts.SearchForMissing)
 				am["value"] = vm
 			}
 			if am["foreignAuthorId"] == nil || am["foreignAuthorId"] == "" {
@@ -351,7 +353,7 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 					if Debug {
 						fmt.Printf("DEBUG: Author missing foreignAuthorId, trying to resolve name='%s'\n", nm)
 					}
-					if fid := r.LookupForeignAuthorIDString(ctx, nm); fid != "" {
+					if fid := en.foreignAuthorID; fid != "" {
 						if Debug {
 							fmt.Printf("DEBUG: Found foreignAuthorId via lookup: %s\n", fid)
 						}
@@ -383,7 +385,7 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 						}
 					}
 					if aid > 0 {
-						if details, err := r.GetAuthorByID(ctx, aid); err == nil && details != nil {
+						if details := en.author; details != nil {
 							if fid, _ := details["foreignAuthorId"].(string); strings.TrimSpace(fid) != "" {
 								am["foreignAuthorId"] = fid
 							}
@@ -396,7 +398,7 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 			}
 			// Ensure author metadataProfileId
 			if am["metadataProfileId"] == nil || fmt.Sprint(am["metadataProfileId"]) == "" || fmt.Sprint(am["metadataProfileId"]) == "0" {
-				am["metadataProfileId"] = 1
+				am["metadataProfileId"] = metaID
 			}
 			// Ensure author tags, prefer payload/top-level tags when present
 			if am["tags"] == nil {
@@ -410,24 +412,9 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 					}
 				}
 			}
-			// Ensure author addOptions block exists
-			if _, ok := am["addOptions"].(map[string]any); !ok {
-				am["addOptions"] = map[string]any{
-					// Ensure we do not auto-monitor author books when sending via Scriptorum
-					"monitor":               "none",
-					"booksToMonitor":        []any{},
-					"monitored":             true,
-					"searchForMissingBooks": opts.SearchForMissing,
-				}
-			} else {
-				// If author.addOptions exists, override monitor to "none" to avoid enabling monitoring of new books
-				if aao, ok := am["addOptions"].(map[string]any); ok {
-					aao["monitor"] = "none"
-					am["addOptions"] = aao
-				}
-			}
-			// Ensure author.monitorNewItems exists at top-level of the author object
-			am["monitorNewItems"] = "none"
+			// A new author only gets the rest of their books monitored when
+			// the policy asks for it.
+			policy.applyAuthorPolicy(am, opts.SearchForMissing)
 			pmap["author"] = am
 		}
 	}
@@ -462,7 +449,7 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 	if _, hasAuthor := pmap["author"]; !hasAuthor {
 		if aid, ok := pmap["authorId"].(int); ok && aid > 0 {
 			am := map[string]any{"id": aid}
-			if details, err := r.GetAuthorByID(ctx, aid); err == nil && details != nil {
+			if details := en.author; details != nil {
 				if fid, _ := details["foreignAuthorId"].(string); strings.TrimSpace(fid) != "" {
 					am["foreignAuthorId"] = fid
 				}
@@ -473,8 +460,8 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 			if resolvedQID != 0 {
 				am["qualityProfileId"] = resolvedQID
 			}
-			am["metadataProfileId"] = 1
-			if rp := r.getValidRootFolderPath(ctx, ""); rp != "" {
+			am["metadataProfileId"] = metaID
+			if rp := rootFolder(""); rp != "" {
 				am["rootFolderPath"] = rp
 			}
 			// Carry over tags/addOptions to author
@@ -487,34 +474,28 @@ func (r *Readarr) sanitizeAndEnrichPayload(ctx context.Context, pmap map[string]
 					am["tags"] = ints
 				}
 			}
-			if _, ok := am["addOptions"].(map[string]any); !ok {
-				am["addOptions"] = map[string]any{
-					// When injecting author from authorId, avoid enabling monitoring of all author books
-					"monitor":               "none",
-					"booksToMonitor":        []any{},
-					"monitored":             true,
-					"searchForMissingBooks": opts.SearchForMissing,
-				}
-			} else {
-				// Ensure injected author addOptions also force monitor to "none"
-				if aao, ok := am["addOptions"].(map[string]any); ok {
-					aao["monitor"] = "none"
-					am["addOptions"] = aao
-				}
-			}
+			policy.applyAuthorPolicy(am, opts.SearchForMissing)
 			pmap["author"] = am
 		}
 	}
 
+	if policy.strict() {
+		restrictToRequestedBook(pmap)
+	}
+
 	return pmap
 }
 
 func NewReadarrWithDB(i ReadarrInstance, db *sql.DB) *Readarr {
-	r := &Readarr{inst: normalize(i), cl: &http.Client{Timeout: 12 * time.Second}, db: db}
+	r := &Readarr{inst: normalize(i), db: db}
+	var tr http.RoundTripper
 	if r.inst.InsecureSkipVerify {
-		tr := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-		r.cl = &http.Client{Timeout: 12 * time.Second, Transport: tr}
+		tr = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	if db != nil {
+		tr = &usageTransport{db: db, provider: ReadarrUsageName(r.inst), next: tr}
 	}
+	r.cl = &http.Client{Timeout: 12 * time.Second, Transport: &readarrRetryTransport{next: tr}}
 	if db != nil {
 		r.initCacheTables()
 	}
@@ -580,7 +561,7 @@ func (r *Readarr) PingLookup(ctx context.Context) error {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
 		return readarrHTTPError("lookup ping failed", u, r.inst.APIKey, resp, body)
 	}
 	return nil
@@ -613,9 +594,8 @@ func (r *Readarr) LookupForeignAuthorIDString(ctx context.Context, name string)
 	if resp.StatusCode >= 400 {
 		return ""
 	}
-	body, _ := io.ReadAll(resp.Body)
 	var arr []map[string]any
-	if err := json.Unmarshal(body, &arr); err != nil {
+	if err := decodeReadarrBody(resp, &arr, "author lookup", r.inst.APIKey); err != nil {
 		return ""
 	}
 	for _, a := range arr {
@@ -648,13 +628,16 @@ func (r *Readarr) GetAuthorByID(ctx context.Context, id int) (map[string]any, er
 		return nil, readarrTransportError(u, r.inst.APIKey, err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// The author was deleted in Readarr; stop handing out its id.
+		r.InvalidateCachedAuthor(id)
+	}
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
 		return nil, readarrHTTPError("get author failed", u, r.inst.APIKey, resp, body)
 	}
-	body, _ := io.ReadAll(resp.Body)
 	var out map[string]any
-	if err := json.Unmarshal(body, &out); err != nil {
+	if err := decodeReadarrBody(resp, &out, "get author", r.inst.APIKey); err != nil {
 		return nil, err
 	}
 	return out, nil
@@ -677,25 +660,12 @@ func (r *Readarr) AddBook(ctx context.Context, candidate Candidate, opts AddOpts
 	// Parse, sanitize, and enrich JSON payload consistently
 	var pmap map[string]any
 	if err := json.Unmarshal(payload, &pmap); err == nil {
-		pmap = r.sanitizeAndEnrichPayload(context.Background(), pmap, opts)
+		pmap = r.sanitizeAndEnrichPayload(ctx, pmap, opts)
 		if b, err := json.Marshal(pmap); err == nil {
 			payload = b
 		}
 	}
-	req, u, err := r.newJSONRequest(ctx, readarrAddMethod, readarrAddEndpoint, url.Values{"includeAllAuthorBooks": {"false"}}, bytes.NewReader(payload))
-	if err != nil {
-		return payload, nil, err
-	}
-	resp, err := r.cl.Do(req)
-	if err != nil {
-		return payload, nil, readarrTransportError(u, r.inst.APIKey, err)
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 400 {
-		return payload, respBody, readarrHTTPError("add book failed", u, r.inst.APIKey, resp, respBody)
-	}
-	return payload, respBody, nil
+	return r.postAdd(ctx, url.Values{"includeAllAuthorBooks": {"false"}}, payload, "add book")
 }
 
 // AddBookRaw accepts a raw JSON payload (full Readarr book schema), performs
@@ -707,26 +677,13 @@ func (r *Readarr) AddBookRaw(ctx context.Context, raw json.RawMessage) ([]byte,
 	var pmap map[string]any
 	payload := raw
 	if err := json.Unmarshal(raw, &pmap); err == nil {
-		pmap = r.sanitizeAndEnrichPayload(context.Background(), pmap, AddOpts{})
+		pmap = r.sanitizeAndEnrichPayload(ctx, pmap, AddOpts{})
 		if b, err := json.Marshal(pmap); err == nil {
 			payload = b
 		}
 	}
 
-	req, u, err := r.newJSONRequest(ctx, readarrAddMethod, readarrAddEndpoint, nil, bytes.NewReader(payload))
-	if err != nil {
-		return payload, nil, err
-	}
-	resp, err := r.cl.Do(req)
-	if err != nil {
-		return payload, nil, readarrTransportError(u, r.inst.APIKey, err)
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 400 {
-		return payload, respBody, readarrHTTPError("add book (raw) failed", u, r.inst.APIKey, resp, respBody)
-	}
-	return payload, respBody, nil
+	return r.postAdd(ctx, nil, payload, "add book (raw)")
 }
 
 // GetBookByAddPayload resolves a matching catalog book for the payload that would
@@ -829,11 +786,11 @@ func (r *Readarr) MonitorBooks(ctx context.Context, ids []int, monitored bool) (
 		return nil, readarrTransportError(u, r.inst.APIKey, err)
 	}
 	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
+	body, err := readReadarrBody(resp, r.inst.APIKey)
 	if resp.StatusCode >= 400 {
 		return body, readarrHTTPError("monitor update failed", u, r.inst.APIKey, resp, body)
 	}
-	return body, nil
+	return body, err
 }
 
 // SearchBooks queues a Readarr book search command for the provided book ids.
@@ -855,11 +812,11 @@ func (r *Readarr) SearchBooks(ctx context.Context, ids []int) ([]byte, error) {
 		return nil, readarrTransportError(u, r.inst.APIKey, err)
 	}
 	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
+	body, err := readReadarrBody(resp, r.inst.APIKey)
 	if resp.StatusCode >= 400 {
 		return body, readarrHTTPError("book search command failed", u, r.inst.APIKey, resp, body)
 	}
-	return body, nil
+	return body, err
 }
 
 // ----- Lookup & matching (ISBN13 -> ISBN10 -> ASIN) -----
@@ -885,36 +842,19 @@ func (r *Readarr) LookupByTerm(ctx context.Context, term string) ([]LookupBook,
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
 		return nil, readarrHTTPError("lookup failed", u, r.inst.APIKey, resp, body)
 	}
-	// Read the entire response body first
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %s", sanitizeReadarrText(err.Error(), r.inst.APIKey))
-	}
-
-	// Try to decode as JSON
+	// Decode as it streams in; large author catalogs can run to megabytes.
 	var arr []LookupBook
-	if err := json.Unmarshal(body, &arr); err != nil {
-		bodyStr := string(body)
-		if len(bodyStr) > 200 {
-			bodyStr = bodyStr[:200] + "..."
-		}
-		// redact apikey in URL if present
-		safeURL := redactAPIKey(u)
-		return nil, fmt.Errorf("invalid JSON (ct=%s) (HTTP %s) from %s: %s", resp.Header.Get("Content-Type"), resp.Status, safeURL, bodyStr)
+	if err := decodeReadarrBody(resp, &arr, "lookup", r.inst.APIKey); err != nil {
+		return nil, err
 	}
 
 	// Cache the results for 1 hour
 	if data, err := json.Marshal(arr); err == nil {
 		r.setCachedData(cacheKey, "lookup", string(data), time.Hour)
 	}
-
-	// Debug: dump the full JSON response
-	if Debug {
-		fmt.Printf("DEBUG: Full Readarr lookup JSON: %s\n", string(body))
-	}
 	return arr, nil
 }
 
@@ -951,13 +891,8 @@ func (r *Readarr) GetBookDetails(ctx context.Context, bookID int) (map[string]in
 			continue // Try next endpoint
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			continue
-		}
-
 		var details map[string]interface{}
-		if err := json.Unmarshal(body, &details); err != nil {
+		if err := decodeReadarrBody(resp, &details, "book details", r.inst.APIKey); err != nil {
 			continue
 		}
 
@@ -966,101 +901,12 @@ func (r *Readarr) GetBookDetails(ctx context.Context, bookID int) (map[string]in
 			r.setCachedData(cacheKey, "book_details", string(data), time.Hour)
 		}
 
-		// Debug: dump the full JSON response
-		if Debug {
-			fmt.Printf("DEBUG: Full Readarr book details JSON from %s: %s\n", endpoint, string(body))
-		}
-
 		return details, nil
 	}
 
 	return nil, fmt.Errorf("failed to fetch book details for ID %d", bookID)
 }
 
-// FindAuthorIDByName searches Readarr for an author by name. If found, returns the id.
-// If no author is found, returns (0, nil). Returns a non-nil error only for transport/parse errors.
-func (r *Readarr) FindAuthorIDByName(ctx context.Context, name string) (int, error) {
-	name = strings.TrimSpace(name)
-	if name == "" {
-		return 0, nil
-	}
-
-	// Check cache first
-	if cachedID, found := r.getCachedAuthor(name); found {
-		return cachedID, nil
-	}
-
-	// Use the author lookup endpoint
-	req, u, err := r.newRequest(ctx, http.MethodGet, "/api/v1/author/lookup", url.Values{"term": {name}}, nil)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := r.cl.Do(req)
-	if err != nil {
-		return 0, readarrTransportError(u, r.inst.APIKey, err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, readarrHTTPError("author lookup failed", u, r.inst.APIKey, resp, body)
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read response body: %s", sanitizeReadarrText(err.Error(), r.inst.APIKey))
-	}
-	var arr []map[string]any
-	if err := json.Unmarshal(body, &arr); err != nil {
-		return 0, fmt.Errorf("invalid JSON from author lookup: %v", err)
-	}
-
-	var foundID int
-	for _, a := range arr {
-		if nm, _ := a["name"].(string); nm != "" && strings.EqualFold(strings.TrimSpace(nm), name) {
-			if idv, ok := a["id"]; ok {
-				switch v := idv.(type) {
-				case float64:
-					foundID = int(v)
-				case int:
-					foundID = v
-				case int64:
-					foundID = int(v)
-				case string:
-					if i, err := strconv.Atoi(v); err == nil {
-						foundID = i
-					}
-				}
-				if foundID > 0 {
-					r.setCachedAuthor(name, foundID)
-					return foundID, nil
-				}
-			}
-		}
-	}
-	// no exact match found; if we have results, prefer the first with an id
-	for _, a := range arr {
-		if idv, ok := a["id"]; ok {
-			switch v := idv.(type) {
-			case float64:
-				foundID = int(v)
-			case int:
-				foundID = v
-			case int64:
-				foundID = int(v)
-			case string:
-				if i, err := strconv.Atoi(v); err == nil {
-					foundID = i
-				}
-			}
-			if foundID > 0 {
-				r.setCachedAuthor(name, foundID)
-				return foundID, nil
-			}
-		}
-	}
-	return 0, nil
-}
-
 // redactAPIKey hides apikey query param values from logs/errors
 func redactAPIKey(u string) string {
 	if u == "" {
@@ -1092,16 +938,34 @@ func readarrTransportError(u, apiKey string, err error) error {
 	return fmt.Errorf("request to %s failed: %s", redactAPIKey(u), sanitizeReadarrText(err.Error(), apiKey))
 }
 
+// ErrReadarrUnauthorized is wrapped by errors for Readarr responses with
+// HTTP 401 or 403, which almost always mean the API key was rotated or revoked.
+var ErrReadarrUnauthorized = errors.New("readarr rejected the API key")
+
 func readarrHTTPError(prefix, u, apiKey string, resp *http.Response, body []byte) error {
 	bodyStr := sanitizeReadarrText(string(body), apiKey)
 	if len(bodyStr) > 200 {
 		bodyStr = bodyStr[:200] + "..."
 	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%s (HTTP %s) from %s: %w", prefix, resp.Status, redactAPIKey(u), ErrReadarrUnauthorized)
+	}
 	return fmt.Errorf("%s (HTTP %s) from %s: %s", prefix, resp.Status, redactAPIKey(u), bodyStr)
 }
 
-// fetchQualityProfiles queries Readarr for quality profiles and returns a map[id->name]
+// fetchQualityProfiles returns the quality profiles as a map[id->name],
+// from the cache when it is fresh.
 func (r *Readarr) fetchQualityProfiles(ctx context.Context) (map[int]string, error) {
+	var out map[int]string
+	err := r.cachedSettings(cacheQualityProfiles, &out, func() (err error) {
+		out, err = r.loadQualityProfiles(ctx)
+		return err
+	})
+	return out, err
+}
+
+// loadQualityProfiles queries Readarr for quality profiles and returns a map[id->name]
+func (r *Readarr) loadQualityProfiles(ctx context.Context) (map[int]string, error) {
 	req, u, err := r.newRequest(ctx, http.MethodGet, "/api/v1/qualityprofile", nil, nil)
 	if err != nil {
 		return nil, err
@@ -1112,12 +976,11 @@ func (r *Readarr) fetchQualityProfiles(ctx context.Context) (map[int]string, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
 		return nil, readarrHTTPError("quality profile lookup failed", u, r.inst.APIKey, resp, body)
 	}
-	body, _ := io.ReadAll(resp.Body)
 	var arr []map[string]any
-	if err := json.Unmarshal(body, &arr); err != nil {
+	if err := decodeReadarrBody(resp, &arr, "quality profile lookup", r.inst.APIKey); err != nil {
 		return nil, err
 	}
 	out := make(map[int]string)
@@ -1162,12 +1025,11 @@ func (r *Readarr) fetchQualityProfileByID(ctx context.Context, id int) (string,
 		return "", false, nil
 	}
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
 		return "", false, readarrHTTPError("quality profile lookup failed", u, r.inst.APIKey, resp, body)
 	}
-	body, _ := io.ReadAll(resp.Body)
 	var obj map[string]any
-	if err := json.Unmarshal(body, &obj); err != nil {
+	if err := decodeReadarrBody(resp, &obj, "quality profile lookup", r.inst.APIKey); err != nil {
 		return "", false, err
 	}
 	name, _ := obj["name"].(string)
@@ -1192,8 +1054,19 @@ func (r *Readarr) GetQualityProfilesByID(ctx context.Context) (map[int]string, e
 	return out, nil
 }
 
-// fetchRootFolders queries Readarr for root folders and returns a slice of paths
+// fetchRootFolders returns the root folder paths, from the cache when it
+// is fresh.
 func (r *Readarr) fetchRootFolders(ctx context.Context) ([]string, error) {
+	var out []string
+	err := r.cachedSettings(cacheRootFolders, &out, func() (err error) {
+		out, err = r.loadRootFolders(ctx)
+		return err
+	})
+	return out, err
+}
+
+// loadRootFolders queries Readarr for root folders and returns a slice of paths
+func (r *Readarr) loadRootFolders(ctx context.Context) ([]string, error) {
 	req, u, err := r.newRequest(ctx, http.MethodGet, "/api/v1/rootfolder", nil, nil)
 	if err != nil {
 		return nil, err
@@ -1204,12 +1077,11 @@ func (r *Readarr) fetchRootFolders(ctx context.Context) ([]string, error) {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
 		return nil, readarrHTTPError("root folder lookup failed", u, r.inst.APIKey, resp, body)
 	}
-	body, _ := io.ReadAll(resp.Body)
 	var arr []map[string]any
-	if err := json.Unmarshal(body, &arr); err != nil {
+	if err := decodeReadarrBody(resp, &arr, "root folder lookup", r.inst.APIKey); err != nil {
 		return nil, err
 	}
 	out := []string{}
@@ -1223,55 +1095,14 @@ func (r *Readarr) fetchRootFolders(ctx context.Context) ([]string, error) {
 
 // getValidQualityProfileID returns a quality profile id to use: prefer configured DefaultQualityProfileID if present on server, otherwise return first available id
 func (r *Readarr) getValidQualityProfileID(ctx context.Context) int {
-	// prefer configured
-	if r.inst.DefaultQualityProfileID != 0 {
-		// verify it exists
-		if qps, err := r.fetchQualityProfiles(ctx); err == nil {
-			if _, ok := qps[r.inst.DefaultQualityProfileID]; ok {
-				return r.inst.DefaultQualityProfileID
-			}
-			// fallback to first available
-			for id := range qps {
-				return id
-			}
-		}
-	} else {
-		if qps, err := r.fetchQualityProfiles(ctx); err == nil {
-			for id := range qps {
-				return id
-			}
-		}
-	}
-	return 0
+	qps, err := r.fetchQualityProfiles(ctx)
+	return validQualityProfileID(r.inst.DefaultQualityProfileID, qps, err)
 }
 
 // getValidRootFolderPath returns a root folder path to use: prefer provided override, otherwise configured DefaultRootFolderPath if it exists on server, otherwise first available
 func (r *Readarr) getValidRootFolderPath(ctx context.Context, override string) string {
-	if override != "" {
-		// verify override exists
-		if rfs, err := r.fetchRootFolders(ctx); err == nil {
-			for _, p := range rfs {
-				if p == override {
-					return override
-				}
-			}
-		}
-	}
-	if r.inst.DefaultRootFolderPath != "" {
-		if rfs, err := r.fetchRootFolders(ctx); err == nil {
-			for _, p := range rfs {
-				if p == r.inst.DefaultRootFolderPath {
-					return r.inst.DefaultRootFolderPath
-				}
-			}
-		}
-	}
-	if rfs, err := r.fetchRootFolders(ctx); err == nil {
-		if len(rfs) > 0 {
-			return rfs[0]
-		}
-	}
-	return ""
+	rfs, err := r.fetchRootFolders(ctx)
+	return validRootFolderPath(override, r.inst.DefaultRootFolderPath, rfs, err)
 }
 
 // ----- Database caching methods -----
@@ -1296,6 +1127,8 @@ func (r *Readarr) initCacheTables() {
 			id INTEGER PRIMARY KEY,
 			name TEXT NOT NULL,
 			readarr_id INTEGER,
+			foreign_author_id TEXT NOT NULL DEFAULT '',
+			base_url TEXT NOT NULL DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)
@@ -1314,6 +1147,23 @@ func (r *Readarr) initCacheTables() {
 			FOREIGN KEY (author_id) REFERENCES readarr_authors(id)
 		)
 	`)
+	r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS cache_stats (
+			day TEXT NOT NULL,
+			cache_type TEXT NOT NULL,
+			hits INTEGER NOT NULL DEFAULT 0,
+			misses INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (day, cache_type)
+		)
+	`)
+	r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS provider_calls (
+			day TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			calls INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (day, provider)
+		)
+	`)
 }
 
 func (r *Readarr) getCachedData(cacheKey, cacheType string) (string, bool) {
@@ -1325,6 +1175,7 @@ func (r *Readarr) getCachedData(cacheKey, cacheType string) (string, bool) {
 		SELECT data FROM readarr_cache 
 		WHERE cache_key = ? AND cache_type = ? AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
 	`, cacheKey, cacheType).Scan(&data)
+	countCacheLookup(r.db, cacheType, err == nil)
 	if err != nil {
 		return "", false
 	}
@@ -1337,7 +1188,7 @@ func (r *Readarr) setCachedData(cacheKey, cacheType, data string, ttl time.Durat
 	}
 	var expiresAt *time.Time
 	if ttl > 0 {
-		t := time.Now().Add(ttl)
+		t := time.Now().UTC().Add(ttl)
 		expiresAt = &t
 	}
 	r.db.Exec(`
@@ -1345,28 +1196,3 @@ func (r *Readarr) setCachedData(cacheKey, cacheType, data string, ttl time.Durat
 		VALUES (?, ?, ?, ?)
 	`, cacheKey, cacheType, data, expiresAt)
 }
-
-func (r *Readarr) getCachedAuthor(name string) (int, bool) {
-	if r.db == nil {
-		return 0, false
-	}
-	var readarrID int
-	err := r.db.QueryRow(`
-		SELECT readarr_id FROM readarr_authors 
-		WHERE name = ? AND readarr_id IS NOT NULL
-	`, strings.ToLower(name)).Scan(&readarrID)
-	if err != nil {
-		return 0, false
-	}
-	return readarrID, true
-}
-
-func (r *Readarr) setCachedAuthor(name string, readarrID int) {
-	if r.db == nil {
-		return
-	}
-	r.db.Exec(`
-		INSERT OR REPLACE INTO readarr_authors (name, readarr_id, updated_at)
-		VALUES (?, ?, CURRENT_TIMESTAMP)
-	`, strings.ToLower(name), readarrID)
-}
@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Monitor policies for books added through Scriptorum. They decide what
+// Readarr monitors besides the requested book when the author is new to it.
+const (
+	MonitorNone = "none" // only the requested book
+	MonitorNew  = "new"  // the requested book and the author's future releases
+	MonitorAll  = "all"  // the author's whole backlist
+	// MonitorRequested is the strict form of MonitorNone: the author's
+	// booksToMonitor names only the requested book and only the requested
+	// edition is monitored, so Readarr cannot pick up other titles.
+	MonitorRequested = "requested"
+)
+
+// Readarr addOptions.addType values.
+const (
+	AddTypeAutomatic = "automatic"
+	AddTypeManual    = "manual"
+)
+
+// AddPolicy is how a Readarr instance adds requested books. The zero value
+// monitors only the requested book and searches for it right away.
+type AddPolicy struct {
+	Monitor    string
+	AddType    string
+	SkipSearch bool
+}
+
+// NormalizeMonitorPolicy maps a configured value to one of the Monitor*
+// constants, defaulting to MonitorNone.
+func NormalizeMonitorPolicy(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case MonitorAll:
+		return MonitorAll
+	case MonitorNew, "future":
+		return MonitorNew
+	case MonitorRequested, "strict":
+		return MonitorRequested
+	default:
+		return MonitorNone
+	}
+}
+
+// NormalizeAddType maps a configured value to AddTypeAutomatic or
+// AddTypeManual, defaulting to automatic.
+func NormalizeAddType(v string) string {
+	if strings.EqualFold(strings.TrimSpace(v), AddTypeManual) {
+		return AddTypeManual
+	}
+	return AddTypeAutomatic
+}
+
+// authorMonitor returns the author addOptions.monitor value and the
+// author's monitorNewItems value for the policy.
+func (p AddPolicy) authorMonitor() (monitor, newItems string) {
+	switch NormalizeMonitorPolicy(p.Monitor) {
+	case MonitorAll:
+		return "all", "all"
+	case MonitorNew:
+		return "future", "new"
+	default:
+		return "none", "none"
+	}
+}
+
+// strict reports whether the policy limits monitoring to the requested book.
+func (p AddPolicy) strict() bool {
+	return NormalizeMonitorPolicy(p.Monitor) == MonitorRequested
+}
+
+// restrictToRequestedBook points every booksToMonitor list in the payload at
+// the requested book and leaves only the requested edition monitored. It is
+// a no-op when the payload has no foreignBookId.
+func restrictToRequestedBook(pmap map[string]any) {
+	fb := strings.TrimSpace(fmt.Sprint(pmap["foreignBookId"]))
+	if fb == "" || fb == "<nil>" {
+		return
+	}
+	books := []any{fb}
+	setBooks := func(m map[string]any) {
+		if ao, ok := m["addOptions"].(map[string]any); ok {
+			ao["booksToMonitor"] = books
+		}
+	}
+	setBooks(pmap)
+	if am, ok := pmap["author"].(map[string]any); ok {
+		setBooks(am)
+		if vm, ok := am["value"].(map[string]any); ok {
+			setBooks(vm)
+		}
+	}
+	fe := strings.TrimSpace(fmt.Sprint(pmap["foreignEditionId"]))
+	if fe == "" || fe == "<nil>" {
+		return
+	}
+	pmap["anyEditionOk"] = false
+	if eds, ok := pmap["editions"].([]any); ok {
+		for _, e := range eds {
+			if em, ok := e.(map[string]any); ok {
+				em["monitored"] = strings.TrimSpace(fmt.Sprint(em["foreignEditionId"])) == fe
+			}
+		}
+	}
+}
+
+// bookAddOptions is the book-level addOptions block for the policy.
+func (p AddPolicy) bookAddOptions(searchForMissing bool) map[string]any {
+	monitor, _ := p.authorMonitor()
+	return map[string]any{
+		"addType":               NormalizeAddType(p.AddType),
+		"searchForNewBook":      !p.SkipSearch,
+		"monitor":               monitor,
+		"monitored":             true,
+		"booksToMonitor":        []any{},
+		"searchForMissingBooks": searchForMissing,
+	}
+}
+
+// applyAuthorPolicy sets the monitor fields on an author object, keeping any
+// other addOptions keys it already has.
+func (p AddPolicy) applyAuthorPolicy(am map[string]any, searchForMissing bool) {
+	monitor, newItems := p.authorMonitor()
+	ao, ok := am["addOptions"].(map[string]any)
+	if !ok {
+		ao = map[string]any{
+			"booksToMonitor":        []any{},
+			"monitored":             true,
+			"searchForMissingBooks": searchForMissing,
+		}
+	}
+	ao["monitor"] = monitor
+	am["addOptions"] = ao
+	am["monitorNewItems"] = newItems
+}
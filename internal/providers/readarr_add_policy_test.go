@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeAddPolicyValues(t *testing.T) {
+	for in, want := range map[string]string{"": MonitorNone, "bogus": MonitorNone, "ALL": MonitorAll, "future": MonitorNew, "new": MonitorNew} {
+		if got := NormalizeMonitorPolicy(in); got != want {
+			t.Errorf("NormalizeMonitorPolicy(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if NormalizeAddType("Manual") != AddTypeManual || NormalizeAddType("") != AddTypeAutomatic {
+		t.Fatal("unexpected add type normalization")
+	}
+}
+
+func TestAddBookAppliesAddPolicy(t *testing.T) {
+	var sent map[string]any
+	r := NewReadarrWithDB(ReadarrInstance{
+		BaseURL:   "http://readarr.local",
+		APIKey:    "k",
+		AddPolicy: AddPolicy{Monitor: MonitorNew, AddType: AddTypeManual, SkipSearch: true},
+	}, nil)
+	r.cl.Transport = rtFunc(func(req *http.Request) (*http.Response, error) {
+		body := "[]"
+		if req.Method == http.MethodPost {
+			b, _ := io.ReadAll(req.Body)
+			_ = json.Unmarshal(b, &sent)
+			body = `{"id":1}`
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	raw := json.RawMessage(`{"title":"Dune","foreignBookId":"fb","foreignEditionId":"fe","author":{"name":"Frank Herbert","foreignAuthorId":"fa","addOptions":{"monitor":"all"},"value":{}},"addOptions":{"addType":"automatic","searchForNewBook":true,"monitor":"all"}}`)
+	if _, _, err := r.AddBookRaw(context.Background(), raw); err != nil {
+		t.Fatal(err)
+	}
+	ao, _ := sent["addOptions"].(map[string]any)
+	if ao["addType"] != "manual" || ao["searchForNewBook"] != false || ao["monitor"] != "future" || ao["monitored"] != true {
+		t.Fatalf("book addOptions = %#v", ao)
+	}
+	author, _ := sent["author"].(map[string]any)
+	aao, _ := author["addOptions"].(map[string]any)
+	if aao["monitor"] != "future" || author["monitorNewItems"] != "new" {
+		t.Fatalf("author = %#v", author)
+	}
+	value, _ := author["value"].(map[string]any)
+	if value["monitorNewItems"] != "new" {
+		t.Fatalf("author.value = %#v", value)
+	}
+	if sent["monitored"] != true {
+		t.Fatalf("requested book should stay monitored: %#v", sent["monitored"])
+	}
+}
+
+func TestStrictPolicyMonitorsOnlyRequestedBook(t *testing.T) {
+	r := NewReadarrWithDB(ReadarrInstance{BaseURL: "http://readarr.local", APIKey: "k", AddPolicy: AddPolicy{Monitor: "strict"}}, nil)
+	r.cl.Transport = rtFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("[]")), Header: make(http.Header)}, nil
+	})
+	pmap := map[string]any{
+		"foreignBookId":    "fb-1",
+		"foreignEditionId": "fe-2",
+		"anyEditionOk":     true,
+		"editions": []any{
+			map[string]any{"foreignEditionId": "fe-1", "monitored": true},
+			map[string]any{"foreignEditionId": "fe-2", "monitored": false},
+		},
+		"author": map[string]any{"name": "A", "foreignAuthorId": "fa", "value": map[string]any{}},
+	}
+	out := r.sanitizeAndEnrichPayload(context.Background(), pmap, AddOpts{})
+
+	author := out["author"].(map[string]any)
+	aao := author["addOptions"].(map[string]any)
+	if aao["monitor"] != "none" || author["monitorNewItems"] != "none" {
+		t.Fatalf("author = %#v", author)
+	}
+	for name, ao := range map[string]map[string]any{
+		"book":         out["addOptions"].(map[string]any),
+		"author":       aao,
+		"author.value": author["value"].(map[string]any)["addOptions"].(map[string]any),
+	} {
+		if books, _ := ao["booksToMonitor"].([]any); len(books) != 1 || books[0] != "fb-1" {
+			t.Errorf("%s booksToMonitor = %#v", name, ao["booksToMonitor"])
+		}
+	}
+	eds := out["editions"].([]any)
+	if eds[0].(map[string]any)["monitored"] != false || eds[1].(map[string]any)["monitored"] != true || out["anyEditionOk"] != false {
+		t.Fatalf("editions = %#v anyEditionOk = %v", eds, out["anyEditionOk"])
+	}
+
+	// Without strict mode the lists stay empty.
+	r.inst.AddPolicy.Monitor = MonitorNone
+	out = r.sanitizeAndEnrichPayload(context.Background(), map[string]any{"foreignBookId": "fb-1"}, AddOpts{})
+	if books, _ := out["addOptions"].(map[string]any)["booksToMonitor"].([]any); len(books) != 0 {
+		t.Fatalf("booksToMonitor = %#v", books)
+	}
+}
@@ -0,0 +1,290 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AuthorMatch is one result of a Readarr author lookup. ID is 0 when the
+// author is not in the Readarr library yet.
+type AuthorMatch struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	ForeignAuthorID string `json:"foreignAuthorId"`
+	Disambiguation  string `json:"disambiguation,omitempty"`
+}
+
+// LookupAuthors runs a Readarr author lookup for term. Results keep Readarr's
+// order.
+func (r *Readarr) LookupAuthors(ctx context.Context, term string) ([]AuthorMatch, error) {
+	req, u, err := r.newRequest(ctx, http.MethodGet, "/api/v1/author/lookup", url.Values{"term": {term}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.cl.Do(req)
+	if err != nil {
+		return nil, readarrTransportError(u, r.inst.APIKey, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
+		return nil, readarrHTTPError("author lookup failed", u, r.inst.APIKey, resp, body)
+	}
+	var arr []map[string]any
+	if err := decodeReadarrBody(resp, &arr, "author lookup", r.inst.APIKey); err != nil {
+		return nil, err
+	}
+	out := make([]AuthorMatch, 0, len(arr))
+	for _, a := range arr {
+		m := AuthorMatch{ID: authorIDValue(a["id"])}
+		m.Name, _ = a["name"].(string)
+		m.Name = strings.TrimSpace(m.Name)
+		m.ForeignAuthorID, _ = a["foreignAuthorId"].(string)
+		m.ForeignAuthorID = strings.TrimSpace(m.ForeignAuthorID)
+		m.Disambiguation, _ = a["disambiguation"].(string)
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// DeleteAuthor removes an author from Readarr without deleting files or
+// adding an import list exclusion, and forgets its cached id.
+func (r *Readarr) DeleteAuthor(ctx context.Context, id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid author id")
+	}
+	q := url.Values{"deleteFiles": {"false"}, "addImportListExclusion": {"false"}}
+	req, u, err := r.newRequest(ctx, http.MethodDelete, "/api/v1/author/"+strconv.Itoa(id), q, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.cl.Do(req)
+	if err != nil {
+		return readarrTransportError(u, r.inst.APIKey, err)
+	}
+	defer resp.Body.Close()
+	// An author that is already gone counts as removed.
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
+		return readarrHTTPError("delete author failed", u, r.inst.APIKey, resp, body)
+	}
+	r.InvalidateCachedAuthor(id)
+	return nil
+}
+
+// authorIDValue reads a Readarr id that may arrive as a number or a string.
+func authorIDValue(v any) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return 0
+}
+
+// FindAuthorIDByName searches Readarr for an author by name. If found, returns the id.
+// If no author is found, returns (0, nil). Returns a non-nil error only for transport/parse errors.
+// Distinct authors can share a name; callers that know the foreignAuthorId
+// should use FindAuthorID instead.
+func (r *Readarr) FindAuthorIDByName(ctx context.Context, name string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, nil
+	}
+
+	if cachedID, found := r.getCachedAuthor(name); found {
+		return cachedID, nil
+	}
+
+	matches, err := r.LookupAuthors(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	// Cache every exact match under its foreignAuthorId. When several
+	// authors share the name, the name alone stays ambiguous and later calls
+	// go back to Readarr instead of trusting whichever row was cached first.
+	foundID := 0
+	for _, m := range matches {
+		if m.ID <= 0 || !strings.EqualFold(m.Name, name) {
+			continue
+		}
+		r.CacheAuthor(m.Name, m.ForeignAuthorID, m.ID)
+		if foundID == 0 {
+			foundID = m.ID
+		}
+	}
+	if foundID > 0 {
+		return foundID, nil
+	}
+	// no exact match found; if we have results, prefer the first with an id
+	for _, m := range matches {
+		if m.ID > 0 {
+			if m.ForeignAuthorID != "" {
+				r.CacheAuthor(m.Name, m.ForeignAuthorID, m.ID)
+			}
+			return m.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// FindAuthorID resolves the Readarr id of the author with foreignAuthorID,
+// using name as the lookup term. It never falls back to a different author
+// with the same name: (0, nil) means the author is not in Readarr yet. With
+// an empty foreignAuthorID it behaves like FindAuthorIDByName.
+func (r *Readarr) FindAuthorID(ctx context.Context, name, foreignAuthorID string) (int, error) {
+	foreignAuthorID = strings.TrimSpace(foreignAuthorID)
+	if foreignAuthorID == "" {
+		return r.FindAuthorIDByName(ctx, name)
+	}
+	if id, found := r.CachedAuthorID(foreignAuthorID); found {
+		return id, nil
+	}
+	term := strings.TrimSpace(name)
+	if term == "" {
+		term = foreignAuthorID
+	}
+	matches, err := r.LookupAuthors(ctx, term)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range matches {
+		if m.ForeignAuthorID == foreignAuthorID && m.ID > 0 {
+			r.CacheAuthor(m.Name, m.ForeignAuthorID, m.ID)
+			return m.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// authorCacheScope keys cached author rows by instance, since each Readarr
+// instance numbers its authors independently.
+func (r *Readarr) authorCacheScope() string {
+	return strings.TrimRight(strings.TrimSpace(r.inst.BaseURL), "/")
+}
+
+// AuthorCacheScope is the key instance i's cached authors are stored under.
+func AuthorCacheScope(i ReadarrInstance) string {
+	return strings.TrimRight(normalize(i).BaseURL, "/")
+}
+
+// CachedAuthorID returns the cached Readarr id for foreignAuthorID.
+func (r *Readarr) CachedAuthorID(foreignAuthorID string) (int, bool) {
+	if r.db == nil || strings.TrimSpace(foreignAuthorID) == "" {
+		return 0, false
+	}
+	var readarrID int
+	err := r.db.QueryRow(`
+		SELECT readarr_id FROM readarr_authors
+		WHERE base_url = ? AND foreign_author_id = ? AND readarr_id IS NOT NULL
+		ORDER BY id DESC LIMIT 1
+	`, r.authorCacheScope(), strings.TrimSpace(foreignAuthorID)).Scan(&readarrID)
+	if err != nil {
+		return 0, false
+	}
+	return readarrID, true
+}
+
+// getCachedAuthor returns the cached id for name only when every cached row
+// for that name agrees on it.
+func (r *Readarr) getCachedAuthor(name string) (int, bool) {
+	if r.db == nil {
+		return 0, false
+	}
+	rows, err := r.db.Query(`
+		SELECT DISTINCT readarr_id FROM readarr_authors
+		WHERE base_url = ? AND name = ? AND readarr_id IS NOT NULL
+		LIMIT 2
+	`, r.authorCacheScope(), strings.ToLower(strings.TrimSpace(name)))
+	if err != nil {
+		return 0, false
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return 0, false
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 1 {
+		return 0, false
+	}
+	return ids[0], true
+}
+
+func (r *Readarr) setCachedAuthor(name string, readarrID int) {
+	r.CacheAuthor(name, "", readarrID)
+}
+
+// CacheAuthor stores one name/foreignAuthorId -> Readarr id mapping,
+// replacing any earlier row for the same foreignAuthorId (or, without one,
+// the same name).
+func (r *Readarr) CacheAuthor(name, foreignAuthorID string, readarrID int) {
+	if r.db == nil || readarrID <= 0 {
+		return
+	}
+	scope := r.authorCacheScope()
+	name = strings.ToLower(strings.TrimSpace(name))
+	foreignAuthorID = strings.TrimSpace(foreignAuthorID)
+	if foreignAuthorID != "" {
+		r.db.Exec(`DELETE FROM readarr_authors WHERE base_url = ? AND foreign_author_id = ?`, scope, foreignAuthorID)
+	} else {
+		r.db.Exec(`DELETE FROM readarr_authors WHERE base_url = ? AND name = ? AND foreign_author_id = ''`, scope, name)
+	}
+	r.db.Exec(`
+		INSERT INTO readarr_authors (name, readarr_id, foreign_author_id, base_url, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, name, readarrID, foreignAuthorID, scope)
+}
+
+// InvalidateCachedAuthor drops every cached mapping to readarrID, e.g. after
+// the author was deleted in Readarr.
+func (r *Readarr) InvalidateCachedAuthor(readarrID int) {
+	if r.db == nil {
+		return
+	}
+	r.db.Exec(`DELETE FROM readarr_authors WHERE base_url = ? AND readarr_id = ?`, r.authorCacheScope(), readarrID)
+}
+
+// PruneAuthorCache drops cached mappings whose Readarr id is not in live,
+// the author ids currently in the library. It returns how many rows went.
+func (r *Readarr) PruneAuthorCache(live map[int]bool) int {
+	if r.db == nil {
+		return 0
+	}
+	rows, err := r.db.Query(`SELECT DISTINCT readarr_id FROM readarr_authors WHERE base_url = ? AND readarr_id IS NOT NULL`, r.authorCacheScope())
+	if err != nil {
+		return 0
+	}
+	var stale []int
+	for rows.Next() {
+		var id int
+		if rows.Scan(&id) == nil && !live[id] {
+			stale = append(stale, id)
+		}
+	}
+	rows.Close()
+	removed := 0
+	for _, id := range stale {
+		res, err := r.db.Exec(`DELETE FROM readarr_authors WHERE base_url = ? AND readarr_id = ?`, r.authorCacheScope(), id)
+		if err == nil {
+			n, _ := res.RowsAffected()
+			removed += int(n)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Two authors called "John Smith": 11 is fa-1, 22 is fa-2.
+const sameNameAuthorsJSON = `[{"id":11,"name":"John Smith","foreignAuthorId":"fa-1"},{"id":22,"name":"John Smith","foreignAuthorId":"fa-2","disambiguation":"historian"}]`
+
+func TestFindAuthorIDKeysByForeignAuthorID(t *testing.T) {
+	calls := 0
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(sameNameAuthorsJSON))
+	}))
+	defer readarr.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "k"})
+	ctx := context.Background()
+
+	if id, err := ra.FindAuthorID(ctx, "John Smith", "fa-2"); err != nil || id != 22 {
+		t.Fatalf("fa-2: id=%d err=%v", id, err)
+	}
+	if id, err := ra.FindAuthorID(ctx, "John Smith", "fa-1"); err != nil || id != 11 {
+		t.Fatalf("fa-1: id=%d err=%v", id, err)
+	}
+	if id, _ := ra.FindAuthorID(ctx, "John Smith", "fa-2"); id != 22 || calls != 2 {
+		t.Fatalf("cached fa-2: id=%d calls=%d", id, calls)
+	}
+	if id, _ := ra.FindAuthorID(ctx, "John Smith", "fa-9"); id != 0 {
+		t.Fatalf("unknown foreign id resolved to %d", id)
+	}
+
+	// Both authors are cached under the same name, so the name alone is
+	// ambiguous and is looked up again rather than served from the cache.
+	before := calls
+	if _, found := ra.getCachedAuthor("john smith"); found {
+		t.Fatal("ambiguous name should not be served from the cache")
+	}
+	if id, _ := ra.FindAuthorIDByName(ctx, "John Smith"); id != 11 || calls != before+1 {
+		t.Fatalf("by name: id=%d calls=%d", id, calls)
+	}
+}
+
+func TestAuthorCacheInvalidation(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer readarr.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "k"})
+	other, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: "http://audio", APIKey: "k"})
+	other.db = ra.db
+
+	ra.CacheAuthor("Jane Doe", "fa-jane", 5)
+	ra.CacheAuthor("Ann Roe", "fa-ann", 6)
+	other.CacheAuthor("Jane Doe", "fa-jane", 50)
+	if id, found := other.CachedAuthorID("fa-jane"); !found || id != 50 {
+		t.Fatalf("instances should cache separately: id=%d found=%v", id, found)
+	}
+
+	if _, err := ra.GetAuthorByID(context.Background(), 5); err == nil {
+		t.Fatal("expected 404 error")
+	}
+	if _, found := ra.CachedAuthorID("fa-jane"); found {
+		t.Fatal("deleted author should be dropped from the cache")
+	}
+	if _, found := other.CachedAuthorID("fa-jane"); !found {
+		t.Fatal("other instance's mapping should survive")
+	}
+
+	ra.CacheAuthor("Jane Doe", "fa-jane", 5)
+	if n := ra.PruneAuthorCache(map[int]bool{5: true}); n != 1 {
+		t.Fatalf("pruned %d rows, want 1", n)
+	}
+	if _, found := ra.CachedAuthorID("fa-ann"); found {
+		t.Fatal("author missing from the library should be pruned")
+	}
+	if id, found := ra.CachedAuthorID("fa-jane"); !found || id != 5 {
+		t.Fatalf("live author pruned: id=%d found=%v", id, found)
+	}
+}
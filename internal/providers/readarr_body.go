@@ -0,0 +1,163 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrReadarrResponseTooLarge is returned when a Readarr response body is
+// larger than maxReadarrBodyBytes.
+var ErrReadarrResponseTooLarge = errors.New("readarr response too large")
+
+var (
+	// maxReadarrBodyBytes caps a successful response. Whole-library book
+	// listings are the largest responses Readarr sends.
+	maxReadarrBodyBytes int64 = 64 << 20
+	// maxReadarrErrorBytes caps an error response; only its start is shown.
+	maxReadarrErrorBytes int64 = 64 << 10
+)
+
+// readarrBodyReader reads a response body up to a size cap and keeps its
+// first bytes for error messages and the debug capture.
+type readarrBodyReader struct {
+	r    io.Reader
+	max  int64
+	n    int64
+	head []byte
+}
+
+func newReadarrBodyReader(resp *http.Response, max int64) *readarrBodyReader {
+	return &readarrBodyReader{r: io.LimitReader(resp.Body, max+1), max: max}
+}
+
+func (b *readarrBodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if room := captureBodyBytes - len(b.head); room > 0 {
+		b.head = append(b.head, p[:min(n, room)]...)
+	}
+	b.n += int64(n)
+	if b.n > b.max {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrReadarrResponseTooLarge, b.max)
+	}
+	return n, err
+}
+
+// snippet returns the start of the body, shortened for error messages.
+func (b *readarrBodyReader) snippet(apiKey string) string {
+	s := sanitizeReadarrText(string(b.head), apiKey)
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
+
+// readReadarrBody reads a whole response body, failing once it passes the
+// cap for its status.
+func readReadarrBody(resp *http.Response, apiKey string) ([]byte, error) {
+	max := maxReadarrBodyBytes
+	if resp.StatusCode >= 400 {
+		max = maxReadarrErrorBytes
+	}
+	b := newReadarrBodyReader(resp, max)
+	body, err := io.ReadAll(b)
+	captureReadarrResponse(resp, b, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %s", sanitizeReadarrText(err.Error(), apiKey))
+	}
+	return body, nil
+}
+
+// readReadarrErrorBody reads the start of an error response for the error
+// message; the rest is dropped.
+func readReadarrErrorBody(resp *http.Response, apiKey string) []byte {
+	b := newReadarrBodyReader(resp, maxReadarrErrorBytes)
+	body, _ := io.ReadAll(io.LimitReader(b, maxReadarrErrorBytes))
+	captureReadarrResponse(resp, b, apiKey)
+	return body
+}
+
+// decodeReadarrBody decodes a JSON response into v as it streams in, without
+// holding the raw body, failing once it passes maxReadarrBodyBytes.
+func decodeReadarrBody(resp *http.Response, v any, what, apiKey string) error {
+	b := newReadarrBodyReader(resp, maxReadarrBodyBytes)
+	err := json.NewDecoder(b).Decode(v)
+	captureReadarrResponse(resp, b, apiKey)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrReadarrResponseTooLarge):
+		return fmt.Errorf("%s from %s: %w", what, redactAPIKey(requestURL(resp)), err)
+	}
+	return fmt.Errorf("invalid JSON (ct=%s) (HTTP %s) from %s: %s", resp.Header.Get("Content-Type"), resp.Status, redactAPIKey(requestURL(resp)), b.snippet(apiKey))
+}
+
+func requestURL(resp *http.Response) string {
+	if resp.Request == nil || resp.Request.URL == nil {
+		return ""
+	}
+	return resp.Request.URL.String()
+}
+
+// ----- Debug capture -----
+
+// CapturedResponse is the start of a recent Readarr response, kept while
+// debug logging is on in place of printing whole bodies.
+type CapturedResponse struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	Status    int       `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	Body      string    `json:"body"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+const (
+	captureSize      = 50
+	captureBodyBytes = 4 << 10
+)
+
+var captures struct {
+	sync.Mutex
+	ring [captureSize]CapturedResponse
+	next int
+	n    int
+}
+
+func captureReadarrResponse(resp *http.Response, b *readarrBodyReader, apiKey string) {
+	if !Debug {
+		return
+	}
+	c := CapturedResponse{
+		Time:      time.Now().UTC(),
+		URL:       sanitizeReadarrText(requestURL(resp), apiKey),
+		Status:    resp.StatusCode,
+		Bytes:     b.n,
+		Body:      sanitizeReadarrText(string(b.head), apiKey),
+		Truncated: b.n > int64(len(b.head)),
+	}
+	if resp.Request != nil {
+		c.Method = resp.Request.Method
+	}
+	captures.Lock()
+	captures.ring[captures.next] = c
+	captures.next = (captures.next + 1) % captureSize
+	captures.n = min(captures.n+1, captureSize)
+	captures.Unlock()
+}
+
+// RecentReadarrResponses returns the captured responses, newest first.
+func RecentReadarrResponses() []CapturedResponse {
+	captures.Lock()
+	defer captures.Unlock()
+	out := make([]CapturedResponse, 0, captures.n)
+	for i := 1; i <= captures.n; i++ {
+		out = append(out, captures.ring[(captures.next-i+captureSize)%captureSize])
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLookupByTermCapsResponseSize(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"title":"` + strings.Repeat("x", 4096) + `"}]`))
+	}))
+	defer readarr.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "k"})
+
+	defer func(n int64) { maxReadarrBodyBytes = n }(maxReadarrBodyBytes)
+	maxReadarrBodyBytes = 1024
+	if _, err := ra.LookupByTerm(context.Background(), "big"); !errors.Is(err, ErrReadarrResponseTooLarge) {
+		t.Fatalf("expected a size error, got %v", err)
+	}
+	maxReadarrBodyBytes = 1 << 20
+	books, err := ra.LookupByTerm(context.Background(), "big")
+	if err != nil || len(books) != 1 {
+		t.Fatalf("books=%v err=%v", books, err)
+	}
+}
+
+func TestReadarrResponsesAreCapturedWhileDebugging(t *testing.T) {
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"title":"Dune","apikey":"secret-key"}` + strings.Repeat(" ", captureBodyBytes) + `]`))
+	}))
+	defer readarr.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "secret-key"})
+
+	defer func(d bool) { Debug = d }(Debug)
+	Debug = true
+	if _, err := ra.LookupByTerm(context.Background(), "dune"); err != nil {
+		t.Fatal(err)
+	}
+	got := RecentReadarrResponses()
+	if len(got) == 0 || !strings.Contains(got[0].URL, "term=dune") {
+		t.Fatalf("captures = %+v", got)
+	}
+	c := got[0]
+	if c.Status != http.StatusOK || !c.Truncated || len(c.Body) > captureBodyBytes || strings.Contains(c.Body, "secret-key") {
+		t.Fatalf("capture = %+v", c)
+	}
+}
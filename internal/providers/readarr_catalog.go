@@ -2,10 +2,9 @@ package providers
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
 type ReadarrStatistics struct {
@@ -20,7 +19,16 @@ type CatalogBook struct {
 }
 
 func (r *Readarr) ListBooks(ctx context.Context) ([]CatalogBook, error) {
-	req, u, err := r.newRequest(ctx, http.MethodGet, "/api/v1/book", nil, nil)
+	return r.listBooks(ctx, nil)
+}
+
+// ListAuthorBooks returns the books Readarr holds for one author.
+func (r *Readarr) ListAuthorBooks(ctx context.Context, authorID int) ([]CatalogBook, error) {
+	return r.listBooks(ctx, url.Values{"authorId": {strconv.Itoa(authorID)}})
+}
+
+func (r *Readarr) listBooks(ctx context.Context, query url.Values) ([]CatalogBook, error) {
+	req, u, err := r.newRequest(ctx, http.MethodGet, "/api/v1/book", query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -30,17 +38,14 @@ func (r *Readarr) ListBooks(ctx context.Context) ([]CatalogBook, error) {
 		return nil, readarrTransportError(u, r.inst.APIKey, err)
 	}
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %s", sanitizeReadarrText(err.Error(), r.inst.APIKey))
-	}
 	if resp.StatusCode >= 400 {
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
 		return nil, readarrHTTPError("list books failed", u, r.inst.APIKey, resp, body)
 	}
 
 	var out []CatalogBook
-	if err := json.Unmarshal(body, &out); err != nil {
-		return nil, fmt.Errorf("invalid JSON from catalog listing: %w", err)
+	if err := decodeReadarrBody(resp, &out, "catalog listing", r.inst.APIKey); err != nil {
+		return nil, err
 	}
 	return out, nil
 }
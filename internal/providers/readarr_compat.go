@@ -0,0 +1,182 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// compatVariant rewrites an add payload into a shape some Readarr versions
+// accept when they reject ours. It reports false when the rewrite does not
+// apply to the payload.
+type compatVariant struct {
+	Name  string
+	Apply func(pmap map[string]any) bool
+}
+
+// compatVariants are tried in order after a validation error when the
+// instance has CompatRetry on.
+var compatVariants = []compatVariant{
+	{Name: "without_quality_profile", Apply: withoutQualityProfile},
+	{Name: "author_id", Apply: authorIDOnly},
+	{Name: "manual_add", Apply: manualAddType},
+}
+
+const compatCacheType = "compat_variant"
+
+// withoutQualityProfile drops qualityProfileId so Readarr falls back to its
+// own default profile.
+func withoutQualityProfile(pmap map[string]any) bool {
+	changed := false
+	if _, ok := pmap["qualityProfileId"]; ok {
+		delete(pmap, "qualityProfileId")
+		changed = true
+	}
+	if am, ok := pmap["author"].(map[string]any); ok {
+		if _, ok := am["qualityProfileId"]; ok {
+			delete(am, "qualityProfileId")
+			changed = true
+		}
+	}
+	return changed
+}
+
+// authorIDOnly replaces the nested author object with authorId, for
+// authors already in the library.
+func authorIDOnly(pmap map[string]any) bool {
+	am, ok := pmap["author"].(map[string]any)
+	if !ok {
+		return false
+	}
+	id := authorIDValue(am["id"])
+	if id <= 0 {
+		id = authorIDValue(pmap["authorId"])
+	}
+	if id <= 0 {
+		return false
+	}
+	delete(pmap, "author")
+	pmap["authorId"] = id
+	return true
+}
+
+// manualAddType sets addType to "manual" on the book and its author.
+func manualAddType(pmap map[string]any) bool {
+	changed := false
+	set := func(m map[string]any) {
+		ao, _ := m["addOptions"].(map[string]any)
+		if ao == nil {
+			ao = map[string]any{}
+		}
+		if ao["addType"] != AddTypeManual {
+			ao["addType"] = AddTypeManual
+			m["addOptions"] = ao
+			changed = true
+		}
+	}
+	set(pmap)
+	if am, ok := pmap["author"].(map[string]any); ok {
+		set(am)
+	}
+	return changed
+}
+
+// isReadarrValidationError reports whether an add failed Readarr's request
+// validation, which answers 400 with a list of propertyName/errorMessage
+// entries. Other failures are never retried with a variant.
+func isReadarrValidationError(status int, body []byte) bool {
+	if status != http.StatusBadRequest && status != http.StatusUnprocessableEntity {
+		return false
+	}
+	return bytes.Contains(body, []byte(`"propertyName"`)) || bytes.Contains(body, []byte(`"errorMessage"`))
+}
+
+func (r *Readarr) compatCacheKey() string {
+	return compatCacheType + ":" + r.authorCacheScope()
+}
+
+// CompatVariant returns the payload variant that last got a rejected add
+// accepted by this instance, or "" when none has been needed.
+func (r *Readarr) CompatVariant() string {
+	v, _ := r.getCachedData(r.compatCacheKey(), compatCacheType)
+	return v
+}
+
+func (r *Readarr) rememberCompatVariant(name string) {
+	r.setCachedData(r.compatCacheKey(), compatCacheType, name, 0)
+}
+
+// postAdd POSTs an add payload. With CompatRetry on, a validation error
+// retries the compatibility variants, starting with the one that last
+// worked. The returned payload is the one Readarr accepted, or the original
+// when every attempt failed. A payload that fails ValidateAddPayload is not
+// sent at all.
+func (r *Readarr) postAdd(ctx context.Context, query url.Values, payload []byte, what string) ([]byte, []byte, error) {
+	if err := ValidateAddPayload(payload); err != nil {
+		return payload, nil, fmt.Errorf("%s: %w", what, err)
+	}
+	respBody, status, err := r.sendAdd(ctx, query, payload, what)
+	if err == nil || !r.inst.CompatRetry || !isReadarrValidationError(status, respBody) {
+		return payload, respBody, err
+	}
+	if json.Unmarshal(payload, new(map[string]any)) != nil {
+		return payload, respBody, err
+	}
+
+	order := compatVariants
+	if last := r.CompatVariant(); last != "" {
+		order = nil
+		for _, v := range compatVariants {
+			if v.Name == last {
+				order = append([]compatVariant{v}, order...)
+			} else {
+				order = append(order, v)
+			}
+		}
+	}
+	for _, v := range order {
+		var pmap map[string]any
+		_ = json.Unmarshal(payload, &pmap)
+		if !v.Apply(pmap) {
+			continue
+		}
+		b, merr := json.Marshal(pmap)
+		if merr != nil {
+			continue
+		}
+		vBody, vStatus, vErr := r.sendAdd(ctx, query, b, what)
+		if vErr == nil {
+			fmt.Printf("readarr: %s accepted by %s with compatibility variant %q; using it first from now on\n", what, redactAPIKey(r.inst.BaseURL), v.Name)
+			r.rememberCompatVariant(v.Name)
+			return b, vBody, nil
+		}
+		if !isReadarrValidationError(vStatus, vBody) {
+			return b, vBody, vErr
+		}
+		if Debug {
+			fmt.Printf("DEBUG: readarr compatibility variant %q rejected: %v\n", v.Name, vErr)
+		}
+	}
+	return payload, respBody, err
+}
+
+// sendAdd performs one add request and returns the response body and status.
+func (r *Readarr) sendAdd(ctx context.Context, query url.Values, payload []byte, what string) ([]byte, int, error) {
+	req, u, err := r.newJSONRequest(ctx, readarrAddMethod, readarrAddEndpoint, query, bytes.NewReader(payload))
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := r.cl.Do(req)
+	if err != nil {
+		return nil, 0, readarrTransportError(u, r.inst.APIKey, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := readReadarrBody(resp, r.inst.APIKey)
+	if resp.StatusCode >= 400 {
+		return respBody, resp.StatusCode, readarrHTTPError(what+" failed", u, r.inst.APIKey, resp, respBody)
+	}
+	return respBody, resp.StatusCode, err
+}
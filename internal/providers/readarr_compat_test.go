@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompatRetryFindsAndRemembersVariant(t *testing.T) {
+	var bodies []map[string]any
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		var pmap map[string]any
+		_ = json.Unmarshal(b, &pmap)
+		bodies = append(bodies, pmap)
+		// This Readarr only accepts adds by authorId.
+		if _, ok := pmap["author"]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`[{"propertyName":"Author","errorMessage":"Author already exists"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer readarr.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "k", DefaultQualityProfileID: 1, DefaultRootFolderPath: "/books", CompatRetry: true})
+	raw := json.RawMessage(`{"title":"T","foreignBookId":"fb","foreignEditionId":"fe","author":{"id":7,"name":"A","foreignAuthorId":"fa","qualityProfileId":1}}`)
+
+	sent, _, err := ra.AddBookRaw(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	var got map[string]any
+	_ = json.Unmarshal(sent, &got)
+	if got["author"] != nil || got["authorId"] != float64(7) {
+		t.Fatalf("accepted payload = %s", sent)
+	}
+	if len(bodies) != 3 || ra.CompatVariant() != "author_id" {
+		t.Fatalf("attempts=%d variant=%q", len(bodies), ra.CompatVariant())
+	}
+
+	// The remembered variant is tried right after the original next time.
+	bodies = nil
+	if _, _, err := ra.AddBookRaw(context.Background(), raw); err != nil || len(bodies) != 2 {
+		t.Fatalf("second add: attempts=%d err=%v", len(bodies), err)
+	}
+}
+
+func TestCompatRetryOffOrNonValidationErrors(t *testing.T) {
+	attempts := 0
+	status := http.StatusBadRequest
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		attempts++
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`[{"propertyName":"Title","errorMessage":"bad"}]`))
+	}))
+	defer readarr.Close()
+	raw := json.RawMessage(`{"title":"T","foreignBookId":"fb","foreignEditionId":"fe","author":{"id":7,"name":"A"}}`)
+
+	off, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "k", DefaultQualityProfileID: 1, DefaultRootFolderPath: "/books"})
+	if _, _, err := off.AddBookRaw(context.Background(), raw); err == nil || attempts != 1 {
+		t.Fatalf("retry off: attempts=%d err=%v", attempts, err)
+	}
+
+	on, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "k", DefaultQualityProfileID: 1, DefaultRootFolderPath: "/books", CompatRetry: true})
+	attempts = 0
+	status = http.StatusInternalServerError
+	if _, _, err := on.AddBookRaw(context.Background(), raw); err == nil || attempts != 1 {
+		t.Fatalf("server error: attempts=%d err=%v", attempts, err)
+	}
+	attempts = 0
+	status = http.StatusBadRequest
+	if sent, _, err := on.AddBookRaw(context.Background(), raw); err == nil || attempts != 4 || on.CompatVariant() != "" {
+		t.Fatalf("all variants rejected: attempts=%d err=%v sent=%s", attempts, err, sent)
+	}
+}
@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// readarrEnrichTimeout bounds how long building an add payload may spend
+// asking Readarr for profiles, root folders, and author details. Whatever
+// has not arrived by then falls back to the configured defaults, so a slow
+// Readarr delays the add instead of stalling it.
+const readarrEnrichTimeout = 8 * time.Second
+
+// readarrEnrichment is what sanitizeAndEnrichPayload reads from Readarr,
+// fetched up front so the calls run side by side instead of one after the
+// other.
+type readarrEnrichment struct {
+	qualityProfiles map[int]string
+	qualityErr      error
+	rootFolders     []string
+	rootErr         error
+	// foreignAuthorID is the lookup result for the nested author's name,
+	// when the payload has a named author without a foreignAuthorId.
+	foreignAuthorID string
+	// author holds Readarr's details for the author id the payload names,
+	// when it needs them.
+	author map[string]any
+}
+
+// loadEnrichment fetches in parallel everything pmap needs from Readarr.
+func (r *Readarr) loadEnrichment(ctx context.Context, pmap map[string]any) *readarrEnrichment {
+	en := &readarrEnrichment{}
+	var wg sync.WaitGroup
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+	run(func() { en.qualityProfiles, en.qualityErr = r.fetchQualityProfiles(ctx) })
+	run(func() { en.rootFolders, en.rootErr = r.fetchRootFolders(ctx) })
+
+	authorName, authorID := enrichmentAuthor(pmap)
+	if authorName != "" {
+		run(func() { en.foreignAuthorID = r.LookupForeignAuthorIDString(ctx, authorName) })
+	} else if authorID > 0 {
+		run(func() {
+			if details, err := r.GetAuthorByID(ctx, authorID); err == nil {
+				en.author = details
+			}
+		})
+	}
+	wg.Wait()
+	return en
+}
+
+// enrichmentAuthor reports which author lookup pmap needs: by name for a
+// nested author missing its foreignAuthorId, otherwise by the id in the
+// nested author or in authorId.
+func enrichmentAuthor(pmap map[string]any) (string, int) {
+	if am, ok := pmap["author"].(map[string]any); ok {
+		if am["foreignAuthorId"] != nil && am["foreignAuthorId"] != "" {
+			return "", 0
+		}
+		if nm, _ := am["name"].(string); nm != "" {
+			return nm, 0
+		}
+		return "", payloadInt(am["id"])
+	}
+	if pmap["author"] == nil {
+		return "", payloadInt(pmap["authorId"])
+	}
+	return "", 0
+}
+
+// validQualityProfileID picks the quality profile to use from the server's
+// profiles: the configured default when it exists, otherwise any profile.
+func validQualityProfileID(configured int, qps map[int]string, err error) int {
+	if err != nil {
+		return 0
+	}
+	if _, ok := qps[configured]; ok && configured != 0 {
+		return configured
+	}
+	for id := range qps {
+		return id
+	}
+	return 0
+}
+
+// validRootFolderPath picks the root folder to use from the server's
+// folders: override, then the configured default, then the first folder,
+// each only when the server has it.
+func validRootFolderPath(override, configured string, rfs []string, err error) string {
+	if err != nil {
+		return ""
+	}
+	for _, want := range []string{override, configured} {
+		if want == "" {
+			continue
+		}
+		for _, p := range rfs {
+			if p == want {
+				return want
+			}
+		}
+	}
+	if len(rfs) > 0 {
+		return rfs[0]
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeAndEnrichPayloadFetchesInParallel(t *testing.T) {
+	ra := NewReadarrWithDB(ReadarrInstance{BaseURL: "http://readarr", DefaultRootFolderPath: "/books"}, nil)
+	ra.cl.Transport = rtFunc(func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		body := `[]`
+		switch req.URL.Path {
+		case "/api/v1/qualityprofile":
+			body = `[{"id":3,"name":"Any"}]`
+		case "/api/v1/rootfolder":
+			body = `[{"path":"/books"}]`
+		case "/api/v1/author/lookup":
+			body = `[{"name":"Frank Herbert","foreignAuthorId":"fa-1"}]`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	start := time.Now()
+	out := ra.sanitizeAndEnrichPayload(context.Background(), map[string]any{"author": map[string]any{"name": "Frank Herbert"}}, AddOpts{})
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Fatalf("enrichment took %s; the three lookups ran one after another", elapsed)
+	}
+	am, _ := out["author"].(map[string]any)
+	if out["qualityProfileId"] != 3 || out["rootFolderPath"] != "/books" || am["foreignAuthorId"] != "fa-1" {
+		t.Fatalf("payload = %v", out)
+	}
+
+	// A caller's deadline cuts the lookups short and the configured
+	// defaults are used instead.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	out = ra.sanitizeAndEnrichPayload(ctx, map[string]any{"author": map[string]any{"name": "Frank Herbert"}}, AddOpts{})
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("enrichment ignored the caller's deadline: %s", elapsed)
+	}
+	if out["rootFolderPath"] != "/books" {
+		t.Fatalf("payload = %v", out)
+	}
+}
@@ -0,0 +1,104 @@
+package providers
+
+import "strings"
+
+// ReadarrFailure is an actionable explanation of a Readarr error.
+type ReadarrFailure struct {
+	Category string `json:"category"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint"`
+}
+
+// readarrFailureRule matches when the lower-cased error text contains one of
+// Any and, if set, one of Also.
+type readarrFailureRule struct {
+	Any     []string
+	Also    []string
+	Failure ReadarrFailure
+}
+
+// readarrFailureRules are checked in order; the first match wins.
+var readarrFailureRules = []readarrFailureRule{
+	{
+		Any: []string{ErrReadarrUnauthorized.Error(), "http 401", "http 403"},
+		Failure: ReadarrFailure{"unauthorized", "Readarr rejected the API key",
+			"Copy the current API key from Readarr (Settings → General) into Scriptorum's settings."},
+	},
+	{
+		Any: []string{ErrInvalidAddPayload.Error()},
+		Failure: ReadarrFailure{"invalid_payload", "The add request was incomplete and was not sent to Readarr",
+			"The error names the missing fields. Hydrate the request or pick its edition again on the request page, then retry."},
+	},
+	{
+		Any: []string{"out of space", "not enough free space", "no space left"},
+		Failure: ReadarrFailure{"disk_space", "Readarr's root folder is out of disk space",
+			"Free up space on the Readarr host or lower the minimum free space setting, then approve again."},
+	},
+	{
+		Any:  []string{"root folder", "rootfolder"},
+		Also: []string{"does not exist", "not exist", "not found", "missing", "invalid", "must be", "is not", "unable to"},
+		Failure: ReadarrFailure{"root_folder", "Root folder missing on the Readarr server",
+			"Create the folder on the Readarr host, or pick an existing root folder in Scriptorum's settings."},
+	},
+	{
+		Any:  []string{"qualityprofile", "quality profile"},
+		Also: []string{"does not exist", "not exist", "not found", "must be", "invalid", "greater than"},
+		Failure: ReadarrFailure{"quality_profile", "Quality profile deleted or invalid",
+			"Choose a quality profile that still exists in Readarr in Scriptorum's settings."},
+	},
+	{
+		Any:  []string{"metadataprofile", "metadata profile"},
+		Also: []string{"does not exist", "not exist", "not found", "must be", "invalid", "greater than"},
+		Failure: ReadarrFailure{"metadata_profile", "Metadata profile deleted or invalid",
+			"Check that the author's metadata profile still exists in Readarr."},
+	},
+	{
+		Any: []string{"already been added", "already exists", "duplicate", "unique constraint"},
+		Failure: ReadarrFailure{"duplicate", "Duplicate edition: Readarr already has this book",
+			"Find the book in Readarr and mark the request approved, or hydrate it to re-link the existing entry."},
+	},
+	{
+		Any: []string{"nullreferenceexception", "object reference not set"},
+		Failure: ReadarrFailure{"incomplete_metadata", "Readarr crashed on incomplete book metadata",
+			"Hydrate the request or fix its author mapping so the payload carries foreign ids, then retry."},
+	},
+	{
+		Any: []string{"could not be matched", "foreignbookid must not be empty", "no readarr match"},
+		Failure: ReadarrFailure{"no_match", "Readarr could not identify the book",
+			"Hydrate the request, or pick a different edition when re-requesting."},
+	},
+	{
+		Any: []string{"connection refused", "no such host", "deadline exceeded", "timeout"},
+		Failure: ReadarrFailure{"unreachable", "Readarr could not be reached",
+			"Check the Readarr URL in settings and that the server is running."},
+	},
+}
+
+// ClassifyReadarrError maps a raw Readarr error message, including any
+// response body it quotes, to an actionable failure. It returns false for
+// errors it does not recognise.
+func ClassifyReadarrError(text string) (ReadarrFailure, bool) {
+	t := strings.ToLower(text)
+	if strings.TrimSpace(t) == "" {
+		return ReadarrFailure{}, false
+	}
+	for _, rule := range readarrFailureRules {
+		if !containsAny(t, rule.Any) {
+			continue
+		}
+		if len(rule.Also) > 0 && !containsAny(t, rule.Also) {
+			continue
+		}
+		return rule.Failure, true
+	}
+	return ReadarrFailure{}, false
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
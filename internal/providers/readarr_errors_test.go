@@ -0,0 +1,23 @@
+package providers
+
+import "testing"
+
+func TestClassifyReadarrError(t *testing.T) {
+	cases := map[string]string{
+		`add book failed (HTTP 400 Bad Request) from http://r/api/v1/book: [{"propertyName":"RootFolderPath","errorMessage":"Root folder '/books' does not exist"}]`:                                    "root_folder",
+		`add book failed (HTTP 400 Bad Request) from http://r/api/v1/book: [{"propertyName":"QualityProfileId","errorMessage":"'Quality Profile Id' must be greater than '0'."}]`:                       "quality_profile",
+		`add book failed (HTTP 500 Internal Server Error) from http://r/api/v1/book: {"message":"Object reference not set to an instance of an object.","description":"System.NullReferenceException"}`: "incomplete_metadata",
+		`add book failed (HTTP 409 Conflict) from http://r/api/v1/book: {"message":"This edition has already been added"}`:                                                                              "duplicate",
+		`add book failed (HTTP 401 Unauthorized) from http://r/api/v1/book: readarr rejected the API key`:                                                                                               "unauthorized",
+		`request to http://r/api/v1/book failed: dial tcp 10.0.0.2:8787: connect: connection refused`:                                                                                                   "unreachable",
+	}
+	for text, want := range cases {
+		f, ok := ClassifyReadarrError(text)
+		if !ok || f.Category != want || f.Message == "" || f.Hint == "" {
+			t.Errorf("%s: got %+v ok=%v, want %s", text, f, ok, want)
+		}
+	}
+	if f, ok := ClassifyReadarrError("something unexpected happened"); ok {
+		t.Fatalf("unknown error classified as %+v", f)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -305,3 +306,28 @@ func TestReadarrCachingHelpersAndRedaction(t *testing.T) {
 		t.Fatalf("unexpected redacted URL: %s", got)
 	}
 }
+
+func TestReadarrAuthFailuresWrapUnauthorized(t *testing.T) {
+	for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", code)
+		}))
+		ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: srv.URL, APIKey: "secret-key"})
+		err := ra.PingLookup(context.Background())
+		srv.Close()
+		if !errors.Is(err, ErrReadarrUnauthorized) {
+			t.Fatalf("HTTP %d: expected ErrReadarrUnauthorized, got %v", code, err)
+		}
+		if strings.Contains(err.Error(), "secret-key") {
+			t.Fatalf("error leaks API key: %v", err)
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: srv.URL, APIKey: "k"})
+	if err := ra.PingLookup(context.Background()); err == nil || errors.Is(err, ErrReadarrUnauthorized) {
+		t.Fatalf("HTTP 500 should not be an auth failure: %v", err)
+	}
+}
@@ -329,6 +329,7 @@ func TestReadarrAddBookUsesCorrectEndpointAndMethod(t *testing.T) {
 		"title":         "Test Book",
 		"authorTitle":   "Test Author",
 		"foreignBookId": "test-id",
+		"editions":      []any{map[string]any{"foreignEditionId": "test-edition"}},
 		"authorId":      456,
 		"addOptions":    map[string]any{},
 	}
@@ -375,8 +376,8 @@ func TestReadarrAddBookUsesCorrectEndpointAndMethod(t *testing.T) {
 			if !ok {
 				t.Fatalf("Expected addOptions object, got %T", payload["addOptions"])
 			}
-			if monitor, _ := addOptions["monitor"].(string); monitor != "all" {
-				t.Errorf("Expected addOptions.monitor 'all', got %v", addOptions["monitor"])
+			if monitor, _ := addOptions["monitor"].(string); monitor != "none" {
+				t.Errorf("Expected addOptions.monitor 'none' by default, got %v", addOptions["monitor"])
 			}
 			if monitored, ok := addOptions["monitored"].(bool); !ok || !monitored {
 				t.Errorf("Expected addOptions.monitored=true, got %v", addOptions["monitored"])
@@ -425,8 +426,8 @@ func TestReadarrAddBookRawKeepsTopLevelMonitoringEnabled(t *testing.T) {
 	if !ok {
 		t.Fatalf("Expected addOptions object, got %T", payload["addOptions"])
 	}
-	if monitor, _ := addOptions["monitor"].(string); monitor != "all" {
-		t.Fatalf("Expected addOptions.monitor 'all', got %v", addOptions["monitor"])
+	if monitor, _ := addOptions["monitor"].(string); monitor != "none" {
+		t.Fatalf("Expected addOptions.monitor 'none' by default, got %v", addOptions["monitor"])
 	}
 	if monitored, ok := addOptions["monitored"].(bool); !ok || !monitored {
 		t.Fatalf("Expected addOptions.monitored=true, got %v", addOptions["monitored"])
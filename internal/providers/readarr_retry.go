@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readarrMaxRetries is how many times a call Readarr answered with 429 or
+// 503 is retried before the response is handed back to the caller.
+const readarrMaxRetries = 3
+
+// readarrBackoffBase is the first retry delay; each later retry doubles it.
+var readarrBackoffBase = 500 * time.Millisecond
+
+// readarrMaxRetryAfter caps how long a Retry-After header can make us wait.
+const readarrMaxRetryAfter = 30 * time.Second
+
+// readarrRetryTransport retries calls Readarr refused because it was rate
+// limiting (429) or briefly unavailable (503). It honours Retry-After and
+// otherwise backs off exponentially with jitter, and never waits past the
+// request context's deadline: when the next attempt would not fit, the last
+// response is returned as-is so the caller reports Readarr's error.
+type readarrRetryTransport struct {
+	next http.RoundTripper
+}
+
+func (t *readarrRetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := next.RoundTrip(req)
+		if err != nil || !readarrRetryable(resp.StatusCode) || attempt == readarrMaxRetries {
+			return resp, err
+		}
+		// A body that cannot be replayed cannot be retried.
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+		wait := readarrRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if wait < 0 {
+			wait = readarrBackoff(attempt)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, nil
+		}
+		fmt.Printf("readarr: %s %s returned %d; retrying in %s\n", req.Method, req.URL.Path, resp.StatusCode, wait.Round(time.Millisecond))
+		resp.Body.Close()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+func readarrRetryable(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// readarrRetryAfter parses a Retry-After header, given either in seconds or
+// as an HTTP date, into a wait capped at readarrMaxRetryAfter. It returns -1
+// when the header is missing or unreadable.
+func readarrRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return -1
+	}
+	var wait time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return -1
+		}
+		wait = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(v); err == nil {
+		wait = at.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+	} else {
+		return -1
+	}
+	if wait > readarrMaxRetryAfter {
+		wait = readarrMaxRetryAfter
+	}
+	return wait
+}
+
+// readarrBackoff returns the delay before retry attempt+1:
+// base * 2^attempt + random(0, base).
+func readarrBackoff(attempt int) time.Duration {
+	delay := readarrBackoffBase * time.Duration(1<<uint(attempt))
+	jitter := time.Duration(rand.Int63n(int64(readarrBackoffBase) + 1))
+	return delay + jitter
+}
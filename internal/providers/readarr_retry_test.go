@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadarrRetriesRateLimitedCalls(t *testing.T) {
+	orig := readarrBackoffBase
+	readarrBackoffBase = time.Millisecond
+	defer func() { readarrBackoffBase = orig }()
+
+	var calls int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	r := NewReadarrWithDB(ReadarrInstance{BaseURL: srv.URL}, nil)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/book", strings.NewReader(`{"title":"Dune"}`))
+	resp, err := r.cl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("status %d after %d calls", resp.StatusCode, calls)
+	}
+	for _, b := range bodies {
+		if b != `{"title":"Dune"}` {
+			t.Fatalf("retried with body %q", b)
+		}
+	}
+}
+
+func TestReadarrRetryStopsAtDeadline(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	r := NewReadarrWithDB(ReadarrInstance{BaseURL: srv.URL}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/system/status", nil)
+	start := time.Now()
+	resp, err := r.cl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("status %d after %d calls in %s", resp.StatusCode, calls, time.Since(start))
+	}
+}
+
+func TestReadarrRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":     -1,
+		"soon": -1,
+		"-3":   -1,
+		"2":    2 * time.Second,
+		"3600": readarrMaxRetryAfter,
+		now.Add(5 * time.Second).Format(http.TimeFormat): 5 * time.Second,
+		now.Add(-time.Minute).Format(http.TimeFormat):    0,
+	}
+	for in, want := range cases {
+		if got := readarrRetryAfter(in, now); got != want {
+			t.Errorf("readarrRetryAfter(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
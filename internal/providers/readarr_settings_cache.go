@@ -0,0 +1,99 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// readarrSettingsTTL is how long cached quality profiles, root folders,
+// metadata profiles, and tags are trusted before Readarr is asked again.
+// Adding a book reads them several times, so without the cache every
+// approval costs a handful of extra round trips.
+const readarrSettingsTTL = 15 * time.Minute
+
+// Cache types of the instance settings an add payload is built from.
+const (
+	cacheQualityProfiles  = "quality_profiles"
+	cacheRootFolders      = "root_folders"
+	cacheMetadataProfiles = "metadata_profiles"
+	cacheTags             = "tags"
+)
+
+// ReadarrMetadataProfile is one entry from /api/v1/metadataprofile.
+type ReadarrMetadataProfile struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// settingsCacheKey keys a cached settings list by instance, since each
+// Readarr instance has its own profiles and folders.
+func (r *Readarr) settingsCacheKey(kind string) string {
+	return kind + ":" + r.authorCacheScope()
+}
+
+// cachedSettings fills out from the instance's cached kind list, or runs
+// fetch to fill it and caches the result.
+func (r *Readarr) cachedSettings(kind string, out any, fetch func() error) error {
+	if data, ok := r.getCachedData(r.settingsCacheKey(kind), kind); ok && json.Unmarshal([]byte(data), out) == nil {
+		return nil
+	}
+	if err := fetch(); err != nil {
+		return err
+	}
+	if b, err := json.Marshal(out); err == nil {
+		r.setCachedData(r.settingsCacheKey(kind), kind, string(b), readarrSettingsTTL)
+	}
+	return nil
+}
+
+// forgetCachedSettings drops the instance's cached kind lists.
+func (r *Readarr) forgetCachedSettings(kinds ...string) {
+	if r.db == nil {
+		return
+	}
+	for _, kind := range kinds {
+		r.db.Exec(`DELETE FROM readarr_cache WHERE cache_key = ? AND cache_type = ?`, r.settingsCacheKey(kind), kind)
+	}
+}
+
+// MetadataProfiles lists the metadata profiles defined in Readarr.
+func (r *Readarr) MetadataProfiles(ctx context.Context) ([]ReadarrMetadataProfile, error) {
+	var out []ReadarrMetadataProfile
+	err := r.cachedSettings(cacheMetadataProfiles, &out, func() error {
+		return r.getJSON(ctx, "/api/v1/metadataprofile", "metadata profile lookup", &out)
+	})
+	return out, err
+}
+
+// defaultMetadataProfileID is the metadata profile new authors get when a
+// payload names none: Readarr's built-in profile 1, unless the cached
+// profiles show it was deleted, then the first one defined. Only the cache
+// is consulted, so building a payload never waits on this lookup; the
+// startup warm-up fills it.
+func (r *Readarr) defaultMetadataProfileID() int {
+	var profiles []ReadarrMetadataProfile
+	data, ok := r.getCachedData(r.settingsCacheKey(cacheMetadataProfiles), cacheMetadataProfiles)
+	if !ok || json.Unmarshal([]byte(data), &profiles) != nil || len(profiles) == 0 {
+		return 1
+	}
+	for _, p := range profiles {
+		if p.ID == 1 {
+			return 1
+		}
+	}
+	return profiles[0].ID
+}
+
+// WarmCache reloads the quality profiles, root folders, metadata profiles,
+// and tags from Readarr into the cache, so the next add builds its payload
+// without waiting on them. Every list is tried; the errors are joined.
+func (r *Readarr) WarmCache(ctx context.Context) error {
+	r.forgetCachedSettings(cacheQualityProfiles, cacheRootFolders, cacheMetadataProfiles, cacheTags)
+	_, qErr := r.fetchQualityProfiles(ctx)
+	_, rErr := r.fetchRootFolders(ctx)
+	_, mErr := r.MetadataProfiles(ctx)
+	_, tErr := r.Tags(ctx)
+	return errors.Join(qErr, rErr, mErr, tErr)
+}
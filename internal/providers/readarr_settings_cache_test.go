@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadarrWarmCache(t *testing.T) {
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/qualityprofile":
+			_, _ = w.Write([]byte(`[{"id":2,"name":"eBook"}]`))
+		case "/api/v1/rootfolder":
+			_, _ = w.Write([]byte(`[{"path":"/books"}]`))
+		case "/api/v1/metadataprofile":
+			_, _ = w.Write([]byte(`[{"id":4,"name":"Standard"},{"id":5,"name":"None"}]`))
+		case "/api/v1/tag":
+			_, _ = w.Write([]byte(`[{"id":1,"label":"gift"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: srv.URL, APIKey: "k"})
+	if got := ra.defaultMetadataProfileID(); got != 1 {
+		t.Fatalf("cold default metadata profile = %d, want 1", got)
+	}
+	if len(hits) != 0 {
+		t.Fatalf("cold default called Readarr: %v", hits)
+	}
+
+	ctx := context.Background()
+	if err := ra.WarmCache(ctx); err != nil {
+		t.Fatalf("WarmCache: %v", err)
+	}
+	if _, err := ra.fetchQualityProfiles(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if folders, err := ra.fetchRootFolders(ctx); err != nil || len(folders) != 1 || folders[0] != "/books" {
+		t.Fatalf("root folders = %v, %v", folders, err)
+	}
+	if _, err := ra.Tags(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/api/v1/qualityprofile", "/api/v1/rootfolder", "/api/v1/metadataprofile", "/api/v1/tag"} {
+		if hits[path] != 1 {
+			t.Errorf("%s fetched %d times, want once", path, hits[path])
+		}
+	}
+	if got := ra.defaultMetadataProfileID(); got != 4 {
+		t.Fatalf("default metadata profile = %d, want the first defined when 1 is gone", got)
+	}
+
+	// Warming again refreshes instead of trusting the cache.
+	if err := ra.WarmCache(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if hits["/api/v1/tag"] != 2 {
+		t.Fatalf("tags fetched %d times after second warm-up, want 2", hits["/api/v1/tag"])
+	}
+}
@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ReadarrSystemStatus is the subset of /api/v1/system/status shown to admins.
+type ReadarrSystemStatus struct {
+	AppName string `json:"appName"`
+	Version string `json:"version"`
+	Branch  string `json:"branch"`
+}
+
+// ReadarrDiskSpace describes one mount reported by /api/v1/diskspace.
+type ReadarrDiskSpace struct {
+	Path       string `json:"path"`
+	Label      string `json:"label"`
+	FreeSpace  int64  `json:"freeSpace"`
+	TotalSpace int64  `json:"totalSpace"`
+}
+
+// ReadarrRootFolder is a configured root folder with its free space.
+type ReadarrRootFolder struct {
+	Path       string `json:"path"`
+	Accessible bool   `json:"accessible"`
+	FreeSpace  int64  `json:"freeSpace"`
+	TotalSpace int64  `json:"totalSpace"`
+}
+
+// ReadarrHealthCheck is a warning or error from /api/v1/health.
+type ReadarrHealthCheck struct {
+	Source  string `json:"source"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	WikiURL string `json:"wikiUrl"`
+}
+
+// getJSON performs a GET against path and decodes the response into out.
+func (r *Readarr) getJSON(ctx context.Context, path, what string, out any) error {
+	req, u, err := r.newRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.cl.Do(req)
+	if err != nil {
+		return readarrTransportError(u, r.inst.APIKey, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body := readReadarrErrorBody(resp, r.inst.APIKey)
+		return readarrHTTPError(what+" failed", u, r.inst.APIKey, resp, body)
+	}
+	if err := decodeReadarrBody(resp, out, what, r.inst.APIKey); err != nil {
+		return fmt.Errorf("%s: decode response: %w", what, err)
+	}
+	return nil
+}
+
+// SystemStatus returns the Readarr version information.
+func (r *Readarr) SystemStatus(ctx context.Context) (*ReadarrSystemStatus, error) {
+	var st ReadarrSystemStatus
+	if err := r.getJSON(ctx, "/api/v1/system/status", "system status lookup", &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// DiskSpace returns free and total space for each mount Readarr can see.
+func (r *Readarr) DiskSpace(ctx context.Context) ([]ReadarrDiskSpace, error) {
+	var out []ReadarrDiskSpace
+	if err := r.getJSON(ctx, "/api/v1/diskspace", "disk space lookup", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RootFolders returns the configured root folders including free space.
+// Readarr builds that omit space on root folders are filled in from the
+// disk space endpoint using the longest matching mount path.
+func (r *Readarr) RootFolders(ctx context.Context) ([]ReadarrRootFolder, error) {
+	var out []ReadarrRootFolder
+	if err := r.getJSON(ctx, "/api/v1/rootfolder", "root folder lookup", &out); err != nil {
+		return nil, err
+	}
+	missing := false
+	for _, f := range out {
+		missing = missing || f.TotalSpace == 0
+	}
+	if !missing {
+		return out, nil
+	}
+	disks, err := r.DiskSpace(ctx)
+	if err != nil {
+		return out, nil
+	}
+	for i := range out {
+		if out[i].TotalSpace > 0 {
+			continue
+		}
+		if d := mountFor(disks, out[i].Path); d != nil {
+			out[i].FreeSpace, out[i].TotalSpace = d.FreeSpace, d.TotalSpace
+		}
+	}
+	return out, nil
+}
+
+// mountFor returns the disk whose path is the longest prefix of path.
+func mountFor(disks []ReadarrDiskSpace, path string) *ReadarrDiskSpace {
+	var best *ReadarrDiskSpace
+	for i := range disks {
+		p := strings.TrimRight(disks[i].Path, "/")
+		if p != "" && path != p && !strings.HasPrefix(path, p+"/") {
+			continue
+		}
+		if best == nil || len(p) > len(strings.TrimRight(best.Path, "/")) {
+			best = &disks[i]
+		}
+	}
+	return best
+}
+
+// Health returns the active health check warnings and errors.
+func (r *Readarr) Health(ctx context.Context) ([]ReadarrHealthCheck, error) {
+	var out []ReadarrHealthCheck
+	if err := r.getJSON(ctx, "/api/v1/health", "health lookup", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TargetRootFolder picks the root folder new books are added to, using the
+// same preference order as AddBook: the configured default when Readarr still
+// has it, otherwise the first root folder. It returns nil when none exist.
+func TargetRootFolder(folders []ReadarrRootFolder, preferred string) *ReadarrRootFolder {
+	preferred = strings.TrimRight(strings.TrimSpace(preferred), "/")
+	if preferred != "" {
+		for i := range folders {
+			if strings.TrimRight(folders[i].Path, "/") == preferred {
+				return &folders[i]
+			}
+		}
+	}
+	if len(folders) == 0 {
+		return nil
+	}
+	return &folders[0]
+}
@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadarrSystemStatusAndRootFolderSpace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/system/status":
+			_, _ = w.Write([]byte(`{"appName":"Readarr","version":"0.4.1.2648"}`))
+		case "/api/v1/rootfolder":
+			// The second folder omits space, as some Readarr builds do.
+			_, _ = w.Write([]byte(`[{"path":"/books","accessible":true,"freeSpace":100,"totalSpace":1000},{"path":"/media/audio","accessible":true}]`))
+		case "/api/v1/diskspace":
+			_, _ = w.Write([]byte(`[{"path":"/","freeSpace":1,"totalSpace":2},{"path":"/media","freeSpace":50,"totalSpace":500}]`))
+		case "/api/v1/health":
+			_, _ = w.Write([]byte(`[{"source":"IndexerStatusCheck","type":"warning","message":"Indexers unavailable"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: srv.URL, APIKey: "k"})
+	ctx := context.Background()
+
+	st, err := ra.SystemStatus(ctx)
+	if err != nil || st.Version != "0.4.1.2648" {
+		t.Fatalf("status: %+v %v", st, err)
+	}
+	folders, err := ra.RootFolders(ctx)
+	if err != nil || len(folders) != 2 {
+		t.Fatalf("root folders: %+v %v", folders, err)
+	}
+	if folders[0].FreeSpace != 100 {
+		t.Fatalf("reported space should be kept: %+v", folders[0])
+	}
+	if folders[1].FreeSpace != 50 || folders[1].TotalSpace != 500 {
+		t.Fatalf("missing space should come from longest mount: %+v", folders[1])
+	}
+	checks, err := ra.Health(ctx)
+	if err != nil || len(checks) != 1 || checks[0].Type != "warning" {
+		t.Fatalf("health: %+v %v", checks, err)
+	}
+
+	if got := TargetRootFolder(folders, "/media/audio/"); got == nil || got.Path != "/media/audio" {
+		t.Fatalf("expected configured default, got %+v", got)
+	}
+	if got := TargetRootFolder(folders, "/gone"); got == nil || got.Path != "/books" {
+		t.Fatalf("expected first folder fallback, got %+v", got)
+	}
+	if TargetRootFolder(nil, "") != nil {
+		t.Fatalf("expected nil without folders")
+	}
+}
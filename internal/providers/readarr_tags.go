@@ -0,0 +1,107 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ReadarrTag is one entry from /api/v1/tag.
+type ReadarrTag struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+}
+
+// ReadarrTagLabel converts a free-form label into the form Readarr accepts for
+// tags: lower case letters, digits, and single dashes ("Book Club" becomes
+// "book-club"). It returns "" when nothing usable is left.
+func ReadarrTagLabel(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// Tags lists the tags defined in Readarr, from the cache when it is fresh.
+func (r *Readarr) Tags(ctx context.Context) ([]ReadarrTag, error) {
+	var out []ReadarrTag
+	if err := r.cachedSettings(cacheTags, &out, func() error {
+		return r.getJSON(ctx, "/api/v1/tag", "tag lookup", &out)
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EnsureTags returns the Readarr tag ids for labels, creating any tag that
+// does not exist yet. Labels are converted with ReadarrTagLabel; ones that
+// convert to "" are skipped.
+func (r *Readarr) EnsureTags(ctx context.Context, labels []string) ([]int, error) {
+	var want []string
+	seen := map[string]bool{}
+	for _, l := range labels {
+		if l = ReadarrTagLabel(l); l != "" && !seen[l] {
+			seen[l] = true
+			want = append(want, l)
+		}
+	}
+	if len(want) == 0 {
+		return nil, nil
+	}
+	existing, err := r.Tags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byLabel := make(map[string]int, len(existing))
+	for _, t := range existing {
+		byLabel[strings.ToLower(t.Label)] = t.ID
+	}
+	ids := make([]int, 0, len(want))
+	for _, l := range want {
+		id, ok := byLabel[l]
+		if !ok {
+			if id, err = r.createTag(ctx, l); err != nil {
+				return nil, err
+			}
+			r.forgetCachedSettings(cacheTags)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *Readarr) createTag(ctx context.Context, label string) (int, error) {
+	body, _ := json.Marshal(map[string]string{"label": label})
+	req, u, err := r.newJSONRequest(ctx, http.MethodPost, "/api/v1/tag", nil, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	resp, err := r.cl.Do(req)
+	if err != nil {
+		return 0, readarrTransportError(u, r.inst.APIKey, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := readReadarrBody(resp, r.inst.APIKey)
+	if resp.StatusCode >= 400 {
+		return 0, readarrHTTPError("create tag failed", u, r.inst.APIKey, resp, respBody)
+	}
+	var t ReadarrTag
+	if err := json.Unmarshal(respBody, &t); err != nil || t.ID == 0 {
+		return 0, fmt.Errorf("create tag: unexpected response %q", strings.TrimSpace(string(respBody)))
+	}
+	return t.ID, nil
+}
@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadarrTagLabel(t *testing.T) {
+	cases := map[string]string{
+		"Book Club":    "book-club",
+		"  Q4 ":        "q4",
+		"gift!!":       "gift",
+		"sci-fi / YA":  "sci-fi-ya",
+		"---":          "",
+		"Café société": "caf-soci-t",
+	}
+	for in, want := range cases {
+		if got := ReadarrTagLabel(in); got != want {
+			t.Errorf("ReadarrTagLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReadarrEnsureTags(t *testing.T) {
+	var created []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/tag" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodPost {
+			var in ReadarrTag
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			created = append(created, in.Label)
+			_ = json.NewEncoder(w).Encode(ReadarrTag{ID: 10 + len(created), Label: in.Label})
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":3,"label":"gift"}]`))
+	}))
+	defer srv.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: srv.URL, APIKey: "k"})
+
+	ids, err := ra.EnsureTags(context.Background(), []string{"Gift", "Book Club", "book club", "!!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 3 || ids[1] != 11 {
+		t.Fatalf("ids = %v", ids)
+	}
+	if len(created) != 1 || created[0] != "book-club" {
+		t.Fatalf("created = %v", created)
+	}
+
+	if ids, err := ra.EnsureTags(context.Background(), nil); err != nil || ids != nil {
+		t.Fatalf("empty labels: %v %v", ids, err)
+	}
+}
@@ -0,0 +1,119 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidAddPayload wraps the problems ValidateAddPayload finds.
+var ErrInvalidAddPayload = errors.New("invalid add payload")
+
+// ValidateAddPayload checks a final add payload against the parts of
+// Readarr's book schema that it does not validate itself: missing foreign
+// ids, an empty edition list, or an author it cannot resolve make Readarr
+// fail with a NullReferenceException instead of a useful message. Every
+// problem is listed in the returned error.
+func ValidateAddPayload(payload []byte) error {
+	var pmap map[string]any
+	if err := json.Unmarshal(payload, &pmap); err != nil || pmap == nil {
+		return fmt.Errorf("%w: not a JSON object", ErrInvalidAddPayload)
+	}
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	requireString := func(m map[string]any, key, where string) {
+		switch v := m[key].(type) {
+		case nil:
+			add("%s%s is missing", where, key)
+		case string:
+			if strings.TrimSpace(v) == "" {
+				add("%s%s is empty", where, key)
+			}
+		default:
+			add("%s%s must be a string", where, key)
+		}
+	}
+	requireString(pmap, "title", "")
+	requireString(pmap, "foreignBookId", "")
+
+	switch eds := pmap["editions"].(type) {
+	case nil:
+		add("editions is missing")
+	case []any:
+		if len(eds) == 0 {
+			add("editions is empty")
+		}
+		for i, e := range eds {
+			em, ok := e.(map[string]any)
+			if !ok {
+				add("editions[%d] must be an object", i)
+				continue
+			}
+			requireString(em, "foreignEditionId", fmt.Sprintf("editions[%d].", i))
+		}
+	default:
+		add("editions must be a list")
+	}
+
+	switch am := pmap["author"].(type) {
+	case nil:
+		if payloadInt(pmap["authorId"]) <= 0 {
+			add("author is missing and authorId is not set")
+		}
+	case map[string]any:
+		fid, _ := am["foreignAuthorId"].(string)
+		if strings.TrimSpace(fid) == "" && payloadInt(am["id"]) <= 0 {
+			add("author has neither foreignAuthorId nor id")
+		}
+	default:
+		add("author must be an object")
+	}
+
+	for _, key := range []string{"qualityProfileId", "metadataProfileId", "authorId"} {
+		if v, ok := pmap[key]; ok && v != nil {
+			if _, isNum := v.(float64); !isNum {
+				add("%s must be a number", key)
+			}
+		}
+	}
+	if v, ok := pmap["rootFolderPath"]; ok && v != nil {
+		if _, isStr := v.(string); !isStr {
+			add("rootFolderPath must be a string")
+		}
+	}
+	if v, ok := pmap["monitored"]; ok && v != nil {
+		if _, isBool := v.(bool); !isBool {
+			add("monitored must be true or false")
+		}
+	}
+	if v, ok := pmap["addOptions"]; ok && v != nil {
+		if _, isObj := v.(map[string]any); !isObj {
+			add("addOptions must be an object")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidAddPayload, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// payloadInt reads a decoded JSON number, or a numeric string, as an int.
+func payloadInt(v any) int {
+	switch t := v.(type) {
+	case float64:
+		return int(t)
+	case int:
+		return t
+	case string:
+		var n int
+		if _, err := fmt.Sscan(strings.TrimSpace(t), &n); err == nil {
+			return n
+		}
+	}
+	return 0
+}
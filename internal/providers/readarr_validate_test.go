@@ -0,0 +1,63 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateAddPayload(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		want    []string
+	}{
+		{"valid", `{"title":"T","foreignBookId":"fb","editions":[{"foreignEditionId":"fe"}],"author":{"foreignAuthorId":"fa"},"qualityProfileId":1,"monitored":true}`, nil},
+		{"author by id", `{"title":"T","foreignBookId":"fb","editions":[{"foreignEditionId":"fe"}],"authorId":7}`, nil},
+		{"not an object", `[1]`, []string{"not a JSON object"}},
+		{"missing ids", `{"title":" ","editions":[],"author":{"name":"A"}}`, []string{"title is empty", "foreignBookId is missing", "editions is empty", "author has neither foreignAuthorId nor id"}},
+		{"bad edition", `{"title":"T","foreignBookId":"fb","editions":[{"foreignEditionId":""},"x"],"authorId":7}`, []string{"editions[0].foreignEditionId is empty", "editions[1] must be an object"}},
+		{"no author", `{"title":"T","foreignBookId":"fb","editions":[{"foreignEditionId":"fe"}]}`, []string{"author is missing and authorId is not set"}},
+		{"wrong types", `{"title":"T","foreignBookId":5,"editions":[{"foreignEditionId":"fe"}],"authorId":7,"qualityProfileId":"1","rootFolderPath":3,"monitored":"yes","addOptions":[]}`, []string{"foreignBookId must be a string", "qualityProfileId must be a number", "rootFolderPath must be a string", "monitored must be true or false", "addOptions must be an object"}},
+	}
+	for _, c := range cases {
+		err := ValidateAddPayload([]byte(c.payload))
+		if len(c.want) == 0 {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", c.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidAddPayload) {
+			t.Fatalf("%s: got %v", c.name, err)
+		}
+		for _, w := range c.want {
+			if !strings.Contains(err.Error(), w) {
+				t.Fatalf("%s: %q missing from %v", c.name, w, err)
+			}
+		}
+	}
+}
+
+func TestAddBookRawRejectsInvalidPayloadWithoutSending(t *testing.T) {
+	posts := 0
+	readarr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer readarr.Close()
+	ra, _ := newReadarrWithTempDB(t, ReadarrInstance{BaseURL: readarr.URL, APIKey: "k", DefaultQualityProfileID: 1, DefaultRootFolderPath: "/books"})
+
+	_, _, err := ra.AddBookRaw(context.Background(), []byte(`{"title":"T","foreignBookId":"fb","author":{"name":"A","foreignAuthorId":"fa"}}`))
+	if !errors.Is(err, ErrInvalidAddPayload) || !strings.Contains(err.Error(), "editions is empty") || posts != 0 {
+		t.Fatalf("err=%v posts=%d", err, posts)
+	}
+	if f, ok := ClassifyReadarrError(err.Error()); !ok || f.Category != "invalid_payload" {
+		t.Fatalf("classified as %+v", f)
+	}
+}
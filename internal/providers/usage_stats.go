@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// usageDay is the UTC day the cache_stats and provider_calls counters are
+// kept under.
+func usageDay() string { return time.Now().UTC().Format("2006-01-02") }
+
+// countCacheLookup adds a hit or a miss on cacheType to today's cache stats,
+// so operators can see which cache TTLs pay off.
+func countCacheLookup(db *sql.DB, cacheType string, hit bool) {
+	if db == nil {
+		return
+	}
+	col := "misses"
+	if hit {
+		col = "hits"
+	}
+	db.Exec(`
+		INSERT INTO cache_stats (day, cache_type, `+col+`) VALUES (?, ?, 1)
+		ON CONFLICT(day, cache_type) DO UPDATE SET `+col+` = `+col+` + 1
+	`, usageDay(), cacheType)
+}
+
+// ReadarrUsageName is the provider name a Readarr instance's calls are
+// counted under.
+func ReadarrUsageName(i ReadarrInstance) string {
+	return "readarr:" + AuthorCacheScope(i)
+}
+
+// usageTransport counts every request it sends, retries included, against
+// provider in today's call volume.
+type usageTransport struct {
+	db       *sql.DB
+	provider string
+	next     http.RoundTripper
+}
+
+func (t *usageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.db.Exec(`
+		INSERT INTO provider_calls (day, provider, calls) VALUES (?, ?, 1)
+		ON CONFLICT(day, provider) DO UPDATE SET calls = calls + 1
+	`, usageDay(), t.provider)
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
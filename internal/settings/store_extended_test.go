@@ -14,9 +14,7 @@ func TestStoreInitialization(t *testing.T) {
 	configPath := filepath.Join(tmpDir, "test_config.yaml")
 
 	cfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 	}
 
 	store := New(configPath, cfg)
@@ -42,18 +40,14 @@ func TestUpdateConfiguration(t *testing.T) {
 	configPath := filepath.Join(tmpDir, "test_config.yaml")
 
 	initialCfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 	}
 
 	store := New(configPath, initialCfg)
 
 	// Update configuration
 	newCfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":9090"},
+		HTTP: config.HTTPConfig{Listen: ":9090"},
 	}
 
 	err := store.Update(newCfg)
@@ -79,9 +73,7 @@ func TestConcurrentAccess(t *testing.T) {
 	configPath := filepath.Join(tmpDir, "concurrent_config.yaml")
 
 	cfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 	}
 
 	store := New(configPath, cfg)
@@ -104,9 +96,7 @@ func TestConcurrentAccess(t *testing.T) {
 			// Each goroutine updates configuration with different port
 			port := ":800" + string(rune('0'+id))
 			newCfg := &config.Config{
-				HTTP: struct {
-					Listen string `yaml:"listen"`
-				}{Listen: port},
+				HTTP: config.HTTPConfig{Listen: port},
 			}
 
 			err := store.Update(newCfg)
@@ -140,9 +130,7 @@ func TestFileOperations(t *testing.T) {
 	configPath := filepath.Join(tmpDir, "file_ops_config.yaml")
 
 	cfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 		DB: struct {
 			Path string `yaml:"path"`
 		}{Path: "/path/to/db"},
@@ -179,9 +167,7 @@ func TestErrorHandling(t *testing.T) {
 	invalidPath := "/invalid/path/that/cannot/be/created/config.yaml"
 
 	cfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 	}
 
 	store := New(invalidPath, cfg)
@@ -205,9 +191,7 @@ func TestGetThreadSafety(t *testing.T) {
 	configPath := filepath.Join(tmpDir, "thread_safe_config.yaml")
 
 	cfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 	}
 
 	store := New(configPath, cfg)
@@ -246,9 +230,7 @@ func (e *testError) Error() string {
 // Benchmark store operations
 func BenchmarkStoreGet(b *testing.B) {
 	cfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 	}
 
 	store := New("/tmp/bench_config.yaml", cfg)
@@ -264,9 +246,7 @@ func BenchmarkStoreUpdate(b *testing.B) {
 	configPath := filepath.Join(tmpDir, "bench_config.yaml")
 
 	cfg := &config.Config{
-		HTTP: struct {
-			Listen string `yaml:"listen"`
-		}{Listen: ":8080"},
+		HTTP: config.HTTPConfig{Listen: ":8080"},
 	}
 
 	store := New(configPath, cfg)
@@ -275,9 +255,7 @@ func BenchmarkStoreUpdate(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		port := ":800" + string(rune('0'+(i%10)))
 		newCfg := &config.Config{
-			HTTP: struct {
-				Listen string `yaml:"listen"`
-			}{Listen: port},
+			HTTP: config.HTTPConfig{Listen: port},
 		}
 		store.Update(newCfg)
 	}